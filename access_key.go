@@ -0,0 +1,9 @@
+package buxclient
+
+import (
+	"github.com/BuxOrg/go-buxclient/utils"
+)
+
+// ErrInvalidAccessKey is when the access key is not a private key in the WIF or the hex format (see
+// utils.ParseAccessKey)
+var ErrInvalidAccessKey = utils.ErrInvalidAccessKey
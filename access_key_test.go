@@ -0,0 +1,80 @@
+package buxclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseAccessKey will test the method utils.ParseAccessKey()
+func TestParseAccessKey(t *testing.T) {
+	wifKey, err := bitcoin.PrivateKeyToWif(accessKeyString)
+	require.NoError(t, err)
+
+	t.Run("valid access keys", func(t *testing.T) {
+		for _, accessKey := range []string{
+			accessKeyString,
+			strings.ToUpper(accessKeyString),
+			" " + accessKeyString + "\n",
+			wifKey.String(),
+		} {
+			privateKey, err := utils.ParseAccessKey(accessKey)
+			require.NoError(t, err, accessKey)
+			assert.Equal(t, accessKeyString, hex.EncodeToString(privateKey.Serialise()))
+			assert.True(t, utils.IsValidAccessKey(accessKey))
+		}
+	})
+
+	t.Run("invalid access keys", func(t *testing.T) {
+		wifString := wifKey.String()
+		tests := map[string]string{
+			"empty":         "",
+			"short hex":     accessKeyString[:62],
+			"zero":          strings.Repeat("0", 64),
+			"out of range":  strings.Repeat("f", 64),
+			"wif checksum":  wifString[:len(wifString)-1] + string("12"[wifString[len(wifString)-1]%2]),
+			"not a key":     "not an access key",
+			"xPriv instead": xPrivString,
+		}
+		for name, accessKey := range tests {
+			_, err := utils.ParseAccessKey(accessKey)
+			assert.ErrorIs(t, err, ErrInvalidAccessKey, name)
+			assert.False(t, utils.IsValidAccessKey(accessKey), name)
+		}
+	})
+
+	t.Run("new client", func(t *testing.T) {
+		_, err := New(WithAccessKey(accessKeyString[:62]), WithHTTP(serverURL))
+		assert.ErrorIs(t, err, ErrInvalidAccessKey)
+	})
+}
+
+// TestAccessKeyID will test the methods utils.AccessKeyPublicKey() and utils.AccessKeyID()
+func TestAccessKeyID(t *testing.T) {
+	privateKey, err := bitcoin.PrivateKeyFromString(accessKeyString)
+	require.NoError(t, err)
+	publicKey := hex.EncodeToString(privateKey.PubKey().SerialiseCompressed())
+	hash := sha256.Sum256([]byte(publicKey))
+	wifKey, err := bitcoin.PrivateKeyToWif(accessKeyString)
+	require.NoError(t, err)
+
+	for _, accessKey := range []string{accessKeyString, wifKey.String()} {
+		key, err := utils.AccessKeyPublicKey(accessKey)
+		require.NoError(t, err)
+		assert.Equal(t, publicKey, key)
+
+		id, err := utils.AccessKeyID(accessKey)
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(hash[:]), id)
+	}
+	assert.Equal(t, hex.EncodeToString(hash[:]), utils.PrivateKeyAccessKeyID(privateKey))
+
+	_, err = utils.AccessKeyID("invalid")
+	assert.ErrorIs(t, err, ErrInvalidAccessKey)
+}
@@ -0,0 +1,107 @@
+package amounts
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// MaxSatoshis is the maximum amount in satoshis (21 million BSV)
+const MaxSatoshis = 21_000_000 * SatoshisPerBSV
+
+// bsvDecimals is the number of decimal places of a BSV amount (1 satoshi = 0.00000001 BSV)
+const bsvDecimals = 8
+
+// ErrInvalidAmount is when an amount is not a positive decimal number (ex: "0.001")
+var ErrInvalidAmount = errors.New("invalid amount, expected a positive decimal number")
+
+// ErrTooManyDecimals is when a BSV amount is more precise than a satoshi
+var ErrTooManyDecimals = errors.New("the amount has more than 8 decimal places")
+
+// ErrAmountTooLarge is when an amount exceeds the maximum amount in satoshis (21 million BSV)
+var ErrAmountTooLarge = errors.New("the amount exceeds 21 million BSV")
+
+// ParseDecimal will parse a decimal string (ex: "1234.56") as an exact rational number, without going
+// through a float. Only digits and an optional decimal point are accepted: no sign, exponent or
+// group separator.
+func ParseDecimal(amount string) (*big.Rat, error) {
+	amount = strings.TrimSpace(amount)
+	integer, fraction := amount, ""
+	if index := strings.Index(amount, "."); index >= 0 {
+		integer, fraction = amount[:index], amount[index+1:]
+	}
+	if integer+fraction == "" || !isDigits(integer) || !isDigits(fraction) {
+		return nil, ErrInvalidAmount
+	}
+
+	value, ok := new(big.Rat).SetString(integer + "." + fraction + "0")
+	if !ok {
+		return nil, ErrInvalidAmount
+	}
+	return value, nil
+}
+
+// ParseBSV will convert a BSV decimal string (ex: "0.001") to satoshis, it returns ErrTooManyDecimals
+// when the amount is more precise than a satoshi instead of rounding it
+func ParseBSV(amount string) (uint64, error) {
+	value, err := ParseDecimal(amount)
+	if err != nil {
+		return 0, err
+	}
+	satoshis := value.Mul(value, new(big.Rat).SetInt64(SatoshisPerBSV))
+	if !satoshis.IsInt() {
+		return 0, ErrTooManyDecimals
+	}
+	return toSatoshis(satoshis.Num())
+}
+
+// SatoshisToBSV will convert satoshis to a BSV decimal string, without trailing zeros (ex: 100000 is
+// "0.001"), ParseBSV converts it back
+func SatoshisToBSV(satoshis uint64) string {
+	digits := new(big.Int).SetUint64(satoshis).String()
+	if len(digits) <= bsvDecimals {
+		digits = strings.Repeat("0", bsvDecimals-len(digits)+1) + digits
+	}
+	integer, fraction := digits[:len(digits)-bsvDecimals], strings.TrimRight(digits[len(digits)-bsvDecimals:], "0")
+	if fraction == "" {
+		return integer
+	}
+	return integer + "." + fraction
+}
+
+// SatoshisToFiat will convert satoshis to an exact fiat amount, given the price of 1 BSV in the currency
+func SatoshisToFiat(satoshis uint64, rate *big.Rat) *big.Rat {
+	amount := new(big.Rat).SetFrac(new(big.Int).SetUint64(satoshis), big.NewInt(SatoshisPerBSV))
+	return amount.Mul(amount, rate)
+}
+
+// FiatToSatoshis will convert a fiat amount to satoshis, given the price of 1 BSV in the currency. The
+// satoshis are rounded with the rounding mode (ex: RoundUp so the receiver gets at least the amount).
+func FiatToSatoshis(amount, rate *big.Rat, rounding RoundingMode) (uint64, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return 0, ErrInvalidAmount
+	}
+	if rate == nil || rate.Sign() <= 0 {
+		return 0, ErrRateNotFound
+	}
+	satoshis := new(big.Rat).Mul(amount, new(big.Rat).SetInt64(SatoshisPerBSV))
+	return toSatoshis(roundRat(satoshis.Quo(satoshis, rate), rounding))
+}
+
+// toSatoshis will return the integer as satoshis, ErrAmountTooLarge if it exceeds MaxSatoshis
+func toSatoshis(value *big.Int) (uint64, error) {
+	if !value.IsUint64() || value.Uint64() > MaxSatoshis {
+		return 0, ErrAmountTooLarge
+	}
+	return value.Uint64(), nil
+}
+
+// isDigits will return whether the string only contains the digits 0-9 (true when empty)
+func isDigits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package amounts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseBSV will test the method ParseBSV()
+func TestParseBSV(t *testing.T) {
+	tests := map[string]uint64{
+		"1":           100000000,
+		"0.001":       100000,
+		"0.00000001":  1,
+		".5":          50000000,
+		"2.":          200000000,
+		" 0.1 ":       10000000,
+		"0.29":        29000000, // 0.29 * 1e8 is 28999999.999999996 as a float
+		"21000000":    MaxSatoshis,
+		"20999999.99": MaxSatoshis - 1000000,
+	}
+	for amount, expected := range tests {
+		satoshis, err := ParseBSV(amount)
+		require.NoError(t, err, amount)
+		assert.Equal(t, expected, satoshis, amount)
+	}
+
+	for _, amount := range []string{"", ".", "-1", "1e3", "1,000", "0x10", "1.2.3", "abc"} {
+		_, err := ParseBSV(amount)
+		assert.ErrorIs(t, err, ErrInvalidAmount, amount)
+	}
+	_, err := ParseBSV("0.000000001")
+	assert.ErrorIs(t, err, ErrTooManyDecimals)
+	_, err = ParseBSV("21000000.00000001")
+	assert.ErrorIs(t, err, ErrAmountTooLarge)
+	_, err = ParseBSV("999999999999999999999999")
+	assert.ErrorIs(t, err, ErrAmountTooLarge)
+}
+
+// TestSatoshisToBSV will test the method SatoshisToBSV()
+func TestSatoshisToBSV(t *testing.T) {
+	tests := map[uint64]string{
+		0:           "0",
+		1:           "0.00000001",
+		100000:      "0.001",
+		150000000:   "1.5",
+		MaxSatoshis: "21000000",
+	}
+	for satoshis, expected := range tests {
+		assert.Equal(t, expected, SatoshisToBSV(satoshis))
+
+		parsed, err := ParseBSV(expected)
+		require.NoError(t, err)
+		assert.Equal(t, satoshis, parsed)
+	}
+}
+
+// TestFiat will test the methods SatoshisToFiat() and FiatToSatoshis()
+func TestFiat(t *testing.T) {
+	rate := big.NewRat(5025, 100) // 1 BSV = 50.25
+
+	assert.Equal(t, big.NewRat(201, 2), SatoshisToFiat(200000000, rate))
+
+	amount, err := ParseDecimal("0.10")
+	require.NoError(t, err)
+	satoshis, err := FiatToSatoshis(amount, rate, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(199004), satoshis) // 199004.975...
+	satoshis, err = FiatToSatoshis(amount, rate, RoundUp)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(199005), satoshis)
+
+	_, err = FiatToSatoshis(amount, nil, RoundDown)
+	assert.ErrorIs(t, err, ErrRateNotFound)
+	_, err = FiatToSatoshis(big.NewRat(-1, 1), rate, RoundDown)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	_, err = FiatToSatoshis(big.NewRat(1e12, 1), rate, RoundDown)
+	assert.ErrorIs(t, err, ErrAmountTooLarge)
+}
@@ -0,0 +1,81 @@
+// Package amounts contains helpers for working with satoshi amounts: exact conversions between
+// satoshis, BSV decimal strings and fiat amounts, and locale-aware display in BSV and fiat currencies
+package amounts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// SatoshisPerBSV is the number of satoshis in one BSV
+const SatoshisPerBSV = 100000000
+
+// ErrRateNotFound is when the rate provider has no rate for the requested currency
+var ErrRateNotFound = errors.New("exchange rate not found for currency")
+
+// Currency describes how an amount in a given currency is displayed
+type Currency struct {
+	Code     string // ISO 4217 code (or BSV)
+	Symbol   string // Display symbol, empty to display the code instead
+	Decimals int    // Number of decimal places to display
+}
+
+// Known currencies
+var (
+	// BSV is displayed with all 8 decimal places
+	BSV = Currency{Code: "BSV", Symbol: "", Decimals: 8}
+
+	// Satoshis displays the raw satoshi amount
+	Satoshis = Currency{Code: "sat", Symbol: "", Decimals: 0}
+
+	// USD United States dollar
+	USD = Currency{Code: "USD", Symbol: "$", Decimals: 2}
+
+	// EUR Euro
+	EUR = Currency{Code: "EUR", Symbol: "€", Decimals: 2}
+
+	// GBP Pound sterling
+	GBP = Currency{Code: "GBP", Symbol: "£", Decimals: 2}
+
+	// JPY Japanese yen
+	JPY = Currency{Code: "JPY", Symbol: "¥", Decimals: 0}
+)
+
+var currencies = map[string]Currency{
+	BSV.Code:      BSV,
+	Satoshis.Code: Satoshis,
+	USD.Code:      USD,
+	EUR.Code:      EUR,
+	GBP.Code:      GBP,
+	JPY.Code:      JPY,
+}
+
+// GetCurrency will return a known currency by its code, falling back to a
+// symbol-less currency with 2 decimal places
+func GetCurrency(code string) Currency {
+	if currency, ok := currencies[strings.ToUpper(code)]; ok {
+		return currency
+	}
+	if currency, ok := currencies[code]; ok {
+		return currency
+	}
+	return Currency{Code: strings.ToUpper(code), Decimals: 2}
+}
+
+// RateProvider returns the price of 1 BSV in the given fiat currency
+type RateProvider interface {
+	Rate(ctx context.Context, currencyCode string) (*big.Rat, error)
+}
+
+// StaticRates is a RateProvider using a fixed set of rates, keyed by currency code
+type StaticRates map[string]*big.Rat
+
+// Rate will return the rate for the given currency
+func (s StaticRates) Rate(_ context.Context, currencyCode string) (*big.Rat, error) {
+	if rate, ok := s[strings.ToUpper(currencyCode)]; ok && rate != nil {
+		return rate, nil
+	}
+	return nil, ErrRateNotFound
+}
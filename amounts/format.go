@@ -0,0 +1,253 @@
+package amounts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidDecimals is when the number of decimals of the format options is negative
+var ErrInvalidDecimals = errors.New("the number of decimals must not be negative")
+
+// RoundingMode is how amounts are rounded to the displayed number of decimals
+type RoundingMode int
+
+// Supported rounding modes
+const (
+	// RoundHalfUp rounds half away from zero (1.005 -> 1.01)
+	RoundHalfUp RoundingMode = iota
+
+	// RoundHalfEven rounds half to the nearest even digit (bankers rounding)
+	RoundHalfEven
+
+	// RoundDown truncates towards zero
+	RoundDown
+
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+// Locale describes the number formatting conventions of a locale
+type Locale struct {
+	DecimalSeparator string
+	GroupSeparator   string
+	SymbolAfter      bool // Place the symbol (or code) after the number
+	SymbolSpace      bool // Put a space between the number and the symbol
+}
+
+// Known locales
+var (
+	// LocaleEnUS formats as $1,234.56
+	LocaleEnUS = Locale{DecimalSeparator: ".", GroupSeparator: ","}
+
+	// LocaleEnGB formats as £1,234.56
+	LocaleEnGB = Locale{DecimalSeparator: ".", GroupSeparator: ","}
+
+	// LocaleDeDE formats as 1.234,56 €
+	LocaleDeDE = Locale{DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true}
+
+	// LocaleFrFR formats as 1 234,56 €
+	LocaleFrFR = Locale{DecimalSeparator: ",", GroupSeparator: "\u00a0", SymbolAfter: true, SymbolSpace: true}
+
+	// LocaleJaJP formats as ¥1,235
+	LocaleJaJP = Locale{DecimalSeparator: ".", GroupSeparator: ","}
+)
+
+var locales = map[string]Locale{
+	"en":    LocaleEnUS,
+	"en-us": LocaleEnUS,
+	"en-gb": LocaleEnGB,
+	"de":    LocaleDeDE,
+	"de-de": LocaleDeDE,
+	"fr":    LocaleFrFR,
+	"fr-fr": LocaleFrFR,
+	"ja":    LocaleJaJP,
+	"ja-jp": LocaleJaJP,
+}
+
+// GetLocale will return the locale for the given language tag (en-US, de_DE, fr...),
+// falling back to the language only and then to en-US
+func GetLocale(tag string) Locale {
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if locale, ok := locales[tag]; ok {
+		return locale
+	}
+	if index := strings.Index(tag, "-"); index > 0 {
+		if locale, ok := locales[tag[:index]]; ok {
+			return locale
+		}
+	}
+	return LocaleEnUS
+}
+
+// FormatOptions are the options used when formatting an amount
+type FormatOptions struct {
+	Locale   Locale
+	Rounding RoundingMode
+	Decimals *int // Overrides the currency decimals when set, must not be negative (see Validate)
+}
+
+// Validate will return ErrInvalidDecimals if the decimals override is negative
+func (o FormatOptions) Validate() error {
+	if o.Decimals != nil && *o.Decimals < 0 {
+		return ErrInvalidDecimals
+	}
+	return nil
+}
+
+// Format will format the amount (in whole units of the currency) for display, a negative decimals
+// override is ignored (see FormatOptions.Validate)
+func Format(amount *big.Rat, currency Currency, opts FormatOptions) string {
+	decimals := currency.Decimals
+	if opts.Decimals != nil && *opts.Decimals >= 0 {
+		decimals = *opts.Decimals
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := roundRat(new(big.Rat).Mul(amount, new(big.Rat).SetInt(scale)), opts.Rounding)
+
+	negative := scaled.Sign() < 0
+	digits := new(big.Int).Abs(scaled).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	number := groupDigits(digits[:len(digits)-decimals], opts.Locale.GroupSeparator)
+	if decimals > 0 {
+		number += opts.Locale.DecimalSeparator + digits[len(digits)-decimals:]
+	}
+
+	return addSymbol(number, negative, currency, opts.Locale)
+}
+
+// FormatSatoshis will format a satoshi amount in BSV
+func FormatSatoshis(satoshis uint64, opts FormatOptions) string {
+	return Format(new(big.Rat).SetFrac(
+		new(big.Int).SetUint64(satoshis), big.NewInt(SatoshisPerBSV),
+	), BSV, opts)
+}
+
+// FormatFiat will format a satoshi amount in fiat, given the price of 1 BSV in that currency
+func FormatFiat(satoshis uint64, rate *big.Rat, currency Currency, opts FormatOptions) string {
+	return Format(SatoshisToFiat(satoshis, rate), currency, opts)
+}
+
+// Formatter formats satoshi amounts using rates from a RateProvider
+type Formatter struct {
+	Options FormatOptions
+	Rates   RateProvider
+}
+
+// NewFormatter will create a new formatter for the given locale tag (en-US, de-DE...)
+func NewFormatter(localeTag string, rates RateProvider) *Formatter {
+	return &Formatter{
+		Options: FormatOptions{Locale: GetLocale(localeTag)},
+		Rates:   rates,
+	}
+}
+
+// Format will format the satoshis in the given currency code (BSV, sat, USD...)
+func (f *Formatter) Format(ctx context.Context, satoshis uint64, currencyCode string) (string, error) {
+	if err := f.Options.Validate(); err != nil {
+		return "", err
+	}
+	currency := GetCurrency(currencyCode)
+	switch currency.Code {
+	case BSV.Code:
+		return FormatSatoshis(satoshis, f.Options), nil
+	case Satoshis.Code:
+		return Format(new(big.Rat).SetInt(new(big.Int).SetUint64(satoshis)), Satoshis, f.Options), nil
+	}
+
+	if f.Rates == nil {
+		return "", ErrRateNotFound
+	}
+	rate, err := f.Rates.Rate(ctx, currency.Code)
+	if err != nil {
+		return "", err
+	}
+	return FormatFiat(satoshis, rate, currency, f.Options), nil
+}
+
+// roundRat will round the rational number to an integer using the rounding mode
+func roundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	away := false
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		// compare 2*|remainder| with the denominator
+		twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+		switch twice.Cmp(r.Denom()) {
+		case 1:
+			away = true
+		case 0:
+			away = mode == RoundHalfUp || quotient.Bit(0) == 1
+		}
+	}
+
+	if away {
+		if r.Sign() < 0 {
+			return quotient.Sub(quotient, big.NewInt(1))
+		}
+		return quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// groupDigits will insert the group separator every 3 digits
+func groupDigits(digits, separator string) string {
+	if separator == "" || len(digits) <= 3 {
+		return digits
+	}
+	var builder strings.Builder
+	first := len(digits) % 3
+	if first > 0 {
+		builder.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if builder.Len() > 0 {
+			builder.WriteString(separator)
+		}
+		builder.WriteString(digits[i : i+3])
+	}
+	return builder.String()
+}
+
+// addSymbol will add the currency symbol (or code) and the sign to the number
+func addSymbol(number string, negative bool, currency Currency, locale Locale) string {
+	symbol := currency.Symbol
+	after := locale.SymbolAfter
+	space := locale.SymbolSpace
+	if symbol == "" {
+		// codes are always displayed after the number
+		symbol = currency.Code
+		after = true
+		space = true
+	}
+
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	separator := ""
+	if space {
+		separator = " "
+	}
+	if after {
+		return sign + number + separator + symbol
+	}
+	return sign + symbol + separator + number
+}
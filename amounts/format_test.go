@@ -0,0 +1,118 @@
+package amounts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFormat will test the method Format()
+func TestFormat(t *testing.T) {
+	t.Run("en-US", func(t *testing.T) {
+		amount := big.NewRat(123456789, 100)
+		assert.Equal(t, "$1,234,567.89", Format(amount, USD, FormatOptions{Locale: LocaleEnUS}))
+	})
+
+	t.Run("de-DE", func(t *testing.T) {
+		amount := big.NewRat(123456, 100)
+		assert.Equal(t, "1.234,56 €", Format(amount, EUR, FormatOptions{Locale: LocaleDeDE}))
+	})
+
+	t.Run("fr-FR", func(t *testing.T) {
+		amount := big.NewRat(123456, 100)
+		assert.Equal(t, "1\u00a0234,56 €", Format(amount, EUR, FormatOptions{Locale: LocaleFrFR}))
+	})
+
+	t.Run("small amount", func(t *testing.T) {
+		amount := big.NewRat(5, 100)
+		assert.Equal(t, "$0.05", Format(amount, USD, FormatOptions{Locale: LocaleEnUS}))
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		amount := big.NewRat(-15, 10)
+		assert.Equal(t, "-$1.50", Format(amount, USD, FormatOptions{Locale: LocaleEnUS}))
+	})
+
+	t.Run("decimals override", func(t *testing.T) {
+		decimals := 0
+		amount := big.NewRat(15, 10)
+		assert.Equal(t, "$2", Format(amount, USD, FormatOptions{Locale: LocaleEnUS, Decimals: &decimals}))
+	})
+
+	t.Run("negative decimals", func(t *testing.T) {
+		decimals := -1
+		opts := FormatOptions{Locale: LocaleEnUS, Decimals: &decimals}
+		assert.ErrorIs(t, opts.Validate(), ErrInvalidDecimals)
+		assert.Equal(t, "$1.50", Format(big.NewRat(15, 10), USD, opts))
+		assert.Equal(t, "$2", Format(big.NewRat(15, 10), Currency{Code: "USD", Symbol: "$", Decimals: -2}, FormatOptions{}))
+	})
+}
+
+// TestRounding will test the rounding modes
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		amount   *big.Rat
+		mode     RoundingMode
+		expected string
+	}{
+		{big.NewRat(1005, 1000), RoundHalfUp, "$1.01"},
+		{big.NewRat(1005, 1000), RoundHalfEven, "$1.00"},
+		{big.NewRat(1015, 1000), RoundHalfEven, "$1.02"},
+		{big.NewRat(1009, 1000), RoundDown, "$1.00"},
+		{big.NewRat(1001, 1000), RoundUp, "$1.01"},
+		{big.NewRat(-1005, 1000), RoundHalfUp, "-$1.01"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Format(test.amount, USD, FormatOptions{Locale: LocaleEnUS, Rounding: test.mode}))
+	}
+}
+
+// TestFormatSatoshis will test the method FormatSatoshis()
+func TestFormatSatoshis(t *testing.T) {
+	assert.Equal(t, "0.00001000 BSV", FormatSatoshis(1000, FormatOptions{Locale: LocaleEnUS}))
+	assert.Equal(t, "1,234.56789012 BSV", FormatSatoshis(123456789012, FormatOptions{Locale: LocaleEnUS}))
+	assert.Equal(t, "1.234,56789012 BSV", FormatSatoshis(123456789012, FormatOptions{Locale: LocaleDeDE}))
+}
+
+// TestFormatter will test the Formatter
+func TestFormatter(t *testing.T) {
+	rates := StaticRates{"USD": big.NewRat(5025, 100)}
+
+	t.Run("fiat", func(t *testing.T) {
+		formatter := NewFormatter("en_US", rates)
+		value, err := formatter.Format(context.Background(), 200000000, "usd")
+		require.NoError(t, err)
+		assert.Equal(t, "$100.50", value)
+	})
+
+	t.Run("bsv", func(t *testing.T) {
+		formatter := NewFormatter("de-AT", rates)
+		value, err := formatter.Format(context.Background(), 150000000, "BSV")
+		require.NoError(t, err)
+		assert.Equal(t, "1,50000000 BSV", value)
+	})
+
+	t.Run("satoshis", func(t *testing.T) {
+		formatter := NewFormatter("en", nil)
+		value, err := formatter.Format(context.Background(), 1234567, "sat")
+		require.NoError(t, err)
+		assert.Equal(t, "1,234,567 sat", value)
+	})
+
+	t.Run("negative decimals", func(t *testing.T) {
+		decimals := -2
+		formatter := NewFormatter("en", rates)
+		formatter.Options.Decimals = &decimals
+		_, err := formatter.Format(context.Background(), 1000, "BSV")
+		assert.ErrorIs(t, err, ErrInvalidDecimals)
+	})
+
+	t.Run("missing rate", func(t *testing.T) {
+		formatter := NewFormatter("en", rates)
+		_, err := formatter.Format(context.Background(), 1000, "EUR")
+		assert.ErrorIs(t, err, ErrRateNotFound)
+	})
+}
@@ -0,0 +1,170 @@
+package beef
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/libsv/go-bt/v2"
+)
+
+// Version is the version of the BEEF format (the bytes 0100BEEF)
+const Version uint32 = 0xEFBE0001
+
+// Markers of the transactions of a BEEF
+const (
+	markerNoBUMP  = 0x00 // the transaction is not mined, its inputs are in the BEEF
+	markerHasBUMP = 0x01 // the index of the BUMP of the transaction follows
+)
+
+// ErrInvalidBEEF is when a BEEF is malformed
+var ErrInvalidBEEF = errors.New("invalid beef")
+
+// BEEF is a transaction with its unmined ancestors and the merkle paths of the mined ones (BRC-62),
+// the transactions are ordered parents first and the subject transaction is the last one
+type BEEF struct {
+	BUMPs        []*BUMP
+	Transactions []*Transaction
+}
+
+// Transaction is a transaction of a BEEF
+type Transaction struct {
+	BUMP *BUMP // merkle path of the transaction, one of the BUMPs of the BEEF, nil if not mined
+	Tx   *bt.Tx
+}
+
+// NewFromBytes will decode a BEEF
+func NewFromBytes(data []byte) (*BEEF, error) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != Version {
+		return nil, ErrInvalidBEEF
+	}
+	reader := bytes.NewReader(data[4:])
+
+	count, err := readVarInt(reader)
+	if err != nil || count > uint64(reader.Len()) {
+		return nil, ErrInvalidBEEF
+	}
+	beef := &BEEF{BUMPs: make([]*BUMP, 0, count)}
+	for index := uint64(0); index < count; index++ {
+		var bump *BUMP
+		if bump, err = readBUMP(reader); err != nil {
+			return nil, err
+		}
+		beef.BUMPs = append(beef.BUMPs, bump)
+	}
+
+	if count, err = readVarInt(reader); err != nil || count == 0 || count > uint64(reader.Len()) {
+		return nil, ErrInvalidBEEF
+	}
+	beef.Transactions = make([]*Transaction, 0, count)
+	for index := uint64(0); index < count; index++ {
+		transaction := &Transaction{Tx: new(bt.Tx)}
+		if _, err = transaction.Tx.ReadFrom(reader); err != nil {
+			return nil, ErrInvalidBEEF
+		}
+
+		var marker byte
+		if marker, err = reader.ReadByte(); err != nil {
+			return nil, ErrInvalidBEEF
+		}
+		switch marker {
+		case markerNoBUMP:
+		case markerHasBUMP:
+			var bumpIndex uint64
+			if bumpIndex, err = readVarInt(reader); err != nil || bumpIndex >= uint64(len(beef.BUMPs)) {
+				return nil, ErrInvalidBEEF
+			}
+			if transaction.BUMP = beef.BUMPs[bumpIndex]; !transaction.BUMP.Contains(transaction.Tx.TxID()) {
+				return nil, ErrInvalidBEEF
+			}
+		default:
+			return nil, ErrInvalidBEEF
+		}
+		beef.Transactions = append(beef.Transactions, transaction)
+	}
+
+	if reader.Len() != 0 {
+		return nil, ErrInvalidBEEF
+	}
+	return beef, nil
+}
+
+// NewFromHex will decode a hex encoded BEEF
+func NewFromHex(data string) (*BEEF, error) {
+	decoded, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidBEEF
+	}
+	return NewFromBytes(decoded)
+}
+
+// Bytes will encode the BEEF, the BUMP of every transaction must be one of the BUMPs of the BEEF
+func (b *BEEF) Bytes() ([]byte, error) {
+	if len(b.Transactions) == 0 {
+		return nil, ErrInvalidBEEF
+	}
+
+	buffer := new(bytes.Buffer)
+	version := make([]byte, 4)
+	binary.LittleEndian.PutUint32(version, Version)
+	buffer.Write(version)
+
+	buffer.Write(bt.VarInt(uint64(len(b.BUMPs))).Bytes())
+	bumpIndexes := make(map[*BUMP]uint64, len(b.BUMPs))
+	for index, bump := range b.BUMPs {
+		data, err := bump.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(data)
+		bumpIndexes[bump] = uint64(index)
+	}
+
+	buffer.Write(bt.VarInt(uint64(len(b.Transactions))).Bytes())
+	for _, transaction := range b.Transactions {
+		if transaction.Tx == nil {
+			return nil, ErrInvalidBEEF
+		}
+		buffer.Write(transaction.Tx.Bytes())
+		if transaction.BUMP == nil {
+			buffer.WriteByte(markerNoBUMP)
+			continue
+		}
+
+		bumpIndex, ok := bumpIndexes[transaction.BUMP]
+		if !ok || !transaction.BUMP.Contains(transaction.Tx.TxID()) {
+			return nil, ErrInvalidBEEF
+		}
+		buffer.WriteByte(markerHasBUMP)
+		buffer.Write(bt.VarInt(bumpIndex).Bytes())
+	}
+	return buffer.Bytes(), nil
+}
+
+// Hex will encode the BEEF in hex
+func (b *BEEF) Hex() (string, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// Subject will return the subject transaction (the last one), nil if the BEEF has no transactions
+func (b *BEEF) Subject() *Transaction {
+	if len(b.Transactions) == 0 {
+		return nil
+	}
+	return b.Transactions[len(b.Transactions)-1]
+}
+
+// Transaction will return the transaction of the BEEF, nil if the transaction is not in the BEEF
+func (b *BEEF) Transaction(txID string) *Transaction {
+	for _, transaction := range b.Transactions {
+		if transaction.Tx.TxID() == txID {
+			return transaction
+		}
+	}
+	return nil
+}
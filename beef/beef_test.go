@@ -0,0 +1,151 @@
+package beef
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPubKeyHash = "0b2b03751813e3467a28ce916cbb102d84c6eec5"
+
+// testBlock is a block of three transactions, and its merkle root
+type testBlock struct {
+	root  string
+	txs   []*bt.Tx
+	left  []byte
+	right []byte
+}
+
+func newTestBlock(t *testing.T) *testBlock {
+	block := new(testBlock)
+	var leaves [][]byte
+	for _, satoshis := range []uint64{1000, 2000, 3000} {
+		tx := bt.NewTx()
+		require.NoError(t, tx.AddP2PKHOutputFromPubKeyHashStr(testPubKeyHash, satoshis))
+		block.txs = append(block.txs, tx)
+		leaves = append(leaves, reverseBytes(tx.TxIDBytes()))
+	}
+
+	block.left = doubleSha256(leaves[0], leaves[1])
+	block.right = doubleSha256(leaves[2], leaves[2])
+	block.root = hex.EncodeToString(reverseBytes(doubleSha256(block.left, block.right)))
+	return block
+}
+
+// merkleProof will return the merkle proof (TSC format) of the transaction of the block
+func (b *testBlock) merkleProof(index int) *transports.MerkleProof {
+	nodes := map[int][]string{
+		0: {b.txs[1].TxID(), hex.EncodeToString(reverseBytes(b.right))},
+		1: {b.txs[0].TxID(), hex.EncodeToString(reverseBytes(b.right))},
+		2: {"*", hex.EncodeToString(reverseBytes(b.left))},
+	}
+	return &transports.MerkleProof{Index: uint64(index), TxID: b.txs[index].TxID(), Nodes: nodes[index]}
+}
+
+// TestBUMP will test the encoding and decoding of the BUMPs
+func TestBUMP(t *testing.T) {
+	block := newTestBlock(t)
+
+	for index, tx := range block.txs {
+		bump, err := NewBUMPFromMerkleProof(100, block.merkleProof(index))
+		require.NoError(t, err)
+		root, err := bump.ComputeRoot(tx.TxID())
+		require.NoError(t, err)
+		assert.Equal(t, block.root, root)
+
+		encoded, err := bump.Hex()
+		require.NoError(t, err)
+		decoded, err := NewBUMPFromHex(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, bump, decoded)
+	}
+
+	t.Run("compound path", func(t *testing.T) {
+		// the hash of the level 1 is omitted, it is computed from the level 0
+		bump := &BUMP{BlockHeight: 100, Path: [][]*Leaf{{
+			{Offset: 0, Hash: block.txs[0].TxID(), TxID: true},
+			{Offset: 1, Hash: block.txs[1].TxID()},
+			{Offset: 2, Hash: block.txs[2].TxID(), TxID: true},
+			{Offset: 3, Duplicate: true},
+		}, {}}}
+		for _, tx := range []*bt.Tx{block.txs[0], block.txs[2]} {
+			root, err := bump.ComputeRoot(tx.TxID())
+			require.NoError(t, err)
+			assert.Equal(t, block.root, root)
+		}
+		_, err := bump.ComputeRoot(strings.Repeat("00", 32))
+		assert.ErrorIs(t, err, ErrInvalidBUMP)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, data := range []string{"", "64", "6400", "640101000200", "64010100030000", "zz"} {
+			_, err := NewBUMPFromHex(data)
+			assert.ErrorIs(t, err, ErrInvalidBUMP, data)
+		}
+		_, err := NewBUMPFromMerkleProof(100, &transports.MerkleProof{})
+		assert.ErrorIs(t, err, ErrInvalidBUMP)
+	})
+}
+
+// TestBEEF will test the encoding and decoding of the BEEFs
+func TestBEEF(t *testing.T) {
+	block := newTestBlock(t)
+	bump, err := NewBUMPFromMerkleProof(100, block.merkleProof(2))
+	require.NoError(t, err)
+
+	// the child spends the mined parent, the grandchild spends the unmined child
+	child := bt.NewTx()
+	require.NoError(t, child.From(block.txs[2].TxID(), 0, block.txs[2].Outputs[0].LockingScript.String(), 3000))
+	require.NoError(t, child.AddP2PKHOutputFromPubKeyHashStr(testPubKeyHash, 2900))
+	grandchild := bt.NewTx()
+	require.NoError(t, grandchild.From(child.TxID(), 0, child.Outputs[0].LockingScript.String(), 2900))
+	require.NoError(t, grandchild.AddP2PKHOutputFromPubKeyHashStr(testPubKeyHash, 2800))
+
+	beef := &BEEF{
+		BUMPs: []*BUMP{bump},
+		Transactions: []*Transaction{
+			{BUMP: bump, Tx: block.txs[2]},
+			{Tx: child},
+			{Tx: grandchild},
+		},
+	}
+	encoded, err := beef.Hex()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "0100beef"))
+
+	decoded, err := NewFromHex(encoded)
+	require.NoError(t, err)
+	require.Len(t, decoded.Transactions, 3)
+	assert.Equal(t, grandchild.TxID(), decoded.Subject().Tx.TxID())
+	assert.Nil(t, decoded.Transaction(child.TxID()).BUMP)
+	parent := decoded.Transaction(block.txs[2].TxID())
+	require.NotNil(t, parent)
+	assert.Same(t, decoded.BUMPs[0], parent.BUMP)
+	root, err := parent.BUMP.ComputeRoot(parent.Tx.TxID())
+	require.NoError(t, err)
+	assert.Equal(t, block.root, root)
+
+	reencoded, err := decoded.Hex()
+	require.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, data := range []string{"", "zz", "01000000", "0100beef0000", encoded + "00", encoded[:len(encoded)-2]} {
+			_, err = NewFromHex(data)
+			assert.ErrorIs(t, err, ErrInvalidBEEF, data)
+		}
+
+		// the BUMP of a transaction must be in the BEEF and contain the transaction
+		_, err = (&BEEF{Transactions: []*Transaction{{BUMP: bump, Tx: block.txs[2]}}}).Bytes()
+		assert.ErrorIs(t, err, ErrInvalidBEEF)
+		_, err = (&BEEF{BUMPs: []*BUMP{bump}, Transactions: []*Transaction{{BUMP: bump, Tx: child}}}).Bytes()
+		assert.ErrorIs(t, err, ErrInvalidBEEF)
+		_, err = (&BEEF{}).Bytes()
+		assert.ErrorIs(t, err, ErrInvalidBEEF)
+	})
+}
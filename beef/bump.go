@@ -0,0 +1,280 @@
+// Package beef contains the encoding and decoding of transactions in the BEEF format (BRC-62), with
+// the merkle paths of their mined ancestors in the BUMP format (BRC-74), used by the SPV wallets
+package beef
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bt/v2"
+)
+
+// Flags of the leaves of a BUMP
+const (
+	flagHash      = 0x00 // the hash of a sibling follows
+	flagDuplicate = 0x01 // the sibling is a duplicate of the working hash, no hash follows
+	flagTxID      = 0x02 // the hash of a transaction of the path follows
+)
+
+// maxTreeHeight is the height of the merkle tree of a block of 2^64 transactions
+const maxTreeHeight = 64
+
+// ErrInvalidBUMP is when a BUMP is malformed or does not contain the transaction
+var ErrInvalidBUMP = errors.New("invalid bump")
+
+// BUMP is the merkle path of one or many transactions of a block (BRC-74)
+type BUMP struct {
+	BlockHeight uint64
+	Path        [][]*Leaf // the leaves of every level, from the transactions (0) to the level below the root
+}
+
+// Leaf is a hash of a level of the merkle path
+type Leaf struct {
+	Offset    uint64 // position of the hash in its level
+	Hash      string // display (reversed) hex, empty when Duplicate
+	TxID      bool   // the hash is a transaction of the path
+	Duplicate bool   // the hash is a duplicate of its sibling (last hash of an odd level)
+}
+
+// NewBUMPFromMerkleProof will convert the merkle proof (TSC format) of a transaction to a BUMP
+func NewBUMPFromMerkleProof(blockHeight uint64, merkleProof *transports.MerkleProof) (*BUMP, error) {
+	if merkleProof == nil || len(merkleProof.Nodes) == 0 || len(merkleProof.Nodes) > maxTreeHeight {
+		return nil, ErrInvalidBUMP
+	}
+
+	bump := &BUMP{
+		BlockHeight: blockHeight,
+		Path:        make([][]*Leaf, len(merkleProof.Nodes)),
+	}
+	bump.Path[0] = []*Leaf{{Offset: merkleProof.Index, Hash: merkleProof.TxID, TxID: true}}
+	for level, node := range merkleProof.Nodes {
+		sibling := &Leaf{Offset: (merkleProof.Index >> uint(level)) ^ 1}
+		if node == "*" {
+			sibling.Duplicate = true
+		} else {
+			sibling.Hash = node
+		}
+		bump.Path[level] = append(bump.Path[level], sibling)
+	}
+	return bump, nil
+}
+
+// NewBUMPFromBytes will decode a BUMP
+func NewBUMPFromBytes(data []byte) (*BUMP, error) {
+	reader := bytes.NewReader(data)
+	bump, err := readBUMP(reader)
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() != 0 {
+		return nil, ErrInvalidBUMP
+	}
+	return bump, nil
+}
+
+// NewBUMPFromHex will decode a hex encoded BUMP
+func NewBUMPFromHex(data string) (*BUMP, error) {
+	decoded, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidBUMP
+	}
+	return NewBUMPFromBytes(decoded)
+}
+
+// Bytes will encode the BUMP
+func (b *BUMP) Bytes() ([]byte, error) {
+	if len(b.Path) == 0 || len(b.Path) > maxTreeHeight {
+		return nil, ErrInvalidBUMP
+	}
+
+	buffer := new(bytes.Buffer)
+	buffer.Write(bt.VarInt(b.BlockHeight).Bytes())
+	buffer.WriteByte(byte(len(b.Path)))
+	for _, leaves := range b.Path {
+		buffer.Write(bt.VarInt(uint64(len(leaves))).Bytes())
+		for _, leaf := range leaves {
+			buffer.Write(bt.VarInt(leaf.Offset).Bytes())
+			switch {
+			case leaf.Duplicate:
+				buffer.WriteByte(flagDuplicate)
+				continue
+			case leaf.TxID:
+				buffer.WriteByte(flagTxID)
+			default:
+				buffer.WriteByte(flagHash)
+			}
+
+			hash, err := hexToInternal(leaf.Hash)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(hash)
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// Hex will encode the BUMP in hex
+func (b *BUMP) Hex() (string, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// Contains will return whether the transaction is in the path
+func (b *BUMP) Contains(txID string) bool {
+	return b.txLeaf(txID) != nil
+}
+
+// ComputeRoot will compute the merkle root (display hex) of the block of the transaction, to compare with
+// the merkle root of the block header at BlockHeight
+func (b *BUMP) ComputeRoot(txID string) (string, error) {
+	leaf := b.txLeaf(txID)
+	if leaf == nil {
+		return "", ErrInvalidBUMP
+	}
+
+	hash, err := hexToInternal(leaf.Hash)
+	if err != nil {
+		return "", err
+	}
+	for level := range b.Path {
+		offset := leaf.Offset >> uint(level)
+		var sibling []byte
+		if sibling, err = b.hashAt(level, offset^1, hash); err != nil {
+			return "", err
+		}
+		if offset&1 == 1 {
+			hash = doubleSha256(sibling, hash)
+		} else {
+			hash = doubleSha256(hash, sibling)
+		}
+	}
+	return hex.EncodeToString(reverseBytes(hash)), nil
+}
+
+// txLeaf will return the leaf of the transaction, nil if the transaction is not in the path
+func (b *BUMP) txLeaf(txID string) *Leaf {
+	if len(b.Path) == 0 {
+		return nil
+	}
+	for _, leaf := range b.Path[0] {
+		if leaf.Hash == txID && !leaf.Duplicate {
+			return leaf
+		}
+	}
+	return nil
+}
+
+// hashAt will return the hash (internal byte order) at the offset of the level, the hashes that are
+// omitted from a compound path are computed from the level below
+func (b *BUMP) hashAt(level int, offset uint64, working []byte) ([]byte, error) {
+	for _, leaf := range b.Path[level] {
+		if leaf.Offset != offset {
+			continue
+		}
+		if leaf.Duplicate {
+			return working, nil
+		}
+		return hexToInternal(leaf.Hash)
+	}
+	if level == 0 {
+		return nil, ErrInvalidBUMP
+	}
+
+	left, err := b.hashAt(level-1, offset*2, nil)
+	if err != nil {
+		return nil, err
+	}
+	var right []byte
+	if right, err = b.hashAt(level-1, offset*2+1, left); err != nil {
+		return nil, err
+	}
+	return doubleSha256(left, right), nil
+}
+
+// readBUMP will read a BUMP from the reader
+func readBUMP(reader *bytes.Reader) (*BUMP, error) {
+	blockHeight, err := readVarInt(reader)
+	if err != nil {
+		return nil, err
+	}
+	var treeHeight byte
+	if treeHeight, err = reader.ReadByte(); err != nil || treeHeight == 0 || treeHeight > maxTreeHeight {
+		return nil, ErrInvalidBUMP
+	}
+
+	bump := &BUMP{BlockHeight: blockHeight, Path: make([][]*Leaf, treeHeight)}
+	for level := range bump.Path {
+		var count uint64
+		if count, err = readVarInt(reader); err != nil || count > uint64(reader.Len()) {
+			return nil, ErrInvalidBUMP
+		}
+		bump.Path[level] = make([]*Leaf, 0, count)
+		for index := uint64(0); index < count; index++ {
+			leaf := new(Leaf)
+			if leaf.Offset, err = readVarInt(reader); err != nil {
+				return nil, err
+			}
+			var flag byte
+			if flag, err = reader.ReadByte(); err != nil {
+				return nil, ErrInvalidBUMP
+			}
+			switch flag {
+			case flagDuplicate:
+				leaf.Duplicate = true
+			case flagHash, flagTxID:
+				leaf.TxID = flag == flagTxID
+				hash := make([]byte, sha256.Size)
+				if _, err = io.ReadFull(reader, hash); err != nil {
+					return nil, ErrInvalidBUMP
+				}
+				leaf.Hash = hex.EncodeToString(reverseBytes(hash))
+			default:
+				return nil, ErrInvalidBUMP
+			}
+			bump.Path[level] = append(bump.Path[level], leaf)
+		}
+	}
+	return bump, nil
+}
+
+// readVarInt will read a variable length integer from the reader
+func readVarInt(reader *bytes.Reader) (uint64, error) {
+	var value bt.VarInt
+	if _, err := value.ReadFrom(reader); err != nil {
+		return 0, ErrInvalidBUMP
+	}
+	return uint64(value), nil
+}
+
+// doubleSha256 will return sha256(sha256(left | right))
+func doubleSha256(left, right []byte) []byte {
+	first := sha256.Sum256(append(append([]byte{}, left...), right...))
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// hexToInternal will decode a hash in display (reversed) hex to its internal byte order
+func hexToInternal(hash string) ([]byte, error) {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, ErrInvalidBUMP
+	}
+	return reverseBytes(decoded), nil
+}
+
+// reverseBytes will return a reversed copy of the bytes
+func reverseBytes(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for index, value := range data {
+		reversed[len(data)-1-index] = value
+	}
+	return reversed
+}
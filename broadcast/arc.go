@@ -0,0 +1,83 @@
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ARC statuses of the transactions that are rejected
+var arcRejectedStatuses = map[string]bool{
+	"DOUBLE_SPEND_ATTEMPTED": true,
+	"REJECTED":               true,
+}
+
+// arcResponse is the response of the ARC transaction endpoint (the fields of the errors and of the
+// transaction status)
+type arcResponse struct {
+	Detail    string `json:"detail"`
+	ExtraInfo string `json:"extraInfo"`
+	Status    int    `json:"status"`
+	Title     string `json:"title"`
+	TxID      string `json:"txid"`
+	TxStatus  string `json:"txStatus"`
+}
+
+// ARC broadcasts the transactions to an ARC endpoint (POST /v1/tx)
+type ARC struct {
+	apiKey     string
+	httpClient *http.Client
+	url        string
+}
+
+// NewARC will create a new ARC broadcaster, the API key (bearer token) and http client are optional
+func NewARC(url, apiKey string, httpClient *http.Client) *ARC {
+	return &ARC{
+		apiKey:     apiKey,
+		httpClient: defaultHTTPClient(httpClient),
+		url:        strings.TrimSuffix(url, "/"),
+	}
+}
+
+// Broadcast will submit the transaction to ARC
+func (a *ARC) Broadcast(ctx context.Context, hex string) error {
+	body, err := json.Marshal(map[string]string{"rawTx": hex})
+	if err != nil {
+		return err
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, a.url+"/v1/tx", bytes.NewReader(body)); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	var resp *http.Response
+	if resp, err = a.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var response arcResponse
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&response)
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusUnauthorized:
+		return &RejectedError{Reason: strings.TrimSpace(response.Title + " " + response.Detail + " " + response.ExtraInfo)}
+	case resp.StatusCode >= 400:
+		return errors.New("arc error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+	case arcRejectedStatuses[response.TxStatus]:
+		return &RejectedError{Reason: strings.TrimSpace(response.TxStatus + " " + response.ExtraInfo)}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package broadcast contains the broadcasters submitting raw transactions directly to the network, through
+// the ARC or mAPI endpoint of a miner, used as a fallback when the bux server can not broadcast them
+package broadcast
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRejected is when the transaction is rejected by the miner (ex: invalid, double spend)
+var ErrRejected = errors.New("the transaction was rejected by the miner")
+
+// RejectedError is returned when the miner rejects the transaction, it matches ErrRejected with errors.Is
+type RejectedError struct {
+	Reason string
+}
+
+// Error will return the error message, with the reason given by the miner
+func (e *RejectedError) Error() string {
+	if e.Reason == "" {
+		return ErrRejected.Error()
+	}
+	return ErrRejected.Error() + ": " + e.Reason
+}
+
+// Is will return whether the target is ErrRejected
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Broadcaster submits a raw transaction (hex) to the network, a transaction that is already known by the
+// miner is not an error
+type Broadcaster interface {
+	Broadcast(ctx context.Context, hex string) error
+}
+
+// defaultHTTPClient will return the http client, or a client with a timeout if it is nil
+func defaultHTTPClient(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+	return httpClient
+}
@@ -0,0 +1,81 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestARC will test the ARC broadcaster
+func TestARC(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/tx", req.URL.Path)
+		assert.Equal(t, "Bearer api-key", req.Header.Get("Authorization"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch body["rawTx"] {
+		case "double-spend":
+			_, _ = w.Write([]byte(`{"status":200,"txStatus":"DOUBLE_SPEND_ATTEMPTED","extraInfo":"input spent"}`))
+		case "invalid":
+			w.WriteHeader(461)
+			_, _ = w.Write([]byte(`{"status":461,"title":"Malformed transaction","detail":"unlocking script"}`))
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{"status":200,"txStatus":"SEEN_ON_NETWORK","txid":"tx-id"}`))
+		}
+	}))
+	defer server.Close()
+
+	arc := NewARC(server.URL+"/", "api-key", nil)
+	assert.NoError(t, arc.Broadcast(context.Background(), "valid"))
+
+	err := arc.Broadcast(context.Background(), "double-spend")
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Contains(t, err.Error(), "input spent")
+
+	err = arc.Broadcast(context.Background(), "invalid")
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Contains(t, err.Error(), "Malformed transaction")
+
+	err = arc.Broadcast(context.Background(), "unavailable")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRejected)
+}
+
+// TestMAPI will test the mAPI broadcaster
+func TestMAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/mapi/tx", req.URL.Path)
+		assert.Empty(t, req.Header.Get("Authorization"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+
+		payload := map[string]string{"returnResult": "success"}
+		switch body["rawtx"] {
+		case "known":
+			payload = map[string]string{"returnResult": "failure", "resultDescription": "Transaction already known"}
+		case "invalid":
+			payload = map[string]string{"returnResult": "failure", "resultDescription": "Missing inputs"}
+		}
+		data, _ := json.Marshal(payload)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"payload": string(data), "signature": "signature"})
+	}))
+	defer server.Close()
+
+	mapi := NewMAPI(server.URL, "", nil)
+	assert.NoError(t, mapi.Broadcast(context.Background(), "valid"))
+	assert.NoError(t, mapi.Broadcast(context.Background(), "known"))
+
+	err := mapi.Broadcast(context.Background(), "invalid")
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Contains(t, err.Error(), "Missing inputs")
+}
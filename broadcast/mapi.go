@@ -0,0 +1,90 @@
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// mapiAlreadyKnown are the descriptions of the failures of the transactions already known by the miner
+var mapiAlreadyKnown = []string{"already known", "already in the mempool", "txn-already-known"}
+
+// mapiEnvelope is the signed envelope of the mAPI responses
+type mapiEnvelope struct {
+	Payload string `json:"payload"`
+}
+
+// mapiPayload is the payload of the mAPI transaction response
+type mapiPayload struct {
+	ResultDescription string `json:"resultDescription"`
+	ReturnResult      string `json:"returnResult"`
+	TxID              string `json:"txid"`
+}
+
+// MAPI broadcasts the transactions to a merchant API endpoint (POST /mapi/tx)
+type MAPI struct {
+	httpClient *http.Client
+	token      string
+	url        string
+}
+
+// NewMAPI will create a new mAPI broadcaster, the token (bearer token) and http client are optional
+func NewMAPI(url, token string, httpClient *http.Client) *MAPI {
+	return &MAPI{
+		httpClient: defaultHTTPClient(httpClient),
+		token:      token,
+		url:        strings.TrimSuffix(url, "/"),
+	}
+}
+
+// Broadcast will submit the transaction to the merchant API
+func (m *MAPI) Broadcast(ctx context.Context, hex string) error {
+	body, err := json.Marshal(map[string]string{"rawtx": hex})
+	if err != nil {
+		return err
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, m.url+"/mapi/tx", bytes.NewReader(body)); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+
+	var resp *http.Response
+	if resp, err = m.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 400 {
+		return errors.New("mapi error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+	}
+
+	var envelope mapiEnvelope
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope); err != nil {
+		return err
+	}
+	var payload mapiPayload
+	if err = json.Unmarshal([]byte(envelope.Payload), &payload); err != nil {
+		return err
+	}
+	if payload.ReturnResult == "success" {
+		return nil
+	}
+	for _, known := range mapiAlreadyKnown {
+		if strings.Contains(strings.ToLower(payload.ResultDescription), known) {
+			return nil
+		}
+	}
+	return &RejectedError{Reason: payload.ResultDescription}
+}
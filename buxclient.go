@@ -8,44 +8,124 @@ package buxclient
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/broadcast"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/paymail"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
 	"github.com/BuxOrg/go-buxclient/transports"
 	"github.com/BuxOrg/go-buxclient/utils"
-	"github.com/bitcoinschema/go-bitcoin/v2"
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/bip32"
-	"github.com/libsv/go-bk/wif"
 	"github.com/libsv/go-bt/v2"
 	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/pkg/errors"
 )
 
+// DefaultStartupTimeout is the default limit of the requests made to the server when creating the
+// client (see WithStartupTimeout)
+const DefaultStartupTimeout = 30 * time.Second
+
 // ClientOps are used for client options
 type ClientOps func(c *BuxClient)
 
 // BuxClient is the bux client
 type BuxClient struct {
-	accessKey        *bec.PrivateKey
-	accessKeyString  string
-	debug            bool
-	transport        transports.TransportService
-	transportOptions []transports.ClientOps
-	xPriv            *bip32.ExtendedKey
-	xPrivString      string
-	xPub             *bip32.ExtendedKey
-	xPubString       string
+	accessKey           *bec.PrivateKey
+	accessKeyStore      *keyStoreKey
+	accessKeyString     string
+	adminKeyStore       *keyStoreKey
+	broadcaster         broadcast.Broadcaster
+	callbacks           *events.WebhookSink
+	callbacksLock       *sync.Mutex
+	capabilities        *serverCapabilities
+	chainHeight         ChainHeightFunc
+	autoRedraft         bool
+	checkFrozen         bool
+	checkServer         bool
+	negotiate           bool
+	requireCapabilities bool
+	deadLetters         *DeadLetterQueue
+	debug               bool
+	featureFlags        *featureFlags
+	keysLock            *sync.RWMutex // the keys are replaced by the rotations while requests run
+	limits              TransactionLimits
+	loadFeatureFlags    bool
+	minConfirmations    uint64
+	paymail             *paymail.Client
+	readCache           *readCache
+	rotation            *sync.Mutex
+	scheduler           scheduler.Scheduler
+	scriptTemplates     *ScriptTemplateRegistry
+	snapshot            *snapshotOptions
+	startupTimeout      time.Duration
+	store               store.Store
+	transport           transports.TransportService
+	transportOptions    []transports.ClientOps
+	usage               *usageRecorder
+	xPriv               *bip32.ExtendedKey
+	xPrivKeyStore       *keyStoreKey
+	xPrivString         string
+	xPub                *bip32.ExtendedKey
+	xPubString          string
 }
 
 // New create a new bux client
 func New(opts ...ClientOps) (*BuxClient, error) {
-	client := &BuxClient{}
+	client := &BuxClient{
+		autoRedraft:    true,
+		callbacksLock:  &sync.Mutex{},
+		capabilities:   &serverCapabilities{},
+		deadLetters:    NewDeadLetterQueue(),
+		featureFlags:   &featureFlags{},
+		keysLock:       &sync.RWMutex{},
+		negotiate:      true,
+		rotation:       &sync.Mutex{},
+		scheduler:      scheduler.Default(),
+		startupTimeout: DefaultStartupTimeout,
+	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
+	if client.minConfirmations > 0 && client.chainHeight == nil {
+		return nil, ErrMissingChainHeight
+	}
+	if client.usage != nil {
+		client.usage.since = client.scheduler.Now()
+	}
+	if client.scriptTemplates == nil {
+		client.scriptTemplates = NewScriptTemplateRegistry()
+	}
+	if client.paymail == nil {
+		opts := paymail.DefaultOptions()
+		opts.Scheduler = client.scheduler
+		client.paymail = paymail.NewClient(nil, opts)
+	}
 
 	var err error
+	if client.xPrivKeyStore != nil {
+		if client.xPrivString, err = client.xPrivKeyStore.load(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if client.accessKeyStore != nil {
+		if client.accessKeyString, err = client.accessKeyStore.load(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if client.adminKeyStore != nil {
+		var adminKey string
+		if adminKey, err = client.adminKeyStore.load(context.Background()); err != nil {
+			return nil, err
+		}
+		client.transportOptions = append(client.transportOptions, transports.WithAdminKey(adminKey))
+	}
 	if client.xPrivString != "" {
 		if client.xPriv, err = bip32.NewKeyFromString(client.xPrivString); err != nil {
 			return nil, err
@@ -62,18 +142,9 @@ func New(opts ...ClientOps) (*BuxClient, error) {
 		client.xPriv = nil
 		client.xPub = nil
 
-		var privateKey *bec.PrivateKey
-		var decodedWIF *wif.WIF
-		if decodedWIF, err = wif.DecodeWIF(client.accessKeyString); err != nil {
-			// try as a hex string
-			var errHex error
-			if privateKey, errHex = bitcoin.PrivateKeyFromString(client.accessKeyString); errHex != nil {
-				return nil, errors.Wrap(err, errHex.Error())
-			}
-		} else {
-			privateKey = decodedWIF.PrivKey
+		if client.accessKey, err = utils.ParseAccessKey(client.accessKeyString); err != nil {
+			return nil, err
 		}
-		client.accessKey = privateKey
 	} else {
 		return nil, errors.New("no keys available")
 	}
@@ -87,6 +158,12 @@ func New(opts ...ClientOps) (*BuxClient, error) {
 	} else if client.accessKey != nil {
 		transportOptions = append(transportOptions, transports.WithAccessKey(client.accessKey))
 	}
+	if client.store != nil {
+		if client.deadLetters, err = NewPersistentDeadLetterQueue(context.Background(), client.store); err != nil {
+			return nil, err
+		}
+		transportOptions = append(transportOptions, transports.WithEventCheckpoints(client.store))
+	}
 	if len(client.transportOptions) > 0 {
 		transportOptions = append(transportOptions, client.transportOptions...)
 	}
@@ -95,6 +172,27 @@ func New(opts ...ClientOps) (*BuxClient, error) {
 		return nil, err
 	}
 
+	// a hung server cannot block the creation of the client
+	ctx, cancel := context.WithTimeout(context.Background(), client.startupTimeout)
+	defer cancel()
+
+	if client.checkServer {
+		if err = client.Ping(ctx); err != nil {
+			return nil, err
+		}
+	}
+	client.restoreSnapshot()
+	if client.negotiate {
+		if err = client.negotiateCapabilities(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if client.loadFeatureFlags {
+		if err = client.RefreshFeatureFlags(ctx); err != nil && !client.restoreCachedSnapshot() {
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
 
@@ -110,6 +208,17 @@ func (b *BuxClient) SetAdminKey(adminKeyString string) error {
 	return nil
 }
 
+// HasAdminKey return whether the admin key is set
+func (b *BuxClient) HasAdminKey() bool {
+	return b.transport.HasAdminKey()
+}
+
+// RequiresAdmin return whether the operation (the name of the client method, ex: "AdminGetPaymails")
+// needs the admin key, UIs can use it together with HasAdminKey to hide admin features
+func (b *BuxClient) RequiresAdmin(operation string) bool {
+	return transports.RequiresAdmin(operation)
+}
+
 // SetDebug turn the debugging on or off
 func (b *BuxClient) SetDebug(debug bool) {
 	b.debug = debug
@@ -136,38 +245,272 @@ func (b *BuxClient) GetTransport() *transports.TransportService {
 	return &b.transport
 }
 
+// Notifications subscribes to the notification stream of the bux server, the typed events are
+// delivered on the channel (with automatic reconnection) until the context is done
+func (b *BuxClient) Notifications(ctx context.Context) (<-chan *events.Event, error) {
+	if err := b.requireFeature(FeatureSubscriptions); err != nil {
+		return nil, err
+	}
+	return b.transport.Notifications(ctx)
+}
+
+// SubscribeTransactions subscribes to the new transactions of the xPub matching the conditions, the
+// transactions are pushed on the channel as soon as the server learns about them (graphql only)
+func (b *BuxClient) SubscribeTransactions(ctx context.Context,
+	conditions map[string]interface{}) (<-chan *bux.Transaction, error) {
+
+	if err := b.requireFeature(FeatureSubscriptions); err != nil {
+		return nil, err
+	}
+	return b.transport.SubscribeTransactions(ctx, conditions)
+}
+
+// RunGraphQL runs the raw graphql query (or mutation) with the variables, signed like the other requests,
+// and decodes the data of the response into out, for the server features not wrapped by the client
+// (graphql only, see transports.TransportGraphQL.RunGraphQL)
+func (b *BuxClient) RunGraphQL(ctx context.Context, query string, variables map[string]interface{},
+	out interface{}) error {
+
+	return b.transport.RunGraphQL(ctx, query, variables, out)
+}
+
+// DoRawRequest sends the request to the path of the bux server, authenticated like the other requests,
+// and decodes the json response into out, for the endpoints not wrapped by the client yet (http only,
+// see transports.TransportHTTP.DoRawRequest)
+func (b *BuxClient) DoRawRequest(ctx context.Context, method, path string, body interface{},
+	out interface{}) error {
+
+	return b.transport.DoRawRequest(ctx, method, path, body, out)
+}
+
+// DeadLetters returns the queue of failed async operations, for inspection and manual retries
+func (b *BuxClient) DeadLetters() *DeadLetterQueue {
+	return b.deadLetters
+}
+
 // RegisterXpub registers a new xpub - admin key needed
 func (b *BuxClient) RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error {
+	if err := validateXPub(rawXPub); err != nil {
+		return err
+	}
 	return b.transport.RegisterXpub(ctx, rawXPub, metadata)
 }
 
-// DraftTransaction initialize a new draft transaction
+// DraftTransaction initialize a new draft transaction, the options (ex: WithFromUtxos) are the
+// defaults of the server when not set
 func (b *BuxClient) DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig,
-	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
+	metadata *bux.Metadata, opts ...DraftOps) (*bux.DraftTransaction, error) {
+
+	if err := b.checkSigning(OperationDraftTransaction); err != nil {
+		return nil, err
+	}
+	if err := b.requireCapability(CapabilityNewTransaction, OperationDraftTransaction); err != nil {
+		return nil, err
+	}
+	if err := b.checkInputSigners(); err != nil {
+		return nil, err
+	}
+	draftOpts, err := b.draftOptions(transports.DraftOptions{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if transactionConfig != nil {
+		if err = b.validateScriptOutputs(transactionConfig); err != nil {
+			return nil, err
+		}
+		outputs, changeOutputs := configOutputs(transactionConfig), 0
+		if transactionConfig.SendAllTo == "" {
+			requested := transactionConfig.ChangeNumberOfDestinations
+			if draftOpts != nil && draftOpts.ChangeNumberOfDestinations > 0 {
+				requested = draftOpts.ChangeNumberOfDestinations
+			}
+			if changeOutputs = b.fitChangeOutputs(len(outputs), requested); changeOutputs < requested {
+				if draftOpts == nil {
+					draftOpts = &transports.DraftOptions{}
+				}
+				draftOpts.ChangeNumberOfDestinations = changeOutputs
+			}
+		}
+		if err = b.checkOutputLimits(outputs, changeOutputs); err != nil {
+			return nil, err
+		}
+	}
+	if err = b.checkNotFrozen(ctx); err != nil {
+		return nil, err
+	}
+	if draftOpts != nil && len(draftOpts.FromUtxos) > 0 {
+		if draftOpts.FromUtxos, err = b.confirmedInputs(ctx, draftOpts.FromUtxos); err != nil {
+			return nil, err
+		}
+	} else if transactionConfig != nil {
+		var fromUtxos []*bux.UtxoPointer
+		if fromUtxos, err = b.confirmedInputs(ctx, transactionConfig.FromUtxos); err != nil {
+			return nil, err
+		}
+		config := *transactionConfig
+		config.FromUtxos = fromUtxos
+		transactionConfig = &config
+	}
 
-	return b.transport.DraftTransaction(ctx, transactionConfig, metadata)
+	var draft *bux.DraftTransaction
+	if draft, err = b.transport.DraftTransaction(ctx, transactionConfig, metadata, draftOpts); err != nil {
+		return nil, err
+	}
+	if err = b.checkDraftLimits(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
 }
 
-// DraftToRecipients initialize a new P2PKH draft transaction to a list of recipients
+// DraftToRecipients initialize a new P2PKH draft transaction to a list of recipients, the change is
+// split randomly among 3 destinations unless set with WithChangeDestinations
 func (b *BuxClient) DraftToRecipients(ctx context.Context, recipients []*transports.Recipients,
-	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
+	metadata *bux.Metadata, opts ...DraftOps) (*bux.DraftTransaction, error) {
+
+	if err := b.checkSigning(OperationDraftToRecipients); err != nil {
+		return nil, err
+	}
+	if err := b.requireCapability(CapabilityNewTransaction, OperationDraftToRecipients); err != nil {
+		return nil, err
+	}
+	if err := b.checkInputSigners(); err != nil {
+		return nil, err
+	}
+	if err := validateRecipients(recipients); err != nil {
+		return nil, err
+	}
+	if err := b.requireScriptRecipients(recipients); err != nil {
+		return nil, err
+	}
+	draftOpts, err := b.draftOptions(transports.DraftOptions{
+		ChangeDestinationsStrategy: DefaultChangeDestinationsStrategy,
+		ChangeNumberOfDestinations: DefaultChangeDestinations,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var requested int
+	if draftOpts != nil {
+		requested = draftOpts.ChangeNumberOfDestinations
+	}
+	changeOutputs := b.fitChangeOutputs(len(recipients), requested)
+	if changeOutputs < requested {
+		draftOpts.ChangeNumberOfDestinations = changeOutputs
+	}
+	if err = b.checkOutputLimits(recipients, changeOutputs); err != nil {
+		return nil, err
+	}
+	if err = b.checkNotFrozen(ctx); err != nil {
+		return nil, err
+	}
+	var fromUtxos []*bux.UtxoPointer
+	if draftOpts != nil {
+		fromUtxos = draftOpts.FromUtxos
+	}
+	if fromUtxos, err = b.confirmedInputs(ctx, fromUtxos); err != nil {
+		return nil, err
+	} else if len(fromUtxos) > 0 {
+		if draftOpts == nil {
+			draftOpts = &transports.DraftOptions{}
+		}
+		draftOpts.FromUtxos = fromUtxos
+	}
 
-	return b.transport.DraftToRecipients(ctx, recipients, metadata)
+	var draft *bux.DraftTransaction
+	if draft, err = b.transport.DraftToRecipients(ctx, recipients, metadata, draftOpts); err != nil {
+		return nil, err
+	}
+	if err = b.checkDraftLimits(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// GetXPub will get the xPub of the client, its balance and next derivation numbers
+func (b *BuxClient) GetXPub(ctx context.Context) (*bux.Xpub, error) {
+	xPub := new(bux.Xpub)
+	if b.cachedRead(ctx, CacheXPub, noCacheKey, xPub) {
+		return xPub, nil
+	}
+	xPub, err := b.transport.GetXPub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheXPub, noCacheKey, xPub)
+	return xPub, nil
 }
 
 // GetDestination get new fresh destination
 func (b *BuxClient) GetDestination(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error) {
-	return b.transport.GetDestination(ctx, metadata)
+	if err := b.checkSigning(OperationGetDestination); err != nil {
+		return nil, err
+	}
+	destination, err := b.transport.GetDestination(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	b.invalidateXPub(ctx)
+	return destination, nil
+}
+
+// GetDestinationByAddress get the destination of the xPub with the address
+func (b *BuxClient) GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error) {
+	destination := new(bux.Destination)
+	if b.cachedRead(ctx, CacheDestination, address, destination) {
+		return destination, nil
+	}
+	destination, err := b.transport.GetDestinationByAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheDestination, address, destination)
+	return destination, nil
+}
+
+// MaxNewDestinations is the maximum count of destinations created by a NewDestinations call
+const MaxNewDestinations = 100
+
+// NewDestinations get count new fresh destinations (ex: to pre-generate invoice addresses) in a single
+// request with graphql (a request per destination with http), the destinations created before an
+// error are returned with it, the count is at most MaxNewDestinations
+func (b *BuxClient) NewDestinations(ctx context.Context, count int,
+	metadata *bux.Metadata) ([]*bux.Destination, error) {
+
+	if err := validateDestinationCount(count); err != nil {
+		return nil, err
+	}
+	if err := b.checkSigning(OperationNewDestinations); err != nil {
+		return nil, err
+	}
+	destinations, err := b.transport.NewDestinations(ctx, count, metadata)
+	if len(destinations) > 0 {
+		b.invalidateXPub(ctx)
+	}
+	return destinations, err
+}
+
+// VerifyDestination will return utils.ErrDestinationMismatch if the destination (ex: a destination
+// returned by the server) was not derived from the xPub of the client
+func (b *BuxClient) VerifyDestination(destination *bux.Destination) error {
+	_, xPub, _ := b.keys()
+	if xPub == nil {
+		return bux.ErrMissingXpub
+	}
+	return utils.VerifyDestination(xPub, destination)
 }
 
 // FinalizeTransaction will finalize the transaction
 func (b *BuxClient) FinalizeTransaction(draft *bux.DraftTransaction) (string, error) {
+	if err := b.checkSigning(OperationFinalizeTransaction); err != nil {
+		return "", err
+	}
 	txDraft, err := bt.NewTxFromString(draft.Hex)
 	if err != nil {
 		return "", err
 	}
 
-	// sign the inputs
+	// sign the inputs, with the same xPriv even if it is rotated meanwhile
+	xPriv, _, _ := b.keys()
 	for index, input := range draft.Configuration.Inputs {
 		var ls *bscript.Script
 		ls, err = bscript.NewFromHexString(input.Destination.LockingScript)
@@ -175,27 +518,20 @@ func (b *BuxClient) FinalizeTransaction(draft *bux.DraftTransaction) (string, er
 			return "", err
 		}
 		txDraft.Inputs[index].PreviousTxScript = ls
+		txDraft.Inputs[index].PreviousTxSatoshis = input.Satoshis
 
-		var chainKey *bip32.ExtendedKey
-		chainKey, err = b.xPriv.Child(input.Destination.Chain)
-		if err != nil {
+		var signer ScriptSigner
+		if signer, err = b.inputSigner(input); err != nil {
 			return "", err
 		}
-
-		var numKey *bip32.ExtendedKey
-		numKey, err = chainKey.Child(input.Destination.Num)
-		if err != nil {
-			return "", err
-		}
-
 		var privateKey *bec.PrivateKey
-		privateKey, err = bitcoin.GetPrivateKeyFromHDKey(numKey)
+		privateKey, err = utils.DeriveDestinationPrivateKey(xPriv, input.Destination.Chain, input.Destination.Num)
 		if err != nil {
 			return "", err
 		}
 
 		var s *bscript.Script
-		s, err = utils.GetUnlockingScript(txDraft, uint32(index), privateKey)
+		s, err = signer(txDraft, uint32(index), privateKey)
 		if err != nil {
 			return "", err
 		}
@@ -206,44 +542,355 @@ func (b *BuxClient) FinalizeTransaction(draft *bux.DraftTransaction) (string, er
 		}
 	}
 
+	if err = b.checkTxSize(txDraft.Size()); err != nil {
+		return "", err
+	}
+
 	return txDraft.String(), nil
 }
 
 // GetTransaction get a transaction by id
 func (b *BuxClient) GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error) {
-	return b.transport.GetTransaction(ctx, txID)
+	transaction := new(bux.Transaction)
+	if b.cachedRead(ctx, CacheTransaction, txID, transaction) {
+		return transaction, nil
+	}
+	transaction, err := b.transport.GetTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheTransaction, txID, transaction)
+	return transaction, nil
+}
+
+// GetTransactionStatus get the status of a transaction on the network (seen, mined, double spend or rejected)
+func (b *BuxClient) GetTransactionStatus(ctx context.Context, txID string) (*transports.TransactionStatus, error) {
+	return b.transport.GetTransactionStatus(ctx, txID)
+}
+
+// ImportTransaction asks the server to fetch a transaction of the chain that pays one of the destinations
+// of the xPub but was not recorded through bux (ex: an external deposit), so it appears in the history
+func (b *BuxClient) ImportTransaction(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	if err := b.requireFeature(FeatureImportTransactions); err != nil {
+		return nil, err
+	}
+	if err := validateTxID("tx_id", txID); err != nil {
+		return nil, err
+	}
+
+	transaction, err := b.transport.ImportTransaction(ctx, txID, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if transaction != nil && transaction.ID != "" && transaction.ID != txID {
+		return nil, errors.Wrapf(ErrTxIDMismatch, "imported %s, expected %s", transaction.ID, txID)
+	}
+	b.invalidateXPub(ctx)
+	return transaction, nil
+}
+
+// GetMerkleProof get the merkle proof of a mined transaction
+func (b *BuxClient) GetMerkleProof(ctx context.Context, txID string) (*transports.MerkleProof, error) {
+	proof := new(transports.MerkleProof)
+	if b.cachedRead(ctx, CacheMerkleProof, txID, proof) {
+		return proof, nil
+	}
+	proof, err := b.transport.GetMerkleProof(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheMerkleProof, txID, proof)
+	return proof, nil
 }
 
-// GetTransactions get all transactions matching search criteria
+// GetBlockHeader get a block header by hash
+func (b *BuxClient) GetBlockHeader(ctx context.Context, blockHash string) (*transports.BlockHeader, error) {
+	header := new(transports.BlockHeader)
+	if b.cachedRead(ctx, CacheBlockHeader, blockHash, header) {
+		return header, nil
+	}
+	header, err := b.transport.GetBlockHeader(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheBlockHeader, blockHash, header)
+	return header, nil
+}
+
+// GetTransactions get all transactions matching search criteria (a page of them with the query params)
 func (b *BuxClient) GetTransactions(ctx context.Context, conditions map[string]interface{},
-	metadata *bux.Metadata) ([]*bux.Transaction, error) {
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error) {
 
-	return b.transport.GetTransactions(ctx, conditions, metadata)
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.GetTransactions(ctx, conditions, metadata, queryParams)
 }
 
-// RecordTransaction record a new transaction
-func (b *BuxClient) RecordTransaction(ctx context.Context, hex, draftID string,
+// GetTransactionsByIDs get the transactions by id in a single request, the transactions are in the
+// order of the ids (nil for the ids that were not found)
+func (b *BuxClient) GetTransactionsByIDs(ctx context.Context, txIDs []string) ([]*bux.Transaction, error) {
+	transactions := make([]*bux.Transaction, len(txIDs))
+	ids := make([]string, 0, len(txIDs))
+	seen := make(map[string]bool, len(txIDs))
+	for _, txID := range txIDs {
+		if !seen[txID] {
+			seen[txID] = true
+			ids = append(ids, txID)
+		}
+	}
+	if len(ids) == 0 {
+		return transactions, nil
+	}
+
+	found, err := b.transport.SearchTransactions(ctx, map[string]interface{}{
+		"id": map[string]interface{}{"$in": ids},
+	}, nil, &transports.QueryParams{Page: 1, PageSize: len(ids)})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*bux.Transaction, len(found))
+	for _, transaction := range found {
+		byID[transaction.ID] = transaction
+	}
+	for index, txID := range txIDs {
+		transactions[index] = byID[txID]
+	}
+	return transactions, nil
+}
+
+// RecordTransaction record a new transaction, the reference ID is the ID of its draft (see SendContext)
+func (b *BuxClient) RecordTransaction(ctx context.Context, hex, referenceID string,
 	metadata *bux.Metadata) (*bux.Transaction, error) {
 
-	return b.transport.RecordTransaction(ctx, hex, draftID, metadata)
+	tx, err := parseTransactionHex("hex", hex)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.checkNotFrozen(ctx); err != nil {
+		return nil, err
+	}
+
+	var transaction *bux.Transaction
+	transaction, err = b.transport.RecordTransaction(ctx, hex, referenceID, metadata)
+	if errors.Is(err, transports.ErrBroadcastFailed) && b.broadcaster != nil {
+		// broadcast the transaction directly, and record it again
+		if broadcastErr := b.broadcaster.Broadcast(ctx, hex); broadcastErr != nil {
+			return nil, errors.Wrapf(broadcastErr, "%s, and the direct broadcast failed", err.Error())
+		}
+		transaction, err = b.transport.RecordTransaction(ctx, hex, referenceID, metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.invalidateXPub(ctx)
+	if err = checkRecordedTxID(tx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// RecordTransactions record many transactions in a single request with graphql (a request per
+// transaction with http), the transactions are in the order of the requests: when an error is
+// returned, the ones that were not recorded are nil
+func (b *BuxClient) RecordTransactions(ctx context.Context,
+	requests []*transports.RecordRequest) ([]*bux.Transaction, error) {
+
+	txs := make([]*bt.Tx, len(requests))
+	for index, request := range requests {
+		tx, err := parseTransactionHex(fmt.Sprintf("requests[%d].hex", index), request.Hex)
+		if err != nil {
+			return nil, err
+		}
+		txs[index] = tx
+	}
+	if err := b.checkNotFrozen(ctx); err != nil {
+		return nil, err
+	}
+
+	transactions, err := b.transport.RecordTransactions(ctx, requests)
+	if len(transactions) > 0 {
+		b.invalidateXPub(ctx)
+	}
+	for index, transaction := range transactions {
+		if index >= len(txs) {
+			break
+		}
+		if mismatch := checkRecordedTxID(txs[index], transaction); mismatch != nil && err == nil {
+			err = mismatch
+		}
+	}
+	return transactions, err
 }
 
-// SendToRecipients send to recipients
+// SearchTransactions get a page of the transactions matching search criteria
+func (b *BuxClient) SearchTransactions(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error) {
+
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.SearchTransactions(ctx, conditions, metadata, queryParams)
+}
+
+// UpdateTransactionMetadata update the metadata of a transaction
+func (b *BuxClient) UpdateTransactionMetadata(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	transaction, err := b.transport.UpdateTransactionMetadata(ctx, txID, metadata)
+	if err != nil {
+		return nil, err
+	}
+	b.invalidateRead(ctx, CacheTransaction, txID)
+	return transaction, nil
+}
+
+// GetDestinations get a page of the destinations matching search criteria
+func (b *BuxClient) GetDestinations(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Destination, error) {
+
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.GetDestinations(ctx, conditions, metadata, queryParams)
+}
+
+// UpdateDestinationMetadata update the metadata of a destination
+func (b *BuxClient) UpdateDestinationMetadata(ctx context.Context, id string,
+	metadata *bux.Metadata) (*bux.Destination, error) {
+
+	destination, err := b.transport.UpdateDestinationMetadata(ctx, id, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if destination != nil && destination.Address != "" {
+		b.invalidateRead(ctx, CacheDestination, destination.Address)
+	} else {
+		b.invalidateRead(ctx, CacheDestination, "")
+	}
+	return destination, nil
+}
+
+// GetUtxos get a page of the utxos matching search criteria
+func (b *BuxClient) GetUtxos(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Utxo, error) {
+
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.GetUtxos(ctx, conditions, metadata, queryParams)
+}
+
+// GetAccessKeys get a page of the access keys matching search criteria (the server must support
+// FeatureAccessKeys)
+func (b *BuxClient) GetAccessKeys(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.AccessKey, error) {
+
+	if err := b.requireFeature(FeatureAccessKeys); err != nil {
+		return nil, err
+	}
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.GetAccessKeys(ctx, conditions, metadata, queryParams)
+}
+
+// SendToRecipients send to recipients, the transaction is drafted again when its draft expires before
+// it is recorded (see WithAutoRedraft)
 func (b *BuxClient) SendToRecipients(ctx context.Context, recipients []*transports.Recipients,
 	metadata *bux.Metadata) (*bux.Transaction, error) {
 
-	draft, err := b.DraftToRecipients(ctx, recipients, metadata)
+	send := NewSendContext(metadata)
+	draft, err := b.DraftSend(ctx, send, recipients)
 	if err != nil {
 		return nil, err
 	}
-	if draft == nil {
-		return nil, bux.ErrDraftNotFound
+
+	// a draft already expired when received is left to the server (ex: the clocks differ)
+	received := send.ExpiresAt.IsZero() || b.scheduler.Now().Before(send.ExpiresAt)
+	if err = b.SignSend(send, draft); err != nil {
+		return nil, err
+	}
+
+	// the draft expires before it can be recorded, the new inputs are signed
+	if b.autoRedraft && received && b.draftExpired(send.ExpiresAt, DefaultDraftExpiryMargin) {
+		if draft, err = b.renewSend(ctx, send, draft); err != nil {
+			return nil, err
+		}
+		if err = b.SignSend(send, draft); err != nil {
+			return nil, err
+		}
+	}
+
+	var transaction *bux.Transaction
+	if transaction, err = b.RecordSend(ctx, send); !b.autoRedraft || !errors.Is(err, ErrDraftExpired) {
+		return transaction, err
 	}
 
-	var hex string
-	if hex, err = b.FinalizeTransaction(draft); err != nil {
+	// the server rejected the expired draft, it is drafted, signed and recorded again (once)
+	if draft, err = b.renewSend(ctx, send, draft); err != nil {
 		return nil, err
 	}
+	if err = b.SignSend(send, draft); err != nil {
+		return nil, err
+	}
+	return b.RecordSend(ctx, send)
+}
+
+// AdminCreatePaymail will create a new paymail address for the given xPub ID - admin key needed
+func (b *BuxClient) AdminCreatePaymail(ctx context.Context, xPubID, address, publicName, avatar string,
+	metadata *bux.Metadata) (*transports.PaymailAddress, error) {
 
-	return b.RecordTransaction(ctx, hex, draft.ID, metadata)
+	if err := b.requireCapability(CapabilityPaymail, "AdminCreatePaymail"); err != nil {
+		return nil, err
+	}
+	return b.transport.AdminCreatePaymail(ctx, xPubID, address, publicName, avatar, metadata)
+}
+
+// AdminDeletePaymail will delete (deactivate) the given paymail address - admin key needed
+func (b *BuxClient) AdminDeletePaymail(ctx context.Context, address string) error {
+	if err := b.requireCapability(CapabilityPaymail, "AdminDeletePaymail"); err != nil {
+		return err
+	}
+	return b.transport.AdminDeletePaymail(ctx, address)
+}
+
+// AdminGetPaymails will get all paymail addresses matching the conditions and metadata - admin key needed
+func (b *BuxClient) AdminGetPaymails(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*transports.PaymailAddress, error) {
+
+	if err := b.requireCapability(CapabilityPaymail, "AdminGetPaymails"); err != nil {
+		return nil, err
+	}
+	if err := validateQueryParams(queryParams); err != nil {
+		return nil, err
+	}
+	return b.transport.AdminGetPaymails(ctx, conditions, metadata, queryParams)
+}
+
+// RegisterWebhook will register a webhook endpoint on the server, the server will push the given
+// event types to the url, signed with the shared secret
+func (b *BuxClient) RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType,
+	secret string) (*transports.Webhook, error) {
+
+	if err := b.checkSigning(OperationRegisterWebhook); err != nil {
+		return nil, err
+	}
+	return b.transport.RegisterWebhook(ctx, url, eventTypes, secret)
+}
+
+// GetWebhooks will get all registered webhook endpoints
+func (b *BuxClient) GetWebhooks(ctx context.Context) ([]*transports.Webhook, error) {
+	return b.transport.GetWebhooks(ctx)
+}
+
+// DeleteWebhook will delete a registered webhook endpoint
+func (b *BuxClient) DeleteWebhook(ctx context.Context, id string) error {
+	if err := b.checkSigning(OperationDeleteWebhook); err != nil {
+		return err
+	}
+	return b.transport.DeleteWebhook(ctx, id)
 }
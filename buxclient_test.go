@@ -1,20 +1,59 @@
 package buxclient
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/BuxOrg/bux"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/beef"
+	"github.com/BuxOrg/go-buxclient/broadcast"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
 	"github.com/BuxOrg/go-buxclient/transports"
+	buxutils "github.com/BuxOrg/go-buxclient/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
 	"github.com/libsv/go-bt"
+	btv2 "github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/net/websocket"
 )
 
 const (
@@ -27,11 +66,14 @@ const (
 	serverURL        = "https://example.com/"
 	xpubJSON         = `{"data":{"xpub":{"id":"0092de4d2aafa59a71a1f90342c138e1c4f19cd1b10e2d17422b34a1d06733e0"}}}`
 	txID             = "041479f86c475603fd510431cf702bc8c9849a9c350390eb86b467d82a13cc24"
+	txHex            = "0100000004afcafa163824904aa3bbc403b30db56a08f29ffa53b16b1b4b4914b9bd7d7610010000006a4730440220710c2b2fe5a0ece2cbc962635d0fb6dabf95c94db0b125c3e2613cede9738666022067e9cc0f4f706c3a2781990981a50313fb0aad18c1e19a757125eec2408ecadb412103dcd8d28545c9f80af54648fcca87972d89e3e7ed7b482465dd78b62c784ad533ffffffff783452c4038c46a4d68145d829f09c70755edd8d4b3512d7d6a27db08a92a76b000000006b483045022100ee7e24859274013e748090a022bf51200ab216771b5d0d57c0d074843dfa62bd02203933c2bd2880c2f8257befff44dc19cb1f3760c6eea44fc0f8094ff94bce652a41210375680e36c45658bd9b0694a48f5756298cf95b77f50bada14ef1cba6d7ea1d3affffffff25e893beb8240ede7661c02cb959799d364711ba638eccdf12e3ce60faa2fd0f010000006b483045022100fc380099ac7f41329aaeed364b95baa390be616243b80a8ef444ae0ddc76fa3a0220644a9677d40281827fa4602269720a5a453fbe77409be40293c3f8248534e5f8412102398146eff37de36ed608b2ee917a3d4b4a424722f9a00f1b48c183322a8ef2a1ffffffff00e6f915a5a3678f01229e5c320c64755f242be6cebfac54e2f77ec5e0eec581000000006b483045022100951511f81291ac234926c866f777fe8e77bc00661031675978ddecf159cc265902207a5957dac7c89493e2b7df28741ce3291e19dc8bba4b13082c69d0f2b79c70ab4121031d674b3ad42b28f3a445e9970bd9ae8fe5d3fb89ee32452d9f6dc7916ea184bfffffffff04c7110000000000001976a91483615db3fb9b9cbbf4cd407100833511a1cb278588ac30060000000000001976a914296a5295e70697e844fb4c2113b41a501d41452e88ac96040000000000001976a914e73e21935fc48df0d1cf8b73f2e8bbd23b78244a88ac27020000000000001976a9140b2b03751813e3467a28ce916cbb102d84c6eec588ac00000000"
 	draftTxJSON      = `{"created_at":"2022-02-09T16:28:39.000639Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null,"id":"fe6fe12c25b81106b7332d58fe87dab7bc6e56c8c21ca45b4de05f673f3f653c","hex":"010000000141e3be4d5a3f25e11157bfdd100e7c3497b9be2b80b57eb55e5376b075e7dc5d0200000000ffffffff02e8030000000000001976a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac170e0000000000001976a9143dbdb346aaf1c3dc501a2f8c186c3d3e8a87764588ac00000000","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","expires_at":"2022-02-09T16:29:08.991801Z","metadata":{"testkey":"test-value"},"configuration":{"change_destinations":[{"created_at":"2022-02-09T16:28:38.997313Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null,"id":"252e8a915a5f05effab827a887e261a2416a76f3d3aada946a70a575c0bb76a7","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","locking_script":"76a9143dbdb346aaf1c3dc501a2f8c186c3d3e8a87764588ac","type":"pubkeyhash","chain":1,"num":100,"address":"16dTUJwi7qT3JqzAUMcDHaVV3sB4fH85Ep","draft_id":"fe6fe12c25b81106b7332d58fe87dab7bc6e56c8c21ca45b4de05f673f3f653c"}],"change_destinations_strategy":"","change_minimum_satoshis":0,"change_number_of_destinations":0,"change_satoshis":3607,"expires_in":0,"fee":97,"fee_unit":{"satoshis":1,"bytes":2},"from_utxos":null,"inputs":[{"created_at":"2022-01-28T13:45:02.352Z","updated_at":"2022-02-09T16:28:38.993207Z","deleted_at":null,"id":"efe383eea1a6f7925afb2621b69ea9ba6bd0623e8d61827bad994f8be85161fc","transaction_id":"5ddce775b076535eb57eb5802bbeb997347c0e10ddbf5711e1253f5a4dbee341","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","output_index":2,"satoshis":4704,"script_pub_key":"76a914c746bf0f295375cbea4a5ef25b36c84ff9801bac88ac","type":"pubkeyhash","draft_id":"fe6fe12c25b81106b7332d58fe87dab7bc6e56c8c21ca45b4de05f673f3f653c","reserved_at":"2022-02-09T16:28:38.993205Z","spending_tx_id":null,"destination":{"created_at":"2022-01-28T13:45:02.324Z","updated_at":"0001-01-01T00:00:00Z","metadata":{"client_id":"8","run":90,"run_id":"3108aa426fc7102488bb0ffd","xbench":"destination for testing"},"deleted_at":null,"id":"b8bfa56e37c90f1b25df2e571f727cfec80dd17c5d1845c4b93e21034f7f6a0b","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","locking_script":"76a914c746bf0f295375cbea4a5ef25b36c84ff9801bac88ac","type":"pubkeyhash","chain":0,"num":212,"address":"1KAgDiUasnC7roCjQZM1XLJUpq4BYHjdp6","draft_id":""}}],"miner":"","outputs":[{"satoshis":1000,"scripts":[{"address":"1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGt","satoshis":1000,"script":"76a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac","script_type":"pubkeyhash"}],"to":"1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGt","op_return":null},{"satoshis":3607,"scripts":[{"address":"16dTUJwi7qT3JqzAUMcDHaVV3sB4fH85Ep","satoshis":3607,"script":"76a9143dbdb346aaf1c3dc501a2f8c186c3d3e8a87764588ac","script_type":""}],"to":"16dTUJwi7qT3JqzAUMcDHaVV3sB4fH85Ep","op_return":null}],"send_all_to":"","sync":null},"status":"draft"}`
 	destinationJSON  = `{"id":"90d10acb85f37dd009238fe7ec61a1411725825c82099bd8432fcb47ad8326ce","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","locking_script":"76a9140e0eb4911d79e9b7683f268964f595b66fa3604588ac","type":"pubkeyhash","chain":0,"num":245,"address":"12HL5RyEy3Rt6SCwxgpiFSTigem1Pzbq22","metadata":{"test":"test value"}}}`
 	transactionJSON  = `{"id":"041479f86c475603fd510431cf702bc8c9849a9c350390eb86b467d82a13cc24","created_at":"2022-01-28T13:45:01.711Z","updated_at":null,"deleted_at":null,"hex":"0100000004afcafa163824904aa3bbc403b30db56a08f29ffa53b16b1b4b4914b9bd7d7610010000006a4730440220710c2b2fe5a0ece2cbc962635d0fb6dabf95c94db0b125c3e2613cede9738666022067e9cc0f4f706c3a2781990981a50313fb0aad18c1e19a757125eec2408ecadb412103dcd8d28545c9f80af54648fcca87972d89e3e7ed7b482465dd78b62c784ad533ffffffff783452c4038c46a4d68145d829f09c70755edd8d4b3512d7d6a27db08a92a76b000000006b483045022100ee7e24859274013e748090a022bf51200ab216771b5d0d57c0d074843dfa62bd02203933c2bd2880c2f8257befff44dc19cb1f3760c6eea44fc0f8094ff94bce652a41210375680e36c45658bd9b0694a48f5756298cf95b77f50bada14ef1cba6d7ea1d3affffffff25e893beb8240ede7661c02cb959799d364711ba638eccdf12e3ce60faa2fd0f010000006b483045022100fc380099ac7f41329aaeed364b95baa390be616243b80a8ef444ae0ddc76fa3a0220644a9677d40281827fa4602269720a5a453fbe77409be40293c3f8248534e5f8412102398146eff37de36ed608b2ee917a3d4b4a424722f9a00f1b48c183322a8ef2a1ffffffff00e6f915a5a3678f01229e5c320c64755f242be6cebfac54e2f77ec5e0eec581000000006b483045022100951511f81291ac234926c866f777fe8e77bc00661031675978ddecf159cc265902207a5957dac7c89493e2b7df28741ce3291e19dc8bba4b13082c69d0f2b79c70ab4121031d674b3ad42b28f3a445e9970bd9ae8fe5d3fb89ee32452d9f6dc7916ea184bfffffffff04c7110000000000001976a91483615db3fb9b9cbbf4cd407100833511a1cb278588ac30060000000000001976a914296a5295e70697e844fb4c2113b41a501d41452e88ac96040000000000001976a914e73e21935fc48df0d1cf8b73f2e8bbd23b78244a88ac27020000000000001976a9140b2b03751813e3467a28ce916cbb102d84c6eec588ac00000000","block_hash":"","block_height":0,"fee":354,"number_of_inputs":4,"number_of_outputs":4,"total_value":6955,"metadata":{"client_id":"8","run":76,"run_id":"3108aa426fc7102488bb0ffd","xbench":"is awesome"},"output_value":1725,"direction":"incoming"}`
 	transactionsJSON = `[{"id":"caae6e799210dfea7591e3d55455437eb7e1091bb01463ae1e7ddf9e29c75eda","created_at":"2022-01-28T13:44:59.376Z","updated_at":null,"deleted_at":null,"hex":"0100000001cf4faa628ce1abdd2cfc641c948898bb7a3dbe043999236c3ea4436a0c79f5dc000000006a47304402206aeca14175e4477031970c1cda0af4d9d1206289212019b54f8e1c9272b5bac2022067c4d32086146ca77640f02a989f51b3c6738ebfa24683c4a923f647cf7f1c624121036295a81525ba33e22c6497c0b758e6a84b60d97c2d8905aa603dd364915c3a0effffffff023e030000000000001976a914f7fc6e0b05e91c3610efd0ce3f04f6502e2ed93d88ac99030000000000001976a914550e06a3aa71ba7414b53922c13f96a882bf027988ac00000000","block_hash":"","block_height":0,"fee":97,"number_of_inputs":1,"number_of_outputs":2,"total_value":733,"metadata":{"client_id":"8","run":14,"run_id":"3108aa426fc7102488bb0ffd","xbench":"is awesome"},"output_value":921,"direction":"incoming"},{"id":"5f4fd2be162769852e8bd1362bb8d815a89e137707b4985249876a7f0ebbb071","created_at":"2022-01-28T13:44:59.996Z","updated_at":null,"deleted_at":null,"hex":"01000000016c0c005d516ccd1f1029fa5b61be51a0feaee6e2b07804ceba71047e06edb2df000000006b483045022100ab020464941452dff13bf4ff40a6218825b8dc3502d7860857ee0dd9407e490402206325d24bd46c09b246ebe8493257f2b91d4157de58adfdedf42ba72d6de9aaf5412103a06808b0c597ee6c572baf4f167166e9fed4b8ca66d651d2345b12e0ae5344b3ffffffff0208020000000000001976a914c3367acfc659588393c68dae3eb435c5d0a088b988ac46120000000000001976a91492fc673e0630962068c8b7d909fbfeeb77e3ea3288ac00000000","block_hash":"","block_height":0,"fee":97,"number_of_inputs":1,"number_of_outputs":2,"total_value":423,"metadata":{"client_id":"8","run":32,"run_id":"3108aa426fc7102488bb0ffd","xbench":"is awesome"},"output_value":4678,"direction":"incoming"}]`
 	accessKeyString  = `7779d24ca6f8821f225042bf55e8f80aa41b08b879b72827f51e41e6523b9cd0`
+	webhookJSON      = `{"id":"a8f6d8e2b0f9b0c6c3d1e2f3a4b5c6d7","url":"https://example.com/hooks/bux","event_types":["transaction_created"],"created_at":"2022-02-09T16:28:39.000639Z"}`
+	paymailJSON      = `{"id":"c0ba4e1a3a5b1e4b6e2b5c9e1c1b9d3b2f1e1a5c4b3a2d1e0f9e8d7c6b5a4f3e","xpub_id":"9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36","alias":"test","domain":"bux.org","public_name":"Test User","avatar":"https://bux.org/avatar.png","metadata":{"test-key":"test-value"}}`
 )
 
 // localRoundTripper is an http.RoundTripper that executes HTTP transactions
@@ -53,6 +95,13 @@ func mustWrite(w io.Writer, s string) {
 	}
 }
 
+// recordedTransactionJSON will return transactionJSON with the ID of the recorded transaction hex
+func recordedTransactionJSON(t *testing.T, hex string) string {
+	tx, err := btv2.NewTxFromString(hex)
+	require.NoError(t, err)
+	return strings.Replace(transactionJSON, txID, tx.TxID(), 1)
+}
+
 type testTransportHandler struct {
 	ClientURL string
 	Client    func(serverURL string, httpClient *http.Client) ClientOps
@@ -119,6 +168,29 @@ func TestNewBuxClient(t *testing.T) {
 		require.NoError(t, err)
 		assert.IsType(t, BuxClient{}, *client)
 	})
+
+	t.Run("startup timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case <-req.Context().Done():
+			case <-release:
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		start := time.Now()
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTP(server.URL),
+			WithServerCheck(),
+			WithStartupTimeout(50*time.Millisecond),
+		)
+		require.Error(t, err)
+		assert.Nil(t, client)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
 }
 
 // TestSetAdminKey will test the admin key setter
@@ -160,6 +232,30 @@ func TestSetAdminKey(t *testing.T) {
 	})
 }
 
+// TestRequiresAdmin will test the admin key detection
+func TestRequiresAdmin(t *testing.T) {
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTP(serverURL),
+	)
+	require.NoError(t, err)
+
+	assert.True(t, client.RequiresAdmin(transports.OperationAdminGetPaymails))
+	assert.True(t, client.RequiresAdmin("RegisterXpub"))
+	assert.False(t, client.RequiresAdmin("GetTransactions"))
+	assert.False(t, client.HasAdminKey())
+
+	err = client.RegisterXpub(context.Background(), xPubString, nil)
+	require.ErrorIs(t, err, transports.ErrAdminKey)
+	var adminKeyErr *transports.AdminKeyError
+	require.ErrorAs(t, err, &adminKeyErr)
+	assert.Equal(t, transports.OperationRegisterXpub, adminKeyErr.Operation)
+	assert.Contains(t, err.Error(), "RegisterXpub")
+
+	require.NoError(t, client.SetAdminKey(adminKeyXpub))
+	assert.True(t, client.HasAdminKey())
+}
+
 // TestSetDebug will test the debug setter
 func TestSetDebug(t *testing.T) {
 	t.Run("true", func(t *testing.T) {
@@ -220,6 +316,28 @@ func TestSetSignRequest(t *testing.T) {
 	})
 }
 
+// TestWithLogger will test the structured debug output
+func TestWithLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	client := getTestBuxClient(testTransportHandler{
+		Type:      "http",
+		Path:      "/transaction",
+		Result:    transactionJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, false, WithLogger(logging.NewTextLogger(&buffer)), WithDebugging(true))
+
+	_, err := client.GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Contains(t, buffer.String(), "DEBUG transaction tx_id="+txID)
+
+	buffer.Reset()
+	client.SetDebug(false)
+	_, err = client.GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Empty(t, buffer.String())
+}
+
 // TestDraftTransaction will test the DraftTransaction method
 func TestDraftTransaction(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
@@ -256,6 +374,173 @@ func TestDraftTransaction(t *testing.T) {
 	}
 }
 
+// TestDraftTransactionOptions will test the options of the DraftTransaction method
+func TestDraftTransactionOptions(t *testing.T) {
+	var config map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{}`)
+	})
+	mux.HandleFunc("/transactions/new", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Config map[string]interface{} `json:"config"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		config = body.Config
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, draftTxJSON)
+	})
+	newClient := func() *BuxClient {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+		)
+		require.NoError(t, err)
+		return client
+	}
+	transactionConfig := &bux.TransactionConfig{
+		Outputs: []*bux.TransactionOutput{{Satoshis: 1000, To: testAddress}},
+	}
+
+	t.Run("default", func(t *testing.T) {
+		_, err := newClient().DraftTransaction(context.Background(), transactionConfig, nil)
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), config["change_number_of_destinations"])
+		assert.Nil(t, config["from_utxos"])
+		assert.Len(t, config["outputs"], 1)
+	})
+
+	t.Run("from utxos", func(t *testing.T) {
+		const draftInput = "5ddce775b076535eb57eb5802bbeb997347c0e10ddbf5711e1253f5a4dbee341"
+		utxo, err := ParseUtxoPointer(draftInput + ":2")
+		require.NoError(t, err)
+		assert.Equal(t, &bux.UtxoPointer{TransactionID: draftInput, OutputIndex: 2}, utxo)
+
+		_, err = newClient().DraftTransaction(context.Background(), transactionConfig, nil, WithFromUtxos(utxo))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"transaction_id": draftInput, "output_index": float64(2),
+		}}, config["from_utxos"])
+
+		_, err = newClient().DraftTransaction(context.Background(), transactionConfig, nil,
+			WithFromUtxos(&bux.UtxoPointer{TransactionID: "invalid"}))
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		for _, outpoint := range []string{"", draftInput, draftInput + ":", draftInput + ":-1", "invalid:0"} {
+			_, err = ParseUtxoPointer(outpoint)
+			assert.ErrorIs(t, err, ErrInvalidInput, outpoint)
+		}
+	})
+
+	t.Run("change destinations", func(t *testing.T) {
+		recipients := []*transports.Recipients{{To: testAddress, Satoshis: 1000}}
+		_, err := newClient().DraftToRecipients(context.Background(), recipients, nil)
+		require.NoError(t, err)
+		assert.Equal(t, float64(DefaultChangeDestinations), config["change_number_of_destinations"])
+		assert.Equal(t, "random", config["change_destinations_strategy"])
+		assert.Len(t, config["outputs"], 1)
+
+		_, err = newClient().DraftToRecipients(context.Background(), recipients, nil,
+			WithChangeDestinations(1, bux.ChangeStrategyDefault), WithChangeMinimumSatoshis(500))
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), config["change_number_of_destinations"])
+		assert.Equal(t, "default", config["change_destinations_strategy"])
+		assert.Equal(t, float64(500), config["change_minimum_satoshis"])
+
+		_, err = newClient().DraftToRecipients(context.Background(), recipients, nil,
+			WithChangeDestinations(2, bux.ChangeStrategyNominations))
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		_, err = newClient().DraftToRecipients(context.Background(), recipients, nil,
+			WithChangeDestinations(-1, bux.ChangeStrategyRandom))
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+}
+
+// TestScriptOutputs will test the outputs given as locking scripts
+func TestScriptOutputs(t *testing.T) {
+	const lockingScript = "76a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac"
+	var config *bux.TransactionConfig
+	features := `{"script_outputs":true}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, features)
+	})
+	mux.HandleFunc("/transactions/new", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Config *bux.TransactionConfig `json:"config"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		config = body.Config
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, draftTxJSON)
+	})
+	newClient := func() *BuxClient {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+		)
+		require.NoError(t, err)
+		return client
+	}
+	ctx := context.Background()
+
+	t.Run("draft transaction", func(t *testing.T) {
+		_, err := newClient().DraftTransaction(ctx, &bux.TransactionConfig{
+			Outputs: []*bux.TransactionOutput{NewScriptOutput(lockingScript, 1000)},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, config.Outputs, 1)
+		assert.Equal(t, uint64(1000), config.Outputs[0].Satoshis)
+		assert.Equal(t, lockingScript, config.Outputs[0].Scripts[0].Script)
+
+		for _, output := range []*bux.TransactionOutput{
+			NewScriptOutput("not-a-script", 1000),
+			{Satoshis: 1000, Scripts: []*bux.ScriptOutput{{Script: lockingScript, Satoshis: 999}}},
+		} {
+			_, err = newClient().DraftTransaction(ctx, &bux.TransactionConfig{
+				Outputs: []*bux.TransactionOutput{output},
+			}, nil)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		}
+	})
+
+	t.Run("draft to recipients", func(t *testing.T) {
+		_, err := newClient().DraftToRecipients(ctx, []*transports.Recipients{
+			{Script: lockingScript, Satoshis: 1000},
+			{Script: "006a0474657374"}, // data output, without satoshis
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, config.Outputs, 2)
+		assert.Equal(t, uint64(1000), config.Outputs[0].Satoshis)
+		assert.Equal(t, lockingScript, config.Outputs[0].Scripts[0].Script)
+		assert.Empty(t, config.Outputs[0].To)
+
+		for _, recipient := range []*transports.Recipients{
+			{Script: "not-a-script", Satoshis: 1000},
+			{Script: lockingScript},
+			{Script: lockingScript, To: testAddress, Satoshis: 1000},
+		} {
+			_, err = newClient().DraftToRecipients(ctx, []*transports.Recipients{recipient}, nil)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		}
+	})
+
+	t.Run("not supported by the server", func(t *testing.T) {
+		features = `{}`
+		_, err := newClient().DraftToRecipients(ctx, []*transports.Recipients{
+			{Script: lockingScript, Satoshis: 1000},
+		}, nil)
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+		_, err = newClient().DraftTransaction(ctx, &bux.TransactionConfig{
+			Outputs: []*bux.TransactionOutput{NewScriptOutput(lockingScript, 1000)},
+		}, nil)
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+	})
+}
+
 // TestRegisterXpub will test the RegisterXpub method
 func TestRegisterXpub(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
@@ -284,226 +569,149 @@ func TestRegisterXpub(t *testing.T) {
 	}
 }
 
-// TestDraftToRecipients will test the DraftToRecipients method
-func TestDraftToRecipients(t *testing.T) {
+// TestAdminCreatePaymail will test the AdminCreatePaymail method
+func TestAdminCreatePaymail(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
 		Type:      "http",
-		Path:      "/transactions/new",
-		Result:    draftTxJSON,
+		Path:      "/admin/paymail/create",
+		Result:    paymailJSON,
 		ClientURL: serverURL,
 		Client:    WithHTTPClient,
 	}, {
 		Type:      "graphql",
 		Path:      "/graphql",
-		Result:    `{"data":{"new_transaction":` + draftTxJSON + `}}`,
+		Result:    `{"data":{"admin_paymail_create":` + paymailJSON + `}}`,
 		ClientURL: serverURL + `graphql`,
 		Client:    WithGraphQLClient,
 	}}
 
 	for _, transportHandler := range transportHandlers {
-		t.Run("draft transaction "+transportHandler.Type, func(t *testing.T) {
-			client := getTestBuxClient(transportHandler, false)
-
-			recipients := []*transports.Recipients{{
-				Satoshis: 1000,
-				To:       testAddress,
-			}}
+		t.Run("create paymail "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, true)
 			metadata := &bux.Metadata{
 				"test-key": "test-value",
 			}
+			paymailAddress, err := client.AdminCreatePaymail(
+				context.Background(), xPubID, "test@bux.org", "Test User", "https://bux.org/avatar.png", metadata,
+			)
+			require.NoError(t, err)
+			assert.IsType(t, transports.PaymailAddress{}, *paymailAddress)
+			assert.Equal(t, xPubID, paymailAddress.XpubID)
+			assert.Equal(t, "test", paymailAddress.Alias)
+			assert.Equal(t, "bux.org", paymailAddress.Domain)
+			assert.Equal(t, "Test User", paymailAddress.PublicName)
+		})
 
-			draft, err := client.DraftToRecipients(context.Background(), recipients, metadata)
-			assert.NoError(t, err)
-			checkDraftTransactionOutput(t, draft)
+		t.Run("missing admin key "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+			paymailAddress, err := client.AdminCreatePaymail(
+				context.Background(), xPubID, "test@bux.org", "", "", nil,
+			)
+			assert.ErrorIs(t, err, transports.ErrAdminKey)
+			assert.Nil(t, paymailAddress)
 		})
 	}
 }
 
-func checkDraftTransactionOutput(t *testing.T, draft *bux.DraftTransaction) {
-	assert.IsType(t, bux.DraftTransaction{}, *draft)
-	assert.Equal(t, xPubID, draft.XpubID)
-	assert.Equal(t, bux.DraftStatusDraft, draft.Status)
-	assert.Len(t, draft.Configuration.Inputs, 1)
-	assert.Len(t, draft.Configuration.Outputs, 2)
-	assert.Equal(t, uint64(1000), draft.Configuration.Outputs[0].Satoshis)
-	assert.Equal(t, "test-value", draft.Metadata["testkey"])
-}
-
-// TestGetDestination will test the GetDestination method
-func TestGetDestination(t *testing.T) {
+// TestAdminDeletePaymail will test the AdminDeletePaymail method
+func TestAdminDeletePaymail(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
 		Type:      "http",
-		Path:      "/destinations",
-		Result:    destinationJSON,
+		Path:      "/admin/paymail/delete",
+		Result:    `true`,
 		ClientURL: serverURL,
 		Client:    WithHTTPClient,
 	}, {
 		Type:      "graphql",
 		Path:      "/graphql",
-		Result:    `{"data":{"destination":` + destinationJSON + `}}`,
+		Result:    `{"data":{"admin_paymail_delete":true}}`,
 		ClientURL: serverURL + `graphql`,
 		Client:    WithGraphQLClient,
 	}}
 
 	for _, transportHandler := range transportHandlers {
-		t.Run("new destination "+transportHandler.Type, func(t *testing.T) {
-			client := getTestBuxClient(transportHandler, false)
-
-			destination, err := client.GetDestination(context.Background(), nil)
+		t.Run("delete paymail "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, true)
+			err := client.AdminDeletePaymail(context.Background(), "test@bux.org")
 			assert.NoError(t, err)
-			assert.IsType(t, bux.Destination{}, *destination)
-			assert.Equal(t, "90d10acb85f37dd009238fe7ec61a1411725825c82099bd8432fcb47ad8326ce", destination.ID)
-			assert.Equal(t, xPubID, destination.XpubID)
-			assert.Equal(t, "76a9140e0eb4911d79e9b7683f268964f595b66fa3604588ac", destination.LockingScript)
-			assert.Equal(t, utils.ScriptTypePubKeyHash, destination.Type)
-			assert.Equal(t, uint32(0), destination.Chain)
-			assert.Equal(t, uint32(245), destination.Num)
-			assert.Equal(t, "12HL5RyEy3Rt6SCwxgpiFSTigem1Pzbq22", destination.Address)
 		})
-	}
-}
-
-// TestGetTransaction will test the GetTransaction method
-func TestGetTransaction(t *testing.T) {
-	transportHandlers := []testTransportHandler{{
-		Type:      "http",
-		Path:      "/transaction",
-		Result:    transactionJSON,
-		ClientURL: serverURL,
-		Client:    WithHTTPClient,
-	}, {
-		Type:      "graphql",
-		Path:      "/graphql",
-		Result:    `{"data":{"transaction":` + transactionJSON + `}}`,
-		ClientURL: serverURL + `graphql`,
-		Client:    WithGraphQLClient,
-	}}
 
-	for _, transportHandler := range transportHandlers {
-		t.Run("get transaction "+transportHandler.Type, func(t *testing.T) {
+		t.Run("missing admin key "+transportHandler.Type, func(t *testing.T) {
 			client := getTestBuxClient(transportHandler, false)
-
-			transaction, err := client.GetTransaction(context.Background(), txID)
-			assert.NoError(t, err)
-			assert.IsType(t, bux.Transaction{}, *transaction)
-			assert.Equal(t, txID, transaction.ID)
-			assert.Equal(t, uint64(354), transaction.Fee)
-			assert.Equal(t, uint32(4), transaction.NumberOfInputs)
-			assert.Equal(t, uint32(4), transaction.NumberOfOutputs)
-			assert.Equal(t, uint64(6955), transaction.TotalValue)
+			err := client.AdminDeletePaymail(context.Background(), "test@bux.org")
+			assert.ErrorIs(t, err, transports.ErrAdminKey)
 		})
 	}
 }
 
-// TestGetTransactions will test the GetTransactions method
-func TestGetTransactions(t *testing.T) {
+// TestAdminGetPaymails will test the AdminGetPaymails method
+func TestAdminGetPaymails(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
 		Type:      "http",
-		Path:      "/transactions",
-		Result:    transactionsJSON,
+		Path:      "/admin/paymails/search",
+		Result:    `[` + paymailJSON + `]`,
 		ClientURL: serverURL,
 		Client:    WithHTTPClient,
 	}, {
 		Type:      "graphql",
 		Path:      "/graphql",
-		Result:    `{"data":{"transactions":` + transactionsJSON + `}}`,
+		Result:    `{"data":{"admin_paymails_list":[` + paymailJSON + `]}}`,
 		ClientURL: serverURL + `graphql`,
 		Client:    WithGraphQLClient,
 	}}
 
 	for _, transportHandler := range transportHandlers {
-		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
-			client := getTestBuxClient(transportHandler, false)
-
+		t.Run("list paymails "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, true)
 			conditions := map[string]interface{}{
-				"fee": map[string]interface{}{
-					"$lt": 100,
-				},
-				"total_value": map[string]interface{}{
-					"$lt": 740,
-				},
-			}
-			metadata := &bux.Metadata{
-				"run_id": "3108aa426fc7102488bb0ffd",
+				"xpub_id": xPubID,
 			}
-			transactions, err := client.GetTransactions(context.Background(), conditions, metadata)
-			assert.NoError(t, err)
-			assert.IsType(t, []*bux.Transaction{}, transactions)
-			assert.Len(t, transactions, 2)
-			assert.Equal(t, "caae6e799210dfea7591e3d55455437eb7e1091bb01463ae1e7ddf9e29c75eda", transactions[0].ID)
-			assert.Equal(t, uint64(97), transactions[0].Fee)
-			assert.Equal(t, uint64(733), transactions[0].TotalValue)
-			assert.Equal(t, "8", transactions[0].Metadata["client_id"])
-			assert.Equal(t, "5f4fd2be162769852e8bd1362bb8d815a89e137707b4985249876a7f0ebbb071", transactions[1].ID)
-			assert.Equal(t, uint64(97), transactions[1].Fee)
-			assert.Equal(t, uint64(423), transactions[1].TotalValue)
-			assert.Equal(t, "8", transactions[1].Metadata["client_id"])
+			paymailAddresses, err := client.AdminGetPaymails(
+				context.Background(), conditions, nil, &transports.QueryParams{Page: 1, PageSize: 10},
+			)
+			require.NoError(t, err)
+			require.Len(t, paymailAddresses, 1)
+			assert.Equal(t, xPubID, paymailAddresses[0].XpubID)
+			assert.Equal(t, "test", paymailAddresses[0].Alias)
 		})
-	}
-}
-
-// TestRecordTransaction will test the RecordTransaction method
-func TestRecordTransaction(t *testing.T) {
-	transportHandlers := []testTransportHandler{{
-		Type:      "http",
-		Path:      "/transactions/record",
-		Result:    transactionJSON,
-		ClientURL: serverURL,
-		Client:    WithHTTPClient,
-	}, {
-		Type:      "graphql",
-		Path:      "/graphql",
-		Result:    `{"data":{"transaction":` + transactionJSON + `}}`,
-		ClientURL: serverURL + `graphql`,
-		Client:    WithGraphQLClient,
-	}}
 
-	for _, transportHandler := range transportHandlers {
-		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
+		t.Run("missing admin key "+transportHandler.Type, func(t *testing.T) {
 			client := getTestBuxClient(transportHandler, false)
-
-			hex := ""
-			metadata := &bux.Metadata{
-				"test-key": "test-value",
-			}
-			transaction, err := client.RecordTransaction(context.Background(), hex, "", metadata)
-			assert.NoError(t, err)
-			assert.IsType(t, bux.Transaction{}, *transaction)
-			assert.Equal(t, txID, transaction.ID)
+			paymailAddresses, err := client.AdminGetPaymails(context.Background(), nil, nil, nil)
+			assert.ErrorIs(t, err, transports.ErrAdminKey)
+			assert.Nil(t, paymailAddresses)
 		})
 	}
 }
 
-// TestSendToRecipients will test the SendToRecipients method
-func TestSendToRecipients(t *testing.T) {
+// TestWebhooks will test the RegisterWebhook, GetWebhooks and DeleteWebhook methods
+func TestWebhooks(t *testing.T) {
 	transportHandlers := []testTransportHandler{{
 		Type: "http",
+		Path: "/webhooks",
 		Queries: []*testTransportHandlerRequest{{
-			Path: "/transactions/new",
-			Result: func(w http.ResponseWriter, req *http.Request) {
-				w.Header().Set("Content-Type", "application/json")
-				mustWrite(w, draftTxJSON)
-			},
-		}, {
-			Path: "/transactions/record",
+			Path: "/webhooks",
 			Result: func(w http.ResponseWriter, req *http.Request) {
 				w.Header().Set("Content-Type", "application/json")
-				mustWrite(w, transactionJSON)
+				switch req.Method {
+				case http.MethodPost:
+					mustWrite(w, webhookJSON)
+				case http.MethodGet:
+					mustWrite(w, `[`+webhookJSON+`]`)
+				default:
+					mustWrite(w, `true`)
+				}
 			},
 		}},
-		ClientURL: serverURL,
+		ClientURL: strings.TrimSuffix(serverURL, "/"), // no redirect, the handler checks the method
 		Client:    WithHTTPClient,
 	}, {
 		Type: "graphql",
 		Queries: []*testTransportHandlerRequest{{
 			Path: "/graphql",
 			Result: func(w http.ResponseWriter, req *http.Request) {
-				result := `{"data":{"transaction":` + transactionJSON + `}}`
-				if req.ContentLength > 1000 {
-					result = `{"data":{"new_transaction":` + draftTxJSON + `}}`
-				}
 				w.Header().Set("Content-Type", "application/json")
-				mustWrite(w, result)
+				mustWrite(w, `{"data":{"webhook_create":`+webhookJSON+`,"webhooks":[`+webhookJSON+`],"webhook_delete":true}}`)
 			},
 		}},
 		ClientURL: serverURL + `graphql`,
@@ -511,41 +719,3669 @@ func TestSendToRecipients(t *testing.T) {
 	}}
 
 	for _, transportHandler := range transportHandlers {
-		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
+		t.Run("webhooks "+transportHandler.Type, func(t *testing.T) {
 			client := getTestBuxClient(transportHandler, false)
 
-			recipients := []*transports.Recipients{{
-				To:       testAddress,
-				Satoshis: 1234,
-			}, {
-				To:       testAddress2,
-				Satoshis: 4321,
-			}}
-			metadata := &bux.Metadata{
-				"test-key": "test-value",
-			}
-			transaction, err := client.SendToRecipients(context.Background(), recipients, metadata)
+			webhook, err := client.RegisterWebhook(
+				context.Background(), "https://example.com/hooks/bux",
+				[]events.EventType{events.EventTransactionCreated}, "shared-secret",
+			)
 			require.NoError(t, err)
-			assert.IsType(t, bux.Transaction{}, *transaction)
-			assert.Equal(t, txID, transaction.ID)
+			assert.Equal(t, "https://example.com/hooks/bux", webhook.URL)
+			assert.Equal(t, []events.EventType{events.EventTransactionCreated}, webhook.EventTypes)
+
+			var webhooks []*transports.Webhook
+			webhooks, err = client.GetWebhooks(context.Background())
+			require.NoError(t, err)
+			require.Len(t, webhooks, 1)
+			assert.Equal(t, webhook.ID, webhooks[0].ID)
+
+			err = client.DeleteWebhook(context.Background(), webhook.ID)
+			assert.NoError(t, err)
 		})
 	}
 }
 
-// TestFinalizeTransaction will test the FinalizeTransaction method
-func TestFinalizeTransaction(t *testing.T) {
-
-	t.Run("mock", func(t *testing.T) {
-		httpclient := &http.Client{Transport: localRoundTripper{handler: http.NewServeMux()}}
-		client, err := New(
-			WithXPriv(xPrivString),
-			WithHTTPClient(serverURL, httpclient),
-		)
-		require.NoError(t, err)
-
-		var draft *bux.DraftTransaction
-		err = json.Unmarshal([]byte(draftTxJSON), &draft)
-		require.NoError(t, err)
+// TestNotifications will test the Notifications method
+func TestNotifications(t *testing.T) {
+	var lastEventIDs []string
+	stream := func(w http.ResponseWriter, req *http.Request) {
+		lastEventIDs = append(lastEventIDs, req.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		if len(lastEventIDs) == 1 {
+			mustWrite(w, "retry: 10\n: keep-alive\n\n")
+			mustWrite(w, "id: 1\nevent: transaction_created\ndata: {\"xpub_id\":\""+xPubID+"\",\"transaction_id\":\""+txID+"\"}\n\n")
+			return // the connection drops, the client reconnects
+		}
+		mustWrite(w, "id: 2\nevent: destination_used\ndata: {\"xpub_id\":\""+xPubID+"\",\"address\":\""+testAddress+"\"}\n\n")
+	}
+
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Queries:   []*testTransportHandlerRequest{{Path: transports.NotificationsPath, Result: stream}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Queries:   []*testTransportHandlerRequest{{Path: transports.NotificationsPath, Result: stream}},
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("notifications "+transportHandler.Type, func(t *testing.T) {
+			lastEventIDs = nil
+			client := getTestBuxClient(transportHandler, false)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			notifications, err := client.Notifications(ctx)
+			require.NoError(t, err)
+
+			event := <-notifications
+			assert.Equal(t, "1", event.ID)
+			assert.Equal(t, events.EventTransactionCreated, event.Type)
+			assert.Equal(t, txID, event.TransactionID)
+
+			event = <-notifications
+			assert.Equal(t, "2", event.ID)
+			assert.Equal(t, events.EventDestinationUsed, event.Type)
+			assert.Equal(t, testAddress, event.Address)
+
+			cancel()
+			for range notifications {
+				// drain until the stream is closed
+			}
+			assert.Equal(t, "1", lastEventIDs[1])
+		})
+	}
+}
+
+// TestSubscribeTransactions will test the SubscribeTransactions method
+func TestSubscribeTransactions(t *testing.T) {
+	subscription := websocket.Handler(func(conn *websocket.Conn) {
+		var message map[string]interface{}
+		require.NoError(t, websocket.JSON.Receive(conn, &message)) // connection_init
+		assert.Equal(t, "connection_init", message["type"])
+		assert.NotEmpty(t, message["payload"])
+		require.NoError(t, websocket.JSON.Receive(conn, &message)) // start
+		assert.Equal(t, "start", message["type"])
+
+		mustWrite(conn, `{"type":"connection_ack"}`)
+		mustWrite(conn, `{"type":"ka"}`)
+		mustWrite(conn, `{"id":"1","type":"data","payload":{"data":{"transaction_created":`+transactionJSON+`}}}`)
+		_ = websocket.JSON.Receive(conn, &message) // wait for the client to stop
+	})
+
+	t.Run("graphql", func(t *testing.T) {
+		server := httptest.NewServer(subscription)
+		defer server.Close()
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithGraphQL(server.URL+"/graphql"),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		transactions, err := client.SubscribeTransactions(ctx, map[string]interface{}{"direction": "incoming"})
+		require.NoError(t, err)
+
+		transaction := <-transactions
+		require.NotNil(t, transaction)
+		assert.Equal(t, txID, transaction.ID)
+		assert.Equal(t, bux.TransactionDirectionIn, transaction.Direction)
+
+		cancel()
+		for range transactions {
+			// drain until the subscription is closed
+		}
+	})
+
+	t.Run("through the proxy", func(t *testing.T) {
+		server := httptest.NewServer(subscription)
+		defer server.Close()
+
+		// the proxy tunnels the connections with CONNECT
+		tunnels := make(chan string, 1)
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodConnect {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			tunnels <- req.Host
+			target, err := net.Dial("tcp", req.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			conn, buffered, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				_ = target.Close()
+				return
+			}
+			mustWrite(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go func() {
+				_, _ = io.Copy(target, buffered)
+				_ = target.Close()
+			}()
+			_, _ = io.Copy(conn, target)
+			_ = conn.Close()
+		}))
+		defer proxy.Close()
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithGraphQL(server.URL+"/graphql"),
+			WithProxyURL(proxy.URL),
+			WithoutCapabilities(),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		transactions, err := client.SubscribeTransactions(ctx, nil)
+		require.NoError(t, err)
+
+		transaction := <-transactions
+		require.NotNil(t, transaction)
+		assert.Equal(t, txID, transaction.ID)
+		assert.Equal(t, strings.TrimPrefix(server.URL, "http://"), <-tunnels)
+
+		cancel()
+		for range transactions {
+			// drain until the subscription is closed
+		}
+	})
+
+	t.Run("dial interrupted by the context", func(t *testing.T) {
+		// the server accepts the connection but never answers the handshake
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() {
+			_ = listener.Close()
+		}()
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			if conn, acceptErr := listener.Accept(); acceptErr == nil {
+				accepted <- conn
+			}
+		}()
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithGraphQL("http://"+listener.Addr().String()+"/graphql"),
+			WithoutCapabilities(),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		transactions, err := client.SubscribeTransactions(ctx, nil)
+		require.NoError(t, err)
+		conn := <-accepted
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		cancel()
+		select {
+		case _, ok := <-transactions:
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the handshake was not interrupted by the context")
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTP(serverURL),
+		)
+		require.NoError(t, err)
+
+		_, err = client.SubscribeTransactions(context.Background(), nil)
+		assert.ErrorIs(t, err, transports.ErrSubscriptionsNotSupported)
+	})
+}
+
+type testMetricsRecorder struct {
+	errors     []error
+	operations []string
+	transports []transports.TransportType
+}
+
+func (r *testMetricsRecorder) RecordRequest(transport transports.TransportType, operation string,
+	_ time.Duration, err error) {
+
+	r.transports = append(r.transports, transport)
+	r.operations = append(r.operations, operation)
+	r.errors = append(r.errors, err)
+}
+
+// TestMetrics will test the metrics recorder
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/destinations", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, destinationJSON)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"destination":`+destinationJSON+`}}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	t.Run("http", func(t *testing.T) {
+		recorder := &testMetricsRecorder{}
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithMetrics(recorder),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetDestination(context.Background(), nil)
+		require.NoError(t, err)
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.Error(t, err)
+
+		assert.Equal(t, []string{"POST /destinations", "GET /transaction"}, recorder.operations)
+		assert.Equal(t, transports.BuxTransportHTTP, recorder.transports[0])
+		assert.NoError(t, recorder.errors[0])
+		assert.Error(t, recorder.errors[1])
+	})
+
+	t.Run("graphql", func(t *testing.T) {
+		recorder := &testMetricsRecorder{}
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithGraphQLClient(serverURL+"graphql", httpClient),
+			WithMetrics(recorder),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetDestination(context.Background(), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"destination"}, recorder.operations)
+		assert.Equal(t, transports.BuxTransportGraphQL, recorder.transports[0])
+	})
+}
+
+// testUsageCollector collects the usage reports
+type testUsageCollector struct {
+	mu      sync.Mutex
+	reports []*UsageReport
+}
+
+// CollectUsage will store the report
+func (c *testUsageCollector) CollectUsage(report *UsageReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reports = append(c.reports, report)
+}
+
+// TestIDObfuscator will test the obfuscation of the identifiers in the logs
+func TestIDObfuscator(t *testing.T) {
+	var buffer bytes.Buffer
+	client := getTestBuxClient(testTransportHandler{
+		Type:      "http",
+		Path:      "/transaction",
+		Result:    transactionJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, false,
+		WithDebugging(true),
+		WithLogger(logging.NewTextLogger(&buffer)),
+		WithIDObfuscator(logging.HashIDs("tenant-secret")),
+	)
+
+	_, err := client.GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.NotContains(t, buffer.String(), txID)
+	assert.Contains(t, buffer.String(), "tx_id="+logging.HashIDs("tenant-secret")("tx_id", txID))
+}
+
+// TestUsageAnalytics will test the usage reports
+func TestUsageAnalytics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/destinations", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, destinationJSON)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("disabled", func(t *testing.T) {
+		client, err := New(WithXPriv(xPrivString), WithHTTP(serverURL))
+		require.NoError(t, err)
+		assert.ErrorIs(t, client.ReportUsage(), ErrUsageAnalyticsDisabled)
+		assert.ErrorIs(t, client.RunUsageReports(context.Background(), time.Minute), ErrUsageAnalyticsDisabled)
+	})
+
+	t.Run("report", func(t *testing.T) {
+		collector := &testUsageCollector{}
+		recorder := &testMetricsRecorder{}
+		virtual := scheduler.NewVirtual(start)
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithMetrics(recorder),
+			WithUsageAnalytics(collector),
+			WithScheduler(virtual),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = client.GetDestination(context.Background(), nil)
+			require.NoError(t, err)
+		}
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.Error(t, err)
+
+		// the metrics recorder still records
+		assert.Len(t, recorder.operations, 4)
+
+		virtual.Advance(time.Minute)
+		require.NoError(t, client.ReportUsage())
+		require.Len(t, collector.reports, 1)
+		report := collector.reports[0]
+		assert.Len(t, report.InstanceID, 32)
+		assert.Equal(t, start, report.Since)
+		assert.Equal(t, start.Add(time.Minute), report.Until)
+		assert.Equal(t, transports.BuxUserAgent, report.UserAgent)
+		assert.Equal(t, []*OperationUsage{
+			{Operation: "GET /transaction", Requests: 1, Errors: 1, Transport: transports.BuxTransportHTTP},
+			{Operation: "POST /destinations", Requests: 3, Transport: transports.BuxTransportHTTP},
+		}, report.Operations)
+		assert.Equal(t, float64(1), report.Operations[0].ErrorRate())
+		assert.Equal(t, float64(0), report.Operations[1].ErrorRate())
+
+		// the next report starts a new period
+		require.NoError(t, client.ReportUsage())
+		require.Len(t, collector.reports, 2)
+		assert.Empty(t, collector.reports[1].Operations)
+		assert.Equal(t, report.InstanceID, collector.reports[1].InstanceID)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		collector := &testUsageCollector{}
+		virtual := scheduler.NewVirtual(start)
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithUsageAnalytics(collector),
+			WithScheduler(virtual),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- client.RunUsageReports(ctx, time.Minute)
+		}()
+		virtual.BlockUntil(1)
+
+		_, err = client.GetDestination(context.Background(), nil)
+		require.NoError(t, err)
+		virtual.Advance(time.Minute)
+		require.Eventually(t, func() bool {
+			collector.mu.Lock()
+			defer collector.mu.Unlock()
+			return len(collector.reports) == 1
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		require.Len(t, collector.reports, 2)
+		assert.Equal(t, uint64(1), collector.reports[0].Operations[0].Requests)
+		assert.Empty(t, collector.reports[1].Operations)
+	})
+}
+
+// TestProxyURL will test sending the requests through a proxy
+func TestProxyURL(t *testing.T) {
+	var proxied []*http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req)
+		if req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithoutCapabilities(),
+		WithHTTP("http://bux.example.com"),
+		WithProxyURL(proxyURL.String()),
+	)
+	require.NoError(t, err)
+
+	transaction, err := client.GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Equal(t, txID, transaction.ID)
+	require.Len(t, proxied, 1)
+	assert.Equal(t, "bux.example.com", proxied[0].Host)
+	assert.Equal(t, "/transaction", proxied[0].URL.Path)
+
+	t.Run("custom round tripper", func(t *testing.T) {
+		_, err = New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{}}),
+			WithProxyURL(proxy.URL),
+		)
+		assert.ErrorIs(t, err, transports.ErrProxyNotSupported)
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		_, err = New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTP(serverURL),
+			WithProxyURL("http://proxy:port"),
+		)
+		assert.Error(t, err)
+	})
+}
+
+// newTestClientCertificate will create a self-signed client certificate
+func newTestClientCertificate(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "bux client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	var certificate *x509.Certificate
+	certificate, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: certificate}, certificate
+}
+
+// TestTLS will test mutual TLS and custom certificate authorities
+func TestTLS(t *testing.T) {
+	clientCertificate, clientLeaf := newTestClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientLeaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/graphql" {
+			mustWrite(w, `{"data":{"transaction":`+transactionJSON+`}}`)
+			return
+		}
+		mustWrite(w, transactionJSON)
+	}))
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // the rejected handshakes are expected
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	t.Run("mutual tls", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTP(server.URL),
+			WithRootCAs(rootCAs),
+			WithClientCertificate(clientCertificate),
+		)
+		require.NoError(t, err)
+
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+	})
+
+	t.Run("tls config", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithTLSConfig(&tls.Config{
+				Certificates: []tls.Certificate{clientCertificate},
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      rootCAs,
+			}),
+			WithGraphQL(server.URL+"/graphql"),
+		)
+		require.NoError(t, err)
+
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+	})
+
+	t.Run("missing client certificate", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTP(server.URL),
+			WithRootCAs(rootCAs),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetTransaction(context.Background(), txID)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown certificate authority", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTP(server.URL),
+			WithClientCertificate(clientCertificate),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetTransaction(context.Background(), txID)
+		assert.Error(t, err)
+	})
+
+	t.Run("custom round tripper", func(t *testing.T) {
+		_, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{}}),
+			WithRootCAs(rootCAs),
+		)
+		assert.ErrorIs(t, err, transports.ErrTLSNotSupported)
+	})
+}
+
+// TestServerXPub will test the verification of the response signatures
+func TestServerXPub(t *testing.T) {
+	serverKey, err := bip32.NewKeyFromString(adminKeyXpub)
+	require.NoError(t, err)
+	var serverXPub string
+	serverXPub, err = bitcoin.GetExtendedPublicKey(serverKey)
+	require.NoError(t, err)
+	otherXPub, err := bitcoin.GetExtendedPublicKey(mustKey(t, xPrivString))
+	require.NoError(t, err)
+
+	// the server signs the signed body for the signed path, and sends the body
+	signedBody, body, signedPath := transactionJSON, transactionJSON, ""
+	unsigned := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		header := w.Header()
+		if !unsigned {
+			signed := req.Clone(req.Context())
+			if signedPath != "" {
+				signed.URL.Path = signedPath
+			}
+			require.NoError(t, bux.SetSignature(&header, serverKey, transports.ResponseSigningPayload(signed, signedBody)))
+		}
+		header.Set("Content-Type", "application/json")
+		mustWrite(w, body)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		header := w.Header()
+		response := `{"data":{"transaction":` + transactionJSON + `}}`
+		require.NoError(t, bux.SetSignature(&header, serverKey, transports.ResponseSigningPayload(req, response)))
+		header.Set("Content-Type", "application/json")
+		mustWrite(w, response)
+	})
+	newClient := func(xPub string, opts ...ClientOps) *BuxClient {
+		client, clientErr := New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithServerXPub(xPub),
+		}, opts...)...)
+		require.NoError(t, clientErr)
+		return client
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		transaction, getErr := newClient(serverXPub).GetTransaction(context.Background(), txID)
+		require.NoError(t, getErr)
+		assert.Equal(t, txID, transaction.ID)
+	})
+
+	t.Run("signed graphql", func(t *testing.T) {
+		client, clientErr := New(
+			WithXPriv(xPrivString),
+			WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithServerXPub(serverXPub),
+		)
+		require.NoError(t, clientErr)
+		transaction, getErr := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, getErr)
+		assert.Equal(t, txID, transaction.ID)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		body = strings.Replace(transactionJSON, `"output_value":`, `"output_value":1`, 1)
+		defer func() {
+			body = transactionJSON
+		}()
+		_, getErr := newClient(serverXPub).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("other request", func(t *testing.T) {
+		signedPath = "/destination"
+		defer func() {
+			signedPath = ""
+		}()
+		_, getErr := newClient(serverXPub).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		// the middlewares see the verified responses, their changes are not verified
+		rewrite := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				resp, err := next(req)
+				if err == nil {
+					resp.Header.Set(bux.AuthSignature, "rewritten")
+				}
+				return resp, err
+			}
+		}
+		transaction, getErr := newClient(serverXPub, WithMiddleware(rewrite)).GetTransaction(context.Background(), txID)
+		require.NoError(t, getErr)
+		assert.Equal(t, txID, transaction.ID)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		unsigned = true
+		defer func() {
+			unsigned = false
+		}()
+		_, getErr := newClient(serverXPub).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("other server", func(t *testing.T) {
+		_, getErr := newClient(otherXPub).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now().Add(time.Hour))
+		_, getErr := newClient(serverXPub, WithScheduler(virtual)).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now().Add(time.Hour))
+		client := newClient(serverXPub, WithScheduler(virtual), WithSignatureTolerance(2*time.Hour))
+		_, getErr := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, getErr)
+
+		virtual = scheduler.NewVirtual(time.Now().Add(-time.Hour))
+		_, getErr = newClient(serverXPub, WithScheduler(virtual)).GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("replayed", func(t *testing.T) {
+		captured := http.Header{}
+		request, requestErr := http.NewRequest(http.MethodGet, serverURL+"transaction", nil)
+		require.NoError(t, requestErr)
+		require.NoError(t, bux.SetSignature(&captured, serverKey, transports.ResponseSigningPayload(request, transactionJSON)))
+		replayMux := http.NewServeMux()
+		replayMux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+			for key, values := range captured {
+				w.Header()[key] = values
+			}
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, transactionJSON)
+		})
+		client, clientErr := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: replayMux}}),
+			WithServerXPub(serverXPub),
+		)
+		require.NoError(t, clientErr)
+
+		_, getErr := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, getErr)
+		_, getErr = client.GetTransaction(context.Background(), txID)
+		assert.ErrorIs(t, getErr, transports.ErrResponseSignature)
+	})
+
+	t.Run("invalid xpub", func(t *testing.T) {
+		_, newErr := New(WithXPriv(xPrivString), WithHTTP(serverURL), WithServerXPub("xpub-invalid"))
+		assert.Error(t, newErr)
+	})
+}
+
+// TestRequestSignature will test the nonce and time bound into the signature of the requests
+func TestRequestSignature(t *testing.T) {
+	xPub, err := bitcoin.GetExtendedPublicKey(mustKey(t, xPrivString))
+	require.NoError(t, err)
+
+	var headers []http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		headers = append(headers, req.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	virtual := scheduler.NewVirtual(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithScheduler(virtual),
+		WithSignRequest(true),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+	}
+	require.Len(t, headers, 2)
+
+	for _, header := range headers {
+		nonce := header.Get(bux.AuthHeaderNonce)
+		assert.Len(t, nonce, 64)
+		assert.Equal(t, strconv.FormatInt(virtual.Now().UnixMilli(), 10), header.Get(bux.AuthHeaderTime))
+
+		// the signature covers the xPub, body hash, nonce and time
+		key, keyErr := utils.DeriveChildKeyFromHex(mustKey(t, xPub), nonce)
+		require.NoError(t, keyErr)
+		address, addressErr := bitcoin.GetAddressFromHDKey(key)
+		require.NoError(t, addressErr)
+		message := xPub + header.Get(bux.AuthHeaderHash) + nonce + header.Get(bux.AuthHeaderTime)
+		assert.NoError(t, bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message))
+		assert.Error(t, bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message+"0"))
+	}
+	assert.NotEqual(t, headers[0].Get(bux.AuthHeaderNonce), headers[1].Get(bux.AuthHeaderNonce))
+}
+
+// customSigner is a request signer setting a header
+type customSigner struct{}
+
+// SignRequest will set the custom signature header
+func (customSigner) SignRequest(header *http.Header, request *transports.SignatureRequest) error {
+	header.Set(bux.AuthSignature, "custom:"+request.Key)
+	return nil
+}
+
+// TestRequestSigner will test the signature schemes of the requests
+func TestRequestSigner(t *testing.T) {
+	xPub, err := bitcoin.GetExtendedPublicKey(mustKey(t, xPrivString))
+	require.NoError(t, err)
+
+	var header http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	newClient := func(signer transports.RequestSigner) *BuxClient {
+		client, newErr := New(
+			WithXPriv(xPrivString),
+			WithRequestSigner(signer),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithSignRequest(true),
+		)
+		require.NoError(t, newErr)
+		return client
+	}
+
+	t.Run("ecdsa", func(t *testing.T) {
+		_, err = newClient(transports.ECDSASigner{}).GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+
+		nonce := header.Get(bux.AuthHeaderNonce)
+		key, keyErr := utils.DeriveChildKeyFromHex(mustKey(t, xPub), nonce)
+		require.NoError(t, keyErr)
+		publicKey, keyErr := key.ECPubKey()
+		require.NoError(t, keyErr)
+
+		signature, decodeErr := hex.DecodeString(header.Get(bux.AuthSignature))
+		require.NoError(t, decodeErr)
+		parsed, parseErr := bec.ParseDERSignature(signature, bec.S256())
+		require.NoError(t, parseErr)
+		message := xPub + header.Get(bux.AuthHeaderHash) + nonce + header.Get(bux.AuthHeaderTime)
+		hash := sha256.Sum256([]byte(message))
+		assert.True(t, parsed.Verify(hash[:], publicKey))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		_, err = newClient(customSigner{}).GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, "custom:"+xPub, header.Get(bux.AuthSignature))
+		assert.Equal(t, xPub, header.Get(bux.AuthHeader))
+	})
+}
+
+// mustKey will parse the extended key
+func mustKey(t *testing.T, key string) *bip32.ExtendedKey {
+	extendedKey, err := bip32.NewKeyFromString(key)
+	require.NoError(t, err)
+	return extendedKey
+}
+
+// TestReadReplica will test the read replica and the stale reads detection
+func TestReadReplica(t *testing.T) {
+	const primaryURL, replicaURL = "https://primary.example.com", "https://replica.example.com"
+
+	var hosts []string
+	var onStale func()
+	replicaSynced := false
+	mux := http.NewServeMux()
+	stale := func(req *http.Request) bool {
+		hosts = append(hosts, req.URL.Host)
+		isStale := req.URL.Host == "replica.example.com" && !replicaSynced
+		if isStale && onStale != nil {
+			onStale()
+		}
+		return isStale
+	}
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		hosts = append(hosts, req.URL.Host)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"id":"`+txID+`"}`)
+	})
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		if stale(req) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"id":"`+req.URL.Query().Get("id")+`"}`)
+	})
+	mux.HandleFunc("/transactions", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if stale(req) {
+			mustWrite(w, `[{"id":"tx-old"}]`)
+			return
+		}
+		mustWrite(w, `[{"id":"tx-old"},{"id":"`+txID+`"}]`)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(string(body), "mutation") {
+			hosts = append(hosts, req.URL.Host)
+			mustWrite(w, `{"data":{"transaction":{"id":"`+txID+`"}}}`)
+		} else if stale(req) {
+			mustWrite(w, `{"data":{"transaction":null}}`)
+		} else {
+			mustWrite(w, `{"data":{"transaction":{"id":"`+txID+`"}}}`)
+		}
+	})
+	virtual := scheduler.NewVirtual(time.Now())
+	newClient := func(transportOpt ClientOps) *BuxClient {
+		hosts, replicaSynced = nil, false
+		client, err := New(
+			WithXPriv(xPrivString),
+			transportOpt,
+			WithReadReplica(replicaURL, time.Minute),
+			WithScheduler(virtual),
+		)
+		require.NoError(t, err)
+		return client
+	}
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	t.Run("http", func(t *testing.T) {
+		client := newClient(WithHTTPClient(primaryURL, httpClient))
+
+		// reads go to the replica
+		_, err := client.GetTransactions(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+
+		// writes go to the primary
+		hosts = nil
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"primary.example.com"}, hosts)
+
+		// the replica has not seen the write yet
+		hosts = nil
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+		assert.Equal(t, []string{"replica.example.com", "primary.example.com"}, hosts)
+
+		// the reads of other records are answered by the replica, even not found
+		hosts = nil
+		transactions, err := client.GetTransactions(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, transactions, 1)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+		hosts = nil
+		_, err = client.GetTransaction(context.Background(), "tx-other")
+		require.Error(t, err)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+
+		// the replica caught up
+		hosts, replicaSynced = nil, true
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+
+		// after the staleness window, the replica is trusted
+		hosts, replicaSynced = nil, false
+		virtual.Advance(2 * time.Minute)
+		transactions, err = client.GetTransactions(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, transactions, 1)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+	})
+
+	t.Run("graphql", func(t *testing.T) {
+		client := newClient(WithGraphQLClient(primaryURL+"/graphql", httpClient))
+
+		_, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"primary.example.com"}, hosts)
+
+		hosts = nil
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		assert.Equal(t, txID, transaction.ID)
+		assert.Equal(t, []string{"replica.example.com", "primary.example.com"}, hosts)
+	})
+
+	t.Run("canceled before the retry on the primary", func(t *testing.T) {
+		client := newClient(WithHTTPClient(primaryURL, httpClient))
+		_, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		onStale = cancel
+		defer func() {
+			onStale = nil
+		}()
+
+		hosts = nil
+		_, err = client.GetTransaction(ctx, txID)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []string{"replica.example.com"}, hosts)
+	})
+}
+
+// TestDefaultTimeout will test the default timeout of the requests without a deadline
+func TestDefaultTimeout(t *testing.T) {
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-hung:
+		case <-req.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(hung)
+
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTP(server.URL),
+		"graphql": WithGraphQL(server.URL + "/graphql"),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			client, err := New(
+				WithXPriv(xPrivString),
+				transportOption,
+				WithDefaultTimeout(20*time.Millisecond),
+			)
+			require.NoError(t, err)
+
+			_, err = client.DraftTransaction(context.Background(), &bux.TransactionConfig{}, nil)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			// the deadline of the context is kept
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			deadlineCtx, cancelDeadline := context.WithTimeout(ctx, time.Hour)
+			defer cancelDeadline()
+			_, err = client.GetTransaction(deadlineCtx, txID)
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
+
+// TestBulkheads will test the isolation of the operation classes
+func TestBulkheads(t *testing.T) {
+	hung := make(chan struct{})
+	reading := make(chan struct{}, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case reading <- struct{}{}:
+			select {
+			case <-hung:
+			case <-req.Context().Done():
+			}
+		default:
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTP(server.URL),
+		WithBulkheads(map[transports.OperationClass]*transports.Bulkhead{
+			transports.OperationClassRead: {MaxConcurrent: 1, MaxConnsPerHost: 1, MaxWait: 20 * time.Millisecond},
+		}),
+	)
+	require.NoError(t, err)
+
+	// a hung read holds the only slot of the reads
+	done := make(chan error)
+	go func() {
+		_, err := client.GetTransaction(context.Background(), txID)
+		done <- err
+	}()
+	<-reading
+
+	_, err = client.GetTransaction(context.Background(), txID)
+	assert.ErrorIs(t, err, transports.ErrBulkheadFull)
+
+	// the writes are not affected
+	transaction, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+	require.NoError(t, err)
+	assert.Equal(t, txID, transaction.ID)
+
+	// the slot is released with the response
+	close(hung)
+	require.NoError(t, <-done)
+	_, err = client.GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+
+	t.Run("pool not supported", func(t *testing.T) {
+		_, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithBulkheads(map[transports.OperationClass]*transports.Bulkhead{
+				transports.OperationClassWrite: {MaxConnsPerHost: 2},
+			}),
+		)
+		assert.ErrorIs(t, err, transports.ErrBulkheadPoolNotSupported)
+	})
+}
+
+// TestMiddleware will test the middleware chain
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	middleware := func(name string) transports.Middleware {
+		return func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				req.Header.Set("X-"+name, "true")
+				resp, err := next(req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+	errChaos := errors.New("chaos")
+	chaos := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Chaos") != "" {
+				return nil, errChaos
+			}
+			return next(req)
+		}
+	}
+
+	var headers http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"destination":`+destinationJSON+`}}`)
+	})
+	recorder := &testMetricsRecorder{}
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithoutCapabilities(),
+		WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithMiddleware(middleware("First"), middleware("Second")),
+		WithMiddleware(chaos),
+		WithMetrics(recorder),
+	)
+	require.NoError(t, err)
+
+	_, err = client.GetDestination(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First before", "Second before", "Second after", "First after"}, calls)
+	assert.Equal(t, "true", headers.Get("X-First"))
+	assert.Equal(t, "true", headers.Get("X-Second"))
+
+	t.Run("chaos", func(t *testing.T) {
+		client, err = New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithMiddleware(middleware("Chaos"), chaos),
+			WithMetrics(recorder),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetDestination(context.Background(), nil)
+		assert.ErrorIs(t, err, errChaos)
+		assert.ErrorIs(t, recorder.errors[len(recorder.errors)-1], errChaos)
+	})
+}
+
+// TestHeaders will test the custom static and per-request headers
+func TestHeaders(t *testing.T) {
+	var headers http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"transaction":`+transactionJSON+`}}`)
+	})
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+
+	clientHeaders := http.Header{}
+	clientHeaders.Set("x-tenant-id", "tenant-1")
+	clientHeaders.Set("X-Source", "client")
+	clientHeaders.Set(bux.AuthHeader, "overwritten")
+
+	for _, transportOpt := range []ClientOps{
+		WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+	} {
+		client, err := New(
+			WithXPriv(xPrivString),
+			transportOpt,
+			WithHeaders(clientHeaders),
+		)
+		require.NoError(t, err)
+
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1", headers.Get("X-Tenant-Id"))
+		assert.Equal(t, "client", headers.Get("X-Source"))
+		assert.Equal(t, xPubString, headers.Get(bux.AuthHeader))
+
+		ctx := transports.ContextWithHeaders(context.Background(), http.Header{"X-Source": {"request"}})
+		ctx = transports.ContextWithHeaders(ctx, http.Header{"X-Request-Id": {"request-1"}})
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1", headers.Get("X-Tenant-Id"))
+		assert.Equal(t, "request", headers.Get("X-Source"))
+		assert.Equal(t, "request-1", headers.Get("X-Request-Id"))
+	}
+
+	t.Run("subscriptions", func(t *testing.T) {
+		received := make(chan http.Header, 1)
+		server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+			received <- conn.Request().Header
+			var message map[string]interface{}
+			_ = websocket.JSON.Receive(conn, &message)
+		}))
+		defer server.Close()
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithGraphQL(server.URL+"/graphql"),
+			WithHeaders(clientHeaders),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx = transports.ContextWithHeaders(ctx, http.Header{"X-Request-Id": {"request-1"}})
+
+		_, err = client.SubscribeTransactions(ctx, nil)
+		require.NoError(t, err)
+
+		header := <-received
+		assert.Equal(t, "tenant-1", header.Get("X-Tenant-Id"))
+		assert.Equal(t, "request-1", header.Get("X-Request-Id"))
+		assert.Equal(t, xPubString, header.Get(bux.AuthHeader))
+	})
+}
+
+// TestTracing will test the OpenTelemetry tracing
+func TestTracing(t *testing.T) {
+	var traceParent string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		traceParent = req.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"destination":`+destinationJSON+`}}`)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithoutCapabilities(),
+		WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+	)
+	require.NoError(t, err)
+
+	_, err = client.GetDestination(context.Background(), nil)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "destination", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("graphql.operation.name", "destination"))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.status_code", http.StatusOK))
+	assert.Contains(t, traceParent, spans[0].SpanContext().TraceID().String())
+}
+
+// TestRunGraphQL will test running raw graphql queries
+func TestRunGraphQL(t *testing.T) {
+	var headers http.Header
+	var variables map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		headers, variables = req.Header, body.Variables
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"server_feature":{"name":"feature","enabled":true}}}`)
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+	)
+	require.NoError(t, err)
+
+	var out struct {
+		ServerFeature struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+		} `json:"server_feature"`
+	}
+	err = client.RunGraphQL(context.Background(), `query ($name: String!) { server_feature(name: $name) { name enabled } }`,
+		map[string]interface{}{"name": "feature"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "feature", out.ServerFeature.Name)
+	assert.True(t, out.ServerFeature.Enabled)
+	assert.Equal(t, map[string]interface{}{"name": "feature"}, variables)
+	assert.Equal(t, xPubString, headers.Get(bux.AuthHeader))
+
+	t.Run("http", func(t *testing.T) {
+		httpClient, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: mux}}),
+		)
+		require.NoError(t, err)
+		err = httpClient.RunGraphQL(context.Background(), `{ server_feature { name } }`, nil, &out)
+		assert.ErrorIs(t, err, transports.ErrRawGraphQLNotSupported)
+	})
+}
+
+// TestDoRawRequest will test sending raw http requests
+func TestDoRawRequest(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/utxos/unreserve", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		headers = req.Header
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"unreserved":2}`)
+	})
+	mux.HandleFunc("/utxos/reserved", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithSignRequest(true),
+	)
+	require.NoError(t, err)
+
+	var out struct {
+		Unreserved int `json:"unreserved"`
+	}
+	err = client.DoRawRequest(context.Background(), http.MethodPost, "utxos/unreserve",
+		map[string]interface{}{"draft_id": "draft"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.Unreserved)
+	assert.JSONEq(t, `{"draft_id":"draft"}`, string(body))
+	assert.NotEmpty(t, headers.Get(bux.AuthSignature))
+	assert.Equal(t, utils.Hash(string(body)), headers.Get(bux.AuthHeaderHash))
+
+	t.Run("no response", func(t *testing.T) {
+		require.NoError(t, client.DoRawRequest(context.Background(), http.MethodDelete, "/utxos/reserved", nil, nil))
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		err := client.DoRawRequest(context.Background(), http.MethodGet, "/unknown", nil, &out)
+		assert.Error(t, err)
+	})
+
+	t.Run("graphql", func(t *testing.T) {
+		graphqlClient, err := New(
+			WithXPriv(xPrivString),
+			WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		)
+		require.NoError(t, err)
+		err = graphqlClient.DoRawRequest(context.Background(), http.MethodGet, "/utxos", nil, &out)
+		assert.ErrorIs(t, err, transports.ErrRawRequestNotSupported)
+	})
+}
+
+// TestConditionalRequests will test polling with conditional requests
+func TestConditionalRequests(t *testing.T) {
+	const etag = `"v1"`
+	var notModified, full int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Tenant-ID") == "" && req.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		mustWrite(w, transactionJSON)
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithConditionalRequests(),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+	}
+	assert.Equal(t, 1, full)
+	assert.Equal(t, 2, notModified)
+
+	// the responses of other tenants are not reused
+	ctx := transports.ContextWithHeaders(context.Background(), http.Header{"X-Tenant-ID": []string{"tenant"}})
+	_, err = client.GetTransaction(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, full)
+}
+
+// TestFields will test selecting the fields of the graphql responses
+func TestFields(t *testing.T) {
+	var query string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		query = body.Query
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"transactions":[{"id":"`+txID+`","hex":"`+txHex+`"}]}}`)
+	})
+	newClient := func(opts ...ClientOps) (*BuxClient, error) {
+		return New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		}, opts...)...)
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		client, err := newClient()
+		require.NoError(t, err)
+		_, err = client.SearchTransactions(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, query, "output_value")
+	})
+
+	t.Run("client fields", func(t *testing.T) {
+		client, err := newClient(WithFields(transports.FieldsTransaction, "hex"))
+		require.NoError(t, err)
+		transactions, err := client.SearchTransactions(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, txHex, transactions[0].Hex)
+		assert.Contains(t, query, "{\nid\nhex\n}")
+		assert.NotContains(t, query, "output_value")
+	})
+
+	t.Run("context fields", func(t *testing.T) {
+		client, err := newClient(WithFields(transports.FieldsTransaction, "hex"))
+		require.NoError(t, err)
+		ctx, err := transports.ContextWithFields(context.Background(), transports.FieldsTransaction,
+			"id", "block_height", "metadata")
+		require.NoError(t, err)
+		_, err = client.SearchTransactions(ctx, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, query, "{\nid\nblock_height\nmetadata\n}")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newClient(WithFields(transports.FieldsTransaction, "hex) { injected"))
+		assert.ErrorIs(t, err, transports.ErrInvalidField)
+		_, err = transports.ContextWithFields(context.Background(), transports.FieldsTransaction, "fee_unit { satoshis")
+		assert.ErrorIs(t, err, transports.ErrInvalidField)
+		_, err = transports.ContextWithFields(context.Background(), transports.FieldsDraftTransaction,
+			"configuration { fee_unit { satoshis } }")
+		assert.NoError(t, err)
+	})
+}
+
+// TestPersistedQueries will test sending the hash of the graphql queries
+func TestPersistedQueries(t *testing.T) {
+	persisted := make(map[string]string)
+	supported := true
+	var queries []string // the query sent with every request, empty when only the hash is sent
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Query      string `json:"query"`
+			Extensions struct {
+				PersistedQuery *struct {
+					SHA256Hash string `json:"sha256Hash"`
+				} `json:"persistedQuery"`
+			} `json:"extensions"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		queries = append(queries, body.Query)
+		w.Header().Set("Content-Type", "application/json")
+
+		if persistedQuery := body.Extensions.PersistedQuery; persistedQuery != nil {
+			switch {
+			case !supported:
+				mustWrite(w, `{"errors":[{"message":"PersistedQueryNotSupported"}]}`)
+				return
+			case body.Query == "" && persisted[persistedQuery.SHA256Hash] == "":
+				mustWrite(w, `{"errors":[{"message":"PersistedQueryNotFound"}]}`)
+				return
+			case body.Query != "":
+				hash := sha256.Sum256([]byte(body.Query))
+				require.Equal(t, hex.EncodeToString(hash[:]), persistedQuery.SHA256Hash)
+				persisted[persistedQuery.SHA256Hash] = body.Query
+			}
+		}
+		mustWrite(w, `{"data":{"transaction":`+transactionJSON+`}}`)
+	})
+	recorder := &testMetricsRecorder{}
+	newClient := func() *BuxClient {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithoutCapabilities(),
+			WithGraphQLClient(serverURL+"graphql", &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithPersistedQueries(),
+			WithMetrics(recorder),
+		)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("persisted", func(t *testing.T) {
+		client := newClient()
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+		require.Len(t, queries, 2)
+		assert.Empty(t, queries[0])
+		assert.NotEmpty(t, queries[1])
+
+		// the server knows the hash now
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, queries, 3)
+		assert.Empty(t, queries[2])
+		assert.Equal(t, []string{"transaction", "transaction"}, recorder.operations)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		supported = false
+		queries = nil
+		client := newClient()
+		_, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, queries, 2)
+		assert.Empty(t, queries[0])
+		assert.NotEmpty(t, queries[1])
+
+		// the full queries are sent from now on
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, queries, 3)
+		assert.NotEmpty(t, queries[2])
+	})
+}
+
+// TestRequestManifest will test the request manifest and pinning the requests to it
+func TestRequestManifest(t *testing.T) {
+	manifest, err := transports.GenerateRequestManifest()
+	require.NoError(t, err)
+
+	// the manifest does not depend on the keys of the generator
+	regenerated, err := transports.GenerateRequestManifest()
+	require.NoError(t, err)
+	assert.Equal(t, manifest, regenerated)
+
+	assert.True(t, manifest.Contains(&transports.RequestShape{
+		Method:    http.MethodGet,
+		Path:      "/transaction?id=",
+		Transport: transports.BuxTransportHTTP,
+	}))
+	assert.True(t, manifest.Contains(&transports.RequestShape{
+		Method:    http.MethodGet,
+		Path:      transports.NotificationsPath,
+		Transport: transports.BuxTransportGraphQL,
+	}))
+
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transaction",
+		Result:    transactionJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transaction":` + transactionJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("pinned "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false, WithRequestManifest(manifest))
+
+			transaction, err := client.GetTransaction(context.Background(), txID)
+			require.NoError(t, err)
+			assert.Equal(t, txID, transaction.ID)
+		})
+
+		t.Run("not in manifest "+transportHandler.Type, func(t *testing.T) {
+			var pinned transports.RequestManifest
+			for _, shape := range manifest {
+				if !strings.Contains(shape.String(), "transaction") {
+					pinned = append(pinned, shape)
+				}
+			}
+			client := getTestBuxClient(transportHandler, false, WithRequestManifest(pinned))
+
+			_, err := client.GetTransaction(context.Background(), txID)
+			assert.ErrorIs(t, err, transports.ErrRequestNotInManifest)
+		})
+	}
+}
+
+// TestFeatureFlags will test the feature flags
+func TestFeatureFlags(t *testing.T) {
+	const featuresJSON = `{"beef":true,"subscriptions":false}`
+
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/features",
+		Result:    featuresJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"features":` + featuresJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("feature flags "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			// not fetched, everything is enabled
+			assert.True(t, client.FeatureEnabled(FeatureSubscriptions))
+
+			err := client.RefreshFeatureFlags(context.Background())
+			require.NoError(t, err)
+			assert.True(t, client.FeatureEnabled(FeatureBEEF))
+			assert.False(t, client.FeatureEnabled(FeatureSubscriptions))
+			assert.False(t, client.FeatureEnabled(FeatureAccessKeys))
+
+			_, err = client.Notifications(context.Background())
+			assert.ErrorIs(t, err, ErrFeatureDisabled)
+			assert.Contains(t, err.Error(), FeatureSubscriptions)
+
+			// the access keys are not supported
+			_, err = client.GetAccessKeys(context.Background(), nil, nil, nil)
+			assert.ErrorIs(t, err, ErrFeatureDisabled)
+			_, err = client.GetAccessKeysCount(context.Background(), nil, nil)
+			assert.ErrorIs(t, err, ErrFeatureDisabled)
+			_, err = client.ReplaceAccessKey(context.Background(), nil)
+			assert.ErrorIs(t, err, ErrFeatureDisabled)
+			assert.Contains(t, err.Error(), FeatureAccessKeys)
+		})
+	}
+
+	t.Run("fetched on creation", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, featuresJSON)
+		})
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+		)
+		require.NoError(t, err)
+		assert.False(t, client.FeatureEnabled(FeatureSubscriptions))
+	})
+}
+
+// TestSnapshot will test the warm-start snapshots
+func TestSnapshot(t *testing.T) {
+	fetched := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+		fetched[req.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"beef":true,"subscriptions":false}`)
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, req *http.Request) {
+		fetched[req.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"version":"v0.4.0","capabilities":{"beef":true,"paymail":false}}`)
+	})
+	mux.HandleFunc("/fee_quote", func(w http.ResponseWriter, req *http.Request) {
+		fetched[req.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"fee_unit":{"satoshis":1,"bytes":20},"miner":"taal"}`)
+	})
+	mux.HandleFunc("/xpub", func(w http.ResponseWriter, req *http.Request) {
+		fetched[req.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"id":"`+xPubID+`","next_external_num":7,"next_internal_num":3}`)
+	})
+	chainHeight := func(context.Context) (uint64, error) {
+		fetched["chain_height"]++
+		return 720000, nil
+	}
+	virtual := scheduler.NewVirtual(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	newClient := func(opts ...ClientOps) *BuxClient {
+		client, err := New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithScheduler(virtual),
+			WithFeatureFlags(),
+			WithReadCache(nil),
+			WithMinConfirmations(1, chainHeight),
+		}, opts...)...)
+		require.NoError(t, err)
+		return client
+	}
+
+	ctx := context.Background()
+	client := newClient()
+	_, err := client.GetFeeQuote(ctx)
+	require.NoError(t, err)
+	_, err = client.GetXPub(ctx)
+	require.NoError(t, err)
+	_, err = client.getChainHeight(ctx)
+	require.NoError(t, err)
+
+	snapshot, err := client.Snapshot()
+	require.NoError(t, err)
+	expected := map[string]int{"/features": 1, "/info": 1, "/fee_quote": 1, "/xpub": 1, "chain_height": 1}
+	require.Equal(t, expected, fetched)
+	assert.NotContains(t, string(snapshot), xPrivString)
+
+	t.Run("restored", func(t *testing.T) {
+		restored := newClient(WithSnapshot(snapshot, time.Hour))
+		assert.True(t, restored.FeatureEnabled(FeatureBEEF))
+		assert.False(t, restored.FeatureEnabled(FeatureSubscriptions))
+		assert.True(t, restored.Supports(CapabilityBEEF))
+		assert.False(t, restored.Supports(CapabilityPaymail))
+
+		feeQuote, err := restored.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "taal", feeQuote.Miner)
+		xPub, err := restored.GetXPub(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(7), xPub.NextExternalNum)
+		height, err := restored.getChainHeight(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(720000), height)
+		assert.Equal(t, expected, fetched)
+	})
+
+	t.Run("expired reads", func(t *testing.T) {
+		// the chain tip and the xPub expire before the fee quote
+		virtual.Advance(time.Minute)
+		restored := newClient(WithSnapshot(snapshot, time.Hour))
+		_, err = restored.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		_, err = restored.GetXPub(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fetched["/fee_quote"])
+		assert.Equal(t, 2, fetched["/xpub"])
+	})
+
+	t.Run("other xpub", func(t *testing.T) {
+		other, err := parseSnapshot(snapshot)
+		require.NoError(t, err)
+		other.XPubKey = "another"
+		data, err := json.Marshal(other)
+		require.NoError(t, err)
+
+		restored := newClient(WithSnapshot(data, time.Hour))
+		_, err = restored.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fetched["/fee_quote"])
+		assert.False(t, restored.Supports(CapabilityPaymail))
+	})
+
+	t.Run("outdated", func(t *testing.T) {
+		virtual.Advance(2 * time.Hour)
+		newClient(WithSnapshot(snapshot, time.Hour))
+		assert.Equal(t, 2, fetched["/features"])
+		assert.Equal(t, 2, fetched["/info"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		restored := newClient(WithSnapshot([]byte(`{"version":99}`), 0))
+		assert.Equal(t, 3, fetched["/features"])
+
+		err = restored.RestoreSnapshot([]byte(`{"version":99}`))
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+		err = restored.RestoreSnapshot([]byte(`not json`))
+		assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	})
+}
+
+// TestWithStore will test keeping the state of the client in a store
+func TestWithStore(t *testing.T) {
+	ctx := context.Background()
+	memory := store.NewMemory()
+
+	available := true
+	var lastEventIDs []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"beef":true,"subscriptions":true}`)
+	})
+	mux.HandleFunc(transports.NotificationsPath, func(w http.ResponseWriter, req *http.Request) {
+		lastEventIDs = append(lastEventIDs, req.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		mustWrite(w, "id: "+strconv.Itoa(len(lastEventIDs))+"\nevent: transaction_created\ndata: {\"xpub_id\":\""+xPubID+"\"}\n\n")
+	})
+	newClient := func() (*BuxClient, error) {
+		return New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+			WithStore(memory),
+		)
+	}
+
+	client, err := newClient()
+	require.NoError(t, err)
+	_, err = client.DeadLetters().Add("test", "payload", errors.New("failed"))
+	require.NoError(t, err)
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	notifications, err := client.Notifications(streamCtx)
+	require.NoError(t, err)
+	event := <-notifications
+	assert.Equal(t, "1", event.ID)
+	cancel()
+	for range notifications {
+		// drain until the stream is closed
+	}
+
+	t.Run("restored", func(t *testing.T) {
+		available = false
+		restored, err := newClient()
+		require.NoError(t, err)
+		assert.True(t, restored.FeatureEnabled(FeatureBEEF))
+		assert.False(t, restored.FeatureEnabled(FeatureXPubFreeze))
+		assert.Len(t, restored.DeadLetters().List(), 1)
+
+		// the stream resumes after the checkpoint
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		notifications, err := restored.Notifications(streamCtx)
+		require.NoError(t, err)
+		<-notifications
+		assert.Equal(t, "1", lastEventIDs[len(lastEventIDs)-1])
+	})
+
+	t.Run("outdated", func(t *testing.T) {
+		_, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+			WithStore(memory),
+			WithSnapshot(nil, time.Nanosecond),
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		require.NoError(t, memory.Delete(ctx, store.NamespaceCache, snapshotCacheKey))
+		_, err := newClient()
+		assert.Error(t, err)
+	})
+}
+
+// TestFreeze will test freezing xPubs and the frozen errors
+func TestFreeze(t *testing.T) {
+	frozen := false
+	var paths []string
+	mux := http.NewServeMux()
+	writeStatus := func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		status := &transports.XPubStatus{ID: xPubID, Frozen: frozen}
+		if frozen {
+			status.Reason = "compliance hold"
+		}
+		writeTestJSON(t, w, status)
+	}
+	mux.HandleFunc("/admin/xpub/freeze", func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, xPubID, body["id"])
+		assert.Equal(t, "compliance hold", body["reason"])
+		frozen = true
+		writeStatus(w, req)
+	})
+	mux.HandleFunc("/admin/xpub/unfreeze", func(w http.ResponseWriter, req *http.Request) {
+		frozen = false
+		writeStatus(w, req)
+	})
+	mux.HandleFunc("/xpub/status", writeStatus)
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if frozen {
+			w.WriteHeader(http.StatusLocked)
+			mustWrite(w, `"compliance hold"`)
+			return
+		}
+		mustWrite(w, transactionJSON)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"errors":[{"message":"xpub is frozen: compliance hold"}],"data":null}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+	newClient := func(opts ...ClientOps) *BuxClient {
+		frozen, paths = false, nil
+		client, err := New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithAdminKey(adminKeyXpub),
+		}, opts...)...)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("admin", func(t *testing.T) {
+		client := newClient()
+		assert.True(t, client.RequiresAdmin(transports.OperationAdminFreezeXPub))
+		assert.True(t, client.RequiresAdmin(transports.OperationAdminUnfreezeXPub))
+
+		status, err := client.AdminFreezeXPub(context.Background(), xPubID, "compliance hold")
+		require.NoError(t, err)
+		assert.True(t, status.Frozen)
+
+		status, err = client.GetXPubStatus(context.Background())
+		require.NoError(t, err)
+		assert.True(t, status.Frozen)
+		assert.Equal(t, "compliance hold", status.Reason)
+
+		status, err = client.AdminUnfreezeXPub(context.Background(), xPubID)
+		require.NoError(t, err)
+		assert.False(t, status.Frozen)
+	})
+
+	t.Run("missing admin key", func(t *testing.T) {
+		client, err := New(WithXPriv(xPrivString), WithHTTPClient(serverURL, httpClient))
+		require.NoError(t, err)
+		_, err = client.AdminFreezeXPub(context.Background(), xPubID, "")
+		assert.ErrorIs(t, err, transports.ErrAdminKey)
+	})
+
+	t.Run("frozen mutation", func(t *testing.T) {
+		client := newClient()
+		frozen = true
+		_, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		assert.ErrorIs(t, err, ErrAccountFrozen)
+		var frozenErr *transports.AccountFrozenError
+		require.True(t, errors.As(err, &frozenErr))
+		assert.Equal(t, "compliance hold", frozenErr.Reason)
+	})
+
+	t.Run("frozen graphql mutation", func(t *testing.T) {
+		client, err := New(WithXPriv(xPrivString), WithGraphQLClient(serverURL+"graphql", httpClient))
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		assert.ErrorIs(t, err, ErrAccountFrozen)
+	})
+
+	t.Run("frozen check", func(t *testing.T) {
+		client := newClient(WithFrozenCheck(true))
+		_, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/xpub/status", "/transactions/record"}, paths)
+
+		// the mutation is not attempted
+		frozen, paths = true, nil
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		assert.ErrorIs(t, err, ErrAccountFrozen)
+		assert.Equal(t, []string{"/xpub/status"}, paths)
+	})
+
+	t.Run("feature disabled", func(t *testing.T) {
+		mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, `{"xpub_freeze":false}`)
+		})
+		client := newClient(WithFeatureFlags(), WithFrozenCheck(true))
+		_, err := client.AdminFreezeXPub(context.Background(), xPubID, "")
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+
+		// the frozen check is skipped
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/transactions/record"}, paths)
+	})
+}
+
+// TestKeyRotation will test rotating the xPriv and replacing the access key
+func TestKeyRotation(t *testing.T) {
+	var headers []http.Header
+	var paths []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		headers = append(headers, req.Header.Clone())
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	t.Run("xpriv", func(t *testing.T) {
+		newXPriv, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		newXPub, err := bitcoin.GetExtendedPublicKey(newXPriv)
+		require.NoError(t, err)
+
+		mux.HandleFunc("/xpub/rotate", func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, xPubString, req.Header.Get(bux.AuthHeader))
+			var body struct {
+				Xpub  string            `json:"xpub"`
+				Proof map[string]string `json:"proof"`
+			}
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			assert.Equal(t, newXPub, body.Xpub)
+
+			// the proof is signed by the new xPriv
+			key, keyErr := utils.DeriveChildKeyFromHex(mustKey(t, newXPub), body.Proof[bux.AuthHeaderNonce])
+			require.NoError(t, keyErr)
+			address, addressErr := bitcoin.GetAddressFromHDKey(key)
+			require.NoError(t, addressErr)
+			message := newXPub + body.Proof[bux.AuthHeaderHash] + body.Proof[bux.AuthHeaderNonce] + body.Proof[bux.AuthHeaderTime]
+			assert.NoError(t, bitcoin.VerifyMessage(address.AddressString, body.Proof[bux.AuthSignature], message))
+
+			writeTestJSON(t, w, &bux.Xpub{ID: utils.Hash(newXPub)})
+		})
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithSignRequest(true),
+		)
+		require.NoError(t, err)
+
+		headers = nil
+		require.NoError(t, client.RotateXPriv(context.Background(), newXPriv.String()))
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, newXPub, headers[0].Get(bux.AuthHeader))
+
+		_, err = client.ReplaceAccessKey(context.Background(), nil)
+		assert.ErrorIs(t, err, bux.ErrMissingAccessKey)
+	})
+
+	accessKey, err := bitcoin.PrivateKeyFromString(accessKeyString)
+	require.NoError(t, err)
+	oldPublicKey := hex.EncodeToString(accessKey.PubKey().SerialiseCompressed())
+	newAccessKey, err := bitcoin.CreatePrivateKey()
+	require.NoError(t, err)
+	newPrivateKey := hex.EncodeToString(newAccessKey.Serialise())
+	newPublicKey := hex.EncodeToString(newAccessKey.PubKey().SerialiseCompressed())
+	revokeFails := false
+	mux.HandleFunc("/access-key", func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.Method+" "+req.URL.Query().Get("id"))
+		signedBy := req.Header.Get(bux.AuthAccessKey)
+		switch {
+		case req.Method == http.MethodPost:
+			assert.Equal(t, oldPublicKey, signedBy)
+			writeTestJSON(t, w, map[string]string{"id": "new-id", "key": newPrivateKey})
+		case revokeFails && signedBy == newPublicKey:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			writeTestJSON(t, w, map[string]string{"id": req.URL.Query().Get("id")})
+		}
+	})
+	newAccessKeyClient := func() *BuxClient {
+		client, clientErr := New(
+			WithAccessKey(accessKeyString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		)
+		require.NoError(t, clientErr)
+		headers, paths = nil, nil
+		return client
+	}
+
+	t.Run("access key", func(t *testing.T) {
+		client := newAccessKeyClient()
+		replaced, replaceErr := client.ReplaceAccessKey(context.Background(), nil)
+		require.NoError(t, replaceErr)
+		assert.Equal(t, "new-id", replaced.ID)
+		assert.Equal(t, []string{"POST ", "DELETE " + utils.Hash(oldPublicKey)}, paths)
+
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, newPublicKey, headers[0].Get(bux.AuthAccessKey))
+
+		assert.ErrorIs(t, client.RotateXPriv(context.Background(), xPrivString), bux.ErrMissingXPriv)
+	})
+
+	t.Run("access key revocation failed", func(t *testing.T) {
+		revokeFails = true
+		defer func() {
+			revokeFails = false
+		}()
+
+		client := newAccessKeyClient()
+		_, err = client.ReplaceAccessKey(context.Background(), nil)
+		require.Error(t, err)
+
+		// the new access key is revoked, the current one is kept
+		assert.Equal(t, []string{"POST ", "DELETE " + utils.Hash(oldPublicKey), "DELETE new-id"}, paths)
+		_, err = client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		require.Len(t, headers, 1)
+		assert.Equal(t, oldPublicKey, headers[0].Get(bux.AuthAccessKey))
+	})
+}
+
+// TestKeyRotationConcurrency will test rotating the keys while requests run (run with -race)
+func TestKeyRotationConcurrency(t *testing.T) {
+	newPrivateKey := func() string {
+		key, err := bitcoin.CreatePrivateKey()
+		require.NoError(t, err)
+		return hex.EncodeToString(key.Serialise())
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	mux.HandleFunc("/xpub/rotate", func(w http.ResponseWriter, req *http.Request) {
+		writeTestJSON(t, w, &bux.Xpub{ID: "rotated"})
+	})
+	mux.HandleFunc("/access-key", func(w http.ResponseWriter, req *http.Request) {
+		writeTestJSON(t, w, map[string]string{"id": req.URL.Query().Get("id"), "key": newPrivateKey()})
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case bytes.Contains(body, []byte("xpub_rotate")):
+			mustWrite(w, `{"data":{"xpub_rotate":{"id":"rotated"}}}`)
+		case bytes.Contains(body, []byte("access_key_revoke")):
+			mustWrite(w, `{"data":{"access_key_revoke":{"id":"revoked"}}}`)
+		case bytes.Contains(body, []byte("access_key")):
+			mustWrite(w, `{"data":{"access_key":{"id":"new-id","key":"`+newPrivateKey()+`"}}}`)
+		default:
+			mustWrite(w, `{"data":{"transaction":`+transactionJSON+`}}`)
+		}
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		"graphql": WithGraphQLClient(serverURL+"graphql", httpClient),
+	}
+
+	// rotate will rotate the keys of the client while requests run
+	rotate := func(t *testing.T, client *BuxClient, rotation func() error) {
+		ctx := context.Background()
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					_, err := client.GetTransaction(ctx, txID)
+					assert.NoError(t, err)
+					assert.False(t, client.IsWatchOnly())
+				}
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			require.NoError(t, rotation())
+		}
+		close(done)
+		wg.Wait()
+	}
+
+	for name, transportOption := range transportOptions {
+		t.Run("xpriv "+name, func(t *testing.T) {
+			client, err := New(WithXPriv(xPrivString), transportOption, WithSignRequest(true))
+			require.NoError(t, err)
+			rotate(t, client, func() error {
+				newXPriv, keyErr := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+				require.NoError(t, keyErr)
+				return client.RotateXPriv(context.Background(), newXPriv.String())
+			})
+		})
+
+		t.Run("access key "+name, func(t *testing.T) {
+			client, err := New(WithAccessKey(accessKeyString), transportOption)
+			require.NoError(t, err)
+			rotate(t, client, func() error {
+				_, replaceErr := client.ReplaceAccessKey(context.Background(), nil)
+				return replaceErr
+			})
+		})
+	}
+}
+
+// TestGetBalance will test the GetBalance method
+func TestGetBalance(t *testing.T) {
+	const balanceTransactionsJSON = `[` +
+		`{"id":"tx1","block_height":100,"output_value":1000,"direction":"incoming"},` +
+		`{"id":"tx2","block_height":0,"output_value":500,"direction":"incoming"},` +
+		`{"id":"tx3","block_height":0,"output_value":-300,"direction":"outgoing"}]`
+	chainHeight := func(context.Context) (uint64, error) {
+		return 101, nil
+	}
+
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transactions",
+		Result:    balanceTransactionsJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transactions":` + balanceTransactionsJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("trusted "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+			balance, err := client.GetBalance(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, int64(1200), balance.Spendable)
+			assert.Equal(t, int64(0), balance.Pending)
+		})
+
+		t.Run("cautious "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false, WithMinConfirmations(2, chainHeight))
+			balance, err := client.GetBalance(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, int64(700), balance.Spendable)
+			assert.Equal(t, int64(500), balance.Pending)
+			assert.Equal(t, int64(1200), balance.Total)
+		})
+
+	}
+
+	t.Run("missing chain height", func(t *testing.T) {
+		_, err := New(WithXPriv(xPrivString), WithHTTP(serverURL), WithMinConfirmations(2, nil))
+		assert.ErrorIs(t, err, ErrMissingChainHeight)
+	})
+
+	t.Run("unconfirmed draft inputs", func(t *testing.T) {
+		ctx := context.Background()
+		server := buxtest.NewServer()
+		defer server.Close()
+
+		xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		confirmed, err := server.Fund(xPub, 5000)
+		require.NoError(t, err)
+		require.NoError(t, server.Mine(confirmed.ID, 100))
+		unconfirmed, err := server.Fund(xPub, 50000)
+		require.NoError(t, err)
+
+		client, err := New(WithXPriv(xPriv), WithHTTP(server.URL), WithMinConfirmations(2, chainHeight))
+		require.NoError(t, err)
+
+		// the server only selects among the confirmed utxos
+		config := &bux.TransactionConfig{Outputs: []*bux.TransactionOutput{{To: testAddress, Satoshis: 1000}}}
+		draft, err := client.DraftTransaction(ctx, config, nil)
+		require.NoError(t, err)
+		require.Len(t, draft.Configuration.Inputs, 1)
+		assert.Equal(t, confirmed.ID, draft.Configuration.Inputs[0].TransactionID)
+		assert.Empty(t, config.FromUtxos)
+
+		_, err = client.DraftToRecipients(ctx, []*transports.Recipients{{To: testAddress, Satoshis: 1000}}, nil,
+			WithFromUtxos(&bux.UtxoPointer{TransactionID: unconfirmed.ID}))
+		assert.ErrorIs(t, err, ErrUnconfirmedInputs)
+
+		// nothing is drafted, nothing is reserved
+		client.minConfirmations = 3
+		_, err = client.DraftTransaction(ctx, config, nil)
+		assert.ErrorIs(t, err, ErrUnconfirmedInputs)
+		var drafts int64
+		drafts, err = client.GetDraftTransactionsCount(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), drafts)
+	})
+}
+
+// TestMigrateMetadata will test the MigrateMetadata method
+func TestMigrateMetadata(t *testing.T) {
+	pages := []string{
+		`[{"id":"tx1","metadata":{"category":"food"}},{"id":"tx2","metadata":{"category":"rent"}}]`,
+		`[{"id":"tx3","metadata":{"kind":"food"}}]`,
+	}
+	transform := func(id string, metadata bux.Metadata) (bux.Metadata, error) {
+		if category, ok := metadata["category"]; ok {
+			metadata["kind"] = category
+			delete(metadata, "category")
+		}
+		return metadata, nil
+	}
+
+	var updates []map[string]interface{}
+	transportHandler := testTransportHandler{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transactions/search",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body struct {
+					Params transports.QueryParams `json:"params"`
+				}
+				_ = json.NewDecoder(req.Body).Decode(&body)
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, pages[body.Params.Page-1])
+			},
+		}, {
+			Path: "/transaction",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body map[string]interface{}
+				_ = json.NewDecoder(req.Body).Decode(&body)
+				updates = append(updates, body)
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, `{"id":"`+body["id"].(string)+`"}`)
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}
+	selector := &MetadataSelector{Model: MetadataModelTransaction}
+
+	t.Run("dry run", func(t *testing.T) {
+		updates = nil
+		client := getTestBuxClient(transportHandler, false)
+		result, err := client.MigrateMetadata(context.Background(), selector, transform, &MigrateMetadataOptions{
+			DryRun:   true,
+			PageSize: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Scanned)
+		assert.Equal(t, 0, result.Updated)
+		require.Len(t, result.Changes, 2)
+		assert.Equal(t, bux.Metadata{"kind": "food"}, result.Changes[0].After)
+		assert.Len(t, updates, 0)
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		updates = nil
+		client := getTestBuxClient(transportHandler, false)
+		result, err := client.MigrateMetadata(context.Background(), selector, transform, &MigrateMetadataOptions{
+			Interval: time.Millisecond,
+			PageSize: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Updated)
+		require.Len(t, updates, 2)
+		assert.Equal(t, "tx2", updates[1]["id"])
+		assert.Equal(t, map[string]interface{}{"kind": "rent", "category": nil}, updates[1]["metadata"])
+	})
+
+	t.Run("virtual time", func(t *testing.T) {
+		updates = nil
+		virtual := scheduler.NewVirtual(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		client := getTestBuxClient(transportHandler, false, WithScheduler(virtual))
+
+		done := make(chan error)
+		go func() {
+			_, err := client.MigrateMetadata(context.Background(), selector, transform, &MigrateMetadataOptions{
+				Interval: time.Hour,
+				PageSize: 2,
+			})
+			done <- err
+		}()
+
+		// the second update waits for the interval
+		virtual.BlockUntil(1)
+		assert.Len(t, updates, 1)
+		virtual.Advance(time.Hour)
+		require.NoError(t, <-done)
+		assert.Len(t, updates, 2)
+	})
+
+	t.Run("destinations graphql", func(t *testing.T) {
+		destination := `{"id":"90d10acb85f37dd009238fe7ec61a1411725825c82099bd8432fcb47ad8326ce","metadata":{"test":"test value"}}`
+		client := getTestBuxClient(testTransportHandler{
+			Type:      "graphql",
+			Path:      "/graphql",
+			Result:    `{"data":{"destinations":[` + destination + `],"destination_metadata":` + destination + `}}`,
+			ClientURL: serverURL + `graphql`,
+			Client:    WithGraphQLClient,
+		}, false)
+		result, err := client.MigrateMetadata(context.Background(), &MetadataSelector{Model: MetadataModelDestination},
+			func(id string, metadata bux.Metadata) (bux.Metadata, error) {
+				metadata["migrated"] = true
+				return metadata, nil
+			}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Scanned)
+		assert.Equal(t, 1, result.Updated)
+	})
+
+	t.Run("unknown model", func(t *testing.T) {
+		client := getTestBuxClient(transportHandler, false)
+		_, err := client.MigrateMetadata(context.Background(), &MetadataSelector{}, transform, nil)
+		assert.ErrorIs(t, err, ErrUnknownMetadataModel)
+	})
+}
+
+// TestPaymentPipeline will test the payment pipeline against the fake server
+func TestPaymentPipeline(t *testing.T) {
+	ctx := context.Background()
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	senderXPriv, senderXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	receiverXPriv, receiverXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	_, err = server.Fund(senderXPub, 100000)
+	require.NoError(t, err)
+	_, err = server.RegisterXPub(receiverXPub)
+	require.NoError(t, err)
+
+	receiver, err := New(WithXPriv(receiverXPriv), WithGraphQL(server.GraphQLURL()))
+	require.NoError(t, err)
+	destination, err := receiver.GetDestination(ctx, nil)
+	require.NoError(t, err)
+
+	// runPipeline will send the intents with a new sender client
+	runPipeline := func(intents []*PaymentIntent) (*BuxClient, *PaymentPipelineResult, map[PaymentStatus]int) {
+		client, err := New(WithXPriv(senderXPriv), WithGraphQL(server.GraphQLURL()))
+		require.NoError(t, err)
+
+		stream := make(chan *PaymentIntent)
+		go func() {
+			defer close(stream)
+			for _, intent := range intents {
+				stream <- intent
+			}
+		}()
+
+		var mu sync.Mutex
+		statuses := make(map[PaymentStatus]int)
+		opts := DefaultPaymentPipelineOptions()
+		opts.Backoff = time.Millisecond
+		opts.OnStatus = func(update *PaymentUpdate) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[update.Status]++
+		}
+		result, err := client.RunPaymentPipeline(ctx, stream, opts)
+		require.NoError(t, err)
+		return client, result, statuses
+	}
+
+	t.Run("utxo aware", func(t *testing.T) {
+		// the sender has a single utxo, every draft spends the change of the previous transaction
+		intents := make([]*PaymentIntent, 0)
+		for index := 0; index < 5; index++ {
+			intents = append(intents, &PaymentIntent{
+				ID:         strconv.Itoa(index),
+				Metadata:   &bux.Metadata{"payout": strconv.Itoa(index)},
+				Recipients: []*transports.Recipients{{To: destination.Address, Satoshis: 1000}},
+			})
+		}
+
+		client, result, statuses := runPipeline(intents)
+		assert.Equal(t, &PaymentPipelineResult{Accepted: 5, Recorded: 5}, result)
+		assert.Equal(t, 5, statuses[PaymentStatusRecorded])
+		assert.Positive(t, statuses[PaymentStatusWaiting])
+		assert.Zero(t, statuses[PaymentStatusRetrying])
+		assert.Len(t, client.DeadLetters().List(), 0)
+		assert.Equal(t, uint64(5000), server.Balance(receiverXPub))
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		client, result, statuses := runPipeline([]*PaymentIntent{{
+			ID:         "invalid",
+			Recipients: []*transports.Recipients{{To: "invalid-address", Satoshis: 1000}},
+		}})
+		assert.Equal(t, &PaymentPipelineResult{Accepted: 1, Failed: 1, DeadLettered: 1}, result)
+		assert.Equal(t, 2, statuses[PaymentStatusRetrying])
+
+		letters := client.DeadLetters().List()
+		require.Len(t, letters, 1)
+		assert.Equal(t, OperationSendPayment, letters[0].Operation)
+		assert.Error(t, client.DeadLetters().Retry(ctx, letters[0].ID))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		client, err := New(WithXPriv(senderXPriv), WithGraphQL(server.GraphQLURL()))
+		require.NoError(t, err)
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		result, err := client.RunPaymentPipeline(canceledCtx, make(chan *PaymentIntent), nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, &PaymentPipelineResult{}, result)
+	})
+}
+
+// TestDraftToRecipients will test the DraftToRecipients method
+func TestDraftToRecipients(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transactions/new",
+		Result:    draftTxJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"new_transaction":` + draftTxJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("draft transaction "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			recipients := []*transports.Recipients{{
+				Satoshis: 1000,
+				To:       testAddress,
+			}}
+			metadata := &bux.Metadata{
+				"test-key": "test-value",
+			}
+
+			draft, err := client.DraftToRecipients(context.Background(), recipients, metadata)
+			assert.NoError(t, err)
+			checkDraftTransactionOutput(t, draft)
+		})
+	}
+}
+
+func checkDraftTransactionOutput(t *testing.T, draft *bux.DraftTransaction) {
+	assert.IsType(t, bux.DraftTransaction{}, *draft)
+	assert.Equal(t, xPubID, draft.XpubID)
+	assert.Equal(t, bux.DraftStatusDraft, draft.Status)
+	assert.Len(t, draft.Configuration.Inputs, 1)
+	assert.Len(t, draft.Configuration.Outputs, 2)
+	assert.Equal(t, uint64(1000), draft.Configuration.Outputs[0].Satoshis)
+	assert.Equal(t, "test-value", draft.Metadata["testkey"])
+}
+
+// TestGetDestination will test the GetDestination method
+func TestGetDestination(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/destinations",
+		Result:    destinationJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"destination":` + destinationJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("new destination "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			destination, err := client.GetDestination(context.Background(), nil)
+			assert.NoError(t, err)
+			assert.IsType(t, bux.Destination{}, *destination)
+			assert.Equal(t, "90d10acb85f37dd009238fe7ec61a1411725825c82099bd8432fcb47ad8326ce", destination.ID)
+			assert.Equal(t, xPubID, destination.XpubID)
+			assert.Equal(t, "76a9140e0eb4911d79e9b7683f268964f595b66fa3604588ac", destination.LockingScript)
+			assert.Equal(t, utils.ScriptTypePubKeyHash, destination.Type)
+			assert.Equal(t, uint32(0), destination.Chain)
+			assert.Equal(t, uint32(245), destination.Num)
+			assert.Equal(t, "12HL5RyEy3Rt6SCwxgpiFSTigem1Pzbq22", destination.Address)
+		})
+	}
+}
+
+// TestVerifyDestination will test the VerifyDestination method
+func TestVerifyDestination(t *testing.T) {
+	xPub := mustKey(t, xPubString)
+	lockingScript, err := buxutils.DeriveDestinationLockingScript(xPub, 0, 245)
+	require.NoError(t, err)
+	address, err := buxutils.DeriveDestinationAddress(xPub, 0, 245)
+	require.NoError(t, err)
+	destination := &bux.Destination{
+		ID:            buxutils.Hash(lockingScript),
+		XpubID:        buxutils.Hash(xPubString),
+		LockingScript: lockingScript,
+		Chain:         0,
+		Num:           245,
+		Address:       address,
+	}
+
+	for _, key := range []ClientOps{WithXPriv(xPrivString), WithXPub(xPubString)} {
+		client, err := New(key, WithHTTP(serverURL))
+		require.NoError(t, err)
+		require.NoError(t, client.VerifyDestination(destination))
+
+		// another chain/num, or a script of another key
+		tampered := *destination
+		tampered.Num++
+		assert.ErrorIs(t, client.VerifyDestination(&tampered), buxutils.ErrDestinationMismatch)
+		tampered = *destination
+		tampered.LockingScript = "76a9140b2b03751813e3467a28ce916cbb102d84c6eec588ac"
+		assert.ErrorIs(t, client.VerifyDestination(&tampered), buxutils.ErrDestinationMismatch)
+	}
+
+	client, err := New(WithAccessKey(accessKeyString), WithHTTP(serverURL))
+	require.NoError(t, err)
+	assert.ErrorIs(t, client.VerifyDestination(destination), bux.ErrMissingXpub)
+
+	// the private key of the destination signs for its locking script
+	privateKey, err := buxutils.DeriveDestinationPrivateKey(mustKey(t, xPrivString), 0, 245)
+	require.NoError(t, err)
+	script, err := bscript.NewP2PKHFromPubKeyEC(privateKey.PubKey())
+	require.NoError(t, err)
+	assert.Equal(t, lockingScript, script.String())
+}
+
+// TestNewDestinations will test the NewDestinations method
+func TestNewDestinations(t *testing.T) {
+	manifest, err := transports.GenerateRequestManifest()
+	require.NoError(t, err)
+
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/destinations", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeTestJSON(t, w, map[string]interface{}{"id": "destination-" + strconv.Itoa(requests)})
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Contains(t, body.Query, `destination_batch2: destination(`)
+		assert.Equal(t, "yes", body.Variables["metadata"].(map[string]interface{})["invoice"])
+		mustWrite(w, `{"data":{"destination_batch0":{"id":"destination-1"},"destination_batch1":{"id":"destination-2"},`+
+			`"destination_batch2":null},"errors":[{"message":"server error"}]}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		"graphql": WithGraphQLClient(serverURL+"graphql", httpClient),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			requests = 0
+			client, err := New(WithXPriv(xPrivString), transportOption, WithRequestManifest(manifest))
+			require.NoError(t, err)
+
+			destinations, err := client.NewDestinations(context.Background(), 3, &bux.Metadata{"invoice": "yes"})
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, transports.ErrRequestNotInManifest)
+			require.Len(t, destinations, 2)
+			assert.Equal(t, "destination-1", destinations[0].ID)
+			assert.Equal(t, "destination-2", destinations[1].ID)
+			if name == "graphql" {
+				assert.Equal(t, 1, requests)
+			} else {
+				assert.Equal(t, 3, requests)
+			}
+
+			requests = 0
+			destinations, err = client.NewDestinations(context.Background(), 0, nil)
+			require.NoError(t, err)
+			assert.Len(t, destinations, 0)
+			assert.Equal(t, 0, requests)
+		})
+	}
+}
+
+// TestGetTransaction will test the GetTransaction method
+func TestGetTransaction(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transaction",
+		Result:    transactionJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transaction":` + transactionJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transaction "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			transaction, err := client.GetTransaction(context.Background(), txID)
+			assert.NoError(t, err)
+			assert.IsType(t, bux.Transaction{}, *transaction)
+			assert.Equal(t, txID, transaction.ID)
+			assert.Equal(t, uint64(354), transaction.Fee)
+			assert.Equal(t, uint32(4), transaction.NumberOfInputs)
+			assert.Equal(t, uint32(4), transaction.NumberOfOutputs)
+			assert.Equal(t, uint64(6955), transaction.TotalValue)
+		})
+	}
+}
+
+// TestGetTransactionStatus will test the GetTransactionStatus method
+func TestGetTransactionStatus(t *testing.T) {
+	statusJSON := `{"tx_id":"` + txID + `","seen_on_network":true,"mined":false,"double_spend":true,` +
+		`"rejected_reason":"input already spent"}`
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transaction/status",
+		Result:    statusJSON,
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transaction_status":` + statusJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transaction status "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			status, err := client.GetTransactionStatus(context.Background(), txID)
+			require.NoError(t, err)
+			assert.Equal(t, txID, status.TxID)
+			assert.True(t, status.SeenOnNetwork)
+			assert.False(t, status.Mined)
+			assert.True(t, status.Rejected())
+			assert.Equal(t, "input already spent", status.RejectedReason)
+		})
+	}
+
+	t.Run("in get transaction", func(t *testing.T) {
+		var query string
+		client := getTestBuxClient(testTransportHandler{
+			Type: "graphql",
+			Queries: []*testTransportHandlerRequest{{
+				Path: "/graphql",
+				Result: func(w http.ResponseWriter, req *http.Request) {
+					body, _ := ioutil.ReadAll(req.Body)
+					query = string(body)
+					w.Header().Set("Content-Type", "application/json")
+					mustWrite(w, `{"data":{"transaction":{"id":"`+txID+`","status":"complete"}}}`)
+				},
+			}},
+			ClientURL: serverURL + `graphql`,
+			Client:    WithGraphQLClient,
+		}, false)
+
+		transaction, err := client.GetTransaction(context.Background(), txID)
+		require.NoError(t, err)
+		assert.Contains(t, query, `\nstatus\n`)
+		assert.Equal(t, bux.SyncStatusComplete, transaction.Status)
+	})
+}
+
+// TestImportTransaction will test the ImportTransaction method
+func TestImportTransaction(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transaction/import",
+		Result:    `{"id":"` + txID + `"}`,
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transaction_import":{"id":"` + txID + `"}}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("import transaction "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			transaction, err := client.ImportTransaction(context.Background(), txID, nil)
+			require.NoError(t, err)
+			assert.Equal(t, txID, transaction.ID)
+
+			_, err = client.ImportTransaction(context.Background(), "not-a-tx-id", nil)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		})
+	}
+
+	t.Run("another transaction", func(t *testing.T) {
+		client := getTestBuxClient(testTransportHandler{
+			Type:      "http",
+			Path:      "/transaction/import",
+			Result:    `{"id":"` + strings.Repeat("ab", 32) + `"}`,
+			ClientURL: strings.TrimSuffix(serverURL, "/"),
+			Client:    WithHTTPClient,
+		}, false)
+
+		_, err := client.ImportTransaction(context.Background(), txID, nil)
+		assert.ErrorIs(t, err, ErrTxIDMismatch)
+	})
+}
+
+// TestGetTransactions will test the GetTransactions method
+func TestGetTransactions(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transactions",
+		Result:    transactionsJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transactions":` + transactionsJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			conditions := map[string]interface{}{
+				"fee": map[string]interface{}{
+					"$lt": 100,
+				},
+				"total_value": map[string]interface{}{
+					"$lt": 740,
+				},
+			}
+			metadata := &bux.Metadata{
+				"run_id": "3108aa426fc7102488bb0ffd",
+			}
+			transactions, err := client.GetTransactions(context.Background(), conditions, metadata, nil)
+			assert.NoError(t, err)
+			assert.IsType(t, []*bux.Transaction{}, transactions)
+			assert.Len(t, transactions, 2)
+			assert.Equal(t, "caae6e799210dfea7591e3d55455437eb7e1091bb01463ae1e7ddf9e29c75eda", transactions[0].ID)
+			assert.Equal(t, uint64(97), transactions[0].Fee)
+			assert.Equal(t, uint64(733), transactions[0].TotalValue)
+			assert.Equal(t, "8", transactions[0].Metadata["client_id"])
+			assert.Equal(t, "5f4fd2be162769852e8bd1362bb8d815a89e137707b4985249876a7f0ebbb071", transactions[1].ID)
+			assert.Equal(t, uint64(97), transactions[1].Fee)
+			assert.Equal(t, uint64(423), transactions[1].TotalValue)
+			assert.Equal(t, "8", transactions[1].Metadata["client_id"])
+		})
+	}
+
+	t.Run("query params", func(t *testing.T) {
+		var params *transports.QueryParams
+		paramsHandlers := []testTransportHandler{{
+			Type: "http",
+			Queries: []*testTransportHandlerRequest{{
+				Path: "/transactions",
+				Result: func(w http.ResponseWriter, req *http.Request) {
+					var body struct {
+						Params *transports.QueryParams `json:"params"`
+					}
+					_ = json.NewDecoder(req.Body).Decode(&body)
+					params = body.Params
+					w.Header().Set("Content-Type", "application/json")
+					mustWrite(w, transactionsJSON)
+				},
+			}},
+			ClientURL: strings.TrimSuffix(serverURL, "/"),
+			Client:    WithHTTPClient,
+		}, {
+			Type: "graphql",
+			Queries: []*testTransportHandlerRequest{{
+				Path: "/graphql",
+				Result: func(w http.ResponseWriter, req *http.Request) {
+					var body struct {
+						Variables struct {
+							Params *transports.QueryParams `json:"params"`
+						} `json:"variables"`
+					}
+					_ = json.NewDecoder(req.Body).Decode(&body)
+					params = body.Variables.Params
+					w.Header().Set("Content-Type", "application/json")
+					mustWrite(w, `{"data":{"transactions":`+transactionsJSON+`}}`)
+				},
+			}},
+			ClientURL: serverURL + `graphql`,
+			Client:    WithGraphQLClient,
+		}}
+
+		for _, transportHandler := range paramsHandlers {
+			params = nil
+			client := getTestBuxClient(transportHandler, false)
+			transactions, err := client.GetTransactions(context.Background(), nil, nil, transports.NewestFirst(2, 10))
+			require.NoError(t, err, transportHandler.Type)
+			assert.Len(t, transactions, 2)
+			assert.Equal(t, transports.NewestFirst(2, 10), params, transportHandler.Type)
+
+			_, err = client.GetTransactions(context.Background(), nil, nil, &transports.QueryParams{Page: -1})
+			assert.ErrorIs(t, err, ErrInvalidInput)
+		}
+	})
+}
+
+// TestGetTransactionsByIDs will test the GetTransactionsByIDs method
+func TestGetTransactionsByIDs(t *testing.T) {
+	const firstID = "caae6e799210dfea7591e3d55455437eb7e1091bb01463ae1e7ddf9e29c75eda"
+	const secondID = "5f4fd2be162769852e8bd1362bb8d815a89e137707b4985249876a7f0ebbb071"
+
+	var requests []string
+	transportHandlers := []testTransportHandler{{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transactions/search",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				requests = append(requests, string(body))
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, transactionsJSON)
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type: "graphql",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/graphql",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				requests = append(requests, string(body))
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, `{"data":{"transactions":`+transactionsJSON+`}}`)
+			},
+		}},
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transactions by ids "+transportHandler.Type, func(t *testing.T) {
+			requests = nil
+			client := getTestBuxClient(transportHandler, false)
+
+			transactions, err := client.GetTransactionsByIDs(context.Background(), []string{secondID, "unknown", firstID, secondID})
+			require.NoError(t, err)
+			require.Len(t, transactions, 4)
+			assert.Equal(t, secondID, transactions[0].ID)
+			assert.Nil(t, transactions[1])
+			assert.Equal(t, firstID, transactions[2].ID)
+			assert.Equal(t, secondID, transactions[3].ID)
+
+			// a single search of the distinct ids
+			require.Len(t, requests, 1)
+			assert.Contains(t, requests[0], `"$in":["`+secondID+`","unknown","`+firstID+`"]`)
+			assert.Contains(t, requests[0], `"page_size":3`)
+
+			transactions, err = client.GetTransactionsByIDs(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Len(t, transactions, 0)
+			assert.Len(t, requests, 1)
+		})
+	}
+}
+
+// TestRecordTransaction will test the RecordTransaction method
+func TestRecordTransaction(t *testing.T) {
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/transactions/record",
+		Result:    transactionJSON,
+		ClientURL: serverURL,
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"transaction":` + transactionJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			metadata := &bux.Metadata{
+				"test-key": "test-value",
+			}
+			transaction, err := client.RecordTransaction(context.Background(), txHex, "", metadata)
+			assert.NoError(t, err)
+			assert.IsType(t, bux.Transaction{}, *transaction)
+			assert.Equal(t, txID, transaction.ID)
+		})
+	}
+}
+
+// TestRecordTransactions will test the RecordTransactions method
+func TestRecordTransactions(t *testing.T) {
+	manifest, err := transports.GenerateRequestManifest()
+	require.NoError(t, err)
+
+	// three valid transactions, the second one is rejected by the server
+	hexes, ids := make([]string, 3), make([]string, 3)
+	for index := range hexes {
+		tx, err := btv2.NewTxFromString(txHex)
+		require.NoError(t, err)
+		tx.LockTime = uint32(index + 1)
+		hexes[index], ids[index] = tx.String(), tx.TxID()
+	}
+
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		tx, err := btv2.NewTxFromString(body["hex"].(string))
+		require.NoError(t, err)
+		if tx.TxID() == ids[1] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeTestJSON(t, w, map[string]interface{}{"id": tx.TxID()})
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Contains(t, body.Query, `transaction_batch2: transaction(`)
+		assert.Contains(t, body.Variables, "metadata_batch1")
+		mustWrite(w, `{"data":{"transaction_batch0":{"id":"`+ids[0]+`"},"transaction_batch1":null,`+
+			`"transaction_batch2":{"id":"`+ids[2]+`"}},"errors":[{"message":"invalid transaction"}]}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	recordRequests := []*transports.RecordRequest{
+		{Hex: hexes[0], Metadata: &bux.Metadata{"import": "1"}},
+		{Hex: hexes[1]},
+		{Hex: hexes[2], ReferenceID: "draft-id"},
+	}
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		"graphql": WithGraphQLClient(serverURL+"graphql", httpClient),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			requests = 0
+			client, err := New(WithXPriv(xPrivString), transportOption, WithRequestManifest(manifest))
+			require.NoError(t, err)
+
+			transactions, err := client.RecordTransactions(context.Background(), recordRequests)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, transports.ErrRequestNotInManifest)
+			require.Len(t, transactions, 3)
+			assert.Equal(t, ids[0], transactions[0].ID)
+			assert.Nil(t, transactions[1])
+			assert.Equal(t, ids[2], transactions[2].ID)
+			if name == "graphql" {
+				assert.Equal(t, 1, requests)
+			} else {
+				assert.Equal(t, 3, requests)
+			}
+
+			// an invalid hex is rejected before sending any request
+			requests = 0
+			_, err = client.RecordTransactions(context.Background(), []*transports.RecordRequest{
+				{Hex: hexes[0]}, {Hex: "invalid"},
+			})
+			var inputErr *InvalidInputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, "requests[1].hex", inputErr.Field)
+			assert.Equal(t, 0, requests)
+
+			transactions, err = client.RecordTransactions(context.Background(), nil)
+			require.NoError(t, err)
+			assert.Len(t, transactions, 0)
+		})
+	}
+}
+
+// testBroadcaster is a broadcaster calling the function
+type testBroadcaster func(ctx context.Context, hex string) error
+
+func (f testBroadcaster) Broadcast(ctx context.Context, hex string) error {
+	return f(ctx, hex)
+}
+
+// TestBroadcaster will test the direct broadcast when the server can not broadcast a transaction
+func TestBroadcaster(t *testing.T) {
+	var records int
+	var broadcasted bool
+	code := transports.ErrorCodeBroadcastFailed
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		records++
+		if !broadcasted {
+			w.WriteHeader(http.StatusInternalServerError)
+			mustWrite(w, `{"code":"`+code+`","message":"failed to broadcast transaction: miner unreachable"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"id":"`+txID+`"}`)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		records++
+		w.Header().Set("Content-Type", "application/json")
+		if !broadcasted {
+			mustWrite(w, `{"data":null,"errors":[{"message":"failed to broadcast transaction","extensions":{"code":"`+code+`"}}]}`)
+			return
+		}
+		mustWrite(w, `{"data":{"transaction":{"id":"`+txID+`"}}}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+	broadcaster := testBroadcaster(func(ctx context.Context, hex string) error {
+		assert.Equal(t, txHex, hex)
+		broadcasted = true
+		return nil
+	})
+
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		"graphql": WithGraphQLClient(serverURL+"graphql", httpClient),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			records, broadcasted = 0, false
+			client, err := New(WithXPriv(xPrivString), transportOption, WithBroadcaster(broadcaster))
+			require.NoError(t, err)
+
+			transaction, err := client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+			require.NoError(t, err)
+			assert.Equal(t, txID, transaction.ID)
+			assert.True(t, broadcasted)
+			assert.Equal(t, 2, records)
+		})
+	}
+
+	t.Run("no broadcaster", func(t *testing.T) {
+		records, broadcasted = 0, false
+		client, err := New(WithXPriv(xPrivString), transportOptions["http"])
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		assert.ErrorIs(t, err, transports.ErrBroadcastFailed)
+		assert.Contains(t, err.Error(), "miner unreachable")
+		assert.Equal(t, 1, records)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		records, broadcasted = 0, false
+		client, err := New(WithXPriv(xPrivString), transportOptions["http"], WithBroadcaster(
+			testBroadcaster(func(ctx context.Context, hex string) error {
+				return &broadcast.RejectedError{Reason: "missing inputs"}
+			}),
+		))
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+		assert.ErrorIs(t, err, broadcast.ErrRejected)
+		assert.Contains(t, err.Error(), "could not broadcast")
+		assert.Equal(t, 1, records)
+	})
+
+	t.Run("other server errors", func(t *testing.T) {
+		records, broadcasted, code = 0, false, "error-internal"
+		defer func() {
+			code = transports.ErrorCodeBroadcastFailed
+		}()
+		for name, transportOption := range transportOptions {
+			client, err := New(WithXPriv(xPrivString), transportOption, WithBroadcaster(broadcaster))
+			require.NoError(t, err)
+
+			// the message mentions the broadcast, but the code is not the failed broadcast
+			_, err = client.RecordTransaction(context.Background(), txHex, "draft-id", nil)
+			require.Error(t, err, name)
+			assert.NotErrorIs(t, err, transports.ErrBroadcastFailed, name)
+			assert.False(t, broadcasted, name)
+		}
+	})
+}
+
+// TestTransactionBEEF will test the methods GetTransactionBEEF() and RecordTransactionBEEF()
+func TestTransactionBEEF(t *testing.T) {
+	tx, err := btv2.NewTxFromString(txHex)
+	require.NoError(t, err)
+	transaction := &beef.BEEF{Transactions: []*beef.Transaction{{Tx: tx}}}
+	encoded, err := transaction.Hex()
+	require.NoError(t, err)
+
+	var recordBody map[string]interface{}
+	var features string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/features", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, features)
+	})
+	mux.HandleFunc("/transaction/beef", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("id") == "missing" {
+			writeTestJSON(t, w, nil)
+			return
+		}
+		writeTestJSON(t, w, &transports.TransactionBEEF{TxID: req.URL.Query().Get("id"), BEEF: encoded})
+	})
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&recordBody))
+		writeTestJSON(t, w, map[string]interface{}{"id": txID})
+	})
+	newClient := func() *BuxClient {
+		client, clientErr := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithFeatureFlags(),
+		)
+		require.NoError(t, clientErr)
+		return client
+	}
+
+	features = `{"beef":true}`
+	client := newClient()
+
+	t.Run("get", func(t *testing.T) {
+		fetched, getErr := client.GetTransactionBEEF(context.Background(), txID)
+		require.NoError(t, getErr)
+		assert.Equal(t, txID, fetched.Subject().Tx.TxID())
+
+		_, getErr = client.GetTransactionBEEF(context.Background(), "another-transaction")
+		assert.ErrorIs(t, getErr, beef.ErrInvalidBEEF)
+
+		// the server returned no transaction
+		_, getErr = client.GetTransactionBEEF(context.Background(), "missing")
+		assert.ErrorIs(t, getErr, transports.ErrMissingBEEF)
+	})
+
+	t.Run("record", func(t *testing.T) {
+		recorded, recordErr := client.RecordTransactionBEEF(
+			context.Background(), transaction, "draft-id", &bux.Metadata{"key": "value"},
+		)
+		require.NoError(t, recordErr)
+		assert.Equal(t, txID, recorded.ID)
+		assert.Equal(t, encoded, recordBody["beef"])
+		assert.Equal(t, "draft-id", recordBody["reference_id"])
+
+		_, recordErr = client.RecordTransactionBEEF(context.Background(), &beef.BEEF{}, "", nil)
+		assert.ErrorIs(t, recordErr, ErrInvalidInput)
+	})
+
+	t.Run("feature disabled", func(t *testing.T) {
+		features = `{"beef":false}`
+		disabled := newClient()
+		_, err = disabled.GetTransactionBEEF(context.Background(), txID)
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+		_, err = disabled.RecordTransactionBEEF(context.Background(), transaction, "", nil)
+		assert.ErrorIs(t, err, ErrFeatureDisabled)
+	})
+}
+
+// TestSendToRecipients will test the SendToRecipients method
+func TestSendToRecipients(t *testing.T) {
+	var recorded string
+	transportHandlers := []testTransportHandler{{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transactions/new",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, draftTxJSON)
+			},
+		}, {
+			Path: "/transactions/record",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body struct {
+					Hex string `json:"hex"`
+				}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				recorded = body.Hex
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, recordedTransactionJSON(t, body.Hex))
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type: "graphql",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/graphql",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body struct {
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				result := `{"data":{"new_transaction":` + draftTxJSON + `}}`
+				if hex, ok := body.Variables["hex"].(string); ok {
+					recorded = hex
+					result = `{"data":{"transaction":` + recordedTransactionJSON(t, hex) + `}}`
+				}
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, result)
+			},
+		}},
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get transactions "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			recipients := []*transports.Recipients{{
+				To:       testAddress,
+				Satoshis: 1234,
+			}, {
+				To:       testAddress2,
+				Satoshis: 4321,
+			}}
+			metadata := &bux.Metadata{
+				"test-key": "test-value",
+			}
+			transaction, err := client.SendToRecipients(context.Background(), recipients, metadata)
+			require.NoError(t, err)
+			assert.IsType(t, bux.Transaction{}, *transaction)
+			tx, err := btv2.NewTxFromString(recorded)
+			require.NoError(t, err)
+			assert.Equal(t, tx.TxID(), transaction.ID)
+		})
+	}
+}
+
+// TestSendContext will test the send flow with a send context
+func TestSendContext(t *testing.T) {
+	var draft bux.DraftTransaction
+	require.NoError(t, json.Unmarshal([]byte(draftTxJSON), &draft))
+	draft.Configuration.Outputs[0].PaymailP4 = &bux.PaymailP4{Alias: "alice", Domain: "bux.org", ReferenceID: "p2p-ref"}
+
+	var draftMetadata, recordBody map[string]interface{}
+	client := getTestBuxClient(testTransportHandler{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transactions/new",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body map[string]interface{}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				draftMetadata, _ = body["metadata"].(map[string]interface{})
+				writeTestJSON(t, w, &draft)
+			},
+		}, {
+			Path: "/transactions/record",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&recordBody))
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, recordedTransactionJSON(t, recordBody["hex"].(string)))
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, false)
+	recipients := []*transports.Recipients{{To: "alice@bux.org", Satoshis: 1000}}
+
+	send := NewSendContext(&bux.Metadata{"order_id": "order-1"})
+	drafted, err := client.DraftSend(context.Background(), send, recipients)
+	require.NoError(t, err)
+	assert.Equal(t, draft.ID, send.DraftID)
+	assert.Equal(t, "p2p-ref", send.ReferenceID("alice@bux.org"))
+	assert.Equal(t, "order-1", draftMetadata["order_id"])
+
+	require.NoError(t, client.SignSend(send, drafted))
+	assert.NotEmpty(t, send.Hex)
+	assert.Len(t, send.TxID, 64)
+
+	_, err = client.RecordSend(context.Background(), send)
+	require.NoError(t, err)
+	assert.Equal(t, send.DraftID, recordBody["reference_id"])
+	assert.Equal(t, send.Hex, recordBody["hex"])
+	assert.Equal(t, "order-1", recordBody["metadata"].(map[string]interface{})["order_id"])
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err = client.DraftSend(context.Background(), send, recipients)
+		assert.ErrorIs(t, err, ErrSendContextMismatch)
+
+		other := draft
+		other.ID = "another-draft"
+		assert.ErrorIs(t, client.SignSend(send, &other), ErrSendContextMismatch)
+		assert.ErrorIs(t, send.SetDraft(&other), ErrSendContextMismatch)
+
+		_, err = client.RecordSend(context.Background(), NewSendContext(nil))
+		assert.ErrorIs(t, err, ErrSendContextMismatch)
+	})
+
+	t.Run("set draft", func(t *testing.T) {
+		created := NewSendContext(nil)
+		require.NoError(t, created.SetDraft(&draft))
+		assert.Equal(t, draft.ID, created.DraftID)
+		assert.ErrorIs(t, created.SetDraft(nil), bux.ErrDraftNotFound)
+	})
+}
+
+// TestTransactionLimits will test the transaction limits and SendToRecipientsInBatches()
+func TestTransactionLimits(t *testing.T) {
+	var baseDraft bux.DraftTransaction
+	require.NoError(t, json.Unmarshal([]byte(draftTxJSON), &baseDraft))
+	baseTx, err := btv2.NewTxFromString(baseDraft.Hex)
+	require.NoError(t, err)
+
+	// the server drafts a P2PKH or OP_RETURN output per recipient, and the change outputs
+	var changeOutputs, drafts, requestedChange int
+	var recorded []*btv2.Tx
+	transportHandler := testTransportHandler{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transactions/new",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				drafts++
+				var body struct {
+					Config bux.TransactionConfig `json:"config"`
+				}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				requestedChange = body.Config.ChangeNumberOfDestinations
+
+				tx := btv2.NewTx()
+				tx.Inputs = baseTx.Inputs
+				for _, output := range body.Config.Outputs {
+					if output.OpReturn != nil {
+						parts := make([][]byte, 0)
+						for _, part := range output.OpReturn.StringParts {
+							parts = append(parts, []byte(part))
+						}
+						require.NoError(t, tx.AddOpReturnPartsOutput(parts))
+					} else {
+						require.NoError(t, tx.PayToAddress(output.To, output.Satoshis))
+					}
+				}
+				for index := 0; index < changeOutputs; index++ {
+					require.NoError(t, tx.PayToAddress(testAddress2, 1000))
+				}
+
+				draft := baseDraft
+				draft.Hex = tx.String()
+				w.Header().Set("Content-Type", "application/json")
+				require.NoError(t, json.NewEncoder(w).Encode(&draft))
+			},
+		}, {
+			Path: "/transactions/record",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				var body map[string]interface{}
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				tx, txErr := btv2.NewTxFromString(body["hex"].(string))
+				require.NoError(t, txErr)
+				recorded = append(recorded, tx)
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, `{"id":"`+tx.TxID()+`"}`)
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}
+	recipients := func(count int) []*transports.Recipients {
+		list := make([]*transports.Recipients, 0, count)
+		for index := 0; index < count; index++ {
+			list = append(list, &transports.Recipients{To: testAddress, Satoshis: uint64(1000 + index)})
+		}
+		return list
+	}
+	reset := func(change int) {
+		changeOutputs, drafts, recorded = change, 0, nil
+	}
+
+	t.Run("too many recipients", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOutputs: 2}))
+		_, err = client.DraftToRecipients(context.Background(), recipients(3), nil)
+		assert.ErrorIs(t, err, ErrTooManyOutputs)
+		assert.Equal(t, 0, drafts)
+	})
+
+	t.Run("too many outputs with change", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOutputs: 2}))
+		_, err = client.SendToRecipients(context.Background(), recipients(2), nil)
+		assert.ErrorIs(t, err, ErrTooManyOutputs)
+		assert.Contains(t, err.Error(), "5 outputs, the maximum is 2")
+		assert.Equal(t, 0, drafts)
+	})
+
+	t.Run("change fitted to the limits", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOutputs: 4}))
+		_, err = client.DraftToRecipients(context.Background(), recipients(2), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, requestedChange)
+
+		_, err = client.DraftTransaction(context.Background(), &bux.TransactionConfig{
+			ChangeNumberOfDestinations: 5,
+			Outputs:                    []*bux.TransactionOutput{{To: testAddress, Satoshis: 1000}},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, requestedChange)
+	})
+
+	t.Run("op_return too large", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOpReturnSize: 20}))
+		_, err = client.SendToRecipients(context.Background(), []*transports.Recipients{{
+			OpReturn: &bux.OpReturn{StringParts: []string{"a message that is too long"}},
+		}}, nil)
+		assert.ErrorIs(t, err, ErrOpReturnTooLarge)
+		assert.Contains(t, err.Error(), "output 0 is 29 bytes")
+		assert.Equal(t, 0, drafts)
+	})
+
+	t.Run("transaction too large", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxTxSize: 200}))
+		_, err = client.SendToRecipients(context.Background(), recipients(3), nil)
+		assert.ErrorIs(t, err, ErrTransactionTooLarge)
+		assert.Equal(t, 0, drafts)
+	})
+
+	t.Run("batches by outputs", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOutputs: 3}))
+		transactions, sendErr := client.SendToRecipientsInBatches(context.Background(), recipients(5), nil)
+		require.NoError(t, sendErr)
+		require.Len(t, transactions, 3)
+		require.Len(t, recorded, 3)
+		assert.Equal(t, []int{3, 3, 2}, []int{recorded[0].OutputCount(), recorded[1].OutputCount(), recorded[2].OutputCount()})
+		assert.Equal(t, uint64(1004), recorded[2].Outputs[0].Satoshis)
+	})
+
+	t.Run("batches by size", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxTxSize: 400}))
+		transactions, sendErr := client.SendToRecipientsInBatches(context.Background(), recipients(5), nil)
+		require.NoError(t, sendErr)
+		require.Len(t, transactions, 2)
+		for _, tx := range recorded {
+			assert.LessOrEqual(t, tx.Size(), 400)
+		}
+	})
+
+	t.Run("drafts exceeding the limits are not drafted again", func(t *testing.T) {
+		// the server adds more change outputs than requested
+		reset(2)
+		client := getTestBuxClient(transportHandler, false, WithTransactionLimits(TransactionLimits{MaxOutputs: 3}))
+		transactions, sendErr := client.SendToRecipientsInBatches(context.Background(), recipients(4), nil)
+		assert.ErrorIs(t, sendErr, ErrTooManyOutputs)
+		assert.Empty(t, transactions)
+		assert.Equal(t, 1, drafts)
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		reset(1)
+		client := getTestBuxClient(transportHandler, false)
+		transactions, sendErr := client.SendToRecipientsInBatches(context.Background(), recipients(5), nil)
+		require.NoError(t, sendErr)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, 6, recorded[0].OutputCount())
+	})
+}
+
+// TestVerifyServerContract will test the checks of the server contract
+func TestVerifyServerContract(t *testing.T) {
+	ctx := context.Background()
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	_, err = server.Fund(xPub, 10000)
+	require.NoError(t, err)
+
+	t.Run("compatible", func(t *testing.T) {
+		for _, opt := range []ClientOps{WithHTTP(server.URL), WithGraphQL(server.GraphQLURL())} {
+			client, err := New(WithXPriv(xPriv), opt)
+			require.NoError(t, err)
+
+			report, err := client.VerifyServerContract(ctx)
+			require.NoError(t, err)
+			assert.True(t, report.Compatible)
+			assert.Len(t, report.Checks, 4)
+			assert.Len(t, report.Failed(), 0)
+		}
+	})
+
+	t.Run("renamed field", func(t *testing.T) {
+		// renameHex renames the hex of the transactions in the responses, as a breaking server upgrade would
+		renameHex := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				resp, err := next(req)
+				if err != nil || req.URL.Path != "/transactions/search" {
+					return resp, err
+				}
+				body, err := ioutil.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				resp.Body = ioutil.NopCloser(bytes.NewReader(bytes.ReplaceAll(body, []byte(`"hex":`), []byte(`"raw_hex":`))))
+				resp.ContentLength = -1
+				return resp, nil
+			}
+		}
+		client, err := New(WithXPriv(xPriv), WithHTTP(server.URL), WithMiddleware(renameHex))
+		require.NoError(t, err)
+
+		report, err := client.VerifyServerContract(ctx)
+		require.ErrorIs(t, err, ErrIncompatibleServer)
+		assert.False(t, report.Compatible)
+		require.Len(t, report.Failed(), 1)
+		assert.Equal(t, "transactions", report.Failed()[0].Name)
+		assert.Equal(t, []string{"hex: missing"}, report.Failed()[0].Problems)
+	})
+
+	t.Run("failed operation", func(t *testing.T) {
+		client, err := New(WithXPriv(xPriv), WithHTTP(server.URL+"/v2"))
+		require.NoError(t, err)
+
+		report, err := client.VerifyServerContract(ctx)
+		require.ErrorIs(t, err, ErrIncompatibleServer)
+		assert.Len(t, report.Failed(), 4)
+		assert.NotEmpty(t, report.Checks[0].Error)
+	})
+}
+
+// TestFinalizeTransaction will test the FinalizeTransaction method
+func TestFinalizeTransaction(t *testing.T) {
+
+	t.Run("mock", func(t *testing.T) {
+		httpclient := &http.Client{Transport: localRoundTripper{handler: http.NewServeMux()}}
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(serverURL, httpclient),
+		)
+		require.NoError(t, err)
+
+		var draft *bux.DraftTransaction
+		err = json.Unmarshal([]byte(draftTxJSON), &draft)
+		require.NoError(t, err)
 
 		var draftHex string
 		draftHex, err = client.FinalizeTransaction(draft)
@@ -557,7 +4393,31 @@ func TestFinalizeTransaction(t *testing.T) {
 		assert.Len(t, txDraft.Inputs, 1)
 		assert.Len(t, txDraft.GetInputs(), 1)
 		assert.Len(t, txDraft.GetOutputs(), 2)
-		// todo check the signature
+
+		// the inputs are signed over their previous satoshis, with the signature hash as computed
+		var signed *btv2.Tx
+		signed, err = btv2.NewTxFromString(draftHex)
+		require.NoError(t, err)
+		for index, input := range draft.Configuration.Inputs {
+			signed.Inputs[index].PreviousTxSatoshis = input.Satoshis
+			signed.Inputs[index].PreviousTxScript, err = bscript.NewFromHexString(input.ScriptPubKey)
+			require.NoError(t, err)
+			var sigHash []byte
+			sigHash, err = signed.CalcInputSignatureHash(uint32(index), sighash.AllForkID)
+			require.NoError(t, err)
+
+			var parts [][]byte
+			parts, err = bscript.DecodeParts(*signed.Inputs[index].UnlockingScript)
+			require.NoError(t, err)
+			require.Len(t, parts, 2)
+			var signature *bec.Signature
+			signature, err = bec.ParseDERSignature(parts[0][:len(parts[0])-1], bec.S256())
+			require.NoError(t, err)
+			var publicKey *bec.PublicKey
+			publicKey, err = bec.ParsePubKey(parts[1], bec.S256())
+			require.NoError(t, err)
+			assert.True(t, signature.Verify(sigHash, publicKey))
+		}
 	})
 }
 
@@ -585,7 +4445,7 @@ func TestGetTransport(t *testing.T) {
 	})
 }
 
-func getTestBuxClient(transportHandler testTransportHandler, adminKey bool) *BuxClient {
+func getTestBuxClient(transportHandler testTransportHandler, adminKey bool, clientOpts ...ClientOps) *BuxClient {
 	mux := http.NewServeMux()
 	if transportHandler.Queries != nil {
 		for _, query := range transportHandler.Queries {
@@ -606,8 +4466,464 @@ func getTestBuxClient(transportHandler testTransportHandler, adminKey bool) *Bux
 	if adminKey {
 		opts = append(opts, WithAdminKey(adminKeyXpub))
 	}
+	opts = append(opts, clientOpts...)
 
 	client, _ := New(opts...)
 
 	return client
 }
+
+// TestCapabilities will test gating the operations with the capabilities advertised by the server
+func TestCapabilities(t *testing.T) {
+	const capabilitiesJSON = `{"beef":false,"graphql":false,"new_transaction":true,"paymail":false}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"version":"v0.4.0","capabilities":`+capabilitiesJSON+`}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	// an older server does not advertise its capabilities
+	olderClient := &http.Client{Transport: localRoundTripper{handler: http.NewServeMux()}}
+
+	t.Run("not fetched", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithoutCapabilities(),
+		)
+		require.NoError(t, err)
+		assert.True(t, client.Supports(CapabilityPaymail))
+	})
+
+	t.Run("not advertised", func(t *testing.T) {
+		client, err := New(WithXPriv(xPrivString), WithHTTPClient(strings.TrimSuffix(serverURL, "/"), olderClient))
+		require.NoError(t, err)
+		assert.True(t, client.Supports(CapabilityPaymail))
+
+		_, err = New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), olderClient),
+			WithCapabilities(),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("gated operations", func(t *testing.T) {
+		client, err := New(WithXPriv(xPrivString), WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient))
+		require.NoError(t, err)
+		assert.True(t, client.Supports(CapabilityNewTransaction))
+		assert.False(t, client.Supports(CapabilityBEEF))
+
+		_, err = client.GetTransactionBEEF(context.Background(), txID)
+		require.ErrorIs(t, err, ErrNotSupportedByServer)
+		var capabilityErr *CapabilityError
+		require.ErrorAs(t, err, &capabilityErr)
+		assert.Equal(t, CapabilityBEEF, capabilityErr.Capability)
+		assert.Contains(t, err.Error(), "GetTransactionBEEF")
+
+		err = client.AdminDeletePaymail(context.Background(), "alice@example.com")
+		require.ErrorIs(t, err, ErrNotSupportedByServer)
+	})
+
+	t.Run("graphql not served", func(t *testing.T) {
+		// the capabilities are fetched over plain HTTP, the server has no graphql endpoint
+		_, err := New(WithXPriv(xPrivString), WithGraphQLClient(serverURL+"graphql", httpClient))
+		require.ErrorIs(t, err, ErrNotSupportedByServer)
+		var capabilityErr *CapabilityError
+		require.ErrorAs(t, err, &capabilityErr)
+		assert.Equal(t, CapabilityGraphQL, capabilityErr.Capability)
+	})
+}
+
+// failoverNetwork is the network of the failover tests: the requests of the hosts that are down fail to
+// connect, the hosts dropping the connections drop them once the request is sent, the failing hosts
+// answer 503 and the others are served by the handler
+type failoverNetwork struct {
+	down    map[string]bool
+	dropped map[string]bool
+	failing map[string]bool
+	handler http.Handler
+	hosts   []string
+	mu      sync.Mutex
+}
+
+func (n *failoverNetwork) RoundTrip(req *http.Request) (*http.Response, error) {
+	n.mu.Lock()
+	n.hosts = append(n.hosts, req.URL.Host+req.URL.Path)
+	down, dropped, failing := n.down[req.URL.Host], n.dropped[req.URL.Host], n.failing[req.URL.Host]
+	n.mu.Unlock()
+
+	switch {
+	case down:
+		return nil, errors.New("connection refused")
+	case dropped:
+		if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.WroteHeaders != nil {
+			trace.WroteHeaders()
+		}
+		return nil, errors.New("connection reset by peer")
+	case failing:
+		return &http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Header:     http.Header{},
+			Request:    req,
+			StatusCode: http.StatusServiceUnavailable,
+		}, nil
+	}
+	return localRoundTripper{handler: n.handler}.RoundTrip(req)
+}
+
+// set will set the state of the host in the states (down, dropped or failing)
+func (n *failoverNetwork) set(states map[string]bool, host string, state bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	states[host] = state
+}
+
+// sent will return the requests sent since the last call
+func (n *failoverNetwork) sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	hosts := n.hosts
+	n.hosts = nil
+	return hosts
+}
+
+// TestFailover will test failing over to the next servers
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		switch strings.TrimPrefix(req.URL.Path, "/v1") {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/transaction":
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, transactionJSON)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	newNetwork := func() *failoverNetwork {
+		return &failoverNetwork{
+			down:    make(map[string]bool),
+			dropped: make(map[string]bool),
+			failing: make(map[string]bool),
+			handler: mux,
+		}
+	}
+	newClient := func(network *failoverNetwork, virtual *scheduler.Virtual) *BuxClient {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: network}),
+			WithScheduler(virtual),
+			WithFailover([]string{"https://backup1.example.com/v1", "https://backup2.example.com/v1/"}, time.Minute),
+			WithoutCapabilities(),
+		)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("reads", func(t *testing.T) {
+		network := newNetwork()
+		virtual := scheduler.NewVirtual(time.Now())
+		client := newClient(network, virtual)
+		getTransaction := func() []string {
+			_, err := client.GetTransaction(ctx, txID)
+			require.NoError(t, err)
+			return network.sent()
+		}
+
+		// the primary is used while healthy
+		assert.Equal(t, []string{"example.com/transaction"}, getTransaction())
+
+		// connection error on the primary, server error on the first backup
+		network.set(network.down, "example.com", true)
+		network.set(network.failing, "backup1.example.com", true)
+		assert.Equal(t, []string{
+			"example.com/transaction", "backup1.example.com/v1/transaction", "backup2.example.com/v1/transaction",
+		}, getTransaction())
+
+		// the failed servers are skipped until the recovery interval
+		network.set(network.down, "example.com", false)
+		assert.Equal(t, []string{"backup2.example.com/v1/transaction"}, getTransaction())
+
+		// the failed servers are checked in the background, the primary recovered
+		virtual.Advance(time.Minute)
+		assert.Contains(t, getTransaction(), "backup2.example.com/v1/transaction")
+		assert.Eventually(t, func() bool {
+			hosts := getTransaction()
+			return hosts[len(hosts)-1] == "example.com/transaction"
+		}, time.Second, time.Millisecond)
+
+		// all the servers failed: they are all tried, in order
+		network.set(network.down, "example.com", true)
+		network.set(network.down, "backup2.example.com", true)
+		_, err := client.GetTransaction(ctx, txID)
+		require.Error(t, err)
+		network.set(network.down, "example.com", false)
+		network.sent()
+		assert.Equal(t, []string{"example.com/transaction"}, getTransaction())
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		network := newNetwork()
+		client := newClient(network, scheduler.NewVirtual(time.Now()))
+		updateTransaction := func() ([]string, error) {
+			_, err := client.UpdateTransactionMetadata(ctx, txID, &bux.Metadata{"note": "rent"})
+			return network.sent(), err
+		}
+
+		// not sent: sent to the next server
+		network.set(network.down, "example.com", true)
+		hosts, err := updateTransaction()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"example.com/transaction", "backup1.example.com/v1/transaction"}, hosts)
+
+		// maybe received by the server: never sent again
+		network.set(network.dropped, "backup1.example.com", true)
+		hosts, err = updateTransaction()
+		require.Error(t, err)
+		assert.Equal(t, []string{"backup1.example.com/v1/transaction"}, hosts)
+
+		// a server error is returned
+		network.set(network.failing, "backup2.example.com", true)
+		hosts, err = updateTransaction()
+		require.Error(t, err)
+		assert.Equal(t, []string{"backup2.example.com/v1/transaction"}, hosts)
+	})
+}
+
+// TestLoadBalancing will test balancing the reads between the servers
+func TestLoadBalancing(t *testing.T) {
+	ctx := context.Background()
+	virtual := scheduler.NewVirtual(time.Now())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Host == "example.com" {
+			virtual.Advance(100 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	newClient := func(strategy transports.LoadBalancing) (*BuxClient, *failoverNetwork, error) {
+		network := &failoverNetwork{handler: mux}
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: network}),
+			WithScheduler(virtual),
+			WithFailover([]string{"https://backup1.example.com", "https://backup2.example.com"}, time.Minute),
+			WithLoadBalancing(strategy),
+			WithoutCapabilities(),
+		)
+		return client, network, err
+	}
+	reads := func(client *BuxClient, network *failoverNetwork, count int) []string {
+		network.hosts = nil
+		for i := 0; i < count; i++ {
+			_, err := client.GetTransaction(ctx, txID)
+			require.NoError(t, err)
+		}
+		return network.hosts
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		client, network, err := newClient(transports.LoadBalancingRoundRobin)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"example.com/transaction", "backup1.example.com/transaction", "backup2.example.com/transaction",
+			"example.com/transaction",
+		}, reads(client, network, 4))
+
+		// the mutations are pinned to the primary
+		network.hosts = nil
+		for i := 0; i < 2; i++ {
+			_, err = client.UpdateTransactionMetadata(ctx, txID, &bux.Metadata{"note": "rent"})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"example.com/transaction", "example.com/transaction"}, network.hosts)
+	})
+
+	t.Run("least latency", func(t *testing.T) {
+		client, network, err := newClient(transports.LoadBalancingLeastLatency)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"example.com/transaction", "backup1.example.com/transaction", "backup2.example.com/transaction",
+			"backup1.example.com/transaction",
+		}, reads(client, network, 4))
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, _, err := newClient("random")
+		require.ErrorIs(t, err, transports.ErrInvalidLoadBalancing)
+	})
+}
+
+// TestUserAgent will test the User-Agent and API version headers
+func TestUserAgent(t *testing.T) {
+	var header http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	newClient := func(opts ...ClientOps) *BuxClient {
+		client, err := New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithHeaders(http.Header{"User-Agent": []string{"custom header"}}),
+		}, opts...)...)
+		require.NoError(t, err)
+		return client
+	}
+
+	_, err := newClient().GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Equal(t, transports.DefaultUserAgent(), header.Get("User-Agent"))
+	assert.Contains(t, header.Get("User-Agent"), transports.Version)
+	assert.Contains(t, header.Get("User-Agent"), runtime.Version())
+	assert.Equal(t, transports.APIVersion, header.Get(transports.APIVersionHeader))
+
+	_, err = newClient(WithUserAgent("wallet/2.1"), WithAPIVersion("v2")).GetTransaction(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Equal(t, "wallet/2.1", header.Get("User-Agent"))
+	assert.Equal(t, "v2", header.Get(transports.APIVersionHeader))
+}
+
+// TestDiscovery will test sending the requests to the discovered server
+func TestDiscovery(t *testing.T) {
+	ctx := context.Background()
+	var hosts []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		hosts = append(hosts, req.URL.Host+req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	discovered := []string{"http://bux-0.bux.svc:3003/v1"}
+	var discoverErr error
+	var discoveries int
+	virtual := scheduler.NewVirtual(time.Now())
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient("http://bux-server", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithScheduler(virtual),
+		WithoutCapabilities(),
+		WithDiscovery(func(context.Context) ([]string, error) {
+			discoveries++
+			return discovered, discoverErr
+		}, time.Minute),
+	)
+	require.NoError(t, err)
+	getTransaction := func() {
+		_, err := client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+	}
+
+	getTransaction()
+	getTransaction()
+	assert.Equal(t, []string{"bux-0.bux.svc:3003/v1/transaction", "bux-0.bux.svc:3003/v1/transaction"}, hosts)
+	assert.Equal(t, 1, discoveries)
+
+	// refreshed after the interval
+	discovered = []string{"http://bux-1.bux.svc:3003/v1", "http://bux-0.bux.svc:3003/v1"}
+	virtual.Advance(time.Minute)
+	getTransaction()
+	assert.Equal(t, "bux-1.bux.svc:3003/v1/transaction", hosts[2])
+
+	// the last discovered server is kept when the discovery fails
+	discoverErr = errors.New("dns failure")
+	virtual.Advance(time.Minute)
+	getTransaction()
+	getTransaction()
+	assert.Equal(t, "bux-1.bux.svc:3003/v1/transaction", hosts[4])
+	assert.Equal(t, 3, discoveries)
+
+	// nothing discovered yet
+	client, err = New(
+		WithXPriv(xPrivString),
+		WithHTTPClient("http://bux-server", &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithDiscovery(func(context.Context) ([]string, error) {
+			return nil, nil
+		}, 0),
+	)
+	require.NoError(t, err)
+	_, err = client.GetTransaction(ctx, txID)
+	require.ErrorIs(t, err, transports.ErrNoServerDiscovered)
+}
+
+// TestDeprecations will test reporting the deprecation warnings of the server
+func TestDeprecations(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Sunset", "Wed, 01 Jan 2025 00:00:00 GMT")
+		w.Header().Set("Link", `<https://docs.example.com/v2>; rel="deprecation"`)
+		w.Header().Add("Warning", `299 bux "the field \"hex\" will be removed"`)
+		w.Header().Add("Warning", `199 bux "miscellaneous"`)
+		mustWrite(w, transactionJSON)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"transaction":`+transactionJSON+`},"extensions":{"deprecations":[`+
+			`{"message":"transaction.hex is deprecated, use transaction.beef","sunset":"2025-01-01"}]}}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	var deprecations []*transports.Deprecation
+	handler := WithDeprecationHandler(func(_ context.Context, deprecation *transports.Deprecation) {
+		deprecations = append(deprecations, deprecation)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		deprecations = nil
+		client, err := New(WithXPriv(xPrivString), WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient), handler)
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = client.GetTransaction(ctx, txID)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []*transports.Deprecation{{
+			Link:      "https://docs.example.com/v2",
+			Message:   "deprecated",
+			Operation: "GET /transaction",
+			Sunset:    "Wed, 01 Jan 2025 00:00:00 GMT",
+		}, {
+			Message:   `the field "hex" will be removed`,
+			Operation: "GET /transaction",
+			Sunset:    "Wed, 01 Jan 2025 00:00:00 GMT",
+		}}, deprecations)
+	})
+
+	t.Run("graphql extensions", func(t *testing.T) {
+		deprecations = nil
+		client, err := New(WithXPriv(xPrivString), WithGraphQLClient(serverURL+"graphql", httpClient), handler)
+		require.NoError(t, err)
+		transaction, err := client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, txID, transaction.ID)
+		require.Len(t, deprecations, 1)
+		assert.Equal(t, "transaction.hex is deprecated, use transaction.beef", deprecations[0].Message)
+		assert.Equal(t, "2025-01-01", deprecations[0].Sunset)
+		assert.Equal(t, "transaction", deprecations[0].Operation)
+	})
+
+	t.Run("logged by default", func(t *testing.T) {
+		var logs bytes.Buffer
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+			WithLogger(logging.NewTextLogger(&logs)),
+		)
+		require.NoError(t, err)
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Contains(t, logs.String(), `bux server deprecation: the field "hex" will be removed`)
+	})
+}
@@ -0,0 +1,206 @@
+package buxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/BuxOrg/bux"
+	"github.com/pkg/errors"
+)
+
+// graphqlOperation matches the type and the (first) field of a graphql operation
+var graphqlOperation = regexp.MustCompile(`^\s*(query|mutation)[^{]*{\s*(\w+)`)
+
+// graphqlArgument matches the string arguments of a graphql field, inline (ex: txId:"...") or
+// variables (ex: txId: $txId)
+var graphqlArgument = regexp.MustCompile(`(\w+)\s*:\s*(?:"([^"]*)"|\$(\w+))`)
+
+// graphqlRequest is the body of a graphql request
+type graphqlRequest struct {
+	Query     string                     `json:"query"`
+	Variables map[string]json.RawMessage `json:"variables"`
+}
+
+// graphqlError is an error of a graphql response
+type graphqlError struct {
+	Extensions *graphqlErrorExtensions `json:"extensions,omitempty"`
+	Message    string                  `json:"message"`
+}
+
+// graphqlErrorExtensions are the extensions of a graphql error, its code
+type graphqlErrorExtensions struct {
+	Code string `json:"code"`
+}
+
+// handleGraphQL will serve the graphql operations of the bux client, the operations are matched by
+// their field, the selection sets are ignored (the full records are returned)
+func (s *Server) handleGraphQL(w http.ResponseWriter, req *http.Request) {
+	var body graphqlRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	operation := graphqlOperation.FindStringSubmatch(body.Query)
+	if operation == nil {
+		writeJSON(w, http.StatusBadRequest, "invalid graphql operation")
+		return
+	}
+	arguments := make(map[string]string)
+	for _, argument := range graphqlArgument.FindAllStringSubmatch(body.Query, -1) {
+		if argument[3] == "" {
+			arguments[argument[1]] = argument[2]
+			continue
+		}
+		var value string
+		if err := decodeVariable(body.Variables, argument[3], &value); err == nil {
+			arguments[argument[1]] = value
+		}
+	}
+
+	kind, field := operation[1], operation[2]
+	if kind == "query" && field == "__typename" {
+		// health check, without authentication
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{field: "Query"},
+		})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	xPubID, err := s.authenticate(req.Header, kind == "mutation" && field == "xpub")
+	var result interface{}
+	if err == nil {
+		result, err = s.resolve(kind, field, xPubID, arguments, body.Variables)
+	}
+	if err != nil {
+		graphqlErr := &graphqlError{Message: err.Error()}
+		if code := errorCode(err); code != "" {
+			graphqlErr.Extensions = &graphqlErrorExtensions{Code: code}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"errors": []*graphqlError{graphqlErr},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{field: result},
+	})
+}
+
+// resolve will execute the graphql operation
+func (s *Server) resolve(kind, field, xPubID string, arguments map[string]string,
+	variables map[string]json.RawMessage) (interface{}, error) {
+
+	var metadata bux.Metadata
+	if err := decodeVariable(variables, "metadata", &metadata); err != nil {
+		return nil, err
+	}
+
+	switch kind + " " + field {
+	case "mutation xpub":
+		registeredID, err := s.registerXPub(arguments["xpub"])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"id": registeredID}, nil
+	case "mutation destination":
+		return s.newDestination(xPubID, chainExternal, metadata)
+	case "query destination":
+		return s.destinationOf(xPubID, arguments["address"])
+	case "query destinations":
+		var search searchRequest
+		if err := decodeSearch(variables, &search); err != nil {
+			return nil, err
+		}
+		destinations, err := s.destinationsOf(xPubID, search.Conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(destinations, search.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(destinations), search.Params)
+		return destinations[start:end], nil
+	case "query utxos":
+		var search searchRequest
+		if err := decodeSearch(variables, &search); err != nil {
+			return nil, err
+		}
+		utxos, err := s.utxosOf(xPubID, search.Conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(utxos, search.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(utxos), search.Params)
+		return utxos[start:end], nil
+	case "mutation new_transaction":
+		config := new(bux.TransactionConfig)
+		if err := decodeVariable(variables, "transactionConfig", config); err != nil {
+			return nil, err
+		}
+		if err := decodeVariable(variables, "outputs", &config.Outputs); err != nil {
+			return nil, err
+		}
+		return s.newDraftTransaction(xPubID, config, metadata)
+	case "mutation transaction":
+		transaction, err := s.recordTransaction(xPubID, arguments["hex"], arguments["draft_id"], metadata)
+		if err != nil {
+			return nil, err
+		}
+		return transactionFor(transaction, xPubID), nil
+	case "query transaction":
+		return s.transactionOf(xPubID, arguments["txId"])
+	case "query transactions":
+		var search searchRequest
+		if err := decodeSearch(variables, &search); err != nil {
+			return nil, err
+		}
+		transactions, err := s.transactionsOf(xPubID, search.Conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(transactions, search.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(transactions), search.Params)
+		return transactions[start:end], nil
+	case "query destinations_count", "query draft_transactions_count", "query transactions_count", "query utxos_count":
+		var search searchRequest
+		if err := decodeSearch(variables, &search); err != nil {
+			return nil, err
+		}
+		return s.countOf(strings.TrimSuffix(field, "_count"), xPubID, search.Conditions, metadata)
+	case "query features":
+		return s.features, nil
+	case "query server_info":
+		return s.serverInfo(), nil
+	case "query xpub":
+		return s.xPubOf(xPubID)
+	case "query xpub_status":
+		return xPubStatus(xPubID), nil
+	}
+	return nil, errors.Wrap(ErrUnsupported, kind+" "+field)
+}
+
+// decodeSearch will decode the conditions and paging variables of a search
+func decodeSearch(variables map[string]json.RawMessage, search *searchRequest) error {
+	if err := decodeVariable(variables, "conditions", &search.Conditions); err != nil {
+		return err
+	}
+	return decodeVariable(variables, "params", &search.Params)
+}
+
+// decodeVariable will decode the variable, if it is set
+func decodeVariable(variables map[string]json.RawMessage, name string, value interface{}) error {
+	raw, ok := variables[name]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(raw, value)
+}
@@ -0,0 +1,396 @@
+package buxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/pkg/errors"
+)
+
+// queryParams are the paging and sorting parameters of the search requests
+type queryParams struct {
+	OrderByField  string `json:"order_by_field"`
+	Page          int    `json:"page"`
+	PageSize      int    `json:"page_size"`
+	SortDirection string `json:"sort_direction"`
+}
+
+// searchRequest is the body of the search requests
+type searchRequest struct {
+	Conditions map[string]interface{} `json:"conditions"`
+	Metadata   bux.Metadata           `json:"metadata"`
+	Params     *queryParams           `json:"params"`
+}
+
+// registerRoutes will register the REST routes of the fake server
+func (s *Server) registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/xpubs", s.handle(http.MethodPost, true, func(_ string, req *http.Request) (interface{}, error) {
+		var body struct {
+			Key string `json:"key"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		xPubID, err := s.registerXPub(body.Key)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"id": xPubID}, nil
+	}))
+
+	mux.HandleFunc("/destinations", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		var body struct {
+			Metadata bux.Metadata `json:"metadata"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		return s.newDestination(xPubID, chainExternal, body.Metadata)
+	}))
+
+	mux.HandleFunc("/destination", s.handle(http.MethodGet, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		return s.destinationOf(xPubID, req.URL.Query().Get("address"))
+	}))
+
+	mux.HandleFunc("/destinations/search", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		var body searchRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		destinations, err := s.destinationsOf(xPubID, body.Conditions, body.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(destinations, body.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(destinations), body.Params)
+		return destinations[start:end], nil
+	}))
+
+	mux.HandleFunc("/utxos/search", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		var body searchRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		utxos, err := s.utxosOf(xPubID, body.Conditions, body.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(utxos, body.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(utxos), body.Params)
+		return utxos[start:end], nil
+	}))
+
+	mux.HandleFunc("/transactions/new", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		var body struct {
+			Config   *bux.TransactionConfig `json:"config"`
+			Metadata bux.Metadata           `json:"metadata"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		return s.newDraftTransaction(xPubID, body.Config, body.Metadata)
+	}))
+
+	mux.HandleFunc("/transactions/record", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		var body struct {
+			Hex         string       `json:"hex"`
+			Metadata    bux.Metadata `json:"metadata"`
+			ReferenceID string       `json:"reference_id"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		transaction, err := s.recordTransaction(xPubID, body.Hex, body.ReferenceID, body.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		return transactionFor(transaction, xPubID), nil
+	}))
+
+	mux.HandleFunc("/transaction", s.handle(http.MethodGet, false, func(xPubID string, req *http.Request) (interface{}, error) {
+		return s.transactionOf(xPubID, req.URL.Query().Get("id"))
+	}))
+
+	search := func(xPubID string, req *http.Request) (interface{}, error) {
+		var body searchRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		transactions, err := s.transactionsOf(xPubID, body.Conditions, body.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if err = sortOf(transactions, body.Params); err != nil {
+			return nil, err
+		}
+		start, end := pageOf(len(transactions), body.Params)
+		return transactions[start:end], nil
+	}
+	mux.HandleFunc("/transactions", s.handle(http.MethodPost, false, search))
+	mux.HandleFunc("/transactions/search", s.handle(http.MethodPost, false, search))
+
+	for _, model := range []string{"destinations", "draft_transactions", "transactions", "utxos"} {
+		model := model
+		mux.HandleFunc("/"+strings.ReplaceAll(model, "_", "-")+"/count", s.handle(http.MethodPost, false, func(xPubID string, req *http.Request) (interface{}, error) {
+			var body searchRequest
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+			return s.countOf(model, xPubID, body.Conditions, body.Metadata)
+		}))
+	}
+
+	mux.HandleFunc("/features", s.handle(http.MethodGet, false, func(string, *http.Request) (interface{}, error) {
+		return s.features, nil
+	}))
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/info", s.handle(http.MethodGet, false, func(string, *http.Request) (interface{}, error) {
+		return s.serverInfo(), nil
+	}))
+
+	mux.HandleFunc("/xpub", s.handle(http.MethodGet, false, func(xPubID string, _ *http.Request) (interface{}, error) {
+		return s.xPubOf(xPubID)
+	}))
+
+	mux.HandleFunc("/xpub/status", s.handle(http.MethodGet, false, func(xPubID string, _ *http.Request) (interface{}, error) {
+		return xPubStatus(xPubID), nil
+	}))
+}
+
+// handle will return the handler of a REST route: the request is authenticated (as an admin or
+// as a registered xPub), the store is locked while handling it and the result is returned as json
+func (s *Server) handle(method string, admin bool, handler func(xPubID string, req *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != method {
+			writeJSON(w, http.StatusMethodNotAllowed, req.Method+" is not allowed")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		xPubID, err := s.authenticate(req.Header, admin)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		result, err := handler(xPubID, req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// authenticate will return the ID of the xPub of the request, the signature is verified when the
+// request is signed, admin requests must be signed (by any key) and other xPubs must be registered
+func (s *Server) authenticate(header http.Header, admin bool) (string, error) {
+	rawXPub := header.Get(bux.AuthHeader)
+	if rawXPub == "" {
+		if header.Get(bux.AuthAccessKey) != "" {
+			return "", errors.Wrap(ErrUnsupported, "access keys")
+		}
+		return "", bux.ErrMissingAuthHeader
+	}
+
+	if header.Get(bux.AuthSignature) != "" {
+		if err := verifySignature(rawXPub, header); err != nil {
+			return "", err
+		}
+	} else if admin {
+		return "", ErrInvalidSignature
+	}
+
+	xPubID := buxutils.Hash(rawXPub)
+	if _, ok := s.xPubs[xPubID]; !ok && !admin {
+		return "", ErrUnknownXPub
+	}
+	return xPubID, nil
+}
+
+// verifySignature will verify the signature headers of the xPub (the hash of the body is not verified)
+func verifySignature(rawXPub string, header http.Header) error {
+	nonce := header.Get(bux.AuthHeaderNonce)
+	if _, err := strconv.ParseInt(header.Get(bux.AuthHeaderTime), 10, 64); err != nil {
+		return ErrInvalidSignature
+	}
+
+	key, err := bitcoin.GetHDKeyFromExtendedPublicKey(rawXPub)
+	if err != nil {
+		return err
+	}
+	if key, err = buxutils.DeriveChildKeyFromHex(key, nonce); err != nil {
+		return ErrInvalidSignature
+	}
+	address, err := bitcoin.GetAddressFromHDKey(key)
+	if err != nil {
+		return err
+	}
+
+	message := rawXPub + header.Get(bux.AuthHeaderHash) + nonce + header.Get(bux.AuthHeaderTime)
+	if err = bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// transactionOf will return the transaction, as seen by the xPub
+func (s *Server) transactionOf(xPubID, txID string) (*bux.Transaction, error) {
+	for _, transaction := range s.transactions {
+		if _, ok := transaction.XpubOutputValue[xPubID]; ok && transaction.ID == txID {
+			return transactionFor(transaction, xPubID), nil
+		}
+	}
+	return nil, errors.Wrap(ErrNotFound, "transaction")
+}
+
+// xPubOf will return the xPub, its balance (its unspent utxos) and next derivation numbers
+func (s *Server) xPubOf(xPubID string) (*bux.Xpub, error) {
+	utxos, err := s.utxosOf(xPubID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	xPub := &bux.Xpub{
+		ID:              xPubID,
+		NextExternalNum: s.nums[xPubID+"/"+strconv.FormatUint(uint64(chainExternal), 10)],
+		NextInternalNum: s.nums[xPubID+"/"+strconv.FormatUint(uint64(chainInternal), 10)],
+	}
+	for _, utxo := range utxos {
+		xPub.CurrentBalance += utxo.Satoshis
+	}
+	return xPub, nil
+}
+
+// destinationOf will return the destination of the xPub with the address
+func (s *Server) destinationOf(xPubID, address string) (*bux.Destination, error) {
+	for _, destination := range s.destinations {
+		if destination.XpubID == xPubID && destination.Address == address {
+			return destination, nil
+		}
+	}
+	return nil, errors.Wrap(ErrNotFound, "destination")
+}
+
+// xPubStatus will return the status of the xPub (xPubs are never frozen on the fake server)
+func xPubStatus(xPubID string) map[string]interface{} {
+	return map[string]interface{}{"id": xPubID, "frozen": false}
+}
+
+// pageOf will return the bounds of the page of the query params
+func pageOf(count int, params *queryParams) (int, int) {
+	if params == nil {
+		return 0, count
+	}
+	return page(count, params.Page, params.PageSize)
+}
+
+// sortOf will sort the records (a slice of models) by the (json) field of the query params, in their
+// sort direction (ascending by default)
+func sortOf(records interface{}, params *queryParams) error {
+	if params == nil || params.OrderByField == "" {
+		return nil
+	}
+	slice := reflect.ValueOf(records)
+	values := make([]interface{}, slice.Len())
+	for index := range values {
+		data, err := json.Marshal(slice.Index(index).Interface())
+		if err != nil {
+			return err
+		}
+		var fields map[string]interface{}
+		if err = json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		values[index] = fields[params.OrderByField]
+	}
+
+	descending := params.SortDirection == "desc"
+	sort.Stable(&recordSorter{swap: reflect.Swapper(records), values: values, less: func(i, j int) bool {
+		if descending {
+			return lessValue(values[j], values[i])
+		}
+		return lessValue(values[i], values[j])
+	}})
+	return nil
+}
+
+// recordSorter sorts the records of a slice by their values
+type recordSorter struct {
+	less   func(i, j int) bool
+	swap   func(i, j int)
+	values []interface{}
+}
+
+func (r *recordSorter) Len() int           { return len(r.values) }
+func (r *recordSorter) Less(i, j int) bool { return r.less(i, j) }
+func (r *recordSorter) Swap(i, j int) {
+	r.values[i], r.values[j] = r.values[j], r.values[i]
+	r.swap(i, j)
+}
+
+// lessValue will compare two json values: numbers by value, anything else by its text
+func lessValue(a, b interface{}) bool {
+	numberA, isNumberA := a.(float64)
+	numberB, isNumberB := b.(float64)
+	if isNumberA && isNumberB {
+		return numberA < numberB
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// errorStatus will return the status code of the error
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUnknownXPub), errors.Is(err, ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrUnsupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// errorCode will return the code of the error sent to the clients, empty if the error has none
+func errorCode(err error) string {
+	if errors.Is(err, ErrDraftNotFound) {
+		return transports.ErrorCodeDraftNotFound
+	}
+	return ""
+}
+
+// writeError will write the error response: the json error with its code, or its message
+func writeError(w http.ResponseWriter, err error) {
+	code := errorCode(err)
+	if code == "" {
+		writeJSON(w, errorStatus(err), err.Error())
+		return
+	}
+	writeJSON(w, errorStatus(err), map[string]string{"code": code, "message": err.Error()})
+}
+
+// writeJSON will write the value as the json response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
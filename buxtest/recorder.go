@@ -0,0 +1,260 @@
+package buxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// RecorderMode is the mode of a recorder
+type RecorderMode string
+
+// Modes of the recorders
+const (
+	// ModeRecord sends the requests to the server, and records them with their responses
+	ModeRecord RecorderMode = "record"
+
+	// ModeReplay replays the recorded responses, without sending the requests
+	ModeReplay RecorderMode = "replay"
+)
+
+// normalizedValue replaces the values of the headers that change with every request
+const normalizedValue = "<normalized>"
+
+// normalizedHeaders are the headers that change with every request (signature nonce and time)
+var normalizedHeaders = []string{
+	bux.AuthHeaderNonce,
+	bux.AuthHeaderTime,
+	bux.AuthSignature,
+}
+
+// authHeaders are the authentication headers, they are not matched when replaying so that the
+// fixtures replay whatever the keys and the signing of the client
+var authHeaders = []string{
+	bux.AuthAccessKey,
+	bux.AuthHeader,
+	bux.AuthHeaderHash,
+	bux.AuthHeaderNonce,
+	bux.AuthHeaderTime,
+	bux.AuthSignature,
+}
+
+// ErrInteractionNotFound is when replaying a request that was not recorded (or already replayed)
+var ErrInteractionNotFound = errors.New("no recorded interaction for the request")
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request, the url is relative to the server (path and query)
+type RecordedRequest struct {
+	Body   string      `json:"body,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+}
+
+// RecordedResponse is a recorded response
+type RecordedResponse struct {
+	Body       string      `json:"body,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	StatusCode int         `json:"status_code"`
+}
+
+// Recorder records the requests to the bux server and their responses in a fixture file, and
+// replays them deterministically in tests (see Middleware), the values of the signature headers
+// that change with every request (nonce, time and signature) are normalized, and the
+// authentication headers are not matched when replaying
+type Recorder struct {
+	interactions []*Interaction
+	mode         RecorderMode
+	mu           sync.Mutex
+	path         string
+	replayed     map[int]bool
+}
+
+// NewRecorder will return a new recorder of the fixture file, the file is loaded when replaying
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		interactions: make([]*Interaction, 0),
+		mode:         mode,
+		path:         path,
+		replayed:     make(map[int]bool),
+	}
+	if mode != ModeReplay {
+		return r, nil
+	}
+
+	data, err := ioutil.ReadFile(path) //nolint:gosec // the fixture files are chosen by the tests
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Middleware will return the middleware recording or replaying the requests (see buxclient.WithMiddleware)
+func (r *Recorder) Middleware() transports.Middleware {
+	return func(next transports.RoundTripFunc) transports.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			request, err := recordRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			if r.mode == ModeReplay {
+				return r.replay(req, request)
+			}
+
+			resp, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+			var body []byte
+			if body, err = ioutil.ReadAll(resp.Body); err != nil {
+				return nil, err
+			}
+			_ = resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.interactions = append(r.interactions, &Interaction{
+				Request: request,
+				Response: &RecordedResponse{
+					Body:       string(body),
+					Header:     resp.Header.Clone(),
+					StatusCode: resp.StatusCode,
+				},
+			})
+			return resp, nil
+		}
+	}
+}
+
+// Save will write the recorded interactions to the fixture file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0o600)
+}
+
+// Interactions will return the recorded (or loaded) interactions
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+// replay will return the response of the first interaction matching the request that was not
+// replayed yet, identical requests are replayed in the recorded order
+func (r *Recorder) replay(req *http.Request, request *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for index, interaction := range r.interactions {
+		if r.replayed[index] || !interaction.Request.matches(request) {
+			continue
+		}
+		r.replayed[index] = true
+		return &http.Response{
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Header:        interaction.Response.Header.Clone(),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+			Status:        strconv.Itoa(interaction.Response.StatusCode) + " " + http.StatusText(interaction.Response.StatusCode),
+			StatusCode:    interaction.Response.StatusCode,
+		}, nil
+	}
+	return nil, errors.Wrap(ErrInteractionNotFound, request.Method+" "+request.URL)
+}
+
+// matches will return whether the recorded request matches the request (method, url, headers
+// and body), the authentication headers are not matched
+func (r *RecordedRequest) matches(request *RecordedRequest) bool {
+	if r.Method != request.Method || r.URL != request.URL || r.Body != request.Body {
+		return false
+	}
+	recorded, header := matchedHeader(r.Header), matchedHeader(request.Header)
+	for name, values := range recorded {
+		if !equalValues(values, header.Values(name)) {
+			return false
+		}
+	}
+	return len(recorded) == len(header)
+}
+
+// matchedHeader will return the headers matched when replaying (without the authentication headers)
+func matchedHeader(header http.Header) http.Header {
+	matched := header.Clone()
+	if matched == nil {
+		matched = make(http.Header)
+	}
+	for _, name := range authHeaders {
+		matched.Del(name)
+	}
+	return matched
+}
+
+// recordRequest will return the recorded request, with its signature headers normalized
+func recordRequest(req *http.Request) (*RecordedRequest, error) {
+	request := &RecordedRequest{
+		Header: req.Header.Clone(),
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+	}
+	for _, name := range normalizedHeaders {
+		if request.Header.Get(name) != "" {
+			request.Header.Set(name, normalizedValue)
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(body)
+
+		var data []byte
+		if data, err = ioutil.ReadAll(body); err != nil {
+			return nil, err
+		}
+		request.Body = string(data)
+	}
+	return request, nil
+}
+
+// equalValues will return whether the header values are equal
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package buxtest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecorder will test recording requests to the fake server and replaying them
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	receiverXPriv, receiverXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	fixture := filepath.Join(t.TempDir(), "send.json")
+
+	server := buxtest.NewServer()
+	_, err = server.Fund(xPub, 10000)
+	require.NoError(t, err)
+	_, err = server.RegisterXPub(receiverXPub)
+	require.NoError(t, err)
+	receiver, err := buxclient.New(buxclient.WithXPriv(receiverXPriv), buxclient.WithHTTP(server.URL))
+	require.NoError(t, err)
+	destination, err := receiver.GetDestination(ctx, nil)
+	require.NoError(t, err)
+
+	// send runs the same flow when recording and replaying
+	send := func(recorder *buxtest.Recorder, signRequest bool) (*bux.Transaction, error) {
+		client, err := buxclient.New(
+			buxclient.WithXPriv(xPriv),
+			buxclient.WithSignRequest(signRequest),
+			buxclient.WithHTTP(server.URL),
+			buxclient.WithMiddleware(recorder.Middleware()),
+			buxclient.WithoutCapabilities(),
+		)
+		require.NoError(t, err)
+		return client.SendToRecipients(ctx, []*transports.Recipients{{
+			To:       destination.Address,
+			Satoshis: 1000,
+		}}, nil)
+	}
+
+	recorder, err := buxtest.NewRecorder(fixture, buxtest.ModeRecord)
+	require.NoError(t, err)
+	recorded, err := send(recorder, true)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Save())
+	require.Len(t, recorder.Interactions(), 2)
+	server.Close()
+
+	t.Run("replay", func(t *testing.T) {
+		replayer, err := buxtest.NewRecorder(fixture, buxtest.ModeReplay)
+		require.NoError(t, err)
+		assert.Equal(t, "<normalized>", replayer.Interactions()[0].Request.Header.Get(bux.AuthSignature))
+
+		replayed, err := send(replayer, true)
+		require.NoError(t, err)
+		assert.Equal(t, recorded.ID, replayed.ID)
+
+		// every interaction is replayed once
+		_, err = send(replayer, true)
+		assert.ErrorIs(t, err, buxtest.ErrInteractionNotFound)
+	})
+
+	t.Run("authentication headers not matched", func(t *testing.T) {
+		replayer, err := buxtest.NewRecorder(fixture, buxtest.ModeReplay)
+		require.NoError(t, err)
+
+		replayed, err := send(replayer, false)
+		require.NoError(t, err)
+		assert.Equal(t, recorded.ID, replayed.ID)
+	})
+}
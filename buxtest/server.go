@@ -0,0 +1,276 @@
+// Package buxtest contains an in-process fake bux server for integration tests: an httptest server
+// speaking the REST and GraphQL contracts of the bux client with an in-memory store, so the
+// draft, sign and record flows can run end-to-end in CI without a live bux server
+//
+// The fake is not a bux server: the request signatures are verified but not their body hash, there
+// is no broadcasting or mining, and only the xPub, destination and transaction APIs are served
+package buxtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+)
+
+// GraphQLPath is the path of the graphql endpoint of the fake server
+const GraphQLPath = "/graphql"
+
+// Version is the version of the fake server, returned by the server info
+const Version = "buxtest"
+
+// Chains of the destinations of an xPub
+const (
+	chainExternal uint32 = 0
+	chainInternal uint32 = 1
+)
+
+// ErrUnknownXPub is when the xPub of the request is not registered on the fake server
+var ErrUnknownXPub = errors.New("xpub is not registered")
+
+// ErrInvalidSignature is when the signature of the request is invalid
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// ErrNotFound is when the requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrDraftNotFound is when the draft of a recorded transaction does not exist, was completed or
+// expired (the error of the bux server, sent with the transports.ErrorCodeDraftNotFound code)
+var ErrDraftNotFound = errors.Wrap(ErrNotFound, bux.ErrDraftNotFound.Error())
+
+// ErrUnsupported is when the request is not supported by the fake server
+var ErrUnsupported = errors.New("not supported by the fake bux server")
+
+// Server is an in-process fake bux server, with an in-memory store
+type Server struct {
+	*httptest.Server
+
+	destinations map[string]*bux.Destination // by locking script
+	drafts       map[string]*bux.DraftTransaction
+	features     map[string]bool
+	mu           sync.Mutex
+	nums         map[string]uint32 // next num of the chains of the xPubs
+	spent        map[string]string // spending transaction of the outputs
+	transactions []*bux.Transaction
+	utxos        map[string]*utxo
+	xPubs        map[string]*bip32.ExtendedKey // by xPub ID
+}
+
+// utxo is an unspent output of a registered xPub
+type utxo struct {
+	destination *bux.Destination
+	draftID     string // reserved by a draft
+	outputIndex uint32
+	satoshis    uint64
+	txID        string
+}
+
+// NewServer will start a new fake bux server, close it at the end of the test
+//
+// Use the URL of the server with the http transport, and GraphQLURL with the graphql transport
+func NewServer() *Server {
+	s := &Server{
+		destinations: make(map[string]*bux.Destination),
+		drafts:       make(map[string]*bux.DraftTransaction),
+		features:     make(map[string]bool),
+		nums:         make(map[string]uint32),
+		spent:        make(map[string]string),
+		utxos:        make(map[string]*utxo),
+		xPubs:        make(map[string]*bip32.ExtendedKey),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(GraphQLPath, s.handleGraphQL)
+	s.registerRoutes(mux)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// GraphQLURL will return the url of the graphql endpoint
+func (s *Server) GraphQLURL() string {
+	return s.URL + GraphQLPath
+}
+
+// SetFeatureFlags will set the feature flags returned by the server (none are enabled by default)
+func (s *Server) SetFeatureFlags(features map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.features = make(map[string]bool, len(features))
+	for name, enabled := range features {
+		s.features[name] = enabled
+	}
+}
+
+// capabilities are the capabilities advertised by the fake server
+var capabilities = map[string]bool{"beef": true, "graphql": true, "new_transaction": true, "paymail": false}
+
+// serverInfo will return the version, the feature flags and the capabilities of the server (the store
+// must be locked)
+func (s *Server) serverInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"capabilities": capabilities,
+		"features":     s.features,
+		"version":      Version,
+	}
+}
+
+// RegisterXPub will register the xPub, like an admin would (see buxclient.RegisterXpub)
+func (s *Server) RegisterXPub(rawXPub string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registerXPub(rawXPub)
+}
+
+// Fund will send the satoshis to a new destination of the xPub, from outside the server (the
+// transaction has no known inputs), the xPub is registered if needed
+func (s *Server) Fund(rawXPub string, satoshis uint64) (*bux.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	xPubID, err := s.registerXPub(rawXPub)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := s.newDestination(xPubID, chainExternal, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// the input spends a random (unknown) output
+	prevTxID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	tx := bt.NewTx()
+	if err = tx.From(prevTxID, 0, destination.LockingScript, satoshis); err != nil {
+		return nil, err
+	}
+	if err = tx.AddP2PKHOutputFromAddress(destination.Address, satoshis); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.recordTransaction("", tx.String(), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return transactionFor(transaction, xPubID), nil
+}
+
+// Mine will set the block height of the transaction, as if it was mined in that block
+func (s *Server) Mine(txID string, blockHeight uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transaction := range s.transactions {
+		if transaction.ID == txID {
+			transaction.BlockHeight = blockHeight
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ExpireDrafts will expire the pending drafts as the bux server does after their expiry: their
+// utxos are released and recording their transactions fails, without waiting for the expiry
+func (s *Server) ExpireDrafts() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, draft := range s.drafts {
+		if draft.Status == bux.DraftStatusDraft {
+			draft.Status = bux.DraftStatusExpired
+		}
+	}
+}
+
+// Balance will return the unspent satoshis of the xPub
+func (s *Server) Balance(rawXPub string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	xPubID := buxutils.Hash(rawXPub)
+	var balance uint64
+	for _, output := range s.utxos {
+		if output.destination.XpubID == xPubID {
+			balance += output.satoshis
+		}
+	}
+	return balance
+}
+
+// registerXPub will register the xPub, and return its ID
+func (s *Server) registerXPub(rawXPub string) (string, error) {
+	key, err := bitcoin.GetHDKeyFromExtendedPublicKey(rawXPub)
+	if err != nil {
+		return "", err
+	}
+	xPubID := buxutils.Hash(rawXPub)
+	if _, ok := s.xPubs[xPubID]; !ok {
+		s.xPubs[xPubID] = key
+	}
+	return xPubID, nil
+}
+
+// newDestination will derive the next destination of the chain of the xPub
+func (s *Server) newDestination(xPubID string, chain uint32, metadata bux.Metadata) (*bux.Destination, error) {
+	key, ok := s.xPubs[xPubID]
+	if !ok {
+		return nil, ErrUnknownXPub
+	}
+
+	numKey := xPubID + "/" + strconv.FormatUint(uint64(chain), 10)
+	num := s.nums[numKey]
+	s.nums[numKey] = num + 1
+
+	childKey, err := bitcoin.GetHDKeyByPath(key, chain, num)
+	if err != nil {
+		return nil, err
+	}
+	address, err := bitcoin.GetAddressFromHDKey(childKey)
+	if err != nil {
+		return nil, err
+	}
+	lockingScript, err := bitcoin.ScriptFromAddress(address.AddressString)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := &bux.Destination{
+		Address:       address.AddressString,
+		Chain:         chain,
+		ID:            buxutils.Hash(lockingScript),
+		LockingScript: lockingScript,
+		Num:           num,
+		Type:          buxutils.ScriptTypePubKeyHash,
+		XpubID:        xPubID,
+	}
+	destination.CreatedAt = time.Now().UTC()
+	destination.Metadata = metadata
+	s.destinations[lockingScript] = destination
+	return destination, nil
+}
+
+// randomID will return a random 32 bytes ID, hex encoded
+func randomID() (string, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// outpoint is the key of an output
+func outpoint(txID string, index uint32) string {
+	return txID + ":" + strconv.FormatUint(uint64(index), 10)
+}
@@ -0,0 +1,230 @@
+package buxtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServer will test the draft, sign and record flows against the fake server
+func TestServer(t *testing.T) {
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	transportOptions := map[string]buxclient.ClientOps{
+		"http":    buxclient.WithHTTP(server.URL),
+		"graphql": buxclient.WithGraphQL(server.GraphQLURL()),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			adminXPriv, _, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+			require.NoError(t, err)
+			senderXPriv, senderXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+			require.NoError(t, err)
+			receiverXPriv, receiverXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+			require.NoError(t, err)
+
+			newClient := func(xPriv string) *buxclient.BuxClient {
+				client, err := buxclient.New(
+					buxclient.WithXPriv(xPriv),
+					buxclient.WithAdminKey(adminXPriv),
+					buxclient.WithSignRequest(true),
+					transportOption,
+				)
+				require.NoError(t, err)
+				return client
+			}
+			sender := newClient(senderXPriv)
+			receiver := newClient(receiverXPriv)
+
+			// the receiver is registered by an admin, the sender is registered when funded
+			require.NoError(t, sender.RegisterXpub(ctx, receiverXPub, nil))
+			funding, err := server.Fund(senderXPub, 10000)
+			require.NoError(t, err)
+			assert.Equal(t, int64(10000), funding.OutputValue)
+
+			destination, err := receiver.GetDestination(ctx, &bux.Metadata{"label": "invoice"})
+			require.NoError(t, err)
+			assert.NotEmpty(t, destination.Address)
+
+			transaction, err := sender.SendToRecipients(ctx, []*transports.Recipients{{
+				To:       destination.Address,
+				Satoshis: 1000,
+			}}, &bux.Metadata{"note": "test"})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1000), server.Balance(receiverXPub))
+			assert.Less(t, server.Balance(senderXPub), uint64(9000))
+
+			received, err := receiver.GetTransaction(ctx, transaction.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1000), received.OutputValue)
+			assert.Equal(t, bux.TransactionDirectionIn, received.Direction)
+
+			sent, err := sender.GetTransactions(ctx, nil, &bux.Metadata{"note": "test"}, nil)
+			require.NoError(t, err)
+			require.Len(t, sent, 1)
+			assert.Equal(t, transaction.ID, sent[0].ID)
+			assert.Equal(t, bux.TransactionDirectionOut, sent[0].Direction)
+			assert.NotZero(t, sent[0].Fee)
+
+			destinations, err := receiver.GetDestinations(ctx, nil, &bux.Metadata{"label": "invoice"}, nil)
+			require.NoError(t, err)
+			require.Len(t, destinations, 1)
+			assert.Equal(t, destination.Address, destinations[0].Address)
+
+			utxos, err := receiver.GetUtxos(ctx, map[string]interface{}{"transaction_id": transaction.ID}, nil, nil)
+			require.NoError(t, err)
+			require.Len(t, utxos, 1)
+			assert.Equal(t, uint64(1000), utxos[0].Satoshis)
+			assert.Equal(t, destination.LockingScript, utxos[0].ScriptPubKey)
+
+			count, err := receiver.GetUtxosCount(ctx, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+			count, err = receiver.GetDestinationsCount(ctx, nil, &bux.Metadata{"label": "invoice"})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+			count, err = sender.GetTransactionsCount(ctx, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+
+			recent, err := sender.SearchTransactions(ctx, transports.MergeConditions(
+				transports.Since(transports.FieldCreatedAt, time.Now().Add(-time.Hour)),
+				map[string]interface{}{"output_value": map[string]interface{}{transports.ConditionLessThan: 0}},
+			), nil, nil)
+			require.NoError(t, err)
+			require.Len(t, recent, 1)
+			assert.Equal(t, transaction.ID, recent[0].ID)
+			recent, err = sender.SearchTransactions(ctx, transports.Until(transports.FieldCreatedAt, time.Now().Add(-time.Hour)), nil, nil)
+			require.NoError(t, err)
+			assert.Empty(t, recent)
+
+			for direction, expectedID := range map[transports.SortDirection]string{
+				transports.SortAscending:  transaction.ID,
+				transports.SortDescending: funding.ID,
+			} {
+				sorted, err := sender.SearchTransactions(ctx, nil, nil, &transports.QueryParams{
+					OrderByField: "output_value", Page: 1, PageSize: 1, SortDirection: direction,
+				})
+				require.NoError(t, err)
+				require.Len(t, sorted, 1)
+				assert.Equal(t, expectedID, sorted[0].ID, direction)
+			}
+
+			t.Run("script output", func(t *testing.T) {
+				scriptDestination, err := receiver.GetDestination(ctx, nil)
+				require.NoError(t, err)
+
+				_, err = sender.SendToRecipients(ctx, []*transports.Recipients{{
+					Script:   scriptDestination.LockingScript,
+					Satoshis: 500,
+				}}, nil)
+				require.NoError(t, err)
+				assert.Equal(t, uint64(1500), server.Balance(receiverXPub))
+			})
+
+			t.Run("not enough funds", func(t *testing.T) {
+				_, err = receiver.DraftToRecipients(ctx, []*transports.Recipients{{
+					To:       destination.Address,
+					Satoshis: 5000,
+				}}, nil)
+				assert.Error(t, err)
+			})
+
+			t.Run("unsigned input", func(t *testing.T) {
+				draft, err := sender.DraftToRecipients(ctx, []*transports.Recipients{{
+					To:       destination.Address,
+					Satoshis: 1000,
+				}}, nil)
+				require.NoError(t, err)
+
+				_, err = sender.RecordTransaction(ctx, draft.Hex, draft.ID, nil)
+				assert.Error(t, err)
+			})
+
+			t.Run("expired draft", func(t *testing.T) {
+				// the utxos of the draft of the unsigned input are released
+				server.ExpireDrafts()
+				draft, err := sender.DraftToRecipients(ctx, []*transports.Recipients{{
+					To:       destination.Address,
+					Satoshis: 1000,
+				}}, nil)
+				require.NoError(t, err)
+				hex, err := sender.FinalizeTransaction(draft)
+				require.NoError(t, err)
+
+				server.ExpireDrafts()
+				_, err = sender.RecordTransaction(ctx, hex, draft.ID, nil)
+				assert.Equal(t, transports.ErrorCodeDraftNotFound, transports.ErrorCode(err))
+
+				var count int64
+				count, err = sender.GetDraftTransactionsCount(ctx, map[string]interface{}{
+					"id":     draft.ID,
+					"status": bux.DraftStatusExpired,
+				}, nil)
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), count)
+			})
+
+			t.Run("unknown xpub", func(t *testing.T) {
+				unknownXPriv, _, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+				require.NoError(t, err)
+
+				_, err = newClient(unknownXPriv).GetTransactions(ctx, nil, nil, nil)
+				assert.Error(t, err)
+			})
+		})
+	}
+}
+
+// TestServerInfo will test the health check and the server info against the fake server
+func TestServerInfo(t *testing.T) {
+	server := buxtest.NewServer()
+	defer server.Close()
+	server.SetFeatureFlags(map[string]bool{buxclient.FeatureBEEF: true})
+
+	ctx := context.Background()
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	_, err = server.RegisterXPub(xPub)
+	require.NoError(t, err)
+
+	transportOptions := map[string][]buxclient.ClientOps{
+		"http":    {buxclient.WithHTTP(server.URL), buxclient.WithHTTP(server.URL + "/wrong")},
+		"graphql": {buxclient.WithGraphQL(server.GraphQLURL()), buxclient.WithGraphQL(server.URL + "/wrong")},
+	}
+	for name, options := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			client, err := buxclient.New(
+				buxclient.WithXPriv(xPriv), buxclient.WithServerCheck(), buxclient.WithCapabilities(), options[0],
+			)
+			require.NoError(t, err)
+			require.NoError(t, client.Ping(ctx))
+
+			info, err := client.ServerInfo(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, buxtest.Version, info.Version)
+			assert.Equal(t, map[string]bool{buxclient.FeatureBEEF: true}, info.Features)
+
+			// the fake server does not manage the paymail addresses
+			assert.True(t, client.Supports(buxclient.CapabilityNewTransaction))
+			assert.False(t, client.Supports(buxclient.CapabilityPaymail))
+			_, err = client.AdminGetPaymails(ctx, nil, nil, nil)
+			require.ErrorIs(t, err, buxclient.ErrNotSupportedByServer)
+
+			// a wrong server URL fails when creating the client
+			_, err = buxclient.New(buxclient.WithXPriv(xPriv), buxclient.WithServerCheck(), options[1])
+			require.ErrorIs(t, err, transports.ErrServerUnavailable)
+			assert.Contains(t, err.Error(), server.URL+"/wrong")
+		})
+	}
+}
@@ -0,0 +1,624 @@
+package buxtest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/pkg/errors"
+)
+
+// Fee rate of the drafts, when the transaction config has no fee unit (same as the bux default)
+const (
+	defaultFeeBytes    = 2
+	defaultFeeSatoshis = 1
+)
+
+// Estimated sizes of a transaction, to compute the fee of a draft before it is signed
+const (
+	changeOutputSize   = 34
+	unlockingScriptLen = 107 // P2PKH signature and compressed public key
+)
+
+// draftExpiration is how long the utxos of a draft are reserved
+const draftExpiration = 20 * time.Second
+
+// ErrNotEnoughFunds is when the unspent outputs of the xPub do not cover the outputs and the fee
+// (the error of the bux server, so the clients can detect it)
+var ErrNotEnoughFunds = bux.ErrNotEnoughUtxos
+
+// ErrAlreadySpent is when a transaction spends an output that was already spent
+var ErrAlreadySpent = errors.New("output already spent")
+
+// newDraftTransaction will create a draft transaction for the xPub, reserving the utxos of its inputs
+func (s *Server) newDraftTransaction(xPubID string, config *bux.TransactionConfig,
+	metadata bux.Metadata) (*bux.DraftTransaction, error) {
+
+	if config == nil || len(config.Outputs) == 0 {
+		return nil, errors.New("transaction config has no outputs")
+	}
+	if config.SendAllTo != "" {
+		return nil, errors.Wrap(ErrUnsupported, "send_all_to")
+	}
+
+	tx := bt.NewTx()
+	var outputSatoshis uint64
+	for _, output := range config.Outputs {
+		if err := addOutput(tx, output); err != nil {
+			return nil, err
+		}
+		outputSatoshis += output.Satoshis
+	}
+
+	// the change is sent to a new internal destination
+	feeUnit := config.FeeUnit
+	if feeUnit == nil || feeUnit.Bytes <= 0 {
+		feeUnit = &buxutils.FeeUnit{Bytes: defaultFeeBytes, Satoshis: defaultFeeSatoshis}
+	}
+	var inputs []*bux.TransactionInput
+	var inputSatoshis, fee uint64
+	for _, output := range s.spendableUtxos(xPubID, config.FromUtxos) {
+		inputs = append(inputs, newTransactionInput(output))
+		inputSatoshis += output.satoshis
+		if err := tx.From(output.txID, output.outputIndex, output.destination.LockingScript, output.satoshis); err != nil {
+			return nil, err
+		}
+
+		if fee = config.Fee; fee == 0 {
+			size := tx.Size() + len(tx.Inputs)*unlockingScriptLen + changeOutputSize
+			fee = uint64((size*feeUnit.Satoshis + feeUnit.Bytes - 1) / feeUnit.Bytes)
+		}
+		if inputSatoshis >= outputSatoshis+fee {
+			break
+		}
+	}
+	if inputSatoshis < outputSatoshis+fee || len(inputs) == 0 {
+		return nil, ErrNotEnoughFunds
+	}
+
+	draftID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	if change := inputSatoshis - outputSatoshis - fee; change > 0 {
+		var destination *bux.Destination
+		if destination, err = s.newDestination(xPubID, chainInternal, nil); err != nil {
+			return nil, err
+		}
+		if err = tx.AddP2PKHOutputFromAddress(destination.Address, change); err != nil {
+			return nil, err
+		}
+		destination.DraftID = draftID
+		config.ChangeDestinations = []*bux.Destination{destination}
+		config.ChangeSatoshis = change
+	}
+	config.Fee = fee
+	config.FeeUnit = feeUnit
+	config.Inputs = inputs
+
+	now := time.Now().UTC()
+	draft := &bux.DraftTransaction{
+		Configuration: *config,
+		ExpiresAt:     now.Add(draftExpiration),
+		Status:        bux.DraftStatusDraft,
+		XpubID:        xPubID,
+	}
+	draft.ID = draftID
+	draft.Hex = tx.String()
+	draft.CreatedAt = now
+	draft.Metadata = metadata
+	for _, input := range inputs {
+		s.utxos[outpoint(input.TransactionID, input.OutputIndex)].draftID = draftID
+	}
+	s.drafts[draftID] = draft
+	return draft, nil
+}
+
+// expire will mark the pending draft as expired once its expiry passed (as the task of the bux server
+// does), and return whether the draft expired
+func expire(draft *bux.DraftTransaction, now time.Time) bool {
+	if draft.Status == bux.DraftStatusDraft && !now.Before(draft.ExpiresAt) {
+		draft.Status = bux.DraftStatusExpired
+	}
+	return draft.Status == bux.DraftStatusExpired
+}
+
+// addOutput will add the output of the transaction config to the transaction, and fill its scripts
+func addOutput(tx *bt.Tx, output *bux.TransactionOutput) error {
+	if output.PaymailP4 != nil || strings.Contains(output.To, "@") {
+		return errors.Wrap(ErrUnsupported, "paymail outputs")
+	}
+
+	var err error
+	switch {
+	case output.To == "" && output.OpReturn == nil && len(output.Scripts) > 0:
+		return addScriptOutputs(tx, output)
+	case output.To != "":
+		err = tx.AddP2PKHOutputFromAddress(output.To, output.Satoshis)
+	case output.OpReturn != nil:
+		err = addOpReturnOutput(tx, output.OpReturn)
+	default:
+		return errors.New("output has no destination")
+	}
+	if err != nil {
+		return err
+	}
+
+	script := tx.Outputs[len(tx.Outputs)-1].LockingScript
+	output.Scripts = []*bux.ScriptOutput{{
+		Address:    output.To,
+		Satoshis:   output.Satoshis,
+		Script:     script.String(),
+		ScriptType: buxutils.GetDestinationType(script.String()),
+	}}
+	return nil
+}
+
+// addScriptOutputs will add the outputs of the locking scripts of the output to the transaction
+func addScriptOutputs(tx *bt.Tx, output *bux.TransactionOutput) error {
+	for _, scriptOutput := range output.Scripts {
+		script, err := bscript.NewFromHexString(scriptOutput.Script)
+		if err != nil {
+			return err
+		}
+		tx.AddOutput(&bt.Output{LockingScript: script, Satoshis: scriptOutput.Satoshis})
+		scriptOutput.ScriptType = buxutils.GetDestinationType(scriptOutput.Script)
+	}
+	return nil
+}
+
+// addOpReturnOutput will add the op_return output to the transaction (MAP is not supported)
+func addOpReturnOutput(tx *bt.Tx, opReturn *bux.OpReturn) error {
+	var parts [][]byte
+	switch {
+	case opReturn.Hex != "":
+		script, err := bscript.NewFromHexString(opReturn.Hex)
+		if err != nil {
+			return err
+		}
+		tx.AddOutput(&bt.Output{LockingScript: script})
+		return nil
+	case len(opReturn.HexParts) > 0:
+		for _, part := range opReturn.HexParts {
+			data, err := hex.DecodeString(part)
+			if err != nil {
+				return err
+			}
+			parts = append(parts, data)
+		}
+	case len(opReturn.StringParts) > 0:
+		for _, part := range opReturn.StringParts {
+			parts = append(parts, []byte(part))
+		}
+	default:
+		return errors.Wrap(ErrUnsupported, "op_return without hex or parts")
+	}
+	return tx.AddOpReturnPartsOutput(parts)
+}
+
+// spendableUtxos will return the utxos of the xPub that are not reserved by a draft (or whose draft
+// expired), oldest first, only the utxos pinned by the draft if any
+func (s *Server) spendableUtxos(xPubID string, fromUtxos []*bux.UtxoPointer) []*utxo {
+	pinned := make(map[string]bool, len(fromUtxos))
+	for _, pointer := range fromUtxos {
+		pinned[outpoint(pointer.TransactionID, pointer.OutputIndex)] = true
+	}
+	now := time.Now()
+	spendable := make([]*utxo, 0)
+	for _, output := range s.utxos {
+		if output.destination.XpubID != xPubID ||
+			len(pinned) > 0 && !pinned[outpoint(output.txID, output.outputIndex)] {
+			continue
+		}
+		if draft, ok := s.drafts[output.draftID]; ok && draft.Status == bux.DraftStatusDraft && now.Before(draft.ExpiresAt) {
+			continue
+		}
+		spendable = append(spendable, output)
+	}
+	sort.Slice(spendable, func(i, j int) bool {
+		return outpoint(spendable[i].txID, spendable[i].outputIndex) < outpoint(spendable[j].txID, spendable[j].outputIndex)
+	})
+	return spendable
+}
+
+// newTransactionInput will return the input of a draft spending the utxo
+func newTransactionInput(output *utxo) *bux.TransactionInput {
+	input := &bux.TransactionInput{Destination: *output.destination}
+	input.ID = buxutils.Hash(outpoint(output.txID, output.outputIndex))
+	input.OutputIndex = output.outputIndex
+	input.Satoshis = output.satoshis
+	input.ScriptPubKey = output.destination.LockingScript
+	input.TransactionID = output.txID
+	input.Type = output.destination.Type
+	input.XpubID = output.destination.XpubID
+	return input
+}
+
+// recordTransaction will record the transaction: the inputs spending known outputs must be signed,
+// the outputs to known destinations become utxos of their xPub
+func (s *Server) recordTransaction(xPubID, txHex, draftID string, metadata bux.Metadata) (*bux.Transaction, error) {
+	tx, err := bt.NewTxFromString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range s.transactions {
+		if transaction.ID == tx.TxID() {
+			return transaction, nil
+		}
+	}
+
+	var draft *bux.DraftTransaction
+	if draftID != "" {
+		var ok bool
+		if draft, ok = s.drafts[draftID]; !ok || draft.XpubID != xPubID || expire(draft, time.Now()) ||
+			draft.Status != bux.DraftStatusDraft {
+			return nil, ErrDraftNotFound
+		}
+	}
+
+	transaction := &bux.Transaction{
+		DraftID:         draftID,
+		NumberOfInputs:  uint32(len(tx.Inputs)),
+		NumberOfOutputs: uint32(len(tx.Outputs)),
+		XpubOutputValue: make(bux.XpubOutputValue),
+	}
+	transaction.ID = tx.TxID()
+	transaction.Hex = txHex
+	transaction.CreatedAt = time.Now().UTC()
+	transaction.Metadata = metadata
+
+	// check all the inputs before spending them
+	spending := make([]*utxo, 0, len(tx.Inputs))
+	allKnown := true
+	var inputSatoshis uint64
+	for index, input := range tx.Inputs {
+		key := outpoint(input.PreviousTxIDStr(), input.PreviousTxOutIndex)
+		if _, ok := s.spent[key]; ok {
+			return nil, errors.Wrap(ErrAlreadySpent, key)
+		}
+		output, ok := s.utxos[key]
+		if !ok {
+			allKnown = false
+			continue
+		}
+		if err = verifyInput(tx, index, output); err != nil {
+			return nil, err
+		}
+		spending = append(spending, output)
+		inputSatoshis += output.satoshis
+	}
+
+	for _, output := range spending {
+		key := outpoint(output.txID, output.outputIndex)
+		delete(s.utxos, key)
+		s.spent[key] = transaction.ID
+		xPubID := output.destination.XpubID
+		transaction.XpubInIDs = appendID(transaction.XpubInIDs, xPubID)
+		transaction.XpubOutputValue[xPubID] -= int64(output.satoshis)
+	}
+
+	var outputSatoshis uint64
+	for index, output := range tx.Outputs {
+		outputSatoshis += output.Satoshis
+		destination, ok := s.destinations[output.LockingScript.String()]
+		if !ok {
+			continue
+		}
+		s.utxos[outpoint(transaction.ID, uint32(index))] = &utxo{
+			destination: destination,
+			outputIndex: uint32(index),
+			satoshis:    output.Satoshis,
+			txID:        transaction.ID,
+		}
+		transaction.XpubOutIDs = appendID(transaction.XpubOutIDs, destination.XpubID)
+		transaction.XpubOutputValue[destination.XpubID] += int64(output.Satoshis)
+	}
+	transaction.TotalValue = outputSatoshis
+	if allKnown && inputSatoshis >= outputSatoshis {
+		transaction.Fee = inputSatoshis - outputSatoshis
+	}
+
+	if draft != nil {
+		draft.FinalTxID = transaction.ID
+		draft.Status = bux.DraftStatusComplete
+	}
+	s.transactions = append(s.transactions, transaction)
+	return transaction, nil
+}
+
+// verifyInput will execute the unlocking script of the input against the locking script of the output
+func verifyInput(tx *bt.Tx, index int, output *utxo) error {
+	lockingScript, err := bscript.NewFromHexString(output.destination.LockingScript)
+	if err != nil {
+		return err
+	}
+
+	// the signature hash covers the previous output, which is not serialized in the transaction
+	tx.Inputs[index].PreviousTxScript = lockingScript
+	tx.Inputs[index].PreviousTxSatoshis = output.satoshis
+	if err = interpreter.NewEngine().Execute(
+		interpreter.WithTx(tx, index, &bt.Output{LockingScript: lockingScript, Satoshis: output.satoshis}),
+		interpreter.WithAfterGenesis(),
+		interpreter.WithForkID(),
+	); err != nil {
+		return errors.Wrapf(err, "input %d", index)
+	}
+	return nil
+}
+
+// appendID will append the ID if it is not in the IDs yet
+func appendID(ids bux.IDs, id string) bux.IDs {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// transactionFor will return a copy of the transaction as seen by the xPub (output value and direction)
+func transactionFor(transaction *bux.Transaction, xPubID string) *bux.Transaction {
+	view := *transaction
+	view.OutputValue = transaction.XpubOutputValue[xPubID]
+	view.Direction = bux.TransactionDirectionIn
+	if view.OutputValue < 0 {
+		view.Direction = bux.TransactionDirectionOut
+	}
+	return &view
+}
+
+// transactionsOf will return the transactions of the xPub matching the conditions and metadata
+func (s *Server) transactionsOf(xPubID string, conditions map[string]interface{}, metadata bux.Metadata) ([]*bux.Transaction, error) {
+	transactions := make([]*bux.Transaction, 0)
+	for _, transaction := range s.transactions {
+		if _, ok := transaction.XpubOutputValue[xPubID]; !ok {
+			continue
+		}
+		view := transactionFor(transaction, xPubID)
+		matched, err := matches(view, view.Metadata, conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			transactions = append(transactions, view)
+		}
+	}
+	return transactions, nil
+}
+
+// destinationsOf will return the destinations of the xPub matching the conditions and metadata
+func (s *Server) destinationsOf(xPubID string, conditions map[string]interface{}, metadata bux.Metadata) ([]*bux.Destination, error) {
+	destinations := make([]*bux.Destination, 0)
+	for _, destination := range s.destinations {
+		if destination.XpubID != xPubID {
+			continue
+		}
+		matched, err := matches(destination, destination.Metadata, conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			destinations = append(destinations, destination)
+		}
+	}
+	sort.Slice(destinations, func(i, j int) bool {
+		if destinations[i].Chain != destinations[j].Chain {
+			return destinations[i].Chain < destinations[j].Chain
+		}
+		return destinations[i].Num < destinations[j].Num
+	})
+	return destinations, nil
+}
+
+// utxosOf will return the unspent outputs of the xPub matching the conditions and metadata, ordered
+// by outpoint
+func (s *Server) utxosOf(xPubID string, conditions map[string]interface{}, metadata bux.Metadata) ([]*bux.Utxo, error) {
+	utxos := make([]*bux.Utxo, 0)
+	for _, output := range s.utxos {
+		if output.destination.XpubID != xPubID {
+			continue
+		}
+		view := utxoFor(output)
+		matched, err := matches(view, view.Metadata, conditions, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			utxos = append(utxos, view)
+		}
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		return outpoint(utxos[i].TransactionID, utxos[i].OutputIndex) < outpoint(utxos[j].TransactionID, utxos[j].OutputIndex)
+	})
+	return utxos, nil
+}
+
+// utxoFor will return the utxo model of the unspent output
+func utxoFor(output *utxo) *bux.Utxo {
+	view := &bux.Utxo{
+		ID:            buxutils.Hash(outpoint(output.txID, output.outputIndex)),
+		OutputIndex:   output.outputIndex,
+		Satoshis:      output.satoshis,
+		ScriptPubKey:  output.destination.LockingScript,
+		TransactionID: output.txID,
+		Type:          output.destination.Type,
+		XpubID:        output.destination.XpubID,
+	}
+	if output.draftID != "" {
+		view.DraftID.Valid = true
+		view.DraftID.String = output.draftID
+	}
+	return view
+}
+
+// draftsOf will return the drafts of the xPub matching the conditions and metadata
+func (s *Server) draftsOf(xPubID string, conditions map[string]interface{}, metadata bux.Metadata) ([]*bux.DraftTransaction, error) {
+	now := time.Now()
+	drafts := make([]*bux.DraftTransaction, 0)
+	for _, draft := range s.drafts {
+		if draft.XpubID != xPubID {
+			continue
+		}
+		expire(draft, now)
+		ok, err := matches(draft, draft.Metadata, conditions, metadata)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].CreatedAt.Before(drafts[j].CreatedAt)
+	})
+	return drafts, nil
+}
+
+// countOf will return the number of records of the model (destinations, drafts, transactions or utxos) of
+// the xPub matching the conditions and metadata
+func (s *Server) countOf(model, xPubID string, conditions map[string]interface{}, metadata bux.Metadata) (int, error) {
+	var count int
+	var err error
+	switch model {
+	case "destinations":
+		var destinations []*bux.Destination
+		destinations, err = s.destinationsOf(xPubID, conditions, metadata)
+		count = len(destinations)
+	case "transactions":
+		var transactions []*bux.Transaction
+		transactions, err = s.transactionsOf(xPubID, conditions, metadata)
+		count = len(transactions)
+	case "utxos":
+		var utxos []*bux.Utxo
+		utxos, err = s.utxosOf(xPubID, conditions, metadata)
+		count = len(utxos)
+	case "draft_transactions":
+		var drafts []*bux.DraftTransaction
+		drafts, err = s.draftsOf(xPubID, conditions, metadata)
+		count = len(drafts)
+	default:
+		err = errors.Wrap(ErrUnsupported, model+" count")
+	}
+	return count, err
+}
+
+// matches will return whether the (json) fields of the record match the conditions, and its metadata
+// the metadata filter, only equality, comparison ($gt, $gte, $lt, $lte) and $in conditions on top
+// level fields are supported
+func matches(record interface{}, recordMetadata bux.Metadata, conditions map[string]interface{},
+	metadata bux.Metadata) (bool, error) {
+
+	for key, value := range metadata {
+		if fmt.Sprint(recordMetadata[key]) != fmt.Sprint(value) {
+			return false, nil
+		}
+	}
+	if len(conditions) == 0 {
+		return true, nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return false, err
+	}
+	for key, value := range conditions {
+		operators, ok := value.(map[string]interface{})
+		if !ok {
+			if fmt.Sprint(fields[key]) != fmt.Sprint(value) {
+				return false, nil
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			matched, err := compare(fields[key], operator, operand)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// compare will return whether the value of a field satisfies the comparison with the operand (or is
+// in its list), the times (RFC 3339) and the numbers are compared by value, anything else by its text
+func compare(value interface{}, operator string, operand interface{}) (bool, error) {
+	if operator == "$in" {
+		values, ok := operand.([]interface{})
+		if !ok {
+			return false, errors.Wrap(ErrUnsupported, "condition $in without a list")
+		}
+		for _, in := range values {
+			if fmt.Sprint(value) == fmt.Sprint(in) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	var order int
+	valueTime, valueErr := time.Parse(time.RFC3339Nano, fmt.Sprint(value))
+	operandTime, operandErr := time.Parse(time.RFC3339Nano, fmt.Sprint(operand))
+	valueNumber, isValueNumber := value.(float64)
+	operandNumber, isOperandNumber := operand.(float64)
+	switch {
+	case valueErr == nil && operandErr == nil:
+		order = compareNumbers(float64(valueTime.Sub(operandTime)), 0)
+	case isValueNumber && isOperandNumber:
+		order = compareNumbers(valueNumber, operandNumber)
+	default:
+		order = strings.Compare(fmt.Sprint(value), fmt.Sprint(operand))
+	}
+
+	switch operator {
+	case "$gt":
+		return order > 0, nil
+	case "$gte":
+		return order >= 0, nil
+	case "$lt":
+		return order < 0, nil
+	case "$lte":
+		return order <= 0, nil
+	}
+	return false, errors.Wrap(ErrUnsupported, "condition "+operator)
+}
+
+// compareNumbers will return -1, 0 or 1 when a is less than, equal to or greater than b
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// page will return the page of the records of the query params (all the records without paging)
+func page(count, page, pageSize int) (int, int) {
+	if pageSize <= 0 {
+		return 0, count
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	if start > count {
+		start = count
+	}
+	end := start + pageSize
+	if end > count {
+		end = count
+	}
+	return start, end
+}
@@ -0,0 +1,46 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/pkg/errors"
+)
+
+// ErrNoCallbacks is when running the callbacks before registering any callback URL
+var ErrNoCallbacks = errors.New("no callback url registered, see RegisterCallback")
+
+// RegisterCallback will register a local callback URL for the event types (all events if none are
+// given), RunCallbacks POSTs the events of the notification stream to it, signed with the secret
+// (see events.VerifyWebhookSignature)
+func (b *BuxClient) RegisterCallback(url, secret string, eventTypes ...events.EventType) {
+	b.callbacksLock.Lock()
+	defer b.callbacksLock.Unlock()
+	if b.callbacks == nil {
+		b.callbacks = events.NewWebhookSink(nil)
+	}
+	b.callbacks.Register(url, secret, eventTypes...)
+}
+
+// RunCallbacks will POST the events of the notification stream to the registered callback URLs,
+// until the context is done, the events that failed maxAttempts times (0 retries until the context
+// is done) are dead lettered and published again to the callbacks on retry (see DeadLetters)
+func (b *BuxClient) RunCallbacks(ctx context.Context, maxAttempts int) error {
+	b.callbacksLock.Lock()
+	callbacks := b.callbacks
+	b.callbacksLock.Unlock()
+	if callbacks == nil {
+		return ErrNoCallbacks
+	}
+
+	notifications, err := b.Notifications(ctx)
+	if err != nil {
+		return err
+	}
+	opts := b.deadLetters.EventExportOptions(callbacks, maxAttempts)
+	opts.Scheduler = b.scheduler
+	if err = events.Export(ctx, notifications, callbacks, opts); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,63 @@
+package buxclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunCallbacks will test the method RunCallbacks()
+func TestRunCallbacks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(transports.NotificationsPath, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		mustWrite(w, "id: 1\nevent: transaction_created\ndata: {\"xpub_id\":\""+xPubID+"\",\"transaction_id\":\""+txID+"\"}\n\n")
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+	)
+	require.NoError(t, err)
+
+	t.Run("no callbacks", func(t *testing.T) {
+		assert.ErrorIs(t, client.RunCallbacks(context.Background(), 1), ErrNoCallbacks)
+	})
+
+	t.Run("signed events", func(t *testing.T) {
+		received := make(chan *http.Request, 10)
+		bodies := make(chan []byte, 10)
+		callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := ioutil.ReadAll(req.Body)
+			received <- req
+			bodies <- body
+		}))
+		defer callback.Close()
+		client.RegisterCallback(callback.URL, "secret", events.EventTransactionCreated)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- client.RunCallbacks(ctx, 1)
+		}()
+
+		req := <-received
+		body := <-bodies
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "1", req.Header.Get(events.WebhookHeaderEventID))
+		assert.Equal(t, string(events.EventTransactionCreated), req.Header.Get(events.WebhookHeaderEventType))
+		require.NoError(t, events.VerifyWebhookSignature("secret", req.Header, body, time.Minute))
+		assert.Contains(t, string(body), txID)
+	})
+}
@@ -0,0 +1,112 @@
+package buxclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// Capabilities advertised by the bux server in its server info, fetched when creating the client
+const (
+	// CapabilityBEEF is when the server accepts and returns transactions in the BEEF format
+	CapabilityBEEF = "beef"
+
+	// CapabilityGraphQL is when the server serves the graphql endpoint
+	CapabilityGraphQL = "graphql"
+
+	// CapabilityNewTransaction is when the server supports the new transaction (draft, sign, record) flow
+	CapabilityNewTransaction = "new_transaction"
+
+	// CapabilityPaymail is when the server manages the paymail addresses
+	CapabilityPaymail = "paymail"
+)
+
+// ErrNotSupportedByServer is when the bux server does not advertise the capability needed by an operation
+var ErrNotSupportedByServer = errors.New("not supported by the bux server")
+
+// CapabilityError is returned (upfront, without calling the server) when an operation needs a capability
+// that the server does not advertise, it matches ErrNotSupportedByServer with errors.Is
+type CapabilityError struct {
+	Capability string
+	Operation  string
+}
+
+// Error will return the error message, with the operation and the missing capability
+func (e *CapabilityError) Error() string {
+	return e.Operation + " is " + ErrNotSupportedByServer.Error() + ": the server does not advertise the " +
+		e.Capability + " capability (upgrade or configure the server)"
+}
+
+// Is will return whether the target is ErrNotSupportedByServer
+func (e *CapabilityError) Is(target error) bool {
+	return target == ErrNotSupportedByServer
+}
+
+// serverCapabilities are the capabilities advertised by the server, nil until fetched
+type serverCapabilities struct {
+	advertised map[string]bool
+	mu         sync.RWMutex
+}
+
+// RefreshCapabilities will fetch the capabilities advertised by the server again, over plain HTTP
+// (GET /info) with both transports
+func (b *BuxClient) RefreshCapabilities(ctx context.Context) error {
+	capabilities, err := b.transport.GetCapabilities(ctx)
+	if err != nil {
+		return err
+	}
+	if capabilities == nil {
+		capabilities = make(map[string]bool)
+	}
+
+	b.capabilities.mu.Lock()
+	b.capabilities.advertised = capabilities
+	b.capabilities.mu.Unlock()
+
+	b.cacheSnapshot(ctx)
+	return nil
+}
+
+// Supports will return whether the server advertises the capability, all capabilities are considered
+// supported when they could not be fetched (see WithCapabilities and WithoutCapabilities)
+func (b *BuxClient) Supports(capability string) bool {
+	b.capabilities.mu.RLock()
+	defer b.capabilities.mu.RUnlock()
+
+	if b.capabilities.advertised == nil {
+		return true
+	}
+	return b.capabilities.advertised[capability]
+}
+
+// requireCapability will return a CapabilityError if the server does not advertise the capability
+func (b *BuxClient) requireCapability(capability, operation string) error {
+	if b.Supports(capability) {
+		return nil
+	}
+	return &CapabilityError{Capability: capability, Operation: operation}
+}
+
+// negotiateCapabilities will fetch the capabilities of the server (unless restored from a snapshot) and
+// check the transport of the client, a server that does not advertise its capabilities (ex: an older
+// server without /info) is not gated unless the capabilities are required (see WithCapabilities)
+func (b *BuxClient) negotiateCapabilities(ctx context.Context) error {
+	b.capabilities.mu.RLock()
+	restored := b.capabilities.advertised != nil
+	b.capabilities.mu.RUnlock()
+
+	if !restored {
+		if err := b.RefreshCapabilities(ctx); err != nil {
+			if b.requireCapabilities {
+				return err
+			}
+			return nil
+		}
+	}
+	if _, ok := b.transport.(*transports.TransportGraphQL); ok {
+		return b.requireCapability(CapabilityGraphQL, "the graphql transport (use WithHTTP)")
+	}
+	return nil
+}
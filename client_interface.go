@@ -0,0 +1,194 @@
+package buxclient
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/beef"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/paymail"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// ClientInterface is the interface of the bux client, services can depend on it and use the mocks
+// package in their unit tests instead of a live bux server
+type ClientInterface interface {
+	AccountService
+	AdminService
+	ClientSettings
+	DestinationService
+	NotificationService
+	SendService
+	ServerService
+	TransactionService
+	UsageService
+	WebhookService
+}
+
+// ClientSettings are the settings, keys and capabilities of the client
+type ClientSettings interface {
+	DeadLetters() *DeadLetterQueue
+	DoRawRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error
+	FeatureEnabled(name string) bool
+	GetTransport() *transports.TransportService
+	HasAdminKey() bool
+	InvalidateCache(ctx context.Context, read CachedRead, key string) error
+	IsDebug() bool
+	IsSignRequest() bool
+	IsWatchOnly() bool
+	MinConfirmations() uint64
+	RefreshCapabilities(ctx context.Context) error
+	RefreshFeatureFlags(ctx context.Context) error
+	RequiresAdmin(operation string) bool
+	RestoreSnapshot(data []byte) error
+	RunGraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error
+	ScriptTemplates() *ScriptTemplateRegistry
+	SetAdminKey(adminKeyString string) error
+	SetDebug(debug bool)
+	SetSignRequest(signRequest bool)
+	Snapshot() ([]byte, error)
+	Supports(capability string) bool
+	TransactionLimits() TransactionLimits
+}
+
+// AccountService is the status and the keys of the xPub of the client
+type AccountService interface {
+	GetAccessKeys(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.AccessKey, error)
+	GetAccessKeysCount(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata) (int64, error)
+	GetXPub(ctx context.Context) (*bux.Xpub, error)
+	GetXPubStatus(ctx context.Context) (*transports.XPubStatus, error)
+	ReplaceAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error)
+	RotateXPriv(ctx context.Context, newXPrivString string) error
+}
+
+// AdminService is the admin operations (admin key needed)
+type AdminService interface {
+	AdminCreatePaymail(ctx context.Context, xPubID, address, publicName, avatar string,
+		metadata *bux.Metadata) (*transports.PaymailAddress, error)
+	AdminDeletePaymail(ctx context.Context, address string) error
+	AdminFreezeXPub(ctx context.Context, xPubID, reason string) (*transports.XPubStatus, error)
+	AdminGetPaymails(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*transports.PaymailAddress, error)
+	AdminUnfreezeXPub(ctx context.Context, xPubID string) (*transports.XPubStatus, error)
+	RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error
+}
+
+// DestinationService is the destination operations
+type DestinationService interface {
+	GetDestination(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error)
+	GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error)
+	GetDestinations(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Destination, error)
+	GetDestinationsCount(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata) (int64, error)
+	NewDestinations(ctx context.Context, count int, metadata *bux.Metadata) ([]*bux.Destination, error)
+	UpdateDestinationMetadata(ctx context.Context, id string,
+		metadata *bux.Metadata) (*bux.Destination, error)
+	VerifyDestination(destination *bux.Destination) error
+}
+
+// NotificationService is the notification stream and the subscriptions
+type NotificationService interface {
+	Notifications(ctx context.Context) (<-chan *events.Event, error)
+	RegisterCallback(url, secret string, eventTypes ...events.EventType)
+	RunCallbacks(ctx context.Context, maxAttempts int) error
+	SubscribeTransactions(ctx context.Context,
+		conditions map[string]interface{}) (<-chan *bux.Transaction, error)
+}
+
+// SendService is the operations to build, sign and record transactions
+type SendService interface {
+	DraftSend(ctx context.Context, send *SendContext,
+		recipients []*transports.Recipients) (*bux.DraftTransaction, error)
+	DraftToRecipients(ctx context.Context, recipients []*transports.Recipients,
+		metadata *bux.Metadata, opts ...DraftOps) (*bux.DraftTransaction, error)
+	DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig,
+		metadata *bux.Metadata, opts ...DraftOps) (*bux.DraftTransaction, error)
+	EnsureValid(ctx context.Context, draft *bux.DraftTransaction,
+		margin time.Duration) (*bux.DraftTransaction, error)
+	FinalizeTransaction(draft *bux.DraftTransaction) (string, error)
+	GetDraftTransactionsCount(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata) (int64, error)
+	GetFeeQuote(ctx context.Context) (*transports.FeeQuote, error)
+	GetP2PPaymentDestination(ctx context.Context, address string,
+		satoshis uint64) (*paymail.PaymentDestination, error)
+	RecordSend(ctx context.Context, send *SendContext) (*bux.Transaction, error)
+	RecordTransaction(ctx context.Context, hex, referenceID string,
+		metadata *bux.Metadata) (*bux.Transaction, error)
+	RecordTransactionBEEF(ctx context.Context, transaction *beef.BEEF, referenceID string,
+		metadata *bux.Metadata) (*bux.Transaction, error)
+	RecordTransactions(ctx context.Context, requests []*transports.RecordRequest) ([]*bux.Transaction, error)
+	RenewDraft(ctx context.Context, draft *bux.DraftTransaction) (*bux.DraftTransaction, error)
+	RunPaymentPipeline(ctx context.Context, intents <-chan *PaymentIntent,
+		opts *PaymentPipelineOptions) (*PaymentPipelineResult, error)
+	SendToRecipients(ctx context.Context, recipients []*transports.Recipients,
+		metadata *bux.Metadata) (*bux.Transaction, error)
+	SendToRecipientsInBatches(ctx context.Context, recipients []*transports.Recipients,
+		metadata *bux.Metadata) ([]*bux.Transaction, error)
+	SendToPaymailP2P(ctx context.Context, address string, satoshis uint64,
+		metadata *bux.Metadata) (*bux.Transaction, error)
+	SignSend(send *SendContext, draft *bux.DraftTransaction) error
+	TemplateOutput(name string, params map[string]interface{}, satoshis uint64) (*bux.TransactionOutput, error)
+}
+
+// ServerService is the status and the compatibility of the bux server
+type ServerService interface {
+	Ping(ctx context.Context) error
+	ServerInfo(ctx context.Context) (*transports.ServerInfo, error)
+	VerifyServerContract(ctx context.Context) (*ContractReport, error)
+}
+
+// TransactionService is the transaction operations
+type TransactionService interface {
+	ExportProofBundle(ctx context.Context, txIDs []string) ([]byte, error)
+	ExportTransactions(ctx context.Context, conditions map[string]interface{},
+		format ExportFormat, w io.Writer) (int, error)
+	GetBalance(ctx context.Context) (*Balance, error)
+	GetBlockHeader(ctx context.Context, blockHash string) (*transports.BlockHeader, error)
+	GetDashboard(ctx context.Context, recent int, opts *MultiQueryOptions) (*Dashboard, error)
+	GetMerkleProof(ctx context.Context, txID string) (*transports.MerkleProof, error)
+	GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error)
+	GetTransactionBEEF(ctx context.Context, txID string) (*beef.BEEF, error)
+	GetTransactionProof(ctx context.Context, txID string) (*TransactionProof, error)
+	GetTransactionStatus(ctx context.Context, txID string) (*transports.TransactionStatus, error)
+	GetTransactions(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error)
+	GetTransactionsByIDs(ctx context.Context, txIDs []string) ([]*bux.Transaction, error)
+	GetTransactionsCount(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata) (int64, error)
+	GetUtxos(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Utxo, error)
+	GetUtxosCount(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata) (int64, error)
+	ImportTransaction(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	IsSpendable(transaction *bux.Transaction, chainHeight uint64) bool
+	MigrateMetadata(ctx context.Context, selector *MetadataSelector,
+		transform MetadataTransformFunc, opts *MigrateMetadataOptions) (*MigrateMetadataResult, error)
+	RunQueries(ctx context.Context, queries map[string]Query, opts *MultiQueryOptions) error
+	SearchTransactions(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error)
+	UpdateTransactionMetadata(ctx context.Context, txID string,
+		metadata *bux.Metadata) (*bux.Transaction, error)
+	WaitForTransaction(ctx context.Context, txID string, opts *WaitOptions) (*transports.TransactionStatus, error)
+}
+
+// UsageService is the usage analytics reports
+type UsageService interface {
+	ReportUsage() error
+	RunUsageReports(ctx context.Context, interval time.Duration) error
+}
+
+// WebhookService is the webhook operations
+type WebhookService interface {
+	DeleteWebhook(ctx context.Context, id string) error
+	GetWebhooks(ctx context.Context) ([]*transports.Webhook, error)
+	RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType,
+		secret string) (*transports.Webhook, error)
+}
+
+// the bux client implements the whole interface
+var _ ClientInterface = (*BuxClient)(nil)
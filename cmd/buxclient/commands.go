@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/BuxOrg/bux"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/BuxOrg/go-buxclient/amounts"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// command is a command of the command line, it returns the result to print
+type command struct {
+	run   func(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error)
+	usage string
+}
+
+// commands are the commands of the command line, by name
+var commands = map[string]*command{
+	"admin": {
+		run:   runAdmin,
+		usage: "admin commands: create-paymail, delete-paymail, paymails, freeze, unfreeze",
+	},
+	"balance": {
+		run:   runBalance,
+		usage: "[-unit bsv|sat] [-locale tag] balance of the xPub, in satoshis and formatted in the unit",
+	},
+	"list-transactions": {
+		run:   runListTransactions,
+		usage: "[-page n] [-page-size n] list the transactions, the newest first",
+	},
+	"new-destination": {
+		run: func(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+			var metadata metadataFlag
+			if _, err := parseFlags("new-destination", args, 0, metadata.register); err != nil {
+				return nil, err
+			}
+			return client.GetDestination(ctx, metadata.value)
+		},
+		usage: "[-metadata json] create a new destination (address) of the xPub",
+	},
+	"record": {
+		run:   runRecord,
+		usage: "[-reference-id id] [-metadata json] <hex> record a transaction (hex, or - for stdin)",
+	},
+	"register-xpub": {
+		run: func(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+			var metadata metadataFlag
+			flags, err := parseFlags("register-xpub", args, 1, metadata.register)
+			if err != nil {
+				return nil, err
+			}
+			if err = client.RegisterXpub(ctx, flags.Arg(0), metadata.value); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"registered": true, "xpub": flags.Arg(0)}, nil
+		},
+		usage: "[-metadata json] <xpub> register an xPub (admin key needed)",
+	},
+	"send": {
+		run:   runSend,
+		usage: "-to address=amount [-to ...] [-unit bsv|sat] [-metadata json] send to the recipients",
+	},
+}
+
+// balance is the result of the balance command: the amounts in satoshis, and formatted in the unit
+type balance struct {
+	*buxclient.Balance
+	Display map[string]string `json:"display"`
+}
+
+// runBalance will return the balance of the xPub
+func runBalance(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+	var unit, locale string
+	if _, err := parseFlags("balance", args, 0, func(flags *flag.FlagSet) {
+		flags.StringVar(&unit, "unit", "bsv", "unit of the formatted amounts: bsv or sat")
+		flags.StringVar(&locale, "locale", "en-US", "locale of the formatted amounts (ex: de-DE)")
+	}); err != nil {
+		return nil, err
+	}
+	if err := checkUnit(unit); err != nil {
+		return nil, err
+	}
+
+	result, err := client.GetBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	formatter := amounts.NewFormatter(locale, nil)
+	display := make(map[string]string, 3)
+	for name, satoshis := range map[string]int64{
+		"pending":   result.Pending,
+		"spendable": result.Spendable,
+		"total":     result.Total,
+	} {
+		if display[name], err = formatSatoshis(ctx, formatter, satoshis, unit); err != nil {
+			return nil, err
+		}
+	}
+	return &balance{Balance: result, Display: display}, nil
+}
+
+// formatSatoshis will format the (signed) satoshis in the unit
+func formatSatoshis(ctx context.Context, formatter *amounts.Formatter, satoshis int64, unit string) (string, error) {
+	if satoshis < 0 {
+		formatted, err := formatter.Format(ctx, uint64(-satoshis), unit)
+		return "-" + formatted, err
+	}
+	return formatter.Format(ctx, uint64(satoshis), unit)
+}
+
+// checkUnit will return ErrUsage if the unit of the amounts is not bsv or sat
+func checkUnit(unit string) error {
+	switch strings.ToLower(unit) {
+	case "bsv", "sat":
+		return nil
+	}
+	return fmt.Errorf("%w: unknown unit %q (bsv or sat)", ErrUsage, unit)
+}
+
+// parseAmount will parse the amount of the unit (ex: 0.001 bsv or 100000 sat) in satoshis
+func parseAmount(amount, unit string) (uint64, error) {
+	if strings.ToLower(unit) == "bsv" {
+		return amounts.ParseBSV(amount)
+	}
+	satoshis, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		return 0, amounts.ErrInvalidAmount
+	}
+	if satoshis > amounts.MaxSatoshis {
+		return 0, amounts.ErrAmountTooLarge
+	}
+	return satoshis, nil
+}
+
+// runListTransactions will list a page of the transactions, the newest first
+func runListTransactions(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+	var page, pageSize int
+	if _, err := parseFlags("list-transactions", args, 0, func(flags *flag.FlagSet) {
+		flags.IntVar(&page, "page", 1, "page of the transactions")
+		flags.IntVar(&pageSize, "page-size", 20, "number of transactions of a page")
+	}); err != nil {
+		return nil, err
+	}
+	return client.SearchTransactions(ctx, nil, nil, transports.NewestFirst(page, pageSize))
+}
+
+// runRecord will record a transaction
+func runRecord(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+	var metadata metadataFlag
+	var referenceID string
+	flags, err := parseFlags("record", args, 1, metadata.register, func(flags *flag.FlagSet) {
+		flags.StringVar(&referenceID, "reference-id", "", "reference ID of the transaction (ID of its draft)")
+	})
+	if err != nil {
+		return nil, err
+	}
+	hex := flags.Arg(0)
+	if hex == "-" {
+		var data []byte
+		if data, err = ioutil.ReadAll(stdin); err != nil {
+			return nil, err
+		}
+		hex = strings.TrimSpace(string(data))
+	}
+	return client.RecordTransaction(ctx, hex, referenceID, metadata.value)
+}
+
+// runSend will send to the recipients
+func runSend(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+	var metadata metadataFlag
+	var to recipientsFlag
+	var unit string
+	if _, err := parseFlags("send", args, 0, metadata.register, func(flags *flag.FlagSet) {
+		flags.Var(&to, "to", "recipient (address=amount), repeatable")
+		flags.StringVar(&unit, "unit", "bsv", "unit of the amounts: bsv (ex: 0.001) or sat")
+	}); err != nil {
+		return nil, err
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("%w: send needs a recipient (-to address=amount)", ErrUsage)
+	}
+	if err := checkUnit(unit); err != nil {
+		return nil, err
+	}
+
+	recipients := make([]*transports.Recipients, 0, len(to))
+	for _, r := range to {
+		satoshis, err := parseAmount(r.amount, unit)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrUsage, r.address, err.Error())
+		}
+		recipients = append(recipients, &transports.Recipients{To: r.address, Satoshis: satoshis})
+	}
+	return client.SendToRecipients(ctx, recipients, metadata.value)
+}
+
+// runAdmin will execute an admin command
+func runAdmin(ctx context.Context, client *buxclient.BuxClient, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: missing admin command", ErrUsage)
+	}
+	name, args := args[0], args[1:]
+	switch name {
+	case "create-paymail":
+		var metadata metadataFlag
+		var xPubID, publicName, avatar string
+		flags, err := parseFlags("admin create-paymail", args, 1, metadata.register, func(flags *flag.FlagSet) {
+			flags.StringVar(&xPubID, "xpub-id", "", "ID of the xPub of the paymail")
+			flags.StringVar(&publicName, "public-name", "", "public name of the paymail")
+			flags.StringVar(&avatar, "avatar", "", "url of the avatar of the paymail")
+		})
+		if err != nil {
+			return nil, err
+		}
+		return client.AdminCreatePaymail(ctx, xPubID, flags.Arg(0), publicName, avatar, metadata.value)
+	case "delete-paymail":
+		flags, err := parseFlags("admin delete-paymail", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err = client.AdminDeletePaymail(ctx, flags.Arg(0)); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"deleted": true, "address": flags.Arg(0)}, nil
+	case "paymails":
+		if _, err := parseFlags("admin paymails", args, 0); err != nil {
+			return nil, err
+		}
+		return client.AdminGetPaymails(ctx, nil, nil, nil)
+	case "freeze":
+		var reason string
+		flags, err := parseFlags("admin freeze", args, 1, func(flags *flag.FlagSet) {
+			flags.StringVar(&reason, "reason", "", "reason of the freeze")
+		})
+		if err != nil {
+			return nil, err
+		}
+		return client.AdminFreezeXPub(ctx, flags.Arg(0), reason)
+	case "unfreeze":
+		flags, err := parseFlags("admin unfreeze", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return client.AdminUnfreezeXPub(ctx, flags.Arg(0))
+	}
+	return nil, fmt.Errorf("%w: unknown admin command %q", ErrUsage, name)
+}
+
+// parseFlags will parse the flags of a command, with the number of arguments expected
+func parseFlags(name string, args []string, arguments int, register ...func(flags *flag.FlagSet)) (*flag.FlagSet, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	for _, r := range register {
+		r(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrUsage, name, err.Error())
+	}
+	if flags.NArg() != arguments {
+		return nil, fmt.Errorf("%w: %s expects %d argument(s), got %d", ErrUsage, name, arguments, flags.NArg())
+	}
+	return flags, nil
+}
+
+// metadataFlag is the -metadata flag of a command (json object)
+type metadataFlag struct {
+	value *bux.Metadata
+}
+
+// register will register the flag
+func (m *metadataFlag) register(flags *flag.FlagSet) {
+	flags.Var(m, "metadata", "metadata (json object)")
+}
+
+// String will return the metadata as json
+func (m *metadataFlag) String() string {
+	if m == nil || m.value == nil {
+		return ""
+	}
+	data, _ := json.Marshal(m.value)
+	return string(data)
+}
+
+// Set will decode the metadata
+func (m *metadataFlag) Set(value string) error {
+	metadata := make(bux.Metadata)
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return err
+	}
+	m.value = &metadata
+	return nil
+}
+
+// recipient is a recipient of the send command, the amount is parsed in the unit of the command
+type recipient struct {
+	address string
+	amount  string
+}
+
+// recipientsFlag is the repeatable -to flag of the send command (address=amount)
+type recipientsFlag []recipient
+
+// String will return the recipients
+func (r *recipientsFlag) String() string {
+	parts := make([]string, 0, len(*r))
+	for _, to := range *r {
+		parts = append(parts, to.address+"="+to.amount)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set will add a recipient
+func (r *recipientsFlag) Set(value string) error {
+	index := strings.LastIndex(value, "=")
+	if index <= 0 || index == len(value)-1 {
+		return fmt.Errorf("%q is not address=amount", value)
+	}
+	*r = append(*r, recipient{address: value[:index], amount: value[index+1:]})
+	return nil
+}
@@ -0,0 +1,203 @@
+// Command buxclient is a command line client of the bux server, for operations and for validating the
+// deployments of the server:
+//
+//	buxclient [flags] <command> [command flags] [arguments]
+//
+// The client is configured by the environment as with NewBuxClientFromEnv (BUX_SERVER, BUX_XPRIV,
+// BUX_XPRIV_FILE, BUX_TIMEOUT, ...), the flags replace the environment variables: the keys are read from
+// the flags or from key files (-xpriv-file, ...), the environment keys are ignored when a key flag is set.
+// The amounts are in BSV (ex: 0.001) unless -unit sat is set, the results are printed as json.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	buxclient "github.com/BuxOrg/go-buxclient"
+)
+
+// ErrUsage is when the command line is invalid
+var ErrUsage = errors.New("invalid usage")
+
+// options are the global flags of the command line
+type options struct {
+	accessKey     string
+	accessKeyFile string
+	adminXPriv    string
+	adminFile     string
+	debug         bool
+	server        string
+	signRequests  bool
+	timeout       time.Duration
+	transport     string
+	xPriv         string
+	xPrivFile     string
+	xPub          string
+}
+
+// env returns the value of an environment variable
+type env func(key string) string
+
+// stdin is the input of the commands reading from the standard input (ex: record -)
+var stdin io.Reader = os.Stdin
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Getenv, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "buxclient: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+// run will execute the command line
+func run(ctx context.Context, args []string, getenv env, stdout, stderr io.Writer) error {
+	opts := &options{}
+	flags := flag.NewFlagSet("buxclient", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.server, "server", getenv("BUX_SERVER"), "url of the bux server (env BUX_SERVER)")
+	flags.StringVar(&opts.transport, "transport", envOr(getenv, "BUX_TRANSPORT", "http"), "http or graphql (env BUX_TRANSPORT)")
+	flags.StringVar(&opts.xPriv, "xpriv", "", "xPriv of the client (env BUX_XPRIV)")
+	flags.StringVar(&opts.xPrivFile, "xpriv-file", "", "file of the xPriv of the client (env BUX_XPRIV_FILE)")
+	flags.StringVar(&opts.xPub, "xpub", "", "xPub of a watch-only client (env BUX_XPUB)")
+	flags.StringVar(&opts.accessKey, "access-key", "", "access key of the client (env BUX_ACCESS_KEY)")
+	flags.StringVar(&opts.accessKeyFile, "access-key-file", "", "file of the access key (env BUX_ACCESS_KEY_FILE)")
+	flags.StringVar(&opts.adminXPriv, "admin-xpriv", "", "admin xPriv, for the admin commands (env BUX_ADMIN_XPRIV)")
+	flags.StringVar(&opts.adminFile, "admin-xpriv-file", "", "file of the admin xPriv (env BUX_ADMIN_XPRIV_FILE)")
+	flags.BoolVar(&opts.signRequests, "sign", envBool(getenv, "BUX_SIGN_REQUESTS"), "sign the requests (env BUX_SIGN_REQUESTS)")
+	flags.BoolVar(&opts.debug, "debug", envBool(getenv, "BUX_DEBUG"), "log the requests (env BUX_DEBUG)")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of the command")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: buxclient [flags] <command> [command flags] [arguments]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %-18s %s\n", name, commands[name].usage)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("%w: missing command", ErrUsage)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, flags.Arg(0))
+	}
+
+	client, err := opts.client(getenv)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	result, err := cmd.run(ctx, client, flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// client will create the bux client of the options, configured as NewBuxClientFromEnv with the flags
+// replacing the environment variables
+func (o *options) client(getenv env) (*buxclient.BuxClient, error) {
+	if o.server == "" {
+		return nil, fmt.Errorf("%w: the server url is missing (-server or BUX_SERVER)", ErrUsage)
+	}
+	clientOptions, err := buxclient.EnvOptions(o.environment(getenv))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUsage, err.Error())
+	}
+	return buxclient.New(clientOptions...)
+}
+
+// environment will return the environment with the flags: the key flags replace the keys of the
+// environment, and the admin xPriv is the xPriv of the client when no other key is set (the admin
+// commands only need the admin key)
+func (o *options) environment(getenv env) env {
+	values := map[string]string{
+		buxclient.EnvDebug:        strconv.FormatBool(o.debug),
+		buxclient.EnvServer:       o.server,
+		buxclient.EnvSignRequests: strconv.FormatBool(o.signRequests),
+		buxclient.EnvTransport:    o.transport,
+	}
+	clientKeys := map[string]string{
+		buxclient.EnvXPriv:               o.xPriv,
+		buxclient.EnvXPriv + "_FILE":     o.xPrivFile,
+		buxclient.EnvXPub:                o.xPub,
+		buxclient.EnvAccessKey:           o.accessKey,
+		buxclient.EnvAccessKey + "_FILE": o.accessKeyFile,
+	}
+	if !anySet(clientKeys) {
+		for name := range clientKeys {
+			clientKeys[name] = getenv(name)
+		}
+	}
+	adminKeys := map[string]string{
+		buxclient.EnvAdminXPriv:           o.adminXPriv,
+		buxclient.EnvAdminXPriv + "_FILE": o.adminFile,
+	}
+	if !anySet(adminKeys) {
+		for name := range adminKeys {
+			adminKeys[name] = getenv(name)
+		}
+	}
+	if !anySet(clientKeys) {
+		clientKeys[buxclient.EnvXPriv] = adminKeys[buxclient.EnvAdminXPriv]
+		clientKeys[buxclient.EnvXPriv+"_FILE"] = adminKeys[buxclient.EnvAdminXPriv+"_FILE"]
+	}
+	for _, keys := range []map[string]string{clientKeys, adminKeys} {
+		for name, value := range keys {
+			values[name] = value
+		}
+	}
+
+	return func(name string) string {
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return getenv(name)
+	}
+}
+
+// anySet will return whether one of the variables is set
+func anySet(variables map[string]string) bool {
+	for _, value := range variables {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// envOr will return the environment variable, or the default value when it is not set
+func envOr(getenv env, name, defaultValue string) string {
+	if value := getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// envBool will return whether the environment variable is true
+func envBool(getenv env, name string) bool {
+	value, _ := strconv.ParseBool(getenv(name))
+	return value
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRun will test the commands against the fake server
+func TestRun(t *testing.T) {
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	adminXPriv, _, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "xpriv")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(xPriv+"\n"), 0o600))
+
+	environment := map[string]string{"BUX_SERVER": server.URL, "BUX_SIGN_REQUESTS": "true"}
+	getenv := func(key string) string {
+		return environment[key]
+	}
+	run := func(out interface{}, args ...string) error {
+		var stdout, stderr bytes.Buffer
+		if err := run(context.Background(), args, getenv, &stdout, &stderr); err != nil {
+			return err
+		}
+		return json.Unmarshal(stdout.Bytes(), out)
+	}
+
+	t.Run("register-xpub", func(t *testing.T) {
+		var result map[string]interface{}
+		require.NoError(t, run(&result, "-admin-xpriv", adminXPriv, "register-xpub", xPub))
+		assert.Equal(t, true, result["registered"])
+	})
+
+	t.Run("new-destination", func(t *testing.T) {
+		var destination bux.Destination
+		require.NoError(t, run(&destination, "-xpriv-file", keyFile, "new-destination", "-metadata", `{"label":"invoice"}`))
+		assert.NotEmpty(t, destination.Address)
+	})
+
+	t.Run("balance and transactions", func(t *testing.T) {
+		_, err := server.Fund(xPub, 5000)
+		require.NoError(t, err)
+		environment["BUX_XPRIV"] = xPriv
+		defer delete(environment, "BUX_XPRIV")
+
+		var balance struct {
+			Total   uint64            `json:"total"`
+			Display map[string]string `json:"display"`
+		}
+		require.NoError(t, run(&balance, "-transport", "graphql", "-server", server.GraphQLURL(), "balance"))
+		assert.Equal(t, uint64(5000), balance.Total)
+		assert.Equal(t, "0.00005000 BSV", balance.Display["total"])
+		require.NoError(t, run(&balance, "-transport", "graphql", "-server", server.GraphQLURL(), "balance", "-unit", "sat", "-locale", "de-DE"))
+		assert.Equal(t, "5.000 sat", balance.Display["total"])
+
+		var transactions []*bux.Transaction
+		require.NoError(t, run(&transactions, "list-transactions", "-page-size", "10"))
+		assert.Len(t, transactions, 1)
+	})
+
+	t.Run("send", func(t *testing.T) {
+		var destination bux.Destination
+		require.NoError(t, run(&destination, "-xpriv-file", keyFile, "new-destination"))
+		before := server.Balance(xPub)
+
+		// the keys of the environment, read from a file
+		environment["BUX_XPRIV_FILE"] = keyFile
+		defer delete(environment, "BUX_XPRIV_FILE")
+		var transaction bux.Transaction
+		require.NoError(t, run(&transaction, "send", "-to", destination.Address+"=0.00001"))
+		assert.NotEmpty(t, transaction.ID)
+		assert.Equal(t, before-server.Balance(xPub), transaction.Fee)
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		var result interface{}
+		assert.ErrorIs(t, run(&result), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "unknown"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "balance"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "send", "-to", "1address"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "send"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "send", "-to", "1address=0.000000001"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "send", "-unit", "usd", "-to", "1address=1"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "-xpub", xPub, "balance"), ErrUsage)
+		assert.ErrorIs(t, run(&result, "-xpriv", xPriv, "-transport", "grpc", "balance"), ErrUsage)
+	})
+}
@@ -0,0 +1,289 @@
+// Package config builds a configured bux client from a configuration file (YAML, JSON or TOML), so the
+// services do not duplicate the wiring of the client:
+//
+//	server: https://bux.example.com/v1
+//	transport: graphql
+//	keys:
+//	  xpriv: env:BUX_XPRIV
+//	  admin_xpriv: file:/run/secrets/bux_admin_xpriv
+//	sign_requests: true
+//	timeout: 30s
+//
+// The keys are references (env:NAME, file:PATH, encrypted:PATH or keyring:NAME), never the keys
+// themselves.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/BuxOrg/go-buxclient/keystore"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a configuration file
+type Format string
+
+// Formats of the configuration files
+const (
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+	FormatYAML Format = "yaml"
+)
+
+// Transports of the client
+const (
+	TransportGraphQL = "graphql"
+	TransportHTTP    = "http"
+)
+
+// PassphraseEnv is the environment variable of the passphrase of the encrypted key files (encrypted:PATH)
+const PassphraseEnv = "BUX_KEY_PASSPHRASE"
+
+// ErrUnsupportedFormat is when the format of the configuration file is unknown
+var ErrUnsupportedFormat = errors.New("unsupported configuration format")
+
+// ErrInvalidConfig is when the configuration is invalid (ex: the server url is missing)
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// ErrInvalidTOML is when a TOML configuration can not be decoded
+var ErrInvalidTOML = errors.New("invalid toml")
+
+// ErrKeyReference is when a key is not a valid reference (see ResolveKey), or can not be resolved
+var ErrKeyReference = errors.New("invalid key reference")
+
+// Config is the configuration of a bux client
+type Config struct {
+	Debug           bool              `json:"debug"`
+	FailoverServers []string          `json:"failover_servers"` // see buxclient.WithFailover
+	Headers         map[string]string `json:"headers"`          // custom headers (ex: X-Tenant-ID)
+	Keys            Keys              `json:"keys"`
+	Server          string            `json:"server"`
+	SignRequests    bool              `json:"sign_requests"`
+	Timeout         Duration          `json:"timeout"`   // default timeout of the requests (ex: 30s)
+	Transport       string            `json:"transport"` // http (default) or graphql
+}
+
+// Keys are the references of the keys of the client (see ResolveKey), one of the xPriv, the xPub or the
+// access key must be set
+type Keys struct {
+	AccessKey  string `json:"access_key"`
+	AdminXPriv string `json:"admin_xpriv"`
+	XPriv      string `json:"xpriv"`
+	XPub       string `json:"xpub"`
+}
+
+// Duration is a duration of the configuration, a string (ex: 1m30s) or a number of seconds
+type Duration time.Duration
+
+// UnmarshalJSON will decode the duration
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Load will read the configuration file, the format is the extension of the file (.yaml, .yml, .json or .toml)
+func Load(path string) (*Config, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".json":
+		format = FormatJSON
+	case ".toml":
+		format = FormatTOML
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+
+	data, err := ioutil.ReadFile(path) //nolint:gosec // the configuration file is chosen by the application
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, format)
+}
+
+// Parse will decode and validate the configuration
+func Parse(data []byte, format Format) (*Config, error) {
+	var values map[string]interface{}
+	var err error
+	switch format {
+	case FormatJSON:
+		err = json.Unmarshal(data, &values)
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &values)
+	case FormatTOML:
+		if _, err = toml.Decode(string(data), &values); err != nil {
+			err = fmt.Errorf("%w: %s", ErrInvalidTOML, err.Error())
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the formats are decoded with the json names of the fields
+	var encoded []byte
+	if encoded, err = json.Marshal(values); err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err = json.Unmarshal(encoded, config); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	if err = config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate will return ErrInvalidConfig if the configuration can not build a client
+func (c *Config) Validate() error {
+	if c.Server == "" {
+		return fmt.Errorf("%w: the server url is missing", ErrInvalidConfig)
+	}
+	if c.Transport != "" && c.Transport != TransportHTTP && c.Transport != TransportGraphQL {
+		return fmt.Errorf("%w: unknown transport %q (http or graphql)", ErrInvalidConfig, c.Transport)
+	}
+	if c.Keys.XPriv == "" && c.Keys.XPub == "" && c.Keys.AccessKey == "" {
+		return fmt.Errorf("%w: one of the xpriv, xpub or access_key keys must be set", ErrInvalidConfig)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("%w: the timeout is negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Options will return the options of the client of the configuration, with the keys resolved
+func (c *Config) Options() ([]buxclient.ClientOps, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	opts := []buxclient.ClientOps{
+		buxclient.WithDebugging(c.Debug),
+		buxclient.WithSignRequest(c.SignRequests),
+	}
+	if c.Transport == TransportGraphQL {
+		opts = append(opts, buxclient.WithGraphQL(c.Server))
+	} else {
+		opts = append(opts, buxclient.WithHTTP(c.Server))
+	}
+
+	keys := []struct {
+		option    func(string) buxclient.ClientOps
+		reference string
+	}{
+		{buxclient.WithXPriv, c.Keys.XPriv},
+		{buxclient.WithXPub, c.Keys.XPub},
+		{buxclient.WithAccessKey, c.Keys.AccessKey},
+		{buxclient.WithAdminKey, c.Keys.AdminXPriv},
+	}
+	for _, key := range keys {
+		if key.reference == "" {
+			continue
+		}
+		value, err := ResolveKey(key.reference)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, key.option(value))
+	}
+
+	if len(c.FailoverServers) > 0 {
+		opts = append(opts, buxclient.WithFailover(c.FailoverServers, 0))
+	}
+	if len(c.Headers) > 0 {
+		headers := make(map[string][]string, len(c.Headers))
+		for name, value := range c.Headers {
+			headers[name] = []string{value}
+		}
+		opts = append(opts, buxclient.WithHeaders(headers))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, buxclient.WithDefaultTimeout(time.Duration(c.Timeout)))
+	}
+	return opts, nil
+}
+
+// NewClient will create the client of the configuration, the options are applied after the configuration
+func (c *Config) NewClient(opts ...buxclient.ClientOps) (*buxclient.BuxClient, error) {
+	configOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return buxclient.New(append(configOpts, opts...)...)
+}
+
+// LoadClient will create the client of the configuration file (see Load)
+func LoadClient(path string, opts ...buxclient.ClientOps) (*buxclient.BuxClient, error) {
+	config, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.NewClient(opts...)
+}
+
+// ResolveKey will return the key of the reference: env:NAME (environment variable), file:PATH (the
+// content of the file, without the surrounding spaces), encrypted:PATH (the key file encrypted with the
+// passphrase of PassphraseEnv, see keystore.CreateKeyFile) or keyring:NAME (the key of the OS keyring,
+// see keystore.NewKeyring, in the keystore.DefaultKeyringService service)
+func ResolveKey(reference string) (string, error) {
+	var value string
+	switch {
+	case strings.HasPrefix(reference, "env:"):
+		name := strings.TrimPrefix(reference, "env:")
+		if value = os.Getenv(name); value == "" {
+			return "", fmt.Errorf("%w: the environment variable %s is not set", ErrKeyReference, name)
+		}
+	case strings.HasPrefix(reference, "file:"):
+		data, err := ioutil.ReadFile(strings.TrimPrefix(reference, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrKeyReference, err.Error())
+		}
+		if value = strings.TrimSpace(string(data)); value == "" {
+			return "", fmt.Errorf("%w: the file %s is empty", ErrKeyReference, strings.TrimPrefix(reference, "file:"))
+		}
+	case strings.HasPrefix(reference, "encrypted:"):
+		key, err := keystore.ReadKeyFile(strings.TrimPrefix(reference, "encrypted:"), keystore.EnvPassphrase(PassphraseEnv))
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrKeyReference, err.Error())
+		}
+		value = key
+	case strings.HasPrefix(reference, "keyring:"):
+		key, err := keystore.NewKeyring(keystore.DefaultKeyringService).Get(
+			context.Background(), strings.TrimPrefix(reference, "keyring:"),
+		)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrKeyReference, err.Error())
+		}
+		value = key
+	default:
+		// the keys do not belong in the configuration files
+		return "", fmt.Errorf("%w: the keys are references (env:NAME, file:PATH, encrypted:PATH or keyring:NAME)", ErrKeyReference)
+	}
+	return value, nil
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	xPrivString = "xprv9s21ZrQH143K3N6qVJQAu4EP51qMcyrKYJLkLgmYXgz58xmVxVLSsbx2DfJUtjcnXK8NdvkHMKfmmg5AJT2nqqRWUrjSHX29qEJwBgBPkJQ"
+	xPubString  = "xpub661MyMwAqRbcFrBJbKwBGCB7d3fr2SaAuXGM95BA62X41m6eW2ehRQGW4xLi9wkEXUGnQZYxVVj4PxXnyrLk7jdqvBAs1Qq9gf6ykMvjR7J"
+)
+
+// expected is the configuration of the test files
+var expected = &Config{
+	Debug:           true,
+	FailoverServers: []string{"https://bux-2.example.com/v1"},
+	Headers:         map[string]string{"X-Tenant-ID": "tenant"},
+	Keys:            Keys{XPriv: "env:TEST_BUX_XPRIV", AdminXPriv: "file:/run/secrets/admin"},
+	Server:          "https://bux.example.com/v1",
+	SignRequests:    true,
+	Timeout:         Duration(30 * time.Second),
+	Transport:       TransportGraphQL,
+}
+
+// TestParse will test the method Parse()
+func TestParse(t *testing.T) {
+
+	t.Run("yaml", func(t *testing.T) {
+		config, err := Parse([]byte(`
+server: https://bux.example.com/v1
+transport: graphql
+keys:
+  xpriv: env:TEST_BUX_XPRIV
+  admin_xpriv: file:/run/secrets/admin
+sign_requests: true
+debug: true
+timeout: 30s
+headers:
+  X-Tenant-ID: tenant
+failover_servers:
+  - https://bux-2.example.com/v1
+`), FormatYAML)
+		require.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		config, err := Parse([]byte(`{
+			"server": "https://bux.example.com/v1",
+			"transport": "graphql",
+			"keys": {"xpriv": "env:TEST_BUX_XPRIV", "admin_xpriv": "file:/run/secrets/admin"},
+			"sign_requests": true,
+			"debug": true,
+			"timeout": 30,
+			"headers": {"X-Tenant-ID": "tenant"},
+			"failover_servers": ["https://bux-2.example.com/v1"]
+		}`), FormatJSON)
+		require.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		config, err := Parse([]byte(`
+# bux client
+server = "https://bux.example.com/v1" # the primary
+transport = 'graphql'
+sign_requests = true
+debug = true
+timeout = "30s"
+failover_servers = ["https://bux-2.example.com/v1",]
+
+[keys]
+xpriv = "env:TEST_BUX_XPRIV"
+admin_xpriv = "file:/run/secrets/admin"
+
+[headers]
+"X-Tenant-ID" = "tenant"
+`), FormatTOML)
+		require.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("toml multi-line arrays, strings and inline tables", func(t *testing.T) {
+		config, err := Parse([]byte(`
+server = """
+https://bux.example.com/v1"""
+transport = "graphql"
+sign_requests = true
+debug = true
+timeout = 30
+failover_servers = [
+  "https://bux-2.example.com/v1", # the secondary
+]
+keys = { xpriv = "env:TEST_BUX_XPRIV", admin_xpriv = 'file:/run/secrets/admin' }
+headers = { "X-Tenant-ID" = "tenant" }
+`), FormatTOML)
+		require.NoError(t, err)
+		assert.Equal(t, expected, config)
+	})
+
+	t.Run("invalid toml", func(t *testing.T) {
+		_, err := Parse([]byte("server = \"https://bux.example.com\"\nkeys xpriv"), FormatTOML)
+		assert.ErrorIs(t, err, ErrInvalidTOML)
+		assert.Contains(t, err.Error(), "line 2")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := Parse([]byte(`{}`), "xml")
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := Parse([]byte(`{"keys": {"xpriv": "env:TEST_BUX_XPRIV"}}`), FormatJSON)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = Parse([]byte(`{"server": "https://bux.example.com"}`), FormatJSON)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = Parse([]byte(`{"server": "https://bux.example.com", "transport": "grpc", "keys": {"xpub": "env:X"}}`), FormatJSON)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = Parse([]byte(`{"server": "https://bux.example.com", "timeout": "soon", "keys": {"xpub": "env:X"}}`), FormatJSON)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
+
+// TestLoadClient will test the method LoadClient()
+func TestLoadClient(t *testing.T) {
+
+	t.Run("keys from the environment and files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "admin"), []byte(xPrivString+"\n"), 0o600))
+		t.Setenv("TEST_BUX_XPRIV", xPrivString)
+
+		path := filepath.Join(dir, "bux.yml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`
+server: https://bux.example.com/v1
+keys:
+  xpriv: env:TEST_BUX_XPRIV
+  admin_xpriv: file:`+filepath.Join(dir, "admin")+`
+sign_requests: true
+debug: true
+`), 0o600))
+
+		client, err := LoadClient(path)
+		require.NoError(t, err)
+		assert.True(t, client.IsDebug())
+		assert.True(t, client.IsSignRequest())
+		assert.False(t, client.IsWatchOnly())
+	})
+
+	t.Run("encrypted key file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "xpriv.key")
+		require.NoError(t, keystore.CreateKeyFile(path, xPrivString, []byte("passphrase")))
+		config := &Config{Server: "https://bux.example.com/v1", Keys: Keys{XPriv: "encrypted:" + path}}
+
+		t.Setenv(PassphraseEnv, "wrong")
+		_, err := config.NewClient()
+		assert.ErrorIs(t, err, ErrKeyReference)
+
+		t.Setenv(PassphraseEnv, "passphrase")
+		client, err := config.NewClient()
+		require.NoError(t, err)
+		assert.False(t, client.IsWatchOnly())
+	})
+
+	t.Run("literal keys are rejected", func(t *testing.T) {
+		config := &Config{Server: "https://bux.example.com/v1", Keys: Keys{XPub: xPubString}}
+		_, err := config.NewClient()
+		assert.ErrorIs(t, err, ErrKeyReference)
+	})
+
+	t.Run("missing environment variable", func(t *testing.T) {
+		config := &Config{Server: "https://bux.example.com/v1", Keys: Keys{XPub: "env:TEST_BUX_MISSING"}}
+		_, err := config.NewClient()
+		assert.ErrorIs(t, err, ErrKeyReference)
+		assert.Contains(t, err.Error(), "TEST_BUX_MISSING")
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, err := LoadClient("bux.ini")
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+}
@@ -0,0 +1,172 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// ErrIncompatibleServer is when the server failed checks of the contract expected by the client
+var ErrIncompatibleServer = errors.New("the bux server is not compatible with the client")
+
+// ContractCheck is the result of a check of the server contract: a cheap read operation and the
+// assertions on the shape of its response
+type ContractCheck struct {
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`    // the operation failed
+	Name     string        `json:"name"`               // ex: "transactions"
+	Problems []string      `json:"problems,omitempty"` // the shape assertions that failed
+}
+
+// Passed will return whether the operation succeeded and its response has the expected shape
+func (c *ContractCheck) Passed() bool {
+	return c.Error == "" && len(c.Problems) == 0
+}
+
+// ContractReport is the compatibility of the server with the client (see VerifyServerContract)
+type ContractReport struct {
+	Checks     []*ContractCheck `json:"checks"`
+	Compatible bool             `json:"compatible"`
+	VerifiedAt time.Time        `json:"verified_at"`
+}
+
+// Failed will return the checks that did not pass
+func (r *ContractReport) Failed() []*ContractCheck {
+	failed := make([]*ContractCheck, 0)
+	for _, check := range r.Checks {
+		if !check.Passed() {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// contractCheck is a check of the server contract, returning the problems of the response shape
+type contractCheck struct {
+	name  string
+	check func(ctx context.Context) ([]string, error)
+}
+
+// VerifyServerContract will run cheap read operations against the server and assert the shape of
+// their responses, ex: at the startup of a service to detect a breaking upgrade of the server before
+// traffic flows. The report is always returned, the error is ErrIncompatibleServer when a check
+// failed (or the context error when the context is done).
+func (b *BuxClient) VerifyServerContract(ctx context.Context) (*ContractReport, error) {
+	report := &ContractReport{
+		Checks:     make([]*ContractCheck, 0),
+		VerifiedAt: b.scheduler.Now(),
+	}
+
+	for _, check := range b.contractChecks() {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		start := b.scheduler.Now()
+		problems, err := check.check(ctx)
+		result := &ContractCheck{
+			Duration: b.scheduler.Now().Sub(start),
+			Name:     check.name,
+			Problems: problems,
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		names := make([]string, 0, len(failed))
+		for _, check := range failed {
+			names = append(names, check.Name)
+		}
+		return report, errors.Wrapf(ErrIncompatibleServer, "failed checks %v", names)
+	}
+	report.Compatible = true
+	return report, nil
+}
+
+// contractChecks will return the checks of the server contract, only the operations that read data
+func (b *BuxClient) contractChecks() []*contractCheck {
+	firstPage := &transports.QueryParams{Page: 1, PageSize: 1}
+
+	return []*contractCheck{{
+		name: "feature_flags",
+		check: func(ctx context.Context) ([]string, error) {
+			_, err := b.transport.GetFeatureFlags(ctx)
+			return nil, err
+		},
+	}, {
+		name: "xpub_status",
+		check: func(ctx context.Context) ([]string, error) {
+			status, err := b.transport.GetXPubStatus(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var problems []string
+			if status == nil {
+				return append(problems, "no xpub status"), nil
+			}
+			return checkContractID(problems, "id", status.ID), nil
+		},
+	}, {
+		name: "destinations",
+		check: func(ctx context.Context) ([]string, error) {
+			destinations, err := b.transport.GetDestinations(ctx, nil, nil, firstPage)
+			if err != nil {
+				return nil, err
+			}
+			var problems []string
+			for _, destination := range destinations {
+				problems = checkContractID(problems, "id", destination.ID)
+				problems = checkContractID(problems, "xpub_id", destination.XpubID)
+				problems = checkContractValue(problems, "address", destination.Address)
+				problems = checkContractValue(problems, "locking_script", destination.LockingScript)
+			}
+			return problems, nil
+		},
+	}, {
+		name: "transactions",
+		check: func(ctx context.Context) ([]string, error) {
+			transactions, err := b.transport.SearchTransactions(ctx, nil, nil, firstPage)
+			if err != nil {
+				return nil, err
+			}
+			var problems []string
+			for _, transaction := range transactions {
+				problems = checkContractID(problems, "id", transaction.ID)
+				problems = checkContractValue(problems, "hex", transaction.Hex)
+				switch transaction.Direction {
+				case bux.TransactionDirectionIn, bux.TransactionDirectionOut, bux.TransactionDirectionReconcile:
+				default:
+					problems = append(problems, fmt.Sprintf("direction: unknown value %q", transaction.Direction))
+				}
+			}
+			return problems, nil
+		},
+	}}
+}
+
+// checkContractValue will add a problem when the field of the response is empty (ex: renamed by the server)
+func checkContractValue(problems []string, field, value string) []string {
+	if value == "" {
+		return append(problems, field+": missing")
+	}
+	return problems
+}
+
+// checkContractID will add a problem when the field of the response is not an ID (64 hex characters)
+func checkContractID(problems []string, field, value string) []string {
+	if value == "" {
+		return append(problems, field+": missing")
+	}
+	if _, err := hex.DecodeString(value); err != nil || len(value) != 64 {
+		return append(problems, fmt.Sprintf("%s: %q is not an id", field, value))
+	}
+	return problems
+}
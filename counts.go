@@ -0,0 +1,47 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// GetAccessKeysCount get the number of access keys matching search criteria (the server must support
+// FeatureAccessKeys)
+func (b *BuxClient) GetAccessKeysCount(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	if err := b.requireFeature(FeatureAccessKeys); err != nil {
+		return 0, err
+	}
+	return b.transport.Count(ctx, transports.CountAccessKeys, conditions, metadata)
+}
+
+// GetDestinationsCount get the number of destinations matching search criteria
+func (b *BuxClient) GetDestinationsCount(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	return b.transport.Count(ctx, transports.CountDestinations, conditions, metadata)
+}
+
+// GetDraftTransactionsCount get the number of draft transactions matching search criteria
+func (b *BuxClient) GetDraftTransactionsCount(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	return b.transport.Count(ctx, transports.CountDraftTransactions, conditions, metadata)
+}
+
+// GetTransactionsCount get the number of transactions matching search criteria
+func (b *BuxClient) GetTransactionsCount(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	return b.transport.Count(ctx, transports.CountTransactions, conditions, metadata)
+}
+
+// GetUtxosCount get the number of utxos matching search criteria
+func (b *BuxClient) GetUtxosCount(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	return b.transport.Count(ctx, transports.CountUtxos, conditions, metadata)
+}
@@ -0,0 +1,232 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/store"
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/pkg/errors"
+)
+
+// OperationPublishEvent is the dead letter operation of events that could not be published to a sink
+const OperationPublishEvent = "events.publish"
+
+// ErrDeadLetterNotFound is when the dead letter does not exist (or was already retried or discarded)
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrNoDeadLetterHandler is when no handler was registered for the operation of a dead letter
+var ErrNoDeadLetterHandler = errors.New("no dead letter handler registered for operation")
+
+// DeadLetter is an async operation that failed permanently and was set aside for inspection
+type DeadLetter struct {
+	ID        string          `json:"id"`
+	Operation string          `json:"operation"`
+	Payload   json.RawMessage `json:"payload"`
+	Error     string          `json:"error"`
+	Attempts  int             `json:"attempts"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// DeadLetterHandler will execute the operation of the dead letter again
+type DeadLetterHandler func(ctx context.Context, letter *DeadLetter) error
+
+// DeadLetterQueue keeps the failed async operations, so they are never silently lost
+type DeadLetterQueue struct {
+	handlers map[string]DeadLetterHandler
+	letters  map[string]*DeadLetter
+	mu       sync.Mutex
+	order    []string
+	store    store.Store
+}
+
+// NewDeadLetterQueue will create a new (in-memory) dead letter queue
+func NewDeadLetterQueue() *DeadLetterQueue {
+	return &DeadLetterQueue{
+		handlers: make(map[string]DeadLetterHandler),
+		letters:  make(map[string]*DeadLetter),
+	}
+}
+
+// NewPersistentDeadLetterQueue will create a dead letter queue kept in the store, the dead letters
+// of the store are restored (oldest failure first)
+func NewPersistentDeadLetterQueue(ctx context.Context, s store.Store) (*DeadLetterQueue, error) {
+	items, err := s.List(ctx, store.NamespaceDeadLetters)
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewDeadLetterQueue()
+	q.store = s
+	for _, item := range items {
+		letter := new(DeadLetter)
+		if err = json.Unmarshal(item.Value, letter); err != nil {
+			return nil, err
+		}
+		q.letters[letter.ID] = letter
+		q.order = append(q.order, letter.ID)
+	}
+	sort.SliceStable(q.order, func(i, j int) bool {
+		return q.letters[q.order[i]].FailedAt.Before(q.letters[q.order[j]].FailedAt)
+	})
+	return q, nil
+}
+
+// RegisterHandler will register the handler used to retry dead letters of the operation
+func (q *DeadLetterQueue) RegisterHandler(operation string, handler DeadLetterHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[operation] = handler
+}
+
+// Add will add a failed operation to the queue
+func (q *DeadLetterQueue) Add(operation string, payload interface{}, cause error) (*DeadLetter, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	if id, err = utils.RandomHex(16); err != nil {
+		return nil, err
+	}
+
+	letter := &DeadLetter{
+		ID:        id,
+		Operation: operation,
+		Payload:   data,
+		Attempts:  1,
+		FailedAt:  time.Now().UTC(),
+	}
+	if cause != nil {
+		letter.Error = cause.Error()
+	}
+	if err = q.persist(context.Background(), letter); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters[id] = letter
+	q.order = append(q.order, id)
+
+	return letter, nil
+}
+
+// List will return all dead letters, oldest first
+func (q *DeadLetterQueue) List() []*DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := make([]*DeadLetter, 0, len(q.order))
+	for _, id := range q.order {
+		letter := *q.letters[id]
+		letters = append(letters, &letter)
+	}
+	return letters
+}
+
+// Get will return a dead letter by ID
+func (q *DeadLetterQueue) Get(id string) (*DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letter, ok := q.letters[id]
+	if !ok {
+		return nil, ErrDeadLetterNotFound
+	}
+	copied := *letter
+	return &copied, nil
+}
+
+// Retry will execute the operation of the dead letter again, removing it from the queue on
+// success, or keeping it with the new error on failure
+func (q *DeadLetterQueue) Retry(ctx context.Context, id string) error {
+	q.mu.Lock()
+	letter, ok := q.letters[id]
+	var handler DeadLetterHandler
+	if ok {
+		handler = q.handlers[letter.Operation]
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return ErrDeadLetterNotFound
+	} else if handler == nil {
+		return ErrNoDeadLetterHandler
+	}
+
+	copied := *letter
+	if err := handler(ctx, &copied); err != nil {
+		q.mu.Lock()
+		letter.Attempts++
+		letter.Error = err.Error()
+		letter.FailedAt = time.Now().UTC()
+		copied = *letter
+		q.mu.Unlock()
+		// the error of the operation is returned, the store keeps the previous attempt if it fails
+		_ = q.persist(ctx, &copied)
+		return err
+	}
+
+	return q.Discard(id)
+}
+
+// Discard will remove the dead letter from the queue without retrying it
+func (q *DeadLetterQueue) Discard(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.letters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	if q.store != nil {
+		if err := q.store.Delete(context.Background(), store.NamespaceDeadLetters, id); err != nil {
+			return err
+		}
+	}
+	delete(q.letters, id)
+	for index, existing := range q.order {
+		if existing == id {
+			q.order = append(q.order[:index], q.order[index+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// persist will write the dead letter to the store of a persistent queue
+func (q *DeadLetterQueue) persist(ctx context.Context, letter *DeadLetter) error {
+	if q.store == nil {
+		return nil
+	}
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	return q.store.Put(ctx, store.NamespaceDeadLetters, letter.ID, data)
+}
+
+// EventExportOptions will return the export options to publish an event stream to the sink, events
+// that exhausted their attempts are dead lettered (and republished to the sink on retry)
+func (q *DeadLetterQueue) EventExportOptions(sink events.Sink, maxAttempts int) *events.ExportOptions {
+	q.RegisterHandler(OperationPublishEvent, func(ctx context.Context, letter *DeadLetter) error {
+		var event events.Event
+		if err := json.Unmarshal(letter.Payload, &event); err != nil {
+			return err
+		}
+		return sink.Publish(ctx, &event)
+	})
+
+	opts := events.DefaultExportOptions()
+	opts.MaxAttempts = maxAttempts
+	opts.OnFailure = func(event *events.Event, err error) error {
+		_, addErr := q.Add(OperationPublishEvent, event, err)
+		return addErr
+	}
+	return opts
+}
@@ -0,0 +1,129 @@
+package buxclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeadLetterQueue will test the dead letter queue
+func TestDeadLetterQueue(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	t.Run("client queue", func(t *testing.T) {
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTP(serverURL),
+		)
+		require.NoError(t, err)
+		require.NotNil(t, client.DeadLetters())
+		assert.Len(t, client.DeadLetters().List(), 0)
+	})
+
+	t.Run("add, list and discard", func(t *testing.T) {
+		queue := NewDeadLetterQueue()
+		letter, err := queue.Add("test", map[string]string{"key": "value"}, errFailed)
+		require.NoError(t, err)
+		assert.Equal(t, "failed", letter.Error)
+		assert.JSONEq(t, `{"key":"value"}`, string(letter.Payload))
+
+		letters := queue.List()
+		require.Len(t, letters, 1)
+		assert.Equal(t, letter.ID, letters[0].ID)
+
+		require.NoError(t, queue.Discard(letter.ID))
+		assert.Len(t, queue.List(), 0)
+		assert.ErrorIs(t, queue.Discard(letter.ID), ErrDeadLetterNotFound)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		queue := NewDeadLetterQueue()
+		letter, err := queue.Add("test", "payload", errFailed)
+		require.NoError(t, err)
+		assert.ErrorIs(t, queue.Retry(context.Background(), letter.ID), ErrNoDeadLetterHandler)
+
+		fail := true
+		queue.RegisterHandler("test", func(ctx context.Context, letter *DeadLetter) error {
+			if fail {
+				return errFailed
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, queue.Retry(context.Background(), letter.ID), errFailed)
+		letter, err = queue.Get(letter.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, letter.Attempts)
+
+		fail = false
+		require.NoError(t, queue.Retry(context.Background(), letter.ID))
+		assert.Len(t, queue.List(), 0)
+	})
+
+	t.Run("persistent", func(t *testing.T) {
+		ctx := context.Background()
+		memory := store.NewMemory()
+		queue, err := NewPersistentDeadLetterQueue(ctx, memory)
+		require.NoError(t, err)
+		first, err := queue.Add("test", "first", errFailed)
+		require.NoError(t, err)
+		second, err := queue.Add("test", "second", errFailed)
+		require.NoError(t, err)
+
+		queue.RegisterHandler("test", func(ctx context.Context, letter *DeadLetter) error {
+			return errFailed
+		})
+		assert.ErrorIs(t, queue.Retry(ctx, first.ID), errFailed)
+
+		// restored from the store, with the new attempt
+		restored, err := NewPersistentDeadLetterQueue(ctx, memory)
+		require.NoError(t, err)
+		letters := restored.List()
+		require.Len(t, letters, 2)
+		assert.Equal(t, second.ID, letters[0].ID)
+		assert.Equal(t, first.ID, letters[1].ID)
+		assert.Equal(t, 2, letters[1].Attempts)
+
+		require.NoError(t, restored.Discard(second.ID))
+		items, err := memory.List(ctx, store.NamespaceDeadLetters)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, first.ID, items[0].Key)
+	})
+
+	t.Run("event export", func(t *testing.T) {
+		queue := NewDeadLetterQueue()
+		available := false
+		var published []*events.Event
+		sink := events.SinkFunc(func(_ context.Context, event *events.Event) error {
+			if !available {
+				return errFailed
+			}
+			published = append(published, event)
+			return nil
+		})
+
+		stream := make(chan *events.Event, 1)
+		stream <- &events.Event{ID: "event-1", Type: events.EventTransactionCreated}
+		close(stream)
+
+		opts := queue.EventExportOptions(sink, 1)
+		opts.Backoff = time.Millisecond
+		require.NoError(t, events.Export(context.Background(), stream, sink, opts))
+
+		letters := queue.List()
+		require.Len(t, letters, 1)
+		assert.Equal(t, OperationPublishEvent, letters[0].Operation)
+
+		available = true
+		require.NoError(t, queue.Retry(context.Background(), letters[0].ID))
+		require.Len(t, published, 1)
+		assert.Equal(t, "event-1", published[0].ID)
+	})
+}
@@ -0,0 +1,105 @@
+package buxclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/pkg/errors"
+)
+
+// DefaultDraftExpiryMargin is the time left before the expiry of a draft under which it is renewed
+// before signing and recording (see EnsureValid)
+const DefaultDraftExpiryMargin = 10 * time.Second
+
+// ErrDraftExpired is when the draft transaction expired before it was recorded, its inputs are no
+// longer reserved and it must be drafted again (see RenewDraft)
+var ErrDraftExpired = errors.New("draft transaction expired")
+
+// RenewDraft will draft again the transaction of an expired draft: the same outputs, change and fee
+// settings and metadata, with new inputs (the draft must be signed again)
+func (b *BuxClient) RenewDraft(ctx context.Context, draft *bux.DraftTransaction) (*bux.DraftTransaction, error) {
+	if draft == nil {
+		return nil, bux.ErrDraftNotFound
+	}
+	return b.renewDraft(ctx, draft, &draft.Metadata)
+}
+
+// EnsureValid will return the draft if it does not expire within the margin (DefaultDraftExpiryMargin
+// if zero), or a renewed draft with new inputs (see RenewDraft)
+func (b *BuxClient) EnsureValid(ctx context.Context, draft *bux.DraftTransaction,
+	margin time.Duration) (*bux.DraftTransaction, error) {
+
+	if draft == nil {
+		return nil, bux.ErrDraftNotFound
+	} else if !b.draftExpired(draft.ExpiresAt, margin) {
+		return draft, nil
+	}
+	return b.RenewDraft(ctx, draft)
+}
+
+// draftExpired will return true if the draft expires within the margin (DefaultDraftExpiryMargin if
+// zero), a draft without expiry never expires
+func (b *BuxClient) draftExpired(expiresAt time.Time, margin time.Duration) bool {
+	if expiresAt.IsZero() {
+		return false
+	}
+	if margin <= 0 {
+		margin = DefaultDraftExpiryMargin
+	}
+	return !b.scheduler.Now().Add(margin).Before(expiresAt)
+}
+
+// renewDraft will draft again the transaction configuration of the draft, without its inputs, fee
+// and change which are chosen by the server for the new draft
+func (b *BuxClient) renewDraft(ctx context.Context, draft *bux.DraftTransaction,
+	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
+
+	config := draft.Configuration
+	transactionConfig := &bux.TransactionConfig{
+		ChangeDestinationsStrategy: config.ChangeDestinationsStrategy,
+		ChangeMinimumSatoshis:      config.ChangeMinimumSatoshis,
+		ChangeNumberOfDestinations: config.ChangeNumberOfDestinations,
+		ExpiresIn:                  config.ExpiresIn,
+		FeeUnit:                    config.FeeUnit,
+		FromUtxos:                  config.FromUtxos,
+		Miner:                      config.Miner,
+		SendAllTo:                  config.SendAllTo,
+		Sync:                       config.Sync,
+	}
+	if config.SendAllTo == "" {
+		for _, output := range config.Outputs {
+			renewed := &bux.TransactionOutput{
+				OpReturn: output.OpReturn,
+				Satoshis: output.Satoshis,
+				To:       output.To,
+			}
+			if output.To == "" && output.OpReturn == nil {
+				// a script output has no recipient to resolve again, its scripts are the output
+				renewed.Scripts = output.Scripts
+			}
+			transactionConfig.Outputs = append(transactionConfig.Outputs, renewed)
+		}
+	}
+	return b.DraftTransaction(ctx, transactionConfig, metadata)
+}
+
+// renewSend will renew the draft of the send context, the send context is reset to the new draft
+// which must be signed again
+func (b *BuxClient) renewSend(ctx context.Context, send *SendContext,
+	draft *bux.DraftTransaction) (*bux.DraftTransaction, error) {
+
+	renewed, err := b.renewDraft(ctx, draft, send.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	send.DraftID = ""
+	send.ExpiresAt = time.Time{}
+	send.Hex = ""
+	send.ReferenceIDs = make(map[string]string)
+	send.TxID = ""
+	if err = send.SetDraft(renewed); err != nil {
+		return nil, err
+	}
+	return renewed, nil
+}
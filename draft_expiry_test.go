@@ -0,0 +1,234 @@
+package buxclient
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDraftExpiry will test the renewal of the expired drafts
+func TestDraftExpiry(t *testing.T) {
+	ctx := context.Background()
+	metadata := &bux.Metadata{"purpose": "expiry"}
+
+	// newFundedClient will return a client of a new xPub funded with utxos of 10000 satoshis
+	newFundedClient := func(t *testing.T, server *buxtest.Server, utxos int, opts ...ClientOps) *BuxClient {
+		xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		for i := 0; i < utxos; i++ {
+			_, err = server.Fund(xPub, 10000)
+			require.NoError(t, err)
+		}
+		client, err := New(append([]ClientOps{WithXPriv(xPriv), WithHTTP(server.URL)}, opts...)...)
+		require.NoError(t, err)
+		return client
+	}
+	recipients := []*transports.Recipients{{To: testAddress, Satoshis: 1000}}
+
+	t.Run("renew draft", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+		client := newFundedClient(t, server, 2)
+
+		draft, err := client.DraftToRecipients(ctx, recipients, metadata)
+		require.NoError(t, err)
+		assert.False(t, draft.ExpiresAt.IsZero())
+
+		var renewed *bux.DraftTransaction
+		renewed, err = client.RenewDraft(ctx, draft)
+		require.NoError(t, err)
+		assert.NotEqual(t, draft.ID, renewed.ID)
+		assert.Equal(t, "expiry", renewed.Metadata["purpose"])
+		require.Len(t, renewed.Configuration.Outputs, 1)
+		assert.Equal(t, testAddress, renewed.Configuration.Outputs[0].To)
+		assert.Equal(t, uint64(1000), renewed.Configuration.Outputs[0].Satoshis)
+		require.Len(t, renewed.Configuration.Inputs, 1)
+		assert.NotEqual(t, draft.Configuration.Inputs[0].ID, renewed.Configuration.Inputs[0].ID)
+
+		var hex string
+		hex, err = client.FinalizeTransaction(renewed)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, hex, renewed.ID, nil)
+		require.NoError(t, err)
+
+		_, err = client.RenewDraft(ctx, nil)
+		assert.ErrorIs(t, err, bux.ErrDraftNotFound)
+	})
+
+	t.Run("ensure valid", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+		virtual := scheduler.NewVirtual(time.Now())
+		client := newFundedClient(t, server, 2, WithScheduler(virtual))
+
+		draft, err := client.DraftToRecipients(ctx, recipients, metadata)
+		require.NoError(t, err)
+
+		var valid *bux.DraftTransaction
+		valid, err = client.EnsureValid(ctx, draft, 0)
+		require.NoError(t, err)
+		assert.Equal(t, draft.ID, valid.ID)
+
+		// within the margin of the expiry
+		virtual.Advance(time.Until(draft.ExpiresAt) - DefaultDraftExpiryMargin/2)
+		valid, err = client.EnsureValid(ctx, draft, 0)
+		require.NoError(t, err)
+		assert.NotEqual(t, draft.ID, valid.ID)
+
+		valid, err = client.EnsureValid(ctx, &bux.DraftTransaction{}, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, valid.ExpiresAt.IsZero())
+	})
+
+	t.Run("send context", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+		client := newFundedClient(t, server, 2)
+
+		send := NewSendContext(metadata)
+		draft, err := client.DraftSend(ctx, send, recipients)
+		require.NoError(t, err)
+		assert.Equal(t, draft.ExpiresAt, send.ExpiresAt)
+		require.NoError(t, client.SignSend(send, draft))
+
+		var renewed *bux.DraftTransaction
+		renewed, err = client.renewSend(ctx, send, draft)
+		require.NoError(t, err)
+		assert.Equal(t, renewed.ID, send.DraftID)
+		assert.Equal(t, renewed.ExpiresAt, send.ExpiresAt)
+		assert.Empty(t, send.Hex)
+		assert.Empty(t, send.TxID)
+
+		require.NoError(t, client.SignSend(send, renewed))
+		var transaction *bux.Transaction
+		transaction, err = client.RecordSend(ctx, send)
+		require.NoError(t, err)
+		assert.Equal(t, renewed.ID, transaction.DraftID)
+	})
+
+	t.Run("send renews the draft expiring while signing", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+
+		// expireFirstDraft makes the first draft of the server expire before it can be recorded
+		var drafts int
+		expiresAt := regexp.MustCompile(`"expires_at":"[^"]*"`)
+		expireFirstDraft := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				resp, err := next(req)
+				if err != nil || req.URL.Path != "/transactions/new" {
+					return resp, err
+				}
+				if drafts++; drafts > 1 {
+					return resp, nil
+				}
+				body, err := ioutil.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if err != nil {
+					return nil, err
+				}
+				expired := []byte(`"expires_at":"` + time.Now().Add(DefaultDraftExpiryMargin/2).UTC().Format(time.RFC3339Nano) + `"`)
+				resp.Body = ioutil.NopCloser(bytes.NewReader(expiresAt.ReplaceAll(body, expired)))
+				resp.ContentLength = -1
+				return resp, nil
+			}
+		}
+		client := newFundedClient(t, server, 2, WithMiddleware(expireFirstDraft))
+
+		transaction, err := client.SendToRecipients(ctx, recipients, metadata)
+		require.NoError(t, err)
+		assert.Equal(t, 2, drafts)
+		assert.NotEmpty(t, transaction.DraftID)
+	})
+
+	t.Run("send re-drafts the draft expired at record time", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+
+		// expireOnRecord expires the drafts of the server before the first record
+		var records int
+		expireOnRecord := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				if req.URL.Path == "/transactions/record" {
+					if records++; records == 1 {
+						server.ExpireDrafts()
+					}
+				}
+				return next(req)
+			}
+		}
+		client := newFundedClient(t, server, 1, WithMiddleware(expireOnRecord))
+
+		transaction, err := client.SendToRecipients(ctx, recipients, metadata)
+		require.NoError(t, err)
+		assert.Equal(t, 2, records)
+		assert.NotEmpty(t, transaction.DraftID)
+
+		records = 0
+		client = newFundedClient(t, server, 1, WithMiddleware(expireOnRecord), WithAutoRedraft(false))
+		_, err = client.SendToRecipients(ctx, recipients, metadata)
+		assert.ErrorIs(t, err, ErrDraftExpired)
+		assert.Equal(t, 1, records)
+	})
+
+	t.Run("send returns the transaction recorded when the response was lost", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+
+		// loseFirstRecord records the first transaction but answers that its draft is not found, as a
+		// replayed record would be
+		var drafts, records int
+		loseFirstRecord := func(next transports.RoundTripFunc) transports.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				resp, err := next(req)
+				if err != nil {
+					return resp, err
+				}
+				switch req.URL.Path {
+				case "/transactions/new":
+					drafts++
+				case "/transactions/record":
+					if records++; records == 1 {
+						_ = resp.Body.Close()
+						return &http.Response{
+							Body: ioutil.NopCloser(bytes.NewBufferString(
+								`{"code":"error-draft-transaction-not-found","message":"corresponding draft transaction not found"}`,
+							)),
+							Header:     http.Header{"Content-Type": []string{"application/json"}},
+							Request:    req,
+							Status:     "404 Not Found",
+							StatusCode: http.StatusNotFound,
+						}, nil
+					}
+				}
+				return resp, nil
+			}
+		}
+		client := newFundedClient(t, server, 2, WithMiddleware(loseFirstRecord))
+
+		transaction, err := client.SendToRecipients(ctx, recipients, metadata)
+		require.NoError(t, err)
+		assert.Equal(t, 1, drafts)
+		assert.Equal(t, 1, records)
+		assert.NotEmpty(t, transaction.DraftID)
+
+		var count int64
+		count, err = client.GetTransactionsCount(ctx, map[string]interface{}{
+			"direction": bux.TransactionDirectionOut,
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
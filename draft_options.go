@@ -0,0 +1,93 @@
+package buxclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// Default change of DraftToRecipients: the change is split randomly among 3 destinations
+const (
+	DefaultChangeDestinations         = 3
+	DefaultChangeDestinationsStrategy = bux.ChangeStrategyRandom
+)
+
+// DraftOps are the options of a draft transaction (see DraftTransaction and DraftToRecipients)
+type DraftOps func(opts *transports.DraftOptions)
+
+// WithChangeDestinations will set the number of change destinations of the draft, and how the change
+// is split among them (bux.ChangeStrategyDefault splits it evenly, bux.ChangeStrategyRandom randomly)
+func WithChangeDestinations(number int, strategy bux.ChangeStrategy) DraftOps {
+	return func(opts *transports.DraftOptions) {
+		opts.ChangeNumberOfDestinations = number
+		opts.ChangeDestinationsStrategy = strategy
+	}
+}
+
+// WithChangeMinimumSatoshis will set the minimum satoshis of each change output, the server uses fewer
+// change destinations when the change is too small to split
+func WithChangeMinimumSatoshis(satoshis uint64) DraftOps {
+	return func(opts *transports.DraftOptions) {
+		opts.ChangeMinimumSatoshis = satoshis
+	}
+}
+
+// WithFromUtxos will pin the UTXOs funding the draft (coin control), the server can only
+// spend these UTXOs (bux.TransactionConfig.FromUtxos, see ParseUtxoPointer)
+func WithFromUtxos(utxos ...*bux.UtxoPointer) DraftOps {
+	return func(opts *transports.DraftOptions) {
+		opts.FromUtxos = append(opts.FromUtxos, utxos...)
+	}
+}
+
+// ParseUtxoPointer will parse a UTXO pointer in the txid:vout format
+func ParseUtxoPointer(outpoint string) (*bux.UtxoPointer, error) {
+	index := strings.LastIndex(outpoint, ":")
+	if index < 0 {
+		return nil, &InvalidInputError{Field: "utxo", Reason: "is not in the txid:vout format"}
+	}
+	if err := validateTxID("utxo", outpoint[:index]); err != nil {
+		return nil, err
+	}
+	vout, err := strconv.ParseUint(outpoint[index+1:], 10, 32)
+	if err != nil {
+		return nil, &InvalidInputError{Field: "utxo", Reason: "is not a valid output index"}
+	}
+	return &bux.UtxoPointer{TransactionID: outpoint[:index], OutputIndex: uint32(vout)}, nil
+}
+
+// draftOptions will apply the draft options over the defaults, nil when no option is set (the defaults
+// of the server)
+func (b *BuxClient) draftOptions(defaults transports.DraftOptions, ops []DraftOps) (*transports.DraftOptions, error) {
+	opts := &defaults
+	for _, op := range ops {
+		if op != nil {
+			op(opts)
+		}
+	}
+	if opts.IsDefault() {
+		return nil, nil
+	}
+
+	for index, utxo := range opts.FromUtxos {
+		field := fmt.Sprintf("from_utxos[%d]", index)
+		if utxo == nil {
+			return nil, &InvalidInputError{Field: field, Reason: "is nil"}
+		}
+		if err := validateTxID(field, utxo.TransactionID); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ChangeNumberOfDestinations < 0 {
+		return nil, &InvalidInputError{Field: "change_number_of_destinations", Reason: "must be positive"}
+	}
+	switch opts.ChangeDestinationsStrategy {
+	case "", bux.ChangeStrategyDefault, bux.ChangeStrategyRandom:
+	default:
+		return nil, &InvalidInputError{Field: "change_destinations_strategy", Reason: "is not a supported strategy"}
+	}
+	return opts, nil
+}
@@ -0,0 +1,168 @@
+package buxclient
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/pkg/errors"
+)
+
+// Environment variables read by NewBuxClientFromEnv, the keys can also be read from a file with the
+// variable suffixed with _FILE (ex: BUX_XPRIV_FILE=/run/secrets/bux_xpriv)
+const (
+	EnvAccessKey       = "BUX_ACCESS_KEY"
+	EnvAdminXPriv      = "BUX_ADMIN_XPRIV"
+	EnvDebug           = "BUX_DEBUG"
+	EnvFailoverServers = "BUX_FAILOVER_SERVERS" // comma separated
+	EnvServer          = "BUX_SERVER"
+	EnvSignRequests    = "BUX_SIGN_REQUESTS"
+	EnvTimeout         = "BUX_TIMEOUT" // ex: 30s
+	EnvTransport       = "BUX_TRANSPORT"
+	EnvXPriv           = "BUX_XPRIV"
+	EnvXPub            = "BUX_XPUB"
+)
+
+// ErrInvalidEnvironment is when the environment variables can not configure a client
+var ErrInvalidEnvironment = errors.New("invalid bux client environment")
+
+// NewBuxClientFromEnv will create a client configured by the environment variables (see EnvServer, ...):
+// the server url, the transport (http by default, or graphql), one of the xPriv, the xPub or the access
+// key, the admin xPriv, the signing of the requests, the debugging, the failover servers and the default
+// timeout. The options are applied after the environment.
+func NewBuxClientFromEnv(opts ...ClientOps) (*BuxClient, error) {
+	envOpts, err := EnvOptions(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	return New(append(envOpts, opts...)...)
+}
+
+// EnvOptions will return the options of the client configured by the environment variables read with
+// getenv (see NewBuxClientFromEnv), ex: to overlay the flags of a command line over the environment
+func EnvOptions(getenv func(string) string) ([]ClientOps, error) {
+	server := getenv(EnvServer)
+	if server == "" {
+		return nil, errors.Wrapf(ErrInvalidEnvironment, "%s is not set (the url of the bux server)", EnvServer)
+	}
+	if serverURL, err := url.Parse(server); err != nil || serverURL.Host == "" ||
+		(serverURL.Scheme != "http" && serverURL.Scheme != "https") {
+		return nil, errors.Wrapf(ErrInvalidEnvironment, "%s is not an http(s) url: %q", EnvServer, server)
+	}
+
+	var opts []ClientOps
+	switch transport := strings.ToLower(getenv(EnvTransport)); transport {
+	case "", "http":
+		opts = append(opts, WithHTTP(server))
+	case "graphql":
+		opts = append(opts, WithGraphQL(server))
+	default:
+		return nil, errors.Wrapf(ErrInvalidEnvironment, "%s must be http or graphql, got %q", EnvTransport, transport)
+	}
+
+	keys := make(map[string]string)
+	for _, name := range []string{EnvXPriv, EnvXPub, EnvAccessKey, EnvAdminXPriv} {
+		value, err := envKey(getenv, name)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			keys[name] = value
+		}
+	}
+	var set []string
+	for _, name := range []string{EnvXPriv, EnvXPub, EnvAccessKey} {
+		if keys[name] != "" {
+			set = append(set, name)
+		}
+	}
+	switch len(set) {
+	case 0:
+		return nil, errors.Wrapf(ErrInvalidEnvironment, "one of %s, %s or %s must be set", EnvXPriv, EnvXPub, EnvAccessKey)
+	case 1:
+	default:
+		return nil, errors.Wrapf(ErrInvalidEnvironment, "only one of %s must be set", strings.Join(set, ", "))
+	}
+
+	if xPriv := keys[EnvXPriv]; xPriv != "" {
+		if key, err := bip32.NewKeyFromString(xPriv); err != nil || !key.IsPrivate() {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s is not a valid xPriv", EnvXPriv)
+		}
+		opts = append(opts, WithXPriv(xPriv))
+	}
+	if xPub := keys[EnvXPub]; xPub != "" {
+		if key, err := bip32.NewKeyFromString(xPub); err != nil || key.IsPrivate() {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s is not a valid xPub", EnvXPub)
+		}
+		opts = append(opts, WithXPub(xPub))
+	}
+	if accessKey := keys[EnvAccessKey]; accessKey != "" {
+		if _, err := utils.ParseAccessKey(accessKey); err != nil {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s: %s", EnvAccessKey, err.Error())
+		}
+		opts = append(opts, WithAccessKey(accessKey))
+	}
+	if adminXPriv := keys[EnvAdminXPriv]; adminXPriv != "" {
+		if key, err := bip32.NewKeyFromString(adminXPriv); err != nil || !key.IsPrivate() {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s is not a valid xPriv", EnvAdminXPriv)
+		}
+		opts = append(opts, WithAdminKey(adminXPriv))
+	}
+
+	for _, flag := range []struct {
+		name   string
+		option func(bool) ClientOps
+	}{
+		{EnvDebug, WithDebugging},
+		{EnvSignRequests, WithSignRequest},
+	} {
+		value := getenv(flag.name)
+		if value == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s must be true or false, got %q", flag.name, value)
+		}
+		opts = append(opts, flag.option(enabled))
+	}
+
+	if value := getenv(EnvTimeout); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, errors.Wrapf(ErrInvalidEnvironment, "%s must be a positive duration (ex: 30s), got %q", EnvTimeout, value)
+		}
+		opts = append(opts, WithDefaultTimeout(timeout))
+	}
+	if value := getenv(EnvFailoverServers); value != "" {
+		var servers []string
+		for _, server := range strings.Split(value, ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				servers = append(servers, server)
+			}
+		}
+		opts = append(opts, WithFailover(servers, 0))
+	}
+	return opts, nil
+}
+
+// envKey will return the key of the environment variable, or of the file of the <name>_FILE variable
+func envKey(getenv func(string) string, name string) (string, error) {
+	if value := strings.TrimSpace(getenv(name)); value != "" {
+		return value, nil
+	}
+	file := getenv(name + "_FILE")
+	if file == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(file) //nolint:gosec // the key file is chosen by the deployment
+	if err != nil {
+		return "", errors.Wrapf(ErrInvalidEnvironment, "%s_FILE: %s", name, err.Error())
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,107 @@
+package buxclient
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewBuxClientFromEnv will test the method NewBuxClientFromEnv()
+func TestNewBuxClientFromEnv(t *testing.T) {
+
+	t.Run("xPriv", func(t *testing.T) {
+		t.Setenv(EnvServer, serverURL)
+		t.Setenv(EnvXPriv, xPrivString)
+		t.Setenv(EnvSignRequests, "true")
+		t.Setenv(EnvDebug, "1")
+		t.Setenv(EnvTimeout, "30s")
+
+		client, err := NewBuxClientFromEnv()
+		require.NoError(t, err)
+		assert.True(t, client.IsSignRequest())
+		assert.True(t, client.IsDebug())
+		assert.False(t, client.IsWatchOnly())
+	})
+
+	t.Run("keys from files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "xpub"), []byte(xPubString+"\n"), 0o600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "admin"), []byte(xPrivString), 0o600))
+		t.Setenv(EnvServer, serverURL)
+		t.Setenv(EnvTransport, "graphql")
+		t.Setenv(EnvXPub+"_FILE", filepath.Join(dir, "xpub"))
+		t.Setenv(EnvAdminXPriv+"_FILE", filepath.Join(dir, "admin"))
+
+		client, err := NewBuxClientFromEnv()
+		require.NoError(t, err)
+		assert.True(t, client.IsWatchOnly())
+		assert.True(t, client.HasAdminKey())
+	})
+
+	t.Run("access key", func(t *testing.T) {
+		t.Setenv(EnvServer, serverURL)
+		t.Setenv(EnvAccessKey, accessKeyString)
+
+		client, err := NewBuxClientFromEnv()
+		require.NoError(t, err)
+		assert.NotNil(t, client.accessKey)
+	})
+
+	t.Run("invalid environments", func(t *testing.T) {
+		tests := map[string]struct {
+			env     map[string]string
+			message string
+		}{
+			"missing server": {
+				env:     map[string]string{EnvXPriv: xPrivString},
+				message: EnvServer + " is not set",
+			},
+			"invalid server": {
+				env:     map[string]string{EnvServer: "example.com", EnvXPriv: xPrivString},
+				message: EnvServer + " is not an http(s) url",
+			},
+			"invalid transport": {
+				env:     map[string]string{EnvServer: serverURL, EnvTransport: "grpc", EnvXPriv: xPrivString},
+				message: EnvTransport + " must be http or graphql",
+			},
+			"missing key": {
+				env:     map[string]string{EnvServer: serverURL},
+				message: "one of " + EnvXPriv,
+			},
+			"several keys": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPriv: xPrivString, EnvAccessKey: accessKeyString},
+				message: "only one of " + EnvXPriv + ", " + EnvAccessKey,
+			},
+			"xPub as xPriv": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPriv: xPubString},
+				message: EnvXPriv + " is not a valid xPriv",
+			},
+			"invalid admin xPriv": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPub: xPubString, EnvAdminXPriv: "admin"},
+				message: EnvAdminXPriv + " is not a valid xPriv",
+			},
+			"invalid boolean": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPriv: xPrivString, EnvSignRequests: "yes"},
+				message: EnvSignRequests + " must be true or false",
+			},
+			"invalid timeout": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPriv: xPrivString, EnvTimeout: "30"},
+				message: EnvTimeout + " must be a positive duration",
+			},
+			"missing key file": {
+				env:     map[string]string{EnvServer: serverURL, EnvXPriv + "_FILE": filepath.Join(t.TempDir(), "missing")},
+				message: EnvXPriv + "_FILE",
+			},
+		}
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				_, err := EnvOptions(func(name string) string { return test.env[name] })
+				require.ErrorIs(t, err, ErrInvalidEnvironment)
+				assert.Contains(t, err.Error(), test.message)
+			})
+		}
+	})
+}
@@ -0,0 +1,134 @@
+// Package events contains the unified wallet event stream produced by the bux client,
+// and sinks that export it to external systems (Kafka, NATS...)
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// EventType is the type of wallet event
+type EventType string
+
+// Known event types
+const (
+	// EventTransactionCreated is when a new transaction was recorded for the xPub
+	EventTransactionCreated EventType = "transaction_created"
+
+	// EventDraftExpired is when a draft transaction expired before it was recorded
+	EventDraftExpired EventType = "draft_expired"
+
+	// EventDestinationUsed is when a destination received a payment
+	EventDestinationUsed EventType = "destination_used"
+)
+
+// Event is a single event on the wallet event stream
+type Event struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	XpubID        string          `json:"xpub_id"`
+	TransactionID string          `json:"transaction_id,omitempty"`
+	DraftID       string          `json:"draft_id,omitempty"`
+	Address       string          `json:"address,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Sink receives events from the event stream
+type Sink interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// SinkFunc is a function that implements the Sink interface
+type SinkFunc func(ctx context.Context, event *Event) error
+
+// Publish will call the function
+func (f SinkFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// ExportOptions are the options for exporting an event stream
+type ExportOptions struct {
+	MaxAttempts int                                 // Attempts per event, 0 retries until the context is done
+	Backoff     time.Duration                       // Wait between attempts, doubled after each failure (100ms when not positive)
+	MaxBackoff  time.Duration                       // Maximum wait between attempts
+	OnFailure   func(event *Event, err error) error // Called when an event exhausted its attempts, return nil to continue
+	Scheduler   scheduler.Scheduler                 // Scheduler of the waits between attempts, real time when nil
+}
+
+// defaultExportBackoff is the wait between the first attempts, when the backoff is not set
+const defaultExportBackoff = 100 * time.Millisecond
+
+// DefaultExportOptions will return the default export options (retry forever)
+func DefaultExportOptions() *ExportOptions {
+	return &ExportOptions{
+		Backoff:    defaultExportBackoff,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// Export will publish every event from the stream to the sink, in order, with at-least-once
+// delivery: an event is retried until the sink accepts it before the next one is read.
+// Export returns when the stream is closed or the context is done.
+func Export(ctx context.Context, stream <-chan *Event, sink Sink, opts *ExportOptions) error {
+	if opts == nil {
+		opts = DefaultExportOptions()
+	}
+	if opts.Scheduler == nil || opts.Backoff <= 0 {
+		copied := *opts
+		if copied.Scheduler == nil {
+			copied.Scheduler = scheduler.Default()
+		}
+		if copied.Backoff <= 0 { // a zero backoff would retry without waiting
+			copied.Backoff = defaultExportBackoff
+		}
+		opts = &copied
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-stream:
+			if !ok {
+				return nil
+			}
+			if err := publishWithRetry(ctx, sink, event, opts); err != nil {
+				if opts.OnFailure == nil {
+					return err
+				}
+				if err = opts.OnFailure(event, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// publishWithRetry will publish the event, retrying with backoff on failures
+func publishWithRetry(ctx context.Context, sink Sink, event *Event, opts *ExportOptions) error {
+	backoff := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		err := sink.Publish(ctx, event)
+		if err == nil {
+			return nil
+		}
+		if opts.MaxAttempts > 0 && attempt >= opts.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-opts.Scheduler.After(backoff):
+		}
+
+		backoff *= 2
+		if opts.MaxBackoff > 0 && backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
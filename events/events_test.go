@@ -0,0 +1,229 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProducer struct {
+	failures int
+	messages []testMessage
+}
+
+type testMessage struct {
+	destination string
+	key         string
+	value       []byte
+}
+
+func (p *testProducer) Produce(_ context.Context, topic string, key, value []byte) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.messages = append(p.messages, testMessage{destination: topic, key: string(key), value: value})
+	return nil
+}
+
+func (p *testProducer) Publish(_ context.Context, subject string, data []byte) error {
+	return p.Produce(context.Background(), subject, nil, data)
+}
+
+func testStream(events ...*Event) <-chan *Event {
+	stream := make(chan *Event, len(events))
+	for _, event := range events {
+		stream <- event
+	}
+	close(stream)
+	return stream
+}
+
+// TestExport will test the method Export()
+func TestExport(t *testing.T) {
+	opts := &ExportOptions{Backoff: time.Millisecond}
+
+	t.Run("kafka at least once", func(t *testing.T) {
+		producer := &testProducer{failures: 2}
+		err := Export(context.Background(), testStream(
+			&Event{ID: "1", Type: EventTransactionCreated, XpubID: "xpub-1"},
+			&Event{ID: "2", Type: EventDestinationUsed, XpubID: "xpub-2"},
+		), NewKafkaSink(producer, "wallet-events"), opts)
+		require.NoError(t, err)
+		require.Len(t, producer.messages, 2)
+		assert.Equal(t, "wallet-events", producer.messages[0].destination)
+		assert.Equal(t, "xpub-1", producer.messages[0].key)
+		assert.Equal(t, "xpub-2", producer.messages[1].key)
+
+		var event Event
+		require.NoError(t, json.Unmarshal(producer.messages[0].value, &event))
+		assert.Equal(t, EventTransactionCreated, event.Type)
+	})
+
+	t.Run("nats subjects", func(t *testing.T) {
+		producer := &testProducer{}
+		err := Export(context.Background(), testStream(
+			&Event{ID: "1", Type: EventTransactionCreated, XpubID: "xpub-1"},
+		), NewNATSSink(producer, "bux.events"), opts)
+		require.NoError(t, err)
+		require.Len(t, producer.messages, 1)
+		assert.Equal(t, "bux.events.xpub-1", producer.messages[0].destination)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		producer := &testProducer{failures: 5}
+		err := Export(context.Background(), testStream(
+			&Event{ID: "1", XpubID: "xpub-1"},
+		), NewKafkaSink(producer, "wallet-events"), &ExportOptions{Backoff: time.Millisecond, MaxAttempts: 2})
+		assert.Error(t, err)
+		assert.Len(t, producer.messages, 0)
+	})
+
+	t.Run("on failure continue", func(t *testing.T) {
+		producer := &testProducer{failures: 2}
+		var failed []*Event
+		err := Export(context.Background(), testStream(
+			&Event{ID: "1", XpubID: "xpub-1"},
+			&Event{ID: "2", XpubID: "xpub-1"},
+		), NewKafkaSink(producer, "wallet-events"), &ExportOptions{
+			Backoff:     time.Millisecond,
+			MaxAttempts: 2,
+			OnFailure: func(event *Event, err error) error {
+				failed = append(failed, event)
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+		assert.Equal(t, "1", failed[0].ID)
+		assert.Len(t, producer.messages, 1)
+	})
+
+	t.Run("virtual time", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now())
+		producer := &testProducer{failures: 2}
+
+		done := make(chan error)
+		go func() {
+			done <- Export(context.Background(), testStream(
+				&Event{ID: "1", XpubID: "xpub-1"},
+			), NewKafkaSink(producer, "wallet-events"), &ExportOptions{
+				Backoff:   time.Minute,
+				Scheduler: virtual,
+			})
+		}()
+
+		virtual.BlockUntil(1)
+		virtual.Advance(time.Minute)
+		virtual.BlockUntil(1)
+		virtual.Advance(2 * time.Minute)
+		require.NoError(t, <-done)
+		assert.Len(t, producer.messages, 1)
+	})
+
+	t.Run("default backoff", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now())
+		producer := &testProducer{failures: 1}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- Export(context.Background(), testStream(
+				&Event{ID: "1", XpubID: "xpub-1"},
+			), NewKafkaSink(producer, "wallet-events"), &ExportOptions{Scheduler: virtual})
+		}()
+
+		waiting := make(chan struct{})
+		go func() {
+			virtual.BlockUntil(1)
+			close(waiting)
+		}()
+		select {
+		case <-done:
+			t.Fatal("retried without waiting")
+		case <-waiting:
+		}
+		virtual.Advance(defaultExportBackoff)
+		require.NoError(t, <-done)
+		assert.Len(t, producer.messages, 1)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Export(ctx, make(chan *Event), SinkFunc(func(context.Context, *Event) error {
+			return nil
+		}), nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// TestWebhookSink will test the webhook sink
+func TestWebhookSink(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var all, drafts []received
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		switch req.URL.Path {
+		case "/all":
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			all = append(all, received{header: req.Header, body: body})
+		case "/drafts":
+			drafts = append(drafts, received{header: req.Header, body: body})
+		}
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.Client())
+	sink.Register(server.URL+"/all", "secret-all")
+	sink.Register(server.URL+"/drafts", "secret-drafts", EventDraftExpired)
+
+	err := Export(context.Background(), testStream(
+		&Event{ID: "1", Type: EventTransactionCreated, XpubID: "xpub-1"},
+		&Event{ID: "2", Type: EventDraftExpired, XpubID: "xpub-1", DraftID: "draft-1"},
+	), sink, &ExportOptions{Backoff: time.Millisecond})
+	require.NoError(t, err)
+
+	require.Len(t, all, 2)
+	require.Len(t, drafts, 1)
+
+	t.Run("payload", func(t *testing.T) {
+		var event Event
+		require.NoError(t, json.Unmarshal(drafts[0].body, &event))
+		assert.Equal(t, "draft-1", event.DraftID)
+		assert.Equal(t, "2", drafts[0].header.Get(WebhookHeaderEventID))
+		assert.Equal(t, string(EventDraftExpired), drafts[0].header.Get(WebhookHeaderEventType))
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		assert.NoError(t, VerifyWebhookSignature("secret-all", all[0].header, all[0].body, time.Minute))
+		assert.NoError(t, VerifyWebhookSignature("secret-drafts", drafts[0].header, drafts[0].body, 0))
+		assert.ErrorIs(t, VerifyWebhookSignature("secret-drafts", all[0].header, all[0].body, 0), ErrInvalidWebhookSignature)
+		assert.ErrorIs(t, VerifyWebhookSignature("secret-all", all[0].header, []byte("{}"), 0), ErrInvalidWebhookSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(WebhookHeaderTimestamp, "1600000000")
+		header.Set(WebhookHeaderSignature, WebhookSignature("secret", "1600000000", []byte("{}")))
+		assert.NoError(t, VerifyWebhookSignature("secret", header, []byte("{}"), 0))
+		assert.ErrorIs(t, VerifyWebhookSignature("secret", header, []byte("{}"), time.Minute), ErrInvalidWebhookSignature)
+	})
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// KafkaProducer is the minimal producer needed by the Kafka sink, it should only return
+// once the broker acknowledged the message (ex: a sync producer from sarama or kafka-go)
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaSink publishes events to a Kafka topic, keyed by xPub ID so all events
+// of an xPub land on the same partition (and are kept in order)
+type KafkaSink struct {
+	producer KafkaProducer
+	topic    string
+}
+
+// NewKafkaSink will create a new Kafka sink for the given topic
+func NewKafkaSink(producer KafkaProducer, topic string) *KafkaSink {
+	return &KafkaSink{
+		producer: producer,
+		topic:    topic,
+	}
+}
+
+// Publish will publish the event to the topic
+func (k *KafkaSink) Publish(ctx context.Context, event *Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return k.producer.Produce(ctx, k.topic, []byte(event.XpubID), value)
+}
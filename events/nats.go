@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// NATSPublisher is the minimal publisher needed by the NATS sink, it should only return
+// once the server acknowledged the message (ex: a JetStream publish)
+type NATSPublisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// NATSSink publishes events to NATS subjects partitioned by xPub ID (<prefix>.<xpub_id>)
+type NATSSink struct {
+	publisher     NATSPublisher
+	subjectPrefix string
+}
+
+// NewNATSSink will create a new NATS sink publishing under the given subject prefix
+func NewNATSSink(publisher NATSPublisher, subjectPrefix string) *NATSSink {
+	return &NATSSink{
+		publisher:     publisher,
+		subjectPrefix: subjectPrefix,
+	}
+}
+
+// Publish will publish the event to the subject of its xPub
+func (n *NATSSink) Publish(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return n.publisher.Publish(ctx, n.Subject(event), data)
+}
+
+// Subject will return the subject the event is published to
+func (n *NATSSink) Subject(event *Event) string {
+	if event.XpubID == "" {
+		return n.subjectPrefix
+	}
+	return n.subjectPrefix + "." + event.XpubID
+}
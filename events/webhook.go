@@ -0,0 +1,151 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Webhook headers sent with every event payload
+const (
+	WebhookHeaderEventID   = "X-Bux-Event-Id"
+	WebhookHeaderEventType = "X-Bux-Event-Type"
+	WebhookHeaderSignature = "X-Bux-Signature"
+	WebhookHeaderTimestamp = "X-Bux-Timestamp"
+)
+
+// ErrInvalidWebhookSignature is when the signature of a webhook payload does not match
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// webhookEndpoint is a callback URL registered on the webhook sink
+type webhookEndpoint struct {
+	eventTypes map[EventType]bool
+	secret     string
+	url        string
+}
+
+// WebhookSink POSTs events as JSON to the registered callback URLs, the payloads are signed with
+// the secret of the endpoint (see VerifyWebhookSignature). Delivery is at-least-once: when an
+// endpoint fails the event is published again to all endpoints, receivers should dedupe on the event ID
+type WebhookSink struct {
+	endpoints  []*webhookEndpoint
+	httpClient *http.Client
+	mu         sync.RWMutex
+}
+
+// NewWebhookSink will create a new webhook sink, the http client is optional
+func NewWebhookSink(httpClient *http.Client) *WebhookSink {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &WebhookSink{
+		httpClient: httpClient,
+	}
+}
+
+// Register will register a callback URL for the event types (all events if none are given)
+func (w *WebhookSink) Register(url, secret string, eventTypes ...EventType) {
+	endpoint := &webhookEndpoint{
+		eventTypes: make(map[EventType]bool, len(eventTypes)),
+		secret:     secret,
+		url:        url,
+	}
+	for _, eventType := range eventTypes {
+		endpoint.eventTypes[eventType] = true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.endpoints = append(w.endpoints, endpoint)
+}
+
+// Publish will POST the event to every endpoint registered for its type
+func (w *WebhookSink) Publish(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	w.mu.RLock()
+	endpoints := w.endpoints
+	w.mu.RUnlock()
+
+	for _, endpoint := range endpoints {
+		if len(endpoint.eventTypes) > 0 && !endpoint.eventTypes[event.Type] {
+			continue
+		}
+		if err = w.post(ctx, endpoint, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post will POST the signed payload to the endpoint, any non 2xx status is an error
+func (w *WebhookSink) post(ctx context.Context, endpoint *webhookEndpoint, event *Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEventID, event.ID)
+	req.Header.Set(WebhookHeaderEventType, string(event.Type))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, WebhookSignature(endpoint.secret, timestamp, body))
+
+	var resp *http.Response
+	if resp, err = w.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook error: " + strconv.Itoa(resp.StatusCode) + " - " + endpoint.url)
+	}
+	return nil
+}
+
+// WebhookSignature will return the signature of a webhook payload, the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" with the secret of the endpoint
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "."))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature will verify the signature of a webhook request received by a callback URL,
+// payloads older than the tolerance are rejected to prevent replays (0 disables the check)
+func VerifyWebhookSignature(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(WebhookHeaderTimestamp)
+	expected := WebhookSignature(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(WebhookHeaderSignature))) {
+		return ErrInvalidWebhookSignature
+	}
+
+	if tolerance > 0 {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrInvalidWebhookSignature
+		}
+		if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+			return ErrInvalidWebhookSignature
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package buxclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Feature flags of the bux server that gate client behaviors
+const (
+	// FeatureAccessKeys is when the server supports authentication with access keys
+	FeatureAccessKeys = "access_keys"
+
+	// FeatureBEEF is when the server accepts transactions in the BEEF format
+	FeatureBEEF = "beef"
+
+	// FeatureImportTransactions is when the server can import transactions from the chain by ID
+	FeatureImportTransactions = "import_transactions"
+
+	// FeatureScriptOutputs is when the server funds outputs given as raw locking scripts
+	FeatureScriptOutputs = "script_outputs"
+
+	// FeatureSubscriptions is when the server streams notifications and subscriptions
+	FeatureSubscriptions = "subscriptions"
+
+	// FeatureXPubFreeze is when the server supports freezing xPubs (compliance holds)
+	FeatureXPubFreeze = "xpub_freeze"
+)
+
+// ErrFeatureDisabled is when a feature is not enabled on the bux server
+var ErrFeatureDisabled = errors.New("feature is disabled on the bux server")
+
+// featureFlags are the feature flags fetched from the server, nil until fetched
+type featureFlags struct {
+	flags map[string]bool
+	mu    sync.RWMutex
+}
+
+// RefreshFeatureFlags will fetch the feature flags of the server again
+func (b *BuxClient) RefreshFeatureFlags(ctx context.Context) error {
+	flags, err := b.transport.GetFeatureFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if flags == nil {
+		flags = make(map[string]bool)
+	}
+
+	b.featureFlags.mu.Lock()
+	b.featureFlags.flags = flags
+	b.featureFlags.mu.Unlock()
+
+	b.cacheSnapshot(ctx)
+	return nil
+}
+
+// FeatureEnabled will return whether the feature is enabled on the server, all features are
+// considered enabled when the feature flags were not fetched (see WithFeatureFlags)
+func (b *BuxClient) FeatureEnabled(name string) bool {
+	b.featureFlags.mu.RLock()
+	defer b.featureFlags.mu.RUnlock()
+
+	if b.featureFlags.flags == nil {
+		return true
+	}
+	return b.featureFlags.flags[name]
+}
+
+// requireFeature will return ErrFeatureDisabled, with guidance, if the feature is not enabled
+func (b *BuxClient) requireFeature(name string) error {
+	if b.FeatureEnabled(name) {
+		return nil
+	}
+	return errors.Wrapf(
+		ErrFeatureDisabled,
+		"%s: enable the feature in the bux server configuration (or upgrade the server) to use it", name,
+	)
+}
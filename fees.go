@@ -0,0 +1,55 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+)
+
+// ErrFeeTooLow is when a draft transaction pays less than the fee of the fee quote
+var ErrFeeTooLow = errors.New("draft transaction pays less than the fee quote")
+
+// GetFeeQuote will get the fee policy of the server (ex: the fee unit of its miner), to display the
+// expected fees (see FeeQuote.Fee) and check the drafts before signing them (see CheckDraftFee)
+func (b *BuxClient) GetFeeQuote(ctx context.Context) (*transports.FeeQuote, error) {
+	feeQuote := new(transports.FeeQuote)
+	if b.cachedRead(ctx, CacheFeeQuote, noCacheKey, feeQuote) {
+		return feeQuote, nil
+	}
+	feeQuote, err := b.transport.GetFeeQuote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheFeeQuote, noCacheKey, feeQuote)
+	return feeQuote, nil
+}
+
+// CheckDraftFee will check that the fee paid by the draft transaction (inputs - outputs) covers the fee
+// of the fee quote, the size of the signed transaction is estimated with P2PKH unlocking scripts
+func CheckDraftFee(draft *bux.DraftTransaction, feeQuote *transports.FeeQuote) error {
+	if draft == nil || draft.Configuration.Inputs == nil {
+		return errors.Wrap(ErrInvalidInput, "draft transaction has no inputs")
+	}
+	tx, err := bt.NewTxFromString(draft.Hex)
+	if err != nil {
+		return err
+	}
+
+	var inputs uint64
+	for _, input := range draft.Configuration.Inputs {
+		inputs += input.Satoshis
+	}
+	outputs := tx.TotalOutputSatoshis()
+	if outputs > inputs {
+		return errors.Wrapf(ErrFeeTooLow, "outputs of %d satoshis exceed inputs of %d satoshis", outputs, inputs)
+	}
+
+	size := estimateSignedSize(tx)
+	if fee, expected := inputs-outputs, feeQuote.Fee(size); fee < expected {
+		return errors.Wrapf(ErrFeeTooLow, "%d satoshis for %d bytes, expected %d", fee, size, expected)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetFeeQuote will test the method GetFeeQuote()
+func TestGetFeeQuote(t *testing.T) {
+	feeQuoteJSON := `{"fee_unit":{"satoshis":1,"bytes":2},"miner":"taal","expires_at":"2022-02-09T16:29:08Z"}`
+	transportHandlers := []testTransportHandler{{
+		Type:      "http",
+		Path:      "/fee_quote",
+		Result:    feeQuoteJSON,
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, {
+		Type:      "graphql",
+		Path:      "/graphql",
+		Result:    `{"data":{"fee_quote":` + feeQuoteJSON + `}}`,
+		ClientURL: serverURL + `graphql`,
+		Client:    WithGraphQLClient,
+	}}
+
+	for _, transportHandler := range transportHandlers {
+		t.Run("get fee quote "+transportHandler.Type, func(t *testing.T) {
+			client := getTestBuxClient(transportHandler, false)
+
+			feeQuote, err := client.GetFeeQuote(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, &utils.FeeUnit{Satoshis: 1, Bytes: 2}, feeQuote.FeeUnit)
+			assert.Equal(t, "taal", feeQuote.Miner)
+			require.NotNil(t, feeQuote.ExpiresAt)
+			assert.Equal(t, uint64(113), feeQuote.Fee(226))
+		})
+	}
+}
+
+// TestCheckDraftFee will test the function CheckDraftFee()
+func TestCheckDraftFee(t *testing.T) {
+	var draft bux.DraftTransaction
+	require.NoError(t, json.Unmarshal([]byte(draftTxJSON), &draft))
+
+	// the draft pays 97 satoshis for an estimated 226 bytes
+	assert.NoError(t, CheckDraftFee(&draft, &transports.FeeQuote{FeeUnit: &utils.FeeUnit{Satoshis: 1, Bytes: 3}}))
+	assert.NoError(t, CheckDraftFee(&draft, &transports.FeeQuote{}))
+
+	err := CheckDraftFee(&draft, &transports.FeeQuote{FeeUnit: &utils.FeeUnit{Satoshis: 1, Bytes: 2}})
+	assert.ErrorIs(t, err, ErrFeeTooLow)
+	assert.Contains(t, err.Error(), "97 satoshis for 226 bytes, expected 113")
+
+	draft.Configuration.Inputs[0].Satoshis = 1000
+	err = CheckDraftFee(&draft, &transports.FeeQuote{})
+	assert.ErrorIs(t, err, ErrFeeTooLow)
+
+	assert.ErrorIs(t, CheckDraftFee(nil, &transports.FeeQuote{}), ErrInvalidInput)
+}
@@ -0,0 +1,72 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// ErrAccountFrozen is when the xPub is frozen by an admin (ex: compliance hold), mutations are
+// rejected with a *transports.AccountFrozenError that matches it with errors.Is
+var ErrAccountFrozen = transports.ErrAccountFrozen
+
+// GetXPubStatus will get the status of the xPub of the client (ex: frozen)
+func (b *BuxClient) GetXPubStatus(ctx context.Context) (*transports.XPubStatus, error) {
+	if err := b.requireFeature(FeatureXPubFreeze); err != nil {
+		return nil, err
+	}
+	status := new(transports.XPubStatus)
+	if b.cachedRead(ctx, CacheXPubStatus, noCacheKey, status) {
+		return status, nil
+	}
+	status, err := b.transport.GetXPubStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.cacheRead(ctx, CacheXPubStatus, noCacheKey, status)
+	return status, nil
+}
+
+// AdminFreezeXPub will freeze the xPub (ex: compliance hold), its mutations are rejected until it is
+// unfrozen - admin key needed
+func (b *BuxClient) AdminFreezeXPub(ctx context.Context, xPubID, reason string) (*transports.XPubStatus, error) {
+	if err := b.requireFeature(FeatureXPubFreeze); err != nil {
+		return nil, err
+	}
+	status, err := b.transport.AdminFreezeXPub(ctx, xPubID, reason)
+	if err != nil {
+		return nil, err
+	}
+	_ = b.invalidateCache(ctx, xPubID, CacheXPubStatus, noCacheKey)
+	return status, nil
+}
+
+// AdminUnfreezeXPub will unfreeze the xPub - admin key needed
+func (b *BuxClient) AdminUnfreezeXPub(ctx context.Context, xPubID string) (*transports.XPubStatus, error) {
+	if err := b.requireFeature(FeatureXPubFreeze); err != nil {
+		return nil, err
+	}
+	status, err := b.transport.AdminUnfreezeXPub(ctx, xPubID)
+	if err != nil {
+		return nil, err
+	}
+	_ = b.invalidateCache(ctx, xPubID, CacheXPubStatus, noCacheKey)
+	return status, nil
+}
+
+// checkNotFrozen will return an AccountFrozenError, without attempting the mutation, if the frozen
+// check is enabled (see WithFrozenCheck) and the xPub is frozen
+func (b *BuxClient) checkNotFrozen(ctx context.Context) error {
+	if !b.checkFrozen || !b.FeatureEnabled(FeatureXPubFreeze) {
+		return nil
+	}
+
+	status, err := b.transport.GetXPubStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status != nil && status.Frozen {
+		return &transports.AccountFrozenError{Reason: status.Reason}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/go-buxclient/keystore"
+	"github.com/pkg/errors"
+)
+
+// keyStoreKey is a key of the client loaded from a key store (see WithXPrivFromKeyStore)
+type keyStoreKey struct {
+	name  string
+	store keystore.KeyStore
+}
+
+// load will return the key, the error names the key that could not be loaded
+func (k *keyStoreKey) load(ctx context.Context) (string, error) {
+	key, err := k.store.Get(ctx, k.name)
+	if err != nil {
+		return "", errors.Wrapf(err, "loading the key %q", k.name)
+	}
+	return key, nil
+}
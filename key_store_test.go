@@ -0,0 +1,42 @@
+package buxclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithXPrivFromKeyStore will test the method WithXPrivFromKeyStore()
+func TestWithXPrivFromKeyStore(t *testing.T) {
+	keys := keystore.NewMemory()
+	require.NoError(t, keys.Set(context.Background(), "xpriv", xPrivString))
+	require.NoError(t, keys.Set(context.Background(), "admin", xPrivString))
+
+	t.Run("keys loaded", func(t *testing.T) {
+		client, err := New(
+			WithXPrivFromKeyStore(keys, "xpriv"),
+			WithAdminKeyFromKeyStore(keys, "admin"),
+			WithHTTP(serverURL),
+		)
+		require.NoError(t, err)
+		assert.False(t, client.IsWatchOnly())
+		assert.True(t, client.HasAdminKey())
+		assert.Equal(t, xPubString, client.xPub.String())
+	})
+
+	t.Run("access key", func(t *testing.T) {
+		require.NoError(t, keys.Set(context.Background(), "access", accessKeyString))
+		client, err := New(WithAccessKeyFromKeyStore(keys, "access"), WithHTTP(serverURL))
+		require.NoError(t, err)
+		assert.NotNil(t, client.accessKey)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := New(WithXPrivFromKeyStore(keys, "missing"), WithHTTP(serverURL))
+		assert.ErrorIs(t, err, keystore.ErrKeyNotFound)
+		assert.Contains(t, err.Error(), `"missing"`)
+	})
+}
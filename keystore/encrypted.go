@@ -0,0 +1,299 @@
+package keystore
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Parameters of the scrypt derivation of the encryption key of the new key files
+const (
+	scryptN       = 1 << 15
+	scryptP       = 1
+	scryptR       = 8
+	keyFileSuffix = ".key"
+)
+
+// Bounds of the scrypt parameters of the key files, a corrupt or hostile key file can not force a large
+// allocation (the derivation uses 128 * N * r bytes) or a long derivation
+const (
+	maxScryptMemory = 256 << 20
+	maxScryptP      = 16
+)
+
+// Versions of the format of the key files: the header (the parameters, the salt and the nonce) is
+// authenticated as the additional data of the encryption since version 2
+const (
+	keyFileVersion       = 2
+	legacyKeyFileVersion = 1
+)
+
+// ErrInvalidKeyFile is when a key file is not an encrypted key file
+var ErrInvalidKeyFile = errors.New("invalid encrypted key file")
+
+// ErrWrongPassphrase is when the passphrase can not decrypt the key file
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted key file")
+
+// ErrEmptyPassphrase is when the passphrase of a key file is empty
+var ErrEmptyPassphrase = errors.New("the passphrase of a key file cannot be empty")
+
+// PassphraseFunc returns the passphrase of the encrypted key files (ex: EnvPassphrase or PromptPassphrase)
+type PassphraseFunc func() ([]byte, error)
+
+// EnvPassphrase will return the passphrase of the environment variable (ex: BUX_KEY_PASSPHRASE)
+func EnvPassphrase(name string) PassphraseFunc {
+	return func() ([]byte, error) {
+		passphrase := os.Getenv(name)
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: the environment variable %s is not set", ErrEmptyPassphrase, name)
+		}
+		return []byte(passphrase), nil
+	}
+}
+
+// PromptPassphrase will write the prompt to out and read the passphrase (a line) from in (ex: os.Stdin),
+// the passphrase is not echoed when in is a terminal
+func PromptPassphrase(in io.Reader, out io.Writer, prompt string) PassphraseFunc {
+	return func() ([]byte, error) {
+		if _, err := fmt.Fprint(out, prompt); err != nil {
+			return nil, err
+		}
+		if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+			passphrase, err := term.ReadPassword(int(file.Fd()))
+			_, _ = fmt.Fprintln(out)
+			return passphrase, err
+		}
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+}
+
+// keyFile is the content of an encrypted key file: the key is encrypted with AES-256-GCM, with the
+// encryption key derived from the passphrase with scrypt
+type keyFile struct {
+	Ciphertext []byte `json:"ciphertext"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	Nonce      []byte `json:"nonce"`
+	P          int    `json:"p"`
+	R          int    `json:"r"`
+	Salt       []byte `json:"salt"`
+	Version    int    `json:"version"`
+}
+
+// EncryptKey will encrypt the key (ex: an xPriv) with the passphrase, and return the content of the key file
+func EncryptKey(key string, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+
+	file := &keyFile{KDF: "scrypt", N: scryptN, P: scryptP, R: scryptR, Version: keyFileVersion}
+	file.Salt = make([]byte, 16)
+	if _, err := rand.Read(file.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := file.cipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(file.Nonce); err != nil {
+		return nil, err
+	}
+	header, err := file.header()
+	if err != nil {
+		return nil, err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, []byte(key), header)
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// DecryptKey will decrypt the content of a key file with the passphrase
+func DecryptKey(data, passphrase []byte) (string, error) {
+	file := &keyFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKeyFile, err.Error())
+	}
+	if file.Version != keyFileVersion && file.Version != legacyKeyFileVersion || file.KDF != "scrypt" {
+		return "", fmt.Errorf("%w: unsupported version %d (%s)", ErrInvalidKeyFile, file.Version, file.KDF)
+	}
+	if err := file.checkParameters(); err != nil {
+		return "", err
+	}
+	aead, err := file.cipher(passphrase)
+	if err != nil {
+		return "", err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return "", fmt.Errorf("%w: invalid nonce", ErrInvalidKeyFile)
+	}
+	header, err := file.header()
+	if err != nil {
+		return "", err
+	}
+	key, err := aead.Open(nil, file.Nonce, file.Ciphertext, header)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(key), nil
+}
+
+// checkParameters will return ErrInvalidKeyFile if the scrypt parameters are out of bounds (N a power
+// of 2, the memory and the parallelization bounded)
+func (f *keyFile) checkParameters() error {
+	if f.N < 2 || f.N&(f.N-1) != 0 || f.R < 1 || f.P < 1 || f.P > maxScryptP ||
+		f.N > maxScryptMemory/128/f.R {
+		return fmt.Errorf("%w: scrypt parameters out of bounds (n=%d, r=%d, p=%d)", ErrInvalidKeyFile, f.N, f.R, f.P)
+	}
+	return nil
+}
+
+// header will return the header of the key file, authenticated as the additional data of the
+// encryption (none for the legacy key files)
+func (f *keyFile) header() ([]byte, error) {
+	if f.Version == legacyKeyFileVersion {
+		return nil, nil
+	}
+	return json.Marshal(&keyFile{KDF: f.KDF, N: f.N, Nonce: f.Nonce, P: f.P, R: f.R, Salt: f.Salt, Version: f.Version})
+}
+
+// cipher will return the cipher of the key derived from the passphrase
+func (f *keyFile) cipher(passphrase []byte) (cipher.AEAD, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+	key, err := scrypt.Key(passphrase, f.Salt, f.N, f.R, f.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKeyFile, err.Error())
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// CreateKeyFile will create the key file of the key encrypted with the passphrase (readable by the
+// owner only), an existing file is not overwritten
+func CreateKeyFile(path, key string, passphrase []byte) error {
+	data, err := EncryptKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // chosen by the application
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadKeyFile will decrypt the key file with the passphrase
+func ReadKeyFile(path string, passphrase PassphraseFunc) (string, error) {
+	data, err := ioutil.ReadFile(path) //nolint:gosec // chosen by the application
+	if err != nil {
+		return "", err
+	}
+	secret, err := passphrase()
+	if err != nil {
+		return "", err
+	}
+	return DecryptKey(data, secret)
+}
+
+// EncryptedFiles is the key store of encrypted key files in a directory (<name>.key), encrypted with
+// the same passphrase
+type EncryptedFiles struct {
+	dir        string
+	passphrase PassphraseFunc
+}
+
+// NewEncryptedFiles will return the key store of the encrypted key files of the directory, the
+// passphrase is asked when a key is read or written
+func NewEncryptedFiles(dir string, passphrase PassphraseFunc) *EncryptedFiles {
+	return &EncryptedFiles{dir: dir, passphrase: passphrase}
+}
+
+// Delete will remove the key file
+func (e *EncryptedFiles) Delete(_ context.Context, name string) error {
+	path, err := e.path(name)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Get will decrypt the key file
+func (e *EncryptedFiles) Get(_ context.Context, name string) (string, error) {
+	path, err := e.path(name)
+	if err != nil {
+		return "", err
+	}
+	key, err := ReadKeyFile(path, e.passphrase)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrKeyNotFound
+	}
+	return key, err
+}
+
+// Set will encrypt the key in its key file, replacing the existing key file
+func (e *EncryptedFiles) Set(_ context.Context, name, key string) error {
+	path, err := e.path(name)
+	if err != nil {
+		return err
+	}
+	passphrase, err := e.passphrase()
+	if err != nil {
+		return err
+	}
+	data, err := EncryptKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+
+	// written to a temporary file first, so a key file is never partially written
+	temporary, err := ioutil.TempFile(e.dir, ".tmp-"+name)
+	if err != nil {
+		return err
+	}
+	if _, err = temporary.Write(data); err != nil {
+		_ = temporary.Close()
+		_ = os.Remove(temporary.Name())
+		return err
+	}
+	if err = temporary.Close(); err != nil {
+		_ = os.Remove(temporary.Name())
+		return err
+	}
+	return os.Rename(temporary.Name(), path)
+}
+
+// path will return the path of the key file of the name
+func (e *EncryptedFiles) path(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", ErrInvalidKeyName
+	}
+	return filepath.Join(e.dir, name+keyFileSuffix), nil
+}
@@ -0,0 +1,157 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "xprv9s21ZrQH143K3N6qVJQAu4EP51qMcyrKYJLkLgmYXgz58xmVxVLSsbx2DfJUtjcnXK8NdvkHMKfmmg5AJT2nqqRWUrjSHX29qEJwBgBPkJQ"
+
+// TestEncryptKey will test the methods EncryptKey() and DecryptKey()
+func TestEncryptKey(t *testing.T) {
+
+	t.Run("round trip", func(t *testing.T) {
+		data, err := EncryptKey(testKey, []byte("passphrase"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), testKey)
+
+		key, err := DecryptKey(data, []byte("passphrase"))
+		require.NoError(t, err)
+		assert.Equal(t, testKey, key)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		data, err := EncryptKey(testKey, []byte("passphrase"))
+		require.NoError(t, err)
+
+		_, err = DecryptKey(data, []byte("wrong"))
+		assert.ErrorIs(t, err, ErrWrongPassphrase)
+	})
+
+	t.Run("empty passphrase", func(t *testing.T) {
+		_, err := EncryptKey(testKey, nil)
+		assert.ErrorIs(t, err, ErrEmptyPassphrase)
+	})
+
+	t.Run("invalid key file", func(t *testing.T) {
+		_, err := DecryptKey([]byte(testKey), []byte("passphrase"))
+		assert.ErrorIs(t, err, ErrInvalidKeyFile)
+
+		_, err = DecryptKey([]byte(`{"version":2,"kdf":"argon2"}`), []byte("passphrase"))
+		assert.ErrorIs(t, err, ErrInvalidKeyFile)
+	})
+
+	t.Run("parameters out of bounds", func(t *testing.T) {
+		data, err := EncryptKey(testKey, []byte("passphrase"))
+		require.NoError(t, err)
+		for _, parameters := range []struct{ n, r, p int }{
+			{1 << 30, 8, 1}, {1<<15 + 1, 8, 1}, {1 << 15, 0, 1}, {1 << 15, 8, 1 << 20}, {1 << 15, 1 << 20, 1},
+		} {
+			file := &keyFile{}
+			require.NoError(t, json.Unmarshal(data, file))
+			file.N, file.R, file.P = parameters.n, parameters.r, parameters.p
+			tampered, err := json.Marshal(file)
+			require.NoError(t, err)
+
+			_, err = DecryptKey(tampered, []byte("passphrase"))
+			assert.ErrorIs(t, err, ErrInvalidKeyFile)
+		}
+	})
+
+	t.Run("authenticated header", func(t *testing.T) {
+		data, err := EncryptKey(testKey, []byte("passphrase"))
+		require.NoError(t, err)
+		file := &keyFile{}
+		require.NoError(t, json.Unmarshal(data, file))
+		assert.Equal(t, keyFileVersion, file.Version)
+
+		// downgraded to a key file without additional data
+		file.Version = legacyKeyFileVersion
+		tampered, err := json.Marshal(file)
+		require.NoError(t, err)
+		_, err = DecryptKey(tampered, []byte("passphrase"))
+		assert.ErrorIs(t, err, ErrWrongPassphrase)
+	})
+
+	t.Run("legacy key file", func(t *testing.T) {
+		file := &keyFile{KDF: "scrypt", N: 1 << 10, P: 1, R: 8, Salt: []byte("0123456789abcdef"), Version: legacyKeyFileVersion}
+		aead, err := file.cipher([]byte("passphrase"))
+		require.NoError(t, err)
+		file.Nonce = make([]byte, aead.NonceSize())
+		file.Ciphertext = aead.Seal(nil, file.Nonce, []byte(testKey), nil)
+		data, err := json.Marshal(file)
+		require.NoError(t, err)
+
+		key, err := DecryptKey(data, []byte("passphrase"))
+		require.NoError(t, err)
+		assert.Equal(t, testKey, key)
+	})
+}
+
+// TestCreateKeyFile will test the methods CreateKeyFile() and ReadKeyFile()
+func TestCreateKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xpriv.key")
+	require.NoError(t, CreateKeyFile(path, testKey, []byte("passphrase")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// not overwritten
+	assert.ErrorIs(t, CreateKeyFile(path, "other", []byte("passphrase")), os.ErrExist)
+
+	t.Run("passphrase of the environment", func(t *testing.T) {
+		t.Setenv("TEST_BUX_KEY_PASSPHRASE", "passphrase")
+		key, err := ReadKeyFile(path, EnvPassphrase("TEST_BUX_KEY_PASSPHRASE"))
+		require.NoError(t, err)
+		assert.Equal(t, testKey, key)
+
+		_, err = ReadKeyFile(path, EnvPassphrase("TEST_BUX_MISSING"))
+		assert.ErrorIs(t, err, ErrEmptyPassphrase)
+	})
+
+	t.Run("prompted passphrase", func(t *testing.T) {
+		var out bytes.Buffer
+		key, err := ReadKeyFile(path, PromptPassphrase(strings.NewReader("passphrase\n"), &out, "Passphrase: "))
+		require.NoError(t, err)
+		assert.Equal(t, testKey, key)
+		assert.Equal(t, "Passphrase: ", out.String())
+	})
+}
+
+// TestEncryptedFiles will test the key store of the encrypted key files
+func TestEncryptedFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keys := NewEncryptedFiles(dir, func() ([]byte, error) { return []byte("passphrase"), nil })
+
+	_, err := keys.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, keys.Set(ctx, "xpriv", testKey))
+	require.NoError(t, keys.Set(ctx, "xpriv", testKey))
+	key, err := keys.Get(ctx, "xpriv")
+	require.NoError(t, err)
+	assert.Equal(t, testKey, key)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "xpriv.key", files[0].Name())
+
+	require.NoError(t, keys.Delete(ctx, "xpriv"))
+	require.NoError(t, keys.Delete(ctx, "xpriv"))
+	_, err = keys.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.ErrorIs(t, keys.Set(ctx, "../xpriv", testKey), ErrInvalidKeyName)
+}
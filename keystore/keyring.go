@@ -0,0 +1,101 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DefaultKeyringService is the service of the keys of the bux client in the OS keyring, by default
+const DefaultKeyringService = "bux"
+
+// ErrKeyringUnavailable is when the OS keyring can not be used (ex: the platform is not supported, or
+// the keyring tool is not installed)
+var ErrKeyringUnavailable = errors.New("the OS keyring is not available")
+
+// commandRunner runs a command of the keyring tool with the input, and returns its output
+type commandRunner func(ctx context.Context, input, name string, args ...string) (string, error)
+
+// Keyring is the key store of the OS keyring: the keychain on macOS (security) and the Secret Service
+// on Linux and the BSDs (secret-tool of libsecret), the keys are stored under the service
+type Keyring struct {
+	run     commandRunner
+	service string
+}
+
+// NewKeyring will return the key store of the OS keyring, the keys are stored under the service
+// (DefaultKeyringService if empty)
+func NewKeyring(service string) *Keyring {
+	if service == "" {
+		service = DefaultKeyringService
+	}
+	return &Keyring{run: runCommand, service: service}
+}
+
+// Delete will remove the key from the keyring
+func (k *Keyring) Delete(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidKeyName
+	}
+	return k.delete(ctx, name)
+}
+
+// Get will return the key of the keyring
+func (k *Keyring) Get(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidKeyName
+	}
+	return k.get(ctx, name)
+}
+
+// Set will insert or replace the key in the keyring
+func (k *Keyring) Set(ctx context.Context, name, key string) error {
+	if name == "" {
+		return ErrInvalidKeyName
+	}
+	return k.set(ctx, name, key)
+}
+
+// runCommand will run the command, the input is written to its standard input (so the keys are not
+// visible in the arguments of the process)
+func runCommand(ctx context.Context, input, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:gosec // the keyring tools are fixed
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%w: %s", ErrKeyringUnavailable, err.Error())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", &commandError{code: exitErr.ExitCode(), message: strings.TrimSpace(stderr.String())}
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// commandError is when a keyring tool exits with an error
+type commandError struct {
+	code    int
+	message string
+}
+
+// Error will return the error message
+func (e *commandError) Error() string {
+	return fmt.Sprintf("keyring error (exit code %d): %s", e.code, e.message)
+}
+
+// exitCode will return the exit code of the error of a keyring tool, or -1
+func exitCode(err error) int {
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.code
+	}
+	return -1
+}
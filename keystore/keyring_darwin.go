@@ -0,0 +1,42 @@
+//go:build darwin
+// +build darwin
+
+package keystore
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// securityNotFound is the exit code of security when the item does not exist in the keychain
+const securityNotFound = 44
+
+// get will return the key of the keychain
+func (k *Keyring) get(ctx context.Context, name string) (string, error) {
+	key, err := k.run(ctx, "", "/usr/bin/security", "find-generic-password", "-s", k.service, "-a", name, "-w")
+	if exitCode(err) == securityNotFound {
+		return "", ErrKeyNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(key, "\n"), nil
+}
+
+// set will insert or replace the key in the keychain, the command is read from the standard input
+// (security -i) so the key is not visible in the arguments of the process
+func (k *Keyring) set(ctx context.Context, name, key string) error {
+	command := "add-generic-password -U -s " + strconv.Quote(k.service) + " -a " + strconv.Quote(name) +
+		" -w " + strconv.Quote(key) + "\n"
+	_, err := k.run(ctx, command, "/usr/bin/security", "-i")
+	return err
+}
+
+// delete will remove the key from the keychain
+func (k *Keyring) delete(ctx context.Context, name string) error {
+	_, err := k.run(ctx, "", "/usr/bin/security", "delete-generic-password", "-s", k.service, "-a", name)
+	if exitCode(err) == securityNotFound {
+		return nil
+	}
+	return err
+}
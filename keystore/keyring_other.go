@@ -0,0 +1,30 @@
+//go:build !darwin && !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+// +build !darwin,!linux,!freebsd,!openbsd,!netbsd,!dragonfly
+
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// get will return ErrKeyringUnavailable, the keyring of the platform is not supported
+func (k *Keyring) get(context.Context, string) (string, error) {
+	return "", unsupported()
+}
+
+// set will return ErrKeyringUnavailable, the keyring of the platform is not supported
+func (k *Keyring) set(context.Context, string, string) error {
+	return unsupported()
+}
+
+// delete will return ErrKeyringUnavailable, the keyring of the platform is not supported
+func (k *Keyring) delete(context.Context, string) error {
+	return unsupported()
+}
+
+// unsupported will return the error of the platform
+func unsupported() error {
+	return fmt.Errorf("%w: %s is not supported (use another KeyStore)", ErrKeyringUnavailable, runtime.GOOS)
+}
@@ -0,0 +1,36 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+// +build linux freebsd openbsd netbsd dragonfly
+
+package keystore
+
+import (
+	"context"
+	"strings"
+)
+
+// get will return the key of the Secret Service (secret-tool exits with 1 when the key does not exist)
+func (k *Keyring) get(ctx context.Context, name string) (string, error) {
+	key, err := k.run(ctx, "", "secret-tool", "lookup", "service", k.service, "account", name)
+	if exitCode(err) == 1 {
+		return "", ErrKeyNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(key, "\n"), nil
+}
+
+// set will insert or replace the key in the Secret Service, the key is read from the standard input
+func (k *Keyring) set(ctx context.Context, name, key string) error {
+	_, err := k.run(ctx, key, "secret-tool", "store", "--label", k.service+"/"+name,
+		"service", k.service, "account", name)
+	return err
+}
+
+// delete will remove the key from the Secret Service
+func (k *Keyring) delete(ctx context.Context, name string) error {
+	_, err := k.run(ctx, "", "secret-tool", "clear", "service", k.service, "account", name)
+	if exitCode(err) == 1 {
+		return nil
+	}
+	return err
+}
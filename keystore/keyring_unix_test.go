@@ -0,0 +1,73 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+// +build linux freebsd openbsd netbsd dragonfly
+
+package keystore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretTool is a fake secret-tool, storing the secrets in memory
+type fakeSecretTool struct {
+	args    [][]string
+	secrets map[string]string
+}
+
+// run will run the fake command
+func (f *fakeSecretTool) run(_ context.Context, input, name string, args ...string) (string, error) {
+	f.args = append(f.args, append([]string{name}, args...))
+	attributes := strings.Join(args[len(args)-4:], " ")
+	switch args[0] {
+	case "lookup":
+		secret, ok := f.secrets[attributes]
+		if !ok {
+			return "", &commandError{code: 1}
+		}
+		return secret + "\n", nil
+	case "store":
+		f.secrets[attributes] = input
+	case "clear":
+		if _, ok := f.secrets[attributes]; !ok {
+			return "", &commandError{code: 1}
+		}
+		delete(f.secrets, attributes)
+	}
+	return "", nil
+}
+
+// TestKeyring will test the keyring with the Secret Service
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	tool := &fakeSecretTool{secrets: make(map[string]string)}
+	keyring := &Keyring{run: tool.run, service: "bux"}
+
+	_, err := keyring.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, keyring.Set(ctx, "xpriv", "key"))
+	key, err := keyring.Get(ctx, "xpriv")
+	require.NoError(t, err)
+	assert.Equal(t, "key", key)
+
+	// the key is not in the arguments of the process
+	for _, args := range tool.args {
+		assert.NotContains(t, args, "key")
+	}
+	assert.Equal(t, []string{"secret-tool", "store", "--label", "bux/xpriv", "service", "bux", "account", "xpriv"}, tool.args[1])
+
+	require.NoError(t, keyring.Delete(ctx, "xpriv"))
+	require.NoError(t, keyring.Delete(ctx, "xpriv"))
+	_, err = keyring.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	t.Run("secret-tool not installed", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := NewKeyring("").Get(ctx, "xpriv")
+		assert.ErrorIs(t, err, ErrKeyringUnavailable)
+	})
+}
@@ -0,0 +1,80 @@
+// Package keystore is the storage of the private keys of the client (ex: the xPriv), so the keys do not
+// need to be passed around as plaintext strings in the configuration
+//
+// Use the OS keyring (macOS keychain, Secret Service on Linux) in production, and the in-memory key
+// store in tests. The key stores can be used with buxclient.WithXPrivFromKeyStore.
+package keystore
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrKeyNotFound is when the key store does not have the key
+var ErrKeyNotFound = errors.New("key not found in the key store")
+
+// ErrInvalidKeyName is when the name of a key is empty
+var ErrInvalidKeyName = errors.New("the name of a key cannot be empty")
+
+// KeyStore is the storage of the keys, by name
+type KeyStore interface {
+	// Delete will remove the key, removing a key that does not exist is not an error
+	Delete(ctx context.Context, name string) error
+
+	// Get will return the key, or ErrKeyNotFound
+	Get(ctx context.Context, name string) (string, error)
+
+	// Set will insert or replace the key
+	Set(ctx context.Context, name, key string) error
+}
+
+// Memory is the in-memory key store, the keys are lost when the process stops
+type Memory struct {
+	keys map[string]string
+	mu   sync.RWMutex
+}
+
+// NewMemory will create a new empty in-memory key store
+func NewMemory() *Memory {
+	return &Memory{keys: make(map[string]string)}
+}
+
+// Delete will remove the key
+func (m *Memory) Delete(_ context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidKeyName
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, name)
+	return nil
+}
+
+// Get will return the key
+func (m *Memory) Get(_ context.Context, name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidKeyName
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[name]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// Set will insert or replace the key
+func (m *Memory) Set(_ context.Context, name, key string) error {
+	if name == "" {
+		return ErrInvalidKeyName
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[name] = key
+	return nil
+}
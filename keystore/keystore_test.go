@@ -0,0 +1,44 @@
+package keystore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemory will test the in-memory key store
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	keys := NewMemory()
+
+	_, err := keys.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, keys.Set(ctx, "xpriv", "key"))
+	key, err := keys.Get(ctx, "xpriv")
+	require.NoError(t, err)
+	assert.Equal(t, "key", key)
+
+	require.NoError(t, keys.Set(ctx, "xpriv", "rotated"))
+	key, err = keys.Get(ctx, "xpriv")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", key)
+
+	require.NoError(t, keys.Delete(ctx, "xpriv"))
+	require.NoError(t, keys.Delete(ctx, "xpriv"))
+	_, err = keys.Get(ctx, "xpriv")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.ErrorIs(t, keys.Set(ctx, "", "key"), ErrInvalidKeyName)
+}
+
+// TestNewKeyring will test the method NewKeyring()
+func TestNewKeyring(t *testing.T) {
+	assert.Equal(t, DefaultKeyringService, NewKeyring("").service)
+	assert.Equal(t, "wallet", NewKeyring("wallet").service)
+
+	_, err := NewKeyring("").Get(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidKeyName)
+}
@@ -0,0 +1,229 @@
+// Package ledger is an optional embedded (SQLite) ledger of the transactions of an xPub,
+// kept up to date from the bux server, with a small query API for local history search
+//
+// The package does not import a database driver, open the database with the SQLite
+// driver of your choice (ex: github.com/mattn/go-sqlite3) and pass it to New()
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// Metadata keys used to populate the label and counterparty of an entry
+const (
+	MetadataLabel        = "label"
+	MetadataCounterparty = "counterparty"
+)
+
+// Ledger is the local ledger
+type Ledger struct {
+	db        *sql.DB
+	scheduler scheduler.Scheduler
+}
+
+// Entry is a single transaction in the ledger
+type Entry struct {
+	ID           string                 `json:"id"`
+	XpubID       string                 `json:"xpub_id"`
+	Direction    string                 `json:"direction"`
+	Amount       int64                  `json:"amount"` // Net satoshis for the xPub (negative when sending)
+	Fee          uint64                 `json:"fee"`
+	TotalValue   uint64                 `json:"total_value"`
+	BlockHeight  uint64                 `json:"block_height"`
+	Label        string                 `json:"label"`
+	Counterparty string                 `json:"counterparty"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// Query is the set of filters used to search the ledger
+type Query struct {
+	XpubID       string    // Entries of the xPub, empty for all xPubs
+	From         time.Time // Inclusive, zero for no lower bound
+	To           time.Time // Exclusive, zero for no upper bound
+	Label        string
+	Counterparty string
+	Limit        int
+	Offset       int
+	Descending   bool
+}
+
+// New will create a new ledger on the given database and run any pending migrations
+func New(ctx context.Context, db *sql.DB) (*Ledger, error) {
+	l := &Ledger{db: db, scheduler: scheduler.Default()}
+	if err := l.migrate(ctx); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetScheduler will set the scheduler of the sync loop of Run (real time by default)
+func (l *Ledger) SetScheduler(scheduler scheduler.Scheduler) {
+	l.scheduler = scheduler
+}
+
+// DB will return the underlying database
+func (l *Ledger) DB() *sql.DB {
+	return l.db
+}
+
+// Put will insert or update the entry
+func (l *Ledger) Put(ctx context.Context, entry *Entry) error {
+	metadata, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.db.ExecContext(ctx, `INSERT INTO ledger_entries
+		(id, xpub_id, direction, amount, fee, total_value, block_height, label, counterparty, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			direction = excluded.direction,
+			amount = excluded.amount,
+			fee = excluded.fee,
+			total_value = excluded.total_value,
+			block_height = excluded.block_height,
+			label = excluded.label,
+			counterparty = excluded.counterparty,
+			metadata = excluded.metadata`,
+		entry.ID, entry.XpubID, entry.Direction, entry.Amount, entry.Fee, entry.TotalValue, entry.BlockHeight,
+		entry.Label, entry.Counterparty, string(metadata), entry.CreatedAt.UnixNano(),
+	)
+	return err
+}
+
+// Get will get an entry by transaction ID, returns nil if not found
+func (l *Ledger) Get(ctx context.Context, id string) (*Entry, error) {
+	rows, err := l.db.QueryContext(ctx, selectEntries+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// Entries will return the entries matching the query, ordered by creation time
+func (l *Ledger) Entries(ctx context.Context, query *Query) ([]*Entry, error) {
+	if query == nil {
+		query = &Query{}
+	}
+
+	where := make([]string, 0)
+	args := make([]interface{}, 0)
+	if query.XpubID != "" {
+		where = append(where, "xpub_id = ?")
+		args = append(args, query.XpubID)
+	}
+	if !query.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, query.From.UnixNano())
+	}
+	if !query.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, query.To.UnixNano())
+	}
+	if query.Label != "" {
+		where = append(where, "label = ?")
+		args = append(args, query.Label)
+	}
+	if query.Counterparty != "" {
+		where = append(where, "counterparty = ?")
+		args = append(args, query.Counterparty)
+	}
+
+	statement := selectEntries
+	if len(where) > 0 {
+		statement += " WHERE " + strings.Join(where, " AND ")
+	}
+	if query.Descending {
+		statement += " ORDER BY created_at DESC, id DESC"
+	} else {
+		statement += " ORDER BY created_at ASC, id ASC"
+	}
+	if query.Limit > 0 {
+		statement += " LIMIT ? OFFSET ?"
+		args = append(args, query.Limit, query.Offset)
+	}
+
+	rows, err := l.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanEntries(rows)
+}
+
+// Latest will return the creation time of the most recent entry of the xPub (zero if the xPub has no entry)
+func (l *Ledger) Latest(ctx context.Context, xPubID string) (time.Time, error) {
+	var latest sql.NullInt64
+	if err := l.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM ledger_entries WHERE xpub_id = ?`,
+		xPubID).Scan(&latest); err != nil {
+		return time.Time{}, err
+	}
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, latest.Int64).UTC(), nil
+}
+
+// EntryFromTransaction will convert a bux transaction into a ledger entry
+func EntryFromTransaction(xPubID string, transaction *bux.Transaction) *Entry {
+	entry := &Entry{
+		ID:          transaction.ID,
+		XpubID:      xPubID,
+		Direction:   string(transaction.Direction),
+		Amount:      transaction.OutputValue,
+		Fee:         transaction.Fee,
+		TotalValue:  transaction.TotalValue,
+		BlockHeight: transaction.BlockHeight,
+		Metadata:    transaction.Metadata,
+		CreatedAt:   transaction.CreatedAt.UTC(),
+	}
+	if label, ok := transaction.Metadata[MetadataLabel].(string); ok {
+		entry.Label = label
+	}
+	if counterparty, ok := transaction.Metadata[MetadataCounterparty].(string); ok {
+		entry.Counterparty = counterparty
+	}
+	return entry
+}
+
+const selectEntries = `SELECT id, xpub_id, direction, amount, fee, total_value, block_height, label, counterparty,
+	metadata, created_at FROM ledger_entries`
+
+// scanEntries will scan (and close) the rows into entries
+func scanEntries(rows *sql.Rows) ([]*Entry, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := make([]*Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		var metadata string
+		var createdAt int64
+		if err := rows.Scan(
+			&entry.ID, &entry.XpubID, &entry.Direction, &entry.Amount, &entry.Fee, &entry.TotalValue,
+			&entry.BlockHeight, &entry.Label, &entry.Counterparty, &metadata, &createdAt,
+		); err != nil {
+			return nil, err
+		}
+		if metadata != "" && metadata != "null" {
+			if err := json.Unmarshal([]byte(metadata), &entry.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		entry.CreatedAt = time.Unix(0, createdAt).UTC()
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,193 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testXPubID = "9fe44728bf16a2dde3748f72cc65ea661f3bf18653b320d31eafcab37cf7fb36"
+
+type testSource struct {
+	conditions   map[string]interface{}
+	pages        []int
+	transactions []*bux.Transaction
+}
+
+func (s *testSource) GetTransactions(_ context.Context, conditions map[string]interface{},
+	_ *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error) {
+
+	s.conditions = conditions
+	if queryParams == nil {
+		return s.transactions, nil
+	}
+	s.pages = append(s.pages, queryParams.Page)
+	start := (queryParams.Page - 1) * queryParams.PageSize
+	if start >= len(s.transactions) {
+		return []*bux.Transaction{}, nil
+	}
+	end := start + queryParams.PageSize
+	if end > len(s.transactions) {
+		end = len(s.transactions)
+	}
+	return s.transactions[start:end], nil
+}
+
+func testTransaction(id string, createdAt time.Time, outputValue int64, metadata bux.Metadata) *bux.Transaction {
+	transaction := &bux.Transaction{
+		OutputValue: outputValue,
+		Direction:   bux.TransactionDirectionIn,
+		Fee:         97,
+	}
+	transaction.ID = id
+	transaction.CreatedAt = createdAt
+	transaction.Metadata = metadata
+	if outputValue < 0 {
+		transaction.Direction = bux.TransactionDirectionOut
+	}
+	return transaction
+}
+
+func newTestLedger(t *testing.T) *Ledger {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	l, err := New(context.Background(), db)
+	require.NoError(t, err)
+	return l
+}
+
+// TestNew will test the migrations
+func TestNew(t *testing.T) {
+	l := newTestLedger(t)
+
+	version, err := l.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	// running the migrations again is a no-op
+	_, err = New(context.Background(), l.DB())
+	require.NoError(t, err)
+}
+
+// TestLedger_Sync will test syncing and querying the ledger
+func TestLedger_Sync(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLedger(t)
+	day := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	source := &testSource{transactions: []*bux.Transaction{
+		testTransaction("tx1", day, 5000, bux.Metadata{"label": "salary", "counterparty": "acme@bux.org"}),
+		testTransaction("tx2", day.Add(24*time.Hour), -1200, bux.Metadata{"label": "coffee"}),
+		testTransaction("tx3", day.Add(48*time.Hour), 700, nil),
+	}}
+
+	count, err := l.Sync(ctx, testXPubID, source)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Nil(t, source.conditions)
+
+	t.Run("get", func(t *testing.T) {
+		entry, err := l.Get(ctx, "tx1")
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		assert.Equal(t, int64(5000), entry.Amount)
+		assert.Equal(t, "salary", entry.Label)
+		assert.Equal(t, "acme@bux.org", entry.Counterparty)
+		assert.Equal(t, day, entry.CreatedAt)
+
+		entry, err = l.Get(ctx, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("by date", func(t *testing.T) {
+		entries, err := l.Entries(ctx, &Query{From: day.Add(time.Hour), To: day.Add(72 * time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "tx2", entries[0].ID)
+		assert.Equal(t, "tx3", entries[1].ID)
+	})
+
+	t.Run("by label", func(t *testing.T) {
+		entries, err := l.Entries(ctx, &Query{Label: "coffee"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, int64(-1200), entries[0].Amount)
+	})
+
+	t.Run("by counterparty, descending", func(t *testing.T) {
+		entries, err := l.Entries(ctx, &Query{Counterparty: "acme@bux.org", Descending: true, Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "tx1", entries[0].ID)
+	})
+
+	t.Run("incremental sync", func(t *testing.T) {
+		source.transactions = []*bux.Transaction{
+			testTransaction("tx3", day.Add(48*time.Hour), 700, bux.Metadata{"label": "refund"}),
+		}
+		count, err = l.Sync(ctx, testXPubID, source)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		require.NotNil(t, source.conditions)
+		assert.Contains(t, source.conditions, "created_at")
+
+		entries, err := l.Entries(ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+		assert.Equal(t, "refund", entries[2].Label)
+	})
+
+	t.Run("other xpub", func(t *testing.T) {
+		const otherXPubID = "1b8c3a1e8a3d3f6a2c7d1b5e9f0a4c6e8d2b7f3a5c9e1d4b6a8f0c2e4d6b8a0c"
+		source.transactions = []*bux.Transaction{
+			testTransaction("tx4", day, 300, nil),
+		}
+		count, err = l.Sync(ctx, otherXPubID, source)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Nil(t, source.conditions)
+
+		latest, err := l.Latest(ctx, otherXPubID)
+		require.NoError(t, err)
+		assert.Equal(t, day, latest)
+		latest, err = l.Latest(ctx, testXPubID)
+		require.NoError(t, err)
+		assert.Equal(t, day.Add(48*time.Hour), latest)
+
+		entries, err := l.Entries(ctx, &Query{XpubID: otherXPubID})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "tx4", entries[0].ID)
+	})
+
+	t.Run("several pages", func(t *testing.T) {
+		const pagedXPubID = "5d2f0c8e4a6b1d3f7e9a2c4b6d8f0e1a3c5b7d9f2e4a6c8b0d1f3e5a7c9b2d4f"
+		pagedSource := &testSource{}
+		for i := 0; i < 2*syncPageSize+1; i++ {
+			pagedSource.transactions = append(pagedSource.transactions,
+				testTransaction(fmt.Sprintf("paged%d", i), day.Add(time.Duration(i)*time.Minute), 1, nil))
+		}
+		count, err = l.Sync(ctx, pagedXPubID, pagedSource)
+		require.NoError(t, err)
+		assert.Equal(t, 2*syncPageSize+1, count)
+		assert.Equal(t, []int{1, 2, 3}, pagedSource.pages)
+
+		entries, err := l.Entries(ctx, &Query{XpubID: pagedXPubID})
+		require.NoError(t, err)
+		assert.Len(t, entries, 2*syncPageSize+1)
+	})
+}
@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+)
+
+// migrations are the schema migrations of the ledger, in order - never edit a released
+// migration, append a new one instead
+var migrations = []string{
+	// 1: ledger entries
+	`CREATE TABLE ledger_entries (
+		id TEXT PRIMARY KEY,
+		xpub_id TEXT NOT NULL,
+		direction TEXT NOT NULL DEFAULT '',
+		amount INTEGER NOT NULL DEFAULT 0,
+		fee INTEGER NOT NULL DEFAULT 0,
+		total_value INTEGER NOT NULL DEFAULT 0,
+		block_height INTEGER NOT NULL DEFAULT 0,
+		label TEXT NOT NULL DEFAULT '',
+		counterparty TEXT NOT NULL DEFAULT '',
+		metadata TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	)`,
+
+	// 2: search indexes
+	`CREATE INDEX idx_ledger_entries_created_at ON ledger_entries (created_at);
+	CREATE INDEX idx_ledger_entries_label ON ledger_entries (label);
+	CREATE INDEX idx_ledger_entries_counterparty ON ledger_entries (counterparty)`,
+
+	// 3: entries of an xPub
+	`CREATE INDEX idx_ledger_entries_xpub_id_created_at ON ledger_entries (xpub_id, created_at)`,
+}
+
+// SchemaVersion will return the current schema version of the ledger database
+func (l *Ledger) SchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	if err := l.db.QueryRowContext(ctx, `SELECT MAX(version) FROM ledger_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// migrate will run all pending migrations, each in its own transaction
+func (l *Ledger) migrate(ctx context.Context) error {
+	if _, err := l.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS ledger_migrations (
+		version INTEGER PRIMARY KEY
+	)`); err != nil {
+		return err
+	}
+
+	current, err := l.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for index := current; index < len(migrations); index++ {
+		var tx *sql.Tx
+		if tx, err = l.db.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, migrations[index]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `INSERT INTO ledger_migrations (version) VALUES (?)`, index+1); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
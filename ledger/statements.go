@@ -0,0 +1,151 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/BuxOrg/bux"
+)
+
+// DailyStatement is the summary of a single day
+type DailyStatement struct {
+	Date           time.Time `json:"date"`
+	OpeningBalance int64     `json:"opening_balance"`
+	TotalIn        uint64    `json:"total_in"`
+	TotalOut       uint64    `json:"total_out"`
+	Fees           uint64    `json:"fees"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Transactions   int       `json:"transactions"`
+}
+
+// Statement is the summary of a date range, day by day
+type Statement struct {
+	From           time.Time         `json:"from"`
+	To             time.Time         `json:"to"`
+	OpeningBalance int64             `json:"opening_balance"`
+	TotalIn        uint64            `json:"total_in"`
+	TotalOut       uint64            `json:"total_out"`
+	Fees           uint64            `json:"fees"`
+	ClosingBalance int64             `json:"closing_balance"`
+	Days           []*DailyStatement `json:"days"`
+}
+
+// StatementHeader is the header of the statement table (see Statement.Table)
+var StatementHeader = []string{
+	"date", "opening_balance", "total_in", "total_out", "fees", "closing_balance", "transactions",
+}
+
+// Statement will generate the statement of the xPub for every day from the day of "from" up to (but
+// excluding) the day of "to", days start at midnight in the given location (UTC if nil)
+func (l *Ledger) Statement(ctx context.Context, xPubID string, from, to time.Time,
+	location *time.Location) (*Statement, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	from = startOfDay(from, location)
+	to = startOfDay(to, location)
+
+	// the opening balance is everything before the range
+	var opening sql.NullInt64
+	if err := l.db.QueryRowContext(
+		ctx, `SELECT SUM(amount) FROM ledger_entries WHERE xpub_id = ? AND created_at < ?`, xPubID, from.UnixNano(),
+	).Scan(&opening); err != nil {
+		return nil, err
+	}
+
+	entries, err := l.Entries(ctx, &Query{XpubID: xPubID, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		From:           from,
+		To:             to,
+		OpeningBalance: opening.Int64,
+		ClosingBalance: opening.Int64,
+		Days:           make([]*DailyStatement, 0),
+	}
+
+	index := 0
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		daily := &DailyStatement{
+			Date:           day,
+			OpeningBalance: statement.ClosingBalance,
+			ClosingBalance: statement.ClosingBalance,
+		}
+		for ; index < len(entries) && entries[index].CreatedAt.Before(next); index++ {
+			daily.add(entries[index])
+		}
+
+		statement.TotalIn += daily.TotalIn
+		statement.TotalOut += daily.TotalOut
+		statement.Fees += daily.Fees
+		statement.ClosingBalance = daily.ClosingBalance
+		statement.Days = append(statement.Days, daily)
+	}
+
+	return statement, nil
+}
+
+// add will add the entry to the daily totals
+func (d *DailyStatement) add(entry *Entry) {
+	d.Transactions++
+	d.ClosingBalance += entry.Amount
+	if entry.Amount >= 0 {
+		d.TotalIn += uint64(entry.Amount)
+	} else {
+		d.TotalOut += uint64(-entry.Amount)
+	}
+
+	// only the sender pays the fee
+	if entry.Direction == string(bux.TransactionDirectionOut) {
+		d.Fees += entry.Fee
+	}
+}
+
+// Table will return the days of the statement as rows of strings (ex: for PDF rendering),
+// amounts are formatted with the given function (plain satoshis if nil)
+func (s *Statement) Table(formatAmount func(satoshis int64) string) [][]string {
+	if formatAmount == nil {
+		formatAmount = func(satoshis int64) string {
+			return strconv.FormatInt(satoshis, 10)
+		}
+	}
+
+	rows := make([][]string, 0, len(s.Days))
+	for _, day := range s.Days {
+		rows = append(rows, []string{
+			day.Date.Format("2006-01-02"),
+			formatAmount(day.OpeningBalance),
+			formatAmount(int64(day.TotalIn)),
+			formatAmount(int64(day.TotalOut)),
+			formatAmount(int64(day.Fees)),
+			formatAmount(day.ClosingBalance),
+			strconv.Itoa(day.Transactions),
+		})
+	}
+	return rows
+}
+
+// WriteCSV will write the days of the statement as CSV, with a header row
+func (s *Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(StatementHeader); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(s.Table(nil)); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// startOfDay will return midnight of the day of t in the given location
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
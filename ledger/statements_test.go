@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLedger_Statement will test the method Statement()
+func TestLedger_Statement(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLedger(t)
+	day := time.Date(2022, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	_, err := l.Sync(ctx, testXPubID, &testSource{transactions: []*bux.Transaction{
+		testTransaction("tx0", day.Add(-48*time.Hour), 10000, nil),
+		testTransaction("tx1", day, 5000, nil),
+		testTransaction("tx2", day.Add(2*time.Hour), -1200, nil),
+		testTransaction("tx3", day.Add(48*time.Hour), 700, nil),
+	}})
+	require.NoError(t, err)
+
+	// the entries of another xPub are not in the statement
+	_, err = l.Sync(ctx, "other", &testSource{transactions: []*bux.Transaction{
+		testTransaction("tx4", day.Add(-48*time.Hour), 900, nil),
+		testTransaction("tx5", day, 400, nil),
+	}})
+	require.NoError(t, err)
+
+	statement, err := l.Statement(ctx, testXPubID, day, day.AddDate(0, 0, 3), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(10000), statement.OpeningBalance)
+	assert.Equal(t, int64(14500), statement.ClosingBalance)
+	assert.Equal(t, uint64(5700), statement.TotalIn)
+	assert.Equal(t, uint64(1200), statement.TotalOut)
+	assert.Equal(t, uint64(97), statement.Fees)
+	require.Len(t, statement.Days, 3)
+
+	first := statement.Days[0]
+	assert.Equal(t, time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC), first.Date)
+	assert.Equal(t, int64(10000), first.OpeningBalance)
+	assert.Equal(t, int64(13800), first.ClosingBalance)
+	assert.Equal(t, 2, first.Transactions)
+
+	empty := statement.Days[1]
+	assert.Equal(t, int64(13800), empty.OpeningBalance)
+	assert.Equal(t, int64(13800), empty.ClosingBalance)
+	assert.Equal(t, 0, empty.Transactions)
+
+	t.Run("csv", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, statement.WriteCSV(&buffer))
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Equal(t, "date,opening_balance,total_in,total_out,fees,closing_balance,transactions", lines[0])
+		assert.Equal(t, "2022-02-01,10000,5000,1200,97,13800,2", lines[1])
+	})
+}
@@ -0,0 +1,77 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// TransactionSource is where the ledger gets its transactions from (ex: *buxclient.BuxClient)
+type TransactionSource interface {
+	GetTransactions(ctx context.Context, conditions map[string]interface{},
+		metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error)
+}
+
+// syncPageSize is the number of transactions fetched per page of a sync
+const syncPageSize = 100
+
+// Sync will fetch all transactions created since the latest entry of the xPub and store them,
+// returning the number of entries written
+func (l *Ledger) Sync(ctx context.Context, xPubID string, source TransactionSource) (int, error) {
+	latest, err := l.Latest(ctx, xPubID)
+	if err != nil {
+		return 0, err
+	}
+
+	// the latest entry is fetched again on purpose, entries are upserted
+	var conditions map[string]interface{}
+	if !latest.IsZero() {
+		conditions = transports.Since(transports.FieldCreatedAt, latest)
+	}
+
+	count := 0
+	for page := 1; ; page++ {
+		var transactions []*bux.Transaction
+		if transactions, err = source.GetTransactions(ctx, conditions, nil, &transports.QueryParams{
+			OrderByField:  transports.FieldCreatedAt,
+			Page:          page,
+			PageSize:      syncPageSize,
+			SortDirection: transports.SortAscending,
+		}); err != nil {
+			return count, err
+		}
+
+		for _, transaction := range transactions {
+			if err = l.Put(ctx, EntryFromTransaction(xPubID, transaction)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(transactions) < syncPageSize {
+			return count, nil
+		}
+	}
+}
+
+// Run will keep the ledger in sync, every interval, until the context is done. Sync errors
+// are passed to onError (if set) and do not stop the loop.
+func (l *Ledger) Run(ctx context.Context, xPubID string, source TransactionSource, interval time.Duration,
+	onError func(err error)) error {
+
+	ticker := l.scheduler.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.Sync(ctx, xPubID, source); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	magic "github.com/bitcoinschema/go-map"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/pkg/errors"
+)
+
+// Sizes used to estimate the size of P2PKH transactions
+const (
+	p2pkhInputSize           = 148 // signed P2PKH input
+	p2pkhOutputSize          = 34  // P2PKH output
+	p2pkhUnlockingScriptSize = 107 // signature and public key
+	txOverheadSize           = 10  // version, lock time and the input and output counts
+)
+
+// ErrTransactionTooLarge is when a transaction exceeds the maximum transaction size
+var ErrTransactionTooLarge = errors.New("transaction exceeds the maximum transaction size")
+
+// ErrTooManyOutputs is when a transaction exceeds the maximum number of outputs
+var ErrTooManyOutputs = errors.New("transaction exceeds the maximum number of outputs")
+
+// ErrOpReturnTooLarge is when an OP_RETURN output exceeds the maximum OP_RETURN size
+var ErrOpReturnTooLarge = errors.New("op_return output exceeds the maximum op_return size")
+
+// TransactionLimits are the limits enforced on the transactions built by the client before they are
+// signed (ex: the policy of the miners), a limit of 0 is not enforced
+type TransactionLimits struct {
+	MaxOpReturnSize int // bytes of the locking script of an OP_RETURN output
+	MaxOutputs      int // outputs of a transaction, including the change
+	MaxTxSize       int // bytes of the signed transaction
+}
+
+// TransactionLimits will return the limits enforced on the transactions built by the client
+func (b *BuxClient) TransactionLimits() TransactionLimits {
+	return b.limits
+}
+
+// SendToRecipientsInBatches will send to the recipients in as many transactions as needed to stay
+// within the transaction limits of the client, returning the transactions in order. On error, the
+// transactions that were already sent are returned with it.
+//
+// Note: the batches are checked before they are drafted, a draft still exceeding the limits once
+// drafted (ex: the server funded it with more inputs than estimated) is not drafted again, it keeps
+// its inputs reserved until it expires on the server
+func (b *BuxClient) SendToRecipientsInBatches(ctx context.Context, recipients []*transports.Recipients,
+	metadata *bux.Metadata) ([]*bux.Transaction, error) {
+
+	transactions := make([]*bux.Transaction, 0)
+	for _, batch := range b.splitRecipients(recipients) {
+		var err error
+		if transactions, err = b.sendBatch(ctx, batch, metadata, transactions); err != nil {
+			return transactions, err
+		}
+	}
+	return transactions, nil
+}
+
+// sendBatch will send the batch, splitting it in half when it exceeds the limits before it is drafted
+// (a draft can not be canceled)
+func (b *BuxClient) sendBatch(ctx context.Context, batch []*transports.Recipients, metadata *bux.Metadata,
+	transactions []*bux.Transaction) ([]*bux.Transaction, error) {
+
+	err := b.checkOutputLimits(batch, b.fitChangeOutputs(len(batch), DefaultChangeDestinations))
+	if err == nil {
+		var transaction *bux.Transaction
+		if transaction, err = b.SendToRecipients(ctx, batch, metadata); err != nil {
+			return transactions, err
+		}
+		return append(transactions, transaction), nil
+	}
+	if len(batch) == 1 || !(errors.Is(err, ErrTooManyOutputs) || errors.Is(err, ErrTransactionTooLarge)) {
+		return transactions, err
+	}
+
+	half := len(batch) / 2
+	if transactions, err = b.sendBatch(ctx, batch[:half], metadata, transactions); err != nil {
+		return transactions, err
+	}
+	return b.sendBatch(ctx, batch[half:], metadata, transactions)
+}
+
+// splitRecipients will split the recipients in batches that should stay within the limits, keeping
+// room for a change output and one input
+func (b *BuxClient) splitRecipients(recipients []*transports.Recipients) [][]*transports.Recipients {
+	maxOutputs := b.limits.MaxOutputs - 1
+	maxSize := b.limits.MaxTxSize - txOverheadSize - p2pkhOutputSize - p2pkhInputSize
+
+	batches := make([][]*transports.Recipients, 0)
+	var batch []*transports.Recipients
+	var batchSize int
+	for _, recipient := range recipients {
+		size := recipientOutputSize(recipient)
+		if len(batch) > 0 && (b.limits.MaxOutputs > 0 && len(batch) >= maxOutputs ||
+			b.limits.MaxTxSize > 0 && batchSize+size > maxSize) {
+			batches = append(batches, batch)
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, recipient)
+		batchSize += size
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// recipientOutputSize will estimate the size of the output of the recipient
+func recipientOutputSize(recipient *transports.Recipients) int {
+	scriptSize, err := recipientScriptSize(recipient)
+	if err != nil {
+		// the invalid output is rejected before drafting
+		return p2pkhOutputSize
+	}
+	return 8 + bt.VarInt(uint64(scriptSize)).Length() + scriptSize
+}
+
+// recipientScriptSize will return the size of the locking script of the output of the recipient, as
+// built by the server
+func recipientScriptSize(recipient *transports.Recipients) (int, error) {
+	switch {
+	case recipient.OpReturn != nil:
+		script, err := opReturnScript(recipient.OpReturn)
+		if err != nil {
+			return 0, err
+		}
+		return len(*script), nil
+	case recipient.Script != "":
+		return len(recipient.Script) / 2, nil
+	}
+	return p2pkhOutputSize - 9, nil
+}
+
+// opReturnScript will return the locking script of the OP_RETURN output, as built by the server: the
+// raw script, or OP_FALSE OP_RETURN and the pushed parts (hex, strings or MAP protocol)
+func opReturnScript(opReturn *bux.OpReturn) (*bscript.Script, error) {
+	if opReturn.Hex != "" {
+		return bscript.NewFromHexString(opReturn.Hex)
+	}
+
+	parts := make([][]byte, 0)
+	switch {
+	case len(opReturn.HexParts) > 0:
+		for _, part := range opReturn.HexParts {
+			data, err := hex.DecodeString(part)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, data)
+		}
+	case len(opReturn.StringParts) > 0:
+		for _, part := range opReturn.StringParts {
+			parts = append(parts, []byte(part))
+		}
+	case opReturn.Map != nil:
+		parts = append(parts, []byte(magic.Prefix), []byte(magic.Set), []byte(magic.MapAppKey),
+			[]byte(opReturn.Map.App), []byte(magic.MapTypeKey), []byte(opReturn.Map.Type))
+		for key, value := range opReturn.Map.Keys {
+			valueString, _ := value.(string)
+			parts = append(parts, []byte(key), []byte(valueString))
+		}
+	}
+
+	script := &bscript.Script{}
+	_ = script.AppendOpcodes(bscript.OpFALSE, bscript.OpRETURN)
+	if err := script.AppendPushDataArray(parts); err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// fitChangeOutputs will return the number of change outputs of a draft of the outputs: the requested
+// number (1 when not requested, the default of the server), lowered to fit the maximum number of
+// outputs (the server can use fewer change outputs, never more)
+func (b *BuxClient) fitChangeOutputs(outputs, requested int) int {
+	if requested <= 0 {
+		requested = 1
+	}
+	if b.limits.MaxOutputs > 0 && outputs+requested > b.limits.MaxOutputs && b.limits.MaxOutputs-outputs >= 1 {
+		return b.limits.MaxOutputs - outputs
+	}
+	return requested
+}
+
+// checkOutputLimits will check the outputs of a draft against the limits before it is drafted (a draft
+// can not be canceled, its inputs stay reserved until it expires): the number of outputs with the
+// change outputs, the size of the OP_RETURN outputs, and the size of the signed transaction estimated
+// with a single P2PKH input (the inputs are chosen by the server)
+func (b *BuxClient) checkOutputLimits(outputs []*transports.Recipients, changeOutputs int) error {
+	if b.limits == (TransactionLimits{}) {
+		return nil
+	}
+	if err := b.checkOutputCount(len(outputs) + changeOutputs); err != nil {
+		return err
+	}
+
+	size := txOverheadSize + p2pkhInputSize + changeOutputs*p2pkhOutputSize
+	for index, output := range outputs {
+		scriptSize, err := recipientScriptSize(output)
+		if err != nil {
+			return &InvalidInputError{Field: fmt.Sprintf("outputs[%d].op_return", index), Reason: err.Error()}
+		}
+		if output.OpReturn != nil && b.limits.MaxOpReturnSize > 0 && scriptSize > b.limits.MaxOpReturnSize {
+			return errors.Wrapf(ErrOpReturnTooLarge, "output %d is %d bytes, the maximum is %d",
+				index, scriptSize, b.limits.MaxOpReturnSize)
+		}
+		size += 8 + bt.VarInt(uint64(scriptSize)).Length() + scriptSize
+	}
+	return b.checkTxSize(size)
+}
+
+// configOutputs will return the outputs of the transaction config as recipients, to check them
+// against the limits (see checkOutputLimits)
+func configOutputs(config *bux.TransactionConfig) []*transports.Recipients {
+	if config.SendAllTo != "" {
+		return []*transports.Recipients{{To: config.SendAllTo}}
+	}
+	outputs := make([]*transports.Recipients, 0, len(config.Outputs))
+	for _, output := range config.Outputs {
+		if output.To == "" && output.OpReturn == nil && len(output.Scripts) > 0 {
+			for _, script := range output.Scripts {
+				outputs = append(outputs, &transports.Recipients{Script: script.Script})
+			}
+			continue
+		}
+		outputs = append(outputs, &transports.Recipients{OpReturn: output.OpReturn, To: output.To})
+	}
+	return outputs
+}
+
+// checkOutputCount will return ErrTooManyOutputs when the outputs (without the change) already exceed the limit
+func (b *BuxClient) checkOutputCount(outputs int) error {
+	if b.limits.MaxOutputs > 0 && outputs > b.limits.MaxOutputs {
+		return errors.Wrapf(ErrTooManyOutputs, "%d outputs, the maximum is %d", outputs, b.limits.MaxOutputs)
+	}
+	return nil
+}
+
+// checkDraftLimits will check the unsigned draft transaction against the limits once drafted (the
+// outputs are checked before drafting, see checkOutputLimits), the size of the signed transaction is
+// estimated with P2PKH unlocking scripts
+func (b *BuxClient) checkDraftLimits(draft *bux.DraftTransaction) error {
+	if draft == nil || b.limits == (TransactionLimits{}) {
+		return nil
+	}
+
+	tx, err := bt.NewTxFromString(draft.Hex)
+	if err != nil {
+		return err
+	}
+	if err = b.checkOutputCount(tx.OutputCount()); err != nil {
+		return err
+	}
+
+	if b.limits.MaxOpReturnSize > 0 {
+		for index, output := range tx.Outputs {
+			if size := len(*output.LockingScript); output.LockingScript.IsData() && size > b.limits.MaxOpReturnSize {
+				return errors.Wrapf(ErrOpReturnTooLarge, "output %d is %d bytes, the maximum is %d",
+					index, size, b.limits.MaxOpReturnSize)
+			}
+		}
+	}
+
+	return b.checkTxSize(estimateSignedSize(tx))
+}
+
+// estimateSignedSize will estimate the size of the signed transaction, with P2PKH unlocking scripts for
+// the inputs that are not signed
+func estimateSignedSize(tx *bt.Tx) int {
+	size := tx.Size()
+	for _, input := range tx.Inputs {
+		if input.UnlockingScript == nil || len(*input.UnlockingScript) == 0 {
+			size += p2pkhUnlockingScriptSize
+		}
+	}
+	return size
+}
+
+// checkTxSize will return ErrTransactionTooLarge when the size exceeds the limit
+func (b *BuxClient) checkTxSize(size int) error {
+	if b.limits.MaxTxSize > 0 && size > b.limits.MaxTxSize {
+		return errors.Wrapf(ErrTransactionTooLarge, "%d bytes, the maximum is %d", size, b.limits.MaxTxSize)
+	}
+	return nil
+}
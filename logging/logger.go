@@ -0,0 +1,95 @@
+// Package logging contains the structured logger interface used by the bux client, and adapters
+// for popular logging libraries (zap, zerolog and slog)
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field is a key/value pair of a structured log entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F will return a new field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger is a structured logger
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// Default will return the default logger, writing text lines to stdout
+func Default() Logger {
+	return NewTextLogger(os.Stdout)
+}
+
+// Nop will return a logger that discards everything
+func Nop() Logger {
+	return nopLogger{}
+}
+
+// nopLogger discards everything
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...Field) {}
+func (nopLogger) Info(string, ...Field)  {}
+func (nopLogger) Warn(string, ...Field)  {}
+func (nopLogger) Error(string, ...Field) {}
+
+// TextLogger writes every entry as a single line of text: time, level, message and key=value fields
+type TextLogger struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// NewTextLogger will return a new text logger writing to w
+func NewTextLogger(w io.Writer) *TextLogger {
+	return &TextLogger{writer: w}
+}
+
+// Debug will log a debug entry
+func (l *TextLogger) Debug(msg string, fields ...Field) {
+	l.log("DEBUG", msg, fields)
+}
+
+// Info will log an info entry
+func (l *TextLogger) Info(msg string, fields ...Field) {
+	l.log("INFO", msg, fields)
+}
+
+// Warn will log a warning entry
+func (l *TextLogger) Warn(msg string, fields ...Field) {
+	l.log("WARN", msg, fields)
+}
+
+// Error will log an error entry
+func (l *TextLogger) Error(msg string, fields ...Field) {
+	l.log("ERROR", msg, fields)
+}
+
+// log will write the entry
+func (l *TextLogger) log(level, msg string, fields []Field) {
+	var builder strings.Builder
+	builder.WriteString(time.Now().UTC().Format(time.RFC3339))
+	builder.WriteString(" " + level + " " + msg)
+	for _, field := range fields {
+		builder.WriteString(fmt.Sprintf(" %s=%v", field.Key, field.Value))
+	}
+	builder.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.writer, builder.String())
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSugaredLogger struct {
+	entries []string
+	values  [][]interface{}
+}
+
+func (l *testSugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, "debug "+msg)
+	l.values = append(l.values, keysAndValues)
+}
+
+func (l *testSugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, "info "+msg)
+	l.values = append(l.values, keysAndValues)
+}
+
+func (l *testSugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, "warn "+msg)
+	l.values = append(l.values, keysAndValues)
+}
+
+func (l *testSugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, "error "+msg)
+	l.values = append(l.values, keysAndValues)
+}
+
+// TestTextLogger will test the text logger
+func TestTextLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewTextLogger(&buffer)
+
+	logger.Debug("transaction", F("tx_id", "abc"))
+	logger.Warn("stream interrupted", F("attempt", 2), F("delay", "1s"))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], " DEBUG transaction tx_id=abc"))
+	assert.True(t, strings.HasSuffix(lines[1], " WARN stream interrupted attempt=2 delay=1s"))
+}
+
+// TestNewZapLogger will test the zap adapter
+func TestNewZapLogger(t *testing.T) {
+	sugared := &testSugaredLogger{}
+	logger := NewZapLogger(sugared)
+
+	logger.Info("transactions", F("count", 3))
+	logger.Error("failed")
+
+	assert.Equal(t, []string{"info transactions", "error failed"}, sugared.entries)
+	assert.Equal(t, []interface{}{"count", 3}, sugared.values[0])
+}
+
+// TestNewZerologLogger will test the zerolog adapter
+func TestNewZerologLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewZerologLogger(zerolog.New(&buffer))
+
+	logger.Warn("webhook", F("webhook_id", "123"))
+
+	assert.JSONEq(t, `{"level":"warn","webhook_id":"123","message":"webhook"}`, buffer.String())
+}
+
+// TestObfuscateIDs will test the obfuscation of the identifiers
+func TestObfuscateIDs(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := ObfuscateIDs(NewTextLogger(&buffer), HashIDs("tenant-secret"))
+
+	logger.Debug("transaction", F("tx_id", "tx-1"), F("count", 2), F("xpub_id", ""))
+	line := buffer.String()
+	assert.NotContains(t, line, "tx-1")
+	assert.Contains(t, line, "tx_id="+HashIDs("tenant-secret")("tx_id", "tx-1"))
+	assert.Contains(t, line, "count=2")
+	assert.Contains(t, line, "xpub_id=\n")
+
+	// the identifiers in the errors are obfuscated, the rest of the text is kept
+	const txID = "2198475cda3e713fb6749272f9bbca446bdfc6b34f025c08e3087af7a8981229"
+	const address = "1EXnMRHFbWzpJTh1BpTmXpNtcXYXsbG3Ua"
+	const xPub = "xpub661MyMwAqRbcFrBJbKwBGCB7d3fr2SaAuXGM95BA62X41m6eW2ehRQGW4xLi9wkEXUGnQZYxVVj4PxXnyrLk7jdqvBAs1Qq9gf6ykMvjR7J"
+	buffer.Reset()
+	logger.Warn("failed", F("error", errors.New("utxo "+txID+":1 of "+address+" not found")), F("cause", errors.New(xPub)),
+		F("hash", txID), F("error", "spent by "+txID))
+	line = buffer.String()
+	assert.NotContains(t, line, txID)
+	assert.NotContains(t, line, address)
+	assert.NotContains(t, line, xPub)
+	assert.Contains(t, line, "hash="+HashIDs("tenant-secret")("hash", txID))
+	assert.Contains(t, line, "utxo "+HashIDs("tenant-secret")("error", txID)+":1 of "+
+		HashIDs("tenant-secret")("error", address)+" not found")
+	assert.Contains(t, line, "spent by "+HashIDs("tenant-secret")("error", txID))
+
+	// the hashes are stable for a tenant, and differ between tenants
+	hash := HashIDs("tenant-secret")("tx_id", "tx-1")
+	assert.Len(t, hash, 16)
+	assert.Equal(t, hash, HashIDs("tenant-secret")("tx_id", "tx-1"))
+	assert.NotEqual(t, hash, HashIDs("other-secret")("tx_id", "tx-1"))
+}
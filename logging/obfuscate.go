@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// IDObfuscator returns the obfuscated value of an identifier, the key is the name of the log field
+// (ex: "tx_id", "xpub_id", "address")
+type IDObfuscator func(key, id string) string
+
+// IDFields are the names of the log fields that contain identifiers
+var IDFields = map[string]bool{
+	"address":        true,
+	"destination_id": true,
+	"draft_id":       true,
+	"hash":           true,
+	"id":             true,
+	"reference_id":   true,
+	"target":         true,
+	"tx_id":          true,
+	"webhook_id":     true,
+	"xpub_id":        true,
+}
+
+// embeddedIDs matches the identifiers in the text of the errors: the hex hashes and ids (64 characters),
+// the extended keys and the addresses
+var embeddedIDs = regexp.MustCompile(`\b(?:[0-9a-fA-F]{64}|[xt]p(?:ub|rv)[1-9A-HJ-NP-Za-km-z]{100,112}|[123mn][1-9A-HJ-NP-Za-km-z]{25,34})\b`)
+
+// HashIDs will return an obfuscator that replaces the identifiers by their HMAC-SHA256 with the
+// secret (truncated to 16 hex characters), the same identifier has the same hash for a tenant, but
+// can not be linked across tenants with different secrets
+func HashIDs(secret string) IDObfuscator {
+	return func(_, id string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write([]byte(id))
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+}
+
+// ObfuscateIDs will return a logger that obfuscates the string values of the identifier fields
+// (see IDFields), and the identifiers in the text of the errors (the error values, and the "error"
+// field), before writing to the logger
+func ObfuscateIDs(logger Logger, obfuscator IDObfuscator) Logger {
+	return &obfuscatingLogger{logger: logger, obfuscator: obfuscator}
+}
+
+// obfuscatingLogger obfuscates the identifiers of the entries
+type obfuscatingLogger struct {
+	logger     Logger
+	obfuscator IDObfuscator
+}
+
+// Debug will log a debug entry
+func (l *obfuscatingLogger) Debug(msg string, fields ...Field) {
+	l.logger.Debug(msg, l.obfuscate(fields)...)
+}
+
+// Info will log an info entry
+func (l *obfuscatingLogger) Info(msg string, fields ...Field) {
+	l.logger.Info(msg, l.obfuscate(fields)...)
+}
+
+// Warn will log a warning entry
+func (l *obfuscatingLogger) Warn(msg string, fields ...Field) {
+	l.logger.Warn(msg, l.obfuscate(fields)...)
+}
+
+// Error will log an error entry
+func (l *obfuscatingLogger) Error(msg string, fields ...Field) {
+	l.logger.Error(msg, l.obfuscate(fields)...)
+}
+
+// obfuscate will return a copy of the fields with the identifiers obfuscated
+func (l *obfuscatingLogger) obfuscate(fields []Field) []Field {
+	obfuscated := make([]Field, len(fields))
+	for i, field := range fields {
+		obfuscated[i] = field
+		switch value := field.Value.(type) {
+		case string:
+			if IDFields[field.Key] && value != "" {
+				obfuscated[i].Value = l.obfuscator(field.Key, value)
+			} else if field.Key == "error" {
+				obfuscated[i].Value = l.obfuscateText(field.Key, value)
+			}
+		case error:
+			if value != nil {
+				obfuscated[i].Value = l.obfuscateText(field.Key, value.Error())
+			}
+		}
+	}
+	return obfuscated
+}
+
+// obfuscateText will return the text with the identifiers it contains obfuscated
+func (l *obfuscatingLogger) obfuscateText(key, text string) string {
+	return embeddedIDs.ReplaceAllStringFunc(text, func(id string) string {
+		return l.obfuscator(key, id)
+	})
+}
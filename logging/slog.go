@@ -0,0 +1,44 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger is the log/slog adapter
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger will return a logger writing to log/slog (go 1.21+)
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Debug(msg string, fields ...Field) {
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attributes(fields)...)
+}
+
+func (l *slogLogger) Info(msg string, fields ...Field) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, msg, attributes(fields)...)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...Field) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attributes(fields)...)
+}
+
+func (l *slogLogger) Error(msg string, fields ...Field) {
+	l.logger.LogAttrs(context.Background(), slog.LevelError, msg, attributes(fields)...)
+}
+
+// attributes will convert the fields into slog attributes
+func attributes(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	return attrs
+}
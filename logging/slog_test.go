@@ -0,0 +1,22 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewSlogLogger will test the slog adapter
+func TestNewSlogLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	logger.Debug("transaction", F("tx_id", "abc"))
+
+	assert.Contains(t, buffer.String(), `"level":"DEBUG","msg":"transaction","tx_id":"abc"`)
+}
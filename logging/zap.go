@@ -0,0 +1,44 @@
+package logging
+
+// ZapSugaredLogger is the part of *zap.SugaredLogger used by the adapter
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// zapLogger is the zap adapter
+type zapLogger struct {
+	logger ZapSugaredLogger
+}
+
+// NewZapLogger will return a logger writing to zap (ex: NewZapLogger(zapLogger.Sugar()))
+func NewZapLogger(logger ZapSugaredLogger) Logger {
+	return &zapLogger{logger: logger}
+}
+
+func (l *zapLogger) Debug(msg string, fields ...Field) {
+	l.logger.Debugw(msg, keysAndValues(fields)...)
+}
+
+func (l *zapLogger) Info(msg string, fields ...Field) {
+	l.logger.Infow(msg, keysAndValues(fields)...)
+}
+
+func (l *zapLogger) Warn(msg string, fields ...Field) {
+	l.logger.Warnw(msg, keysAndValues(fields)...)
+}
+
+func (l *zapLogger) Error(msg string, fields ...Field) {
+	l.logger.Errorw(msg, keysAndValues(fields)...)
+}
+
+// keysAndValues will flatten the fields into alternating keys and values
+func keysAndValues(fields []Field) []interface{} {
+	values := make([]interface{}, 0, len(fields)*2)
+	for _, field := range fields {
+		values = append(values, field.Key, field.Value)
+	}
+	return values
+}
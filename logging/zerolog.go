@@ -0,0 +1,37 @@
+package logging
+
+import "github.com/rs/zerolog"
+
+// zerologLogger is the zerolog adapter
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+// NewZerologLogger will return a logger writing to zerolog
+func NewZerologLogger(logger zerolog.Logger) Logger {
+	return &zerologLogger{logger: logger}
+}
+
+func (l *zerologLogger) Debug(msg string, fields ...Field) {
+	l.write(l.logger.Debug(), msg, fields)
+}
+
+func (l *zerologLogger) Info(msg string, fields ...Field) {
+	l.write(l.logger.Info(), msg, fields)
+}
+
+func (l *zerologLogger) Warn(msg string, fields ...Field) {
+	l.write(l.logger.Warn(), msg, fields)
+}
+
+func (l *zerologLogger) Error(msg string, fields ...Field) {
+	l.write(l.logger.Error(), msg, fields)
+}
+
+// write will add the fields to the event and send it
+func (l *zerologLogger) write(event *zerolog.Event, msg string, fields []Field) {
+	for _, field := range fields {
+		event = event.Interface(field.Key, field.Value)
+	}
+	event.Msg(msg)
+}
@@ -0,0 +1,188 @@
+// Package metadata contains a builder for the metadata of the bux models (transactions, destinations,
+// access keys...), with typed getters and setters and merge semantics
+package metadata
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/BuxOrg/bux"
+)
+
+// Reserved keys of the metadata
+const (
+	// KeyUserAgent is the user agent of the client, set on every request
+	KeyUserAgent = "user_agent"
+)
+
+// Metadata is the metadata of a bux model, the setters return the metadata so they can be chained:
+//
+//	metadata.New().SetString("note", "rent").SetInt("invoice", 42).Bux()
+type Metadata map[string]interface{}
+
+// New will create empty metadata
+func New() Metadata {
+	return make(Metadata)
+}
+
+// From will return a copy of the bux metadata (empty when nil), the nested maps are copied too
+func From(metadata *bux.Metadata) Metadata {
+	if metadata == nil {
+		return New()
+	}
+	return Metadata(*metadata).Clone()
+}
+
+// Bux will return the metadata as bux metadata, as taken by the client methods
+func (m Metadata) Bux() *bux.Metadata {
+	metadata := bux.Metadata(m)
+	return &metadata
+}
+
+// Clone will return a deep copy of the metadata (the nested maps are copied)
+func (m Metadata) Clone() Metadata {
+	cloned := make(Metadata, len(m))
+	for key, value := range m {
+		if nested, ok := toMap(value); ok {
+			value = map[string]interface{}(nested.Clone())
+		}
+		cloned[key] = value
+	}
+	return cloned
+}
+
+// Set will set the value of the key
+func (m Metadata) Set(key string, value interface{}) Metadata {
+	m[key] = value
+	return m
+}
+
+// SetString will set the string value of the key
+func (m Metadata) SetString(key, value string) Metadata {
+	return m.Set(key, value)
+}
+
+// SetInt will set the integer value of the key
+func (m Metadata) SetInt(key string, value int64) Metadata {
+	return m.Set(key, value)
+}
+
+// SetTime will set the time of the key, stored as an RFC 3339 string in UTC
+func (m Metadata) SetTime(key string, value time.Time) Metadata {
+	return m.Set(key, value.UTC().Format(time.RFC3339Nano))
+}
+
+// SetMap will set the nested metadata of the key
+func (m Metadata) SetMap(key string, value Metadata) Metadata {
+	return m.Set(key, map[string]interface{}(value))
+}
+
+// Delete will remove the key
+func (m Metadata) Delete(key string) Metadata {
+	delete(m, key)
+	return m
+}
+
+// Has will return whether the key is set
+func (m Metadata) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// String will return the string value of the key, false if it is not set or not a string
+func (m Metadata) String(key string) (string, bool) {
+	value, ok := m[key].(string)
+	return value, ok
+}
+
+// Int will return the integer value of the key, false if it is not set or not an integer. The numbers
+// decoded from JSON (float64, json.Number) and the numeric strings are converted.
+func (m Metadata) Int(key string) (int64, bool) {
+	switch value := m[key].(type) {
+	case int:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case uint32:
+		return int64(value), true
+	case uint64:
+		if value > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(value), true
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) >= 1<<63 {
+			return 0, false
+		}
+		return int64(value), true
+	case json.Number:
+		number, err := value.Int64()
+		return number, err == nil
+	case string:
+		number, err := strconv.ParseInt(value, 10, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// Time will return the time of the key, false if it is not set or not an RFC 3339 time
+func (m Metadata) Time(key string) (time.Time, bool) {
+	switch value := m[key].(type) {
+	case time.Time:
+		return value, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
+
+// Map will return the nested metadata of the key, false if it is not set or not a map
+func (m Metadata) Map(key string) (Metadata, bool) {
+	return toMap(m[key])
+}
+
+// Merge will set the keys of the others over the metadata, in order: the nested maps are merged
+// key by key, any other value replaces the current one. A nil value removes the key.
+func (m Metadata) Merge(others ...Metadata) Metadata {
+	for _, other := range others {
+		for key, value := range other {
+			if value == nil {
+				delete(m, key)
+				continue
+			}
+			nested, isMap := toMap(value)
+			if !isMap {
+				m[key] = value
+				continue
+			}
+			if current, ok := toMap(m[key]); ok {
+				m[key] = map[string]interface{}(current.Clone().Merge(nested))
+			} else {
+				m[key] = map[string]interface{}(nested.Clone())
+			}
+		}
+	}
+	return m
+}
+
+// toMap will return the value as metadata if it is a map
+func toMap(value interface{}) (Metadata, bool) {
+	switch nested := value.(type) {
+	case Metadata:
+		return nested, true
+	case map[string]interface{}:
+		return nested, true
+	case bux.Metadata:
+		return Metadata(nested), true
+	case *bux.Metadata:
+		if nested != nil {
+			return Metadata(*nested), true
+		}
+	}
+	return nil, false
+}
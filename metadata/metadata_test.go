@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetadata will test the typed setters and getters
+func TestMetadata(t *testing.T) {
+	now := time.Date(2022, 3, 14, 15, 9, 26, 0, time.FixedZone("CET", 3600))
+	m := New().
+		SetString("note", "rent").
+		SetInt("invoice", 42).
+		SetTime("paid_at", now).
+		SetMap("customer", New().SetString("name", "satoshi"))
+
+	note, ok := m.String("note")
+	assert.True(t, ok)
+	assert.Equal(t, "rent", note)
+	_, ok = m.String("invoice")
+	assert.False(t, ok)
+
+	invoice, ok := m.Int("invoice")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), invoice)
+	_, ok = m.Int("note")
+	assert.False(t, ok)
+
+	paidAt, ok := m.Time("paid_at")
+	assert.True(t, ok)
+	assert.True(t, now.Equal(paidAt))
+	assert.Equal(t, "2022-03-14T14:09:26Z", m["paid_at"])
+
+	customer, ok := m.Map("customer")
+	require.True(t, ok)
+	name, _ := customer.String("name")
+	assert.Equal(t, "satoshi", name)
+	_, ok = m.Map("note")
+	assert.False(t, ok)
+
+	assert.True(t, m.Has("note"))
+	assert.False(t, m.Delete("note").Has("note"))
+
+	t.Run("decoded from json", func(t *testing.T) {
+		var decoded Metadata
+		require.NoError(t, json.Unmarshal([]byte(`{"invoice":42,"half":1.5,"paid_at":"2022-03-14T14:09:26Z",
+			"customer":{"name":"satoshi"}}`), &decoded))
+
+		invoice, ok := decoded.Int("invoice")
+		assert.True(t, ok)
+		assert.Equal(t, int64(42), invoice)
+		_, ok = decoded.Int("half")
+		assert.False(t, ok)
+
+		paidAt, ok := decoded.Time("paid_at")
+		assert.True(t, ok)
+		assert.True(t, now.Equal(paidAt))
+
+		customer, ok := decoded.Map("customer")
+		assert.True(t, ok)
+		assert.Equal(t, Metadata{"name": "satoshi"}, customer)
+	})
+}
+
+// TestFrom will test the method From()
+func TestFrom(t *testing.T) {
+	assert.Equal(t, New(), From(nil))
+
+	original := &bux.Metadata{"key": "value", "nested": map[string]interface{}{"a": "b"}}
+	m := From(original).SetString("key", "changed")
+	nested, _ := m.Map("nested")
+	nested.SetString("a", "changed")
+
+	assert.Equal(t, &bux.Metadata{"key": "value", "nested": map[string]interface{}{"a": "b"}}, original)
+	assert.Equal(t, &bux.Metadata{"key": "changed", "nested": map[string]interface{}{"a": "changed"}}, m.Bux())
+}
+
+// TestMerge will test the method Merge()
+func TestMerge(t *testing.T) {
+	base := Metadata{
+		"keep":     "value",
+		"replace":  "old",
+		"remove":   "value",
+		"customer": map[string]interface{}{"name": "satoshi", "country": "JP"},
+	}
+	other := Metadata{
+		"customer": map[string]interface{}{"country": "UK"},
+	}
+
+	merged := base.Clone().Merge(
+		Metadata{"replace": "new", "remove": nil, "added": int64(1)},
+		other,
+	)
+	assert.Equal(t, Metadata{
+		"keep":     "value",
+		"replace":  "new",
+		"added":    int64(1),
+		"customer": map[string]interface{}{"name": "satoshi", "country": "UK"},
+	}, merged)
+
+	// the merged maps are copies
+	assert.Equal(t, map[string]interface{}{"name": "satoshi", "country": "JP"}, base["customer"])
+	assert.Equal(t, map[string]interface{}{"country": "UK"}, other["customer"])
+}
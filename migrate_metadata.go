@@ -0,0 +1,193 @@
+package buxclient
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/metadata"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// Models of which the metadata can be migrated
+const (
+	MetadataModelDestination = "destination"
+	MetadataModelTransaction = "transaction"
+)
+
+// defaultMigrationPageSize is the number of records fetched per page
+const defaultMigrationPageSize = 100
+
+// ErrUnknownMetadataModel is when the selector model cannot be migrated
+var ErrUnknownMetadataModel = errors.New("unknown metadata model, use transaction or destination")
+
+// MetadataSelector selects the records of which the metadata is migrated
+type MetadataSelector struct {
+	Model      string                 // MetadataModelTransaction or MetadataModelDestination
+	Conditions map[string]interface{} // Optional conditions
+	Metadata   *bux.Metadata          // Optional metadata filter
+}
+
+// MetadataTransformFunc returns the new metadata of a record, returning the same metadata skips the
+// record (keys set to nil are removed by the server)
+type MetadataTransformFunc func(id string, metadata bux.Metadata) (bux.Metadata, error)
+
+// MigrateMetadataOptions are the options of a metadata migration
+type MigrateMetadataOptions struct {
+	DryRun          bool          // Only report the changes, nothing is written
+	Interval        time.Duration // Minimum wait between updates (rate limiting), 0 for none
+	PageSize        int           // Records fetched per page, defaults to 100
+	ContinueOnError bool          // Record the failed updates and continue, instead of stopping
+}
+
+// MetadataChange is the change of the metadata of a single record
+type MetadataChange struct {
+	ID     string       `json:"id"`
+	Before bux.Metadata `json:"before"`
+	After  bux.Metadata `json:"after"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// MigrateMetadataResult is the result of a metadata migration
+type MigrateMetadataResult struct {
+	Scanned int               `json:"scanned"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Changes []*MetadataChange `json:"changes"`
+}
+
+// metadataRecord is a record of which the metadata can be migrated
+type metadataRecord struct {
+	id       string
+	metadata bux.Metadata
+}
+
+// MigrateMetadata will page through all records matching the selector, apply the transform to their
+// metadata and write the changed metadata back to the server. Records are all fetched before the
+// first update, so updates cannot move records between pages.
+func (b *BuxClient) MigrateMetadata(ctx context.Context, selector *MetadataSelector,
+	transform MetadataTransformFunc, opts *MigrateMetadataOptions) (*MigrateMetadataResult, error) {
+
+	if opts == nil {
+		opts = &MigrateMetadataOptions{}
+	}
+
+	records, err := b.getMetadataRecords(ctx, selector, opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrateMetadataResult{
+		Scanned: len(records),
+		Changes: make([]*MetadataChange, 0),
+	}
+
+	var lastUpdate time.Time
+	for _, record := range records {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var after bux.Metadata
+		if after, err = transform(record.id, bux.Metadata(metadata.Metadata(record.metadata).Clone())); err != nil {
+			return result, err
+		}
+		if len(after) == 0 && len(record.metadata) == 0 || reflect.DeepEqual(after, record.metadata) {
+			continue
+		}
+
+		change := &MetadataChange{ID: record.id, Before: record.metadata, After: after}
+		result.Changes = append(result.Changes, change)
+		if opts.DryRun {
+			continue
+		}
+
+		// rate limiting
+		if wait := opts.Interval - b.scheduler.Now().Sub(lastUpdate); opts.Interval > 0 && wait > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-b.scheduler.After(wait):
+			}
+		}
+		lastUpdate = b.scheduler.Now()
+
+		if err = b.updateMetadata(ctx, selector.Model, record.id, record.metadata, after); err != nil {
+			change.Error = err.Error()
+			result.Failed++
+			if !opts.ContinueOnError {
+				return result, err
+			}
+			continue
+		}
+		result.Updated++
+	}
+
+	return result, nil
+}
+
+// getMetadataRecords will fetch all records matching the selector, page by page
+func (b *BuxClient) getMetadataRecords(ctx context.Context, selector *MetadataSelector,
+	pageSize int) ([]*metadataRecord, error) {
+
+	if pageSize <= 0 {
+		pageSize = defaultMigrationPageSize
+	}
+
+	records := make([]*metadataRecord, 0)
+	for page := 1; ; page++ {
+		queryParams := &transports.QueryParams{Page: page, PageSize: pageSize}
+
+		count := 0
+		switch selector.Model {
+		case MetadataModelTransaction:
+			transactions, err := b.SearchTransactions(ctx, selector.Conditions, selector.Metadata, queryParams)
+			if err != nil {
+				return nil, err
+			}
+			for _, transaction := range transactions {
+				records = append(records, &metadataRecord{id: transaction.ID, metadata: transaction.Metadata})
+			}
+			count = len(transactions)
+		case MetadataModelDestination:
+			destinations, err := b.GetDestinations(ctx, selector.Conditions, selector.Metadata, queryParams)
+			if err != nil {
+				return nil, err
+			}
+			for _, destination := range destinations {
+				records = append(records, &metadataRecord{id: destination.ID, metadata: destination.Metadata})
+			}
+			count = len(destinations)
+		default:
+			return nil, ErrUnknownMetadataModel
+		}
+
+		if count < pageSize {
+			return records, nil
+		}
+	}
+}
+
+// updateMetadata will write the metadata of the record, keys that were removed by the transform
+// are sent as nil so the server removes them
+func (b *BuxClient) updateMetadata(ctx context.Context, model, id string, before, after bux.Metadata) error {
+	values := metadata.Metadata(after).Clone()
+	for key := range before {
+		if !values.Has(key) {
+			values.Set(key, nil)
+		}
+	}
+
+	var err error
+	switch model {
+	case MetadataModelTransaction:
+		_, err = b.UpdateTransactionMetadata(ctx, id, values.Bux())
+	case MetadataModelDestination:
+		_, err = b.UpdateDestinationMetadata(ctx, id, values.Bux())
+	default:
+		err = ErrUnknownMetadataModel
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package buxclient
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bk/bip39"
+	"github.com/libsv/go-bk/chaincfg"
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidMnemonic is when the mnemonic is not a valid BIP39 mnemonic (english words)
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// ErrInvalidDerivationPath is when the derivation path is not a valid BIP32 path (ex: m/0'/1)
+var ErrInvalidDerivationPath = errors.New("invalid derivation path")
+
+// NewBuxClientFromMnemonic will create a client with the xPriv derived from the BIP39 mnemonic and its
+// passphrase (empty if none) at the derivation path (ex: m/0', the master key if empty), the options
+// are applied after the xPriv
+func NewBuxClientFromMnemonic(mnemonic, passphrase, derivationPath string, opts ...ClientOps) (*BuxClient, error) {
+	xPriv, err := XPrivFromMnemonic(mnemonic, passphrase, derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	return New(append([]ClientOps{WithXPriv(xPriv)}, opts...)...)
+}
+
+// XPrivFromMnemonic will return the xPriv derived from the BIP39 mnemonic and its passphrase (empty if
+// none) at the derivation path (ex: m/0', the master key if empty)
+func XPrivFromMnemonic(mnemonic, passphrase, derivationPath string) (string, error) {
+	words := strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic)))
+	if err := validateMnemonic(words); err != nil {
+		return "", err
+	}
+	seed, err := bip39.MnemonicToSeed(strings.Join(words, " "), norm.NFKD.String(passphrase))
+	if err != nil {
+		return "", errors.Wrap(ErrInvalidMnemonic, err.Error())
+	}
+
+	var key *bip32.ExtendedKey
+	if key, err = bip32.NewMaster(seed, &chaincfg.MainNet); err != nil {
+		return "", err
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(derivationPath), "m"), "/")
+	if key, err = key.DeriveChildFromPath(path); err != nil {
+		return "", errors.Wrapf(ErrInvalidDerivationPath, "%q", derivationPath)
+	}
+	return key.String(), nil
+}
+
+// validateMnemonic will return ErrInvalidMnemonic if the words are not a BIP39 mnemonic: 12 to 24 words
+// (a multiple of 3) of the english word list, with the checksum of the entropy in the last word
+func validateMnemonic(words []string) error {
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return errors.Wrapf(ErrInvalidMnemonic, "expected 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+
+	// the 11 bits of the index of every word
+	bits := make([]bool, 0, len(words)*11)
+	for position, word := range words {
+		index := sort.SearchStrings(bip39.English, word)
+		if index == len(bip39.English) || bip39.English[index] != word {
+			return errors.Wrapf(ErrInvalidMnemonic, "word %d (%q) is not in the word list", position+1, word)
+		}
+		for bit := 10; bit >= 0; bit-- {
+			bits = append(bits, index&(1<<bit) != 0)
+		}
+	}
+
+	// the entropy is followed by the first bits of its sha256 (1 bit per 32 bits of entropy)
+	checksumBits := len(bits) / 33
+	entropy := make([]byte, (len(bits)-checksumBits)/8)
+	for i := range entropy {
+		for bit := 0; bit < 8; bit++ {
+			if bits[i*8+bit] {
+				entropy[i] |= 1 << (7 - bit)
+			}
+		}
+	}
+	hash := sha256.Sum256(entropy)
+	for i := 0; i < checksumBits; i++ {
+		if bits[len(entropy)*8+i] != (hash[i/8]&(1<<(7-i%8)) != 0) {
+			return errors.Wrap(ErrInvalidMnemonic, "the checksum does not match (a word is wrong or misplaced)")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package buxclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMnemonic is the mnemonic of the first BIP39 test vector (with the passphrase TREZOR)
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// TestXPrivFromMnemonic will test the method XPrivFromMnemonic()
+func TestXPrivFromMnemonic(t *testing.T) {
+
+	t.Run("bip39 test vectors", func(t *testing.T) {
+		xPriv, err := XPrivFromMnemonic(testMnemonic, "TREZOR", "")
+		require.NoError(t, err)
+		assert.Equal(t, "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF", xPriv)
+
+		xPriv, err = XPrivFromMnemonic(
+			"legal winner thank year wave sausage worth useful legal winner thank yellow", "TREZOR", "m",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "xprv9s21ZrQH143K2gA81bYFHqU68xz1cX2APaSq5tt6MFSLeXnCKV1RVUJt9FWNTbrrryem4ZckN8k4Ls1H6nwdvDTvnV7zEXs2HgPezuVccsq", xPriv)
+	})
+
+	t.Run("normalized words", func(t *testing.T) {
+		xPriv, err := XPrivFromMnemonic("  "+strings.ToUpper(testMnemonic)+"\n", "TREZOR", "")
+		require.NoError(t, err)
+		assert.Equal(t, "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF", xPriv)
+	})
+
+	t.Run("derivation path", func(t *testing.T) {
+		derived, err := XPrivFromMnemonic(testMnemonic, "", "m/0'/1")
+		require.NoError(t, err)
+		master, err := XPrivFromMnemonic(testMnemonic, "", "")
+		require.NoError(t, err)
+		assert.NotEqual(t, master, derived)
+
+		same, err := XPrivFromMnemonic(testMnemonic, "", "0'/1")
+		require.NoError(t, err)
+		assert.Equal(t, derived, same)
+
+		_, err = XPrivFromMnemonic(testMnemonic, "", "m/0x")
+		assert.ErrorIs(t, err, ErrInvalidDerivationPath)
+	})
+
+	t.Run("invalid mnemonics", func(t *testing.T) {
+		_, err := XPrivFromMnemonic("abandon abandon about", "", "")
+		assert.ErrorIs(t, err, ErrInvalidMnemonic)
+
+		_, err = XPrivFromMnemonic(strings.Replace(testMnemonic, "about", "zzzz", 1), "", "")
+		assert.ErrorIs(t, err, ErrInvalidMnemonic)
+		assert.Contains(t, err.Error(), "word 12")
+
+		_, err = XPrivFromMnemonic(strings.Replace(testMnemonic, "about", "abandon", 1), "", "")
+		assert.ErrorIs(t, err, ErrInvalidMnemonic)
+		assert.Contains(t, err.Error(), "checksum")
+	})
+}
+
+// TestNewBuxClientFromMnemonic will test the method NewBuxClientFromMnemonic()
+func TestNewBuxClientFromMnemonic(t *testing.T) {
+	client, err := NewBuxClientFromMnemonic(testMnemonic, "TREZOR", "m/0'", WithHTTP(serverURL))
+	require.NoError(t, err)
+	assert.False(t, client.IsWatchOnly())
+
+	xPriv, err := XPrivFromMnemonic(testMnemonic, "TREZOR", "m/0'")
+	require.NoError(t, err)
+	assert.Equal(t, xPriv, client.xPriv.String())
+}
@@ -0,0 +1,985 @@
+// Package mocks contains a mock of the bux client, to unit test the services that use the client
+// (see buxclient.ClientInterface) without a live bux server
+package mocks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/BuxOrg/go-buxclient/beef"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/paymail"
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// ErrNotMocked is returned by the methods of the mock that have no function set
+var ErrNotMocked = errors.New("method is not mocked")
+
+// Client is a mock of the bux client, every method calls the function of the same name (ex:
+// GetTransactionFunc) when it is set, and otherwise returns zero values and ErrNotMocked
+type Client struct {
+	AdminCreatePaymailFunc        func(ctx context.Context, xPubID string, address string, publicName string, avatar string, metadata *bux.Metadata) (*transports.PaymailAddress, error)
+	AdminDeletePaymailFunc        func(ctx context.Context, address string) error
+	AdminFreezeXPubFunc           func(ctx context.Context, xPubID string, reason string) (*transports.XPubStatus, error)
+	AdminGetPaymailsFunc          func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*transports.PaymailAddress, error)
+	AdminUnfreezeXPubFunc         func(ctx context.Context, xPubID string) (*transports.XPubStatus, error)
+	DeadLettersFunc               func() *buxclient.DeadLetterQueue
+	DeleteWebhookFunc             func(ctx context.Context, id string) error
+	DoRawRequestFunc              func(ctx context.Context, method, path string, body interface{}, out interface{}) error
+	DraftSendFunc                 func(ctx context.Context, send *buxclient.SendContext, recipients []*transports.Recipients) (*bux.DraftTransaction, error)
+	DraftToRecipientsFunc         func(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata, opts ...buxclient.DraftOps) (*bux.DraftTransaction, error)
+	DraftTransactionFunc          func(ctx context.Context, transactionConfig *bux.TransactionConfig, metadata *bux.Metadata, opts ...buxclient.DraftOps) (*bux.DraftTransaction, error)
+	EnsureValidFunc               func(ctx context.Context, draft *bux.DraftTransaction, margin time.Duration) (*bux.DraftTransaction, error)
+	ExportProofBundleFunc         func(ctx context.Context, txIDs []string) ([]byte, error)
+	ExportTransactionsFunc        func(ctx context.Context, conditions map[string]interface{}, format buxclient.ExportFormat, w io.Writer) (int, error)
+	FeatureEnabledFunc            func(name string) bool
+	FinalizeTransactionFunc       func(draft *bux.DraftTransaction) (string, error)
+	GetAccessKeysFunc             func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.AccessKey, error)
+	GetAccessKeysCountFunc        func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	GetBalanceFunc                func(ctx context.Context) (*buxclient.Balance, error)
+	GetBlockHeaderFunc            func(ctx context.Context, blockHash string) (*transports.BlockHeader, error)
+	GetDashboardFunc              func(ctx context.Context, recent int, opts *buxclient.MultiQueryOptions) (*buxclient.Dashboard, error)
+	GetDestinationFunc            func(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error)
+	GetDestinationByAddressFunc   func(ctx context.Context, address string) (*bux.Destination, error)
+	GetDestinationsFunc           func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Destination, error)
+	GetDestinationsCountFunc      func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	GetDraftTransactionsCountFunc func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	GetFeeQuoteFunc               func(ctx context.Context) (*transports.FeeQuote, error)
+	GetMerkleProofFunc            func(ctx context.Context, txID string) (*transports.MerkleProof, error)
+	GetP2PPaymentDestinationFunc  func(ctx context.Context, address string, satoshis uint64) (*paymail.PaymentDestination, error)
+	GetTransactionFunc            func(ctx context.Context, txID string) (*bux.Transaction, error)
+	GetTransactionBEEFFunc        func(ctx context.Context, txID string) (*beef.BEEF, error)
+	GetTransactionProofFunc       func(ctx context.Context, txID string) (*buxclient.TransactionProof, error)
+	GetTransactionStatusFunc      func(ctx context.Context, txID string) (*transports.TransactionStatus, error)
+	GetTransactionsFunc           func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error)
+	GetTransactionsByIDsFunc      func(ctx context.Context, txIDs []string) ([]*bux.Transaction, error)
+	GetTransactionsCountFunc      func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	GetTransportFunc              func() *transports.TransportService
+	GetUtxosFunc                  func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Utxo, error)
+	GetUtxosCountFunc             func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	GetWebhooksFunc               func(ctx context.Context) ([]*transports.Webhook, error)
+	GetXPubFunc                   func(ctx context.Context) (*bux.Xpub, error)
+	GetXPubStatusFunc             func(ctx context.Context) (*transports.XPubStatus, error)
+	HasAdminKeyFunc               func() bool
+	ImportTransactionFunc         func(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	InvalidateCacheFunc           func(ctx context.Context, read buxclient.CachedRead, key string) error
+	IsDebugFunc                   func() bool
+	IsSignRequestFunc             func() bool
+	IsSpendableFunc               func(transaction *bux.Transaction, chainHeight uint64) bool
+	IsWatchOnlyFunc               func() bool
+	MigrateMetadataFunc           func(ctx context.Context, selector *buxclient.MetadataSelector, transform buxclient.MetadataTransformFunc, opts *buxclient.MigrateMetadataOptions) (*buxclient.MigrateMetadataResult, error)
+	MinConfirmationsFunc          func() uint64
+	NewDestinationsFunc           func(ctx context.Context, count int, metadata *bux.Metadata) ([]*bux.Destination, error)
+	NotificationsFunc             func(ctx context.Context) (<-chan *events.Event, error)
+	PingFunc                      func(ctx context.Context) error
+	RecordSendFunc                func(ctx context.Context, send *buxclient.SendContext) (*bux.Transaction, error)
+	RecordTransactionFunc         func(ctx context.Context, hex string, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	RecordTransactionBEEFFunc     func(ctx context.Context, transaction *beef.BEEF, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	RecordTransactionsFunc        func(ctx context.Context, requests []*transports.RecordRequest) ([]*bux.Transaction, error)
+	RefreshCapabilitiesFunc       func(ctx context.Context) error
+	RefreshFeatureFlagsFunc       func(ctx context.Context) error
+	RegisterCallbackFunc          func(url, secret string, eventTypes ...events.EventType)
+	RegisterWebhookFunc           func(ctx context.Context, url string, eventTypes []events.EventType, secret string) (*transports.Webhook, error)
+	RegisterXpubFunc              func(ctx context.Context, rawXPub string, metadata *bux.Metadata) error
+	RenewDraftFunc                func(ctx context.Context, draft *bux.DraftTransaction) (*bux.DraftTransaction, error)
+	ReplaceAccessKeyFunc          func(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error)
+	ReportUsageFunc               func() error
+	RequiresAdminFunc             func(operation string) bool
+	RestoreSnapshotFunc           func(data []byte) error
+	RotateXPrivFunc               func(ctx context.Context, newXPrivString string) error
+	RunCallbacksFunc              func(ctx context.Context, maxAttempts int) error
+	RunGraphQLFunc                func(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error
+	RunPaymentPipelineFunc        func(ctx context.Context, intents <-chan *buxclient.PaymentIntent, opts *buxclient.PaymentPipelineOptions) (*buxclient.PaymentPipelineResult, error)
+	RunQueriesFunc                func(ctx context.Context, queries map[string]buxclient.Query, opts *buxclient.MultiQueryOptions) error
+	RunUsageReportsFunc           func(ctx context.Context, interval time.Duration) error
+	ScriptTemplatesFunc           func() *buxclient.ScriptTemplateRegistry
+	SearchTransactionsFunc        func(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error)
+	SendToPaymailP2PFunc          func(ctx context.Context, address string, satoshis uint64, metadata *bux.Metadata) (*bux.Transaction, error)
+	SendToRecipientsFunc          func(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata) (*bux.Transaction, error)
+	SendToRecipientsInBatchesFunc func(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata) ([]*bux.Transaction, error)
+	ServerInfoFunc                func(ctx context.Context) (*transports.ServerInfo, error)
+	SetAdminKeyFunc               func(adminKeyString string) error
+	SetDebugFunc                  func(debug bool)
+	SetSignRequestFunc            func(signRequest bool)
+	SignSendFunc                  func(send *buxclient.SendContext, draft *bux.DraftTransaction) error
+	SnapshotFunc                  func() ([]byte, error)
+	SubscribeTransactionsFunc     func(ctx context.Context, conditions map[string]interface{}) (<-chan *bux.Transaction, error)
+	SupportsFunc                  func(capability string) bool
+	TemplateOutputFunc            func(name string, params map[string]interface{}, satoshis uint64) (*bux.TransactionOutput, error)
+	TransactionLimitsFunc         func() buxclient.TransactionLimits
+	UpdateDestinationMetadataFunc func(ctx context.Context, id string, metadata *bux.Metadata) (*bux.Destination, error)
+	UpdateTransactionMetadataFunc func(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	VerifyDestinationFunc         func(destination *bux.Destination) error
+	VerifyServerContractFunc      func(ctx context.Context) (*buxclient.ContractReport, error)
+	WaitForTransactionFunc        func(ctx context.Context, txID string, opts *buxclient.WaitOptions) (*transports.TransactionStatus, error)
+
+	calls []string
+	mu    sync.Mutex
+}
+
+// the mock implements the whole client interface
+var _ buxclient.ClientInterface = (*Client)(nil)
+
+// Calls will return the names of the methods called, in order
+func (c *Client) Calls() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+// called will record the call of the method
+func (c *Client) called(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, method)
+}
+
+// AdminCreatePaymail will call AdminCreatePaymailFunc
+func (c *Client) AdminCreatePaymail(ctx context.Context, xPubID string, address string, publicName string, avatar string, metadata *bux.Metadata) (*transports.PaymailAddress, error) {
+	c.called("AdminCreatePaymail")
+	if c.AdminCreatePaymailFunc != nil {
+		return c.AdminCreatePaymailFunc(ctx, xPubID, address, publicName, avatar, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// AdminDeletePaymail will call AdminDeletePaymailFunc
+func (c *Client) AdminDeletePaymail(ctx context.Context, address string) error {
+	c.called("AdminDeletePaymail")
+	if c.AdminDeletePaymailFunc != nil {
+		return c.AdminDeletePaymailFunc(ctx, address)
+	}
+	return ErrNotMocked
+}
+
+// AdminFreezeXPub will call AdminFreezeXPubFunc
+func (c *Client) AdminFreezeXPub(ctx context.Context, xPubID string, reason string) (*transports.XPubStatus, error) {
+	c.called("AdminFreezeXPub")
+	if c.AdminFreezeXPubFunc != nil {
+		return c.AdminFreezeXPubFunc(ctx, xPubID, reason)
+	}
+	return nil, ErrNotMocked
+}
+
+// AdminGetPaymails will call AdminGetPaymailsFunc
+func (c *Client) AdminGetPaymails(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*transports.PaymailAddress, error) {
+	c.called("AdminGetPaymails")
+	if c.AdminGetPaymailsFunc != nil {
+		return c.AdminGetPaymailsFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// AdminUnfreezeXPub will call AdminUnfreezeXPubFunc
+func (c *Client) AdminUnfreezeXPub(ctx context.Context, xPubID string) (*transports.XPubStatus, error) {
+	c.called("AdminUnfreezeXPub")
+	if c.AdminUnfreezeXPubFunc != nil {
+		return c.AdminUnfreezeXPubFunc(ctx, xPubID)
+	}
+	return nil, ErrNotMocked
+}
+
+// DeadLetters will call DeadLettersFunc
+func (c *Client) DeadLetters() *buxclient.DeadLetterQueue {
+	c.called("DeadLetters")
+	if c.DeadLettersFunc != nil {
+		return c.DeadLettersFunc()
+	}
+	return nil
+}
+
+// DeleteWebhook will call DeleteWebhookFunc
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	c.called("DeleteWebhook")
+	if c.DeleteWebhookFunc != nil {
+		return c.DeleteWebhookFunc(ctx, id)
+	}
+	return ErrNotMocked
+}
+
+// DoRawRequest will call DoRawRequestFunc
+func (c *Client) DoRawRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	c.called("DoRawRequest")
+	if c.DoRawRequestFunc != nil {
+		return c.DoRawRequestFunc(ctx, method, path, body, out)
+	}
+	return ErrNotMocked
+}
+
+// DraftSend will call DraftSendFunc
+func (c *Client) DraftSend(ctx context.Context, send *buxclient.SendContext, recipients []*transports.Recipients) (*bux.DraftTransaction, error) {
+	c.called("DraftSend")
+	if c.DraftSendFunc != nil {
+		return c.DraftSendFunc(ctx, send, recipients)
+	}
+	return nil, ErrNotMocked
+}
+
+// DraftToRecipients will call DraftToRecipientsFunc
+func (c *Client) DraftToRecipients(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata, opts ...buxclient.DraftOps) (*bux.DraftTransaction, error) {
+	c.called("DraftToRecipients")
+	if c.DraftToRecipientsFunc != nil {
+		return c.DraftToRecipientsFunc(ctx, recipients, metadata, opts...)
+	}
+	return nil, ErrNotMocked
+}
+
+// DraftTransaction will call DraftTransactionFunc
+func (c *Client) DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig, metadata *bux.Metadata, opts ...buxclient.DraftOps) (*bux.DraftTransaction, error) {
+	c.called("DraftTransaction")
+	if c.DraftTransactionFunc != nil {
+		return c.DraftTransactionFunc(ctx, transactionConfig, metadata, opts...)
+	}
+	return nil, ErrNotMocked
+}
+
+// EnsureValid will call EnsureValidFunc
+func (c *Client) EnsureValid(ctx context.Context, draft *bux.DraftTransaction, margin time.Duration) (*bux.DraftTransaction, error) {
+	c.called("EnsureValid")
+	if c.EnsureValidFunc != nil {
+		return c.EnsureValidFunc(ctx, draft, margin)
+	}
+	return nil, ErrNotMocked
+}
+
+// ExportProofBundle will call ExportProofBundleFunc
+func (c *Client) ExportProofBundle(ctx context.Context, txIDs []string) ([]byte, error) {
+	c.called("ExportProofBundle")
+	if c.ExportProofBundleFunc != nil {
+		return c.ExportProofBundleFunc(ctx, txIDs)
+	}
+	return nil, ErrNotMocked
+}
+
+// ExportTransactions will call ExportTransactionsFunc
+func (c *Client) ExportTransactions(ctx context.Context, conditions map[string]interface{}, format buxclient.ExportFormat, w io.Writer) (int, error) {
+	c.called("ExportTransactions")
+	if c.ExportTransactionsFunc != nil {
+		return c.ExportTransactionsFunc(ctx, conditions, format, w)
+	}
+	return 0, ErrNotMocked
+}
+
+// FeatureEnabled will call FeatureEnabledFunc
+func (c *Client) FeatureEnabled(name string) bool {
+	c.called("FeatureEnabled")
+	if c.FeatureEnabledFunc != nil {
+		return c.FeatureEnabledFunc(name)
+	}
+	return false
+}
+
+// FinalizeTransaction will call FinalizeTransactionFunc
+func (c *Client) FinalizeTransaction(draft *bux.DraftTransaction) (string, error) {
+	c.called("FinalizeTransaction")
+	if c.FinalizeTransactionFunc != nil {
+		return c.FinalizeTransactionFunc(draft)
+	}
+	return "", ErrNotMocked
+}
+
+// GetAccessKeys will call GetAccessKeysFunc
+func (c *Client) GetAccessKeys(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.AccessKey, error) {
+	c.called("GetAccessKeys")
+	if c.GetAccessKeysFunc != nil {
+		return c.GetAccessKeysFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetAccessKeysCount will call GetAccessKeysCountFunc
+func (c *Client) GetAccessKeysCount(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error) {
+	c.called("GetAccessKeysCount")
+	if c.GetAccessKeysCountFunc != nil {
+		return c.GetAccessKeysCountFunc(ctx, conditions, metadata)
+	}
+	return 0, ErrNotMocked
+}
+
+// GetBalance will call GetBalanceFunc
+func (c *Client) GetBalance(ctx context.Context) (*buxclient.Balance, error) {
+	c.called("GetBalance")
+	if c.GetBalanceFunc != nil {
+		return c.GetBalanceFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetBlockHeader will call GetBlockHeaderFunc
+func (c *Client) GetBlockHeader(ctx context.Context, blockHash string) (*transports.BlockHeader, error) {
+	c.called("GetBlockHeader")
+	if c.GetBlockHeaderFunc != nil {
+		return c.GetBlockHeaderFunc(ctx, blockHash)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetDashboard will call GetDashboardFunc
+func (c *Client) GetDashboard(ctx context.Context, recent int, opts *buxclient.MultiQueryOptions) (*buxclient.Dashboard, error) {
+	c.called("GetDashboard")
+	if c.GetDashboardFunc != nil {
+		return c.GetDashboardFunc(ctx, recent, opts)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetDestination will call GetDestinationFunc
+func (c *Client) GetDestination(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error) {
+	c.called("GetDestination")
+	if c.GetDestinationFunc != nil {
+		return c.GetDestinationFunc(ctx, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetDestinationByAddress will call GetDestinationByAddressFunc
+func (c *Client) GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error) {
+	c.called("GetDestinationByAddress")
+	if c.GetDestinationByAddressFunc != nil {
+		return c.GetDestinationByAddressFunc(ctx, address)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetDestinations will call GetDestinationsFunc
+func (c *Client) GetDestinations(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Destination, error) {
+	c.called("GetDestinations")
+	if c.GetDestinationsFunc != nil {
+		return c.GetDestinationsFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetDestinationsCount will call GetDestinationsCountFunc
+func (c *Client) GetDestinationsCount(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error) {
+	c.called("GetDestinationsCount")
+	if c.GetDestinationsCountFunc != nil {
+		return c.GetDestinationsCountFunc(ctx, conditions, metadata)
+	}
+	return 0, ErrNotMocked
+}
+
+// GetDraftTransactionsCount will call GetDraftTransactionsCountFunc
+func (c *Client) GetDraftTransactionsCount(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error) {
+	c.called("GetDraftTransactionsCount")
+	if c.GetDraftTransactionsCountFunc != nil {
+		return c.GetDraftTransactionsCountFunc(ctx, conditions, metadata)
+	}
+	return 0, ErrNotMocked
+}
+
+// GetFeeQuote will call GetFeeQuoteFunc
+func (c *Client) GetFeeQuote(ctx context.Context) (*transports.FeeQuote, error) {
+	c.called("GetFeeQuote")
+	if c.GetFeeQuoteFunc != nil {
+		return c.GetFeeQuoteFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetMerkleProof will call GetMerkleProofFunc
+func (c *Client) GetMerkleProof(ctx context.Context, txID string) (*transports.MerkleProof, error) {
+	c.called("GetMerkleProof")
+	if c.GetMerkleProofFunc != nil {
+		return c.GetMerkleProofFunc(ctx, txID)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetP2PPaymentDestination will call GetP2PPaymentDestinationFunc
+func (c *Client) GetP2PPaymentDestination(ctx context.Context, address string, satoshis uint64) (*paymail.PaymentDestination, error) {
+	c.called("GetP2PPaymentDestination")
+	if c.GetP2PPaymentDestinationFunc != nil {
+		return c.GetP2PPaymentDestinationFunc(ctx, address, satoshis)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransaction will call GetTransactionFunc
+func (c *Client) GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error) {
+	c.called("GetTransaction")
+	if c.GetTransactionFunc != nil {
+		return c.GetTransactionFunc(ctx, txID)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactionBEEF will call GetTransactionBEEFFunc
+func (c *Client) GetTransactionBEEF(ctx context.Context, txID string) (*beef.BEEF, error) {
+	c.called("GetTransactionBEEF")
+	if c.GetTransactionBEEFFunc != nil {
+		return c.GetTransactionBEEFFunc(ctx, txID)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactionProof will call GetTransactionProofFunc
+func (c *Client) GetTransactionProof(ctx context.Context, txID string) (*buxclient.TransactionProof, error) {
+	c.called("GetTransactionProof")
+	if c.GetTransactionProofFunc != nil {
+		return c.GetTransactionProofFunc(ctx, txID)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactionStatus will call GetTransactionStatusFunc
+func (c *Client) GetTransactionStatus(ctx context.Context, txID string) (*transports.TransactionStatus, error) {
+	c.called("GetTransactionStatus")
+	if c.GetTransactionStatusFunc != nil {
+		return c.GetTransactionStatusFunc(ctx, txID)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactions will call GetTransactionsFunc
+func (c *Client) GetTransactions(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error) {
+	c.called("GetTransactions")
+	if c.GetTransactionsFunc != nil {
+		return c.GetTransactionsFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactionsByIDs will call GetTransactionsByIDsFunc
+func (c *Client) GetTransactionsByIDs(ctx context.Context, txIDs []string) ([]*bux.Transaction, error) {
+	c.called("GetTransactionsByIDs")
+	if c.GetTransactionsByIDsFunc != nil {
+		return c.GetTransactionsByIDsFunc(ctx, txIDs)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetTransactionsCount will call GetTransactionsCountFunc
+func (c *Client) GetTransactionsCount(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error) {
+	c.called("GetTransactionsCount")
+	if c.GetTransactionsCountFunc != nil {
+		return c.GetTransactionsCountFunc(ctx, conditions, metadata)
+	}
+	return 0, ErrNotMocked
+}
+
+// GetTransport will call GetTransportFunc
+func (c *Client) GetTransport() *transports.TransportService {
+	c.called("GetTransport")
+	if c.GetTransportFunc != nil {
+		return c.GetTransportFunc()
+	}
+	return nil
+}
+
+// GetUtxos will call GetUtxosFunc
+func (c *Client) GetUtxos(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Utxo, error) {
+	c.called("GetUtxos")
+	if c.GetUtxosFunc != nil {
+		return c.GetUtxosFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetUtxosCount will call GetUtxosCountFunc
+func (c *Client) GetUtxosCount(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error) {
+	c.called("GetUtxosCount")
+	if c.GetUtxosCountFunc != nil {
+		return c.GetUtxosCountFunc(ctx, conditions, metadata)
+	}
+	return 0, ErrNotMocked
+}
+
+// GetWebhooks will call GetWebhooksFunc
+func (c *Client) GetWebhooks(ctx context.Context) ([]*transports.Webhook, error) {
+	c.called("GetWebhooks")
+	if c.GetWebhooksFunc != nil {
+		return c.GetWebhooksFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetXPub will call GetXPubFunc
+func (c *Client) GetXPub(ctx context.Context) (*bux.Xpub, error) {
+	c.called("GetXPub")
+	if c.GetXPubFunc != nil {
+		return c.GetXPubFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// GetXPubStatus will call GetXPubStatusFunc
+func (c *Client) GetXPubStatus(ctx context.Context) (*transports.XPubStatus, error) {
+	c.called("GetXPubStatus")
+	if c.GetXPubStatusFunc != nil {
+		return c.GetXPubStatusFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// HasAdminKey will call HasAdminKeyFunc
+func (c *Client) HasAdminKey() bool {
+	c.called("HasAdminKey")
+	if c.HasAdminKeyFunc != nil {
+		return c.HasAdminKeyFunc()
+	}
+	return false
+}
+
+// ImportTransaction will call ImportTransactionFunc
+func (c *Client) ImportTransaction(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("ImportTransaction")
+	if c.ImportTransactionFunc != nil {
+		return c.ImportTransactionFunc(ctx, txID, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// InvalidateCache will call InvalidateCacheFunc
+func (c *Client) InvalidateCache(ctx context.Context, read buxclient.CachedRead, key string) error {
+	c.called("InvalidateCache")
+	if c.InvalidateCacheFunc != nil {
+		return c.InvalidateCacheFunc(ctx, read, key)
+	}
+	return ErrNotMocked
+}
+
+// IsDebug will call IsDebugFunc
+func (c *Client) IsDebug() bool {
+	c.called("IsDebug")
+	if c.IsDebugFunc != nil {
+		return c.IsDebugFunc()
+	}
+	return false
+}
+
+// IsSignRequest will call IsSignRequestFunc
+func (c *Client) IsSignRequest() bool {
+	c.called("IsSignRequest")
+	if c.IsSignRequestFunc != nil {
+		return c.IsSignRequestFunc()
+	}
+	return false
+}
+
+// IsSpendable will call IsSpendableFunc
+func (c *Client) IsSpendable(transaction *bux.Transaction, chainHeight uint64) bool {
+	c.called("IsSpendable")
+	if c.IsSpendableFunc != nil {
+		return c.IsSpendableFunc(transaction, chainHeight)
+	}
+	return false
+}
+
+// IsWatchOnly will call IsWatchOnlyFunc
+func (c *Client) IsWatchOnly() bool {
+	c.called("IsWatchOnly")
+	if c.IsWatchOnlyFunc != nil {
+		return c.IsWatchOnlyFunc()
+	}
+	return false
+}
+
+// MigrateMetadata will call MigrateMetadataFunc
+func (c *Client) MigrateMetadata(ctx context.Context, selector *buxclient.MetadataSelector, transform buxclient.MetadataTransformFunc, opts *buxclient.MigrateMetadataOptions) (*buxclient.MigrateMetadataResult, error) {
+	c.called("MigrateMetadata")
+	if c.MigrateMetadataFunc != nil {
+		return c.MigrateMetadataFunc(ctx, selector, transform, opts)
+	}
+	return nil, ErrNotMocked
+}
+
+// MinConfirmations will call MinConfirmationsFunc
+func (c *Client) MinConfirmations() uint64 {
+	c.called("MinConfirmations")
+	if c.MinConfirmationsFunc != nil {
+		return c.MinConfirmationsFunc()
+	}
+	return 0
+}
+
+// NewDestinations will call NewDestinationsFunc
+func (c *Client) NewDestinations(ctx context.Context, count int, metadata *bux.Metadata) ([]*bux.Destination, error) {
+	c.called("NewDestinations")
+	if c.NewDestinationsFunc != nil {
+		return c.NewDestinationsFunc(ctx, count, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// Notifications will call NotificationsFunc
+func (c *Client) Notifications(ctx context.Context) (<-chan *events.Event, error) {
+	c.called("Notifications")
+	if c.NotificationsFunc != nil {
+		return c.NotificationsFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// Ping will call PingFunc
+func (c *Client) Ping(ctx context.Context) error {
+	c.called("Ping")
+	if c.PingFunc != nil {
+		return c.PingFunc(ctx)
+	}
+	return ErrNotMocked
+}
+
+// RecordSend will call RecordSendFunc
+func (c *Client) RecordSend(ctx context.Context, send *buxclient.SendContext) (*bux.Transaction, error) {
+	c.called("RecordSend")
+	if c.RecordSendFunc != nil {
+		return c.RecordSendFunc(ctx, send)
+	}
+	return nil, ErrNotMocked
+}
+
+// RecordTransaction will call RecordTransactionFunc
+func (c *Client) RecordTransaction(ctx context.Context, hex string, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("RecordTransaction")
+	if c.RecordTransactionFunc != nil {
+		return c.RecordTransactionFunc(ctx, hex, referenceID, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// RecordTransactionBEEF will call RecordTransactionBEEFFunc
+func (c *Client) RecordTransactionBEEF(ctx context.Context, transaction *beef.BEEF, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("RecordTransactionBEEF")
+	if c.RecordTransactionBEEFFunc != nil {
+		return c.RecordTransactionBEEFFunc(ctx, transaction, referenceID, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// RecordTransactions will call RecordTransactionsFunc
+func (c *Client) RecordTransactions(ctx context.Context, requests []*transports.RecordRequest) ([]*bux.Transaction, error) {
+	c.called("RecordTransactions")
+	if c.RecordTransactionsFunc != nil {
+		return c.RecordTransactionsFunc(ctx, requests)
+	}
+	return nil, ErrNotMocked
+}
+
+// RefreshCapabilities will call RefreshCapabilitiesFunc
+func (c *Client) RefreshCapabilities(ctx context.Context) error {
+	c.called("RefreshCapabilities")
+	if c.RefreshCapabilitiesFunc != nil {
+		return c.RefreshCapabilitiesFunc(ctx)
+	}
+	return ErrNotMocked
+}
+
+// RefreshFeatureFlags will call RefreshFeatureFlagsFunc
+func (c *Client) RefreshFeatureFlags(ctx context.Context) error {
+	c.called("RefreshFeatureFlags")
+	if c.RefreshFeatureFlagsFunc != nil {
+		return c.RefreshFeatureFlagsFunc(ctx)
+	}
+	return ErrNotMocked
+}
+
+// RegisterCallback will call RegisterCallbackFunc
+func (c *Client) RegisterCallback(url, secret string, eventTypes ...events.EventType) {
+	c.called("RegisterCallback")
+	if c.RegisterCallbackFunc != nil {
+		c.RegisterCallbackFunc(url, secret, eventTypes...)
+	}
+}
+
+// RegisterWebhook will call RegisterWebhookFunc
+func (c *Client) RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType, secret string) (*transports.Webhook, error) {
+	c.called("RegisterWebhook")
+	if c.RegisterWebhookFunc != nil {
+		return c.RegisterWebhookFunc(ctx, url, eventTypes, secret)
+	}
+	return nil, ErrNotMocked
+}
+
+// RegisterXpub will call RegisterXpubFunc
+func (c *Client) RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error {
+	c.called("RegisterXpub")
+	if c.RegisterXpubFunc != nil {
+		return c.RegisterXpubFunc(ctx, rawXPub, metadata)
+	}
+	return ErrNotMocked
+}
+
+// RenewDraft will call RenewDraftFunc
+func (c *Client) RenewDraft(ctx context.Context, draft *bux.DraftTransaction) (*bux.DraftTransaction, error) {
+	c.called("RenewDraft")
+	if c.RenewDraftFunc != nil {
+		return c.RenewDraftFunc(ctx, draft)
+	}
+	return nil, ErrNotMocked
+}
+
+// ReplaceAccessKey will call ReplaceAccessKeyFunc
+func (c *Client) ReplaceAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error) {
+	c.called("ReplaceAccessKey")
+	if c.ReplaceAccessKeyFunc != nil {
+		return c.ReplaceAccessKeyFunc(ctx, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// ReportUsage will call ReportUsageFunc
+func (c *Client) ReportUsage() error {
+	c.called("ReportUsage")
+	if c.ReportUsageFunc != nil {
+		return c.ReportUsageFunc()
+	}
+	return ErrNotMocked
+}
+
+// RequiresAdmin will call RequiresAdminFunc
+func (c *Client) RequiresAdmin(operation string) bool {
+	c.called("RequiresAdmin")
+	if c.RequiresAdminFunc != nil {
+		return c.RequiresAdminFunc(operation)
+	}
+	return false
+}
+
+// RestoreSnapshot will call RestoreSnapshotFunc
+func (c *Client) RestoreSnapshot(data []byte) error {
+	c.called("RestoreSnapshot")
+	if c.RestoreSnapshotFunc != nil {
+		return c.RestoreSnapshotFunc(data)
+	}
+	return ErrNotMocked
+}
+
+// RotateXPriv will call RotateXPrivFunc
+func (c *Client) RotateXPriv(ctx context.Context, newXPrivString string) error {
+	c.called("RotateXPriv")
+	if c.RotateXPrivFunc != nil {
+		return c.RotateXPrivFunc(ctx, newXPrivString)
+	}
+	return ErrNotMocked
+}
+
+// RunCallbacks will call RunCallbacksFunc
+func (c *Client) RunCallbacks(ctx context.Context, maxAttempts int) error {
+	c.called("RunCallbacks")
+	if c.RunCallbacksFunc != nil {
+		return c.RunCallbacksFunc(ctx, maxAttempts)
+	}
+	return ErrNotMocked
+}
+
+// RunGraphQL will call RunGraphQLFunc
+func (c *Client) RunGraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	c.called("RunGraphQL")
+	if c.RunGraphQLFunc != nil {
+		return c.RunGraphQLFunc(ctx, query, variables, out)
+	}
+	return ErrNotMocked
+}
+
+// RunPaymentPipeline will call RunPaymentPipelineFunc
+func (c *Client) RunPaymentPipeline(ctx context.Context, intents <-chan *buxclient.PaymentIntent, opts *buxclient.PaymentPipelineOptions) (*buxclient.PaymentPipelineResult, error) {
+	c.called("RunPaymentPipeline")
+	if c.RunPaymentPipelineFunc != nil {
+		return c.RunPaymentPipelineFunc(ctx, intents, opts)
+	}
+	return nil, ErrNotMocked
+}
+
+// RunQueries will call RunQueriesFunc
+func (c *Client) RunQueries(ctx context.Context, queries map[string]buxclient.Query, opts *buxclient.MultiQueryOptions) error {
+	c.called("RunQueries")
+	if c.RunQueriesFunc != nil {
+		return c.RunQueriesFunc(ctx, queries, opts)
+	}
+	return ErrNotMocked
+}
+
+// RunUsageReports will call RunUsageReportsFunc
+func (c *Client) RunUsageReports(ctx context.Context, interval time.Duration) error {
+	c.called("RunUsageReports")
+	if c.RunUsageReportsFunc != nil {
+		return c.RunUsageReportsFunc(ctx, interval)
+	}
+	return ErrNotMocked
+}
+
+// ScriptTemplates will call ScriptTemplatesFunc
+func (c *Client) ScriptTemplates() *buxclient.ScriptTemplateRegistry {
+	c.called("ScriptTemplates")
+	if c.ScriptTemplatesFunc != nil {
+		return c.ScriptTemplatesFunc()
+	}
+	return nil
+}
+
+// SearchTransactions will call SearchTransactionsFunc
+func (c *Client) SearchTransactions(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *transports.QueryParams) ([]*bux.Transaction, error) {
+	c.called("SearchTransactions")
+	if c.SearchTransactionsFunc != nil {
+		return c.SearchTransactionsFunc(ctx, conditions, metadata, queryParams)
+	}
+	return nil, ErrNotMocked
+}
+
+// SendToPaymailP2P will call SendToPaymailP2PFunc
+func (c *Client) SendToPaymailP2P(ctx context.Context, address string, satoshis uint64, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("SendToPaymailP2P")
+	if c.SendToPaymailP2PFunc != nil {
+		return c.SendToPaymailP2PFunc(ctx, address, satoshis, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// SendToRecipients will call SendToRecipientsFunc
+func (c *Client) SendToRecipients(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("SendToRecipients")
+	if c.SendToRecipientsFunc != nil {
+		return c.SendToRecipientsFunc(ctx, recipients, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// SendToRecipientsInBatches will call SendToRecipientsInBatchesFunc
+func (c *Client) SendToRecipientsInBatches(ctx context.Context, recipients []*transports.Recipients, metadata *bux.Metadata) ([]*bux.Transaction, error) {
+	c.called("SendToRecipientsInBatches")
+	if c.SendToRecipientsInBatchesFunc != nil {
+		return c.SendToRecipientsInBatchesFunc(ctx, recipients, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// ServerInfo will call ServerInfoFunc
+func (c *Client) ServerInfo(ctx context.Context) (*transports.ServerInfo, error) {
+	c.called("ServerInfo")
+	if c.ServerInfoFunc != nil {
+		return c.ServerInfoFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// SetAdminKey will call SetAdminKeyFunc
+func (c *Client) SetAdminKey(adminKeyString string) error {
+	c.called("SetAdminKey")
+	if c.SetAdminKeyFunc != nil {
+		return c.SetAdminKeyFunc(adminKeyString)
+	}
+	return ErrNotMocked
+}
+
+// SetDebug will call SetDebugFunc
+func (c *Client) SetDebug(debug bool) {
+	c.called("SetDebug")
+	if c.SetDebugFunc != nil {
+		c.SetDebugFunc(debug)
+	}
+}
+
+// SetSignRequest will call SetSignRequestFunc
+func (c *Client) SetSignRequest(signRequest bool) {
+	c.called("SetSignRequest")
+	if c.SetSignRequestFunc != nil {
+		c.SetSignRequestFunc(signRequest)
+	}
+}
+
+// SignSend will call SignSendFunc
+func (c *Client) SignSend(send *buxclient.SendContext, draft *bux.DraftTransaction) error {
+	c.called("SignSend")
+	if c.SignSendFunc != nil {
+		return c.SignSendFunc(send, draft)
+	}
+	return ErrNotMocked
+}
+
+// Snapshot will call SnapshotFunc
+func (c *Client) Snapshot() ([]byte, error) {
+	c.called("Snapshot")
+	if c.SnapshotFunc != nil {
+		return c.SnapshotFunc()
+	}
+	return nil, ErrNotMocked
+}
+
+// SubscribeTransactions will call SubscribeTransactionsFunc
+func (c *Client) SubscribeTransactions(ctx context.Context, conditions map[string]interface{}) (<-chan *bux.Transaction, error) {
+	c.called("SubscribeTransactions")
+	if c.SubscribeTransactionsFunc != nil {
+		return c.SubscribeTransactionsFunc(ctx, conditions)
+	}
+	return nil, ErrNotMocked
+}
+
+// Supports will call SupportsFunc
+func (c *Client) Supports(capability string) bool {
+	c.called("Supports")
+	if c.SupportsFunc != nil {
+		return c.SupportsFunc(capability)
+	}
+	return false
+}
+
+// TemplateOutput will call TemplateOutputFunc
+func (c *Client) TemplateOutput(name string, params map[string]interface{}, satoshis uint64) (*bux.TransactionOutput, error) {
+	c.called("TemplateOutput")
+	if c.TemplateOutputFunc != nil {
+		return c.TemplateOutputFunc(name, params, satoshis)
+	}
+	return nil, ErrNotMocked
+}
+
+// TransactionLimits will call TransactionLimitsFunc
+func (c *Client) TransactionLimits() buxclient.TransactionLimits {
+	c.called("TransactionLimits")
+	if c.TransactionLimitsFunc != nil {
+		return c.TransactionLimitsFunc()
+	}
+	return buxclient.TransactionLimits{}
+}
+
+// UpdateDestinationMetadata will call UpdateDestinationMetadataFunc
+func (c *Client) UpdateDestinationMetadata(ctx context.Context, id string, metadata *bux.Metadata) (*bux.Destination, error) {
+	c.called("UpdateDestinationMetadata")
+	if c.UpdateDestinationMetadataFunc != nil {
+		return c.UpdateDestinationMetadataFunc(ctx, id, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// UpdateTransactionMetadata will call UpdateTransactionMetadataFunc
+func (c *Client) UpdateTransactionMetadata(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error) {
+	c.called("UpdateTransactionMetadata")
+	if c.UpdateTransactionMetadataFunc != nil {
+		return c.UpdateTransactionMetadataFunc(ctx, txID, metadata)
+	}
+	return nil, ErrNotMocked
+}
+
+// VerifyDestination will call VerifyDestinationFunc
+func (c *Client) VerifyDestination(destination *bux.Destination) error {
+	c.called("VerifyDestination")
+	if c.VerifyDestinationFunc != nil {
+		return c.VerifyDestinationFunc(destination)
+	}
+	return ErrNotMocked
+}
+
+// VerifyServerContract will call VerifyServerContractFunc
+func (c *Client) VerifyServerContract(ctx context.Context) (*buxclient.ContractReport, error) {
+	c.called("VerifyServerContract")
+	if c.VerifyServerContractFunc != nil {
+		return c.VerifyServerContractFunc(ctx)
+	}
+	return nil, ErrNotMocked
+}
+
+// WaitForTransaction will call WaitForTransactionFunc
+func (c *Client) WaitForTransaction(ctx context.Context, txID string,
+	opts *buxclient.WaitOptions) (*transports.TransactionStatus, error) {
+
+	c.called("WaitForTransaction")
+	if c.WaitForTransactionFunc != nil {
+		return c.WaitForTransactionFunc(ctx, txID, opts)
+	}
+	return nil, ErrNotMocked
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	buxclient "github.com/BuxOrg/go-buxclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient will test the mock of the client
+func TestClient(t *testing.T) {
+	mock := &Client{
+		GetTransactionFunc: func(ctx context.Context, txID string) (*bux.Transaction, error) {
+			transaction := &bux.Transaction{}
+			transaction.ID = txID
+			return transaction, nil
+		},
+	}
+	var client buxclient.ClientInterface = mock
+
+	transaction, err := client.GetTransaction(context.Background(), "tx-id")
+	require.NoError(t, err)
+	assert.Equal(t, "tx-id", transaction.ID)
+
+	_, err = client.GetDestination(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrNotMocked)
+	assert.False(t, client.HasAdminKey())
+	client.SetDebug(true)
+
+	assert.Equal(t, []string{"GetTransaction", "GetDestination", "HasAdminKey", "SetDebug"}, mock.Calls())
+}
@@ -0,0 +1,160 @@
+package buxclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// defaultQueryConcurrency is the number of queries run at the same time by default
+const defaultQueryConcurrency = 4
+
+// defaultDashboardSize is the number of recent records of the dashboard by default
+const defaultDashboardSize = 10
+
+// ErrQueriesFailed is when some queries of RunQueries failed
+var ErrQueriesFailed = errors.New("queries failed")
+
+// Query is an independent read of RunQueries, it keeps its result (ex: in a variable of the caller)
+type Query func(ctx context.Context) error
+
+// MultiQueryOptions are the options of RunQueries
+type MultiQueryOptions struct {
+	Concurrency int  // Queries run at the same time, defaults to 4
+	FailFast    bool // Cancel the other queries at the first error
+}
+
+// MultiQueryError is the errors of the failed queries, by name, it matches ErrQueriesFailed and the
+// errors of the queries with errors.Is
+type MultiQueryError struct {
+	Errors  map[string]error
+	Queries int // number of queries run
+}
+
+// Error will return the error message, with the errors of the queries sorted by name
+func (e *MultiQueryError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, name+": "+e.Errors[name].Error())
+	}
+	return fmt.Sprintf("%d of %d %s: %s", len(e.Errors), e.Queries, ErrQueriesFailed.Error(),
+		strings.Join(messages, "; "))
+}
+
+// Is will return whether the target is ErrQueriesFailed or the error of one of the queries
+func (e *MultiQueryError) Is(target error) bool {
+	if target == ErrQueriesFailed {
+		return true
+	}
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunQueries will run the queries concurrently (at most opts.Concurrency at the same time) and wait for
+// all of them, the errors of the failed queries are returned in a *MultiQueryError. With FailFast, the
+// context of the other queries is canceled at the first error, the queries not started yet fail with
+// the error of the context.
+func (b *BuxClient) RunQueries(ctx context.Context, queries map[string]Query, opts *MultiQueryOptions) error {
+	if opts == nil {
+		opts = &MultiQueryOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultQueryConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the queries are started in the order of their names
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	slots := make(chan struct{}, concurrency)
+	for _, name := range names {
+		name, query := name, queries[name]
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			failed[name] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := query(ctx); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+				if opts.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &MultiQueryError{Errors: failed, Queries: len(queries)}
+	}
+	return nil
+}
+
+// Dashboard is the overview of the xPub: its balance, and its most recent destinations and transactions
+type Dashboard struct {
+	Balance            *Balance
+	Destinations       []*bux.Destination
+	RecentTransactions []*bux.Transaction
+}
+
+// GetDashboard will get the balance, and the most recent destinations and transactions (10 by default)
+// concurrently, the parts that could be read are returned with the *MultiQueryError of the others
+func (b *BuxClient) GetDashboard(ctx context.Context, recent int, opts *MultiQueryOptions) (*Dashboard, error) {
+	if recent <= 0 {
+		recent = defaultDashboardSize
+	}
+
+	dashboard := new(Dashboard)
+	err := b.RunQueries(ctx, map[string]Query{
+		"balance": func(ctx context.Context) (err error) {
+			dashboard.Balance, err = b.GetBalance(ctx)
+			return err
+		},
+		"destinations": func(ctx context.Context) (err error) {
+			dashboard.Destinations, err = b.GetDestinations(ctx, nil, nil, transports.NewestFirst(1, recent))
+			return err
+		},
+		"transactions": func(ctx context.Context) (err error) {
+			dashboard.RecentTransactions, err = b.SearchTransactions(ctx, nil, nil, transports.NewestFirst(1, recent))
+			return err
+		},
+	}, opts)
+	return dashboard, err
+}
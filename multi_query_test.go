@@ -0,0 +1,130 @@
+package buxclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunQueries will test running independent reads concurrently
+func TestRunQueries(t *testing.T) {
+	ctx := context.Background()
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: http.NewServeMux()}}),
+	)
+	require.NoError(t, err)
+	errRead := errors.New("read failed")
+
+	t.Run("bounded parallelism", func(t *testing.T) {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		started := make(chan struct{}, 5)
+		release := make(chan struct{})
+		query := func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			started <- struct{}{}
+			<-release
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}
+		queries := map[string]Query{"a": query, "b": query, "c": query, "d": query, "e": query}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- client.RunQueries(ctx, queries, &MultiQueryOptions{Concurrency: 2})
+		}()
+
+		// two queries are running at once, the others wait for a slot until they are released
+		<-started
+		<-started
+		mu.Lock()
+		assert.Equal(t, 2, running)
+		mu.Unlock()
+		close(release)
+
+		require.NoError(t, <-done)
+		assert.LessOrEqual(t, maxRunning, 2)
+		assert.Len(t, started, 3)
+	})
+
+	t.Run("aggregated errors", func(t *testing.T) {
+		var read bool
+		err := client.RunQueries(ctx, map[string]Query{
+			"balance": func(ctx context.Context) error {
+				return errRead
+			},
+			"destinations": func(ctx context.Context) error {
+				read = true
+				return nil
+			},
+			"transactions": func(ctx context.Context) error {
+				return ErrWatchOnly
+			},
+		}, nil)
+
+		assert.True(t, read)
+		assert.True(t, errors.Is(err, ErrQueriesFailed))
+		assert.True(t, errors.Is(err, errRead))
+		assert.True(t, errors.Is(err, ErrWatchOnly))
+		var multiErr *MultiQueryError
+		require.True(t, errors.As(err, &multiErr))
+		assert.Len(t, multiErr.Errors, 2)
+		assert.Equal(t, 3, multiErr.Queries)
+		assert.Equal(t, "2 of 3 queries failed: balance: read failed; transactions: "+ErrWatchOnly.Error(), err.Error())
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		err := client.RunQueries(ctx, map[string]Query{
+			"a": func(ctx context.Context) error {
+				return errRead
+			},
+			"b": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}, &MultiQueryOptions{FailFast: true})
+
+		var multiErr *MultiQueryError
+		require.True(t, errors.As(err, &multiErr))
+		assert.Equal(t, errRead, multiErr.Errors["a"])
+		assert.Equal(t, context.Canceled, multiErr.Errors["b"])
+	})
+}
+
+// TestGetDashboard will test reading the dashboard of the xPub
+func TestGetDashboard(t *testing.T) {
+	ctx := context.Background()
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	_, err = server.Fund(xPub, 10000)
+	require.NoError(t, err)
+
+	for _, opt := range []ClientOps{WithHTTP(server.URL), WithGraphQL(server.GraphQLURL())} {
+		client, err := New(WithXPriv(xPriv), opt)
+		require.NoError(t, err)
+
+		dashboard, err := client.GetDashboard(ctx, 0, nil)
+		require.NoError(t, err)
+		require.NotNil(t, dashboard.Balance)
+		assert.Len(t, dashboard.RecentTransactions, 1)
+		assert.NotEmpty(t, dashboard.Destinations)
+	}
+}
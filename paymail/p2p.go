@@ -0,0 +1,113 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BuxOrg/bux"
+)
+
+// PaymentDestination is the destination of a P2P payment given by the paymail host of the receiver: the
+// outputs of the transaction, and the reference to send with the transaction
+type PaymentDestination struct {
+	Outputs   []*PaymentOutput `json:"outputs"`
+	Reference string           `json:"reference"`
+}
+
+// PaymentOutput is an output of a P2P payment destination
+type PaymentOutput struct {
+	Address  string `json:"address,omitempty"`
+	Satoshis uint64 `json:"satoshis"`
+	Script   string `json:"script"` // locking script (hex)
+}
+
+// TransactionOutputs will return the outputs of the destination as outputs of a draft transaction
+// (see bux.TransactionConfig)
+func (d *PaymentDestination) TransactionOutputs() []*bux.TransactionOutput {
+	outputs := make([]*bux.TransactionOutput, 0, len(d.Outputs))
+	for _, output := range d.Outputs {
+		outputs = append(outputs, &bux.TransactionOutput{
+			Satoshis: output.Satoshis,
+			Scripts: []*bux.ScriptOutput{{
+				Address:  output.Address,
+				Satoshis: output.Satoshis,
+				Script:   output.Script,
+			}},
+		})
+	}
+	return outputs
+}
+
+// P2PTransaction is a transaction sent to the paymail host of the receiver of a P2P payment
+type P2PTransaction struct {
+	Hex       string                `json:"hex"`
+	Metadata  *P2PTransactionSender `json:"metadata,omitempty"`
+	Reference string                `json:"reference"` // reference of the payment destination
+}
+
+// P2PTransactionSender is the sender information of a P2P transaction, all the fields are optional
+type P2PTransactionSender struct {
+	Note      string `json:"note,omitempty"`
+	PubKey    string `json:"pubkey,omitempty"`
+	Sender    string `json:"sender,omitempty"` // paymail of the sender
+	Signature string `json:"signature,omitempty"`
+}
+
+// P2PTransactionResponse is the response of the paymail host of the receiver of a P2P transaction
+type P2PTransactionResponse struct {
+	Note string `json:"note"`
+	TxID string `json:"txid"`
+}
+
+// GetP2PPaymentDestination will request the destination of a P2P payment of the satoshis to the paymail
+// address, from its paymail host
+func (c *Client) GetP2PPaymentDestination(ctx context.Context, address string,
+	satoshis uint64) (*PaymentDestination, error) {
+
+	url, err := c.capabilityURL(ctx, address, BRFCP2PPaymentDestination)
+	if err != nil {
+		return nil, err
+	}
+
+	var destination *PaymentDestination
+	if err = c.doRequest(ctx, http.MethodPost, url, map[string]uint64{"satoshis": satoshis}, &destination); err != nil {
+		return nil, err
+	}
+	if destination == nil || len(destination.Outputs) == 0 || destination.Reference == "" {
+		return nil, errors.New("paymail error: invalid payment destination of " + address)
+	}
+
+	// the receiver must not make the sender pay more (or less) than requested
+	var total uint64
+	for _, output := range destination.Outputs {
+		if output == nil || total+output.Satoshis < total {
+			return nil, errors.New("paymail error: invalid payment destination of " + address)
+		}
+		total += output.Satoshis
+	}
+	if total != satoshis {
+		return nil, fmt.Errorf("%w: %d satoshis instead of %d for %s", ErrAmountMismatch, total, satoshis, address)
+	}
+	return destination, nil
+}
+
+// SendP2PTransaction will send the transaction of a P2P payment to the paymail host of the receiver
+func (c *Client) SendP2PTransaction(ctx context.Context, address string,
+	transaction *P2PTransaction) (*P2PTransactionResponse, error) {
+
+	url, err := c.capabilityURL(ctx, address, BRFCP2PTransactions)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *P2PTransactionResponse
+	if err = c.doRequest(ctx, http.MethodPost, url, transaction, &response); err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, errors.New("paymail error: invalid response of " + address)
+	}
+	return response, nil
+}
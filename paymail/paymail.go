@@ -0,0 +1,247 @@
+// Package paymail contains a client of the paymail hosts of the counterparties (bsvalias), to discover
+// their capabilities and make P2P payments: request payment destinations and send them the transactions
+package paymail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/utils"
+)
+
+// BRFC IDs of the capabilities used by the client
+const (
+	BRFCP2PPaymentDestination = "2a40af698840" // P2P payment destinations (outputs and reference)
+	BRFCP2PTransactions       = "5f1323cddf31" // P2P transactions (receive the transactions of the payments)
+)
+
+// ErrInvalidPaymail is when a paymail address is malformed
+var ErrInvalidPaymail = errors.New("invalid paymail address")
+
+// ErrAmountMismatch is when the outputs of a P2P payment destination do not pay the requested satoshis
+var ErrAmountMismatch = errors.New("the payment destination does not pay the requested satoshis")
+
+// ErrCapabilityNotFound is when the paymail host does not support a capability
+var ErrCapabilityNotFound = errors.New("the paymail host does not support the capability")
+
+// Capabilities are the capabilities of a paymail host (.well-known/bsvalias)
+type Capabilities struct {
+	BsvAlias     string                 `json:"bsvalias"`
+	Capabilities map[string]interface{} `json:"capabilities"`
+}
+
+// URL will return the URL (template) of the capability, empty if the host does not support it
+func (c *Capabilities) URL(brfcID string) string {
+	url, _ := c.Capabilities[brfcID].(string)
+	return url
+}
+
+// LookupSRVFunc returns the SRV records of the service of the domain, in the order of their priority
+// and weight (see net.Resolver.LookupSRV)
+type LookupSRVFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+// Options are the options of the paymail client
+type Options struct {
+	CacheTTL  time.Duration       // Time the capabilities of a domain are cached, 0 disables the cache
+	LookupSRV LookupSRVFunc       // Lookup of the _bsvalias._tcp SRV records, the default resolver when nil
+	Scheduler scheduler.Scheduler // Clock of the cache, real time when nil
+}
+
+// DefaultOptions will return the default options of the paymail client (capabilities cached 10 minutes)
+func DefaultOptions() *Options {
+	return &Options{
+		CacheTTL: 10 * time.Minute,
+	}
+}
+
+// cachedCapabilities are the capabilities of a domain and the time they expire
+type cachedCapabilities struct {
+	capabilities *Capabilities
+	expires      time.Time
+}
+
+// Client requests the paymail hosts of the counterparties, the capabilities of the domains are cached
+// so repeated payments to a domain do not discover them again
+type Client struct {
+	cache      map[string]*cachedCapabilities
+	httpClient *http.Client
+	mu         sync.Mutex
+	opts       *Options
+}
+
+// NewClient will create a new paymail client, the http client and the options are optional
+func NewClient(httpClient *http.Client, opts *Options) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	if opts.Scheduler == nil || opts.LookupSRV == nil {
+		copied := *opts
+		if copied.Scheduler == nil {
+			copied.Scheduler = scheduler.Default()
+		}
+		if copied.LookupSRV == nil {
+			copied.LookupSRV = net.DefaultResolver.LookupSRV
+		}
+		opts = &copied
+	}
+	return &Client{
+		cache:      make(map[string]*cachedCapabilities),
+		httpClient: httpClient,
+		opts:       opts,
+	}
+}
+
+// ParseAddress will return the alias and the domain of the paymail address (alias@domain.tld)
+func ParseAddress(address string) (alias, domain string, err error) {
+	if !utils.IsValidPaymail(address) {
+		return "", "", ErrInvalidPaymail
+	}
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(address)), "@")
+	return parts[0], parts[1], nil
+}
+
+// GetCapabilities will get the capabilities of the paymail host of the domain, from the cache when they
+// were discovered less than the cache TTL ago. The host is the target of the _bsvalias._tcp SRV record
+// of the domain, or the domain without a valid record (see Host).
+func (c *Client) GetCapabilities(ctx context.Context, domain string) (*Capabilities, error) {
+	domain = strings.ToLower(domain)
+	if capabilities := c.cached(domain); capabilities != nil {
+		return capabilities, nil
+	}
+
+	var capabilities *Capabilities
+	url := "https://" + c.Host(ctx, domain) + "/.well-known/bsvalias"
+	if err := c.doRequest(ctx, http.MethodGet, url, nil, &capabilities); err != nil {
+		return nil, err
+	}
+	if capabilities == nil || capabilities.BsvAlias == "" {
+		return nil, errors.New("paymail error: invalid capabilities of " + domain)
+	}
+
+	if c.opts.CacheTTL > 0 {
+		c.mu.Lock()
+		c.cache[domain] = &cachedCapabilities{
+			capabilities: capabilities,
+			expires:      c.opts.Scheduler.Now().Add(c.opts.CacheTTL),
+		}
+		c.mu.Unlock()
+	}
+	return capabilities, nil
+}
+
+// Host will return the paymail host of the domain (host:port): the target of the first _bsvalias._tcp
+// SRV record of the domain, or the domain without records. The DNSSEC status of the records is not
+// known, so the targets outside of the domain (ex: example.com -> paymail.attacker.com) are ignored,
+// the TLS certificate of the host is verified for the target.
+func (c *Client) Host(ctx context.Context, domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	_, records, err := c.opts.LookupSRV(ctx, "bsvalias", "tcp", domain)
+	if err != nil {
+		return domain
+	}
+	for _, record := range records {
+		target := strings.TrimSuffix(strings.ToLower(record.Target), ".")
+		if record.Port == 0 || target != domain && !strings.HasSuffix(target, "."+domain) {
+			continue
+		}
+		if record.Port == 443 {
+			return target
+		}
+		return net.JoinHostPort(target, strconv.Itoa(int(record.Port)))
+	}
+	return domain
+}
+
+// ClearCache will remove the cached capabilities of the domain (ex: after its paymail host moved), or of
+// all the domains when the domain is empty
+func (c *Client) ClearCache(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if domain == "" {
+		c.cache = make(map[string]*cachedCapabilities)
+		return
+	}
+	delete(c.cache, strings.ToLower(domain))
+}
+
+// cached will return the cached capabilities of the domain, nil if they are not cached or expired
+func (c *Client) cached(domain string) *Capabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[domain]
+	if !ok {
+		return nil
+	}
+	if !c.opts.Scheduler.Now().Before(entry.expires) {
+		delete(c.cache, domain)
+		return nil
+	}
+	return entry.capabilities
+}
+
+// capabilityURL will return the URL of the capability for the paymail address
+func (c *Client) capabilityURL(ctx context.Context, address, brfcID string) (string, error) {
+	alias, domain, err := ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	var capabilities *Capabilities
+	if capabilities, err = c.GetCapabilities(ctx, domain); err != nil {
+		return "", err
+	}
+	url := capabilities.URL(brfcID)
+	if url == "" {
+		return "", ErrCapabilityNotFound
+	}
+	url = strings.ReplaceAll(url, "{alias}", alias)
+	return strings.ReplaceAll(url, "{domain.tld}", domain), nil
+}
+
+// doRequest will send the request (json) to the paymail host and decode the response
+func (c *Client) doRequest(ctx context.Context, method, url string, body, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	var resp *http.Response
+	if resp, err = c.httpClient.Do(req); err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 400 {
+		return errors.New("paymail error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(response)
+}
@@ -0,0 +1,184 @@
+package paymail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hostRoundTripper sends all the requests to the handler, whatever their host
+type hostRoundTripper struct {
+	handler http.Handler
+}
+
+func (h hostRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	h.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// noSRV resolves no SRV record, the hosts are the domains
+func noSRV(context.Context, string, string, string) (string, []*net.SRV, error) {
+	return "", nil, &net.DNSError{Err: "no such host", IsNotFound: true}
+}
+
+// testOptions are the default options without DNS lookups
+func testOptions() *Options {
+	opts := DefaultOptions()
+	opts.LookupSRV = noSRV
+	return opts
+}
+
+// TestParseAddress will test the parsing of the paymail addresses
+func TestParseAddress(t *testing.T) {
+	alias, domain, err := ParseAddress(" Alice@Example.com ")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alias)
+	assert.Equal(t, "example.com", domain)
+
+	for _, address := range []string{"", "alice", "@example.com", "alice@example", "alice@bob@example.com"} {
+		_, _, err = ParseAddress(address)
+		assert.ErrorIs(t, err, ErrInvalidPaymail, address)
+	}
+}
+
+// TestClient will test the requests of the paymail client
+func TestClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/bsvalias", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Host {
+		case "example.com":
+			_, _ = w.Write([]byte(`{"bsvalias":"1.0","capabilities":{` +
+				`"2a40af698840":"https://example.com/p2p/{alias}@{domain.tld}/destinations"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/p2p/alice@example.com/destinations", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		_, _ = w.Write([]byte(`{"outputs":[{"script":"76a914","satoshis":600},{"script":"76a915","satoshis":400}],` +
+			`"reference":"ref-1"}`))
+	})
+	mux.HandleFunc("/p2p/bob@example.com/destinations", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"outputs":[{"script":"76a914","satoshis":600},{"script":"76a915","satoshis":900}],` +
+			`"reference":"ref-2"}`))
+	})
+	client := NewClient(&http.Client{Transport: hostRoundTripper{handler: mux}}, testOptions())
+
+	capabilities, err := client.GetCapabilities(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0", capabilities.BsvAlias)
+	assert.Empty(t, capabilities.URL(BRFCP2PTransactions))
+
+	destination, err := client.GetP2PPaymentDestination(context.Background(), "alice@example.com", 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "ref-1", destination.Reference)
+	outputs := destination.TransactionOutputs()
+	require.Len(t, outputs, 2)
+	assert.Equal(t, uint64(600), outputs[0].Satoshis)
+	assert.Equal(t, "76a915", outputs[1].Scripts[0].Script)
+
+	_, err = client.GetP2PPaymentDestination(context.Background(), "bob@example.com", 1000)
+	assert.ErrorIs(t, err, ErrAmountMismatch)
+
+	_, err = client.SendP2PTransaction(context.Background(), "alice@example.com", &P2PTransaction{Hex: "00"})
+	assert.ErrorIs(t, err, ErrCapabilityNotFound)
+
+	_, err = client.GetCapabilities(context.Background(), "unknown.com")
+	assert.Error(t, err)
+}
+
+// TestCapabilitiesCache will test the cache of the capabilities
+func TestCapabilitiesCache(t *testing.T) {
+	var discoveries int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/bsvalias", func(w http.ResponseWriter, req *http.Request) {
+		discoveries++
+		_, _ = w.Write([]byte(`{"bsvalias":"1.0","capabilities":{}}`))
+	})
+	httpClient := &http.Client{Transport: hostRoundTripper{handler: mux}}
+	virtual := scheduler.NewVirtual(time.Now())
+	client := NewClient(httpClient, &Options{CacheTTL: time.Minute, LookupSRV: noSRV, Scheduler: virtual})
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetCapabilities(context.Background(), "Example.com")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, discoveries)
+
+	// the capabilities expire after the TTL
+	virtual.Advance(time.Minute)
+	_, err := client.GetCapabilities(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, discoveries)
+
+	client.ClearCache("EXAMPLE.com")
+	_, err = client.GetCapabilities(context.Background(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, discoveries)
+
+	// the cache is disabled without TTL
+	client = NewClient(httpClient, &Options{LookupSRV: noSRV})
+	for i := 0; i < 2; i++ {
+		_, err = client.GetCapabilities(context.Background(), "example.com")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 5, discoveries)
+}
+
+// TestHost will test the resolution of the paymail hosts from the SRV records
+func TestHost(t *testing.T) {
+	var hosts []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/bsvalias", func(w http.ResponseWriter, req *http.Request) {
+		hosts = append(hosts, req.Host)
+		_, _ = w.Write([]byte(`{"bsvalias":"1.0","capabilities":{}}`))
+	})
+	httpClient := &http.Client{Transport: hostRoundTripper{handler: mux}}
+
+	records := map[string][]*net.SRV{
+		"example.com":  {{Target: "Paymail.Example.com.", Port: 443}},
+		"port.com":     {{Target: "paymail.port.com.", Port: 8443}},
+		"attacker.com": {{Target: "paymail.evil.com.", Port: 443}, {Target: "attacker.com.", Port: 443}},
+		"outside.com":  {{Target: "paymail.notoutside.com.", Port: 443}},
+	}
+	var lookups []string
+	lookupSRV := func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		lookups = append(lookups, "_"+service+"._"+proto+"."+name)
+		if name == "broken.com" {
+			return "", nil, errors.New("servfail")
+		}
+		if srv, ok := records[name]; ok {
+			return "", srv, nil
+		}
+		return noSRV(context.Background(), service, proto, name)
+	}
+	client := NewClient(httpClient, &Options{LookupSRV: lookupSRV})
+
+	tests := map[string]string{
+		"Example.com":  "paymail.example.com", // the SRV target
+		"port.com":     "paymail.port.com:8443",
+		"attacker.com": "attacker.com", // the targets outside of the domain are ignored
+		"outside.com":  "outside.com",
+		"broken.com":   "broken.com", // the domain when the lookup fails
+		"none.com":     "none.com",
+	}
+	for domain, host := range tests {
+		assert.Equal(t, host, client.Host(context.Background(), domain), domain)
+	}
+	assert.Contains(t, lookups, "_bsvalias._tcp.example.com")
+
+	_, err := client.GetCapabilities(context.Background(), "example.com")
+	require.NoError(t, err)
+	_, err = client.GetCapabilities(context.Background(), "none.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"paymail.example.com", "none.com"}, hosts)
+}
@@ -0,0 +1,68 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/paymail"
+	"github.com/pkg/errors"
+)
+
+// ErrPaymentNotDelivered is when a P2P payment was recorded, but the paymail host of the receiver did
+// not accept its transaction (the transaction can be sent again with the reference of the send context)
+var ErrPaymentNotDelivered = errors.New("the transaction was not delivered to the paymail host of the receiver")
+
+// GetP2PPaymentDestination will request the destination (outputs and reference) of a P2P payment of the
+// satoshis to the paymail address, from the paymail host of the receiver, the destination is rejected
+// with paymail.ErrAmountMismatch when its outputs do not pay the satoshis
+func (b *BuxClient) GetP2PPaymentDestination(ctx context.Context, address string,
+	satoshis uint64) (*paymail.PaymentDestination, error) {
+
+	if satoshis == 0 || satoshis > maxSatoshis {
+		return nil, &InvalidInputError{Field: "satoshis", Reason: "is out of range"}
+	}
+	if _, _, err := paymail.ParseAddress(address); err != nil {
+		return nil, &InvalidInputError{Field: "paymail", Reason: err.Error()}
+	}
+	return b.paymail.GetP2PPaymentDestination(ctx, address, satoshis)
+}
+
+// SendToPaymailP2P will send the satoshis to the paymail address with a P2P payment: the outputs of the
+// destination given by the paymail host of the receiver are drafted, signed and recorded, and the
+// transaction is sent to the paymail host with the reference of the destination. When the paymail host
+// does not accept it, the recorded transaction is returned with ErrPaymentNotDelivered.
+func (b *BuxClient) SendToPaymailP2P(ctx context.Context, address string, satoshis uint64,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	destination, err := b.GetP2PPaymentDestination(ctx, address, satoshis)
+	if err != nil {
+		return nil, err
+	}
+
+	send := NewSendContext(metadata)
+	var draft *bux.DraftTransaction
+	if draft, err = b.DraftTransaction(ctx, &bux.TransactionConfig{
+		Outputs: destination.TransactionOutputs(),
+	}, metadata); err != nil {
+		return nil, err
+	}
+	if err = send.SetDraft(draft); err != nil {
+		return nil, err
+	}
+	send.ReferenceIDs[address] = destination.Reference
+	if err = b.SignSend(send, draft); err != nil {
+		return nil, err
+	}
+
+	var transaction *bux.Transaction
+	if transaction, err = b.RecordSend(ctx, send); err != nil {
+		return nil, err
+	}
+	if _, err = b.paymail.SendP2PTransaction(ctx, address, &paymail.P2PTransaction{
+		Hex:       send.Hex,
+		Reference: destination.Reference,
+	}); err != nil {
+		return transaction, errors.Wrap(ErrPaymentNotDelivered, err.Error())
+	}
+	return transaction, nil
+}
@@ -0,0 +1,119 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/paymail"
+	btv2 "github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSendToPaymailP2P will test the method SendToPaymailP2P()
+func TestSendToPaymailP2P(t *testing.T) {
+	const lockingScript = "76a9140b2b03751813e3467a28ce916cbb102d84c6eec588ac"
+	var drafted *bux.TransactionConfig
+	var recorded, received string
+	var receiveStatus int
+
+	// the bux server and the paymail host of the receiver
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions/new", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Config *bux.TransactionConfig `json:"config"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		drafted = body.Config
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, draftTxJSON)
+	})
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Hex string `json:"hex"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		recorded = body.Hex
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, recordedTransactionJSON(t, body.Hex))
+	})
+	mux.HandleFunc("/.well-known/bsvalias", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "example.com", req.Host)
+		mustWrite(w, `{"bsvalias":"1.0","capabilities":{`+
+			`"2a40af698840":"https://example.com/p2p/{alias}@{domain.tld}/destinations",`+
+			`"5f1323cddf31":"https://example.com/p2p/{alias}@{domain.tld}/transactions"}}`)
+	})
+	mux.HandleFunc("/p2p/alice@example.com/destinations", func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]uint64
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, uint64(1000), body["satoshis"])
+		mustWrite(w, `{"outputs":[{"script":"`+lockingScript+`","satoshis":1000}],"reference":"ref-1"}`)
+	})
+	mux.HandleFunc("/p2p/alice@example.com/transactions", func(w http.ResponseWriter, req *http.Request) {
+		var transaction paymail.P2PTransaction
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&transaction))
+		assert.Equal(t, "ref-1", transaction.Reference)
+		received = transaction.Hex
+		if receiveStatus != 0 {
+			w.WriteHeader(receiveStatus)
+			return
+		}
+		mustWrite(w, `{"txid":"`+txID+`","note":"thanks"}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		WithPaymailClient(paymail.NewClient(httpClient, &paymail.Options{
+			LookupSRV: func(context.Context, string, string, string) (string, []*net.SRV, error) {
+				return "", nil, nil
+			},
+		})),
+	)
+	require.NoError(t, err)
+
+	t.Run("destination", func(t *testing.T) {
+		destination, err := client.GetP2PPaymentDestination(context.Background(), "Alice@example.com", 1000)
+		require.NoError(t, err)
+		assert.Equal(t, "ref-1", destination.Reference)
+		outputs := destination.TransactionOutputs()
+		require.Len(t, outputs, 1)
+		assert.Equal(t, lockingScript, outputs[0].Scripts[0].Script)
+
+		_, err = client.GetP2PPaymentDestination(context.Background(), "alice", 1000)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		_, err = client.GetP2PPaymentDestination(context.Background(), "alice@example.com", 0)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+
+	t.Run("send", func(t *testing.T) {
+		transaction, err := client.SendToPaymailP2P(context.Background(), "alice@example.com", 1000, nil)
+		require.NoError(t, err)
+		require.NotNil(t, drafted)
+		require.Len(t, drafted.Outputs, 1)
+		assert.Equal(t, lockingScript, drafted.Outputs[0].Scripts[0].Script)
+		assert.NotEmpty(t, recorded)
+		assert.Equal(t, recorded, received)
+		tx, err := btv2.NewTxFromString(recorded)
+		require.NoError(t, err)
+		assert.Equal(t, tx.TxID(), transaction.ID)
+	})
+
+	t.Run("not delivered", func(t *testing.T) {
+		receiveStatus = http.StatusBadRequest
+		defer func() {
+			receiveStatus = 0
+		}()
+
+		transaction, err := client.SendToPaymailP2P(context.Background(), "alice@example.com", 1000, nil)
+		assert.ErrorIs(t, err, ErrPaymentNotDelivered)
+		require.NotNil(t, transaction)
+		assert.Equal(t, recorded, received)
+	})
+}
@@ -0,0 +1,373 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// OperationSendPayment is the dead letter operation of the payment intents that could not be sent
+const OperationSendPayment = "payments.send"
+
+// defaultPipelineConcurrency is the number of intents processed at the same time by default
+const defaultPipelineConcurrency = 4
+
+// PaymentStatus is the status of a payment intent in the payment pipeline
+type PaymentStatus string
+
+// Statuses of the payment intents
+const (
+	// PaymentStatusAccepted is when the intent was read from the stream by a worker
+	PaymentStatusAccepted PaymentStatus = "accepted"
+
+	// PaymentStatusWaiting is when the draft could not select enough utxos while other intents hold
+	// theirs, the intent is drafted again once one of them is done (does not count as an attempt)
+	PaymentStatusWaiting PaymentStatus = "waiting"
+
+	// PaymentStatusRetrying is when a step failed and is retried after the backoff
+	PaymentStatusRetrying PaymentStatus = "retrying"
+
+	// PaymentStatusDrafted is when the draft transaction was created
+	PaymentStatusDrafted PaymentStatus = "drafted"
+
+	// PaymentStatusSigned is when the draft transaction was signed
+	PaymentStatusSigned PaymentStatus = "signed"
+
+	// PaymentStatusRecorded is when the transaction was recorded, the intent is done
+	PaymentStatusRecorded PaymentStatus = "recorded"
+
+	// PaymentStatusFailed is when the intent failed, it is dead lettered unless it failed before being
+	// signed because the pipeline was stopped
+	PaymentStatusFailed PaymentStatus = "failed"
+)
+
+// PaymentIntent is a payment to send with the payment pipeline
+type PaymentIntent struct {
+	ID         string                   `json:"id"` // id of the intent, chosen by the sender (ex: the id of a payout)
+	Metadata   *bux.Metadata            `json:"metadata"`
+	Recipients []*transports.Recipients `json:"recipients"`
+}
+
+// PaymentUpdate is a status change of a payment intent
+type PaymentUpdate struct {
+	Attempt     int              // failed attempt of the step (retrying)
+	DeadLetter  *DeadLetter      // dead letter of the failed intent
+	Error       error            // error of the failed attempt (retrying, waiting and failed)
+	Intent      *PaymentIntent   // the payment intent
+	Send        *SendContext     // identifiers of the draft and signed transaction, once drafted
+	Status      PaymentStatus    // new status of the intent
+	Transaction *bux.Transaction // recorded transaction
+}
+
+// PaymentPipelineOptions are the options of the payment pipeline
+type PaymentPipelineOptions struct {
+	Concurrency int                         // Intents processed at the same time, defaults to 4
+	MaxAttempts int                         // Attempts of the draft and record steps, 0 retries until the context is done
+	Backoff     time.Duration               // Wait between attempts, doubled after each failure
+	MaxBackoff  time.Duration               // Maximum wait between attempts
+	OnStatus    func(update *PaymentUpdate) // Called on every status change, concurrently by the workers
+}
+
+// DefaultPaymentPipelineOptions will return the default payment pipeline options
+func DefaultPaymentPipelineOptions() *PaymentPipelineOptions {
+	return &PaymentPipelineOptions{
+		Concurrency: defaultPipelineConcurrency,
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// PaymentPipelineResult is the result of a payment pipeline
+type PaymentPipelineResult struct {
+	Accepted     int `json:"accepted"`
+	Recorded     int `json:"recorded"`
+	Failed       int `json:"failed"`
+	DeadLettered int `json:"dead_lettered"`
+}
+
+// paymentLetter is the payload of the dead letters of the payment intents, the send context holds
+// the signed transaction when the intent failed to be recorded (it is recorded again on retry)
+type paymentLetter struct {
+	Intent *PaymentIntent `json:"intent"`
+	Send   *SendContext   `json:"send"`
+}
+
+// RunPaymentPipeline will draft, sign and record the payment intents of the stream, with a worker
+// per concurrent intent. The stream is only read when a worker is free, so a fast sender is slowed
+// down to the pace of the server (backpressure).
+//
+// Drafts that cannot select enough utxos while other intents hold theirs wait for one of them to be
+// recorded (or to fail), as the change of a recorded transaction can be spent by the next draft.
+// Other failures of the draft and record steps are retried with backoff, intents that exhausted
+// their attempts are dead lettered (see DeadLetters, OperationSendPayment), and retrying the dead
+// letter records the signed transaction, or sends the intent again if it was not signed.
+//
+// RunPaymentPipeline returns when the stream is closed and all intents are done, or when the
+// context is done (the signed intents that were not recorded are dead lettered).
+//
+// Note: a draft that failed after being created keeps its inputs reserved until it expires on the server
+func (b *BuxClient) RunPaymentPipeline(ctx context.Context, intents <-chan *PaymentIntent,
+	opts *PaymentPipelineOptions) (*PaymentPipelineResult, error) {
+
+	if opts == nil {
+		opts = DefaultPaymentPipelineOptions()
+	}
+	b.registerPaymentHandler()
+
+	pipeline := &paymentPipeline{
+		client:   b,
+		opts:     opts,
+		released: make(chan struct{}),
+		result:   &PaymentPipelineResult{},
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPipelineConcurrency
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case intent, ok := <-intents:
+					if !ok {
+						return
+					}
+					pipeline.send(ctx, intent)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	pipeline.mu.Lock()
+	defer pipeline.mu.Unlock()
+	result := *pipeline.result
+	return &result, ctx.Err()
+}
+
+// registerPaymentHandler will register the handler retrying the dead lettered payment intents
+func (b *BuxClient) registerPaymentHandler() {
+	b.deadLetters.RegisterHandler(OperationSendPayment, func(ctx context.Context, letter *DeadLetter) error {
+		var payment paymentLetter
+		if err := json.Unmarshal(letter.Payload, &payment); err != nil {
+			return err
+		}
+		if payment.Send != nil && payment.Send.Hex != "" {
+			_, err := b.RecordSend(ctx, payment.Send)
+			return err
+		}
+		_, err := b.SendToRecipients(ctx, payment.Intent.Recipients, payment.Intent.Metadata)
+		return err
+	})
+}
+
+// paymentPipeline is the state shared by the workers of a payment pipeline
+type paymentPipeline struct {
+	client   *BuxClient
+	holding  int        // intents holding a draft (reserved utxos), until they are recorded or failed
+	mu       sync.Mutex // protects holding, released and result
+	opts     *PaymentPipelineOptions
+	released chan struct{} // closed (and replaced) when an intent holding a draft is done
+	result   *PaymentPipelineResult
+}
+
+// send will draft, sign and record the intent
+func (p *paymentPipeline) send(ctx context.Context, intent *PaymentIntent) {
+	p.count(func(result *PaymentPipelineResult) { result.Accepted++ })
+	p.notify(&PaymentUpdate{Intent: intent, Status: PaymentStatusAccepted})
+
+	send := NewSendContext(intent.Metadata)
+	draft, err := p.draft(ctx, intent, send)
+	if err != nil {
+		p.fail(intent, nil, err)
+		return
+	}
+	defer p.release()
+	p.notify(&PaymentUpdate{Intent: intent, Send: send, Status: PaymentStatusDrafted})
+
+	if err = p.client.SignSend(send, draft); err != nil {
+		p.fail(intent, nil, err)
+		return
+	}
+	p.notify(&PaymentUpdate{Intent: intent, Send: send, Status: PaymentStatusSigned})
+
+	var transaction *bux.Transaction
+	err = p.retry(ctx, intent, send, func() error {
+		transaction, err = p.client.RecordSend(ctx, send)
+		return err
+	})
+	if err != nil {
+		p.fail(intent, send, err)
+		return
+	}
+	p.count(func(result *PaymentPipelineResult) { result.Recorded++ })
+	p.notify(&PaymentUpdate{Intent: intent, Send: send, Status: PaymentStatusRecorded, Transaction: transaction})
+}
+
+// draft will create the draft of the intent, waiting for the other intents to release their utxos
+// when there are not enough, the intent holds the draft until release is called
+func (p *paymentPipeline) draft(ctx context.Context, intent *PaymentIntent,
+	send *SendContext) (*bux.DraftTransaction, error) {
+
+	var draft *bux.DraftTransaction
+	err := p.retry(ctx, intent, nil, func() error {
+		for {
+			// the intent holds its draft as soon as it is sent, the server reserves the utxos before
+			// the response is received
+			p.mu.Lock()
+			released := p.released
+			p.holding++
+			p.mu.Unlock()
+
+			var err error
+			if draft, err = p.client.DraftSend(ctx, send, intent.Recipients); err == nil {
+				return nil
+			}
+			wait := p.releaseFailedDraft(released)
+			if !isNotEnoughUtxos(err) || wait == nil {
+				return err
+			}
+			p.notify(&PaymentUpdate{Error: err, Intent: intent, Status: PaymentStatusWaiting})
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-wait:
+			}
+		}
+	})
+	return draft, err
+}
+
+// releaseFailedDraft will release the draft that failed, and return the channel to wait on before
+// drafting again when there were not enough utxos: the released channel of the draft when another
+// intent was done since it was sent (already closed), nil when no other intent holds a draft
+func (p *paymentPipeline) releaseFailedDraft(released chan struct{}) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	closed := false
+	select {
+	case <-released:
+		closed = true
+	default:
+	}
+
+	// the waiting intents are woken up too, the failed draft may have been the one they wait for
+	p.holding--
+	close(p.released)
+	p.released = make(chan struct{})
+
+	switch {
+	case closed:
+		return released
+	case p.holding > 0:
+		return p.released
+	default:
+		return nil
+	}
+}
+
+// release will release the draft held (or being sent) by an intent, waking up the intents waiting for utxos
+func (p *paymentPipeline) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.holding--
+	close(p.released)
+	p.released = make(chan struct{})
+}
+
+// retry will call the step until it succeeds, the error is not retryable or the attempts are exhausted
+func (p *paymentPipeline) retry(ctx context.Context, intent *PaymentIntent, send *SendContext,
+	step func() error) error {
+
+	backoff := p.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		err := step()
+		if err == nil || !isRetryablePayment(err) || p.opts.MaxAttempts > 0 && attempt >= p.opts.MaxAttempts {
+			return err
+		}
+		p.notify(&PaymentUpdate{Attempt: attempt, Error: err, Intent: intent, Send: send, Status: PaymentStatusRetrying})
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.client.scheduler.After(backoff):
+		}
+
+		backoff *= 2
+		if p.opts.MaxBackoff > 0 && backoff > p.opts.MaxBackoff {
+			backoff = p.opts.MaxBackoff
+		}
+	}
+}
+
+// fail will dead letter the failed intent, unless it was stopped by the context before being signed
+func (p *paymentPipeline) fail(intent *PaymentIntent, send *SendContext, cause error) {
+	update := &PaymentUpdate{Error: cause, Intent: intent, Send: send, Status: PaymentStatusFailed}
+	if isContextError(cause) && (send == nil || send.Hex == "") {
+		p.count(func(result *PaymentPipelineResult) { result.Failed++ })
+		p.notify(update)
+		return
+	}
+
+	// the letter is only missing if the intent cannot be encoded, the update still reports the failure
+	letter, _ := p.client.deadLetters.Add(OperationSendPayment, &paymentLetter{Intent: intent, Send: send}, cause)
+	p.count(func(result *PaymentPipelineResult) {
+		result.Failed++
+		if letter != nil {
+			result.DeadLettered++
+		}
+	})
+	update.DeadLetter = letter
+	p.notify(update)
+}
+
+// count will update the result of the pipeline
+func (p *paymentPipeline) count(update func(result *PaymentPipelineResult)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(p.result)
+}
+
+// notify will call the status callback
+func (p *paymentPipeline) notify(update *PaymentUpdate) {
+	if p.opts.OnStatus != nil {
+		p.opts.OnStatus(update)
+	}
+}
+
+// isNotEnoughUtxos will return whether the draft failed because the server could not select enough utxos
+func isNotEnoughUtxos(err error) bool {
+	return errors.Is(err, bux.ErrNotEnoughUtxos) || strings.Contains(err.Error(), bux.ErrNotEnoughUtxos.Error())
+}
+
+// isContextError will return whether the error is the error of a done context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isRetryablePayment will return whether a failed step of a payment can succeed when retried
+func isRetryablePayment(err error) bool {
+	return !isContextError(err) &&
+		!errors.Is(err, transports.ErrAccountFrozen) &&
+		!errors.Is(err, ErrSendContextMismatch) &&
+		!errors.Is(err, ErrTooManyOutputs) &&
+		!errors.Is(err, ErrTransactionTooLarge) &&
+		!errors.Is(err, ErrOpReturnTooLarge) &&
+		!errors.Is(err, ErrUnconfirmedInputs)
+}
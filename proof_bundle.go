@@ -0,0 +1,288 @@
+package buxclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// ProofBundleVersion is the version of the proof bundle format
+const ProofBundleVersion = 1
+
+// Files of the proof bundle archive
+const (
+	proofBundleManifest     = "bundle.json"
+	proofBundleTransactions = "transactions/" // <tx_id>.hex, raw transactions
+	proofBundleProofs       = "proofs/"       // <tx_id>.json, merkle proofs
+	proofBundleHeaders      = "headers/"      // <block_hash>.hex, raw block headers
+)
+
+// blockHeaderSize is the size of a raw block header
+const blockHeaderSize = 80
+
+// ErrTransactionNotMined is when a proof is requested for a transaction that is not in a block yet
+var ErrTransactionNotMined = errors.New("transaction is not mined")
+
+// ErrInvalidProofBundle is when a proof bundle is malformed or does not prove its transactions
+var ErrInvalidProofBundle = errors.New("invalid proof bundle")
+
+// ProofBundle is the manifest of a proof bundle
+type ProofBundle struct {
+	Version      int                       `json:"version"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	Transactions []*ProofBundleTransaction `json:"transactions"`
+}
+
+// ProofBundleTransaction is a transaction of a proof bundle
+type ProofBundleTransaction struct {
+	TxID        string `json:"tx_id"`
+	BlockHash   string `json:"block_hash"`
+	BlockHeight uint64 `json:"block_height"`
+}
+
+// ExportProofBundle will export a zip archive with the raw transactions, their merkle proofs and the
+// headers of their blocks, which can be verified offline with VerifyProofBundle
+func (b *BuxClient) ExportProofBundle(ctx context.Context, txIDs []string) ([]byte, error) {
+	bundle := &ProofBundle{
+		Version:   ProofBundleVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	buffer := new(bytes.Buffer)
+	archive := zip.NewWriter(buffer)
+	headers := make(map[string]bool)
+
+	for _, txID := range txIDs {
+		transaction, err := b.GetTransaction(ctx, txID)
+		if err != nil {
+			return nil, err
+		}
+		if transaction.BlockHash == "" {
+			return nil, ErrTransactionNotMined
+		}
+
+		var merkleProof *transports.MerkleProof
+		if merkleProof, err = b.GetMerkleProof(ctx, txID); err != nil {
+			return nil, err
+		}
+
+		if err = writeZipFile(archive, proofBundleTransactions+txID+".hex", []byte(transaction.Hex)); err != nil {
+			return nil, err
+		}
+		if err = writeZipJSON(archive, proofBundleProofs+txID+".json", merkleProof); err != nil {
+			return nil, err
+		}
+
+		if !headers[merkleProof.Target] {
+			var blockHeader *transports.BlockHeader
+			if blockHeader, err = b.GetBlockHeader(ctx, merkleProof.Target); err != nil {
+				return nil, err
+			}
+			if err = writeZipFile(archive, proofBundleHeaders+merkleProof.Target+".hex", []byte(blockHeader.Header)); err != nil {
+				return nil, err
+			}
+			headers[merkleProof.Target] = true
+		}
+
+		bundle.Transactions = append(bundle.Transactions, &ProofBundleTransaction{
+			TxID:        txID,
+			BlockHash:   merkleProof.Target,
+			BlockHeight: transaction.BlockHeight,
+		})
+	}
+
+	if err := writeZipJSON(archive, proofBundleManifest, bundle); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// VerifyProofBundle will verify a proof bundle offline: every raw transaction must hash to its ID, its
+// merkle proof must lead to the merkle root of the block header, and the header must hash to the block
+// hash and satisfy its proof of work. Checking that the blocks are on the main chain is left to the
+// auditor (ex: against a trusted list of block hashes).
+func VerifyProofBundle(archive []byte) (*ProofBundle, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(reader.File))
+	for _, file := range reader.File {
+		if files[file.Name], err = readZipFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	var bundle ProofBundle
+	if err = json.Unmarshal(files[proofBundleManifest], &bundle); err != nil {
+		return nil, ErrInvalidProofBundle
+	}
+	if bundle.Version != ProofBundleVersion {
+		return nil, errors.New("unsupported proof bundle version")
+	}
+
+	for _, transaction := range bundle.Transactions {
+		var merkleProof transports.MerkleProof
+		if err = json.Unmarshal(files[proofBundleProofs+transaction.TxID+".json"], &merkleProof); err != nil {
+			return nil, ErrInvalidProofBundle
+		}
+		if err = verifyTransactionProof(
+			transaction, files[proofBundleTransactions+transaction.TxID+".hex"], &merkleProof,
+			files[proofBundleHeaders+transaction.BlockHash+".hex"],
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return &bundle, nil
+}
+
+// verifyTransactionProof will verify the raw transaction, merkle proof and block header of a transaction
+func verifyTransactionProof(transaction *ProofBundleTransaction, txHex []byte,
+	merkleProof *transports.MerkleProof, headerHex []byte) error {
+
+	rawTx, err := hex.DecodeString(string(txHex))
+	if err != nil || len(rawTx) == 0 || reverseHex(doubleSha256(rawTx)) != transaction.TxID {
+		return ErrInvalidProofBundle
+	}
+
+	if merkleProof.TxID != transaction.TxID || merkleProof.Target != transaction.BlockHash {
+		return ErrInvalidProofBundle
+	}
+	var merkleRoot []byte
+	if merkleRoot, err = computeMerkleRoot(merkleProof); err != nil {
+		return err
+	}
+
+	var header []byte
+	if header, err = hex.DecodeString(string(headerHex)); err != nil || len(header) != blockHeaderSize {
+		return ErrInvalidProofBundle
+	}
+	blockHash := doubleSha256(header)
+	if reverseHex(blockHash) != transaction.BlockHash || !bytes.Equal(header[36:68], merkleRoot) {
+		return ErrInvalidProofBundle
+	}
+	if !checkProofOfWork(blockHash, header[72:76]) {
+		return ErrInvalidProofBundle
+	}
+	return nil
+}
+
+// computeMerkleRoot will compute the merkle root (internal byte order) of the merkle proof
+func computeMerkleRoot(merkleProof *transports.MerkleProof) ([]byte, error) {
+	hash, err := hexToInternal(merkleProof.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := merkleProof.Index
+	for _, node := range merkleProof.Nodes {
+		sibling := hash
+		if node != "*" {
+			if sibling, err = hexToInternal(node); err != nil {
+				return nil, err
+			}
+		}
+		if index&1 == 1 {
+			hash = doubleSha256(append(append([]byte{}, sibling...), hash...))
+		} else {
+			hash = doubleSha256(append(append([]byte{}, hash...), sibling...))
+		}
+		index >>= 1
+	}
+	return hash, nil
+}
+
+// checkProofOfWork will check that the block hash (internal byte order) is below the target of the bits
+func checkProofOfWork(blockHash, bits []byte) bool {
+	exponent := uint(bits[3])
+	mantissa := new(big.Int).SetBytes([]byte{bits[2], bits[1], bits[0]})
+	if mantissa.Sign() == 0 || bits[2]&0x80 != 0 {
+		return false
+	}
+
+	target := new(big.Int)
+	if exponent <= 3 {
+		target.Rsh(mantissa, 8*(3-exponent))
+	} else {
+		target.Lsh(mantissa, 8*(exponent-3))
+	}
+
+	hash := new(big.Int).SetBytes(reverseBytes(blockHash))
+	return hash.Cmp(target) <= 0
+}
+
+// doubleSha256 will return sha256(sha256(data))
+func doubleSha256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// hexToInternal will decode a hash in display (reversed) hex to its internal byte order
+func hexToInternal(hash string) ([]byte, error) {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, ErrInvalidProofBundle
+	}
+	return reverseBytes(decoded), nil
+}
+
+// reverseHex will encode a hash in internal byte order to display (reversed) hex
+func reverseHex(hash []byte) string {
+	return hex.EncodeToString(reverseBytes(hash))
+}
+
+// reverseBytes will return a reversed copy of the bytes
+func reverseBytes(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for index, value := range data {
+		reversed[len(data)-1-index] = value
+	}
+	return reversed
+}
+
+// writeZipFile will write a file to the archive
+func writeZipFile(archive *zip.Writer, name string, data []byte) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// writeZipJSON will write the value as an indented JSON file to the archive
+func writeZipJSON(archive *zip.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(archive, name, data)
+}
+
+// readZipFile will read a file of the archive
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return ioutil.ReadAll(io.LimitReader(reader, 32<<20))
+}
@@ -0,0 +1,255 @@
+package buxclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlock is a block of three transactions with a valid (regtest difficulty) header
+type testBlock struct {
+	hash   string
+	header string
+	proofs map[string]*transports.MerkleProof
+	rawTxs map[string]string
+	txIDs  []string
+}
+
+func newTestBlock(t *testing.T) *testBlock {
+	block := &testBlock{
+		proofs: make(map[string]*transports.MerkleProof),
+		rawTxs: make(map[string]string),
+	}
+
+	var leaves [][]byte
+	for _, rawTx := range []string{"01000000aa", "01000000bb", "01000000cc"} {
+		raw, err := hex.DecodeString(rawTx)
+		require.NoError(t, err)
+		leaf := doubleSha256(raw)
+		txID := reverseHex(leaf)
+		leaves = append(leaves, leaf)
+		block.rawTxs[txID] = rawTx
+		block.txIDs = append(block.txIDs, txID)
+	}
+
+	left := doubleSha256(append(append([]byte{}, leaves[0]...), leaves[1]...))
+	right := doubleSha256(append(append([]byte{}, leaves[2]...), leaves[2]...))
+	root := doubleSha256(append(append([]byte{}, left...), right...))
+
+	header := make([]byte, blockHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], 1)
+	copy(header[36:68], root)
+	binary.LittleEndian.PutUint32(header[68:72], 1650000000)
+	binary.LittleEndian.PutUint32(header[72:76], 0x207fffff)
+	for nonce := uint32(0); ; nonce++ {
+		binary.LittleEndian.PutUint32(header[76:80], nonce)
+		if checkProofOfWork(doubleSha256(header), header[72:76]) {
+			break
+		}
+	}
+	block.header = hex.EncodeToString(header)
+	block.hash = reverseHex(doubleSha256(header))
+
+	block.proofs[block.txIDs[0]] = &transports.MerkleProof{
+		Index: 0, TxID: block.txIDs[0], Target: block.hash, Nodes: []string{block.txIDs[1], reverseHex(right)},
+	}
+	block.proofs[block.txIDs[1]] = &transports.MerkleProof{
+		Index: 1, TxID: block.txIDs[1], Target: block.hash, Nodes: []string{block.txIDs[0], reverseHex(right)},
+	}
+	block.proofs[block.txIDs[2]] = &transports.MerkleProof{
+		Index: 2, TxID: block.txIDs[2], Target: block.hash, Nodes: []string{"*", reverseHex(left)},
+	}
+	return block
+}
+
+func writeTestJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(value))
+}
+
+// rewriteBundle will return a copy of the bundle archive with the file replaced
+func rewriteBundle(t *testing.T, archive []byte, name string, data []byte) []byte {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	for _, file := range reader.File {
+		content := data
+		if file.Name != name {
+			content, err = readZipFile(file)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writeZipFile(writer, file.Name, content))
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+// TestProofBundle will test the methods ExportProofBundle() and VerifyProofBundle()
+func TestProofBundle(t *testing.T) {
+	block := newTestBlock(t)
+
+	client := getTestBuxClient(testTransportHandler{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transaction",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				txID := req.URL.Query().Get("id")
+				blockHash := block.hash
+				if txID == "unmined" {
+					blockHash = ""
+				}
+				writeTestJSON(t, w, map[string]interface{}{
+					"id": txID, "hex": block.rawTxs[txID], "block_hash": blockHash, "block_height": 100,
+				})
+			},
+		}, {
+			Path: "/transaction/merkle_proof",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				// null for the transactions without proof
+				writeTestJSON(t, w, block.proofs[req.URL.Query().Get("id")])
+			},
+		}, {
+			Path: "/block_header",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, block.hash, req.URL.Query().Get("hash"))
+				writeTestJSON(t, w, &transports.BlockHeader{Hash: block.hash, Height: 100, Header: block.header})
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, false)
+
+	archive, err := client.ExportProofBundle(context.Background(), block.txIDs)
+	require.NoError(t, err)
+
+	t.Run("verify", func(t *testing.T) {
+		var bundle *ProofBundle
+		bundle, err = VerifyProofBundle(archive)
+		require.NoError(t, err)
+		assert.Equal(t, ProofBundleVersion, bundle.Version)
+		require.Len(t, bundle.Transactions, 3)
+		assert.Equal(t, block.txIDs[2], bundle.Transactions[2].TxID)
+		assert.Equal(t, block.hash, bundle.Transactions[2].BlockHash)
+		assert.Equal(t, uint64(100), bundle.Transactions[2].BlockHeight)
+	})
+
+	t.Run("tampered transaction", func(t *testing.T) {
+		tampered := rewriteBundle(t, archive, "transactions/"+block.txIDs[1]+".hex", []byte("01000000dd"))
+		_, err = VerifyProofBundle(tampered)
+		assert.ErrorIs(t, err, ErrInvalidProofBundle)
+	})
+
+	t.Run("tampered proof", func(t *testing.T) {
+		proof := *block.proofs[block.txIDs[0]]
+		proof.Index = 1
+		data, _ := json.Marshal(proof)
+		tampered := rewriteBundle(t, archive, "proofs/"+block.txIDs[0]+".json", data)
+		_, err = VerifyProofBundle(tampered)
+		assert.ErrorIs(t, err, ErrInvalidProofBundle)
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		header, _ := hex.DecodeString(block.header)
+		header[68]++
+		tampered := rewriteBundle(t, archive, "headers/"+block.hash+".hex", []byte(hex.EncodeToString(header)))
+		_, err = VerifyProofBundle(tampered)
+		assert.ErrorIs(t, err, ErrInvalidProofBundle)
+	})
+
+	t.Run("not an archive", func(t *testing.T) {
+		_, err = VerifyProofBundle([]byte("bundle"))
+		assert.Error(t, err)
+	})
+
+	t.Run("unmined", func(t *testing.T) {
+		_, err = client.ExportProofBundle(context.Background(), []string{"unmined"})
+		assert.ErrorIs(t, err, ErrTransactionNotMined)
+	})
+
+	t.Run("missing proof", func(t *testing.T) {
+		_, err = client.ExportProofBundle(context.Background(), []string{"no-proof"})
+		assert.ErrorIs(t, err, transports.ErrMissingMerkleProof)
+	})
+
+	t.Run("readable archive", func(t *testing.T) {
+		reader, readErr := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, readErr)
+		names := make([]string, 0, len(reader.File))
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+		assert.Contains(t, names, "bundle.json")
+		assert.Contains(t, names, "headers/"+block.hash+".hex")
+
+		file, openErr := reader.Open("transactions/" + block.txIDs[0] + ".hex")
+		require.NoError(t, openErr)
+		data, _ := ioutil.ReadAll(file)
+		assert.Equal(t, block.rawTxs[block.txIDs[0]], string(data))
+	})
+}
+
+// TestGetTransactionProof will test the method GetTransactionProof() and TransactionProof.Verify()
+func TestGetTransactionProof(t *testing.T) {
+	block := newTestBlock(t)
+
+	client := getTestBuxClient(testTransportHandler{
+		Type: "http",
+		Queries: []*testTransportHandlerRequest{{
+			Path: "/transaction/merkle_proof",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				if proof, ok := block.proofs[req.URL.Query().Get("id")]; ok {
+					writeTestJSON(t, w, proof)
+					return
+				}
+				writeTestJSON(t, w, &transports.MerkleProof{TxID: req.URL.Query().Get("id")})
+			},
+		}, {
+			Path: "/block_header",
+			Result: func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, block.hash, req.URL.Query().Get("hash"))
+				writeTestJSON(t, w, &transports.BlockHeader{Hash: block.hash, Height: 100, Header: block.header})
+			},
+		}},
+		ClientURL: strings.TrimSuffix(serverURL, "/"),
+		Client:    WithHTTPClient,
+	}, false)
+
+	proof, err := client.GetTransactionProof(context.Background(), block.txIDs[2])
+	require.NoError(t, err)
+	assert.Equal(t, block.proofs[block.txIDs[2]], proof.MerkleProof)
+	assert.Equal(t, uint64(100), proof.BlockHeader.Height)
+	assert.NoError(t, proof.Verify(block.rawTxs[block.txIDs[2]]))
+
+	t.Run("another transaction", func(t *testing.T) {
+		assert.ErrorIs(t, proof.Verify(block.rawTxs[block.txIDs[0]]), ErrInvalidTransactionProof)
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		header, _ := hex.DecodeString(block.header)
+		header[68]++
+		tampered := &TransactionProof{
+			BlockHeader: &transports.BlockHeader{Hash: block.hash, Header: hex.EncodeToString(header)},
+			MerkleProof: proof.MerkleProof,
+		}
+		assert.ErrorIs(t, tampered.Verify(block.rawTxs[block.txIDs[2]]), ErrInvalidTransactionProof)
+		assert.ErrorIs(t, (&TransactionProof{}).Verify(block.rawTxs[block.txIDs[2]]), ErrInvalidTransactionProof)
+	})
+
+	t.Run("unmined", func(t *testing.T) {
+		_, err = client.GetTransactionProof(context.Background(), "unmined")
+		assert.ErrorIs(t, err, ErrTransactionNotMined)
+	})
+}
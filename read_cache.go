@@ -0,0 +1,207 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/store"
+	buxutils "github.com/BuxOrg/go-buxclient/utils"
+)
+
+// CachedRead is a read of the client that can be cached (see WithReadCache)
+type CachedRead string
+
+// Reads that can be cached
+const (
+	CacheBlockHeader CachedRead = "block_header" // by block hash
+	CacheChainTip    CachedRead = "chain_tip"    // single key
+	CacheDestination CachedRead = "destination"  // by address
+	CacheFeeQuote    CachedRead = "fee_quote"    // single key
+	CacheMerkleProof CachedRead = "merkle_proof" // by transaction id
+	CacheTransaction CachedRead = "transaction"  // by transaction id
+	CacheXPub        CachedRead = "xpub"         // single key
+	CacheXPubStatus  CachedRead = "xpub_status"  // single key
+)
+
+// ReadCacheOptions are the options of the read cache
+type ReadCacheOptions struct {
+	Store store.Store                  // Store of the cached reads, in memory by default
+	TTLs  map[CachedRead]time.Duration // Time to live of the reads, the reads without a TTL are not cached
+}
+
+// DefaultReadCacheTTLs will return the default time to live of the cached reads: the block headers do not
+// change, the proofs and the fee quote rarely, the destinations (metadata), transactions (status,
+// metadata) and xPub status often, and the xPub (balance) and the chain tip with every transaction
+// or block
+func DefaultReadCacheTTLs() map[CachedRead]time.Duration {
+	return map[CachedRead]time.Duration{
+		CacheBlockHeader: 24 * time.Hour,
+		CacheChainTip:    10 * time.Second,
+		CacheDestination: 30 * time.Second,
+		CacheFeeQuote:    10 * time.Minute,
+		CacheMerkleProof: time.Hour,
+		CacheTransaction: 30 * time.Second,
+		CacheXPub:        10 * time.Second,
+		CacheXPubStatus:  30 * time.Second,
+	}
+}
+
+// noCacheKey is the key of the reads without a key (ex: the fee quote)
+const noCacheKey = "-"
+
+// readCache is the cache of the idempotent reads of the client
+type readCache struct {
+	store store.Store
+	ttls  map[CachedRead]time.Duration
+}
+
+// readCacheEntry is a cached read
+type readCacheEntry struct {
+	ExpiresAt time.Time       `json:"expires_at"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// WithReadCache will cache the idempotent reads of the client (ex: the fee quote, the block headers) for
+// their time to live (see DefaultReadCacheTTLs when opts or its TTLs are nil), use InvalidateCache to
+// remove the reads that changed. The reads are cached per xPub, the clients of several xPubs can share
+// a store. The cache is best effort: its errors never fail a read.
+func WithReadCache(opts *ReadCacheOptions) ClientOps {
+	return func(c *BuxClient) {
+		if c != nil {
+			if opts == nil {
+				opts = &ReadCacheOptions{}
+			}
+			cache := &readCache{store: opts.Store, ttls: opts.TTLs}
+			if cache.store == nil {
+				cache.store = store.NewMemory()
+			}
+			if cache.ttls == nil {
+				cache.ttls = DefaultReadCacheTTLs()
+			}
+			c.readCache = cache
+		}
+	}
+}
+
+// InvalidateCache will remove the cached read of the key (ex: the transaction id), or all the cached
+// reads of this kind when the key is empty, of the xPub of the client
+func (b *BuxClient) InvalidateCache(ctx context.Context, read CachedRead, key string) error {
+	return b.invalidateCache(ctx, b.xPubCacheKey(), read, key)
+}
+
+// invalidateCache will remove the cached read of the key of the xPub (see xPubCacheKey), or all the
+// cached reads of this kind of the xPub when the key is empty
+func (b *BuxClient) invalidateCache(ctx context.Context, xPubKey string, read CachedRead, key string) error {
+	if b.readCache == nil {
+		return nil
+	}
+	if key != "" {
+		return b.readCache.store.Delete(ctx, store.NamespaceReadCache, readCacheKey(xPubKey, read, key))
+	}
+
+	items, err := b.readCache.store.List(ctx, store.NamespaceReadCache)
+	if err != nil {
+		return err
+	}
+	prefix := readCacheKey(xPubKey, read, "")
+	for _, item := range items {
+		if strings.HasPrefix(item.Key, prefix) {
+			if err = b.readCache.store.Delete(ctx, store.NamespaceReadCache, item.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cachedRead will decode the cached read of the key into value, false if it is not cached or expired
+func (b *BuxClient) cachedRead(ctx context.Context, read CachedRead, key string, value interface{}) bool {
+	if b.readCache == nil || b.readCache.ttls[read] <= 0 {
+		return false
+	}
+	data, err := b.readCache.store.Get(ctx, store.NamespaceReadCache, readCacheKey(b.xPubCacheKey(), read, key))
+	if err != nil {
+		return false
+	}
+	var entry readCacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil || !b.scheduler.Now().Before(entry.ExpiresAt) {
+		return false
+	}
+	return json.Unmarshal(entry.Value, value) == nil
+}
+
+// cacheRead will cache the read of the key for its time to live
+func (b *BuxClient) cacheRead(ctx context.Context, read CachedRead, key string, value interface{}) {
+	if b.readCache == nil || b.readCache.ttls[read] <= 0 {
+		return
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil || string(encoded) == "null" {
+		return
+	}
+	data, err := json.Marshal(&readCacheEntry{
+		ExpiresAt: b.scheduler.Now().Add(b.readCache.ttls[read]),
+		Value:     encoded,
+	})
+	if err != nil {
+		return
+	}
+	_ = b.readCache.store.Put(ctx, store.NamespaceReadCache, readCacheKey(b.xPubCacheKey(), read, key), data)
+}
+
+// cachedEntry will return the cached read without a key (ex: the fee quote) with its expiry, nil if it
+// is not cached or expired (see Snapshot)
+func (b *BuxClient) cachedEntry(ctx context.Context, read CachedRead) *readCacheEntry {
+	if b.readCache == nil || b.readCache.ttls[read] <= 0 {
+		return nil
+	}
+	data, err := b.readCache.store.Get(ctx, store.NamespaceReadCache, readCacheKey(b.xPubCacheKey(), read, noCacheKey))
+	if err != nil {
+		return nil
+	}
+	entry := new(readCacheEntry)
+	if err = json.Unmarshal(data, entry); err != nil || !b.scheduler.Now().Before(entry.ExpiresAt) {
+		return nil
+	}
+	return entry
+}
+
+// restoreEntry will cache the read without a key until its original expiry (see RestoreSnapshot), an
+// expired read is not restored
+func (b *BuxClient) restoreEntry(ctx context.Context, read CachedRead, entry *readCacheEntry) {
+	if b.readCache == nil || b.readCache.ttls[read] <= 0 || entry == nil ||
+		!b.scheduler.Now().Before(entry.ExpiresAt) {
+		return
+	}
+	if data, err := json.Marshal(entry); err == nil {
+		_ = b.readCache.store.Put(ctx, store.NamespaceReadCache, readCacheKey(b.xPubCacheKey(), read, noCacheKey), data)
+	}
+}
+
+// invalidateRead will remove the cached read of the key, ignoring the errors of the store
+func (b *BuxClient) invalidateRead(ctx context.Context, read CachedRead, key string) {
+	_ = b.InvalidateCache(ctx, read, key)
+}
+
+// invalidateXPub will remove the cached xPub of the client, its balance and derivation numbers changed
+// with the transaction or destination
+func (b *BuxClient) invalidateXPub(ctx context.Context) {
+	b.invalidateRead(ctx, CacheXPub, noCacheKey)
+}
+
+// xPubCacheKey will return the key of the reads of the xPub of the client, its id (unknown with an
+// access key)
+func (b *BuxClient) xPubCacheKey() string {
+	_, xPub, _ := b.keys()
+	if xPub == nil {
+		return noCacheKey
+	}
+	return buxutils.Hash(xPub.String())
+}
+
+// readCacheKey will return the key of the cached read of the xPub (see xPubCacheKey) in the store
+func readCacheKey(xPubKey string, read CachedRead, key string) string {
+	return xPubKey + ":" + string(read) + ":" + key
+}
@@ -0,0 +1,178 @@
+package buxclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadCache will test caching the idempotent reads
+func TestReadCache(t *testing.T) {
+	ctx := context.Background()
+	calls := make(map[string]int)
+	var duringUpdate func()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fee_quote", func(w http.ResponseWriter, req *http.Request) {
+		calls["fee_quote"]++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"miner":"miner","fee_unit":{"satoshis":1,"bytes":1000}}`)
+	})
+	mux.HandleFunc("/transaction", func(w http.ResponseWriter, req *http.Request) {
+		calls[req.Method+" transaction"]++
+		if req.Method == http.MethodPatch && duringUpdate != nil {
+			duringUpdate()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionJSON)
+	})
+	newClient := func(opts *ReadCacheOptions) (*BuxClient, *scheduler.Virtual) {
+		for key := range calls {
+			delete(calls, key)
+		}
+		virtual := scheduler.NewVirtual(time.Now())
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithScheduler(virtual),
+			WithReadCache(opts),
+		)
+		require.NoError(t, err)
+		return client, virtual
+	}
+
+	t.Run("ttl", func(t *testing.T) {
+		client, virtual := newClient(nil)
+		for i := 0; i < 3; i++ {
+			feeQuote, err := client.GetFeeQuote(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "miner", feeQuote.Miner)
+		}
+		assert.Equal(t, 1, calls["fee_quote"])
+
+		virtual.Advance(DefaultReadCacheTTLs()[CacheFeeQuote])
+		_, err := client.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls["fee_quote"])
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		client, _ := newClient(nil)
+		transaction, err := client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		cached, err := client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.ID, cached.ID)
+		assert.Equal(t, transaction.Hex, cached.Hex)
+		assert.Equal(t, 1, calls["GET transaction"])
+
+		require.NoError(t, client.InvalidateCache(ctx, CacheTransaction, txID))
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls["GET transaction"])
+
+		// updating the metadata invalidates the transaction once updated, the read during the update
+		// (not cached yet) is not kept
+		require.NoError(t, client.InvalidateCache(ctx, CacheTransaction, txID))
+		duringUpdate = func() {
+			_, _ = client.GetTransaction(ctx, txID)
+		}
+		defer func() {
+			duringUpdate = nil
+		}()
+		_, err = client.UpdateTransactionMetadata(ctx, txID, &bux.Metadata{"note": "rent"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls["GET transaction"])
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, calls["GET transaction"])
+
+		// all the reads of the kind
+		require.NoError(t, client.InvalidateCache(ctx, CacheTransaction, ""))
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, 5, calls["GET transaction"])
+	})
+
+	t.Run("custom ttls and store", func(t *testing.T) {
+		memory := store.NewMemory()
+		client, _ := newClient(&ReadCacheOptions{
+			Store: memory,
+			TTLs:  map[CachedRead]time.Duration{CacheTransaction: time.Minute},
+		})
+		_, err := client.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		_, err = client.GetFeeQuote(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls["fee_quote"])
+
+		_, err = client.GetTransaction(ctx, txID)
+		require.NoError(t, err)
+		items, err := memory.List(ctx, store.NamespaceReadCache)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, client.xPubCacheKey()+":transaction:"+txID, items[0].Key)
+	})
+
+	t.Run("per xpub", func(t *testing.T) {
+		server := buxtest.NewServer()
+		defer server.Close()
+		memory := store.NewMemory()
+
+		// newFundedClient will return a client of a new xPub funded with the satoshis, caching in memory
+		newFundedClient := func(satoshis uint64) *BuxClient {
+			xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+			require.NoError(t, err)
+			_, err = server.Fund(xPub, satoshis)
+			require.NoError(t, err)
+			client, err := New(WithXPriv(xPriv), WithHTTP(server.URL), WithReadCache(&ReadCacheOptions{Store: memory}))
+			require.NoError(t, err)
+			return client
+		}
+		alice, bob := newFundedClient(1000), newFundedClient(2000)
+
+		xPub, err := alice.GetXPub(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), xPub.CurrentBalance)
+		xPub, err = bob.GetXPub(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2000), xPub.CurrentBalance)
+
+		// a new destination changes the derivation numbers of the xPub
+		next := xPub.NextExternalNum
+		destination, err := bob.GetDestination(ctx, nil)
+		require.NoError(t, err)
+		xPub, err = bob.GetXPub(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, next+1, xPub.NextExternalNum)
+
+		var cached *bux.Destination
+		cached, err = bob.GetDestinationByAddress(ctx, destination.Address)
+		require.NoError(t, err)
+		assert.Equal(t, destination.ID, cached.ID)
+		_, err = alice.GetDestinationByAddress(ctx, destination.Address)
+		assert.Error(t, err)
+
+		// invalidating the reads of an xPub keeps the reads of the other xPubs
+		require.NoError(t, bob.InvalidateCache(ctx, CacheXPub, ""))
+		items, err := memory.List(ctx, store.NamespaceReadCache)
+		require.NoError(t, err)
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		assert.ElementsMatch(t, []string{
+			alice.xPubCacheKey() + ":xpub:" + noCacheKey,
+			bob.xPubCacheKey() + ":destination:" + destination.Address,
+		}, keys)
+	})
+}
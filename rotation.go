@@ -0,0 +1,92 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/pkg/errors"
+)
+
+// RotateXPriv will replace the xPriv of the client without downtime: the xPub of the new xPriv
+// replaces the current xPub on the server (the current xPub is revoked in the same operation), and
+// the next requests are signed with the new xPriv. Requests running during the rotation can fail.
+func (b *BuxClient) RotateXPriv(ctx context.Context, newXPrivString string) error {
+	b.rotation.Lock()
+	defer b.rotation.Unlock()
+
+	if err := b.checkSigning(OperationRotateXPriv); err != nil {
+		return err
+	}
+	newXPriv, err := bip32.NewKeyFromString(newXPrivString)
+	if err != nil {
+		return err
+	}
+	newXPub, err := newXPriv.Neuter()
+	if err != nil {
+		return err
+	}
+
+	if _, err = b.transport.RotateXpub(ctx, newXPriv); err != nil {
+		return err
+	}
+	if err = b.transport.SetXPriv(newXPriv); err != nil {
+		return err
+	}
+	b.keysLock.Lock()
+	defer b.keysLock.Unlock()
+	b.xPriv, b.xPub, b.xPrivString = newXPriv, newXPub, newXPrivString
+	return nil
+}
+
+// ReplaceAccessKey will replace the access key of the client without downtime: a new access key is
+// created, the next requests are signed with it, and the current access key is revoked. If the
+// revocation fails, the new access key is revoked and the current one is kept (the server must support
+// FeatureAccessKeys).
+func (b *BuxClient) ReplaceAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error) {
+	if err := b.requireFeature(FeatureAccessKeys); err != nil {
+		return nil, err
+	}
+
+	b.rotation.Lock()
+	defer b.rotation.Unlock()
+
+	_, _, oldAccessKey := b.keys()
+	if oldAccessKey == nil {
+		return nil, bux.ErrMissingAccessKey
+	}
+
+	newAccessKey, err := b.transport.CreateAccessKey(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := utils.ParseAccessKey(newAccessKey.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	b.transport.SetAccessKey(privateKey)
+	oldID := utils.PrivateKeyAccessKeyID(oldAccessKey)
+	if _, err = b.transport.RevokeAccessKey(ctx, oldID); err != nil {
+		// keep the current access key, the new one is revoked with it
+		b.transport.SetAccessKey(oldAccessKey)
+		if _, revokeErr := b.transport.RevokeAccessKey(ctx, newAccessKey.ID); revokeErr != nil {
+			return nil, errors.Wrapf(err, "failed to revoke the new access key %s: %s", newAccessKey.ID, revokeErr.Error())
+		}
+		return nil, err
+	}
+
+	b.keysLock.Lock()
+	defer b.keysLock.Unlock()
+	b.accessKey, b.accessKeyString = privateKey, newAccessKey.Key
+	return newAccessKey, nil
+}
+
+// keys will return the current keys of the client, replaced by RotateXPriv and ReplaceAccessKey
+func (b *BuxClient) keys() (*bip32.ExtendedKey, *bip32.ExtendedKey, *bec.PrivateKey) {
+	b.keysLock.RLock()
+	defer b.keysLock.RUnlock()
+	return b.xPriv, b.xPub, b.accessKey
+}
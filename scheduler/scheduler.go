@@ -0,0 +1,56 @@
+// Package scheduler contains the scheduler used by the bux client for everything that depends on
+// time (retry and reconnect waits, rate limits, pollers), and a virtual time scheduler for tests
+package scheduler
+
+import "time"
+
+// Scheduler schedules the waits of the client
+type Scheduler interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Default will return the default scheduler, using the real time
+func Default() Scheduler {
+	return realScheduler{}
+}
+
+// realScheduler uses the time package
+type realScheduler struct{}
+
+// Now will return the current time
+func (realScheduler) Now() time.Time {
+	return time.Now()
+}
+
+// After will return a channel receiving the time after the duration
+func (realScheduler) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTicker will return a new ticker
+func (realScheduler) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+// realTicker wraps a time.Ticker
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// C will return the channel of the ticks
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// Stop will stop the ticker
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Virtual is a scheduler in virtual time for tests: the time only moves with Advance, which fires
+// the timers and tickers that are due, so retries and pollers run without sleeping
+type Virtual struct {
+	cond   *sync.Cond
+	mu     sync.Mutex
+	now    time.Time
+	timers []*virtualTimer
+}
+
+// virtualTimer is a pending timer (or ticker when the period is set)
+type virtualTimer struct {
+	c      chan time.Time
+	period time.Duration
+	when   time.Time
+}
+
+// NewVirtual will create a new virtual scheduler starting at the given time
+func NewVirtual(now time.Time) *Virtual {
+	v := &Virtual{now: now}
+	v.cond = sync.NewCond(&v.mu)
+	return v
+}
+
+// Now will return the virtual time
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// After will return a channel receiving the virtual time once it advanced by the duration
+func (v *Virtual) After(d time.Duration) <-chan time.Time {
+	return v.add(d, 0).c
+}
+
+// NewTicker will return a ticker firing every period of virtual time
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return &virtualTicker{scheduler: v, timer: v.add(d, d)}
+}
+
+// Advance will move the virtual time forward, firing the due timers and tickers in order
+func (v *Virtual) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	target := v.now.Add(d)
+	for len(v.timers) > 0 && !v.timers[0].when.After(target) {
+		timer := v.timers[0]
+		v.now = timer.when
+
+		// like time.Ticker, ticks are dropped when the receiver is too slow
+		select {
+		case timer.c <- v.now:
+		default:
+		}
+
+		if timer.period > 0 {
+			timer.when = timer.when.Add(timer.period)
+		} else {
+			v.timers = v.timers[1:]
+		}
+		v.sort()
+	}
+	v.now = target
+}
+
+// Waiters will return the number of pending timers and tickers
+func (v *Virtual) Waiters() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.timers)
+}
+
+// BlockUntil will block until at least n timers and tickers are pending, use it to wait for
+// the code under test to schedule its next wait before calling Advance
+func (v *Virtual) BlockUntil(n int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for len(v.timers) < n {
+		v.cond.Wait()
+	}
+}
+
+// add will add a pending timer
+func (v *Virtual) add(d, period time.Duration) *virtualTimer {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	timer := &virtualTimer{c: make(chan time.Time, 1), period: period, when: v.now.Add(d)}
+	if d <= 0 {
+		timer.c <- v.now
+		return timer
+	}
+	v.timers = append(v.timers, timer)
+	v.sort()
+	v.cond.Broadcast()
+	return timer
+}
+
+// remove will remove a pending timer
+func (v *Virtual) remove(timer *virtualTimer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for index, pending := range v.timers {
+		if pending == timer {
+			v.timers = append(v.timers[:index], v.timers[index+1:]...)
+			return
+		}
+	}
+}
+
+// sort will sort the pending timers by time (stable, so equal times fire in creation order)
+func (v *Virtual) sort() {
+	sort.SliceStable(v.timers, func(i, j int) bool {
+		return v.timers[i].when.Before(v.timers[j].when)
+	})
+}
+
+// virtualTicker is a ticker in virtual time
+type virtualTicker struct {
+	scheduler *Virtual
+	timer     *virtualTimer
+}
+
+// C will return the channel of the ticks
+func (t *virtualTicker) C() <-chan time.Time {
+	return t.timer.c
+}
+
+// Stop will stop the ticker
+func (t *virtualTicker) Stop() {
+	t.scheduler.remove(t.timer)
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStart = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestVirtual will test the virtual scheduler
+func TestVirtual(t *testing.T) {
+	t.Run("after", func(t *testing.T) {
+		virtual := NewVirtual(testStart)
+		later := virtual.After(2 * time.Second)
+		sooner := virtual.After(time.Second)
+		assert.Equal(t, 2, virtual.Waiters())
+
+		virtual.Advance(time.Second)
+		require.Len(t, sooner, 1)
+		assert.Equal(t, testStart.Add(time.Second), <-sooner)
+		assert.Len(t, later, 0)
+
+		virtual.Advance(5 * time.Second)
+		assert.Equal(t, testStart.Add(2*time.Second), <-later)
+		assert.Equal(t, testStart.Add(6*time.Second), virtual.Now())
+		assert.Equal(t, 0, virtual.Waiters())
+	})
+
+	t.Run("after now", func(t *testing.T) {
+		virtual := NewVirtual(testStart)
+		assert.Equal(t, testStart, <-virtual.After(0))
+		assert.Equal(t, 0, virtual.Waiters())
+	})
+
+	t.Run("ticker", func(t *testing.T) {
+		virtual := NewVirtual(testStart)
+		ticker := virtual.NewTicker(time.Minute)
+
+		virtual.Advance(time.Minute)
+		assert.Equal(t, testStart.Add(time.Minute), <-ticker.C())
+
+		// ticks are dropped when not received
+		virtual.Advance(3 * time.Minute)
+		assert.Equal(t, testStart.Add(2*time.Minute), <-ticker.C())
+		assert.Len(t, ticker.C(), 0)
+
+		ticker.Stop()
+		virtual.Advance(time.Hour)
+		assert.Len(t, ticker.C(), 0)
+		assert.Equal(t, 0, virtual.Waiters())
+	})
+
+	t.Run("block until", func(t *testing.T) {
+		virtual := NewVirtual(testStart)
+		fired := make(chan time.Time)
+		go func() {
+			fired <- <-virtual.After(time.Hour)
+		}()
+
+		virtual.BlockUntil(1)
+		virtual.Advance(time.Hour)
+		assert.Equal(t, testStart.Add(time.Hour), <-fired)
+	})
+}
+
+// TestDefault will test the default scheduler
+func TestDefault(t *testing.T) {
+	scheduler := Default()
+	assert.WithinDuration(t, time.Now(), scheduler.Now(), time.Second)
+	<-scheduler.After(time.Millisecond)
+
+	ticker := scheduler.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+}
@@ -0,0 +1,97 @@
+package buxclient
+
+import (
+	"fmt"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// NewScriptOutput will return an output of a transaction config paying the satoshis to the locking
+// script (hex), for contracts and non-standard scripts (see DraftTransaction)
+func NewScriptOutput(lockingScript string, satoshis uint64) *bux.TransactionOutput {
+	return &bux.TransactionOutput{
+		Satoshis: satoshis,
+		Scripts: []*bux.ScriptOutput{{
+			Satoshis: satoshis,
+			Script:   lockingScript,
+		}},
+	}
+}
+
+// isScriptOutput will return whether the output is given by its locking scripts only
+func isScriptOutput(output *bux.TransactionOutput) bool {
+	return output != nil && output.To == "" && output.OpReturn == nil && output.PaymailP4 == nil &&
+		len(output.Scripts) > 0
+}
+
+// parseLockingScript will decode the locking script (hex) of an output
+func parseLockingScript(field, lockingScript string) (*bscript.Script, error) {
+	script, err := bscript.NewFromHexString(lockingScript)
+	if err != nil || len(*script) == 0 {
+		return nil, &InvalidInputError{Field: field, Reason: "is not a locking script (hex)"}
+	}
+	return script, nil
+}
+
+// validateScriptRecipient will check the locking script of the recipient, only the data scripts
+// (OP_RETURN) can have no amount
+func validateScriptRecipient(field string, recipient *transports.Recipients) error {
+	if recipient.To != "" || recipient.OpReturn != nil {
+		return &InvalidInputError{Field: field + ".script", Reason: "cannot be set with to or op_return"}
+	}
+	script, err := parseLockingScript(field+".script", recipient.Script)
+	if err != nil {
+		return err
+	}
+	if recipient.Satoshis == 0 && !script.IsData() {
+		return &InvalidInputError{Field: field + ".satoshis", Reason: "must be greater than 0"}
+	}
+	return nil
+}
+
+// validateScriptOutputs will check the locking scripts and the satoshis of the script outputs of the
+// transaction config, the server must support them
+func (b *BuxClient) validateScriptOutputs(transactionConfig *bux.TransactionConfig) error {
+	var scriptOutputs bool
+	for index, output := range transactionConfig.Outputs {
+		if !isScriptOutput(output) {
+			continue
+		}
+		scriptOutputs = true
+
+		var satoshis uint64
+		for scriptIndex, scriptOutput := range output.Scripts {
+			field := fmt.Sprintf("outputs[%d].scripts[%d]", index, scriptIndex)
+			if scriptOutput == nil {
+				return &InvalidInputError{Field: field, Reason: "is nil"}
+			}
+			if _, err := parseLockingScript(field+".script", scriptOutput.Script); err != nil {
+				return err
+			}
+			satoshis += scriptOutput.Satoshis
+		}
+		if satoshis != output.Satoshis {
+			return &InvalidInputError{
+				Field:  fmt.Sprintf("outputs[%d].satoshis", index),
+				Reason: fmt.Sprintf("%d is not the sum of the satoshis of the scripts (%d)", output.Satoshis, satoshis),
+			}
+		}
+	}
+	if !scriptOutputs {
+		return nil
+	}
+	return b.requireFeature(FeatureScriptOutputs)
+}
+
+// requireScriptRecipients will return ErrFeatureDisabled if a recipient is a locking script and the
+// server does not support the script outputs
+func (b *BuxClient) requireScriptRecipients(recipients []*transports.Recipients) error {
+	for _, recipient := range recipients {
+		if recipient.Script != "" {
+			return b.requireFeature(FeatureScriptOutputs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package buxclient
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/pkg/errors"
+)
+
+// ErrScriptTemplateNotFound is when no script template is registered for the type of a destination
+var ErrScriptTemplateNotFound = errors.New("no script template registered for the destination type")
+
+// ScriptBuilder will build the locking script of an output from the parameters of the template
+type ScriptBuilder func(params map[string]interface{}) (*bscript.Script, error)
+
+// ScriptSigner will build the unlocking script of the input of the transaction, spending an output
+// locked by the template, with the private key of the destination
+type ScriptSigner func(tx *bt.Tx, inputIndex uint32, privateKey *bec.PrivateKey) (*bscript.Script, error)
+
+// ScriptTemplate is a type of locking script: how to build its outputs and sign its inputs
+type ScriptTemplate struct {
+	Builder ScriptBuilder // optional, to build outputs (see TemplateOutput)
+	Signer  ScriptSigner  // required to spend the destinations of the type
+}
+
+// ScriptTemplateRegistry are the script templates by name, the name is the type of the destinations
+// (ex: pubkeyhash) so FinalizeTransaction signs the inputs with the signer of their type
+type ScriptTemplateRegistry struct {
+	mu        sync.RWMutex
+	templates map[string]*ScriptTemplate
+}
+
+// NewScriptTemplateRegistry will create a registry with the P2PKH template (pubkeyhash), its builder
+// takes the "address" parameter
+func NewScriptTemplateRegistry() *ScriptTemplateRegistry {
+	registry := &ScriptTemplateRegistry{templates: make(map[string]*ScriptTemplate)}
+	registry.Register(bscript.ScriptTypePubKeyHash, &ScriptTemplate{
+		Builder: func(params map[string]interface{}) (*bscript.Script, error) {
+			address, _ := params["address"].(string)
+			if !utils.IsValidAddress(address) {
+				return nil, &InvalidInputError{Field: "address", Reason: "is not an address"}
+			}
+			return bscript.NewP2PKHFromAddress(address)
+		},
+		Signer: utils.GetUnlockingScript,
+	})
+	return registry
+}
+
+// Register will register the script template under the name, replacing the template of the name
+func (r *ScriptTemplateRegistry) Register(name string, template *ScriptTemplate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[name] = template
+}
+
+// Get will return the script template of the name, false if none is registered
+func (r *ScriptTemplateRegistry) Get(name string) (*ScriptTemplate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	template, ok := r.templates[name]
+	return template, ok && template != nil
+}
+
+// Names will return the names of the registered templates, sorted
+func (r *ScriptTemplateRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScriptTemplates returns the registry of the script templates used to build outputs and sign inputs
+func (b *BuxClient) ScriptTemplates() *ScriptTemplateRegistry {
+	return b.scriptTemplates
+}
+
+// TemplateOutput will return an output of a transaction config paying the satoshis to the locking
+// script built by the template of the name
+func (b *BuxClient) TemplateOutput(name string, params map[string]interface{},
+	satoshis uint64) (*bux.TransactionOutput, error) {
+
+	template, ok := b.scriptTemplates.Get(name)
+	if !ok || template.Builder == nil {
+		return nil, &ScriptTemplateError{Name: name}
+	}
+	script, err := template.Builder(params)
+	if err != nil {
+		return nil, err
+	}
+	return NewScriptOutput(script.String(), satoshis), nil
+}
+
+// inputSigner will return the signer of the input of the draft, from the template of the type of its
+// destination (P2PKH when the type is not set)
+func (b *BuxClient) inputSigner(input *bux.TransactionInput) (ScriptSigner, error) {
+	scriptType := input.Destination.Type
+	if scriptType == "" {
+		scriptType = input.Type
+	}
+	if scriptType == "" {
+		scriptType = bscript.ScriptTypePubKeyHash
+	}
+
+	template, ok := b.scriptTemplates.Get(scriptType)
+	if !ok || template.Signer == nil {
+		return nil, &ScriptTemplateError{Name: scriptType}
+	}
+	return template.Signer, nil
+}
+
+// fundingScriptTypes are the types of the UTXOs the server funds the drafts with (bux only reserves
+// P2PKH UTXOs, pinned or not)
+var fundingScriptTypes = []string{bscript.ScriptTypePubKeyHash}
+
+// checkInputSigners will return ErrScriptTemplateNotFound if the inputs funding a draft could not be
+// signed, before drafting so no UTXO is reserved for a draft that cannot be finalized
+func (b *BuxClient) checkInputSigners() error {
+	for _, scriptType := range fundingScriptTypes {
+		if _, err := b.inputSigner(&bux.TransactionInput{Destination: bux.Destination{Type: scriptType}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScriptTemplateError is when no script template (builder or signer) is registered for a name, it
+// matches ErrScriptTemplateNotFound with errors.Is
+type ScriptTemplateError struct {
+	Name string // ex: the type of the destination of an input
+}
+
+// Error will return the error message, with the name of the template
+func (e *ScriptTemplateError) Error() string {
+	return ErrScriptTemplateNotFound.Error() + ": " + e.Name
+}
+
+// Is will return whether the target is ErrScriptTemplateNotFound
+func (e *ScriptTemplateError) Is(target error) bool {
+	return target == ErrScriptTemplateNotFound
+}
@@ -0,0 +1,123 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScriptTemplates will test the registry of the script templates
+func TestScriptTemplates(t *testing.T) {
+	newClient := func(opts ...ClientOps) *BuxClient {
+		client, err := New(append([]ClientOps{
+			WithXPriv(xPrivString),
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: http.NewServeMux()}}),
+		}, opts...)...)
+		require.NoError(t, err)
+		return client
+	}
+	newDraft := func(scriptType string) *bux.DraftTransaction {
+		var draft *bux.DraftTransaction
+		require.NoError(t, json.Unmarshal([]byte(draftTxJSON), &draft))
+		draft.Configuration.Inputs[0].Destination.Type = scriptType
+		return draft
+	}
+	customScript, err := bscript.NewFromASM("OP_TRUE")
+	require.NoError(t, err)
+
+	t.Run("register", func(t *testing.T) {
+		registry := NewScriptTemplateRegistry()
+		assert.Equal(t, []string{bscript.ScriptTypePubKeyHash}, registry.Names())
+
+		registry.Register("custom", &ScriptTemplate{})
+		assert.Equal(t, []string{"custom", bscript.ScriptTypePubKeyHash}, registry.Names())
+		_, ok := registry.Get("custom")
+		assert.True(t, ok)
+		_, ok = registry.Get("unknown")
+		assert.False(t, ok)
+	})
+
+	t.Run("template output", func(t *testing.T) {
+		client := newClient()
+		output, err := client.TemplateOutput(bscript.ScriptTypePubKeyHash, map[string]interface{}{"address": testAddress}, 1000)
+		require.NoError(t, err)
+		expected, err := bscript.NewP2PKHFromAddress(testAddress)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), output.Satoshis)
+		require.Len(t, output.Scripts, 1)
+		assert.Equal(t, expected.String(), output.Scripts[0].Script)
+
+		_, err = client.TemplateOutput(bscript.ScriptTypePubKeyHash, map[string]interface{}{"address": "invalid"}, 1000)
+		assert.Error(t, err)
+
+		_, err = client.TemplateOutput("unknown", nil, 1000)
+		assert.True(t, errors.Is(err, ErrScriptTemplateNotFound))
+	})
+
+	t.Run("custom signer", func(t *testing.T) {
+		registry := NewScriptTemplateRegistry()
+		registry.Register("custom", &ScriptTemplate{
+			Signer: func(tx *bt.Tx, inputIndex uint32, privateKey *bec.PrivateKey) (*bscript.Script, error) {
+				return customScript, nil
+			},
+		})
+		client := newClient(WithScriptTemplates(registry))
+		assert.Equal(t, registry, client.ScriptTemplates())
+
+		draftHex, err := client.FinalizeTransaction(newDraft("custom"))
+		require.NoError(t, err)
+		tx, err := bt.NewTxFromString(draftHex)
+		require.NoError(t, err)
+		assert.Equal(t, customScript.String(), tx.Inputs[0].UnlockingScript.String())
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		client := newClient()
+		_, err := client.FinalizeTransaction(newDraft("custom"))
+		assert.True(t, errors.Is(err, ErrScriptTemplateNotFound))
+	})
+
+	t.Run("funding inputs not signable", func(t *testing.T) {
+		var drafts int
+		mux := http.NewServeMux()
+		mux.HandleFunc("/transactions/new", func(w http.ResponseWriter, req *http.Request) {
+			drafts++
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, draftTxJSON)
+		})
+		registry := NewScriptTemplateRegistry()
+		registry.Register(bscript.ScriptTypePubKeyHash, &ScriptTemplate{})
+		client := newClient(
+			WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithScriptTemplates(registry),
+		)
+
+		// the draft is not created, no utxo is reserved
+		_, err := client.DraftTransaction(context.Background(), &bux.TransactionConfig{
+			Outputs: []*bux.TransactionOutput{{Satoshis: 1000, To: testAddress}},
+		}, nil)
+		assert.True(t, errors.Is(err, ErrScriptTemplateNotFound))
+		_, err = client.DraftToRecipients(context.Background(), []*transports.Recipients{
+			{To: testAddress, Satoshis: 1000},
+		}, nil)
+		assert.True(t, errors.Is(err, ErrScriptTemplateNotFound))
+		assert.Equal(t, 0, drafts)
+
+		client = newClient(WithHTTPClient(serverURL, &http.Client{Transport: localRoundTripper{handler: mux}}))
+		_, err = client.DraftTransaction(context.Background(), &bux.TransactionConfig{
+			Outputs: []*bux.TransactionOutput{{Satoshis: 1000, To: testAddress}},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, drafts)
+	})
+}
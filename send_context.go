@@ -0,0 +1,133 @@
+package buxclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+)
+
+// ErrSendContextMismatch is when a step of the send flow does not belong to the send context
+// (ex: signing another draft, or drafting twice with the same context)
+var ErrSendContextMismatch = errors.New("the draft transaction does not match the send context")
+
+// SendContext carries the identifiers of a transaction through the whole send flow (draft, sign,
+// record and paymail p2p), so every step uses the same draft ID, metadata and reference IDs
+type SendContext struct {
+	DraftID      string            `json:"draft_id"`      // id of the draft, the reference of the recorded transaction
+	ExpiresAt    time.Time         `json:"expires_at"`    // expiry of the draft, its inputs are released after
+	Hex          string            `json:"hex"`           // signed transaction
+	Metadata     *bux.Metadata     `json:"metadata"`      // metadata of the draft and of the recorded transaction
+	ReferenceIDs map[string]string `json:"reference_ids"` // paymail p2p reference id per paymail recipient (alias@domain)
+	TxID         string            `json:"tx_id"`         // id of the signed transaction
+}
+
+// NewSendContext will create a new send context, the metadata is used by every step
+func NewSendContext(metadata *bux.Metadata) *SendContext {
+	return &SendContext{
+		Metadata:     metadata,
+		ReferenceIDs: make(map[string]string),
+	}
+}
+
+// ReferenceID will return the paymail p2p reference id of the paymail recipient
+func (s *SendContext) ReferenceID(paymail string) string {
+	return s.ReferenceIDs[paymail]
+}
+
+// SetDraft will set the draft of the send context (ex: a draft created with DraftTransaction)
+func (s *SendContext) SetDraft(draft *bux.DraftTransaction) error {
+	if draft == nil {
+		return bux.ErrDraftNotFound
+	} else if s.DraftID != "" && s.DraftID != draft.ID {
+		return ErrSendContextMismatch
+	}
+
+	s.DraftID = draft.ID
+	s.ExpiresAt = draft.ExpiresAt
+	if s.ReferenceIDs == nil {
+		s.ReferenceIDs = make(map[string]string)
+	}
+	for _, output := range draft.Configuration.Outputs {
+		if output.PaymailP4 != nil && output.PaymailP4.ReferenceID != "" {
+			s.ReferenceIDs[output.PaymailP4.Alias+"@"+output.PaymailP4.Domain] = output.PaymailP4.ReferenceID
+		}
+	}
+	return nil
+}
+
+// DraftSend will create the draft transaction to the recipients for the send context
+func (b *BuxClient) DraftSend(ctx context.Context, send *SendContext,
+	recipients []*transports.Recipients) (*bux.DraftTransaction, error) {
+
+	if send.DraftID != "" {
+		return nil, ErrSendContextMismatch
+	}
+
+	draft, err := b.DraftToRecipients(ctx, recipients, send.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err = send.SetDraft(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// SignSend will sign the draft transaction of the send context
+func (b *BuxClient) SignSend(send *SendContext, draft *bux.DraftTransaction) error {
+	if draft == nil {
+		return bux.ErrDraftNotFound
+	} else if draft.ID != send.DraftID {
+		return ErrSendContextMismatch
+	}
+
+	hex, err := b.FinalizeTransaction(draft)
+	if err != nil {
+		return err
+	}
+
+	var tx *bt.Tx
+	if tx, err = bt.NewTxFromString(hex); err != nil {
+		return err
+	}
+	send.Hex = hex
+	send.TxID = tx.TxID()
+	return nil
+}
+
+// RecordSend will record the signed transaction of the send context, referencing its draft, it returns
+// ErrDraftExpired when the server expired the draft
+func (b *BuxClient) RecordSend(ctx context.Context, send *SendContext) (*bux.Transaction, error) {
+	if send.DraftID == "" || send.Hex == "" {
+		return nil, ErrSendContextMismatch
+	}
+	transaction, err := b.RecordTransaction(ctx, send.Hex, send.DraftID, send.Metadata)
+	if err == nil || transports.ErrorCode(err) != transports.ErrorCodeDraftNotFound {
+		return transaction, err
+	}
+	return b.checkDraftNotFound(ctx, send, err)
+}
+
+// checkDraftNotFound will check why the server did not find the draft of the send context: the
+// transaction recorded by an earlier attempt (ex: its response was lost) is returned, ErrDraftExpired
+// when the draft expired, the error of the server otherwise
+func (b *BuxClient) checkDraftNotFound(ctx context.Context, send *SendContext,
+	err error) (*bux.Transaction, error) {
+
+	if recorded, getErr := b.GetTransaction(ctx, send.TxID); getErr == nil && recorded != nil &&
+		recorded.ID == send.TxID {
+		return recorded, nil
+	}
+	expired, countErr := b.GetDraftTransactionsCount(ctx, map[string]interface{}{
+		"id":     send.DraftID,
+		"status": bux.DraftStatusExpired,
+	}, nil)
+	if countErr == nil && expired > 0 {
+		return nil, errors.Wrap(ErrDraftExpired, err.Error())
+	}
+	return nil, err
+}
@@ -0,0 +1,22 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+)
+
+// Ping will check that the server URL points to a healthy bux server, it returns a
+// transports.ServerUnavailableError (matching transports.ErrServerUnavailable) otherwise
+func (b *BuxClient) Ping(ctx context.Context) error {
+	return b.transport.Ping(ctx)
+}
+
+// ServerInfo will return the version and the enabled features of the server, after checking that
+// it is a healthy bux server (see Ping)
+func (b *BuxClient) ServerInfo(ctx context.Context) (*transports.ServerInfo, error) {
+	if err := b.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return b.transport.GetServerInfo(ctx)
+}
@@ -0,0 +1,176 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/store"
+	"github.com/pkg/errors"
+)
+
+// snapshotCacheKey is the key of the snapshot in the cache namespace of the store (see WithStore)
+const snapshotCacheKey = "snapshot"
+
+// snapshotVersion is the version of the snapshot format, snapshots of other versions are rejected
+const snapshotVersion = 1
+
+// ErrInvalidSnapshot is when the snapshot can not be restored (corrupted, or another format version)
+var ErrInvalidSnapshot = errors.New("invalid client snapshot")
+
+// snapshotReads are the cached reads of the xPub in the snapshot: the fee quote, the chain tip and
+// the xPub (its next derivation numbers)
+var snapshotReads = []CachedRead{CacheChainTip, CacheFeeQuote, CacheXPub}
+
+// clientSnapshot is the state derived from the bux server, to warm-start new clients
+type clientSnapshot struct {
+	Capabilities map[string]bool                `json:"capabilities,omitempty"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	FeatureFlags map[string]bool                `json:"feature_flags,omitempty"`
+	Reads        map[CachedRead]*readCacheEntry `json:"reads,omitempty"`
+	Version      int                            `json:"version"`
+	XPubKey      string                         `json:"xpub_key,omitempty"`
+}
+
+// Snapshot will serialize the state the client fetched from the server (the capabilities, the feature
+// flags, and the cached fee quote, chain tip and next derivation numbers of the xPub, see WithReadCache),
+// to restore it in a new client (see WithSnapshot) without fetching it again, the keys are not included
+func (b *BuxClient) Snapshot() ([]byte, error) {
+	snapshot := &clientSnapshot{
+		CreatedAt: b.scheduler.Now().UTC(),
+		Version:   snapshotVersion,
+		XPubKey:   b.xPubCacheKey(),
+	}
+
+	b.capabilities.mu.RLock()
+	snapshot.Capabilities = copyFlags(b.capabilities.advertised)
+	b.capabilities.mu.RUnlock()
+
+	b.featureFlags.mu.RLock()
+	snapshot.FeatureFlags = copyFlags(b.featureFlags.flags)
+	b.featureFlags.mu.RUnlock()
+
+	for _, read := range snapshotReads {
+		if entry := b.cachedEntry(context.Background(), read); entry != nil {
+			if snapshot.Reads == nil {
+				snapshot.Reads = make(map[CachedRead]*readCacheEntry, len(snapshotReads))
+			}
+			snapshot.Reads[read] = entry
+		}
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// copyFlags will return a copy of the flags, nil if they were not fetched
+func copyFlags(flags map[string]bool) map[string]bool {
+	if flags == nil {
+		return nil
+	}
+	copied := make(map[string]bool, len(flags))
+	for name, enabled := range flags {
+		copied[name] = enabled
+	}
+	return copied
+}
+
+// RestoreSnapshot will restore the state of a snapshot (see Snapshot)
+func (b *BuxClient) RestoreSnapshot(data []byte) error {
+	snapshot, err := parseSnapshot(data)
+	if err != nil {
+		return err
+	}
+	b.applySnapshot(snapshot)
+	return nil
+}
+
+// parseSnapshot will decode and validate a snapshot
+func parseSnapshot(data []byte) (*clientSnapshot, error) {
+	snapshot := new(clientSnapshot)
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, errors.Wrap(ErrInvalidSnapshot, err.Error())
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, errors.Wrapf(ErrInvalidSnapshot, "unsupported version %d", snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+// applySnapshot will replace the state of the client by the state of the snapshot, the cached reads
+// are only restored for the same xPub, until their original expiry
+func (b *BuxClient) applySnapshot(snapshot *clientSnapshot) {
+	if snapshot.Capabilities != nil {
+		b.capabilities.mu.Lock()
+		b.capabilities.advertised = snapshot.Capabilities
+		b.capabilities.mu.Unlock()
+	}
+	if snapshot.FeatureFlags != nil {
+		b.featureFlags.mu.Lock()
+		b.featureFlags.flags = snapshot.FeatureFlags
+		b.featureFlags.mu.Unlock()
+	}
+	if snapshot.XPubKey == b.xPubCacheKey() {
+		for _, read := range snapshotReads {
+			b.restoreEntry(context.Background(), read, snapshot.Reads[read])
+		}
+	}
+}
+
+// snapshotOutdated will return whether the snapshot is older than the maximum age of WithSnapshot
+func (b *BuxClient) snapshotOutdated(snapshot *clientSnapshot) bool {
+	return b.snapshot != nil && b.snapshot.maxAge > 0 &&
+		b.scheduler.Now().Sub(snapshot.CreatedAt) > b.snapshot.maxAge
+}
+
+// restoreSnapshot will restore the snapshot of WithSnapshot when creating the client, an invalid
+// or outdated snapshot is ignored (cold start)
+func (b *BuxClient) restoreSnapshot() {
+	if b.snapshot == nil {
+		return
+	}
+
+	snapshot, err := parseSnapshot(b.snapshot.data)
+	if err != nil || b.snapshotOutdated(snapshot) {
+		return
+	}
+	b.applySnapshot(snapshot)
+
+	// the feature flags are already known
+	if snapshot.FeatureFlags != nil {
+		b.loadFeatureFlags = false
+	}
+}
+
+// snapshotOptions are the options of WithSnapshot
+type snapshotOptions struct {
+	data   []byte
+	maxAge time.Duration
+}
+
+// cacheSnapshot will write the snapshot to the store of the client, the cache is best-effort
+func (b *BuxClient) cacheSnapshot(ctx context.Context) {
+	if b.store == nil {
+		return
+	}
+	if data, err := b.Snapshot(); err == nil {
+		_ = b.store.Put(ctx, store.NamespaceCache, snapshotCacheKey, data)
+	}
+}
+
+// restoreCachedSnapshot will restore the snapshot of the store of the client, returning whether a
+// valid snapshot was restored (a snapshot older than the maximum age of WithSnapshot is ignored)
+func (b *BuxClient) restoreCachedSnapshot() bool {
+	if b.store == nil {
+		return false
+	}
+	data, err := b.store.Get(context.Background(), store.NamespaceCache, snapshotCacheKey)
+	if err != nil {
+		return false
+	}
+	snapshot, err := parseSnapshot(data)
+	if err != nil || b.snapshotOutdated(snapshot) {
+		return false
+	}
+	b.applySnapshot(snapshot)
+	return true
+}
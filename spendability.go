@@ -0,0 +1,149 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/pkg/errors"
+)
+
+// ErrUnconfirmedInputs is when a draft transaction would use inputs that do not have enough confirmations
+var ErrUnconfirmedInputs = errors.New("draft transaction uses inputs without enough confirmations")
+
+// ErrMissingChainHeight is when the client requires confirmations without a chain height function
+var ErrMissingChainHeight = errors.New("min confirmations require a chain height function")
+
+// ChainHeightFunc returns the current height of the chain (ex: from a block explorer)
+type ChainHeightFunc func(ctx context.Context) (uint64, error)
+
+// Balance is the balance of the xPub, split by spendability
+type Balance struct {
+	Pending   int64 `json:"pending"`   // incoming funds without enough confirmations
+	Spendable int64 `json:"spendable"` // funds that can be spent
+	Total     int64 `json:"total"`     // pending + spendable
+}
+
+// Confirmations will return the number of confirmations of the transaction at the given chain height
+func Confirmations(transaction *bux.Transaction, chainHeight uint64) uint64 {
+	if transaction.BlockHeight == 0 || transaction.BlockHeight > chainHeight {
+		return 0
+	}
+	return chainHeight - transaction.BlockHeight + 1
+}
+
+// MinConfirmations return the number of confirmations incoming funds need to be spendable
+func (b *BuxClient) MinConfirmations() uint64 {
+	return b.minConfirmations
+}
+
+// IsSpendable will return whether the funds of the transaction are spendable with the confirmation
+// policy of the client (see WithMinConfirmations), outgoing transactions are always final
+func (b *BuxClient) IsSpendable(transaction *bux.Transaction, chainHeight uint64) bool {
+	if b.minConfirmations == 0 || transaction.Direction == bux.TransactionDirectionOut {
+		return true
+	}
+	return Confirmations(transaction, chainHeight) >= b.minConfirmations
+}
+
+// GetBalance will compute the balance of the xPub from its transactions, incoming funds are only
+// spendable once they have the confirmations required by the client
+func (b *BuxClient) GetBalance(ctx context.Context) (*Balance, error) {
+	transactions, err := b.GetTransactions(ctx, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var chainHeight uint64
+	if b.minConfirmations > 0 {
+		if chainHeight, err = b.getChainHeight(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	balance := &Balance{}
+	for _, transaction := range transactions {
+		if b.IsSpendable(transaction, chainHeight) {
+			balance.Spendable += transaction.OutputValue
+		} else {
+			balance.Pending += transaction.OutputValue
+		}
+	}
+	balance.Total = balance.Spendable + balance.Pending
+
+	return balance, nil
+}
+
+// confirmedInputs will return the utxos a draft can spend with the confirmation policy of the client,
+// checked before drafting so the server never reserves unconfirmed inputs: the pinned utxos
+// (ErrUnconfirmedInputs if one of them is not confirmed enough) or the confirmed unspent utxos of the
+// xPub, the pinned utxos without confirmation policy
+func (b *BuxClient) confirmedInputs(ctx context.Context, pinned []*bux.UtxoPointer) ([]*bux.UtxoPointer, error) {
+	if b.minConfirmations == 0 {
+		return pinned, nil
+	}
+
+	candidates := pinned
+	if len(candidates) == 0 {
+		utxos, err := b.GetUtxos(ctx, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, utxo := range utxos {
+			// a null spending transaction is decoded as valid and empty
+			if utxo.SpendingTxID.String == "" {
+				candidates = append(candidates, &bux.UtxoPointer{
+					OutputIndex:   utxo.OutputIndex,
+					TransactionID: utxo.TransactionID,
+				})
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, bux.ErrNotEnoughUtxos
+		}
+	}
+
+	txIDs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		txIDs = append(txIDs, candidate.TransactionID)
+	}
+	transactions, err := b.GetTransactionsByIDs(ctx, txIDs)
+	if err != nil {
+		return nil, err
+	}
+	var chainHeight uint64
+	if chainHeight, err = b.getChainHeight(ctx); err != nil {
+		return nil, err
+	}
+
+	// the inputs are outputs received by the xPub, even when the transaction was sent by the xPub (change)
+	confirmed := make([]*bux.UtxoPointer, 0, len(candidates))
+	for index, transaction := range transactions {
+		if transaction != nil && Confirmations(transaction, chainHeight) >= b.minConfirmations {
+			confirmed = append(confirmed, candidates[index])
+		} else if len(pinned) > 0 {
+			return nil, ErrUnconfirmedInputs
+		}
+	}
+	if len(confirmed) == 0 {
+		return nil, ErrUnconfirmedInputs
+	}
+	return confirmed, nil
+}
+
+// getChainHeight will return the chain height from the chain height function of the client
+// (ErrMissingChainHeight without one), cached as the chain tip (see CacheChainTip)
+func (b *BuxClient) getChainHeight(ctx context.Context) (uint64, error) {
+	if b.chainHeight == nil {
+		return 0, ErrMissingChainHeight
+	}
+	var height uint64
+	if b.cachedRead(ctx, CacheChainTip, noCacheKey, &height) {
+		return height, nil
+	}
+	height, err := b.chainHeight(ctx)
+	if err != nil {
+		return 0, err
+	}
+	b.cacheRead(ctx, CacheChainTip, noCacheKey, height)
+	return height, nil
+}
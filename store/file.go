@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileTempSuffix is the suffix of the file written before replacing an item, it is not a valid escaped name
+const fileTempSuffix = "%tmp"
+
+// File is the store of the items in a directory: a sub-directory per namespace and a file per item
+// (the names are escaped), it is safe for a single process only
+type File struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFile will create the store in the directory, which is created if needed
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir}, nil
+}
+
+// Delete will remove the file of the item
+func (f *File) Delete(_ context.Context, namespace, key string) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.Remove(f.path(namespace, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Get will read the file of the item
+func (f *File) Get(_ context.Context, namespace, key string) ([]byte, error) {
+	if err := validateName(namespace, key); err != nil {
+		return nil, err
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	value, err := ioutil.ReadFile(f.path(namespace, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// List will read the files of the namespace, ordered by key
+func (f *File) List(_ context.Context, namespace string) ([]*Item, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	dir := filepath.Join(f.dir, escapeName(namespace))
+	files, err := ioutil.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]*Item, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	items := make([]*Item, 0, len(files))
+	for _, file := range files {
+		// the temporary files of Put cannot be unescaped
+		key, unescapeErr := url.PathUnescape(file.Name())
+		if file.IsDir() || unescapeErr != nil {
+			continue
+		}
+		value, readErr := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if readErr != nil {
+			return nil, readErr
+		}
+		items = append(items, &Item{Key: key, Value: value})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items, nil
+}
+
+// Put will write the file of the item, the file is replaced atomically (a crash keeps the previous value)
+func (f *File) Put(_ context.Context, namespace, key string, value []byte) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Join(f.dir, escapeName(namespace)), 0o700); err != nil {
+		return err
+	}
+
+	path := f.path(namespace, key)
+	if err := ioutil.WriteFile(path+fileTempSuffix, value, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(path+fileTempSuffix, path)
+}
+
+// path will return the path of the file of the item
+func (f *File) path(namespace, key string) string {
+	return filepath.Join(f.dir, escapeName(namespace), escapeName(key))
+}
+
+// escapeName will escape the namespace or key to a valid file name (including "." and "..")
+func escapeName(name string) string {
+	return strings.ReplaceAll(url.PathEscape(name), ".", "%2E")
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is the in-memory store, the items are lost when the process stops
+type Memory struct {
+	mu         sync.RWMutex
+	namespaces map[string]map[string][]byte
+}
+
+// NewMemory will create a new empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{namespaces: make(map[string]map[string][]byte)}
+}
+
+// Delete will remove the item
+func (m *Memory) Delete(_ context.Context, namespace, key string) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.namespaces[namespace], key)
+	return nil
+}
+
+// Get will return a copy of the value of the item
+func (m *Memory) Get(_ context.Context, namespace, key string) ([]byte, error) {
+	if err := validateName(namespace, key); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.namespaces[namespace][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyValue(value), nil
+}
+
+// List will return copies of the items of the namespace, ordered by key
+func (m *Memory) List(_ context.Context, namespace string) ([]*Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make([]*Item, 0, len(m.namespaces[namespace]))
+	for key, value := range m.namespaces[namespace] {
+		items = append(items, &Item{Key: key, Value: copyValue(value)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items, nil
+}
+
+// Put will store a copy of the value of the item
+func (m *Memory) Put(_ context.Context, namespace, key string, value []byte) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.namespaces[namespace] == nil {
+		m.namespaces[namespace] = make(map[string][]byte)
+	}
+	m.namespaces[namespace][key] = copyValue(value)
+	return nil
+}
+
+// copyValue will return a copy of the value, so the caller cannot modify the stored item
+func copyValue(value []byte) []byte {
+	copied := make([]byte, len(value))
+	copy(copied, value)
+	return copied
+}
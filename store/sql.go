@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// SQLDialect is the SQL dialect of the database of the SQL store
+type SQLDialect string
+
+// SQL dialects
+const (
+	SQLDialectMySQL    SQLDialect = "mysql"
+	SQLDialectPostgres SQLDialect = "postgres"
+	SQLDialectSQLite   SQLDialect = "sqlite"
+)
+
+// DefaultSQLTable is the table of the items of the SQL store
+const DefaultSQLTable = "buxclient_store"
+
+// ErrInvalidSQLTable is when the table name of the SQL store is not a valid identifier
+var ErrInvalidSQLTable = errors.New("invalid sql store table name")
+
+// ErrUnknownSQLDialect is when the SQL dialect is not supported
+var ErrUnknownSQLDialect = errors.New("unknown sql dialect, use mysql, postgres or sqlite")
+
+// sqlTableRegex matches the valid table names
+var sqlTableRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQL is the store of the items in a table of a SQL database (ex: the database of the application),
+// the database driver is not imported, open the database with the driver of your choice
+type SQL struct {
+	db      *sql.DB
+	dialect SQLDialect
+	table   string
+}
+
+// NewSQL will create the store in the table (DefaultSQLTable if empty), the table is created if needed
+func NewSQL(ctx context.Context, db *sql.DB, dialect SQLDialect, table string) (*SQL, error) {
+	if table == "" {
+		table = DefaultSQLTable
+	} else if !sqlTableRegex.MatchString(table) {
+		return nil, ErrInvalidSQLTable
+	}
+
+	var valueType string
+	switch dialect {
+	case SQLDialectMySQL:
+		valueType = "LONGBLOB"
+	case SQLDialectPostgres:
+		valueType = "BYTEA"
+	case SQLDialectSQLite:
+		valueType = "BLOB"
+	default:
+		return nil, ErrUnknownSQLDialect
+	}
+
+	s := &SQL{db: db, dialect: dialect, table: table}
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+		namespace VARCHAR(191) NOT NULL,
+		item_key VARCHAR(191) NOT NULL,
+		item_value `+valueType+` NOT NULL,
+		PRIMARY KEY (namespace, item_key)
+	)`); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Delete will delete the row of the item
+func (s *SQL) Delete(ctx context.Context, namespace, key string) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM `+s.table+` WHERE namespace = `+s.placeholder(1)+
+		` AND item_key = `+s.placeholder(2), namespace, key)
+	return err
+}
+
+// Get will select the value of the item
+func (s *SQL) Get(ctx context.Context, namespace, key string) ([]byte, error) {
+	if err := validateName(namespace, key); err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	err := s.db.QueryRowContext(ctx, `SELECT item_value FROM `+s.table+` WHERE namespace = `+s.placeholder(1)+
+		` AND item_key = `+s.placeholder(2), namespace, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// List will select the items of the namespace, ordered by key
+func (s *SQL) List(ctx context.Context, namespace string) ([]*Item, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT item_key, item_value FROM `+s.table+
+		` WHERE namespace = `+s.placeholder(1)+` ORDER BY item_key`, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	items := make([]*Item, 0)
+	for rows.Next() {
+		item := new(Item)
+		if err = rows.Scan(&item.Key, &item.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Put will replace the row of the item, in a transaction (the upsert syntax is not portable)
+func (s *SQL) Put(ctx context.Context, namespace, key string, value []byte) error {
+	if err := validateName(namespace, key); err != nil {
+		return err
+	}
+	if value == nil {
+		value = make([]byte, 0)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM `+s.table+` WHERE namespace = `+s.placeholder(1)+
+		` AND item_key = `+s.placeholder(2), namespace, key); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO `+s.table+` (namespace, item_key, item_value) VALUES (`+
+		s.placeholder(1)+`, `+s.placeholder(2)+`, `+s.placeholder(3)+`)`, namespace, key, value); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// placeholder will return the placeholder of the query argument of the dialect (the index starts at 1)
+func (s *SQL) placeholder(index int) string {
+	if s.dialect == SQLDialectPostgres {
+		return "$" + strconv.Itoa(index)
+	}
+	return "?"
+}
@@ -0,0 +1,62 @@
+// Package store is the persistence of the state of the client (dead letters, checkpoints of the
+// event streams, cached server state), so applications can back it with their existing database
+//
+// The state is split in namespaces of key-value items, use the in-memory store for tests and
+// short-lived processes, the file store for a single process, and the SQL store to share the state
+// with the database of the application
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// Namespaces of the state of the client
+const (
+	// NamespaceCache is the state fetched from the server (ex: the snapshot of the feature flags)
+	NamespaceCache = "cache"
+
+	// NamespaceDeadLetters is the dead letters of the failed async operations
+	NamespaceDeadLetters = "dead_letters"
+
+	// NamespaceEventCheckpoints is the last event received of every notification stream
+	NamespaceEventCheckpoints = "event_checkpoints"
+
+	// NamespaceReadCache is the cached reads of the client, with their expiry (see buxclient.WithReadCache)
+	NamespaceReadCache = "read_cache"
+)
+
+// ErrNotFound is when the item does not exist in the namespace
+var ErrNotFound = errors.New("item not found in the store")
+
+// ErrInvalidName is when a namespace or a key is empty
+var ErrInvalidName = errors.New("the namespace and the key of an item cannot be empty")
+
+// Store is the persistence of the items of the client, by namespace
+type Store interface {
+	// Delete will remove the item, removing an item that does not exist is not an error
+	Delete(ctx context.Context, namespace, key string) error
+
+	// Get will return the value of the item, or ErrNotFound
+	Get(ctx context.Context, namespace, key string) ([]byte, error)
+
+	// List will return all the items of the namespace, ordered by key
+	List(ctx context.Context, namespace string) ([]*Item, error)
+
+	// Put will insert or replace the item
+	Put(ctx context.Context, namespace, key string, value []byte) error
+}
+
+// Item is an item of a namespace
+type Item struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// validateName will return ErrInvalidName if the namespace or the key is empty
+func validateName(namespace, key string) error {
+	if namespace == "" || key == "" {
+		return ErrInvalidName
+	}
+	return nil
+}
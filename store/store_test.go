@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStores will return a new store of every implementation
+func testStores(t *testing.T) map[string]Store {
+	file, err := NewFile(t.TempDir())
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	sqlStore, err := NewSQL(context.Background(), db, SQLDialectSQLite, "")
+	require.NoError(t, err)
+
+	return map[string]Store{
+		"memory": NewMemory(),
+		"file":   file,
+		"sql":    sqlStore,
+	}
+}
+
+// TestStore will test the implementations of the store
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Get(ctx, NamespaceCache, "snapshot")
+			assert.ErrorIs(t, err, ErrNotFound)
+			items, err := store.List(ctx, NamespaceCache)
+			require.NoError(t, err)
+			assert.Len(t, items, 0)
+
+			// the keys are escaped for the file store
+			require.NoError(t, store.Put(ctx, NamespaceCache, "b/..", []byte("first")))
+			require.NoError(t, store.Put(ctx, NamespaceCache, "a", []byte("second")))
+			require.NoError(t, store.Put(ctx, NamespaceCache, "b/..", []byte("replaced")))
+			require.NoError(t, store.Put(ctx, NamespaceDeadLetters, "a", []byte("other namespace")))
+
+			value, err := store.Get(ctx, NamespaceCache, "b/..")
+			require.NoError(t, err)
+			assert.Equal(t, "replaced", string(value))
+
+			items, err = store.List(ctx, NamespaceCache)
+			require.NoError(t, err)
+			assert.Equal(t, []*Item{{Key: "a", Value: []byte("second")}, {Key: "b/..", Value: []byte("replaced")}}, items)
+
+			require.NoError(t, store.Delete(ctx, NamespaceCache, "a"))
+			require.NoError(t, store.Delete(ctx, NamespaceCache, "a"))
+			_, err = store.Get(ctx, NamespaceCache, "a")
+			assert.ErrorIs(t, err, ErrNotFound)
+			value, err = store.Get(ctx, NamespaceDeadLetters, "a")
+			require.NoError(t, err)
+			assert.Equal(t, "other namespace", string(value))
+
+			assert.ErrorIs(t, store.Put(ctx, NamespaceCache, "", nil), ErrInvalidName)
+		})
+	}
+
+	t.Run("invalid sql table", func(t *testing.T) {
+		_, err := NewSQL(ctx, nil, SQLDialectSQLite, "items; DROP TABLE users")
+		assert.ErrorIs(t, err, ErrInvalidSQLTable)
+		_, err = NewSQL(ctx, nil, "oracle", "")
+		assert.ErrorIs(t, err, ErrUnknownSQLDialect)
+	})
+}
@@ -0,0 +1,71 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/beef"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// GetTransactionBEEF will get a transaction in the BEEF format, with its unmined ancestors and the merkle
+// paths (BUMP) of the mined ones, to hand it to an SPV wallet (the server must support FeatureBEEF)
+func (b *BuxClient) GetTransactionBEEF(ctx context.Context, txID string) (*beef.BEEF, error) {
+	if err := b.requireFeature(FeatureBEEF); err != nil {
+		return nil, err
+	}
+	if err := b.requireCapability(CapabilityBEEF, "GetTransactionBEEF"); err != nil {
+		return nil, err
+	}
+
+	transaction, err := b.transport.GetTransactionBEEF(ctx, txID)
+	if err != nil {
+		return nil, err
+	} else if transaction == nil || transaction.BEEF == "" {
+		return nil, transports.ErrMissingBEEF
+	}
+
+	var decoded *beef.BEEF
+	if decoded, err = beef.NewFromHex(transaction.BEEF); err != nil {
+		return nil, err
+	}
+	if subject := decoded.Subject(); subject.Tx.TxID() != txID {
+		return nil, errors.Wrapf(beef.ErrInvalidBEEF, "the subject transaction is %s, expected %s",
+			subject.Tx.TxID(), txID)
+	}
+	return decoded, nil
+}
+
+// RecordTransactionBEEF will record a transaction received in the BEEF format (ex: from an SPV wallet),
+// the server verifies its ancestors with their merkle paths (the server must support FeatureBEEF)
+func (b *BuxClient) RecordTransactionBEEF(ctx context.Context, transaction *beef.BEEF, referenceID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	if err := b.requireFeature(FeatureBEEF); err != nil {
+		return nil, err
+	}
+	if err := b.requireCapability(CapabilityBEEF, "RecordTransactionBEEF"); err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		return nil, &InvalidInputError{Field: "beef", Reason: "is nil"}
+	}
+	encoded, err := transaction.Hex()
+	if err != nil {
+		return nil, &InvalidInputError{Field: "beef", Reason: err.Error()}
+	}
+	if err = b.checkNotFrozen(ctx); err != nil {
+		return nil, err
+	}
+
+	var recorded *bux.Transaction
+	if recorded, err = b.transport.RecordTransactionBEEF(ctx, encoded, referenceID, metadata); err != nil {
+		return nil, err
+	}
+	b.invalidateXPub(ctx)
+	if err = checkRecordedTxID(transaction.Subject().Tx, recorded); err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
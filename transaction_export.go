@@ -0,0 +1,220 @@
+package buxclient
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// Formats of the transaction exports
+const (
+	ExportFormatCSV       ExportFormat = "csv"   // a header and a row per transaction, the metadata as json
+	ExportFormatJSONLines ExportFormat = "jsonl" // a transaction (json) per line
+)
+
+// defaultExportPageSize is the number of transactions fetched per page of an export
+const defaultExportPageSize = 100
+
+// ErrUnknownExportFormat is when the format of the export is not csv or jsonl
+var ErrUnknownExportFormat = errors.New("unknown export format, use csv or jsonl")
+
+// ExportFormat is the format of the transaction exports
+type ExportFormat string
+
+// exportColumns are the columns of the csv exports
+var exportColumns = []string{
+	"id", "created_at", "direction", "status", "output_value", "total_value", "fee", "block_height",
+	"block_hash", "number_of_inputs", "number_of_outputs", "draft_id", "metadata",
+}
+
+// ExportTransactions will write the whole history of the transactions matching the conditions to the
+// writer, fetched page by page (oldest first, by id when created at the same time) and written as they
+// are fetched, it returns the number of exported transactions
+func (b *BuxClient) ExportTransactions(ctx context.Context, conditions map[string]interface{},
+	format ExportFormat, w io.Writer) (int, error) {
+
+	return b.exportTransactions(ctx, conditions, format, w, defaultExportPageSize)
+}
+
+// exportTransactions will export the transactions with pages of the size
+func (b *BuxClient) exportTransactions(ctx context.Context, conditions map[string]interface{},
+	format ExportFormat, w io.Writer, pageSize int) (int, error) {
+
+	var write func(transaction *bux.Transaction) error
+	var flush func() error
+	switch format {
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return 0, err
+		}
+		write = func(transaction *bux.Transaction) error {
+			row, err := exportRow(transaction)
+			if err != nil {
+				return err
+			}
+			return writer.Write(row)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case ExportFormatJSONLines:
+		encoder := json.NewEncoder(w)
+		write = func(transaction *bux.Transaction) error {
+			return encoder.Encode(transaction)
+		}
+		flush = func() error {
+			return nil
+		}
+	default:
+		return 0, ErrUnknownExportFormat
+	}
+
+	exported := 0
+	export := func(transactions []*bux.Transaction) error {
+		for _, transaction := range transactions {
+			if err := write(transaction); err != nil {
+				return err
+			}
+			exported++
+		}
+		return flush()
+	}
+
+	// keyset paging on (created_at, id), the server orders by one field: the pages are ordered by
+	// created_at, and the transactions of the last time of a full page by id
+	var cursor *time.Time
+	for {
+		after := conditions
+		if cursor != nil {
+			after = transports.MergeConditions(exactConditions(conditions), map[string]interface{}{
+				transports.FieldCreatedAt: map[string]interface{}{
+					transports.ConditionGreaterThan: exportTime(*cursor),
+				},
+			})
+		}
+		transactions, err := b.SearchTransactions(ctx, after, nil, &transports.QueryParams{
+			OrderByField:  transports.FieldCreatedAt,
+			Page:          1,
+			PageSize:      pageSize,
+			SortDirection: transports.SortAscending,
+		})
+		if err != nil {
+			return exported, err
+		}
+		if len(transactions) < pageSize {
+			return exported, export(transactions)
+		}
+
+		last := transactions[len(transactions)-1].CreatedAt
+		before := 0
+		for before < len(transactions) && transactions[before].CreatedAt.Before(last) {
+			before++
+		}
+		if err = export(transactions[:before]); err != nil {
+			return exported, err
+		}
+		if err = b.exportTies(ctx, conditions, last, pageSize, export); err != nil {
+			return exported, err
+		}
+		cursor = &last
+	}
+}
+
+// exportTies will export the transactions created at the time, ordered by id
+func (b *BuxClient) exportTies(ctx context.Context, conditions map[string]interface{}, createdAt time.Time,
+	pageSize int, export func(transactions []*bux.Transaction) error) error {
+
+	at := transports.MergeConditions(exactConditions(conditions), map[string]interface{}{
+		transports.FieldCreatedAt: map[string]interface{}{
+			transports.ConditionGreaterThanOrEqual: exportTime(createdAt),
+			transports.ConditionLessThanOrEqual:    exportTime(createdAt),
+		},
+	})
+	for {
+		transactions, err := b.SearchTransactions(ctx, at, nil, &transports.QueryParams{
+			OrderByField:  "id",
+			Page:          1,
+			PageSize:      pageSize,
+			SortDirection: transports.SortAscending,
+		})
+		if err != nil {
+			return err
+		}
+		if err = export(transactions); err != nil {
+			return err
+		}
+		if len(transactions) < pageSize {
+			return nil
+		}
+		at = transports.MergeConditions(at, map[string]interface{}{
+			"id": map[string]interface{}{transports.ConditionGreaterThan: transactions[len(transactions)-1].ID},
+		})
+	}
+}
+
+// exactConditions will return a copy of the conditions with the values of created_at and id as
+// operators, so the paging conditions of the fields are merged with them
+func exactConditions(conditions map[string]interface{}) map[string]interface{} {
+	exact := transports.MergeConditions(conditions)
+	for _, field := range []string{transports.FieldCreatedAt, "id"} {
+		value, ok := exact[field]
+		if _, isOperators := value.(map[string]interface{}); ok && !isOperators {
+			exact[field] = map[string]interface{}{
+				transports.ConditionGreaterThanOrEqual: value,
+				transports.ConditionLessThanOrEqual:    value,
+			}
+		}
+	}
+	return exact
+}
+
+// exportTime will return the time of a paging condition
+func exportTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339Nano)
+}
+
+// exportRow will return the csv row of the transaction, in the order of the exportColumns
+func exportRow(transaction *bux.Transaction) ([]string, error) {
+	metadata := ""
+	if len(transaction.Metadata) > 0 {
+		data, err := json.Marshal(transaction.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = string(data)
+	}
+	return []string{
+		escapeCell(transaction.ID),
+		transaction.CreatedAt.UTC().Format(time.RFC3339),
+		escapeCell(string(transaction.Direction)),
+		escapeCell(string(transaction.Status)),
+		strconv.FormatInt(transaction.OutputValue, 10),
+		strconv.FormatUint(transaction.TotalValue, 10),
+		strconv.FormatUint(transaction.Fee, 10),
+		strconv.FormatUint(transaction.BlockHeight, 10),
+		escapeCell(transaction.BlockHash),
+		strconv.FormatUint(uint64(transaction.NumberOfInputs), 10),
+		strconv.FormatUint(uint64(transaction.NumberOfOutputs), 10),
+		escapeCell(transaction.DraftID),
+		escapeCell(metadata),
+	}, nil
+}
+
+// escapeCell will prefix the text cell with a quote when it starts like a formula (=, +, -, @, tab or
+// carriage return), so the spreadsheets opening the export do not evaluate it
+func escapeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,165 @@
+package buxclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/buxtest"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportTransactions will test the method ExportTransactions()
+func TestExportTransactions(t *testing.T) {
+	ctx := context.Background()
+	server := buxtest.NewServer()
+	defer server.Close()
+
+	xPriv, xPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	var funded []string
+	for _, satoshis := range []uint64{1000, 2000, 3000, 4000, 5000} {
+		transaction, fundErr := server.Fund(xPub, satoshis)
+		require.NoError(t, fundErr)
+		funded = append(funded, transaction.ID)
+	}
+	client, err := New(WithXPriv(xPriv), WithHTTP(server.URL))
+	require.NoError(t, err)
+
+	t.Run("csv", func(t *testing.T) {
+		var buffer bytes.Buffer
+		exported, err := client.exportTransactions(ctx, nil, ExportFormatCSV, &buffer, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 5, exported)
+
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 6)
+		assert.Equal(t, exportColumns, rows[0])
+		ids := make([]string, 0, len(funded))
+		for _, row := range rows[1:] {
+			require.Len(t, row, len(exportColumns))
+			ids = append(ids, row[0])
+			assert.Equal(t, string(bux.TransactionDirectionIn), row[2])
+		}
+		assert.ElementsMatch(t, funded, ids)
+	})
+
+	t.Run("json lines", func(t *testing.T) {
+		var buffer bytes.Buffer
+		exported, err := client.ExportTransactions(ctx, map[string]interface{}{"id": funded[2]},
+			ExportFormatJSONLines, &buffer)
+		require.NoError(t, err)
+		assert.Equal(t, 1, exported)
+
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		require.Len(t, lines, 1)
+		var transaction bux.Transaction
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &transaction))
+		assert.Equal(t, funded[2], transaction.ID)
+		assert.Equal(t, int64(3000), transaction.OutputValue)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		var buffer bytes.Buffer
+		_, err := client.ExportTransactions(ctx, nil, "xml", &buffer)
+		assert.ErrorIs(t, err, ErrUnknownExportFormat)
+		assert.Zero(t, buffer.Len())
+	})
+}
+
+// TestExportTransactionsPaging will test the paging of the exports on (created_at, id), the server
+// returning the transactions created at the same time in any order
+func TestExportTransactionsPaging(t *testing.T) {
+	base := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	var transactions []*bux.Transaction
+	for index, createdAt := range []time.Time{
+		base, base.Add(time.Second), base.Add(time.Second), base.Add(time.Second), base.Add(time.Second),
+		base.Add(time.Second), base.Add(2 * time.Second),
+	} {
+		transaction := &bux.Transaction{OutputValue: -1000}
+		transaction.ID = fmt.Sprintf("tx-%d", index)
+		transaction.CreatedAt = createdAt
+		transactions = append(transactions, transaction)
+	}
+	transactions[3].DraftID = "=HYPERLINK(\"http://example.com\")"
+	transactions[3].Status = "@SUM(1+1)"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions/search", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Conditions map[string]map[string]string `json:"conditions"`
+			Params     transports.QueryParams       `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		var page []*bux.Transaction
+		for _, transaction := range transactions {
+			createdAt := transaction.CreatedAt.Format(time.RFC3339Nano)
+			matched := true
+			for operator, operand := range body.Conditions[transports.FieldCreatedAt] {
+				switch operator {
+				case transports.ConditionGreaterThan:
+					matched = matched && createdAt > operand
+				case transports.ConditionGreaterThanOrEqual:
+					matched = matched && createdAt >= operand
+				case transports.ConditionLessThanOrEqual:
+					matched = matched && createdAt <= operand
+				}
+			}
+			if after, ok := body.Conditions["id"][transports.ConditionGreaterThan]; ok {
+				matched = matched && transaction.ID > after
+			}
+			if matched {
+				page = append(page, transaction)
+			}
+		}
+		// the transactions created at the same time are in no particular order
+		sort.SliceStable(page, func(i, j int) bool {
+			if body.Params.OrderByField == "id" {
+				return page[i].ID < page[j].ID
+			}
+			if page[i].CreatedAt.Equal(page[j].CreatedAt) {
+				return page[i].ID > page[j].ID
+			}
+			return page[i].CreatedAt.Before(page[j].CreatedAt)
+		})
+		if len(page) > body.Params.PageSize {
+			page = page[:body.Params.PageSize]
+		}
+		writeTestJSON(t, w, page)
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+		WithoutCapabilities(),
+	)
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	exported, err := client.exportTransactions(context.Background(), nil, ExportFormatCSV, &buffer, 2)
+	require.NoError(t, err)
+	assert.Equal(t, len(transactions), exported)
+
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(transactions)+1)
+	for index, row := range rows[1:] {
+		assert.Equal(t, transactions[index].ID, row[0])
+		assert.Equal(t, "-1000", row[4])
+	}
+
+	// the cells starting like a formula are escaped
+	assert.Equal(t, "'@SUM(1+1)", rows[4][3])
+	assert.Equal(t, "'=HYPERLINK(\"http://example.com\")", rows[4][11])
+}
@@ -0,0 +1,53 @@
+package buxclient
+
+import (
+	"context"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTransactionProof is when a transaction proof does not prove the transaction
+var ErrInvalidTransactionProof = errors.New("invalid transaction proof")
+
+// TransactionProof is the SPV data of a mined transaction: its merkle proof (TSC format) and the
+// header of its block
+type TransactionProof struct {
+	BlockHeader *transports.BlockHeader `json:"block_header"`
+	MerkleProof *transports.MerkleProof `json:"merkle_proof"`
+}
+
+// GetTransactionProof will get the merkle proof of a mined transaction and the header of its block,
+// to verify the transaction independently of the bux server (see TransactionProof.Verify)
+func (b *BuxClient) GetTransactionProof(ctx context.Context, txID string) (*TransactionProof, error) {
+	merkleProof, err := b.GetMerkleProof(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if merkleProof == nil || merkleProof.Target == "" {
+		return nil, ErrTransactionNotMined
+	}
+
+	var blockHeader *transports.BlockHeader
+	if blockHeader, err = b.GetBlockHeader(ctx, merkleProof.Target); err != nil {
+		return nil, err
+	}
+	return &TransactionProof{BlockHeader: blockHeader, MerkleProof: merkleProof}, nil
+}
+
+// Verify will verify that the raw transaction (hex) is in the block of the proof: the transaction must
+// hash to the ID of the proof, the merkle proof must lead to the merkle root of the header, and the
+// header must hash to the block hash and satisfy its proof of work. Checking that the block is on the
+// main chain (ex: with a block headers service) is left to the caller.
+func (p *TransactionProof) Verify(rawTx string) error {
+	if p.MerkleProof == nil || p.BlockHeader == nil {
+		return ErrInvalidTransactionProof
+	}
+	if err := verifyTransactionProof(
+		&ProofBundleTransaction{TxID: p.MerkleProof.TxID, BlockHash: p.BlockHeader.Hash},
+		[]byte(rawTx), p.MerkleProof, []byte(p.BlockHeader.Header),
+	); err != nil {
+		return ErrInvalidTransactionProof
+	}
+	return nil
+}
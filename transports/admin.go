@@ -0,0 +1,36 @@
+package transports
+
+import "github.com/libsv/go-bk/bip32"
+
+// Operations that need the admin key
+const (
+	OperationAdminCreatePaymail = "AdminCreatePaymail"
+	OperationAdminDeletePaymail = "AdminDeletePaymail"
+	OperationAdminFreezeXPub    = "AdminFreezeXPub"
+	OperationAdminGetPaymails   = "AdminGetPaymails"
+	OperationAdminUnfreezeXPub  = "AdminUnfreezeXPub"
+	OperationRegisterXpub       = "RegisterXpub"
+)
+
+// adminOperations are all the operations that need the admin key
+var adminOperations = map[string]bool{
+	OperationAdminCreatePaymail: true,
+	OperationAdminDeletePaymail: true,
+	OperationAdminFreezeXPub:    true,
+	OperationAdminGetPaymails:   true,
+	OperationAdminUnfreezeXPub:  true,
+	OperationRegisterXpub:       true,
+}
+
+// RequiresAdmin will return whether the operation (the name of the client method) needs the admin key
+func RequiresAdmin(operation string) bool {
+	return adminOperations[operation]
+}
+
+// checkAdminKey will return an AdminKeyError for the operation if the admin key is not set
+func checkAdminKey(adminXPriv *bip32.ExtendedKey, operation string) error {
+	if adminXPriv == nil {
+		return &AdminKeyError{Operation: operation}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package transports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// OperationClass is a class of requests isolated in its own bulkhead (see WithBulkheads)
+type OperationClass string
+
+// Operation classes
+const (
+	// OperationClassWrite is the requests changing the data of the server (drafts, records, metadata updates...)
+	OperationClassWrite OperationClass = "write"
+
+	// OperationClassRead is the requests only reading the data of the server (graphql queries, searches...)
+	OperationClassRead OperationClass = "read"
+
+	// OperationClassStream is the long-lived connections (notification streams and subscriptions)
+	OperationClassStream OperationClass = "stream"
+)
+
+// ErrBulkheadFull is when a request could not get a slot in the bulkhead of its operation class in time
+var ErrBulkheadFull = errors.New("the bulkhead of the operation class is full")
+
+// ErrBulkheadPoolNotSupported is when a bulkhead limits the connections with an http client that does
+// not use an *http.Transport
+var ErrBulkheadPoolNotSupported = errors.New("the connections of a bulkhead can only be limited on http clients using an *http.Transport")
+
+// BulkheadFullError is returned (without calling the server) when the bulkhead of the operation class
+// is full, it matches ErrBulkheadFull with errors.Is
+type BulkheadFullError struct {
+	Class OperationClass
+}
+
+// Error will return the error message, with the operation class
+func (e *BulkheadFullError) Error() string {
+	return ErrBulkheadFull.Error() + ": " + string(e.Class)
+}
+
+// Is will return whether the target is ErrBulkheadFull
+func (e *BulkheadFullError) Is(target error) bool {
+	return target == ErrBulkheadFull
+}
+
+// Bulkhead is the isolation of an operation class: its requests use their own connection pool, and
+// get a slot in the bulkhead for their duration (the lifetime of the connection for the streams)
+type Bulkhead struct {
+	MaxConcurrent   int           // requests of the class at the same time, 0 for no limit
+	MaxConnsPerHost int           // connections of the pool of the class, 0 for no limit
+	MaxWait         time.Duration // wait for a free slot, 0 waits until the context is done
+}
+
+// WithBulkheads will isolate the operation classes from each other (ex: failing subscriptions
+// cannot exhaust the connections used to record transactions): every class gets its own connection
+// pool (when the http client uses an *http.Transport) and the limits of its bulkhead, the classes
+// without a bulkhead only get their own pool
+func WithBulkheads(bulkheads map[OperationClass]*Bulkhead) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.bulkheads = bulkheads
+		}
+	}
+}
+
+// bulkheads are the slots of the operation classes, shared by the requests and the subscriptions
+type bulkheads struct {
+	configs   map[OperationClass]*Bulkhead
+	scheduler scheduler.Scheduler
+	slots     map[OperationClass]chan struct{}
+}
+
+// newBulkheads will create the slots of the bulkheads that limit the concurrent requests
+func newBulkheads(configs map[OperationClass]*Bulkhead, scheduler scheduler.Scheduler) *bulkheads {
+	b := &bulkheads{
+		configs:   configs,
+		scheduler: scheduler,
+		slots:     make(map[OperationClass]chan struct{}),
+	}
+	for class, config := range configs {
+		if config != nil && config.MaxConcurrent > 0 {
+			b.slots[class] = make(chan struct{}, config.MaxConcurrent)
+		}
+	}
+	return b
+}
+
+// acquire will wait for a slot of the operation class, the returned function releases it
+func (b *bulkheads) acquire(ctx context.Context, class OperationClass) (func(), error) {
+	slots, ok := b.slots[class]
+	if !ok {
+		return func() {}, nil
+	}
+	release := func() {
+		<-slots
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if maxWait := b.configs[class].MaxWait; maxWait > 0 {
+		timeout = b.scheduler.After(maxWait)
+	}
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, &BulkheadFullError{Class: class}
+	}
+}
+
+// wrapper will return the wrap function routing the requests of every class to its own pool
+func (b *bulkheads) wrapper(err *error) func(TransportType, http.RoundTripper) http.RoundTripper {
+	return func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+		httpTransport, isHTTPTransport := next.(*http.Transport)
+		pools := make(map[OperationClass]http.RoundTripper)
+		for _, class := range []OperationClass{OperationClassWrite, OperationClassRead, OperationClassStream} {
+			config := b.configs[class]
+			if !isHTTPTransport {
+				if config != nil && config.MaxConnsPerHost > 0 {
+					*err = ErrBulkheadPoolNotSupported
+				}
+				pools[class] = next
+				continue
+			}
+
+			pool := httpTransport.Clone()
+			if config != nil && config.MaxConnsPerHost > 0 {
+				pool.MaxConnsPerHost = config.MaxConnsPerHost
+			}
+			pools[class] = pool
+		}
+		return &bulkheadRoundTripper{bulkheads: b, pools: pools, transport: transport}
+	}
+}
+
+// bulkheadRoundTripper sends the requests through the bulkhead and the pool of their operation class
+type bulkheadRoundTripper struct {
+	bulkheads *bulkheads
+	pools     map[OperationClass]http.RoundTripper
+	transport TransportType
+}
+
+// RoundTrip will execute the request when its operation class has a free slot, the slot is released
+// when the response body is closed
+func (b *bulkheadRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	class := requestOperationClass(b.transport, req)
+	release, err := b.bulkheads.acquire(req.Context(), class)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.pools[class].RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: release}
+	return resp, nil
+}
+
+// requestOperationClass will return the operation class of the request
+func requestOperationClass(transport TransportType, req *http.Request) OperationClass {
+	switch {
+	case req.Header.Get("Accept") == eventStreamContentType:
+		return OperationClassStream
+	case isReadRequest(transport, req):
+		return OperationClassRead
+	default:
+		return OperationClassWrite
+	}
+}
@@ -0,0 +1,142 @@
+package transports
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BuxOrg/bux"
+)
+
+// maxConditionalEntries is the number of responses kept for the conditional requests, the oldest are
+// evicted first
+const maxConditionalEntries = 1024
+
+// WithConditionalRequests will send the GET requests with the ETag (If-None-Match) or the last
+// modification time (If-Modified-Since) of the previous response of the same request, so polling a
+// resource that did not change returns a 304 Not Modified without a body, the previous response is
+// returned instead. Ignored by the graphql transport.
+func WithConditionalRequests() ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.conditionalRequests = true
+		}
+	}
+}
+
+// conditionalEntry is the previous response of a GET request
+type conditionalEntry struct {
+	body         []byte
+	etag         string
+	header       http.Header
+	lastModified string
+}
+
+// conditionalRoundTripper sends the GET requests conditionally, and returns the previous response
+// when the server answers that it did not change
+type conditionalRoundTripper struct {
+	entries map[string]*conditionalEntry
+	headers http.Header // custom headers of the client, part of the key of the responses
+	mu      sync.Mutex
+	next    http.RoundTripper
+	order   []string // keys of the entries, oldest first
+}
+
+// RoundTrip will send the GET requests with the validators of the previous response, and return the
+// previous response on 304 Not Modified
+func (c *conditionalRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.next.RoundTrip(req)
+	}
+
+	key := c.key(req)
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		_ = resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = entry.header.Clone()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(entry.body))
+		resp.ContentLength = int64(len(entry.body))
+		return resp, nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || etag == "" && lastModified == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.store(key, &conditionalEntry{
+		body:         body,
+		etag:         etag,
+		header:       resp.Header.Clone(),
+		lastModified: lastModified,
+	})
+	return resp, nil
+}
+
+// store will keep the response of the key, evicting the oldest when full
+func (c *conditionalRoundTripper) store(key string, entry *conditionalEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+	for len(c.order) > maxConditionalEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// key will return the key of the response of the request: the url, the identity of the client and
+// the custom headers (ex: the tenant), the responses of other clients are never returned
+func (c *conditionalRoundTripper) key(req *http.Request) string {
+	custom := make(http.Header)
+	for name, values := range c.headers {
+		custom[name] = values
+	}
+	for name, values := range HeadersFromContext(req.Context()) {
+		custom[name] = values
+	}
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{
+		req.URL.String(),
+		req.Header.Get(bux.AuthHeader),
+		req.Header.Get(bux.AuthAccessKey),
+	}
+	for _, name := range names {
+		parts = append(parts, name+":"+strings.Join(custom[name], ","))
+	}
+	return strings.Join(parts, "\n")
+}
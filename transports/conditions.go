@@ -0,0 +1,78 @@
+package transports
+
+import "time"
+
+// Time fields of the models, to filter them by time range
+const (
+	FieldCreatedAt = "created_at"
+	FieldUpdatedAt = "updated_at"
+)
+
+// Operators of the conditions of the bux server, ex: {"fee": {"$lt": 100}}
+const (
+	ConditionGreaterThan        = "$gt"
+	ConditionGreaterThanOrEqual = "$gte"
+	ConditionLessThan           = "$lt"
+	ConditionLessThanOrEqual    = "$lte"
+)
+
+// Since will return the condition of the records of which the time field is at or after since
+func Since(field string, since time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		field: map[string]interface{}{
+			ConditionGreaterThanOrEqual: conditionTime(since),
+		},
+	}
+}
+
+// Until will return the condition of the records of which the time field is before until (excluded)
+func Until(field string, until time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		field: map[string]interface{}{
+			ConditionLessThan: conditionTime(until),
+		},
+	}
+}
+
+// Between will return the condition of the records of which the time field is in [from, to), so
+// consecutive ranges do not overlap
+func Between(field string, from, to time.Time) map[string]interface{} {
+	return MergeConditions(Since(field, from), Until(field, to))
+}
+
+// MergeConditions will return the conditions combined (all must match), the operators of a field are
+// merged (ex: Since and Until of created_at), any other value of a field replaces the previous one
+func MergeConditions(conditions ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, condition := range conditions {
+		for field, value := range condition {
+			operators, isOperators := value.(map[string]interface{})
+			current, hasOperators := merged[field].(map[string]interface{})
+			if !isOperators || !hasOperators {
+				if isOperators {
+					value = copyOperators(operators)
+				}
+				merged[field] = value
+				continue
+			}
+			for operator, operand := range operators {
+				current[operator] = operand
+			}
+		}
+	}
+	return merged
+}
+
+// copyOperators will return a copy of the operators of a field, so merging does not modify them
+func copyOperators(operators map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(operators))
+	for operator, operand := range operators {
+		copied[operator] = operand
+	}
+	return copied
+}
+
+// conditionTime will return the time as expected by the server in the conditions: RFC 3339 in UTC
+func conditionTime(value time.Time) string {
+	return value.UTC().Format(time.RFC3339Nano)
+}
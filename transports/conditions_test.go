@@ -0,0 +1,46 @@
+package transports
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeRangeConditions will test the methods Since(), Until() and Between()
+func TestTimeRangeConditions(t *testing.T) {
+	from := time.Date(2022, 2, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	to := from.Add(24 * time.Hour)
+
+	assert.Equal(t, map[string]interface{}{
+		FieldCreatedAt: map[string]interface{}{"$gte": "2022-02-01T09:00:00Z"},
+	}, Since(FieldCreatedAt, from))
+	assert.Equal(t, map[string]interface{}{
+		FieldUpdatedAt: map[string]interface{}{"$lt": "2022-02-02T09:00:00Z"},
+	}, Until(FieldUpdatedAt, to))
+
+	data, err := json.Marshal(Between(FieldCreatedAt, from, to))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"created_at":{"$gte":"2022-02-01T09:00:00Z","$lt":"2022-02-02T09:00:00Z"}}`, string(data))
+}
+
+// TestMergeConditions will test the method MergeConditions()
+func TestMergeConditions(t *testing.T) {
+	since := Since(FieldCreatedAt, time.Unix(0, 0))
+	merged := MergeConditions(
+		since,
+		map[string]interface{}{"status": "complete", "fee": map[string]interface{}{"$lt": 100}},
+		Until(FieldCreatedAt, time.Unix(60, 0)),
+		map[string]interface{}{"status": "mined"},
+	)
+	assert.Equal(t, map[string]interface{}{
+		FieldCreatedAt: map[string]interface{}{"$gte": "1970-01-01T00:00:00Z", "$lt": "1970-01-01T00:01:00Z"},
+		"fee":          map[string]interface{}{"$lt": 100},
+		"status":       "mined",
+	}, merged)
+
+	// the conditions are not modified
+	assert.Equal(t, Since(FieldCreatedAt, time.Unix(0, 0)), since)
+}
@@ -1,10 +1,133 @@
 package transports
 
-import "github.com/BuxOrg/bux"
+import (
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/events"
+)
 
 // Recipients is a struct for recipients
 type Recipients struct {
 	To       string
 	Satoshis uint64
 	OpReturn *bux.OpReturn
+	Script   string // locking script (hex) of the output, instead of To (ex: contracts, non-standard scripts)
+}
+
+// RecordRequest is a transaction to record with RecordTransactions
+type RecordRequest struct {
+	Hex         string        // signed transaction
+	Metadata    *bux.Metadata // metadata of the recorded transaction
+	ReferenceID string        // id of the draft of the transaction, if any
+}
+
+// PaymailAddress is a paymail handle registered on the bux server
+type PaymailAddress struct {
+	bux.Model
+
+	ID         string `json:"id"`
+	XpubID     string `json:"xpub_id"`
+	Alias      string `json:"alias"`
+	Domain     string `json:"domain"`
+	PublicName string `json:"public_name"`
+	Avatar     string `json:"avatar"`
+}
+
+// SortDirection is the order of the records of a listing, by the OrderByField of the query params
+type SortDirection string
+
+// Sort directions of the listings
+const (
+	SortAscending  SortDirection = "asc"
+	SortDescending SortDirection = "desc"
+)
+
+// QueryParams are the paging and sorting parameters for listing requests
+type QueryParams struct {
+	OrderByField  string        `json:"order_by_field,omitempty"` // ex: created_at (the server default when empty)
+	Page          int           `json:"page,omitempty"`
+	PageSize      int           `json:"page_size,omitempty"`
+	SortDirection SortDirection `json:"sort_direction,omitempty"`
+}
+
+// NewestFirst will return the query params of a page of the records, the most recently created first
+func NewestFirst(page, pageSize int) *QueryParams {
+	return &QueryParams{
+		OrderByField:  "created_at",
+		Page:          page,
+		PageSize:      pageSize,
+		SortDirection: SortDescending,
+	}
+}
+
+// Webhook is a webhook endpoint registered on the bux server
+type Webhook struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []events.EventType `json:"event_types"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// MerkleProof is the merkle proof of a transaction in a block (TSC format)
+type MerkleProof struct {
+	Index  uint64   `json:"index"`  // index of the transaction in the block
+	TxID   string   `json:"tx_id"`  // id of the transaction
+	Target string   `json:"target"` // hash of the block
+	Nodes  []string `json:"nodes"`  // hashes of the branch from the transaction to the root, "*" duplicates the current hash
+}
+
+// BlockHeader is the header of a block
+type BlockHeader struct {
+	Hash   string `json:"hash"`
+	Height uint64 `json:"height"`
+	Header string `json:"header"` // raw 80 bytes header, hex encoded
+}
+
+// FeeQuote is the fee policy of the bux server, used to draft the transactions
+type FeeQuote struct {
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"` // the quote may change after this time
+	FeeUnit   *utils.FeeUnit `json:"fee_unit"`
+	Miner     string         `json:"miner,omitempty"`
+}
+
+// Fee will return the fee of a transaction of the size (bytes), rounded up like the bux server
+func (q *FeeQuote) Fee(size int) uint64 {
+	if q == nil || q.FeeUnit == nil || q.FeeUnit.Bytes <= 0 || size <= 0 {
+		return 0
+	}
+	return (uint64(size)*uint64(q.FeeUnit.Satoshis) + uint64(q.FeeUnit.Bytes) - 1) / uint64(q.FeeUnit.Bytes)
+}
+
+// TransactionBEEF is a transaction in the BEEF format (BRC-62), with its unmined ancestors and the
+// merkle paths of the mined ones
+type TransactionBEEF struct {
+	TxID string `json:"tx_id"`
+	BEEF string `json:"beef"` // hex encoded
+}
+
+// TransactionStatus is the status of a transaction on the network, as seen by the bux server
+type TransactionStatus struct {
+	TxID           string `json:"tx_id"`
+	SeenOnNetwork  bool   `json:"seen_on_network"` // accepted by the miners (in their mempool or mined)
+	Mined          bool   `json:"mined"`
+	BlockHash      string `json:"block_hash,omitempty"`
+	BlockHeight    uint64 `json:"block_height,omitempty"`
+	DoubleSpend    bool   `json:"double_spend"`              // an input is spent by another transaction
+	RejectedReason string `json:"rejected_reason,omitempty"` // empty if the transaction was not rejected
+}
+
+// Rejected will return whether the transaction was rejected by the network (ex: double spend)
+func (s *TransactionStatus) Rejected() bool {
+	return s.DoubleSpend || s.RejectedReason != ""
+}
+
+// XPubStatus is the status of an xPub on the bux server, a frozen xPub can not make transactions
+// (ex: compliance hold)
+type XPubStatus struct {
+	ID       string     `json:"id"`
+	Frozen   bool       `json:"frozen"`
+	FrozenAt *time.Time `json:"frozen_at,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
 }
@@ -0,0 +1,110 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/machinebox/graphql"
+)
+
+// CountModel is a model of which the records can be counted, the name of its listing
+type CountModel string
+
+// Models that can be counted
+const (
+	CountAccessKeys        CountModel = "access_keys"
+	CountDestinations      CountModel = "destinations"
+	CountDraftTransactions CountModel = "draft_transactions"
+	CountTransactions      CountModel = "transactions"
+	CountUtxos             CountModel = "utxos"
+)
+
+// countPaths are the http paths of the counts of the models
+var countPaths = map[CountModel]string{
+	CountAccessKeys:        "/access-keys/count",
+	CountDestinations:      "/destinations/count",
+	CountDraftTransactions: "/draft-transactions/count",
+	CountTransactions:      "/transactions/count",
+	CountUtxos:             "/utxos/count",
+}
+
+// ErrUnknownCountModel is when the model of a count is not one of the models that can be counted
+var ErrUnknownCountModel = errors.New("unknown count model")
+
+// Count will count the records of the model matching the conditions and metadata, without fetching them
+func (h *TransportHTTP) Count(ctx context.Context, model CountModel, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	path, ok := countPaths[model]
+	if !ok {
+		return 0, ErrUnknownCountModel
+	}
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = h.doHTTPRequest(ctx, "POST", path, jsonStr, h.keys().xPriv, h.signRequest, &count)
+	if err != nil {
+		return 0, err
+	}
+	if h.debug {
+		h.logger.Debug("count", logging.F("model", string(model)), logging.F("count", count))
+	}
+
+	return count, nil
+}
+
+// Count will count the records of the model matching the conditions and metadata, without fetching them
+func (g *TransportGraphQL) Count(ctx context.Context, model CountModel, conditions map[string]interface{},
+	metadata *bux.Metadata) (int64, error) {
+
+	if _, ok := countPaths[model]; !ok {
+		return 0, ErrUnknownCountModel
+	}
+
+	// the query is one of the known models, never a value of the caller
+	query := string(model) + "_count"
+	reqBody := `
+   	query ($conditions: Map, $metadata: Map) {
+	  ` + query + `(
+		conditions: $conditions
+		metadata: $metadata
+	  )
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return 0, err
+	}
+
+	// run it and capture the response
+	var respData map[string]int64
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return 0, err
+	}
+	count := respData[query]
+	if g.debug {
+		g.logger.Debug("count", logging.F("model", string(model)), logging.F("count", count))
+	}
+
+	return count, nil
+}
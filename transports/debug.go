@@ -0,0 +1,17 @@
+package transports
+
+import (
+	"reflect"
+
+	"github.com/BuxOrg/go-buxclient/logging"
+)
+
+// debugResult will log the result of a request, the fields are only read when the result is not nil
+// (ex: the server answered with an empty body), so the debug logs cannot dereference a nil result
+func debugResult(logger logging.Logger, msg string, result interface{}, fields func() []logging.Field) {
+	if value := reflect.ValueOf(result); !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+		logger.Debug(msg, logging.F("result", "empty"))
+		return
+	}
+	logger.Debug(msg, fields()...)
+}
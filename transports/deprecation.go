@@ -0,0 +1,146 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/BuxOrg/go-buxclient/logging"
+)
+
+// Deprecation is a deprecation warning of the bux server: from the Deprecation, Sunset and Link headers
+// (rel="deprecation"), the Warning headers (code 299) or the deprecations of the graphql extensions
+type Deprecation struct {
+	Link      string `json:"link,omitempty"`      // documentation of the deprecation
+	Message   string `json:"message"`             // ex: the field that will be removed
+	Operation string `json:"operation,omitempty"` // graphql operation, or method and path of the request
+	Sunset    string `json:"sunset,omitempty"`    // when it will be removed (ex: an http date)
+}
+
+// DeprecationHandler is called with the deprecation warnings of the server, once per distinct warning
+type DeprecationHandler func(ctx context.Context, deprecation *Deprecation)
+
+// WithDeprecationHandler will call the handler with the deprecation warnings of the server responses (once
+// per distinct warning), instead of logging them as warnings
+func WithDeprecationHandler(handler DeprecationHandler) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.deprecationHandler = handler
+		}
+	}
+}
+
+// warningRegex matches the text of a Warning header (ex: 299 - "the field is deprecated")
+var warningRegex = regexp.MustCompile(`^\s*299\s+\S+\s+"((?:[^"\\]|\\.)*)"`)
+
+// linkDeprecationRegex matches the url of the deprecation in a Link header
+var linkDeprecationRegex = regexp.MustCompile(`<([^>]*)>\s*;[^,]*rel="?deprecation"?`)
+
+// deprecationRoundTripper reports the deprecation warnings of the responses
+type deprecationRoundTripper struct {
+	handler   DeprecationHandler
+	mu        sync.Mutex
+	next      http.RoundTripper
+	reported  map[Deprecation]bool
+	transport TransportType
+}
+
+// RoundTrip will execute the request, and report the deprecation warnings of the response
+func (d *deprecationRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	operation := requestOperation(d.transport, req)
+	deprecations := headerDeprecations(resp.Header)
+	if d.transport == BuxTransportGraphQL && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var body []byte
+		if body, err = readResponseBody(resp); err != nil {
+			return nil, err
+		}
+		deprecations = append(deprecations, extensionDeprecations(body)...)
+	}
+	for _, deprecation := range deprecations {
+		deprecation.Operation = operation
+		d.report(req.Context(), deprecation)
+	}
+	return resp, nil
+}
+
+// report will call the handler with the deprecation, if it was not reported yet
+func (d *deprecationRoundTripper) report(ctx context.Context, deprecation *Deprecation) {
+	d.mu.Lock()
+	reported := d.reported[*deprecation]
+	d.reported[*deprecation] = true
+	d.mu.Unlock()
+
+	if !reported {
+		d.handler(ctx, deprecation)
+	}
+}
+
+// logDeprecation will return the handler logging the deprecations as warnings
+func logDeprecation(logger logging.Logger) DeprecationHandler {
+	return func(_ context.Context, deprecation *Deprecation) {
+		logger.Warn("bux server deprecation: "+deprecation.Message,
+			logging.F("operation", deprecation.Operation),
+			logging.F("sunset", deprecation.Sunset),
+			logging.F("link", deprecation.Link),
+		)
+	}
+}
+
+// headerDeprecations will return the deprecations of the headers of a response
+func headerDeprecations(header http.Header) []*Deprecation {
+	var deprecations []*Deprecation
+	if value := header.Get("Deprecation"); value != "" && value != "false" {
+		deprecation := &Deprecation{Message: "deprecated", Sunset: header.Get("Sunset")}
+		if value != "true" {
+			deprecation.Message = "deprecated since " + value
+		}
+		for _, link := range header.Values("Link") {
+			if match := linkDeprecationRegex.FindStringSubmatch(link); match != nil {
+				deprecation.Link = match[1]
+				break
+			}
+		}
+		deprecations = append(deprecations, deprecation)
+	}
+	for _, warning := range header.Values("Warning") {
+		if match := warningRegex.FindStringSubmatch(warning); match != nil {
+			deprecations = append(deprecations, &Deprecation{
+				Message: strings.ReplaceAll(match[1], `\"`, `"`),
+				Sunset:  header.Get("Sunset"),
+			})
+		}
+	}
+	return deprecations
+}
+
+// extensionDeprecations will return the deprecations of the extensions of a graphql response
+// (ex: {"extensions":{"deprecations":[{"message":"...","sunset":"2024-01-01"}]}})
+func extensionDeprecations(body []byte) []*Deprecation {
+	var response struct {
+		Extensions struct {
+			Deprecations []*Deprecation `json:"deprecations"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	deprecations := make([]*Deprecation, 0, len(response.Extensions.Deprecations))
+	for _, deprecation := range response.Extensions.Deprecations {
+		if deprecation != nil && deprecation.Message != "" {
+			deprecations = append(deprecations, &Deprecation{
+				Link:    deprecation.Link,
+				Message: deprecation.Message,
+				Sunset:  deprecation.Sunset,
+			})
+		}
+	}
+	return deprecations
+}
@@ -0,0 +1,125 @@
+package transports
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// defaultDiscoveryInterval is the time after which the server URLs are discovered again, by default
+const defaultDiscoveryInterval = time.Minute
+
+// ErrNoServerDiscovered is when the discovery did not return any server URL
+var ErrNoServerDiscovered = errors.New("no bux server discovered")
+
+// DiscoverFunc returns the URLs of the bux servers (same paths as the server), the preferred first
+type DiscoverFunc func(ctx context.Context) ([]string, error)
+
+// WithDiscovery will send the requests to the server discovered by the function (ex: SRVDiscovery) instead
+// of the server URL of the client, the discovery is refreshed at most once per interval (1 minute by
+// default) and the last discovered server is kept when it fails. The subscriptions are not rerouted.
+func WithDiscovery(discover DiscoverFunc, refreshInterval time.Duration) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.discover = discover
+			c.discoveryInterval = refreshInterval
+		}
+	}
+}
+
+// SRVDiscovery will return the discovery of the servers from the DNS SRV records of the service (ex:
+// _bux._tcp.example.com), in the order of their priority and weight, the URLs are built with the
+// scheme and the path (ex: https, /v1). The default resolver is used when the resolver is nil.
+func SRVDiscovery(resolver *net.Resolver, service, proto, name, scheme, path string) DiscoverFunc {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := resolver.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+		return srvURLs(records, scheme, path), nil
+	}
+}
+
+// srvURLs will return the URLs of the SRV records
+func srvURLs(records []*net.SRV, scheme, path string) []string {
+	urls := make([]string, 0, len(records))
+	for _, record := range records {
+		host := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		urls = append(urls, scheme+"://"+host+path)
+	}
+	return urls
+}
+
+// discoveryRoundTripper sends the requests of the server of the client to the discovered server
+type discoveryRoundTripper struct {
+	discover     DiscoverFunc
+	discovered   *url.URL
+	discoveredAt time.Time
+	interval     time.Duration
+	mu           sync.Mutex
+	next         http.RoundTripper
+	scheduler    scheduler.Scheduler
+	server       *url.URL
+}
+
+// RoundTrip will send the request to the discovered server
+func (d *discoveryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != d.server.Host {
+		// ex: sent to a read replica
+		return d.next.RoundTrip(req)
+	}
+
+	discovered, err := d.endpoint(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = discovered.Scheme
+	req.URL.Host = discovered.Host
+	req.URL.Path = discovered.Path + strings.TrimPrefix(req.URL.Path, d.server.Path)
+	req.URL.RawPath = ""
+	req.Host = ""
+	return d.next.RoundTrip(req)
+}
+
+// endpoint will return the discovered server, discovering it again when the interval elapsed (the last
+// discovered server is kept when the discovery fails)
+func (d *discoveryRoundTripper) endpoint(ctx context.Context) (*url.URL, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.scheduler.Now()
+	if d.discovered != nil && now.Sub(d.discoveredAt) < d.interval {
+		return d.discovered, nil
+	}
+
+	urls, err := d.discover(ctx)
+	if err == nil && len(urls) == 0 {
+		err = ErrNoServerDiscovered
+	}
+	var discovered *url.URL
+	if err == nil {
+		discovered, err = url.Parse(strings.TrimSuffix(urls[0], "/"))
+	}
+	if err != nil {
+		if d.discovered == nil {
+			return nil, err
+		}
+		// retried after the interval
+		discovered = d.discovered
+	}
+	d.discovered = discovered
+	d.discoveredAt = now
+	return discovered, nil
+}
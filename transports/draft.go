@@ -0,0 +1,67 @@
+package transports
+
+import (
+	"encoding/json"
+
+	"github.com/BuxOrg/bux"
+)
+
+// DraftOptions are the options of a draft transaction, they are set over the bux.TransactionConfig
+// (nil for the defaults of the server)
+type DraftOptions struct {
+	ChangeDestinationsStrategy bux.ChangeStrategy `json:"change_destinations_strategy,omitempty"`
+	ChangeMinimumSatoshis      uint64             `json:"change_minimum_satoshis,omitempty"`       // minimum of each change output
+	ChangeNumberOfDestinations int                `json:"change_number_of_destinations,omitempty"` // the change is split among them
+	FromUtxos                  []*bux.UtxoPointer `json:"from_utxos,omitempty"`                    // the only UTXOs the draft can spend
+}
+
+// IsDefault will return whether no option is set (the defaults of the server)
+func (o *DraftOptions) IsDefault() bool {
+	return o == nil || o.ChangeDestinationsStrategy == "" && o.ChangeMinimumSatoshis == 0 &&
+		o.ChangeNumberOfDestinations == 0 && len(o.FromUtxos) == 0
+}
+
+// recipientsConfig will return the transaction config paying the recipients
+func recipientsConfig(recipients []*Recipients) map[string]interface{} {
+	outputs := make([]map[string]interface{}, 0)
+	for _, recipient := range recipients {
+		if recipient.Script != "" {
+			outputs = append(outputs, map[string]interface{}{
+				"satoshis": recipient.Satoshis,
+				"scripts": []map[string]interface{}{{
+					"satoshis": recipient.Satoshis,
+					"script":   recipient.Script,
+				}},
+			})
+			continue
+		}
+		outputs = append(outputs, map[string]interface{}{
+			"to":        recipient.To,
+			"satoshis":  recipient.Satoshis,
+			"op_return": recipient.OpReturn,
+		})
+	}
+	return map[string]interface{}{
+		"outputs": outputs,
+	}
+}
+
+// draftConfig will return the configuration sent to the server: the transaction config with the
+// options of the draft
+func draftConfig(transactionConfig interface{}, opts *DraftOptions) (interface{}, error) {
+	if opts.IsDefault() {
+		return transactionConfig, nil
+	}
+
+	config := make(map[string]interface{})
+	for _, value := range []interface{}{transactionConfig, opts} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
@@ -1,6 +1,142 @@
 package transports
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
 
 // ErrAdminKey admin key not set
-var ErrAdminKey = errors.New("an admin key must be set to be able to create an xpub")
+var ErrAdminKey = errors.New("an admin key must be set to be able to call admin operations")
+
+// ErrMissingKeys no xPriv, xPub or access key set
+var ErrMissingKeys = errors.New("an xPriv, xPub or access key must be set")
+
+// ErrMissingBEEF the server returned no transaction in the BEEF format
+var ErrMissingBEEF = errors.New("the transaction beef is missing from the response")
+
+// ErrMissingBlockHeader the server returned no block header
+var ErrMissingBlockHeader = errors.New("the block header is missing from the response")
+
+// ErrMissingMerkleProof the server returned no merkle proof
+var ErrMissingMerkleProof = errors.New("the merkle proof is missing from the response")
+
+// AdminKeyError is returned (upfront, without calling the server) when an admin operation is
+// called while no admin key is set, it matches ErrAdminKey with errors.Is
+type AdminKeyError struct {
+	Operation string
+}
+
+// Error will return the error message, with the operation and the key that is needed
+func (e *AdminKeyError) Error() string {
+	return "an admin key (admin xPriv, see WithAdminKey or SetAdminKey) must be set to be able to call " + e.Operation
+}
+
+// Is will return whether the target is ErrAdminKey
+func (e *AdminKeyError) Is(target error) bool {
+	return target == ErrAdminKey
+}
+
+// ErrAccountFrozen the xPub is frozen by an admin (ex: compliance hold), it can not make transactions
+var ErrAccountFrozen = errors.New("the xpub is frozen")
+
+// accountFrozenMessage is the message of the bux server errors of frozen xPubs (graphql)
+const accountFrozenMessage = "xpub is frozen"
+
+// AccountFrozenError is returned when a mutation is rejected because the xPub is frozen, it matches
+// ErrAccountFrozen with errors.Is
+type AccountFrozenError struct {
+	Reason string
+}
+
+// Error will return the error message, with the reason of the freeze when known
+func (e *AccountFrozenError) Error() string {
+	if e.Reason == "" {
+		return ErrAccountFrozen.Error()
+	}
+	return ErrAccountFrozen.Error() + ": " + e.Reason
+}
+
+// Is will return whether the target is ErrAccountFrozen
+func (e *AccountFrozenError) Is(target error) bool {
+	return target == ErrAccountFrozen
+}
+
+// accountFrozenError will return an AccountFrozenError if the server error is about a frozen xPub
+func accountFrozenError(err error) error {
+	if err != nil && strings.Contains(err.Error(), accountFrozenMessage) {
+		return &AccountFrozenError{Reason: err.Error()}
+	}
+	return err
+}
+
+// ErrBroadcastFailed the bux server could not broadcast the transaction to the network (ex: its miner
+// is unreachable), the transaction was not recorded
+var ErrBroadcastFailed = errors.New("the server could not broadcast the transaction")
+
+// BroadcastFailedError is returned when a transaction is not recorded because the server could not
+// broadcast it, it matches ErrBroadcastFailed with errors.Is
+type BroadcastFailedError struct {
+	Reason string
+}
+
+// Error will return the error message, with the error of the server
+func (e *BroadcastFailedError) Error() string {
+	return ErrBroadcastFailed.Error() + ": " + e.Reason
+}
+
+// Is will return whether the target is ErrBroadcastFailed
+func (e *BroadcastFailedError) Is(target error) bool {
+	return target == ErrBroadcastFailed
+}
+
+// broadcastFailedError will return a BroadcastFailedError if the code of the server error is
+// ErrorCodeBroadcastFailed
+func broadcastFailedError(err error) error {
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) && serverErr.Code == ErrorCodeBroadcastFailed {
+		return &BroadcastFailedError{Reason: serverErr.Message}
+	}
+	return err
+}
+
+// Codes of the errors of the bux server: the code of the json error of the http responses, the
+// extensions.code of the graphql errors
+const (
+	ErrorCodeBroadcastFailed = "error-broadcast-failed"
+	ErrorCodeDraftNotFound   = "error-draft-transaction-not-found"
+)
+
+// ServerError is the error of a failed response of the server, with the code of the error when the
+// server sends one (see ErrorCode)
+type ServerError struct {
+	Code       string // code of the error (ex: ErrorCodeDraftNotFound), empty if the server sent none
+	Message    string // message of the error
+	Status     string // http status (ex: 404 Not Found), empty for the graphql errors
+	StatusCode int    // http status code, 0 for the graphql errors
+}
+
+// Error will return the error message
+func (e *ServerError) Error() string {
+	if e.StatusCode == 0 {
+		return e.Message
+	}
+	return "server error: " + strconv.Itoa(e.StatusCode) + " - " + e.Status
+}
+
+// ErrorCode will return the code of the server error of the chain, empty if the server sent no code
+func ErrorCode(err error) string {
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.Code
+	}
+	return ""
+}
+
+// graphQLServerError will return a ServerError with the code of the graphql error, if the server sent one
+func graphQLServerError(err error, codes *graphQLErrorCodes) error {
+	if err == nil || len(codes.codes) == 0 {
+		return err
+	}
+	return &ServerError{Code: codes.codes[0], Message: err.Error()}
+}
@@ -0,0 +1,299 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// defaultRecoveryInterval is the time after which a failed server is checked again, by default
+const defaultRecoveryInterval = 30 * time.Second
+
+// healthCheckTimeout is the timeout of the health checks of the failed servers
+const healthCheckTimeout = 10 * time.Second
+
+// WithFailover will fail over to the next server (same paths as the server, in order) when a request
+// fails to connect or the server answers an error (5xx), the failed server is skipped until its health
+// check succeeds (checked in the background at most once per recovery interval, 30s by default), for
+// HA deployments of the bux server. The reads are retried on the next server, the writes only when
+// they failed before being sent (ex: connection refused), a write the server may have received is
+// never sent again.
+func WithFailover(serverURLs []string, recoveryInterval time.Duration) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.failoverURLs = append(c.failoverURLs, serverURLs...)
+			c.recoveryInterval = recoveryInterval
+		}
+	}
+}
+
+// LoadBalancing is the strategy choosing the server of the reads, among the healthy servers of the failover
+type LoadBalancing string
+
+// Load balancing strategies
+const (
+	// LoadBalancingPrimary sends the reads to the primary, the other servers are only used to fail over
+	LoadBalancingPrimary LoadBalancing = "primary"
+
+	// LoadBalancingRoundRobin sends the reads to the servers in turn
+	LoadBalancingRoundRobin LoadBalancing = "round_robin"
+
+	// LoadBalancingLeastLatency sends the reads to the server with the lowest recent latency
+	LoadBalancingLeastLatency LoadBalancing = "least_latency"
+)
+
+// ErrInvalidLoadBalancing is when the load balancing strategy is unknown
+var ErrInvalidLoadBalancing = errors.New("invalid load balancing strategy")
+
+// latencyWeight is the weight of the last latency of a server in its moving average
+const latencyWeight = 0.3
+
+// WithLoadBalancing will spread the reads (queries, GET requests, searches and counts) on the healthy
+// servers of the failover (see WithFailover) with the strategy, the mutations are always sent to the
+// primary first (the server of the client)
+func WithLoadBalancing(strategy LoadBalancing) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.loadBalancing = strategy
+		}
+	}
+}
+
+// failoverServer is a server of the failover, with its health
+type failoverServer struct {
+	checkedAt time.Time // last failure or health check
+	healthy   bool
+	latency   time.Duration // moving average, 0 until measured
+	url       *url.URL
+}
+
+// failoverRoundTripper sends the requests of the primary server to the first healthy server, the reads
+// are balanced between the healthy servers
+type failoverRoundTripper struct {
+	loadBalancing    LoadBalancing
+	mu               sync.Mutex
+	next             http.RoundTripper
+	recoveryInterval time.Duration
+	reads            uint64 // number of balanced reads, for the round-robin
+	scheduler        scheduler.Scheduler
+	servers          []*failoverServer // the primary first
+	transport        TransportType
+}
+
+// newFailoverRoundTripper will return the failover of the primary server to the other servers
+func newFailoverRoundTripper(transport TransportType, next http.RoundTripper, primaryURL string,
+	serverURLs []string, recoveryInterval time.Duration, loadBalancing LoadBalancing,
+	s scheduler.Scheduler) (*failoverRoundTripper, error) {
+
+	switch loadBalancing {
+	case "":
+		loadBalancing = LoadBalancingPrimary
+	case LoadBalancingPrimary, LoadBalancingRoundRobin, LoadBalancingLeastLatency:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLoadBalancing, loadBalancing)
+	}
+	if recoveryInterval <= 0 {
+		recoveryInterval = defaultRecoveryInterval
+	}
+	failover := &failoverRoundTripper{
+		loadBalancing:    loadBalancing,
+		next:             next,
+		recoveryInterval: recoveryInterval,
+		scheduler:        s,
+		transport:        transport,
+	}
+	for _, serverURL := range append([]string{primaryURL}, serverURLs...) {
+		parsed, err := url.Parse(strings.TrimSuffix(serverURL, "/"))
+		if err != nil {
+			return nil, err
+		}
+		failover.servers = append(failover.servers, &failoverServer{healthy: true, url: parsed})
+	}
+	return failover, nil
+}
+
+// RoundTrip will send the request to the healthy servers in order, until one answers without an error
+func (f *failoverRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	primary := f.servers[0].url
+	if req.URL.Host != primary.Host {
+		// ex: sent to a read replica
+		return f.next.RoundTrip(req)
+	}
+
+	// the body is sent again to the next servers
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	servers := f.candidates()
+	read := isReadRequest(f.transport, withRequestBody(req, body))
+	if read {
+		servers = f.balance(servers)
+	}
+	var resp *http.Response
+	var err error
+	for _, server := range servers {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		serverReq := withRequestBody(req, body)
+		serverReq.URL.Scheme = server.url.Scheme
+		serverReq.URL.Host = server.url.Host
+		serverReq.URL.Path = server.url.Path + strings.TrimPrefix(req.URL.Path, primary.Path)
+		serverReq.URL.RawPath = ""
+		serverReq.Host = ""
+		if req.Body == nil {
+			serverReq.Body = nil
+		}
+
+		// a write is only sent again when it never left (nothing was written to the server)
+		var sent int32
+		serverReq = serverReq.WithContext(httptrace.WithClientTrace(serverReq.Context(), &httptrace.ClientTrace{
+			WroteHeaders: func() {
+				atomic.StoreInt32(&sent, 1)
+			},
+		}))
+
+		start := f.scheduler.Now()
+		resp, err = f.next.RoundTrip(serverReq)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			f.measure(server, f.scheduler.Now().Sub(start))
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			// canceled by the caller, not a failure of the server
+			return resp, err
+		}
+		f.markFailed(server)
+		if !read && (err == nil || atomic.LoadInt32(&sent) == 1) {
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// candidates will return the healthy servers, or all the servers when none is healthy, the health of
+// the failed servers is checked in the background once their recovery interval passed
+func (f *failoverRoundTripper) candidates() []*failoverServer {
+	now := f.scheduler.Now()
+	candidates := make([]*failoverServer, 0, len(f.servers))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, server := range f.servers {
+		if server.healthy {
+			candidates = append(candidates, server)
+		} else if now.Sub(server.checkedAt) >= f.recoveryInterval {
+			server.checkedAt = now
+			go f.recover(server)
+		}
+	}
+	if len(candidates) == 0 {
+		return f.servers
+	}
+	return candidates
+}
+
+// recover will check the health of the failed server, it is used again once healthy
+func (f *failoverRoundTripper) recover(server *failoverServer) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	if f.healthCheck(ctx, server) {
+		f.mu.Lock()
+		server.healthy = true
+		f.mu.Unlock()
+	}
+}
+
+// balance will return the servers in the order of the load balancing strategy
+func (f *failoverRoundTripper) balance(servers []*failoverServer) []*failoverServer {
+	if len(servers) < 2 || f.loadBalancing == LoadBalancingPrimary {
+		return servers
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balanced := make([]*failoverServer, len(servers))
+	if f.loadBalancing == LoadBalancingRoundRobin {
+		first := int(f.reads % uint64(len(servers)))
+		f.reads++
+		copy(balanced, servers[first:])
+		copy(balanced[len(servers)-first:], servers[:first])
+		return balanced
+	}
+
+	// the servers not measured yet come first, so they all get measured
+	copy(balanced, servers)
+	sort.SliceStable(balanced, func(i, j int) bool {
+		return balanced[i].latency < balanced[j].latency
+	})
+	return balanced
+}
+
+// measure will update the moving average of the latency of the server
+func (f *failoverRoundTripper) measure(server *failoverServer, latency time.Duration) {
+	if latency <= 0 {
+		// not measured (ex: virtual time)
+		latency = time.Nanosecond
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if server.latency == 0 {
+		server.latency = latency
+		return
+	}
+	server.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(server.latency))
+}
+
+// markFailed will skip the server until it recovers
+func (f *failoverRoundTripper) markFailed(server *failoverServer) {
+	now := f.scheduler.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	server.checkedAt = now
+	server.healthy = false
+}
+
+// healthCheck will return whether the server is healthy (http: GET /health, graphql: the __typename query)
+func (f *failoverRoundTripper) healthCheck(ctx context.Context, server *failoverServer) bool {
+	var req *http.Request
+	var err error
+	if f.transport == BuxTransportGraphQL {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, server.url.String(),
+			bytes.NewBufferString(`{"query":"query { __typename }"}`))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.url.String()+"/health", nil)
+	}
+	if err != nil {
+		return false
+	}
+
+	resp, err := f.next.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
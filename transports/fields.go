@@ -0,0 +1,113 @@
+package transports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FieldModel is a model of which the fields returned by the graphql queries can be selected
+type FieldModel string
+
+// Models of which the fields can be selected
+const (
+	FieldsAccessKey        FieldModel = "access_key"
+	FieldsDestination      FieldModel = "destination"
+	FieldsDraftTransaction FieldModel = "draft_transaction"
+	FieldsPaymail          FieldModel = "paymail"
+	FieldsTransaction      FieldModel = "transaction"
+	FieldsUtxo             FieldModel = "utxo"
+	FieldsWebhook          FieldModel = "webhook"
+	FieldsXPubStatus       FieldModel = "xpub_status"
+)
+
+// ErrInvalidField is when a selected field is not a field name (with its nested selection set)
+var ErrInvalidField = errors.New("invalid field")
+
+// fieldRegex matches a field name, with the selection set of its nested fields (ex: "fee_unit { satoshis }")
+var fieldRegex = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*(\s*{[\s_0-9A-Za-z{}]+})?$`)
+
+// fieldsContextKey is the context key of the per-request selected fields
+type fieldsContextKey struct{}
+
+// WithFields will select the fields of the records of the model returned by the graphql queries, instead
+// of all the fields, to reduce the size of the responses (ex: WithFields(FieldsTransaction, "id", "hex")),
+// the id is always selected. The nested fields are selected with their selection set (ex:
+// "configuration { fee }"), the drafts must keep the fields needed to sign them. The requests are not
+// in the request manifest anymore (see GenerateRequestManifest). Ignored by the http transport.
+func WithFields(model FieldModel, fields ...string) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			if c.fields == nil {
+				c.fields = make(map[FieldModel][]string)
+			}
+			c.fields[model] = append([]string(nil), fields...)
+		}
+	}
+}
+
+// ContextWithFields will return a context selecting the fields of the records of the model returned by
+// the graphql requests made with it (see WithFields), they replace the fields selected by the client
+func ContextWithFields(ctx context.Context, model FieldModel, fields ...string) (context.Context, error) {
+	set, err := selectionSet(fields)
+	if err != nil {
+		return nil, err
+	}
+	selections := make(map[FieldModel]string)
+	for key, value := range fieldsFromContext(ctx) {
+		selections[key] = value
+	}
+	selections[model] = set
+	return context.WithValue(ctx, fieldsContextKey{}, selections), nil
+}
+
+// fieldsFromContext will return the selection sets of the context, by model (nil if none)
+func fieldsFromContext(ctx context.Context) map[FieldModel]string {
+	selections, _ := ctx.Value(fieldsContextKey{}).(map[FieldModel]string)
+	return selections
+}
+
+// selectionSet will return the graphql selection set of the fields, with the id
+func selectionSet(fields []string) (string, error) {
+	lines := []string{"{", "id"}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if !fieldRegex.MatchString(field) || !balancedBraces(field) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidField, field)
+		}
+		if field != "id" {
+			lines = append(lines, field)
+		}
+	}
+	return strings.Join(append(lines, "}"), "\n"), nil
+}
+
+// balancedBraces will return whether the braces of the field are balanced
+func balancedBraces(field string) bool {
+	depth := 0
+	for _, char := range field {
+		switch char {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// selection will return the selection set of the records of the model: the fields selected by the
+// context, or else by the client, or else all the fields
+func (g *TransportGraphQL) selection(ctx context.Context, model FieldModel, all string) string {
+	if set, ok := fieldsFromContext(ctx)[model]; ok {
+		return set
+	}
+	if set, ok := g.fields[model]; ok {
+		return set
+	}
+	return all
+}
@@ -2,11 +2,18 @@ package transports
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/bip32"
 	"github.com/machinebox/graphql"
@@ -18,15 +25,25 @@ type graphQlService interface {
 
 // TransportGraphQL is the graphql struct
 type TransportGraphQL struct {
-	accessKey   *bec.PrivateKey
-	adminXPriv  *bip32.ExtendedKey
-	debug       bool
-	httpClient  *http.Client
-	server      string
-	signRequest bool
-	xPriv       *bip32.ExtendedKey
-	xPub        *bip32.ExtendedKey
-	client      graphQlService
+	accessKey     *bec.PrivateKey
+	adminXPriv    *bip32.ExtendedKey
+	bulkheads     *bulkheads
+	checkpoints   store.Store
+	debug         bool
+	fields        map[FieldModel]string // selection sets by model, see WithFields
+	headers       http.Header
+	httpClient    *http.Client
+	httpTransport *http.Transport // the transport under the round trippers, it also dials the subscriptions
+	keysLock      sync.RWMutex    // the keys are replaced while requests run
+	logger        logging.Logger
+	requestSigner RequestSigner
+	scheduler     scheduler.Scheduler
+	server        string
+	signRequest   bool
+	tlsConfig     *tls.Config
+	xPriv         *bip32.ExtendedKey
+	xPub          *bip32.ExtendedKey
+	client        graphQlService
 }
 
 // DestinationData is the destination data
@@ -54,17 +71,178 @@ type NewTransactionData struct {
 	Transaction *bux.Transaction `json:"transaction"`
 }
 
+// TransactionMetadataData is a transaction with updated metadata
+type TransactionMetadataData struct {
+	Transaction *bux.Transaction `json:"transaction_metadata"`
+}
+
+// DestinationsData is a slice of destinations
+type DestinationsData struct {
+	Destinations []*bux.Destination `json:"destinations"`
+}
+
+// UtxosData is a slice of utxos
+type UtxosData struct {
+	Utxos []*bux.Utxo `json:"utxos"`
+}
+
+// AccessKeysData is a slice of access keys
+type AccessKeysData struct {
+	AccessKeys []*bux.AccessKey `json:"access_keys"`
+}
+
+// DestinationMetadataData is a destination with updated metadata
+type DestinationMetadataData struct {
+	Destination *bux.Destination `json:"destination_metadata"`
+}
+
+// ImportTransactionData is an imported transaction
+type ImportTransactionData struct {
+	Transaction *bux.Transaction `json:"transaction_import"`
+}
+
+// TransactionStatusData is the status of a transaction
+type TransactionStatusData struct {
+	TransactionStatus *TransactionStatus `json:"transaction_status"`
+}
+
+// MerkleProofData is the merkle proof of a transaction
+type MerkleProofData struct {
+	MerkleProof *MerkleProof `json:"merkle_proof"`
+}
+
+// BlockHeaderData is a block header
+type BlockHeaderData struct {
+	BlockHeader *BlockHeader `json:"block_header"`
+}
+
+// FeeQuoteData is the fee policy of the server
+type FeeQuoteData struct {
+	FeeQuote *FeeQuote `json:"fee_quote"`
+}
+
+// TransactionBEEFData is a transaction in the BEEF format
+type TransactionBEEFData struct {
+	Transaction *TransactionBEEF `json:"transaction_beef"`
+}
+
+// PaymailData is a paymail address
+type PaymailData struct {
+	Paymail *PaymailAddress `json:"admin_paymail_create"`
+}
+
+// PaymailsData is a slice of paymail addresses
+type PaymailsData struct {
+	Paymails []*PaymailAddress `json:"admin_paymails_list"`
+}
+
+// WebhookData is a webhook
+type WebhookData struct {
+	Webhook *Webhook `json:"webhook_create"`
+}
+
+// WebhooksData is a slice of webhooks
+type WebhooksData struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+// XPubStatusData is the status of the xPub
+type XPubStatusData struct {
+	XPubStatus *XPubStatus `json:"xpub_status"`
+}
+
+// XPubFreezeData is the status of the frozen xPub
+type XPubFreezeData struct {
+	XPubStatus *XPubStatus `json:"admin_xpub_freeze"`
+}
+
+// XPubUnfreezeData is the status of the unfrozen xPub
+type XPubUnfreezeData struct {
+	XPubStatus *XPubStatus `json:"admin_xpub_unfreeze"`
+}
+
+// XpubData is the xPub of the client
+type XpubData struct {
+	Xpub *bux.Xpub `json:"xpub"`
+}
+
+// XpubRotateData is the new xPub of a rotation
+type XpubRotateData struct {
+	Xpub *bux.Xpub `json:"xpub_rotate"`
+}
+
+// AccessKeyData is a new access key
+type AccessKeyData struct {
+	AccessKey *bux.AccessKey `json:"access_key"`
+}
+
+// AccessKeyRevokeData is a revoked access key
+type AccessKeyRevokeData struct {
+	AccessKey *bux.AccessKey `json:"access_key_revoke"`
+}
+
+// FeatureFlagsData is the map of feature flags
+type FeatureFlagsData struct {
+	FeatureFlags map[string]bool `json:"features"`
+}
+
 // Init will initialize
 func (g *TransportGraphQL) Init() error {
-	g.client = graphql.NewClient(g.server, graphql.WithHTTPClient(g.httpClient))
+	// the error codes are read after every wrapper, as the graphql client returns them
+	httpClient := wrapHTTPClient(g.httpClient, BuxTransportGraphQL, func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+		return &graphQLErrorCodesRoundTripper{next: next}
+	})
+	g.client = graphql.NewClient(g.server, graphql.WithHTTPClient(httpClient))
 	return nil
 }
 
+// wrapRoundTripper will wrap the round tripper of the http client (before Init)
+func (g *TransportGraphQL) wrapRoundTripper(wrap func(transport TransportType, next http.RoundTripper) http.RoundTripper) {
+	g.httpClient = wrapHTTPClient(g.httpClient, BuxTransportGraphQL, func(transport TransportType,
+		next http.RoundTripper) http.RoundTripper {
+
+		// the proxy and tls options replace the transport with a configured copy
+		if httpTransport, ok := next.(*http.Transport); ok && g.httpTransport == nil {
+			g.httpTransport = httpTransport
+		}
+		wrapped := wrap(transport, next)
+		if httpTransport, ok := wrapped.(*http.Transport); ok {
+			g.httpTransport = httpTransport
+		}
+		return wrapped
+	})
+}
+
 // SetAdminKey set the admin key
 func (g *TransportGraphQL) SetAdminKey(adminKey *bip32.ExtendedKey) {
 	g.adminXPriv = adminKey
 }
 
+// HasAdminKey return whether the admin key is set
+func (g *TransportGraphQL) HasAdminKey() bool {
+	return g.adminXPriv != nil
+}
+
+// SetXPriv will replace the xPriv (and xPub) used to sign the requests, see BuxClient.RotateXPriv
+func (g *TransportGraphQL) SetXPriv(xPriv *bip32.ExtendedKey) error {
+	xPub, err := xPriv.Neuter()
+	if err != nil {
+		return err
+	}
+	g.keysLock.Lock()
+	defer g.keysLock.Unlock()
+	g.xPriv = xPriv
+	g.xPub = xPub
+	return nil
+}
+
+// SetAccessKey will replace the access key used to sign the requests, see BuxClient.ReplaceAccessKey
+func (g *TransportGraphQL) SetAccessKey(accessKey *bec.PrivateKey) {
+	g.keysLock.Lock()
+	defer g.keysLock.Unlock()
+	g.accessKey = accessKey
+}
+
 // SetDebug turn the debugging on or off
 func (g *TransportGraphQL) SetDebug(debug bool) {
 	g.debug = debug
@@ -75,6 +253,21 @@ func (g *TransportGraphQL) IsDebug() bool {
 	return g.debug
 }
 
+// SetLogger set the logger of the debug output
+func (g *TransportGraphQL) SetLogger(logger logging.Logger) {
+	g.logger = logger
+}
+
+// SetScheduler set the scheduler of the reconnect and resubscribe waits
+func (g *TransportGraphQL) SetScheduler(scheduler scheduler.Scheduler) {
+	g.scheduler = scheduler
+}
+
+// SetRequestSigner set the signer of the requests (BSMSigner when nil)
+func (g *TransportGraphQL) SetRequestSigner(signer RequestSigner) {
+	g.requestSigner = signer
+}
+
 // SetSignRequest turn the signing of the http request on or off
 func (g *TransportGraphQL) SetSignRequest(signRequest bool) {
 	g.signRequest = signRequest
@@ -89,30 +282,29 @@ func (g *TransportGraphQL) IsSignRequest() bool {
 func (g *TransportGraphQL) RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error {
 
 	// adding an xpub needs to be signed by an admin key
-	if g.adminXPriv == nil {
-		return ErrAdminKey
+	if err := checkAdminKey(g.adminXPriv, OperationRegisterXpub); err != nil {
+		return err
 	}
 
 	reqBody := `
-   	mutation ($metadata: Map) {
+   	mutation ($xpub: String!, $metadata: Map) {
 	  xpub(
-		xpub: "` + rawXPub + `"
+		xpub: $xpub
 		metadata: $metadata
 	  ) {
 	    id
 	  }
 	}`
 	req := graphql.NewRequest(reqBody)
-	req.Var("metadata", processMetadata(metadata))
 	variables := map[string]interface{}{
-		"metadata": processMetadata(metadata),
+		"xpub":     rawXPub,
+		"metadata": withUserAgent(metadata),
 	}
-
-	bodyString, err := getBodyString(reqBody, variables)
-	if err != nil {
-		return err
+	for key, value := range variables {
+		req.Var(key, value)
 	}
-	err = addSignature(&req.Header, g.adminXPriv, bodyString)
+
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
 	if err != nil {
 		return err
 	}
@@ -132,22 +324,13 @@ func (g *TransportGraphQL) GetDestination(ctx context.Context, metadata *bux.Met
    	mutation ($metadata: Map) {
 	  destination(
 		metadata: $metadata
-	  ) {
-		id
-		xpub_id
-		locking_script
-		type
-		chain
-		num
-		address
-		metadata
-	  }
+	  ) ` + g.selection(ctx, FieldsDestination, graphqlDestinationFields) + `
 	}`
 	req := graphql.NewRequest(reqBody)
-	req.Var("metadata", processMetadata(metadata))
+	req.Var("metadata", withUserAgent(metadata))
 
 	variables := map[string]interface{}{
-		"metadata": processMetadata(metadata),
+		"metadata": withUserAgent(metadata),
 	}
 	err := g.signGraphQLRequest(req, reqBody, variables)
 	if err != nil {
@@ -157,67 +340,137 @@ func (g *TransportGraphQL) GetDestination(ctx context.Context, metadata *bux.Met
 	// run it and capture the response
 	var respData DestinationData
 	if err := g.client.Run(ctx, req, &respData); err != nil {
+		return nil, accountFrozenError(err)
+	}
+	destination := respData.Destination
+	if g.debug {
+		debugResult(g.logger, "new destination", destination, func() []logging.Field {
+			return []logging.Field{logging.F("address", destination.Address)}
+		})
+	}
+
+	return destination, nil
+}
+
+// GetDestinationByAddress will get the destination of the xPub with the address
+func (g *TransportGraphQL) GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error) {
+	reqBody := `
+   	query ($address: String) {
+	  destination(
+		address: $address
+	  ) ` + g.selection(ctx, FieldsDestination, graphqlDestinationFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("address", address)
+	variables := map[string]interface{}{
+		"address": address,
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData DestinationData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
 		return nil, err
 	}
 	destination := respData.Destination
 	if g.debug {
-		fmt.Printf("Address for new destination: %s\n", destination.Address)
+		debugResult(g.logger, "destination", destination, func() []logging.Field {
+			return []logging.Field{logging.F("address", destination.Address)}
+		})
 	}
 
 	return destination, nil
 }
 
-// DraftTransaction is a draft transaction
-func (g *TransportGraphQL) DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig,
-	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
+// NewDestinations will get new destinations in a single request, with an alias per destination, the
+// destinations created before an error are returned with it
+func (g *TransportGraphQL) NewDestinations(ctx context.Context, count int,
+	metadata *bux.Metadata) ([]*bux.Destination, error) {
 
-	reqBody := `
-   	mutation ($transactionConfig: TransactionConfigInput!, $metadata: Map) {
-	  new_transaction(
-		transaction_config: $transactionConfig
+	destinations := make([]*bux.Destination, 0, count)
+	if count <= 0 {
+		return destinations, nil
+	}
+
+	var selections strings.Builder
+	for index := 0; index < count; index++ {
+		selections.WriteString(`
+	  destination` + graphqlBatchSuffix + strconv.Itoa(index) + `: destination(
 		metadata: $metadata
-	  ) ` + graphqlDraftTransactionFields + `
+	  ) ` + g.selection(ctx, FieldsDestination, graphqlDestinationFields))
+	}
+	reqBody := `
+   	mutation ($metadata: Map) {` + selections.String() + `
 	}`
 	req := graphql.NewRequest(reqBody)
-	req.Var("transactionConfig", transactionConfig)
-	req.Var("metadata", processMetadata(metadata))
+	req.Var("metadata", withUserAgent(metadata))
+
 	variables := map[string]interface{}{
-		"transaction_config": transactionConfig,
-		"metadata":           processMetadata(metadata),
+		"metadata": withUserAgent(metadata),
+	}
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
 	}
 
-	return g.draftTransactionCommon(ctx, reqBody, variables, req)
+	// the created destinations are returned even when some of them failed
+	respData := make(map[string]*bux.Destination, count)
+	err = g.client.Run(ctx, req, &respData)
+	for index := 0; index < count; index++ {
+		if destination := respData["destination"+graphqlBatchSuffix+strconv.Itoa(index)]; destination != nil {
+			destinations = append(destinations, destination)
+		}
+	}
+	if g.debug {
+		g.logger.Debug("new destinations", logging.F("count", len(destinations)))
+	}
+
+	return destinations, accountFrozenError(err)
+}
+
+// DraftTransaction is a draft transaction
+func (g *TransportGraphQL) DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig,
+	metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error) {
+
+	config, err := draftConfig(transactionConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+	return g.newTransaction(ctx, config, metadata)
 }
 
 // DraftToRecipients is a draft transaction to a slice of recipients
 func (g *TransportGraphQL) DraftToRecipients(ctx context.Context, recipients []*Recipients,
+	metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error) {
+
+	config, err := draftConfig(recipientsConfig(recipients), opts)
+	if err != nil {
+		return nil, err
+	}
+	return g.newTransaction(ctx, config, metadata)
+}
+
+// newTransaction will create a new draft transaction with the transaction config
+func (g *TransportGraphQL) newTransaction(ctx context.Context, config interface{},
 	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
 
 	reqBody := `
-   	mutation ($outputs: [TransactionOutputInput]!, $metadata: Map) {
+   	mutation ($transactionConfig: TransactionConfigInput!, $metadata: Map) {
 	  new_transaction(
-		transaction_config:{
-		  outputs: $outputs
-          change_number_of_destinations:3
-          change_destinations_strategy:"random"
-		}
-		metadata:$metadata
-	  ) ` + graphqlDraftTransactionFields + `
+		transaction_config: $transactionConfig
+		metadata: $metadata
+	  ) ` + g.selection(ctx, FieldsDraftTransaction, graphqlDraftTransactionFields) + `
 	}`
 	req := graphql.NewRequest(reqBody)
-	outputs := make([]map[string]interface{}, 0)
-	for _, recipient := range recipients {
-		outputs = append(outputs, map[string]interface{}{
-			"to":        recipient.To,
-			"satoshis":  recipient.Satoshis,
-			"op_return": recipient.OpReturn,
-		})
-	}
-	req.Var("outputs", outputs)
-	req.Var("metadata", processMetadata(metadata))
+	req.Var("transactionConfig", config)
+	req.Var("metadata", withUserAgent(metadata))
 	variables := map[string]interface{}{
-		"outputs":  outputs,
-		"metadata": processMetadata(metadata),
+		"transaction_config": config,
+		"metadata":           withUserAgent(metadata),
 	}
 
 	return g.draftTransactionCommon(ctx, reqBody, variables, req)
@@ -234,11 +487,13 @@ func (g *TransportGraphQL) draftTransactionCommon(ctx context.Context, reqBody s
 	// run it and capture the response
 	var respData DraftTransactionData
 	if err := g.client.Run(ctx, req, &respData); err != nil {
-		return nil, err
+		return nil, accountFrozenError(err)
 	}
 	draftTransaction := respData.NewTransaction
 	if g.debug {
-		fmt.Printf("Draft transaction: %v\n", draftTransaction)
+		debugResult(g.logger, "draft transaction", draftTransaction, func() []logging.Field {
+			return []logging.Field{logging.F("draft_id", draftTransaction.ID)}
+		})
 	}
 
 	return draftTransaction, nil
@@ -248,16 +503,18 @@ func (g *TransportGraphQL) draftTransactionCommon(ctx context.Context, reqBody s
 func (g *TransportGraphQL) GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error) {
 
 	reqBody := `
-   	query {
+   	query ($txId: String!) {
 	  transaction(
-		txId:"` + txID + `",
-	  ) {
-		id
-	  }
+		txId: $txId
+	  ) ` + g.selection(ctx, FieldsTransaction, graphqlTransactionFields) + `
 	}`
 	req := graphql.NewRequest(reqBody)
+	req.Var("txId", txID)
+	variables := map[string]interface{}{
+		"txId": txID,
+	}
 
-	err := g.signGraphQLRequest(req, reqBody, nil)
+	err := g.signGraphQLRequest(req, reqBody, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -269,61 +526,75 @@ func (g *TransportGraphQL) GetTransaction(ctx context.Context, txID string) (*bu
 	}
 	transaction := respData.Transaction
 	if g.debug {
-		fmt.Printf("Transaction: %s\n", transaction.ID)
+		debugResult(g.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
 	}
 
 	return transaction, nil
 }
 
-// GetTransactions get a transactions, filtered by the given metadata
-func (g *TransportGraphQL) GetTransactions(ctx context.Context, conditions map[string]interface{},
-	metadata *bux.Metadata) ([]*bux.Transaction, error) {
+// GetTransactionStatus will get the status of a transaction on the network
+func (g *TransportGraphQL) GetTransactionStatus(ctx context.Context, txID string) (*TransactionStatus, error) {
+
+	reqBody := `
+   	query ($txId: String!) {
+	  transaction_status(
+		txId: $txId
+	  ) {
+		tx_id
+		seen_on_network
+		mined
+		block_hash
+		block_height
+		double_spend
+		rejected_reason
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("txId", txID)
+	variables := map[string]interface{}{
+		"txId": txID,
+	}
 
-	querySignature := ""
-	queryArguments := ""
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
 
-	// is there a better way to do this ?
-	if conditions != nil {
-		querySignature += "( $conditions Map "
-		queryArguments += " conditions: $conditions\n"
+	// run it and capture the response
+	var respData TransactionStatusData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
 	}
-	if metadata != nil {
-		if conditions == nil {
-			querySignature += "( "
-		} else {
-			querySignature += ", "
-		}
-		querySignature += "$metadata Map"
-		queryArguments += " metadata: $metadata\n"
-	} else {
-		querySignature += " )"
+	status := respData.TransactionStatus
+	if g.debug {
+		debugResult(g.logger, "transaction status", status, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", status.TxID), logging.F("mined", status.Mined)}
+		})
 	}
 
+	return status, nil
+}
+
+// GetMerkleProof will get the merkle proof of a mined transaction
+func (g *TransportGraphQL) GetMerkleProof(ctx context.Context, txID string) (*MerkleProof, error) {
+
 	reqBody := `
-   	query ` + querySignature + `{
-	  transactions ` + queryArguments + ` {
-	    id,
-	    hex,
-	    xpub_in_ids,
-	    xpub_out_ids,
-	    block_hash,
-	    block_height,
-	    fee,
-	    number_of_inputs,
-	    number_of_outputs,
-	    draft_id,
-	    total_value,
+   	query ($txId: String!) {
+	  merkle_proof(
+		txId: $txId
+	  ) {
+		index
+		tx_id
+		target
+		nodes
 	  }
 	}`
 	req := graphql.NewRequest(reqBody)
-	variables := make(map[string]interface{})
-	if conditions != nil {
-		req.Var("conditions", conditions)
-		variables["conditions"] = conditions
-	}
-	if metadata != nil {
-		req.Var("metadata", metadata)
-		variables["metadata"] = metadata
+	req.Var("txId", txID)
+	variables := map[string]interface{}{
+		"txId": txID,
 	}
 
 	err := g.signGraphQLRequest(req, reqBody, variables)
@@ -332,89 +603,1163 @@ func (g *TransportGraphQL) GetTransactions(ctx context.Context, conditions map[s
 	}
 
 	// run it and capture the response
-	var respData TransactionsData
+	var respData MerkleProofData
 	if err = g.client.Run(ctx, req, &respData); err != nil {
 		return nil, err
 	}
-	transactions := respData.Transactions
+	merkleProof := respData.MerkleProof
+	if merkleProof == nil {
+		return nil, ErrMissingMerkleProof
+	}
 	if g.debug {
-		fmt.Printf("Transactions: %d\n", len(transactions))
+		g.logger.Debug("merkle proof", logging.F("tx_id", merkleProof.TxID), logging.F("target", merkleProof.Target))
 	}
 
-	return transactions, nil
+	return merkleProof, nil
 }
 
-// RecordTransaction will record a transaction
-func (g *TransportGraphQL) RecordTransaction(ctx context.Context, hex, referenceID string,
-	metadata *bux.Metadata) (*bux.Transaction, error) {
+// GetBlockHeader will get a block header by hash
+func (g *TransportGraphQL) GetBlockHeader(ctx context.Context, blockHash string) (*BlockHeader, error) {
 
 	reqBody := `
-   	mutation($metadata: Map) {
-	  transaction(
-		hex:"` + hex + `",
-        draft_id:"` + referenceID + `"
-		metadata: $metadata
+   	query ($hash: String!) {
+	  block_header(
+		hash: $hash
 	  ) {
-		id
+		hash
+		height
+		header
 	  }
 	}`
 	req := graphql.NewRequest(reqBody)
-	req.Var("metadata", processMetadata(metadata))
-
+	req.Var("hash", blockHash)
 	variables := map[string]interface{}{
-		"metadata": processMetadata(metadata),
+		"hash": blockHash,
 	}
+
 	err := g.signGraphQLRequest(req, reqBody, variables)
 	if err != nil {
 		return nil, err
 	}
 
 	// run it and capture the response
-	var respData NewTransactionData
+	var respData BlockHeaderData
 	if err = g.client.Run(ctx, req, &respData); err != nil {
 		return nil, err
 	}
-	transaction := respData.Transaction
+	blockHeader := respData.BlockHeader
+	if blockHeader == nil {
+		return nil, ErrMissingBlockHeader
+	}
 	if g.debug {
-		fmt.Printf("Transaction: %s\n", transaction.ID)
+		g.logger.Debug("block header", logging.F("hash", blockHeader.Hash), logging.F("height", blockHeader.Height))
 	}
 
-	return transaction, nil
+	return blockHeader, nil
 }
 
-func getBodyString(reqBody string, variables map[string]interface{}) (string, error) {
-	requestBodyObj := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables"`
-	}{
-		Query:     reqBody,
-		Variables: variables,
-	}
+// GetFeeQuote will get the fee policy of the server
+func (g *TransportGraphQL) GetFeeQuote(ctx context.Context) (*FeeQuote, error) {
 
-	body, err := json.Marshal(requestBodyObj)
+	reqBody := `
+   	query {
+	  fee_quote {
+		fee_unit {
+		  satoshis
+		  bytes
+		}
+		miner
+		expires_at
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(body), nil
+	// run it and capture the response
+	var respData FeeQuoteData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	feeQuote := respData.FeeQuote
+	if g.debug {
+		debugResult(g.logger, "fee quote", feeQuote, func() []logging.Field {
+			return []logging.Field{logging.F("miner", feeQuote.Miner)}
+		})
+	}
+
+	return feeQuote, nil
 }
 
-func (g *TransportGraphQL) signGraphQLRequest(req *graphql.Request, reqBody string, variables map[string]interface{}) error {
-	if g.signRequest {
-		bodyString, err := getBodyString(reqBody, variables)
-		if err != nil {
-			return err
-		}
-		err = addSignature(&req.Header, g.xPriv, bodyString)
-		if err != nil {
-			return err
-		}
-	} else {
-		req.Header.Set("auth_xpub", g.xPub.String())
+// GetTransactionBEEF will get a transaction in the BEEF format, with its unmined ancestors and the
+// merkle paths of the mined ones
+func (g *TransportGraphQL) GetTransactionBEEF(ctx context.Context, txID string) (*TransactionBEEF, error) {
+
+	reqBody := `
+   	query ($txId: String!) {
+	  transaction_beef(
+		txId: $txId
+	  ) {
+		tx_id
+		beef
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("txId", txID)
+	variables := map[string]interface{}{
+		"txId": txID,
 	}
-	return nil
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData TransactionBEEFData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	transaction := respData.Transaction
+	if transaction == nil || transaction.BEEF == "" {
+		return nil, ErrMissingBEEF
+	}
+	if g.debug {
+		debugResult(g.logger, "transaction beef", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.TxID)}
+		})
+	}
+
+	return transaction, nil
 }
 
+// RecordTransactionBEEF will record a transaction in the BEEF format (hex encoded)
+func (g *TransportGraphQL) RecordTransactionBEEF(ctx context.Context, beef, referenceID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	reqBody := `
+   	mutation ($beef: String!, $draft_id: String, $metadata: Map) {
+	  transaction(
+		beef: $beef
+		draft_id: $draft_id
+		metadata: $metadata
+	  ) {
+		id
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"beef":     beef,
+		"draft_id": referenceID,
+		"metadata": withUserAgent(metadata),
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData NewTransactionData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, accountFrozenError(err)
+	}
+	transaction := respData.Transaction
+	if g.debug {
+		debugResult(g.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// ImportTransaction will ask the server to import a transaction of the chain that pays the xPub
+func (g *TransportGraphQL) ImportTransaction(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	reqBody := `
+   	mutation ($tx_id: String!, $metadata: Map) {
+	  transaction_import(
+		tx_id: $tx_id
+		metadata: $metadata
+	  ) {
+		id
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"tx_id":    txID,
+		"metadata": withUserAgent(metadata),
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData ImportTransactionData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, accountFrozenError(err)
+	}
+	transaction := respData.Transaction
+	if g.debug {
+		debugResult(g.logger, "imported transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// GetTransactions will get the transactions matching the conditions and metadata (a page of them
+// with the query params)
+func (g *TransportGraphQL) GetTransactions(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Transaction, error) {
+
+	return g.SearchTransactions(ctx, conditions, metadata, queryParams)
+}
+
+// RecordTransaction will record a transaction
+func (g *TransportGraphQL) RecordTransaction(ctx context.Context, hex, referenceID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	reqBody := `
+   	mutation ($hex: String!, $draft_id: String, $metadata: Map) {
+	  transaction(
+		hex: $hex
+		draft_id: $draft_id
+		metadata: $metadata
+	  ) {
+		id
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"hex":      hex,
+		"draft_id": referenceID,
+		"metadata": withUserAgent(metadata),
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData NewTransactionData
+	runCtx, codes := withGraphQLErrorCodes(ctx)
+	if err = g.client.Run(runCtx, req, &respData); err != nil {
+		return nil, broadcastFailedError(accountFrozenError(graphQLServerError(err, codes)))
+	}
+	transaction := respData.Transaction
+	if g.debug {
+		debugResult(g.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// graphqlBatchSuffix is the suffix of the aliases and variables of the batched operations, followed
+// by the index of the operation in the batch (ex: transaction_batch0)
+const graphqlBatchSuffix = "_batch"
+
+// RecordTransactions will record the transactions in a single request, with an alias per transaction,
+// the transactions that fail are skipped and the first error is returned
+func (g *TransportGraphQL) RecordTransactions(ctx context.Context,
+	requests []*RecordRequest) ([]*bux.Transaction, error) {
+
+	transactions := make([]*bux.Transaction, len(requests))
+	if len(requests) == 0 {
+		return transactions, nil
+	}
+
+	definitions := make([]string, 0, len(requests))
+	var selections strings.Builder
+	variables := make(map[string]interface{}, 3*len(requests))
+	for index, request := range requests {
+		suffix := graphqlBatchSuffix + strconv.Itoa(index)
+		definitions = append(definitions, "$hex"+suffix+": String!", "$draft_id"+suffix+": String",
+			"$metadata"+suffix+": Map")
+		selections.WriteString(`
+	  transaction` + suffix + `: transaction(
+		hex: $hex` + suffix + `
+		draft_id: $draft_id` + suffix + `
+		metadata: $metadata` + suffix + `
+	  ) {
+		id
+	  }`)
+		variables["hex"+suffix] = request.Hex
+		variables["draft_id"+suffix] = request.ReferenceID
+		variables["metadata"+suffix] = withUserAgent(request.Metadata)
+	}
+	reqBody := `
+   	mutation(` + strings.Join(definitions, ", ") + `) {` + selections.String() + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	for name, value := range variables {
+		req.Var(name, value)
+	}
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// the recorded transactions are returned even when some of them failed
+	respData := make(map[string]*bux.Transaction, len(requests))
+	err = g.client.Run(ctx, req, &respData)
+	for index := range requests {
+		transactions[index] = respData["transaction"+graphqlBatchSuffix+strconv.Itoa(index)]
+	}
+	if g.debug {
+		g.logger.Debug("transactions recorded", logging.F("count", len(requests)))
+	}
+	return transactions, accountFrozenError(err)
+}
+
+// SearchTransactions will get a page of the transactions matching the conditions and metadata
+func (g *TransportGraphQL) SearchTransactions(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Transaction, error) {
+
+	req, reqBody, variables := searchRequest("transactions", g.selection(ctx, FieldsTransaction, graphqlTransactionFields), conditions, metadata, queryParams)
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData TransactionsData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	transactions := respData.Transactions
+	if g.debug {
+		g.logger.Debug("transactions", logging.F("count", len(transactions)))
+	}
+
+	return transactions, nil
+}
+
+// UpdateTransactionMetadata will update the metadata of the transaction (keys set to nil are removed)
+func (g *TransportGraphQL) UpdateTransactionMetadata(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	reqBody := `
+   	mutation ($id: String!, $metadata: Map!) {
+	  transaction_metadata(
+		id: $id
+		metadata: $metadata
+	  ) ` + g.selection(ctx, FieldsTransaction, graphqlTransactionFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"id":       txID,
+		"metadata": metadata,
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData TransactionMetadataData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, accountFrozenError(err)
+	}
+	transaction := respData.Transaction
+	if g.debug {
+		debugResult(g.logger, "transaction metadata updated", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// GetDestinations will get a page of the destinations matching the conditions and metadata
+func (g *TransportGraphQL) GetDestinations(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Destination, error) {
+
+	req, reqBody, variables := searchRequest("destinations", g.selection(ctx, FieldsDestination, graphqlDestinationFields), conditions, metadata, queryParams)
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData DestinationsData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	destinations := respData.Destinations
+	if g.debug {
+		g.logger.Debug("destinations", logging.F("count", len(destinations)))
+	}
+
+	return destinations, nil
+}
+
+// UpdateDestinationMetadata will update the metadata of the destination (keys set to nil are removed)
+func (g *TransportGraphQL) UpdateDestinationMetadata(ctx context.Context, id string,
+	metadata *bux.Metadata) (*bux.Destination, error) {
+
+	reqBody := `
+   	mutation ($id: String!, $metadata: Map!) {
+	  destination_metadata(
+		id: $id
+		metadata: $metadata
+	  ) ` + g.selection(ctx, FieldsDestination, graphqlDestinationFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"id":       id,
+		"metadata": metadata,
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData DestinationMetadataData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, accountFrozenError(err)
+	}
+	destination := respData.Destination
+	if g.debug {
+		debugResult(g.logger, "destination metadata updated", destination, func() []logging.Field {
+			return []logging.Field{logging.F("destination_id", destination.ID)}
+		})
+	}
+
+	return destination, nil
+}
+
+// GetUtxos will get a page of the utxos matching the conditions and metadata
+func (g *TransportGraphQL) GetUtxos(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Utxo, error) {
+
+	req, reqBody, variables := searchRequest("utxos", g.selection(ctx, FieldsUtxo, graphqlUtxoFields), conditions, metadata, queryParams)
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData UtxosData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	utxos := respData.Utxos
+	if g.debug {
+		g.logger.Debug("utxos", logging.F("count", len(utxos)))
+	}
+
+	return utxos, nil
+}
+
+// GetAccessKeys will get a page of the access keys matching the conditions and metadata
+func (g *TransportGraphQL) GetAccessKeys(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.AccessKey, error) {
+
+	req, reqBody, variables := searchRequest("access_keys", g.selection(ctx, FieldsAccessKey, graphqlAccessKeyFields), conditions, metadata, queryParams)
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData AccessKeysData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	accessKeys := respData.AccessKeys
+	if g.debug {
+		g.logger.Debug("access keys", logging.F("count", len(accessKeys)))
+	}
+
+	return accessKeys, nil
+}
+
+// AdminCreatePaymail will create a new paymail address for the given xPub
+func (g *TransportGraphQL) AdminCreatePaymail(ctx context.Context, xPubID, address, publicName, avatar string,
+	metadata *bux.Metadata) (*PaymailAddress, error) {
+
+	// creating a paymail needs to be signed by an admin key
+	if err := checkAdminKey(g.adminXPriv, OperationAdminCreatePaymail); err != nil {
+		return nil, err
+	}
+
+	reqBody := `
+   	mutation ($xpub_id: String!, $address: String!, $public_name: String, $avatar: String, $metadata: Map) {
+	  admin_paymail_create(
+		xpub_id: $xpub_id
+		address: $address
+		public_name: $public_name
+		avatar: $avatar
+		metadata: $metadata
+	  ) ` + g.selection(ctx, FieldsPaymail, graphqlPaymailFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"xpub_id":     xPubID,
+		"address":     address,
+		"public_name": publicName,
+		"avatar":      avatar,
+		"metadata":    withUserAgent(metadata),
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData PaymailData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	paymailAddress := respData.Paymail
+	if g.debug {
+		debugResult(g.logger, "paymail address", paymailAddress, func() []logging.Field {
+			return []logging.Field{logging.F("address", paymailAddress.Alias+"@"+paymailAddress.Domain)}
+		})
+	}
+
+	return paymailAddress, nil
+}
+
+// AdminDeletePaymail will delete (deactivate) the given paymail address
+func (g *TransportGraphQL) AdminDeletePaymail(ctx context.Context, address string) error {
+
+	// deleting a paymail needs to be signed by an admin key
+	if err := checkAdminKey(g.adminXPriv, OperationAdminDeletePaymail); err != nil {
+		return err
+	}
+
+	reqBody := `
+   	mutation ($address: String!) {
+	  admin_paymail_delete(
+		address: $address
+	  )
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("address", address)
+	variables := map[string]interface{}{
+		"address": address,
+	}
+
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
+	if err != nil {
+		return err
+	}
+
+	// run it and capture the response
+	var respData interface{}
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return err
+	}
+	if g.debug {
+		g.logger.Debug("deleted paymail address", logging.F("address", address))
+	}
+
+	return nil
+}
+
+// AdminGetPaymails will get all paymail addresses matching the conditions and metadata
+func (g *TransportGraphQL) AdminGetPaymails(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*PaymailAddress, error) {
+
+	// listing paymails needs to be signed by an admin key
+	if err := checkAdminKey(g.adminXPriv, OperationAdminGetPaymails); err != nil {
+		return nil, err
+	}
+
+	req, reqBody, variables := searchRequest("admin_paymails_list", g.selection(ctx, FieldsPaymail, graphqlPaymailFields), conditions, metadata, queryParams)
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData PaymailsData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	paymailAddresses := respData.Paymails
+	if g.debug {
+		g.logger.Debug("paymail addresses", logging.F("count", len(paymailAddresses)))
+	}
+
+	return paymailAddresses, nil
+}
+
+// AdminFreezeXPub will freeze the xPub, its mutations are rejected until it is unfrozen
+func (g *TransportGraphQL) AdminFreezeXPub(ctx context.Context, xPubID, reason string) (*XPubStatus, error) {
+
+	// freezing an xPub needs to be signed by an admin key
+	if err := checkAdminKey(g.adminXPriv, OperationAdminFreezeXPub); err != nil {
+		return nil, err
+	}
+
+	reqBody := `
+   	mutation ($id: String!, $reason: String) {
+	  admin_xpub_freeze(
+		id: $id
+		reason: $reason
+	  ) ` + g.selection(ctx, FieldsXPubStatus, graphqlXPubStatusFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("id", xPubID)
+	req.Var("reason", reason)
+	variables := map[string]interface{}{
+		"id":     xPubID,
+		"reason": reason,
+	}
+
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData XPubFreezeData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	if g.debug {
+		g.logger.Debug("froze xpub", logging.F("xpub_id", xPubID))
+	}
+
+	return respData.XPubStatus, nil
+}
+
+// AdminUnfreezeXPub will unfreeze the xPub
+func (g *TransportGraphQL) AdminUnfreezeXPub(ctx context.Context, xPubID string) (*XPubStatus, error) {
+
+	// unfreezing an xPub needs to be signed by an admin key
+	if err := checkAdminKey(g.adminXPriv, OperationAdminUnfreezeXPub); err != nil {
+		return nil, err
+	}
+
+	reqBody := `
+   	mutation ($id: String!) {
+	  admin_xpub_unfreeze(
+		id: $id
+	  ) ` + g.selection(ctx, FieldsXPubStatus, graphqlXPubStatusFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("id", xPubID)
+	variables := map[string]interface{}{
+		"id": xPubID,
+	}
+
+	err := g.signGraphQLAdminRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData XPubUnfreezeData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	if g.debug {
+		g.logger.Debug("unfroze xpub", logging.F("xpub_id", xPubID))
+	}
+
+	return respData.XPubStatus, nil
+}
+
+// GetXPub will get the xPub of the client (its balance and next derivation numbers)
+func (g *TransportGraphQL) GetXPub(ctx context.Context) (*bux.Xpub, error) {
+
+	reqBody := `
+   	query {
+	  xpub ` + graphqlXpubFields + `
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData XpubData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	xPub := respData.Xpub
+	if g.debug {
+		debugResult(g.logger, "xpub", xPub, func() []logging.Field {
+			return []logging.Field{logging.F("xpub_id", xPub.ID)}
+		})
+	}
+
+	return xPub, nil
+}
+
+// GetXPubStatus will get the status of the xPub of the client (ex: frozen)
+func (g *TransportGraphQL) GetXPubStatus(ctx context.Context) (*XPubStatus, error) {
+
+	reqBody := `
+   	query {
+	  xpub_status ` + g.selection(ctx, FieldsXPubStatus, graphqlXPubStatusFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData XPubStatusData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	status := respData.XPubStatus
+	if g.debug {
+		debugResult(g.logger, "xpub status", status, func() []logging.Field {
+			return []logging.Field{logging.F("frozen", status.Frozen)}
+		})
+	}
+
+	return status, nil
+}
+
+// RotateXpub will replace the xPub of the client with the xPub of the new xPriv on the server, the
+// current xPub is revoked in the same operation (the request is signed with the current key, and
+// carries the proof that the client holds the new xPriv)
+func (g *TransportGraphQL) RotateXpub(ctx context.Context, newXPriv *bip32.ExtendedKey) (*bux.Xpub, error) {
+	newXPub, proof, err := rotationProof(newXPriv, g.scheduler, g.requestSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := `
+   	mutation ($xpub: String!, $proof: Map!) {
+	  xpub_rotate(
+		xpub: $xpub
+		proof: $proof
+	  ) {
+		id
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("xpub", newXPub)
+	req.Var("proof", proof)
+	variables := map[string]interface{}{
+		"xpub":  newXPub,
+		"proof": proof,
+	}
+
+	if err = g.signGraphQLRequest(req, reqBody, variables); err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData XpubRotateData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	xPub := respData.Xpub
+	if g.debug {
+		debugResult(g.logger, "rotated xpub", xPub, func() []logging.Field {
+			return []logging.Field{logging.F("xpub_id", xPub.ID)}
+		})
+	}
+
+	return xPub, nil
+}
+
+// CreateAccessKey will create a new access key for the xPub, the private key is only returned once
+func (g *TransportGraphQL) CreateAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error) {
+	reqBody := `
+   	mutation ($metadata: Map) {
+	  access_key(
+		metadata: $metadata
+	  ) ` + g.selection(ctx, FieldsAccessKey, graphqlAccessKeyFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("metadata", withUserAgent(metadata))
+	variables := map[string]interface{}{
+		"metadata": withUserAgent(metadata),
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData AccessKeyData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	accessKey := respData.AccessKey
+	if g.debug {
+		debugResult(g.logger, "created access key", accessKey, func() []logging.Field {
+			return []logging.Field{logging.F("id", accessKey.ID)}
+		})
+	}
+
+	return accessKey, nil
+}
+
+// RevokeAccessKey will revoke the access key
+func (g *TransportGraphQL) RevokeAccessKey(ctx context.Context, id string) (*bux.AccessKey, error) {
+	reqBody := `
+   	mutation ($id: String!) {
+	  access_key_revoke(
+		id: $id
+	  ) ` + g.selection(ctx, FieldsAccessKey, graphqlAccessKeyFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("id", id)
+	variables := map[string]interface{}{
+		"id": id,
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData AccessKeyRevokeData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	if g.debug {
+		g.logger.Debug("revoked access key", logging.F("id", id))
+	}
+
+	return respData.AccessKey, nil
+}
+
+// RegisterWebhook will register a webhook endpoint for the given event types
+func (g *TransportGraphQL) RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType,
+	secret string) (*Webhook, error) {
+
+	reqBody := `
+   	mutation ($url: String!, $event_types: [String]!, $secret: String) {
+	  webhook_create(
+		url: $url
+		event_types: $event_types
+		secret: $secret
+	  ) ` + g.selection(ctx, FieldsWebhook, graphqlWebhookFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"url":         url,
+		"event_types": eventTypes,
+		"secret":      secret,
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData WebhookData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	webhook := respData.Webhook
+	if g.debug {
+		debugResult(g.logger, "webhook", webhook, func() []logging.Field {
+			return []logging.Field{logging.F("webhook_id", webhook.ID)}
+		})
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooks will get all registered webhook endpoints
+func (g *TransportGraphQL) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	reqBody := `
+   	query {
+	  webhooks ` + g.selection(ctx, FieldsWebhook, graphqlWebhookFields) + `
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData WebhooksData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	webhooks := respData.Webhooks
+	if g.debug {
+		g.logger.Debug("webhooks", logging.F("count", len(webhooks)))
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook will delete a registered webhook endpoint
+func (g *TransportGraphQL) DeleteWebhook(ctx context.Context, id string) error {
+
+	reqBody := `
+   	mutation ($id: String!) {
+	  webhook_delete(
+		id: $id
+	  )
+	}`
+	req := graphql.NewRequest(reqBody)
+	req.Var("id", id)
+	variables := map[string]interface{}{
+		"id": id,
+	}
+
+	err := g.signGraphQLRequest(req, reqBody, variables)
+	if err != nil {
+		return err
+	}
+
+	// run it and capture the response
+	var respData interface{}
+	return g.client.Run(ctx, req, &respData)
+}
+
+// GetFeatureFlags will get the feature flags of the server
+func (g *TransportGraphQL) GetFeatureFlags(ctx context.Context) (map[string]bool, error) {
+
+	reqBody := `
+   	query {
+	  features
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData FeatureFlagsData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	if g.debug {
+		g.logger.Debug("feature flags", logging.F("feature_flags", respData.FeatureFlags))
+	}
+
+	return respData.FeatureFlags, nil
+}
+
+// Notifications will subscribe to the server-sent events notification stream, reconnecting
+// automatically until the context is done. The stream is served next to the graphql endpoint.
+func (g *TransportGraphQL) Notifications(ctx context.Context) (<-chan *events.Event, error) {
+	keys := g.keys()
+	if keys.missing() {
+		return nil, ErrMissingKeys
+	}
+
+	url := strings.TrimSuffix(strings.TrimSuffix(g.server, "/"), "/graphql") + NotificationsPath
+	authorize := authorizeNotifications(keys.xPriv, keys.xPub, keys.accessKey, g.signRequest, g.scheduler, g.requestSigner)
+	return subscribeNotifications(ctx, g.httpClient, g.logger, g.scheduler, url, g.debug, authorize,
+		g.checkpoints, notificationsCheckpointKey(url, keys.xPub, keys.accessKey)), nil
+}
+
+func getBodyString(reqBody string, variables map[string]interface{}) (string, error) {
+	requestBodyObj := struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}{
+		Query:     reqBody,
+		Variables: variables,
+	}
+
+	body, err := json.Marshal(requestBodyObj)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// searchRequest will build the request of a page of the records of the listing query matching the
+// conditions and metadata, all the listing queries take the same arguments
+func searchRequest(query, fields string, conditions map[string]interface{}, metadata *bux.Metadata,
+	queryParams *QueryParams) (*graphql.Request, string, map[string]interface{}) {
+
+	reqBody := `
+   	query ($conditions: Map, $metadata: Map, $params: QueryParams) {
+	  ` + query + `(
+		conditions: $conditions
+		metadata: $metadata
+		params: $params
+	  ) ` + fields + `
+	}`
+	req := graphql.NewRequest(reqBody)
+	variables := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+	return req, reqBody, variables
+}
+
+func (g *TransportGraphQL) signGraphQLRequest(req *graphql.Request, reqBody string, variables map[string]interface{}) error {
+	return g.signGraphQLHeader(req.Header, reqBody, variables)
+}
+
+// signGraphQLHeader will sign with the xPriv when signing is enabled, or else with the access key
+// (always signed), and otherwise only set the xPub (watch-only clients can not sign)
+func (g *TransportGraphQL) signGraphQLHeader(header http.Header, reqBody string, variables map[string]interface{}) error {
+	keys := g.keys()
+	if keys.accessKey == nil && (!g.signRequest || keys.xPriv == nil && keys.xPub != nil) {
+		if keys.xPub == nil {
+			return ErrMissingKeys
+		}
+		header.Set("auth_xpub", keys.xPub.String())
+		return nil
+	}
+
+	bodyString, err := getBodyString(reqBody, variables)
+	if err != nil {
+		return err
+	}
+	if keys.accessKey != nil && (keys.xPriv == nil || !g.signRequest) {
+		return addAccessKeySignature(&header, keys.accessKey, bodyString, g.scheduler, g.requestSigner)
+	}
+	return addSignature(&header, keys.xPriv, bodyString, g.scheduler, g.requestSigner)
+}
+
+func (g *TransportGraphQL) signGraphQLAdminRequest(req *graphql.Request, reqBody string, variables map[string]interface{}) error {
+	bodyString, err := getBodyString(reqBody, variables)
+	if err != nil {
+		return err
+	}
+	return addSignature(&req.Header, g.adminXPriv, bodyString, g.scheduler, g.requestSigner)
+}
+
+const graphqlAccessKeyFields = `{
+id
+xpub_id
+key
+created_at
+revoked_at
+}`
+
+const graphqlUtxoFields = `{
+id
+transaction_id
+xpub_id
+output_index
+satoshis
+script_pub_key
+type
+draft_id
+reserved_at
+spending_tx_id
+metadata
+created_at
+}`
+
+const graphqlXpubFields = `{
+id
+current_balance
+next_internal_num
+next_external_num
+metadata
+created_at
+}`
+
+const graphqlXPubStatusFields = `{
+id
+frozen
+frozen_at
+reason
+}`
+
+const graphqlPaymailFields = `{
+id
+xpub_id
+alias
+domain
+public_name
+avatar
+metadata
+created_at
+updated_at
+}`
+
+const graphqlDestinationFields = `{
+id
+xpub_id
+locking_script
+type
+chain
+num
+address
+metadata
+}`
+
+const graphqlTransactionFields = `{
+id
+hex
+xpub_in_ids
+xpub_out_ids
+block_hash
+block_height
+fee
+number_of_inputs
+number_of_outputs
+draft_id
+total_value
+output_value
+direction
+status
+metadata
+created_at
+}`
+
+const graphqlWebhookFields = `{
+id
+url
+event_types
+created_at
+}`
+
 const graphqlDraftTransactionFields = `{
 id
 xpub_id
@@ -0,0 +1,56 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// graphQLErrorCodesKey is the context key of the error codes collected for a graphql request
+type graphQLErrorCodesKey struct{}
+
+// graphQLErrorCodes are the codes (extensions.code) of the errors of the graphql responses of a
+// request, the graphql client only returns the messages of the errors
+type graphQLErrorCodes struct {
+	codes []string
+}
+
+// withGraphQLErrorCodes will return a context collecting the error codes of the graphql responses
+func withGraphQLErrorCodes(ctx context.Context) (context.Context, *graphQLErrorCodes) {
+	codes := &graphQLErrorCodes{}
+	return context.WithValue(ctx, graphQLErrorCodesKey{}, codes), codes
+}
+
+// graphQLErrorCodesRoundTripper collects the error codes of the responses of the requests whose
+// context collects them (see withGraphQLErrorCodes), the other responses are not read
+type graphQLErrorCodesRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip will send the request, and collect the error codes of its response
+func (g *graphQLErrorCodesRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := g.next.RoundTrip(req)
+	codes, ok := req.Context().Value(graphQLErrorCodesKey{}).(*graphQLErrorCodes)
+	if err != nil || !ok {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var response persistedQueryResponse
+	if json.Unmarshal(body, &response) == nil {
+		for _, graphqlErr := range response.Errors {
+			if graphqlErr.Extensions.Code != "" {
+				codes.codes = append(codes.codes, graphqlErr.Extensions.Code)
+			}
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
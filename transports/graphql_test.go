@@ -2,20 +2,27 @@ package transports
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/BuxOrg/bux"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/bitcoinschema/go-bitcoin/v2"
 	"github.com/libsv/go-bk/bip32"
 	"github.com/machinebox/graphql"
 	"github.com/stretchr/testify/assert"
 )
 
 const (
-	xPrivString = "xprv9s21ZrQH143K3N6qVJQAu4EP51qMcyrKYJLkLgmYXgz58xmVxVLSsbx2DfJUtjcnXK8NdvkHMKfmmg5AJT2nqqRWUrjSHX29qEJwBgBPkJQ"
-	xPubString  = "xpub661MyMwAqRbcFrBJbKwBGCB7d3fr2SaAuXGM95BA62X41m6eW2ehRQGW4xLi9wkEXUGnQZYxVVj4PxXnyrLk7jdqvBAs1Qq9gf6ykMvjR7J"
+	accessKeyString = "7779d24ca6f8821f225042bf55e8f80aa41b08b879b72827f51e41e6523b9cd0"
+	xPrivString     = "xprv9s21ZrQH143K3N6qVJQAu4EP51qMcyrKYJLkLgmYXgz58xmVxVLSsbx2DfJUtjcnXK8NdvkHMKfmmg5AJT2nqqRWUrjSHX29qEJwBgBPkJQ"
+	xPubString      = "xpub661MyMwAqRbcFrBJbKwBGCB7d3fr2SaAuXGM95BA62X41m6eW2ehRQGW4xLi9wkEXUGnQZYxVVj4PxXnyrLk7jdqvBAs1Qq9gf6ykMvjR7J"
 )
 
 // TransportGraphQLMock ...
@@ -154,6 +161,46 @@ func TestGetDestination(t *testing.T) {
 		assert.Len(t, graphqlClient.Request.Header, 1)
 		assert.Contains(t, graphqlClient.Request.Header, "Auth_xpub")
 	})
+
+	t.Run("access key success", func(t *testing.T) {
+		accessKey, err := bitcoin.PrivateKeyFromString(accessKeyString)
+		assert.NoError(t, err)
+		graphqlClient := GraphQLMockClient{
+			Response: DestinationData{
+				Destination: &bux.Destination{
+					Address: "test-address",
+				},
+			},
+		}
+		client := TransportGraphQLMock{
+			TransportGraphQL: TransportGraphQL{
+				accessKey: accessKey,
+				client:    &graphqlClient,
+			},
+		}
+		destination, err := client.GetDestination(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-address", destination.Address)
+
+		header := graphqlClient.Request.Header
+		assert.Len(t, header, 5)
+		assert.Equal(t, hex.EncodeToString(accessKey.PubKey().SerialiseCompressed()), header.Get(bux.AuthAccessKey))
+		address, err := bitcoin.GetAddressFromPubKey(accessKey.PubKey(), true)
+		assert.NoError(t, err)
+		message := header.Get(bux.AuthAccessKey) + header.Get(bux.AuthHeaderHash) +
+			header.Get(bux.AuthHeaderNonce) + header.Get(bux.AuthHeaderTime)
+		assert.NoError(t, bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message))
+	})
+
+	t.Run("missing keys", func(t *testing.T) {
+		client := TransportGraphQLMock{
+			TransportGraphQL: TransportGraphQL{
+				client: &GraphQLMockClient{},
+			},
+		}
+		_, err := client.GetDestination(context.Background(), nil)
+		assert.ErrorIs(t, err, ErrMissingKeys)
+	})
 }
 
 // TestDraftTransaction will test the DraftTransaction method
@@ -171,7 +218,7 @@ func TestDraftTransaction(t *testing.T) {
 				client:      &GraphQLMockClient{},
 			},
 		}
-		destination, err := client.DraftTransaction(context.Background(), config, nil)
+		destination, err := client.DraftTransaction(context.Background(), config, nil, nil)
 		assert.ErrorIs(t, err, bux.ErrMissingXPriv)
 		assert.Nil(t, destination)
 	})
@@ -192,7 +239,7 @@ func TestDraftTransaction(t *testing.T) {
 				signRequest: true,
 			},
 		}
-		draftTransaction, err := client.DraftTransaction(context.Background(), config, nil)
+		draftTransaction, err := client.DraftTransaction(context.Background(), config, nil, nil)
 		assert.NoError(t, err)
 		assert.IsType(t, &bux.DraftTransaction{}, draftTransaction)
 		checkAuthHeaders(t, graphqlClient)
@@ -215,7 +262,7 @@ func TestDraftToRecipients(t *testing.T) {
 				client:      &GraphQLMockClient{},
 			},
 		}
-		destination, err := client.DraftToRecipients(context.Background(), recipients, nil)
+		destination, err := client.DraftToRecipients(context.Background(), recipients, nil, nil)
 		assert.ErrorIs(t, err, bux.ErrMissingXPriv)
 		assert.Nil(t, destination)
 	})
@@ -236,13 +283,122 @@ func TestDraftToRecipients(t *testing.T) {
 				signRequest: true,
 			},
 		}
-		draftTransaction, err := client.DraftToRecipients(context.Background(), recipients, nil)
+		draftTransaction, err := client.DraftToRecipients(context.Background(), recipients, nil, nil)
 		assert.NoError(t, err)
 		assert.IsType(t, &bux.DraftTransaction{}, draftTransaction)
 		checkAuthHeaders(t, graphqlClient)
 	})
 }
 
+// TestGraphQLVariables will test that the values are sent as signed variables, not in the query
+func TestGraphQLVariables(t *testing.T) {
+	xPriv, _ := bip32.NewKeyFromString(xPrivString)
+	xPub, _ := xPriv.Neuter()
+	value := `", metadata: {injected: true}) { id } #\`
+
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	var authHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		authHash = req.Header.Get(bux.AuthHeaderHash)
+		request.Variables = nil
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&request))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"not found"}]}`))
+	}))
+	defer server.Close()
+
+	operations := map[string]func(client *TransportGraphQL) map[string]interface{}{
+		"RegisterXpub": func(client *TransportGraphQL) map[string]interface{} {
+			_ = client.RegisterXpub(context.Background(), value, nil)
+			return map[string]interface{}{"xpub": value}
+		},
+		"GetTransaction": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetTransaction(context.Background(), value)
+			return map[string]interface{}{"txId": value}
+		},
+		"RunGraphQL": func(client *TransportGraphQL) map[string]interface{} {
+			_ = client.RunGraphQL(context.Background(), `query ($txId: String!) { transaction(txId: $txId) { id } }`,
+				map[string]interface{}{"txId": value}, &TransactionData{})
+			return map[string]interface{}{"txId": value}
+		},
+		"GetTransactionStatus": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetTransactionStatus(context.Background(), value)
+			return map[string]interface{}{"txId": value}
+		},
+		"GetMerkleProof": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetMerkleProof(context.Background(), value)
+			return map[string]interface{}{"txId": value}
+		},
+		"GetBlockHeader": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetBlockHeader(context.Background(), value)
+			return map[string]interface{}{"hash": value}
+		},
+		"Count": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.Count(context.Background(), CountUtxos, map[string]interface{}{"transaction_id": value}, nil)
+			return map[string]interface{}{"conditions": map[string]interface{}{"transaction_id": value}}
+		},
+		"GetAccessKeys": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetAccessKeys(context.Background(), map[string]interface{}{"id": value}, nil, nil)
+			return map[string]interface{}{"conditions": map[string]interface{}{"id": value}}
+		},
+		"GetUtxos": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetUtxos(context.Background(), map[string]interface{}{"transaction_id": value}, nil,
+				&QueryParams{Page: 2, PageSize: 10})
+			return map[string]interface{}{
+				"conditions": map[string]interface{}{"transaction_id": value},
+				"params":     map[string]interface{}{"page": float64(2), "page_size": float64(10)},
+			}
+		},
+		"GetTransactionBEEF": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.GetTransactionBEEF(context.Background(), value)
+			return map[string]interface{}{"txId": value}
+		},
+		"ImportTransaction": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.ImportTransaction(context.Background(), value, nil)
+			return map[string]interface{}{"tx_id": value}
+		},
+		"RecordTransaction": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.RecordTransaction(context.Background(), value, value, nil)
+			return map[string]interface{}{"hex": value, "draft_id": value}
+		},
+		"RecordTransactionBEEF": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.RecordTransactionBEEF(context.Background(), value, value, nil)
+			return map[string]interface{}{"beef": value, "draft_id": value}
+		},
+		"RecordTransactions": func(client *TransportGraphQL) map[string]interface{} {
+			_, _ = client.RecordTransactions(context.Background(), []*RecordRequest{{Hex: value, ReferenceID: value}})
+			return map[string]interface{}{"hex_batch0": value, "draft_id_batch0": value}
+		},
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			client := &TransportGraphQL{
+				adminXPriv:  xPriv,
+				client:      graphql.NewClient(server.URL),
+				logger:      logging.Default(),
+				scheduler:   scheduler.Default(),
+				signRequest: true,
+				xPriv:       xPriv,
+				xPub:        xPub,
+			}
+			expected := operation(client)
+
+			assert.NotContains(t, request.Query, "injected")
+			for name, expectedValue := range expected {
+				assert.Equal(t, expectedValue, request.Variables[name])
+			}
+
+			// the variables are signed
+			body, err := getBodyString(request.Query, request.Variables)
+			assert.NoError(t, err)
+			assert.Equal(t, utils.Hash(body), authHash)
+		})
+	}
+}
+
 func checkAuthHeaders(t *testing.T, graphqlClient GraphQLMockClient) {
 	assert.Len(t, graphqlClient.Request.Header, 5)
 	assert.Contains(t, graphqlClient.Request.Header, "Auth_hash")
@@ -0,0 +1,77 @@
+package transports
+
+import (
+	"context"
+	"net/http"
+)
+
+// headersContextKey is the context key of the per-request headers
+type headersContextKey struct{}
+
+// WithHeaders will set custom headers sent with every request (ex: X-Tenant-ID), the headers
+// managed by the client (authentication, content type) cannot be overwritten
+func WithHeaders(headers http.Header) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			if c.headers == nil {
+				c.headers = make(http.Header)
+			}
+			for key, values := range headers {
+				c.headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+			}
+		}
+	}
+}
+
+// ContextWithHeaders will return a context sending custom headers with the requests made with it,
+// they are added to the headers of the client (and replace them for the same key)
+func ContextWithHeaders(ctx context.Context, headers http.Header) context.Context {
+	merged := make(http.Header)
+	for key, values := range HeadersFromContext(ctx) {
+		merged[key] = values
+	}
+	for key, values := range headers {
+		merged[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, headersContextKey{}, merged)
+}
+
+// HeadersFromContext will return the custom headers of the context (nil if none)
+func HeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(headersContextKey{}).(http.Header)
+	return headers
+}
+
+// addCustomHeaders will add the client and context headers that are not already set on the header
+func addCustomHeaders(ctx context.Context, header, clientHeaders http.Header) {
+	custom := make(http.Header)
+	for key, values := range clientHeaders {
+		custom[key] = values
+	}
+	for key, values := range HeadersFromContext(ctx) {
+		custom[key] = values
+	}
+
+	for key, values := range custom {
+		if _, ok := header[key]; !ok {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+// headersRoundTripper adds the custom headers to every request
+type headersRoundTripper struct {
+	headers http.Header
+	next    http.RoundTripper
+}
+
+// RoundTrip will add the custom headers to a copy of the request and execute it
+func (h *headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(h.headers) == 0 && len(HeadersFromContext(req.Context())) == 0 {
+		return h.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	addCustomHeaders(req.Context(), req.Header, h.headers)
+	return h.next.RoundTrip(req)
+}
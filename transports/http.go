@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
-	"strconv"
+	"net/url"
+	"strings"
+	"sync"
 
 	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
 	"github.com/bitcoinschema/go-bitcoin/v2"
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/bip32"
@@ -18,14 +23,19 @@ import (
 
 // TransportHTTP is the struct for HTTP
 type TransportHTTP struct {
-	accessKey   *bec.PrivateKey
-	adminXPriv  *bip32.ExtendedKey
-	debug       bool
-	httpClient  *http.Client
-	server      string
-	signRequest bool
-	xPriv       *bip32.ExtendedKey
-	xPub        *bip32.ExtendedKey
+	accessKey     *bec.PrivateKey
+	adminXPriv    *bip32.ExtendedKey
+	checkpoints   store.Store
+	debug         bool
+	httpClient    *http.Client
+	keysLock      sync.RWMutex // the keys are replaced while requests run
+	logger        logging.Logger
+	requestSigner RequestSigner
+	scheduler     scheduler.Scheduler
+	server        string
+	signRequest   bool
+	xPriv         *bip32.ExtendedKey
+	xPub          *bip32.ExtendedKey
 }
 
 // Init will initialize
@@ -33,6 +43,26 @@ func (h *TransportHTTP) Init() error {
 	return nil
 }
 
+// SetXPriv will replace the xPriv (and xPub) used to sign the requests, see BuxClient.RotateXPriv
+func (h *TransportHTTP) SetXPriv(xPriv *bip32.ExtendedKey) error {
+	xPub, err := xPriv.Neuter()
+	if err != nil {
+		return err
+	}
+	h.keysLock.Lock()
+	defer h.keysLock.Unlock()
+	h.xPriv = xPriv
+	h.xPub = xPub
+	return nil
+}
+
+// SetAccessKey will replace the access key used to sign the requests, see BuxClient.ReplaceAccessKey
+func (h *TransportHTTP) SetAccessKey(accessKey *bec.PrivateKey) {
+	h.keysLock.Lock()
+	defer h.keysLock.Unlock()
+	h.accessKey = accessKey
+}
+
 // SetDebug turn the debugging on or off
 func (h *TransportHTTP) SetDebug(debug bool) {
 	h.debug = debug
@@ -43,6 +73,21 @@ func (h *TransportHTTP) IsDebug() bool {
 	return h.debug
 }
 
+// SetLogger set the logger of the debug output
+func (h *TransportHTTP) SetLogger(logger logging.Logger) {
+	h.logger = logger
+}
+
+// SetScheduler set the scheduler of the reconnect and resubscribe waits
+func (h *TransportHTTP) SetScheduler(scheduler scheduler.Scheduler) {
+	h.scheduler = scheduler
+}
+
+// SetRequestSigner set the signer of the requests (BSMSigner when nil)
+func (h *TransportHTTP) SetRequestSigner(signer RequestSigner) {
+	h.requestSigner = signer
+}
+
 // SetSignRequest turn the signing of the http request on or off
 func (h *TransportHTTP) SetSignRequest(signRequest bool) {
 	h.signRequest = signRequest
@@ -53,21 +98,31 @@ func (h *TransportHTTP) IsSignRequest() bool {
 	return h.signRequest
 }
 
+// wrapRoundTripper will wrap the round tripper of the http client (before Init)
+func (h *TransportHTTP) wrapRoundTripper(wrap func(transport TransportType, next http.RoundTripper) http.RoundTripper) {
+	h.httpClient = wrapHTTPClient(h.httpClient, BuxTransportHTTP, wrap)
+}
+
 // SetAdminKey set the admin key
 func (h *TransportHTTP) SetAdminKey(adminKey *bip32.ExtendedKey) {
 	h.adminXPriv = adminKey
 }
 
+// HasAdminKey return whether the admin key is set
+func (h *TransportHTTP) HasAdminKey() bool {
+	return h.adminXPriv != nil
+}
+
 // RegisterXpub will register an xPub
 func (h *TransportHTTP) RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error {
 
 	// adding an xpub needs to be signed by an admin key
-	if h.adminXPriv == nil {
-		return ErrAdminKey
+	if err := checkAdminKey(h.adminXPriv, OperationRegisterXpub); err != nil {
+		return err
 	}
 
 	jsonData := map[string]interface{}{
-		"metadata": processMetadata(metadata),
+		"metadata": withUserAgent(metadata),
 		"key":      rawXPub,
 	}
 
@@ -88,7 +143,7 @@ func (h *TransportHTTP) RegisterXpub(ctx context.Context, rawXPub string, metada
 // GetDestination will get a destination
 func (h *TransportHTTP) GetDestination(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error) {
 	jsonData := map[string]interface{}{
-		"metadata": processMetadata(metadata),
+		"metadata": withUserAgent(metadata),
 	}
 
 	jsonStr, err := json.Marshal(jsonData)
@@ -97,24 +152,62 @@ func (h *TransportHTTP) GetDestination(ctx context.Context, metadata *bux.Metada
 	}
 
 	var destination bux.Destination
-	err = h.doHTTPRequest(ctx, "POST", "/destinations", jsonStr, h.xPriv, true, &destination)
+	err = h.doHTTPRequest(ctx, "POST", "/destinations", jsonStr, h.keys().xPriv, true, &destination)
 	if err != nil {
 		return nil, err
 	}
 	if h.debug {
-		fmt.Printf("Address for new destination: %s\n", destination.Address)
+		h.logger.Debug("new destination", logging.F("address", destination.Address))
 	}
 
 	return &destination, nil
 }
 
+// GetDestinationByAddress will get the destination of the xPub with the address
+func (h *TransportHTTP) GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error) {
+
+	var destination *bux.Destination
+	err := h.doHTTPRequest(ctx, "GET", "/destination?address="+url.QueryEscape(address), nil, h.keys().xPriv,
+		h.signRequest, &destination)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "destination", destination, func() []logging.Field {
+			return []logging.Field{logging.F("address", destination.Address)}
+		})
+	}
+
+	return destination, nil
+}
+
+// NewDestinations will get new destinations, with a request per destination (the http api has no
+// bulk endpoint), the destinations created before an error are returned with it
+func (h *TransportHTTP) NewDestinations(ctx context.Context, count int,
+	metadata *bux.Metadata) ([]*bux.Destination, error) {
+
+	destinations := make([]*bux.Destination, 0, count)
+	for len(destinations) < count {
+		destination, err := h.GetDestination(ctx, metadata)
+		if err != nil {
+			return destinations, err
+		}
+		destinations = append(destinations, destination)
+	}
+	return destinations, nil
+}
+
 // DraftTransaction is a draft transaction
 func (h *TransportHTTP) DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig,
-	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
+	metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error) {
 
+	config, err := draftConfig(transactionConfig, opts)
+	if err != nil {
+		return nil, err
+	}
 	jsonData := map[string]interface{}{
-		"config":   transactionConfig,
-		"metadata": processMetadata(metadata),
+		"config":   config,
+		"metadata": withUserAgent(metadata),
 	}
 
 	return h.createDraftTransaction(ctx, jsonData)
@@ -122,21 +215,15 @@ func (h *TransportHTTP) DraftTransaction(ctx context.Context, transactionConfig
 
 // DraftToRecipients is a draft transaction to a slice of recipients
 func (h *TransportHTTP) DraftToRecipients(ctx context.Context, recipients []*Recipients,
-	metadata *bux.Metadata) (*bux.DraftTransaction, error) {
-
-	outputs := make([]map[string]interface{}, 0)
-	for _, recipient := range recipients {
-		outputs = append(outputs, map[string]interface{}{
-			"to":        recipient.To,
-			"satoshis":  recipient.Satoshis,
-			"op_return": recipient.OpReturn,
-		})
+	metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error) {
+
+	config, err := draftConfig(recipientsConfig(recipients), opts)
+	if err != nil {
+		return nil, err
 	}
 	jsonData := map[string]interface{}{
-		"config": map[string]interface{}{
-			"outputs": outputs,
-		},
-		"metadata": processMetadata(metadata),
+		"config":   config,
+		"metadata": withUserAgent(metadata),
 	}
 
 	return h.createDraftTransaction(ctx, jsonData)
@@ -149,12 +236,14 @@ func (h *TransportHTTP) createDraftTransaction(ctx context.Context, jsonData map
 	}
 
 	var draftTransaction *bux.DraftTransaction
-	err = h.doHTTPRequest(ctx, "POST", "/transactions/new", jsonStr, h.xPriv, true, &draftTransaction)
+	err = h.doHTTPRequest(ctx, "POST", "/transactions/new", jsonStr, h.keys().xPriv, true, &draftTransaction)
 	if err != nil {
 		return nil, err
 	}
 	if h.debug {
-		fmt.Printf("Draft transaction: %v\n", draftTransaction)
+		debugResult(h.logger, "draft transaction", draftTransaction, func() []logging.Field {
+			return []logging.Field{logging.F("draft_id", draftTransaction.ID)}
+		})
 	}
 
 	return draftTransaction, nil
@@ -164,24 +253,176 @@ func (h *TransportHTTP) createDraftTransaction(ctx context.Context, jsonData map
 func (h *TransportHTTP) GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error) {
 
 	var transaction *bux.Transaction
-	err := h.doHTTPRequest(ctx, "GET", "/transaction?id="+txID, nil, h.xPriv, h.signRequest, &transaction)
+	err := h.doHTTPRequest(ctx, "GET", "/transaction?id="+txID, nil, h.keys().xPriv, h.signRequest, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// GetTransactionStatus will get the status of a transaction on the network
+func (h *TransportHTTP) GetTransactionStatus(ctx context.Context, txID string) (*TransactionStatus, error) {
+
+	var status *TransactionStatus
+	err := h.doHTTPRequest(ctx, "GET", "/transaction/status?id="+url.QueryEscape(txID), nil, h.keys().xPriv, h.signRequest, &status)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "transaction status", status, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", status.TxID), logging.F("mined", status.Mined)}
+		})
+	}
+
+	return status, nil
+}
+
+// GetMerkleProof will get the merkle proof of a mined transaction
+func (h *TransportHTTP) GetMerkleProof(ctx context.Context, txID string) (*MerkleProof, error) {
+
+	var merkleProof *MerkleProof
+	err := h.doHTTPRequest(ctx, "GET", "/transaction/merkle_proof?id="+url.QueryEscape(txID), nil, h.keys().xPriv, h.signRequest, &merkleProof)
+	if err != nil {
+		return nil, err
+	}
+	if merkleProof == nil {
+		return nil, ErrMissingMerkleProof
+	}
+	if h.debug {
+		h.logger.Debug("merkle proof", logging.F("tx_id", merkleProof.TxID), logging.F("target", merkleProof.Target))
+	}
+
+	return merkleProof, nil
+}
+
+// GetBlockHeader will get a block header by hash
+func (h *TransportHTTP) GetBlockHeader(ctx context.Context, blockHash string) (*BlockHeader, error) {
+
+	var blockHeader *BlockHeader
+	err := h.doHTTPRequest(ctx, "GET", "/block_header?hash="+url.QueryEscape(blockHash), nil, h.keys().xPriv, h.signRequest, &blockHeader)
+	if err != nil {
+		return nil, err
+	}
+	if blockHeader == nil {
+		return nil, ErrMissingBlockHeader
+	}
+	if h.debug {
+		h.logger.Debug("block header", logging.F("hash", blockHeader.Hash), logging.F("height", blockHeader.Height))
+	}
+
+	return blockHeader, nil
+}
+
+// GetFeeQuote will get the fee policy of the server
+func (h *TransportHTTP) GetFeeQuote(ctx context.Context) (*FeeQuote, error) {
+
+	var feeQuote *FeeQuote
+	err := h.doHTTPRequest(ctx, "GET", "/fee_quote", nil, h.keys().xPriv, h.signRequest, &feeQuote)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "fee quote", feeQuote, func() []logging.Field {
+			return []logging.Field{logging.F("miner", feeQuote.Miner)}
+		})
+	}
+
+	return feeQuote, nil
+}
+
+// GetTransactionBEEF will get a transaction in the BEEF format, with its unmined ancestors and the
+// merkle paths of the mined ones
+func (h *TransportHTTP) GetTransactionBEEF(ctx context.Context, txID string) (*TransactionBEEF, error) {
+
+	var transaction *TransactionBEEF
+	err := h.doHTTPRequest(ctx, "GET", "/transaction/beef?id="+url.QueryEscape(txID), nil, h.keys().xPriv, h.signRequest, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil || transaction.BEEF == "" {
+		return nil, ErrMissingBEEF
+	}
+	if h.debug {
+		debugResult(h.logger, "transaction beef", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.TxID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// RecordTransactionBEEF will record a transaction in the BEEF format (hex encoded)
+func (h *TransportHTTP) RecordTransactionBEEF(ctx context.Context, beef, referenceID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	jsonData := map[string]interface{}{
+		"beef":         beef,
+		"reference_id": referenceID,
+		"metadata":     withUserAgent(metadata),
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction *bux.Transaction
+	err = h.doHTTPRequest(ctx, "POST", "/transactions/record", jsonStr, h.keys().xPriv, h.signRequest, &transaction)
 	if err != nil {
 		return nil, err
 	}
 	if h.debug {
-		fmt.Printf("Transaction: %s\n", transaction.ID)
+		debugResult(h.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
 	}
 
 	return transaction, nil
 }
 
-// GetTransactions will get a transactions by
+// ImportTransaction will ask the server to import a transaction of the chain that pays the xPub
+func (h *TransportHTTP) ImportTransaction(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	jsonData := map[string]interface{}{
+		"tx_id":    txID,
+		"metadata": withUserAgent(metadata),
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction *bux.Transaction
+	err = h.doHTTPRequest(ctx, "POST", "/transaction/import", jsonStr, h.keys().xPriv, h.signRequest, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "imported transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return transaction, nil
+}
+
+// GetTransactions will get the transactions matching the conditions and metadata (a page of them
+// with the query params)
 func (h *TransportHTTP) GetTransactions(ctx context.Context, conditions map[string]interface{},
-	metadata *bux.Metadata) ([]*bux.Transaction, error) {
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Transaction, error) {
 
 	jsonData := map[string]interface{}{
 		"conditions": conditions,
-		"metadata":   processMetadata(metadata),
+		"metadata":   withUserAgent(metadata),
+		"params":     queryParams,
 	}
 
 	jsonStr, err := json.Marshal(jsonData)
@@ -190,12 +431,12 @@ func (h *TransportHTTP) GetTransactions(ctx context.Context, conditions map[stri
 	}
 
 	var transactions []*bux.Transaction
-	err = h.doHTTPRequest(ctx, "POST", "/transactions", jsonStr, h.xPriv, h.signRequest, &transactions)
+	err = h.doHTTPRequest(ctx, "POST", "/transactions", jsonStr, h.keys().xPriv, h.signRequest, &transactions)
 	if err != nil {
 		return nil, err
 	}
 	if h.debug {
-		fmt.Printf("Transactions: %d\n", len(transactions))
+		h.logger.Debug("transactions", logging.F("count", len(transactions)))
 	}
 
 	return transactions, nil
@@ -208,7 +449,7 @@ func (h *TransportHTTP) RecordTransaction(ctx context.Context, hex, referenceID
 	jsonData := map[string]interface{}{
 		"hex":          hex,
 		"reference_id": referenceID,
-		"metadata":     processMetadata(metadata),
+		"metadata":     withUserAgent(metadata),
 	}
 
 	jsonStr, err := json.Marshal(jsonData)
@@ -217,17 +458,541 @@ func (h *TransportHTTP) RecordTransaction(ctx context.Context, hex, referenceID
 	}
 
 	var transaction *bux.Transaction
-	err = h.doHTTPRequest(ctx, "POST", "/transactions/record", jsonStr, h.xPriv, h.signRequest, &transaction)
+	err = h.doHTTPRequest(ctx, "POST", "/transactions/record", jsonStr, h.keys().xPriv, h.signRequest, &transaction)
 	if err != nil {
 		return nil, err
 	}
 	if h.debug {
-		fmt.Printf("Transaction: %s\n", transaction.ID)
+		debugResult(h.logger, "transaction", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
 	}
 
 	return transaction, nil
 }
 
+// RecordTransactions will record the transactions, with a request per transaction (the http api has
+// no batch endpoint), the transactions that fail are skipped and the first error is returned
+func (h *TransportHTTP) RecordTransactions(ctx context.Context,
+	requests []*RecordRequest) ([]*bux.Transaction, error) {
+
+	transactions := make([]*bux.Transaction, len(requests))
+	var firstErr error
+	for index, request := range requests {
+		transaction, err := h.RecordTransaction(ctx, request.Hex, request.ReferenceID, request.Metadata)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		transactions[index] = transaction
+	}
+	return transactions, firstErr
+}
+
+// SearchTransactions will get a page of the transactions matching the conditions and metadata
+func (h *TransportHTTP) SearchTransactions(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Transaction, error) {
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []*bux.Transaction
+	err = h.doHTTPRequest(ctx, "POST", "/transactions/search", jsonStr, h.keys().xPriv, h.signRequest, &transactions)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("transactions", logging.F("count", len(transactions)))
+	}
+
+	return transactions, nil
+}
+
+// UpdateTransactionMetadata will update the metadata of the transaction (keys set to nil are removed)
+func (h *TransportHTTP) UpdateTransactionMetadata(ctx context.Context, txID string,
+	metadata *bux.Metadata) (*bux.Transaction, error) {
+
+	jsonData := map[string]interface{}{
+		"id":       txID,
+		"metadata": metadata,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction bux.Transaction
+	err = h.doHTTPRequest(ctx, "PATCH", "/transaction", jsonStr, h.keys().xPriv, h.signRequest, &transaction)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "transaction metadata updated", transaction, func() []logging.Field {
+			return []logging.Field{logging.F("tx_id", transaction.ID)}
+		})
+	}
+
+	return &transaction, nil
+}
+
+// GetDestinations will get a page of the destinations matching the conditions and metadata
+func (h *TransportHTTP) GetDestinations(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Destination, error) {
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var destinations []*bux.Destination
+	err = h.doHTTPRequest(ctx, "POST", "/destinations/search", jsonStr, h.keys().xPriv, h.signRequest, &destinations)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("destinations", logging.F("count", len(destinations)))
+	}
+
+	return destinations, nil
+}
+
+// UpdateDestinationMetadata will update the metadata of the destination (keys set to nil are removed)
+func (h *TransportHTTP) UpdateDestinationMetadata(ctx context.Context, id string,
+	metadata *bux.Metadata) (*bux.Destination, error) {
+
+	jsonData := map[string]interface{}{
+		"id":       id,
+		"metadata": metadata,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var destination bux.Destination
+	err = h.doHTTPRequest(ctx, "PATCH", "/destination", jsonStr, h.keys().xPriv, h.signRequest, &destination)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "destination metadata updated", destination, func() []logging.Field {
+			return []logging.Field{logging.F("destination_id", destination.ID)}
+		})
+	}
+
+	return &destination, nil
+}
+
+// GetUtxos will get a page of the utxos matching the conditions and metadata
+func (h *TransportHTTP) GetUtxos(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Utxo, error) {
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []*bux.Utxo
+	err = h.doHTTPRequest(ctx, "POST", "/utxos/search", jsonStr, h.keys().xPriv, h.signRequest, &utxos)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("utxos", logging.F("count", len(utxos)))
+	}
+
+	return utxos, nil
+}
+
+// GetAccessKeys will get a page of the access keys matching the conditions and metadata
+func (h *TransportHTTP) GetAccessKeys(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.AccessKey, error) {
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var accessKeys []*bux.AccessKey
+	err = h.doHTTPRequest(ctx, "POST", "/access-keys/search", jsonStr, h.keys().xPriv, h.signRequest, &accessKeys)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("access keys", logging.F("count", len(accessKeys)))
+	}
+
+	return accessKeys, nil
+}
+
+// AdminCreatePaymail will create a new paymail address for the given xPub
+func (h *TransportHTTP) AdminCreatePaymail(ctx context.Context, xPubID, address, publicName, avatar string,
+	metadata *bux.Metadata) (*PaymailAddress, error) {
+
+	// creating a paymail needs to be signed by an admin key
+	if err := checkAdminKey(h.adminXPriv, OperationAdminCreatePaymail); err != nil {
+		return nil, err
+	}
+
+	jsonData := map[string]interface{}{
+		"xpub_id":     xPubID,
+		"address":     address,
+		"public_name": publicName,
+		"avatar":      avatar,
+		"metadata":    withUserAgent(metadata),
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymailAddress *PaymailAddress
+	err = h.doHTTPRequest(ctx, "POST", "/admin/paymail/create", jsonStr, h.adminXPriv, true, &paymailAddress)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "paymail address", paymailAddress, func() []logging.Field {
+			return []logging.Field{logging.F("address", paymailAddress.Alias+"@"+paymailAddress.Domain)}
+		})
+	}
+
+	return paymailAddress, nil
+}
+
+// AdminDeletePaymail will delete (deactivate) the given paymail address
+func (h *TransportHTTP) AdminDeletePaymail(ctx context.Context, address string) error {
+
+	// deleting a paymail needs to be signed by an admin key
+	if err := checkAdminKey(h.adminXPriv, OperationAdminDeletePaymail); err != nil {
+		return err
+	}
+
+	jsonData := map[string]interface{}{
+		"address": address,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	err = h.doHTTPRequest(ctx, "DELETE", "/admin/paymail/delete", jsonStr, h.adminXPriv, true, &result)
+	if err != nil {
+		return err
+	}
+	if h.debug {
+		h.logger.Debug("deleted paymail address", logging.F("address", address))
+	}
+
+	return nil
+}
+
+// AdminGetPaymails will get all paymail addresses matching the conditions and metadata
+func (h *TransportHTTP) AdminGetPaymails(ctx context.Context, conditions map[string]interface{},
+	metadata *bux.Metadata, queryParams *QueryParams) ([]*PaymailAddress, error) {
+
+	// listing paymails needs to be signed by an admin key
+	if err := checkAdminKey(h.adminXPriv, OperationAdminGetPaymails); err != nil {
+		return nil, err
+	}
+
+	jsonData := map[string]interface{}{
+		"conditions": conditions,
+		"metadata":   metadata,
+		"params":     queryParams,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var paymailAddresses []*PaymailAddress
+	err = h.doHTTPRequest(ctx, "POST", "/admin/paymails/search", jsonStr, h.adminXPriv, true, &paymailAddresses)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("paymail addresses", logging.F("count", len(paymailAddresses)))
+	}
+
+	return paymailAddresses, nil
+}
+
+// AdminFreezeXPub will freeze the xPub, its mutations are rejected until it is unfrozen
+func (h *TransportHTTP) AdminFreezeXPub(ctx context.Context, xPubID, reason string) (*XPubStatus, error) {
+
+	// freezing an xPub needs to be signed by an admin key
+	if err := checkAdminKey(h.adminXPriv, OperationAdminFreezeXPub); err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"id":     xPubID,
+		"reason": reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var status *XPubStatus
+	if err = h.doHTTPRequest(ctx, "POST", "/admin/xpub/freeze", jsonStr, h.adminXPriv, true, &status); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("froze xpub", logging.F("xpub_id", xPubID))
+	}
+
+	return status, nil
+}
+
+// AdminUnfreezeXPub will unfreeze the xPub
+func (h *TransportHTTP) AdminUnfreezeXPub(ctx context.Context, xPubID string) (*XPubStatus, error) {
+
+	// unfreezing an xPub needs to be signed by an admin key
+	if err := checkAdminKey(h.adminXPriv, OperationAdminUnfreezeXPub); err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"id": xPubID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var status *XPubStatus
+	if err = h.doHTTPRequest(ctx, "POST", "/admin/xpub/unfreeze", jsonStr, h.adminXPriv, true, &status); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("unfroze xpub", logging.F("xpub_id", xPubID))
+	}
+
+	return status, nil
+}
+
+// GetXPub will get the xPub of the client (its balance and next derivation numbers)
+func (h *TransportHTTP) GetXPub(ctx context.Context) (*bux.Xpub, error) {
+
+	var xPub *bux.Xpub
+	if err := h.doHTTPRequest(ctx, "GET", "/xpub", nil, h.keys().xPriv, h.signRequest, &xPub); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "xpub", xPub, func() []logging.Field {
+			return []logging.Field{logging.F("xpub_id", xPub.ID)}
+		})
+	}
+
+	return xPub, nil
+}
+
+// GetXPubStatus will get the status of the xPub of the client (ex: frozen)
+func (h *TransportHTTP) GetXPubStatus(ctx context.Context) (*XPubStatus, error) {
+
+	var status *XPubStatus
+	if err := h.doHTTPRequest(ctx, "GET", "/xpub/status", nil, h.keys().xPriv, h.signRequest, &status); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "xpub status", status, func() []logging.Field {
+			return []logging.Field{logging.F("frozen", status.Frozen)}
+		})
+	}
+
+	return status, nil
+}
+
+// RotateXpub will replace the xPub of the client with the xPub of the new xPriv on the server, the
+// current xPub is revoked in the same operation (the request is signed with the current key, and
+// carries the proof that the client holds the new xPriv)
+func (h *TransportHTTP) RotateXpub(ctx context.Context, newXPriv *bip32.ExtendedKey) (*bux.Xpub, error) {
+	newXPub, proof, err := rotationProof(newXPriv, h.scheduler, h.requestSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"xpub":  newXPub,
+		"proof": proof,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var xPub *bux.Xpub
+	if err = h.doHTTPRequest(ctx, "POST", "/xpub/rotate", jsonStr, h.keys().xPriv, true, &xPub); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "rotated xpub", xPub, func() []logging.Field {
+			return []logging.Field{logging.F("xpub_id", xPub.ID)}
+		})
+	}
+
+	return xPub, nil
+}
+
+// CreateAccessKey will create a new access key for the xPub, the private key is only returned once
+func (h *TransportHTTP) CreateAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error) {
+	jsonStr, err := json.Marshal(map[string]interface{}{
+		"metadata": withUserAgent(metadata),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var accessKey *bux.AccessKey
+	if err = h.doHTTPRequest(ctx, "POST", "/access-key", jsonStr, h.keys().xPriv, true, &accessKey); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "created access key", accessKey, func() []logging.Field {
+			return []logging.Field{logging.F("id", accessKey.ID)}
+		})
+	}
+
+	return accessKey, nil
+}
+
+// RevokeAccessKey will revoke the access key
+func (h *TransportHTTP) RevokeAccessKey(ctx context.Context, id string) (*bux.AccessKey, error) {
+	var accessKey *bux.AccessKey
+	if err := h.doHTTPRequest(ctx, "DELETE", "/access-key?id="+url.QueryEscape(id), nil, h.keys().xPriv, true, &accessKey); err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("revoked access key", logging.F("id", id))
+	}
+
+	return accessKey, nil
+}
+
+// RegisterWebhook will register a webhook endpoint for the given event types
+func (h *TransportHTTP) RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType,
+	secret string) (*Webhook, error) {
+
+	jsonData := map[string]interface{}{
+		"url":         url,
+		"event_types": eventTypes,
+		"secret":      secret,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook *Webhook
+	err = h.doHTTPRequest(ctx, "POST", "/webhooks", jsonStr, h.keys().xPriv, true, &webhook)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		debugResult(h.logger, "webhook", webhook, func() []logging.Field {
+			return []logging.Field{logging.F("webhook_id", webhook.ID)}
+		})
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooks will get all registered webhook endpoints
+func (h *TransportHTTP) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	var webhooks []*Webhook
+	err := h.doHTTPRequest(ctx, "GET", "/webhooks", nil, h.keys().xPriv, h.signRequest, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("webhooks", logging.F("count", len(webhooks)))
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook will delete a registered webhook endpoint
+func (h *TransportHTTP) DeleteWebhook(ctx context.Context, id string) error {
+	jsonData := map[string]interface{}{
+		"id": id,
+	}
+
+	jsonStr, err := json.Marshal(jsonData)
+	if err != nil {
+		return err
+	}
+
+	var result interface{}
+	return h.doHTTPRequest(ctx, "DELETE", "/webhooks", jsonStr, h.keys().xPriv, true, &result)
+}
+
+// GetFeatureFlags will get the feature flags of the server
+func (h *TransportHTTP) GetFeatureFlags(ctx context.Context) (map[string]bool, error) {
+
+	var featureFlags map[string]bool
+	err := h.doHTTPRequest(ctx, "GET", "/features", nil, h.keys().xPriv, h.signRequest, &featureFlags)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("feature flags", logging.F("feature_flags", featureFlags))
+	}
+
+	return featureFlags, nil
+}
+
+// Notifications will subscribe to the server-sent events notification stream, reconnecting
+// automatically until the context is done
+func (h *TransportHTTP) Notifications(ctx context.Context) (<-chan *events.Event, error) {
+	keys := h.keys()
+	if keys.missing() {
+		return nil, ErrMissingKeys
+	}
+
+	authorize := authorizeNotifications(keys.xPriv, keys.xPub, keys.accessKey, h.signRequest, h.scheduler, h.requestSigner)
+	url := h.server + NotificationsPath
+	return subscribeNotifications(ctx, h.httpClient, h.logger, h.scheduler, url, h.debug, authorize,
+		h.checkpoints, notificationsCheckpointKey(url, keys.xPub, keys.accessKey)), nil
+}
+
 func (h *TransportHTTP) doHTTPRequest(ctx context.Context, method string, path string, jsonStr []byte, xPriv *bip32.ExtendedKey, sign bool, responseJSON interface{}) error {
 
 	url := h.server + path
@@ -237,26 +1002,40 @@ func (h *TransportHTTP) doHTTPRequest(ctx context.Context, method string, path s
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	if sign {
-		err = addSignature(&req.Header, xPriv, string(jsonStr))
+	keys := h.keys()
+	if xPriv == nil && keys.accessKey != nil {
+		// access keys can not be used without a signature
+		if err = addAccessKeySignature(&req.Header, keys.accessKey, string(jsonStr), h.scheduler, h.requestSigner); err != nil {
+			return err
+		}
+	} else if sign && (xPriv != nil || keys.xPub == nil) {
+		err = addSignature(&req.Header, xPriv, string(jsonStr), h.scheduler, h.requestSigner)
 		if err != nil {
 			return err
 		}
-	} else {
+	} else if xPriv != nil {
 		var xPub string
 		xPub, err = bitcoin.GetExtendedPublicKey(xPriv)
 		if err != nil {
 			return err
 		}
 		req.Header.Set("auth_xpub", xPub)
+	} else if keys.xPub != nil {
+		// watch-only clients (xPub only) can not sign
+		req.Header.Set("auth_xpub", keys.xPub.String())
+	} else {
+		return ErrMissingKeys
 	}
 
 	resp, err := h.httpClient.Do(req) //nolint:bodyclose // done in defer function
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode == http.StatusLocked {
+		return accountFrozenResponse(resp)
+	}
 	if resp.StatusCode >= 400 {
-		return errors.New("server error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+		return serverError(resp)
 	}
 
 	defer func(Body io.ReadCloser) {
@@ -266,6 +1045,10 @@ func (h *TransportHTTP) doHTTPRequest(ctx context.Context, method string, path s
 		}
 	}(resp.Body)
 
+	// the response body is ignored without a response (see DoRawRequest)
+	if responseJSON == nil {
+		return nil
+	}
 	err = json.NewDecoder(resp.Body).Decode(&responseJSON)
 	if err != nil {
 		return err
@@ -273,3 +1056,38 @@ func (h *TransportHTTP) doHTTPRequest(ctx context.Context, method string, path s
 
 	return nil
 }
+
+// serverError will return the ServerError of a failed response, a BroadcastFailedError if its code is
+// ErrorCodeBroadcastFailed
+func serverError(resp *http.Response) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	// the body is the json error ({"code": "...", "message": "..."}) or its message (json string)
+	serverErr := &ServerError{Status: resp.Status, StatusCode: resp.StatusCode}
+	var jsonErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &jsonErr); err == nil {
+		serverErr.Code, serverErr.Message = jsonErr.Code, jsonErr.Message
+	} else if err = json.Unmarshal(body, &serverErr.Message); err != nil {
+		serverErr.Message = strings.TrimSpace(string(body))
+	}
+	return broadcastFailedError(serverErr)
+}
+
+// accountFrozenResponse will return the AccountFrozenError of a response of a frozen xPub (423 Locked),
+// the body is the reason of the freeze (json string)
+func accountFrozenResponse(resp *http.Response) error {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var reason string
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&reason)
+	return &AccountFrozenError{Reason: reason}
+}
@@ -0,0 +1,33 @@
+package transports
+
+import (
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+)
+
+// signingKeys are the keys authenticating the requests of a transport, read once per request as they
+// can be replaced while requests run (see SetXPriv and SetAccessKey)
+type signingKeys struct {
+	accessKey *bec.PrivateKey
+	xPriv     *bip32.ExtendedKey
+	xPub      *bip32.ExtendedKey
+}
+
+// missing will return whether no key can authenticate the requests
+func (k signingKeys) missing() bool {
+	return k.xPriv == nil && k.xPub == nil && k.accessKey == nil
+}
+
+// keys will return the current keys of the transport
+func (h *TransportHTTP) keys() signingKeys {
+	h.keysLock.RLock()
+	defer h.keysLock.RUnlock()
+	return signingKeys{accessKey: h.accessKey, xPriv: h.xPriv, xPub: h.xPub}
+}
+
+// keys will return the current keys of the transport
+func (g *TransportGraphQL) keys() signingKeys {
+	g.keysLock.RLock()
+	defer g.keysLock.RUnlock()
+	return signingKeys{accessKey: g.accessKey, xPriv: g.xPriv, xPub: g.xPub}
+}
@@ -0,0 +1,305 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BuxOrg/bux"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
+)
+
+// ErrRequestNotInManifest is when a request is not in the request manifest (see WithRequestManifest)
+var ErrRequestNotInManifest = errors.New("the request is not in the request manifest")
+
+// RequestNotInManifestError is returned (without calling the server) when a request is not in the
+// request manifest, it matches ErrRequestNotInManifest with errors.Is
+type RequestNotInManifestError struct {
+	Shape *RequestShape
+}
+
+// Error will return the error message, with the shape of the request
+func (e *RequestNotInManifestError) Error() string {
+	return ErrRequestNotInManifest.Error() + ": " + e.Shape.String()
+}
+
+// Is will return whether the target is ErrRequestNotInManifest
+func (e *RequestNotInManifestError) Is(target error) bool {
+	return target == ErrRequestNotInManifest
+}
+
+// RequestShape is the shape of a request sent to the bux server: the method and the path (relative
+// to the server, with the names of the query parameters) for http, and the canonical query (literal
+// values removed, whitespaces collapsed) for graphql
+type RequestShape struct {
+	Method    string        `json:"method"`
+	Path      string        `json:"path,omitempty"`
+	Query     string        `json:"query,omitempty"`
+	Transport TransportType `json:"transport"`
+}
+
+// String will return the shape as a single line, ex: "http GET /transaction?id="
+func (s *RequestShape) String() string {
+	if s.Query != "" {
+		return string(s.Transport) + " " + s.Method + " " + s.Query
+	}
+	return string(s.Transport) + " " + s.Method + " " + s.Path
+}
+
+// RequestManifest is the set of the request shapes a client can send, sorted
+type RequestManifest []*RequestShape
+
+// Contains will return whether the shape is in the manifest
+func (m RequestManifest) Contains(shape *RequestShape) bool {
+	for _, allowed := range m {
+		if allowed.String() == shape.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestServerURL is the server of the transports generating the manifest, no request is sent
+const manifestServerURL = "https://bux.invalid"
+
+// errManifestCaptured stops the requests captured to generate the manifest
+var errManifestCaptured = errors.New("request captured for the manifest")
+
+// GenerateRequestManifest will return the shapes of all the requests this version of the client can
+// send, for the strict allow-lists of bux servers, the manifest only changes with the client version
+//
+// The websocket subscriptions (see SubscribeTransactions) are not part of the manifest
+func GenerateRequestManifest() (RequestManifest, error) {
+	xPriv, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+	if err != nil {
+		return nil, err
+	}
+	xPub, err := xPriv.Neuter()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make(RequestManifest, 0)
+	for _, transportType := range []TransportType{BuxTransportHTTP, BuxTransportGraphQL} {
+		capture := &manifestCapture{shapes: make(map[string]*RequestShape), transport: transportType}
+		httpClient := &http.Client{Transport: capture}
+		transportOption := WithHTTPClient(manifestServerURL, httpClient)
+		if transportType == BuxTransportGraphQL {
+			transportOption = WithGraphQLClient(manifestServerURL+"/graphql", httpClient)
+		}
+
+		var transport TransportService
+		if transport, err = NewTransport(
+			WithXPriv(xPriv),
+			WithXPub(xPub),
+			WithAdminKey(xPriv.String()),
+			WithSignRequest(true),
+			transportOption,
+		); err != nil {
+			return nil, err
+		}
+		callAllRequests(transport, xPriv)
+		if capture.err != nil {
+			return nil, capture.err
+		}
+
+		// the notifications are a long-lived stream, reconnecting until the context is done
+		notifications := &RequestShape{Method: http.MethodGet, Path: NotificationsPath, Transport: transportType}
+		capture.shapes[notifications.String()] = notifications
+		for _, shape := range capture.shapes {
+			manifest = append(manifest, shape)
+		}
+	}
+
+	sort.Slice(manifest, func(i, j int) bool {
+		return manifest[i].String() < manifest[j].String()
+	})
+	return manifest, nil
+}
+
+// callAllRequests will call every request of the transport (the requests fail, they are captured)
+func callAllRequests(transport TransportService, xPriv *bip32.ExtendedKey) {
+	ctx := context.Background()
+	metadata := &bux.Metadata{"key": "value"}
+	conditions := map[string]interface{}{"key": "value"}
+	queryParams := &QueryParams{Page: 1, PageSize: 1}
+	id := "id"
+
+	_ = transport.RegisterXpub(ctx, xPriv.String(), metadata)
+	_, _ = transport.GetXPub(ctx)
+	_, _ = transport.GetDestination(ctx, metadata)
+	_, _ = transport.GetDestinationByAddress(ctx, id)
+	_, _ = transport.NewDestinations(ctx, 2, metadata)
+	_, _ = transport.GetTransaction(ctx, id)
+	for _, getConditions := range []map[string]interface{}{nil, conditions} {
+		for _, getMetadata := range []*bux.Metadata{nil, metadata} {
+			_, _ = transport.GetTransactions(ctx, getConditions, getMetadata, nil)
+		}
+	}
+	_, _ = transport.DraftToRecipients(ctx, []*Recipients{{To: id, Satoshis: 1}}, metadata, &DraftOptions{
+		ChangeDestinationsStrategy: bux.ChangeStrategyRandom, ChangeMinimumSatoshis: 1, ChangeNumberOfDestinations: 1,
+	})
+	_, _ = transport.DraftTransaction(ctx, &bux.TransactionConfig{}, metadata, &DraftOptions{
+		FromUtxos: []*bux.UtxoPointer{{TransactionID: id}},
+	})
+	_, _ = transport.RecordTransaction(ctx, id, id, metadata)
+	_, _ = transport.RecordTransactions(ctx, []*RecordRequest{{Hex: id, Metadata: metadata, ReferenceID: id}})
+	_, _ = transport.SearchTransactions(ctx, conditions, metadata, queryParams)
+	for _, model := range []CountModel{
+		CountAccessKeys, CountDestinations, CountDraftTransactions, CountTransactions, CountUtxos,
+	} {
+		_, _ = transport.Count(ctx, model, conditions, metadata)
+	}
+	_, _ = transport.UpdateTransactionMetadata(ctx, id, metadata)
+	_, _ = transport.GetTransactionStatus(ctx, id)
+	_, _ = transport.GetMerkleProof(ctx, id)
+	_, _ = transport.GetBlockHeader(ctx, id)
+	_, _ = transport.GetFeeQuote(ctx)
+	_, _ = transport.GetTransactionBEEF(ctx, id)
+	_, _ = transport.RecordTransactionBEEF(ctx, id, id, metadata)
+	_, _ = transport.ImportTransaction(ctx, id, metadata)
+	_, _ = transport.GetDestinations(ctx, conditions, metadata, queryParams)
+	_, _ = transport.UpdateDestinationMetadata(ctx, id, metadata)
+	_, _ = transport.GetUtxos(ctx, conditions, metadata, queryParams)
+	_, _ = transport.AdminCreatePaymail(ctx, id, id, id, id, metadata)
+	_ = transport.AdminDeletePaymail(ctx, id)
+	_, _ = transport.AdminGetPaymails(ctx, conditions, metadata, queryParams)
+	_, _ = transport.AdminFreezeXPub(ctx, id, id)
+	_, _ = transport.AdminUnfreezeXPub(ctx, id)
+	_, _ = transport.GetXPubStatus(ctx)
+	_, _ = transport.RotateXpub(ctx, xPriv)
+	_, _ = transport.CreateAccessKey(ctx, metadata)
+	_, _ = transport.RevokeAccessKey(ctx, id)
+	_, _ = transport.GetAccessKeys(ctx, conditions, metadata, queryParams)
+	_, _ = transport.RegisterWebhook(ctx, id, nil, id)
+	_, _ = transport.GetWebhooks(ctx)
+	_ = transport.DeleteWebhook(ctx, id)
+	_, _ = transport.GetFeatureFlags(ctx)
+	_ = transport.Ping(ctx)
+	_, _ = transport.GetServerInfo(ctx)
+	_, _ = transport.GetCapabilities(ctx)
+}
+
+// manifestCapture captures the shapes of the requests, without sending them
+type manifestCapture struct {
+	err       error
+	shapes    map[string]*RequestShape
+	transport TransportType
+}
+
+// RoundTrip will capture the shape of the request, and fail it
+func (m *manifestCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	shape, err := requestShape(m.transport, serverBasePath(m.transport, manifestServerURL), req)
+	if err != nil {
+		m.err = err
+		return nil, err
+	}
+	m.shapes[shape.String()] = shape
+	return nil, errManifestCaptured
+}
+
+// WithRequestManifest will pin the requests to the manifest (see GenerateRequestManifest): the
+// requests that are not in the manifest are refused, without calling the server
+func WithRequestManifest(manifest RequestManifest) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.requestManifest = manifest
+		}
+	}
+}
+
+// manifestRoundTripper refuses the requests that are not in the manifest
+type manifestRoundTripper struct {
+	basePath  string
+	manifest  RequestManifest
+	next      http.RoundTripper
+	transport TransportType
+}
+
+// RoundTrip will execute the request if it is in the manifest
+func (m *manifestRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	shape, err := requestShape(m.transport, m.basePath, req)
+	if err != nil {
+		return nil, err
+	}
+	if !m.manifest.Contains(shape) {
+		return nil, &RequestNotInManifestError{Shape: shape}
+	}
+	return m.next.RoundTrip(req)
+}
+
+// graphqlLiteralRegex matches the string literals of a graphql query
+var graphqlLiteralRegex = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// Regexes of the variables and the aliased fields of the batched operations after the first one
+var (
+	graphqlBatchVariableRegex = regexp.MustCompile(`,\s*\$\w+` + graphqlBatchSuffix + `[1-9]\d*:\s*[\w!\[\]]+`)
+	graphqlBatchFieldRegex    = regexp.MustCompile(`\s*\w+` + graphqlBatchSuffix + `[1-9]\d*:\s*\w+\s*\([^)]*\)\s*{[^{}]*}`)
+)
+
+// canonicalQuery will return the graphql query without its literal values, whitespaces collapsed,
+// the batched operations are reduced to the first one (the shape does not depend on the batch size)
+func canonicalQuery(query string) string {
+	query = graphqlLiteralRegex.ReplaceAllString(query, `""`)
+	query = graphqlBatchVariableRegex.ReplaceAllString(query, "")
+	query = graphqlBatchFieldRegex.ReplaceAllString(query, "")
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// requestShape will return the shape of the request, the path is relative to the base path of the server
+func requestShape(transport TransportType, basePath string, req *http.Request) (*RequestShape, error) {
+	shape := &RequestShape{Method: req.Method, Transport: transport}
+	if transport == BuxTransportGraphQL && req.Method == http.MethodPost {
+		if req.GetBody == nil {
+			return nil, ErrRequestNotInManifest
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = body.Close()
+		}()
+
+		var request struct {
+			Query string `json:"query"`
+		}
+		if err = json.NewDecoder(body).Decode(&request); err != nil {
+			return nil, err
+		}
+		shape.Query = canonicalQuery(request.Query)
+		return shape, nil
+	}
+
+	// the server url can end with a slash (ex: https://bux.example.com/ + /transactions)
+	shape.Path = "/" + strings.TrimLeft(strings.TrimPrefix(req.URL.Path, basePath), "/")
+	if query := req.URL.Query(); len(query) > 0 {
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, url.QueryEscape(name)+"=")
+		}
+		sort.Strings(names)
+		shape.Path += "?" + strings.Join(names, "&")
+	}
+	return shape, nil
+}
+
+// serverBasePath will return the path of the server of the transport, the paths of the requests
+// are relative to it (the graphql endpoint is removed, the notifications are served next to it)
+func serverBasePath(transport TransportType, serverURL string) string {
+	server, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+	basePath := strings.TrimSuffix(server.Path, "/")
+	if transport == BuxTransportGraphQL {
+		basePath = strings.TrimSuffix(basePath, "/graphql")
+	}
+	return basePath
+}
@@ -0,0 +1,96 @@
+package transports
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// MetricsRecorder records the metrics of every request to the bux server (request count, error count
+// and latency per operation and transport), ex: with prometheus counters and histograms
+type MetricsRecorder interface {
+	RecordRequest(transport TransportType, operation string, duration time.Duration, err error)
+}
+
+// roundTripperWrapper is implemented by the transports that use an http client, the wrap function
+// gets the type of the transport and the current round tripper, and returns the new round tripper
+type roundTripperWrapper interface {
+	wrapRoundTripper(wrap func(transport TransportType, next http.RoundTripper) http.RoundTripper)
+}
+
+// WithMetrics will add a recorder of the request metrics, every request is recorded by all the recorders
+func WithMetrics(recorder MetricsRecorder) ClientOps {
+	return func(c *Client) {
+		if c != nil && recorder != nil {
+			c.metrics = append(c.metrics, recorder)
+		}
+	}
+}
+
+// metricsRecorders records the metrics with several recorders
+type metricsRecorders []MetricsRecorder
+
+// RecordRequest will record the request with every recorder
+func (m metricsRecorders) RecordRequest(transport TransportType, operation string, duration time.Duration, err error) {
+	for _, recorder := range m {
+		recorder.RecordRequest(transport, operation, duration, err)
+	}
+}
+
+// wrapHTTPClient will return a copy of the http client using the wrapped round tripper, the
+// original client is left untouched
+func wrapHTTPClient(httpClient *http.Client, transport TransportType,
+	wrap func(transport TransportType, next http.RoundTripper) http.RoundTripper) *http.Client {
+
+	wrapped := &http.Client{}
+	if httpClient != nil {
+		*wrapped = *httpClient
+	}
+	next := wrapped.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped.Transport = wrap(transport, next)
+	return wrapped
+}
+
+// metricsRoundTripper records the metrics of every request
+type metricsRoundTripper struct {
+	next      http.RoundTripper
+	recorder  MetricsRecorder
+	transport TransportType
+}
+
+// RoundTrip will execute the request and record its metrics
+func (m *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := requestOperation(m.transport, req)
+
+	start := time.Now()
+	resp, err := m.next.RoundTrip(req)
+
+	recordErr := err
+	if err == nil && resp.StatusCode >= 400 {
+		recordErr = errors.New("server error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+	}
+	m.recorder.RecordRequest(m.transport, operation, time.Since(start), recordErr)
+
+	return resp, err
+}
+
+// graphqlOperationRegex matches the first (root) field of a graphql query
+var graphqlOperationRegex = regexp.MustCompile(`^[^{]*\{\s*([_A-Za-z][_0-9A-Za-z]*)`)
+
+// requestOperation will return the name of the operation of the request, the root field of the
+// query for graphql ("transactions") and the method and path for http ("POST /transactions/search")
+func requestOperation(transport TransportType, req *http.Request) string {
+	if transport == BuxTransportGraphQL {
+		if query, ok := graphqlRequestQuery(req); ok {
+			if match := graphqlOperationRegex.FindStringSubmatch(query); match != nil {
+				return match[1]
+			}
+		}
+	}
+	return req.Method + " " + req.URL.Path
+}
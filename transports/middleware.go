@@ -0,0 +1,25 @@
+package transports
+
+import "net/http"
+
+// RoundTripFunc executes a single http request to the bux server
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip will call the function, RoundTripFunc implements http.RoundTripper
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the execution of every http request to the bux server (ex: to add headers,
+// log, cache or inject failures), it must call next to execute the request
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware will add middlewares around all the http requests of the transport, the first
+// middleware is the outermost (it sees the request first and the response last)
+func WithMiddleware(middlewares ...Middleware) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.middlewares = append(c.middlewares, middlewares...)
+		}
+	}
+}
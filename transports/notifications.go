@@ -0,0 +1,269 @@
+package transports
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+)
+
+// NotificationsPath is the path of the server-sent events notification stream of the bux server
+const NotificationsPath = "/notifications"
+
+// eventStreamContentType is the content type of the server-sent events streams
+const eventStreamContentType = "text/event-stream"
+
+const (
+	// notificationsReconnectDelay is the wait before reconnecting, doubled after each failed connection,
+	// the server can change it with the retry field of the stream
+	notificationsReconnectDelay = time.Second
+
+	// notificationsMaxReconnectDelay is the maximum wait before reconnecting
+	notificationsMaxReconnectDelay = 30 * time.Second
+)
+
+// WithEventCheckpoints will keep the last event received of the notification streams in the store
+// (see store.NamespaceEventCheckpoints), so a new stream resumes after it, ex: after a restart
+func WithEventCheckpoints(checkpoints store.Store) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.checkpoints = checkpoints
+		}
+	}
+}
+
+// notificationStream consumes a server-sent events stream and reconnects when it is interrupted
+type notificationStream struct {
+	authorize     func(req *http.Request) error
+	checkpointKey string
+	checkpoints   store.Store
+	debug         bool
+	delay         time.Duration
+	httpClient    *http.Client
+	lastEventID   string
+	logger        logging.Logger
+	scheduler     scheduler.Scheduler
+	url           string
+}
+
+// subscribeNotifications will start consuming the notification stream in the background, the
+// returned channel is closed when the context is done
+func subscribeNotifications(ctx context.Context, httpClient *http.Client, logger logging.Logger,
+	scheduler scheduler.Scheduler, url string, debug bool, authorize func(req *http.Request) error,
+	checkpoints store.Store, checkpointKey string) <-chan *events.Event {
+
+	stream := &notificationStream{
+		authorize:     authorize,
+		checkpointKey: checkpointKey,
+		checkpoints:   checkpoints,
+		debug:         debug,
+		delay:         notificationsReconnectDelay,
+		httpClient:    httpClient,
+		logger:        logger,
+		scheduler:     scheduler,
+		url:           url,
+	}
+	if checkpoints != nil {
+		if lastEventID, err := checkpoints.Get(ctx, store.NamespaceEventCheckpoints, checkpointKey); err == nil {
+			stream.lastEventID = string(lastEventID)
+		} else if !errors.Is(err, store.ErrNotFound) && debug {
+			logger.Warn("could not load the notification stream checkpoint", logging.F("error", err))
+		}
+	}
+
+	notifications := make(chan *events.Event)
+	go stream.run(ctx, notifications)
+	return notifications
+}
+
+// notificationsCheckpointKey will return the key of the checkpoint of the stream, the streams of
+// every xPub (or access key) of the server have their own checkpoint
+func notificationsCheckpointKey(url string, xPub *bip32.ExtendedKey, accessKey *bec.PrivateKey) string {
+	if xPub != nil {
+		return url + "#" + buxutils.Hash(xPub.String())
+	} else if accessKey != nil {
+		return url + "#" + buxutils.Hash(hex.EncodeToString(accessKey.PubKey().SerialiseCompressed()))
+	}
+	return url
+}
+
+// authorizeNotifications will return the function that authenticates every (re)connection, the
+// stream is signed with the xPriv or access key when available
+func authorizeNotifications(xPriv, xPub *bip32.ExtendedKey, accessKey *bec.PrivateKey,
+	signRequest bool, scheduler scheduler.Scheduler, signer RequestSigner) func(req *http.Request) error {
+
+	return func(req *http.Request) error {
+		if xPriv != nil && signRequest {
+			return addSignature(&req.Header, xPriv, "", scheduler, signer)
+		} else if accessKey != nil {
+			return addAccessKeySignature(&req.Header, accessKey, "", scheduler, signer)
+		} else if xPub != nil {
+			req.Header.Set(bux.AuthHeader, xPub.String())
+			return nil
+		}
+		return ErrMissingKeys
+	}
+}
+
+// run will (re)connect to the stream until the context is done
+func (s *notificationStream) run(ctx context.Context, notifications chan<- *events.Event) {
+	defer close(notifications)
+
+	delay := s.delay
+	for {
+		retry := s.delay
+		received, err := s.connect(ctx, notifications)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// the delay is reset after receiving events, or set by the retry field of the stream
+		if received || s.delay != retry {
+			delay = s.delay
+		}
+		if s.debug {
+			s.logger.Warn("notification stream interrupted, reconnecting", logging.F("error", err), logging.F("delay", delay))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.scheduler.After(delay):
+		}
+
+		if !received {
+			if delay *= 2; delay > notificationsMaxReconnectDelay {
+				delay = notificationsMaxReconnectDelay
+			}
+			if delay < s.delay {
+				delay = s.delay
+			}
+		}
+	}
+}
+
+// connect will read the stream until it ends, returning whether any event was received
+func (s *notificationStream) connect(ctx context.Context, notifications chan<- *events.Event) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", eventStreamContentType)
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	if err = s.authorize(req); err != nil {
+		return false, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 400 {
+		return false, errors.New("server error: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status)
+	}
+
+	received := false
+	var eventType, eventID string
+	var data []string
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// a blank line dispatches the event
+		if line == "" {
+			if len(data) > 0 {
+				event, decodeErr := decodeNotification(eventType, eventID, strings.Join(data, "\n"))
+				if decodeErr != nil {
+					if s.debug {
+						s.logger.Warn("invalid notification", logging.F("error", decodeErr))
+					}
+				} else {
+					select {
+					case <-ctx.Done():
+						return received, ctx.Err()
+					case notifications <- event:
+						received = true
+					}
+				}
+			}
+			if eventID != "" {
+				s.lastEventID = eventID
+				s.saveCheckpoint(ctx)
+			}
+			eventType, eventID, data = "", "", nil
+			continue
+		}
+
+		field, value := line, ""
+		if index := strings.Index(line, ":"); index == 0 {
+			continue // comment, used as keep-alive
+		} else if index > 0 {
+			field, value = line[:index], strings.TrimPrefix(line[index+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			eventID = value
+		case "retry":
+			if milliseconds, parseErr := strconv.Atoi(value); parseErr == nil && milliseconds > 0 {
+				s.delay = time.Duration(milliseconds) * time.Millisecond
+			}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return received, err
+	}
+	return received, errors.New("notification stream closed")
+}
+
+// decodeNotification will decode the data of a server-sent event into a typed event
+func decodeNotification(eventType, eventID, data string) (*events.Event, error) {
+	var event events.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, err
+	}
+	if event.Type == "" {
+		event.Type = events.EventType(eventType)
+	}
+	if event.ID == "" {
+		event.ID = eventID
+	}
+	return &event, nil
+}
+
+// saveCheckpoint will write the last event ID to the checkpoints of the streams
+func (s *notificationStream) saveCheckpoint(ctx context.Context) {
+	if s.checkpoints == nil {
+		return
+	}
+	err := s.checkpoints.Put(ctx, store.NamespaceEventCheckpoints, s.checkpointKey, []byte(s.lastEventID))
+	if err != nil && s.debug {
+		s.logger.Warn("could not save the notification stream checkpoint", logging.F("error", err))
+	}
+}
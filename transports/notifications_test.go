@@ -0,0 +1,52 @@
+package transports
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNotificationStreamRetry will test that the retry field of the stream sets the reconnect delay
+func TestNotificationStreamRetry(t *testing.T) {
+	connections := make(chan int, 10)
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count++
+		connections <- count
+		w.Header().Set("Content-Type", eventStreamContentType)
+		if count == 1 {
+			_, _ = w.Write([]byte("retry: 5000\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	virtual := scheduler.NewVirtual(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	notifications := subscribeNotifications(ctx, server.Client(), nil, virtual, server.URL, false,
+		func(req *http.Request) error { return nil }, nil, "")
+
+	// the server sets the delay to 5 seconds
+	assert.Equal(t, 1, <-connections)
+	virtual.BlockUntil(1)
+	virtual.Advance(notificationsReconnectDelay)
+	assert.Equal(t, 1, virtual.Waiters())
+	virtual.Advance(5*time.Second - notificationsReconnectDelay)
+	assert.Equal(t, 2, <-connections)
+
+	// then the delay is doubled after a connection without events
+	virtual.BlockUntil(1)
+	virtual.Advance(5 * time.Second)
+	assert.Equal(t, 1, virtual.Waiters())
+	virtual.Advance(5 * time.Second)
+	assert.Equal(t, 3, <-connections)
+
+	cancel()
+	for range notifications {
+		// drain until the stream is closed
+	}
+}
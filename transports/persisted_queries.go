@@ -0,0 +1,190 @@
+package transports
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// Errors of the servers that do not know the hash of a persisted query, or do not support them
+// (automatic persisted queries, as implemented by Apollo and gqlgen)
+const (
+	persistedQueryNotFound     = "PersistedQueryNotFound"
+	persistedQueryNotSupported = "PersistedQueryNotSupported"
+)
+
+// persistedQueryVersion is the version of the persisted queries protocol
+const persistedQueryVersion = 1
+
+// persistedQueryKey is the context key of the query of a request of which only the hash is sent
+type persistedQueryKey struct{}
+
+// WithPersistedQueries will send the sha256 hash of the graphql queries instead of their full body,
+// to cut the size of the requests and work with the servers that only allow persisted queries. The
+// full query is sent when the server does not know the hash (so it can persist it), and always once
+// the server answers that it does not support persisted queries. Ignored by the http transport.
+func WithPersistedQueries() ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.persistedQueries = true
+		}
+	}
+}
+
+// persistedQueryRoundTripper sends the hash of the graphql queries, and the full queries when needed
+type persistedQueryRoundTripper struct {
+	next        http.RoundTripper
+	unsupported int32 // set when the server does not support persisted queries
+}
+
+// persistedQueryRequest is the body of a graphql request, the fields other than the query are kept
+type persistedQueryRequest map[string]json.RawMessage
+
+// persistedQueryResponse is the errors of a graphql response
+type persistedQueryResponse struct {
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// RoundTrip will send the hash of the query, and send the full query again when the server does not
+// know the hash or does not support persisted queries
+func (p *persistedQueryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.LoadInt32(&p.unsupported) == 1 || req.GetBody == nil {
+		return p.next.RoundTrip(req)
+	}
+	request, query, err := readPersistedQueryRequest(req)
+	if err != nil || query == "" {
+		return p.next.RoundTrip(req)
+	}
+
+	hash := sha256.Sum256([]byte(query))
+	extensions, err := json.Marshal(map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    persistedQueryVersion,
+			"sha256Hash": hex.EncodeToString(hash[:]),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	request["extensions"] = extensions
+	fullBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	delete(request, "query")
+	hashBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the query is kept in the context, so the operation of the request is still known
+	hashed := withRequestBody(req.WithContext(context.WithValue(req.Context(), persistedQueryKey{}, query)), hashBody)
+	resp, err := p.next.RoundTrip(hashed)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	switch persistedQueryError(body) {
+	case persistedQueryNotFound:
+		return p.next.RoundTrip(withRequestBody(req, fullBody))
+	case persistedQueryNotSupported:
+		atomic.StoreInt32(&p.unsupported, 1)
+		return p.next.RoundTrip(req)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
+
+// readPersistedQueryRequest will read the body of the graphql request and its query
+func readPersistedQueryRequest(req *http.Request) (persistedQueryRequest, string, error) {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var request persistedQueryRequest
+	if err = json.NewDecoder(body).Decode(&request); err != nil {
+		return nil, "", err
+	}
+	var query string
+	if rawQuery, ok := request["query"]; ok {
+		if err = json.Unmarshal(rawQuery, &query); err != nil {
+			return nil, "", err
+		}
+	}
+	return request, query, nil
+}
+
+// persistedQueryError will return the persisted query error of the graphql response, if any
+func persistedQueryError(body []byte) string {
+	var response persistedQueryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return ""
+	}
+	for _, graphqlErr := range response.Errors {
+		switch {
+		case graphqlErr.Message == persistedQueryNotFound || graphqlErr.Extensions.Code == "PERSISTED_QUERY_NOT_FOUND":
+			return persistedQueryNotFound
+		case graphqlErr.Message == persistedQueryNotSupported || graphqlErr.Extensions.Code == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return persistedQueryNotSupported
+		}
+	}
+	return ""
+}
+
+// withRequestBody will return a copy of the request with the body
+func withRequestBody(req *http.Request, body []byte) *http.Request {
+	copied := req.Clone(req.Context())
+	copied.Body = ioutil.NopCloser(bytes.NewReader(body))
+	copied.ContentLength = int64(len(body))
+	copied.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return copied
+}
+
+// graphqlRequestQuery will return the query of the graphql request, from its body or, when only the
+// hash of the query is sent (see WithPersistedQueries), from its context
+func graphqlRequestQuery(req *http.Request) (string, bool) {
+	if query, ok := req.Context().Value(persistedQueryKey{}).(string); ok {
+		return query, true
+	}
+	if req.GetBody == nil {
+		return "", false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var request struct {
+		Query string `json:"query"`
+	}
+	if err = json.NewDecoder(body).Decode(&request); err != nil {
+		return "", false
+	}
+	return request.Query, true
+}
@@ -0,0 +1,37 @@
+package transports
+
+import (
+	"context"
+	"errors"
+
+	"github.com/machinebox/graphql"
+)
+
+// ErrRawGraphQLNotSupported is when the transport cannot run raw graphql queries (they need graphql)
+var ErrRawGraphQLNotSupported = errors.New("raw graphql queries are only supported by the graphql transport")
+
+// RunGraphQL will run the raw graphql query (or mutation) with the variables, signed like the other
+// requests, and decode the data of the response into out (ex: a pointer to a struct with a field per
+// root field of the query), for the server features not wrapped by the client. The values must be
+// passed as variables, never in the query. The raw queries are not in the request manifest.
+func (g *TransportGraphQL) RunGraphQL(ctx context.Context, query string, variables map[string]interface{},
+	out interface{}) error {
+
+	req := graphql.NewRequest(query)
+	for key, value := range variables {
+		req.Var(key, value)
+	}
+
+	err := g.signGraphQLRequest(req, query, variables)
+	if err != nil {
+		return err
+	}
+
+	// run it and capture the response
+	return accountFrozenError(g.client.Run(ctx, req, out))
+}
+
+// RunGraphQL is not supported by the http transport
+func (h *TransportHTTP) RunGraphQL(_ context.Context, _ string, _ map[string]interface{}, _ interface{}) error {
+	return ErrRawGraphQLNotSupported
+}
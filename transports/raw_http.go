@@ -0,0 +1,44 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrRawRequestNotSupported is when the transport cannot send raw http requests (they need http)
+var ErrRawRequestNotSupported = errors.New("raw requests are only supported by the http transport")
+
+// DoRawRequest will send the request to the path of the bux server (ex: "/utxos/search"), authenticated
+// like the other requests (signed with the xPriv or the access key of the client), and decode the json
+// response into out (nil to ignore the response), for the endpoints not wrapped by the client yet. The
+// body is sent as is when it is a []byte or json.RawMessage, and encoded in json otherwise (none when
+// nil). The raw requests are not in the request manifest.
+func (h *TransportHTTP) DoRawRequest(ctx context.Context, method, path string, body interface{},
+	out interface{}) error {
+
+	var jsonStr []byte
+	switch value := body.(type) {
+	case nil:
+	case []byte:
+		jsonStr = value
+	case json.RawMessage:
+		jsonStr = value
+	default:
+		var err error
+		if jsonStr, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return accountFrozenError(h.doHTTPRequest(ctx, method, path, jsonStr, h.keys().xPriv, h.signRequest, out))
+}
+
+// DoRawRequest is not supported by the graphql transport
+func (g *TransportGraphQL) DoRawRequest(_ context.Context, _, _ string, _ interface{}, _ interface{}) error {
+	return ErrRawRequestNotSupported
+}
@@ -0,0 +1,232 @@
+package transports
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+)
+
+// WithReadReplica will send the read requests to a read replica of the bux server (same paths as the
+// server), the writes go to the server (primary). During the staleness window after a write, a read of
+// the records written during the window is retried on the primary when the replica has not seen the
+// write yet: it answers not found, or the response does not contain the records. The other reads
+// (ex: the searches) are answered by the replica.
+func WithReadReplica(replicaURL string, stalenessWindow time.Duration) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.readReplicaURL = replicaURL
+			c.stalenessWindow = stalenessWindow
+		}
+	}
+}
+
+// recentWrite is a record written on the primary
+type recentWrite struct {
+	id        string
+	writtenAt time.Time
+}
+
+// readReplicaRoundTripper routes the reads to the replica, and detects stale reads
+type readReplicaRoundTripper struct {
+	mu              sync.Mutex
+	next            http.RoundTripper
+	replica         *url.URL
+	scheduler       scheduler.Scheduler
+	stalenessWindow time.Duration
+	transport       TransportType
+	writes          []recentWrite
+}
+
+// RoundTrip will execute the reads on the replica (falling back to the primary when stale), and the writes on the primary
+func (r *readReplicaRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isReadRequest(r.transport, req) {
+		return r.write(req)
+	}
+
+	readIDs, err := readRecentIDs(req, r.recentIDs())
+	if err != nil {
+		return nil, err
+	}
+	replicaReq := req.Clone(req.Context())
+	replicaReq.URL.Scheme = r.replica.Scheme
+	replicaReq.URL.Host = r.replica.Host
+	replicaReq.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		replicaReq.Body = body
+	}
+
+	resp, err := r.next.RoundTrip(replicaReq)
+	if len(readIDs) == 0 || err != nil || req.GetBody == nil {
+		return resp, err
+	}
+
+	var body []byte
+	if body, err = readResponseBody(resp); err != nil {
+		return nil, err
+	}
+	if !isStaleRead(resp.StatusCode, body, readIDs) {
+		return resp, nil
+	}
+
+	// the replica has not seen our writes yet, read from the primary (unless the request was canceled)
+	if err = req.Context().Err(); err != nil {
+		return nil, err
+	}
+	primaryReq := req.Clone(req.Context())
+	if primaryReq.Body, err = req.GetBody(); err != nil {
+		return nil, err
+	}
+	return r.next.RoundTrip(primaryReq)
+}
+
+// write will execute the write on the primary, and remember the written records
+func (r *readReplicaRoundTripper) write(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || r.stalenessWindow <= 0 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	var body []byte
+	if body, err = readResponseBody(resp); err != nil {
+		return nil, err
+	}
+
+	now := r.scheduler.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range writtenIDs(body) {
+		r.writes = append(r.writes, recentWrite{id: id, writtenAt: now})
+	}
+	return resp, nil
+}
+
+// recentIDs will return the IDs of the records written during the staleness window
+func (r *readReplicaRoundTripper) recentIDs() []string {
+	now := r.scheduler.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the writes are in order, drop the ones older than the window
+	for len(r.writes) > 0 && now.Sub(r.writes[0].writtenAt) > r.stalenessWindow {
+		r.writes = r.writes[1:]
+	}
+	ids := make([]string, 0, len(r.writes))
+	for _, write := range r.writes {
+		ids = append(ids, write.id)
+	}
+	return ids
+}
+
+// isReadRequest will return whether the request only reads: graphql queries, http GET requests,
+// and the http searches and counts
+func isReadRequest(transport TransportType, req *http.Request) bool {
+	if transport == BuxTransportGraphQL {
+		query, ok := graphqlRequestQuery(req)
+		if !ok {
+			return false
+		}
+		query = strings.TrimSpace(query)
+		return strings.HasPrefix(query, "query") || strings.HasPrefix(query, "{")
+	}
+
+	return req.Method == http.MethodGet || req.Method == http.MethodPost &&
+		(req.URL.Path == "/transactions" || strings.HasSuffix(req.URL.Path, "/search") ||
+			strings.HasSuffix(req.URL.Path, "/count"))
+}
+
+// readRecentIDs will return the recently written IDs the request reads (in its url or its body)
+func readRecentIDs(req *http.Request, recentIDs []string) ([]string, error) {
+	if len(recentIDs) == 0 {
+		return nil, nil
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		body, err = ioutil.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, 0, len(recentIDs))
+	for _, id := range recentIDs {
+		if strings.Contains(req.URL.RawQuery, id) || bytes.Contains(body, []byte(id)) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// isStaleRead will return whether the replica response misses the recent writes read by the request:
+// not found, or any of their IDs is missing from the response
+func isStaleRead(statusCode int, body []byte, readIDs []string) bool {
+	if len(readIDs) == 0 {
+		return false
+	}
+	if statusCode == http.StatusNotFound {
+		return true
+	}
+	for _, id := range readIDs {
+		if !bytes.Contains(body, []byte(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenIDs will return the IDs of the records in the response of a write (http: the record,
+// graphql: the records of the data)
+func writtenIDs(body []byte) []string {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	records := []map[string]json.RawMessage{response}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(response["data"], &data); err == nil {
+		records = records[:0]
+		for _, value := range data {
+			var record map[string]json.RawMessage
+			if json.Unmarshal(value, &record) == nil {
+				records = append(records, record)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		var id string
+		if json.Unmarshal(record["id"], &id) == nil && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// readResponseBody will read the body of the response, and replace it so it can be read again
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
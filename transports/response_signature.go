@@ -0,0 +1,126 @@
+package transports
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+)
+
+// ErrResponseSignature is when a response of the server is not signed by the server xPub (ex: tampered)
+var ErrResponseSignature = errors.New("invalid server response signature")
+
+// WithServerXPub will verify that every response is signed by the server xPub (the bux-server signs
+// its responses like the client signs its requests, over ResponseSigningPayload), so tampered responses
+// and the responses of other requests are rejected. Note: the notification stream (server-sent events)
+// is not verified.
+func WithServerXPub(serverXPub string) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.serverXPub = serverXPub
+		}
+	}
+}
+
+// responseSignatureRoundTripper verifies the signature of every response
+type responseSignatureRoundTripper struct {
+	next       http.RoundTripper
+	nonces     *nonceCache
+	scheduler  scheduler.Scheduler
+	serverXPub string
+	tolerance  time.Duration
+}
+
+// RoundTrip will execute the request and verify the signature of the response
+func (r *responseSignatureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || strings.HasPrefix(resp.Header.Get("Content-Type"), eventStreamContentType) {
+		return resp, err
+	}
+
+	var body []byte
+	body, err = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.verify(req, resp.Header, body); err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// verify will verify the signature of the response to the request, and that it is not a replay of a
+// previous response
+func (r *responseSignatureRoundTripper) verify(req *http.Request, header http.Header, body []byte) error {
+	now := r.scheduler.Now()
+	authTime, err := verifyResponseSignature(r.serverXPub, header,
+		ResponseSigningPayload(req, strings.TrimSuffix(string(body), "\n")))
+	if err != nil {
+		return err
+	}
+
+	signedAt := time.UnixMilli(authTime)
+	if now.Sub(signedAt) > r.tolerance || signedAt.Sub(now) > r.tolerance {
+		return ErrResponseSignature
+	}
+	if !r.nonces.add(header.Get(bux.AuthHeaderNonce), signedAt.Add(r.tolerance), now) {
+		return ErrResponseSignature
+	}
+	return nil
+}
+
+// ResponseSigningPayload will return what the server signs (as the body of bux.SetSignature) for the
+// response body to the request: the method, path and nonce (empty if unsigned) of the request, then
+// the body, so the signed response of a request can not be sent for another request
+func ResponseSigningPayload(req *http.Request, body string) string {
+	return req.Method + " " + req.URL.Path + " " + req.Header.Get(bux.AuthHeaderNonce) + "\n" + body
+}
+
+// verifyResponseSignature will verify the signature headers of a response against the server xPub,
+// returning the signing time (milliseconds)
+func verifyResponseSignature(serverXPub string, header http.Header, payload string) (int64, error) {
+	signature := header.Get(bux.AuthSignature)
+	nonce := header.Get(bux.AuthHeaderNonce)
+	hash := header.Get(bux.AuthHeaderHash)
+	if signature == "" || header.Get(bux.AuthHeader) != serverXPub {
+		return 0, ErrResponseSignature
+	}
+
+	// the hash covers the payload (request and body), the signature covers the hash
+	if hash != buxutils.Hash(payload) {
+		return 0, ErrResponseSignature
+	}
+	authTime, err := strconv.ParseInt(header.Get(bux.AuthHeaderTime), 10, 64)
+	if err != nil {
+		return 0, ErrResponseSignature
+	}
+
+	key, err := bitcoin.GetHDKeyFromExtendedPublicKey(serverXPub)
+	if err != nil {
+		return 0, err
+	}
+	if key, err = buxutils.DeriveChildKeyFromHex(key, nonce); err != nil {
+		return 0, ErrResponseSignature
+	}
+	address, err := bitcoin.GetAddressFromHDKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	message := serverXPub + hash + nonce + strconv.FormatInt(authTime, 10)
+	if err = bitcoin.VerifyMessage(address.AddressString, signature, message); err != nil {
+		return 0, ErrResponseSignature
+	}
+	return authTime, nil
+}
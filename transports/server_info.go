@@ -0,0 +1,193 @@
+package transports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/machinebox/graphql"
+)
+
+// ErrServerUnavailable is when the bux server can not be reached, or the server URL is not a bux server
+var ErrServerUnavailable = errors.New("the bux server is unavailable")
+
+// ServerUnavailableError is returned by Ping when the server is unreachable or does not answer like a
+// bux server (ex: a wrong URL or path), it matches ErrServerUnavailable with errors.Is
+type ServerUnavailableError struct {
+	Cause  error
+	Server string
+}
+
+// Error will return the error message, with the server URL that was checked
+func (e *ServerUnavailableError) Error() string {
+	return ErrServerUnavailable.Error() + " at " + e.Server + " (check the server URL): " + e.Cause.Error()
+}
+
+// Is will return whether the target is ErrServerUnavailable
+func (e *ServerUnavailableError) Is(target error) bool {
+	return target == ErrServerUnavailable
+}
+
+// Unwrap will return the cause of the error
+func (e *ServerUnavailableError) Unwrap() error {
+	return e.Cause
+}
+
+// ServerInfo is the version, the enabled features and the capabilities (ex: graphql, beef) of the bux server
+type ServerInfo struct {
+	Capabilities map[string]bool `json:"capabilities"`
+	Features     map[string]bool `json:"features"`
+	Version      string          `json:"version"`
+}
+
+// ServerInfoData is the server info
+type ServerInfoData struct {
+	ServerInfo *ServerInfo `json:"server_info"`
+}
+
+// Ping will check that the server is a healthy bux server (GET /health), without authentication
+func (h *TransportHTTP) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.server+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return &ServerUnavailableError{Cause: err, Server: h.server}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode != http.StatusOK {
+		return &ServerUnavailableError{
+			Cause:  errors.New("health check failed: " + strconv.Itoa(resp.StatusCode) + " - " + resp.Status),
+			Server: h.server,
+		}
+	}
+	return nil
+}
+
+// GetServerInfo will get the version and the enabled features of the server
+func (h *TransportHTTP) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+
+	var serverInfo ServerInfo
+	err := h.doHTTPRequest(ctx, "GET", "/info", nil, h.keys().xPriv, h.signRequest, &serverInfo)
+	if err != nil {
+		return nil, err
+	}
+	if h.debug {
+		h.logger.Debug("server info", logging.F("version", serverInfo.Version))
+	}
+
+	return &serverInfo, nil
+}
+
+// GetCapabilities will get the capabilities advertised by the server (GET /info)
+func (h *TransportHTTP) GetCapabilities(ctx context.Context) (map[string]bool, error) {
+	serverInfo, err := h.GetServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return serverInfo.Capabilities, nil
+}
+
+// Ping will check that the server is a healthy bux server (a graphql endpoint answering the
+// __typename of the query), without authentication
+func (g *TransportGraphQL) Ping(ctx context.Context) error {
+
+	reqBody := `
+   	query {
+	  __typename
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	var respData struct {
+		TypeName string `json:"__typename"`
+	}
+	if err := g.client.Run(ctx, req, &respData); err != nil {
+		return &ServerUnavailableError{Cause: err, Server: g.server}
+	}
+	if respData.TypeName == "" {
+		return &ServerUnavailableError{Cause: errors.New("not a graphql endpoint"), Server: g.server}
+	}
+	return nil
+}
+
+// GetServerInfo will get the version and the enabled features of the server
+func (g *TransportGraphQL) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+
+	reqBody := `
+   	query {
+	  server_info {
+		version
+		features
+		capabilities
+	  }
+	}`
+	req := graphql.NewRequest(reqBody)
+
+	err := g.signGraphQLRequest(req, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// run it and capture the response
+	var respData ServerInfoData
+	if err = g.client.Run(ctx, req, &respData); err != nil {
+		return nil, err
+	}
+	if respData.ServerInfo == nil {
+		return nil, errors.New("server info is missing from the response")
+	}
+	if g.debug {
+		g.logger.Debug("server info", logging.F("version", respData.ServerInfo.Version))
+	}
+
+	return respData.ServerInfo, nil
+}
+
+// GetCapabilities will get the capabilities advertised by the server over plain HTTP (GET /info, served
+// next to the graphql endpoint), so a server that does not serve graphql is detected
+func (g *TransportGraphQL) GetCapabilities(ctx context.Context) (map[string]bool, error) {
+	keys := g.keys()
+	url := strings.TrimSuffix(strings.TrimSuffix(g.server, "/"), "/graphql") + "/info"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// signed like the notification stream (no body)
+	authorize := authorizeNotifications(keys.xPriv, keys.xPub, keys.accessKey, g.signRequest, g.scheduler, g.requestSigner)
+	if err = authorize(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, serverError(resp)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var serverInfo ServerInfo
+	if err = json.NewDecoder(resp.Body).Decode(&serverInfo); err != nil {
+		return nil, err
+	}
+	if g.debug {
+		g.logger.Debug("server capabilities", logging.F("capabilities", serverInfo.Capabilities))
+	}
+	return serverInfo.Capabilities, nil
+}
@@ -0,0 +1,241 @@
+package transports
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+)
+
+// signatureNonceLength is the number of random bytes of the nonce of a signature
+const signatureNonceLength = 32
+
+// WithSignatureTolerance will set how old (or how far in the future, for clock skew) the timestamp of
+// a signed server response can be, signed responses are also rejected when their nonce was already
+// seen within the tolerance (replayed), defaults to bux.AuthSignatureTTL. It does not apply to the
+// requests: the server checks their time against its own bux.AuthSignatureTTL.
+func WithSignatureTolerance(tolerance time.Duration) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.signatureTolerance = tolerance
+		}
+	}
+}
+
+// RequestSigner signs the requests to the bux server: it sets the signature headers of the request,
+// see BSMSigner (the default) and ECDSASigner
+type RequestSigner interface {
+	SignRequest(header *http.Header, request *SignatureRequest) error
+}
+
+// SignatureRequest is what a RequestSigner signs, the signed message binds the key, the hash of the
+// body, the nonce and the time, so the server can reject replayed requests. It is the message verified
+// by the bux server, so it does not bind the method nor the path of the request: a signed body could
+// be sent to another endpoint within bux.AuthSignatureTTL, use TLS so it can not be captured.
+type SignatureRequest struct {
+	Body       string          // the body of the request (empty for the notifications stream)
+	Key        string          // the xPub, or the public key of the access key
+	Nonce      string          // random, the signing key of an xPub is derived from it
+	PrivateKey *bec.PrivateKey // the key signing the request
+	Time       time.Time
+}
+
+// message will return the hash of the body, the time of signing (unix milliseconds) and the signed message
+func (r *SignatureRequest) message() (authHash, authTime, message string) {
+	authHash = buxutils.Hash(r.Body)
+	authTime = strconv.FormatInt(r.Time.UnixMilli(), 10)
+	return authHash, authTime, r.Key + authHash + r.Nonce + authTime
+}
+
+// BSMSigner signs the requests with a Bitcoin Signed Message, as verified by the bux server
+type BSMSigner struct{}
+
+// SignRequest will set the signature headers, the signature is a Bitcoin Signed Message (base64)
+func (BSMSigner) SignRequest(header *http.Header, request *SignatureRequest) error {
+	authHash, authTime, message := request.message()
+	signature, err := bitcoin.SignMessage(hex.EncodeToString(request.PrivateKey.Serialise()), message, true)
+	if err != nil {
+		return err
+	}
+	setSignatureHeaders(header, authHash, request.Nonce, authTime, signature)
+	return nil
+}
+
+// ECDSASigner signs the requests with a raw ECDSA signature over the sha256 of the message, for the
+// servers verifying this scheme instead of Bitcoin Signed Messages
+type ECDSASigner struct{}
+
+// SignRequest will set the signature headers, the signature is DER encoded (hex)
+func (ECDSASigner) SignRequest(header *http.Header, request *SignatureRequest) error {
+	authHash, authTime, message := request.message()
+	hash := sha256.Sum256([]byte(message))
+	signature, err := request.PrivateKey.Sign(hash[:])
+	if err != nil {
+		return err
+	}
+	setSignatureHeaders(header, authHash, request.Nonce, authTime, hex.EncodeToString(signature.Serialise()))
+	return nil
+}
+
+// WithRequestSigner will set the signer of the requests (BSMSigner by default), for the servers
+// verifying another signature scheme
+func WithRequestSigner(signer RequestSigner) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.requestSigner = signer
+		}
+	}
+}
+
+// addSignature will add the signature to the request, the signed message binds the xPub, the hash
+// of the body, a random nonce and the time of signing (from the scheduler, the system clock when nil),
+// so the server can reject replayed requests. The headers and the BSM signature are the ones of
+// bux.SetSignature, which is not used as it signs with the system clock and only with BSM (see
+// RequestSigner).
+func addSignature(header *http.Header, xPriv *bip32.ExtendedKey, bodyString string, scheduler scheduler.Scheduler,
+	signer RequestSigner) error {
+
+	if xPriv == nil {
+		return bux.ErrMissingXPriv
+	}
+
+	xPub, err := bitcoin.GetExtendedPublicKey(xPriv)
+	if err != nil {
+		return err
+	}
+	authNonce, err := signatureNonce()
+	if err != nil {
+		return err
+	}
+
+	// the signing key is derived from the nonce
+	key, err := buxutils.DeriveChildKeyFromHex(xPriv, authNonce)
+	if err != nil {
+		return err
+	}
+	privateKey, err := bitcoin.GetPrivateKeyFromHDKey(key)
+	if err != nil {
+		return err
+	}
+
+	header.Set(bux.AuthHeader, xPub)
+	return signRequest(header, signer, scheduler, &SignatureRequest{
+		Body:       bodyString,
+		Key:        xPub,
+		Nonce:      authNonce,
+		PrivateKey: privateKey,
+	})
+}
+
+// addAccessKeySignature will add the signature of the access key to the request, the signed message
+// binds the public key of the access key, the hash of the body, a random nonce and the time of signing
+func addAccessKeySignature(header *http.Header, accessKey *bec.PrivateKey, bodyString string,
+	scheduler scheduler.Scheduler, signer RequestSigner) error {
+
+	if accessKey == nil {
+		return bux.ErrMissingAccessKey
+	}
+
+	authNonce, err := signatureNonce()
+	if err != nil {
+		return err
+	}
+
+	publicKey := hex.EncodeToString(accessKey.PubKey().SerialiseCompressed())
+	header.Set(bux.AuthAccessKey, publicKey)
+	return signRequest(header, signer, scheduler, &SignatureRequest{
+		Body:       bodyString,
+		Key:        publicKey,
+		Nonce:      authNonce,
+		PrivateKey: accessKey,
+	})
+}
+
+// rotationProof will return the proof that the client holds the new xPriv of a rotation: the
+// signature headers of the new xPub, made with the new xPriv
+func rotationProof(newXPriv *bip32.ExtendedKey, scheduler scheduler.Scheduler,
+	signer RequestSigner) (string, map[string]string, error) {
+
+	newXPub, err := bitcoin.GetExtendedPublicKey(newXPriv)
+	if err != nil {
+		return "", nil, err
+	}
+	header := make(http.Header)
+	if err = addSignature(&header, newXPriv, newXPub, scheduler, signer); err != nil {
+		return "", nil, err
+	}
+	return newXPub, map[string]string{
+		bux.AuthHeaderHash:  header.Get(bux.AuthHeaderHash),
+		bux.AuthHeaderNonce: header.Get(bux.AuthHeaderNonce),
+		bux.AuthHeaderTime:  header.Get(bux.AuthHeaderTime),
+		bux.AuthSignature:   header.Get(bux.AuthSignature),
+	}, nil
+}
+
+// signatureNonce will return a new random nonce
+func signatureNonce() (string, error) {
+	nonce := make([]byte, signatureNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// signRequest will sign the request with the signer (BSMSigner when nil) at the time of the scheduler
+// (the system clock when nil)
+func signRequest(header *http.Header, signer RequestSigner, scheduler scheduler.Scheduler,
+	request *SignatureRequest) error {
+
+	request.Time = time.Now()
+	if scheduler != nil {
+		request.Time = scheduler.Now()
+	}
+	if signer == nil {
+		signer = BSMSigner{}
+	}
+	return signer.SignRequest(header, request)
+}
+
+// setSignatureHeaders will set the signature headers of the request
+func setSignatureHeaders(header *http.Header, authHash, authNonce, authTime, signature string) {
+	header.Set(bux.AuthHeaderHash, authHash)
+	header.Set(bux.AuthHeaderNonce, authNonce)
+	header.Set(bux.AuthHeaderTime, authTime)
+	header.Set(bux.AuthSignature, signature)
+}
+
+// nonceCache remembers the nonces of the signatures until they expire, to detect replays
+type nonceCache struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// add will remember the nonce until it expires, returning false when it is already known (replayed)
+func (n *nonceCache) add(nonce string, expires, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.expires == nil {
+		n.expires = make(map[string]time.Time)
+	}
+	for knownNonce, knownExpires := range n.expires {
+		if now.After(knownExpires) {
+			delete(n.expires, knownNonce)
+		}
+	}
+	if _, ok := n.expires[nonce]; ok {
+		return false
+	}
+	n.expires[nonce] = expires
+	return true
+}
@@ -0,0 +1,112 @@
+package transports
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	buxutils "github.com/BuxOrg/bux/utils"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddSignature will test that the signatures are verified as the bux server verifies them
+func TestAddSignature(t *testing.T) {
+	const body = `{"metadata":{"user_agent":"test"}}`
+	rawXPriv, rawXPub, err := bitcoin.GenerateHDKeyPair(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	xPriv, err := bip32.NewKeyFromString(rawXPriv)
+	require.NoError(t, err)
+	signedAt := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	virtual := scheduler.NewVirtual(signedAt)
+
+	// checkHeaders checks the headers set for every signature, and returns the signed message
+	checkHeaders := func(t *testing.T, header http.Header, key string) string {
+		assert.Equal(t, buxutils.Hash(body), header.Get(bux.AuthHeaderHash))
+		assert.Equal(t, strconv.FormatInt(signedAt.UnixMilli(), 10), header.Get(bux.AuthHeaderTime))
+		nonce, err := hex.DecodeString(header.Get(bux.AuthHeaderNonce))
+		require.NoError(t, err)
+		assert.Len(t, nonce, signatureNonceLength)
+		return key + header.Get(bux.AuthHeaderHash) + header.Get(bux.AuthHeaderNonce) + header.Get(bux.AuthHeaderTime)
+	}
+
+	t.Run("xpub", func(t *testing.T) {
+		header := make(http.Header)
+		require.NoError(t, addSignature(&header, xPriv, body, virtual, nil))
+		assert.Equal(t, rawXPub, header.Get(bux.AuthHeader))
+		message := checkHeaders(t, header, rawXPub)
+
+		// the signing key is derived from the xPub with the nonce
+		xPub, err := bitcoin.GetHDKeyFromExtendedPublicKey(rawXPub)
+		require.NoError(t, err)
+		key, err := buxutils.DeriveChildKeyFromHex(xPub, header.Get(bux.AuthHeaderNonce))
+		require.NoError(t, err)
+		address, err := bitcoin.GetAddressFromHDKey(key)
+		require.NoError(t, err)
+		assert.NoError(t, bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message))
+
+		// the same headers as bux.SetSignature
+		expected := make(http.Header)
+		require.NoError(t, bux.SetSignature(&expected, xPriv, body))
+		assert.Equal(t, headerNames(expected), headerNames(header))
+		assert.Equal(t, expected.Get(bux.AuthHeaderHash), header.Get(bux.AuthHeaderHash))
+	})
+
+	t.Run("access key", func(t *testing.T) {
+		accessKey, err := bec.NewPrivateKey(bec.S256())
+		require.NoError(t, err)
+		header := make(http.Header)
+		require.NoError(t, addAccessKeySignature(&header, accessKey, body, virtual, nil))
+		publicKey := hex.EncodeToString(accessKey.PubKey().SerialiseCompressed())
+		assert.Equal(t, publicKey, header.Get(bux.AuthAccessKey))
+		message := checkHeaders(t, header, publicKey)
+
+		address, err := bitcoin.GetAddressFromPubKeyString(publicKey, true)
+		require.NoError(t, err)
+		assert.NoError(t, bitcoin.VerifyMessage(address.AddressString, header.Get(bux.AuthSignature), message))
+
+		expected := make(http.Header)
+		require.NoError(t, bux.SetSignatureFromAccessKey(&expected, hex.EncodeToString(accessKey.Serialise()), body))
+		assert.Equal(t, headerNames(expected), headerNames(header))
+	})
+
+	t.Run("ecdsa", func(t *testing.T) {
+		accessKey, err := bec.NewPrivateKey(bec.S256())
+		require.NoError(t, err)
+		header := make(http.Header)
+		require.NoError(t, addAccessKeySignature(&header, accessKey, body, virtual, ECDSASigner{}))
+		message := checkHeaders(t, header, hex.EncodeToString(accessKey.PubKey().SerialiseCompressed()))
+
+		data, err := hex.DecodeString(header.Get(bux.AuthSignature))
+		require.NoError(t, err)
+		signature, err := bec.ParseDERSignature(data, bec.S256())
+		require.NoError(t, err)
+		hash := sha256.Sum256([]byte(message))
+		assert.True(t, signature.Verify(hash[:], accessKey.PubKey()))
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		header := make(http.Header)
+		assert.ErrorIs(t, addSignature(&header, nil, body, virtual, nil), bux.ErrMissingXPriv)
+		assert.ErrorIs(t, addAccessKeySignature(&header, nil, body, virtual, nil), bux.ErrMissingAccessKey)
+	})
+}
+
+// headerNames will return the names of the headers, sorted
+func headerNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,442 @@
+package transports
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"golang.org/x/net/proxy"
+	"golang.org/x/net/websocket"
+)
+
+// graphqlWebsocketProtocol is the websocket sub-protocol of graphql subscriptions (graphql-ws)
+const graphqlWebsocketProtocol = "graphql-ws"
+
+// subscriptionDialTimeout is the timeout of opening the websocket connection of a subscription
+const subscriptionDialTimeout = 30 * time.Second
+
+// graphql-ws message types
+const (
+	gqlConnectionInit  = "connection_init"
+	gqlConnectionAck   = "connection_ack"
+	gqlConnectionError = "connection_error"
+	gqlKeepAlive       = "ka"
+	gqlStart           = "start"
+	gqlStop            = "stop"
+	gqlData            = "data"
+	gqlError           = "error"
+	gqlComplete        = "complete"
+)
+
+// ErrSubscriptionsNotSupported is when the transport cannot subscribe (subscriptions need graphql)
+var ErrSubscriptionsNotSupported = errors.New("subscriptions are only supported by the graphql transport")
+
+// TransactionSubscriptionData is a transaction pushed by the transactions subscription
+type TransactionSubscriptionData struct {
+	Transaction *bux.Transaction `json:"transaction_created"`
+}
+
+// graphqlMessage is a message of the graphql-ws protocol
+type graphqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphqlResult is the payload of a data message
+type graphqlResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// SubscribeTransactions will subscribe to the transactions of the xPub matching the conditions, new
+// transactions are pushed on the channel (resubscribing automatically) until the context is done
+func (g *TransportGraphQL) SubscribeTransactions(ctx context.Context,
+	conditions map[string]interface{}) (<-chan *bux.Transaction, error) {
+
+	reqBody := `
+   	subscription ($conditions: Map) {
+	  transaction_created(
+		conditions: $conditions
+	  ) ` + g.selection(ctx, FieldsTransaction, graphqlTransactionFields) + `
+	}`
+	variables := map[string]interface{}{
+		"conditions": conditions,
+	}
+
+	// fail early if the subscription cannot be authenticated
+	if err := g.signGraphQLHeader(make(http.Header), reqBody, variables); err != nil {
+		return nil, err
+	}
+
+	url := strings.Replace(g.server, "http", "ws", 1)
+	transactions := make(chan *bux.Transaction)
+	go func() {
+		defer close(transactions)
+
+		delay := notificationsReconnectDelay
+		for {
+			received, err := g.subscribe(ctx, url, reqBody, variables, transactions)
+			if ctx.Err() != nil {
+				return
+			}
+			if received {
+				delay = notificationsReconnectDelay
+			}
+			if g.debug {
+				g.logger.Warn("transactions subscription interrupted, resubscribing", logging.F("error", err), logging.F("delay", delay))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-g.scheduler.After(delay):
+			}
+
+			if !received {
+				if delay *= 2; delay > notificationsMaxReconnectDelay {
+					delay = notificationsMaxReconnectDelay
+				}
+			}
+		}
+	}()
+
+	return transactions, nil
+}
+
+// subscribe will run the subscription on a new websocket connection until it ends, returning
+// whether any transaction was received
+func (g *TransportGraphQL) subscribe(ctx context.Context, url, reqBody string,
+	variables map[string]interface{}, transactions chan<- *bux.Transaction) (bool, error) {
+
+	// the connection holds a slot of the stream bulkhead until it ends
+	if g.bulkheads != nil {
+		release, err := g.bulkheads.acquire(ctx, OperationClassStream)
+		if err != nil {
+			return false, err
+		}
+		defer release()
+	}
+
+	// every connection is authenticated like a regular request, the headers are also sent in the init payload
+	header := make(http.Header)
+	if err := g.signGraphQLHeader(header, reqBody, variables); err != nil {
+		return false, err
+	}
+	addCustomHeaders(ctx, header, g.headers)
+
+	config, err := websocket.NewConfig(url, g.server)
+	if err != nil {
+		return false, err
+	}
+	config.Header = header
+	config.Protocol = []string{graphqlWebsocketProtocol}
+
+	var conn *websocket.Conn
+	if conn, err = g.dialWebsocket(ctx, config); err != nil {
+		return false, err
+	}
+
+	// closing the connection interrupts the pending receive when the context is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	initPayload := make(map[string]string)
+	for key := range header {
+		initPayload[key] = header.Get(key)
+	}
+	if err = sendGraphQLMessage(conn, "", gqlConnectionInit, initPayload); err != nil {
+		return false, err
+	}
+	if err = sendGraphQLMessage(conn, "1", gqlStart, map[string]interface{}{
+		"query":     reqBody,
+		"variables": variables,
+	}); err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = sendGraphQLMessage(conn, "1", gqlStop, nil)
+	}()
+
+	received := false
+	for {
+		var message graphqlMessage
+		if err = websocket.JSON.Receive(conn, &message); err != nil {
+			return received, err
+		}
+
+		switch message.Type {
+		case gqlConnectionAck, gqlKeepAlive:
+			continue
+		case gqlConnectionError, gqlError:
+			return received, errors.New("subscription error: " + string(message.Payload))
+		case gqlComplete:
+			return received, errors.New("subscription completed by the server")
+		case gqlData:
+			var result graphqlResult
+			if err = json.Unmarshal(message.Payload, &result); err != nil {
+				return received, err
+			}
+			if len(result.Errors) > 0 {
+				return received, errors.New("subscription error: " + result.Errors[0].Message)
+			}
+
+			var data TransactionSubscriptionData
+			if err = json.Unmarshal(result.Data, &data); err != nil {
+				return received, err
+			}
+			if data.Transaction == nil {
+				continue
+			}
+			if g.debug {
+				g.logger.Debug("transaction", logging.F("tx_id", data.Transaction.ID))
+			}
+
+			select {
+			case <-ctx.Done():
+				return received, ctx.Err()
+			case transactions <- data.Transaction:
+				received = true
+			}
+		}
+	}
+}
+
+// sendGraphQLMessage will send a graphql-ws message on the connection
+func sendGraphQLMessage(conn *websocket.Conn, id, messageType string, payload interface{}) error {
+	message := graphqlMessage{
+		ID:   id,
+		Type: messageType,
+	}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		message.Payload = data
+	}
+	return websocket.JSON.Send(conn, message)
+}
+
+// SubscribeTransactions is not supported by the http transport
+func (h *TransportHTTP) SubscribeTransactions(_ context.Context,
+	_ map[string]interface{}) (<-chan *bux.Transaction, error) {
+
+	return nil, ErrSubscriptionsNotSupported
+}
+
+// dialWebsocket will open the websocket connection with the context, through the dialer, the proxy
+// and the tls configuration of the http transport of the client
+func (g *TransportGraphQL) dialWebsocket(ctx context.Context, config *websocket.Config) (*websocket.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, subscriptionDialTimeout)
+	defer cancel()
+
+	httpTransport := g.websocketTransport()
+	dial := contextDialer((&net.Dialer{}).DialContext)
+	tlsConfig := g.tlsConfig
+	if httpTransport != nil {
+		if httpTransport.DialContext != nil {
+			dial = httpTransport.DialContext
+		}
+		if httpTransport.TLSClientConfig != nil {
+			tlsConfig = httpTransport.TLSClientConfig
+		}
+	}
+
+	secure := config.Location.Scheme == "wss"
+	conn, err := dialProxy(ctx, httpTransport, dial, config.Location, hostPort(config.Location, secure))
+	if err != nil {
+		return nil, err
+	}
+	stop := closeOnDone(ctx, conn)
+	defer stop()
+
+	if secure {
+		tlsConn := tls.Client(conn, serverTLSConfig(tlsConfig, config.Location.Hostname()))
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	var ws *websocket.Conn
+	if ws, err = websocket.NewClient(config, conn); err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+// websocketTransport will return the http transport dialing the websocket connections, nil when
+// the http client does not use an *http.Transport
+func (g *TransportGraphQL) websocketTransport() *http.Transport {
+	if g.httpTransport != nil {
+		return g.httpTransport
+	}
+	roundTripper := http.DefaultTransport
+	if g.httpClient != nil && g.httpClient.Transport != nil {
+		roundTripper = g.httpClient.Transport
+	}
+	httpTransport, _ := roundTripper.(*http.Transport)
+	return httpTransport
+}
+
+// contextDialer is a dial function, it is also the forward dialer of the socks5 proxies
+type contextDialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Dial will dial the address
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+// DialContext will dial the address with the context
+func (d contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
+
+// dialProxy will open a connection to the address, through the proxy of the http transport for the
+// location (like the http requests to the same server), the http(s) proxies are tunneled with CONNECT
+func dialProxy(ctx context.Context, httpTransport *http.Transport, dial contextDialer, location *url.URL,
+	address string) (net.Conn, error) {
+
+	var proxyURL *url.URL
+	if httpTransport != nil && httpTransport.Proxy != nil {
+		target := *location
+		target.Scheme = strings.Replace(target.Scheme, "ws", "http", 1)
+		var err error
+		if proxyURL, err = httpTransport.Proxy(&http.Request{
+			Header: make(http.Header),
+			Method: http.MethodGet,
+			URL:    &target,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if proxyURL == nil {
+		return dial(ctx, "tcp", address)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(proxyURL, dial)
+		if err != nil {
+			return nil, err
+		}
+		return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	case "http", "https":
+	default:
+		return nil, errors.New("unsupported proxy scheme: " + proxyURL.Scheme)
+	}
+
+	conn, err := dial(ctx, "tcp", hostPort(proxyURL, proxyURL.Scheme == "https"))
+	if err != nil {
+		return nil, err
+	}
+	stop := closeOnDone(ctx, conn)
+	defer stop()
+
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, serverTLSConfig(httpTransport.TLSClientConfig, proxyURL.Hostname()))
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	connect := &http.Request{
+		Header: make(http.Header),
+		Host:   address,
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+	}
+	for name, values := range httpTransport.ProxyConnectHeader {
+		connect.Header[name] = values
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	var resp *http.Response
+	if err = connect.Write(conn); err == nil {
+		resp, err = http.ReadResponse(bufio.NewReader(conn), connect)
+	}
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, errors.New("proxy error: " + resp.Status)
+	}
+	return conn, nil
+}
+
+// closeOnDone will close the connection when the context is done, until stop is called, to
+// interrupt the handshakes on the connection
+func closeOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// serverTLSConfig will return a copy of the tls configuration for the server name
+func serverTLSConfig(tlsConfig *tls.Config, serverName string) *tls.Config {
+	if tlsConfig == nil {
+		return &tls.Config{ServerName: serverName} //nolint:gosec // the default minimum version
+	}
+	tlsConfig = tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = serverName
+	}
+	return tlsConfig
+}
+
+// hostPort will return the host and port of the url, with the default port when it is not set
+func hostPort(location *url.URL, secure bool) string {
+	port := location.Port()
+	if port == "" {
+		port = "80"
+		if secure {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(location.Hostname(), port)
+}
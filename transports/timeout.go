@@ -0,0 +1,57 @@
+package transports
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithDefaultTimeout will limit the duration of the requests sent with a context without a deadline
+// (ex: context.Background()), so a hung server cannot stall a call when the http client has no
+// timeout. The requests with a context deadline and the notification streams are not limited.
+func WithDefaultTimeout(timeout time.Duration) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.defaultTimeout = timeout
+		}
+	}
+}
+
+// timeoutRoundTripper sets the default timeout on the requests without a deadline
+type timeoutRoundTripper struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip will execute the request, with the default timeout when its context has no deadline,
+// the timeout covers reading the response body
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Context().Deadline(); ok || req.Header.Get("Accept") == eventStreamContentType {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: cancel}
+	return resp, nil
+}
+
+// onCloseBody calls the function (once) when the response body is closed (ex: to cancel the context
+// of the request, which would stop reading the body)
+type onCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+// Close will close the body and call the function
+func (b *onCloseBody) Close() error {
+	defer b.once.Do(b.onClose)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,49 @@
+package transports
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// ErrTLSNotSupported is when a TLS config is set with an http client that does not use an *http.Transport
+var ErrTLSNotSupported = errors.New("a TLS config can only be set on http clients using an *http.Transport")
+
+// WithTLSConfig will set the TLS config of the connections to the server (ex: for mutual TLS, or a
+// private certificate authority), the config is copied
+func WithTLSConfig(tlsConfig *tls.Config) ClientOps {
+	return func(c *Client) {
+		if c != nil && tlsConfig != nil {
+			c.tlsConfig = tlsConfig.Clone()
+		}
+	}
+}
+
+// WithClientCertificate will add a client certificate, presented to servers requiring mutual TLS
+func WithClientCertificate(certificate tls.Certificate) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.tlsConfig = ensureTLSConfig(c.tlsConfig)
+			c.tlsConfig.Certificates = append(c.tlsConfig.Certificates, certificate)
+		}
+	}
+}
+
+// WithRootCAs will set the certificate authorities used to verify the certificate of the server,
+// instead of the system pool
+func WithRootCAs(rootCAs *x509.CertPool) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.tlsConfig = ensureTLSConfig(c.tlsConfig)
+			c.tlsConfig.RootCAs = rootCAs
+		}
+	}
+}
+
+// ensureTLSConfig will return the TLS config, or a new one when nil
+func ensureTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return tlsConfig
+}
@@ -0,0 +1,72 @@
+package transports
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the tracer (the instrumentation library)
+const tracerName = "github.com/BuxOrg/go-buxclient"
+
+// tracePropagator injects the trace context (and baggage) in the headers of the requests to the bux server
+var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// WithTracerProvider will trace every request to the bux server with a span of the tracer provider
+func WithTracerProvider(provider trace.TracerProvider) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.tracerProvider = provider
+		}
+	}
+}
+
+// tracingRoundTripper starts a span for every request and propagates it to the bux server
+type tracingRoundTripper struct {
+	next      http.RoundTripper
+	tracer    trace.Tracer
+	transport TransportType
+}
+
+// RoundTrip will execute the request in a new span
+func (t *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := requestOperation(t.transport, req)
+
+	attributes := []attribute.KeyValue{
+		attribute.String("bux.transport", string(t.transport)),
+		attribute.String("bux.operation", operation),
+		attribute.String("http.method", req.Method),
+		attribute.String("net.peer.name", req.URL.Hostname()),
+		attribute.String("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		attribute.Int64("http.request_content_length", req.ContentLength),
+	}
+	if t.transport == BuxTransportGraphQL && !strings.Contains(operation, " ") {
+		attributes = append(attributes, attribute.String("graphql.operation.name", operation))
+	}
+
+	ctx, span := t.tracer.Start(
+		req.Context(), operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...),
+	)
+	defer span.End()
+
+	// the request is cloned, a round tripper must not modify the original request
+	req = req.Clone(ctx)
+	tracePropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
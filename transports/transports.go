@@ -2,19 +2,29 @@ package transports
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/events"
+	"github.com/BuxOrg/go-buxclient/logging"
+	"github.com/BuxOrg/go-buxclient/metadata"
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/BuxOrg/go-buxclient/store"
 	"github.com/libsv/go-bk/bec"
 	"github.com/libsv/go-bk/bip32"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TransportType the type of transport being used (http or graphql)
 type TransportType string
 
 // BuxUserAgent the bux user agent sent to the bux server
-const BuxUserAgent = "BuxClient v1.0.0"
+const BuxUserAgent = "BuxClient " + Version
 
 const (
 	// BuxTransportHTTP uses the http transport for all bux server actions
@@ -27,44 +37,113 @@ const (
 
 // Client ...
 type Client struct {
-	accessKey   *bec.PrivateKey
-	adminKey    string
-	adminXPriv  *bip32.ExtendedKey
-	debug       bool
-	signRequest bool
-	transport   TransportService
-	xPriv       *bip32.ExtendedKey
-	xPub        *bip32.ExtendedKey
+	accessKey           *bec.PrivateKey
+	adminKey            string
+	adminXPriv          *bip32.ExtendedKey
+	apiVersion          string
+	bulkheads           map[OperationClass]*Bulkhead
+	checkpoints         store.Store
+	conditionalRequests bool
+	debug               bool
+	defaultTimeout      time.Duration
+	deprecationHandler  DeprecationHandler
+	discover            DiscoverFunc
+	discoveryInterval   time.Duration
+	failoverURLs        []string
+	fields              map[FieldModel][]string
+	headers             http.Header
+	idObfuscator        logging.IDObfuscator
+	loadBalancing       LoadBalancing
+	logger              logging.Logger
+	metrics             metricsRecorders
+	middlewares         []Middleware
+	persistedQueries    bool
+	proxyURL            string
+	readReplicaURL      string
+	recoveryInterval    time.Duration
+	requestManifest     RequestManifest
+	requestSigner       RequestSigner
+	scheduler           scheduler.Scheduler
+	serverXPub          string
+	signatureTolerance  time.Duration
+	signRequest         bool
+	stalenessWindow     time.Duration
+	tlsConfig           *tls.Config
+	tracerProvider      trace.TracerProvider
+	transport           TransportService
+	userAgent           string
+	xPriv               *bip32.ExtendedKey
+	xPub                *bip32.ExtendedKey
 }
 
 // ClientOps ...
 type ClientOps func(c *Client)
 
-// addSignature will add the signature to the request
-func addSignature(header *http.Header, xPriv *bip32.ExtendedKey, bodyString string) error {
-	return bux.SetSignature(header, xPriv, bodyString)
-}
-
 // TransportService the transport service interface
 type TransportService interface {
 	Init() error
 	SetAdminKey(adminKey *bip32.ExtendedKey)
+	HasAdminKey() bool
+	SetXPriv(xPriv *bip32.ExtendedKey) error
+	SetAccessKey(accessKey *bec.PrivateKey)
 	SetDebug(debug bool)
+	SetLogger(logger logging.Logger)
+	SetScheduler(scheduler scheduler.Scheduler)
+	SetRequestSigner(signer RequestSigner)
 	IsDebug() bool
 	SetSignRequest(debug bool)
 	IsSignRequest() bool
 	RegisterXpub(ctx context.Context, rawXPub string, metadata *bux.Metadata) error
+	GetXPub(ctx context.Context) (*bux.Xpub, error)
 	GetDestination(ctx context.Context, metadata *bux.Metadata) (*bux.Destination, error)
+	GetDestinationByAddress(ctx context.Context, address string) (*bux.Destination, error)
+	NewDestinations(ctx context.Context, count int, metadata *bux.Metadata) ([]*bux.Destination, error)
 	GetTransaction(ctx context.Context, txID string) (*bux.Transaction, error)
-	GetTransactions(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata) ([]*bux.Transaction, error)
-	DraftToRecipients(ctx context.Context, recipients []*Recipients, metadata *bux.Metadata) (*bux.DraftTransaction, error)
-	DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig, metadata *bux.Metadata) (*bux.DraftTransaction, error)
+	GetTransactions(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata,
+		queryParams *QueryParams) ([]*bux.Transaction, error)
+	DraftToRecipients(ctx context.Context, recipients []*Recipients, metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error)
+	DraftTransaction(ctx context.Context, transactionConfig *bux.TransactionConfig, metadata *bux.Metadata, opts *DraftOptions) (*bux.DraftTransaction, error)
 	RecordTransaction(ctx context.Context, hex, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	RecordTransactions(ctx context.Context, requests []*RecordRequest) ([]*bux.Transaction, error)
+	SearchTransactions(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Transaction, error)
+	Count(ctx context.Context, model CountModel, conditions map[string]interface{}, metadata *bux.Metadata) (int64, error)
+	UpdateTransactionMetadata(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	GetTransactionStatus(ctx context.Context, txID string) (*TransactionStatus, error)
+	GetMerkleProof(ctx context.Context, txID string) (*MerkleProof, error)
+	GetBlockHeader(ctx context.Context, blockHash string) (*BlockHeader, error)
+	GetFeeQuote(ctx context.Context) (*FeeQuote, error)
+	GetTransactionBEEF(ctx context.Context, txID string) (*TransactionBEEF, error)
+	RecordTransactionBEEF(ctx context.Context, beef, referenceID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	ImportTransaction(ctx context.Context, txID string, metadata *bux.Metadata) (*bux.Transaction, error)
+	GetDestinations(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Destination, error)
+	UpdateDestinationMetadata(ctx context.Context, id string, metadata *bux.Metadata) (*bux.Destination, error)
+	GetUtxos(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.Utxo, error)
+	AdminCreatePaymail(ctx context.Context, xPubID, address, publicName, avatar string, metadata *bux.Metadata) (*PaymailAddress, error)
+	AdminDeletePaymail(ctx context.Context, address string) error
+	AdminGetPaymails(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *QueryParams) ([]*PaymailAddress, error)
+	AdminFreezeXPub(ctx context.Context, xPubID, reason string) (*XPubStatus, error)
+	AdminUnfreezeXPub(ctx context.Context, xPubID string) (*XPubStatus, error)
+	GetXPubStatus(ctx context.Context) (*XPubStatus, error)
+	RotateXpub(ctx context.Context, newXPriv *bip32.ExtendedKey) (*bux.Xpub, error)
+	CreateAccessKey(ctx context.Context, metadata *bux.Metadata) (*bux.AccessKey, error)
+	RevokeAccessKey(ctx context.Context, id string) (*bux.AccessKey, error)
+	GetAccessKeys(ctx context.Context, conditions map[string]interface{}, metadata *bux.Metadata, queryParams *QueryParams) ([]*bux.AccessKey, error)
+	RegisterWebhook(ctx context.Context, url string, eventTypes []events.EventType, secret string) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	Notifications(ctx context.Context) (<-chan *events.Event, error)
+	SubscribeTransactions(ctx context.Context, conditions map[string]interface{}) (<-chan *bux.Transaction, error)
+	GetFeatureFlags(ctx context.Context) (map[string]bool, error)
+	Ping(ctx context.Context) error
+	GetServerInfo(ctx context.Context) (*ServerInfo, error)
+	GetCapabilities(ctx context.Context) (map[string]bool, error)
+	RunGraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error
+	DoRawRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error
 }
 
 // NewTransport create a new transport service object
 func NewTransport(opts ...ClientOps) (TransportService, error) {
-	client := Client{}
+	client := Client{logger: logging.Default(), scheduler: scheduler.Default()}
 
 	for _, opt := range opts {
 		opt(&client)
@@ -73,6 +152,232 @@ func NewTransport(opts ...ClientOps) (TransportService, error) {
 	if client.transport == nil {
 		return nil, errors.New("no transport client set")
 	}
+	if client.requestSigner != nil {
+		client.transport.SetRequestSigner(client.requestSigner)
+	}
+	if client.idObfuscator != nil {
+		client.transport.SetLogger(logging.ObfuscateIDs(client.logger, client.idObfuscator))
+	}
+
+	var operationBulkheads *bulkheads
+	if wrapper, ok := client.transport.(roundTripperWrapper); ok {
+		if client.proxyURL != "" {
+			proxyURL, err := url.Parse(client.proxyURL)
+			if err != nil {
+				return nil, err
+			}
+			wrapper.wrapRoundTripper(httpTransportWrapper(func(httpTransport *http.Transport) {
+				httpTransport.Proxy = http.ProxyURL(proxyURL)
+			}, ErrProxyNotSupported, &err))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if client.tlsConfig != nil {
+			var err error
+			wrapper.wrapRoundTripper(httpTransportWrapper(func(httpTransport *http.Transport) {
+				httpTransport.TLSClientConfig = client.tlsConfig.Clone()
+			}, ErrTLSNotSupported, &err))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// every operation class gets its own copy of the connection pool
+		if client.bulkheads != nil {
+			var err error
+			operationBulkheads = newBulkheads(client.bulkheads, client.scheduler)
+			wrapper.wrapRoundTripper(operationBulkheads.wrapper(&err))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// the graphql queries are sent by hash, the wrappers above still see the full queries
+		if client.persistedQueries {
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				if transport != BuxTransportGraphQL {
+					return next
+				}
+				return &persistedQueryRoundTripper{next: next}
+			})
+		}
+
+		// the requests of the server are sent to the discovered server, the failover sees the server URL
+		if client.discover != nil {
+			server, err := url.Parse(strings.TrimSuffix(transportServerURL(client.transport), "/"))
+			if err != nil {
+				return nil, err
+			}
+			interval := client.discoveryInterval
+			if interval <= 0 {
+				interval = defaultDiscoveryInterval
+			}
+			wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+				return &discoveryRoundTripper{
+					discover:  client.discover,
+					interval:  interval,
+					next:      next,
+					scheduler: client.scheduler,
+					server:    server,
+				}
+			})
+		}
+
+		// the requests of the primary server are sent to the first healthy server, the reads are balanced
+		if len(client.failoverURLs) > 0 {
+			var err error
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				var failover *failoverRoundTripper
+				if failover, err = newFailoverRoundTripper(transport, next, transportServerURL(client.transport),
+					client.failoverURLs, client.recoveryInterval, client.loadBalancing, client.scheduler); err != nil {
+					return next
+				}
+				return failover
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// the requests outside the manifest never reach the network
+		if client.requestManifest != nil {
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				return &manifestRoundTripper{
+					basePath:  serverBasePath(transport, transportServerURL(client.transport)),
+					manifest:  client.requestManifest,
+					next:      next,
+					transport: transport,
+				}
+			})
+		}
+
+		// the responses are verified as they come from the network (or the failover servers), before the
+		// middlewares which can not alter what is verified
+		if client.serverXPub != "" {
+			if _, err := bip32.NewKeyFromString(client.serverXPub); err != nil {
+				return nil, err
+			}
+			tolerance := client.signatureTolerance
+			if tolerance <= 0 {
+				tolerance = bux.AuthSignatureTTL
+			}
+			wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+				return &responseSignatureRoundTripper{
+					next:       next,
+					nonces:     &nonceCache{},
+					scheduler:  client.scheduler,
+					serverXPub: client.serverXPub,
+					tolerance:  tolerance,
+				}
+			})
+		}
+
+		// the first middleware is the outermost, metrics and tracing see the effect of all middlewares
+		for index := len(client.middlewares) - 1; index >= 0; index-- {
+			middleware := client.middlewares[index]
+			wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+				return middleware(next.RoundTrip)
+			})
+		}
+
+		// the previous responses were verified, the 304 responses are verified too
+		if client.conditionalRequests {
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				if transport != BuxTransportHTTP {
+					return next
+				}
+				return &conditionalRoundTripper{
+					entries: make(map[string]*conditionalEntry),
+					headers: client.headers,
+					next:    next,
+				}
+			})
+		}
+
+		if client.readReplicaURL != "" {
+			replica, err := url.Parse(client.readReplicaURL)
+			if err != nil {
+				return nil, err
+			}
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				return &readReplicaRoundTripper{
+					next:            next,
+					replica:         replica,
+					scheduler:       client.scheduler,
+					stalenessWindow: client.stalenessWindow,
+					transport:       transport,
+				}
+			})
+		}
+
+		// the deprecation warnings of the server are logged, unless handled by the application
+		deprecationHandler := client.deprecationHandler
+		if deprecationHandler == nil {
+			deprecationHandler = logDeprecation(client.logger)
+		}
+		wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+			return &deprecationRoundTripper{
+				handler:   deprecationHandler,
+				next:      next,
+				reported:  make(map[Deprecation]bool),
+				transport: transport,
+			}
+		})
+
+		// the middlewares see the identity of the client and the custom headers, the User-Agent can only be
+		// replaced with WithUserAgent
+		userAgent, apiVersion := client.userAgent, client.apiVersion
+		if userAgent == "" {
+			userAgent = DefaultUserAgent()
+		}
+		if apiVersion == "" {
+			apiVersion = APIVersion
+		}
+		wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+			return &userAgentRoundTripper{apiVersion: apiVersion, next: next, userAgent: userAgent}
+		})
+		wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+			return &headersRoundTripper{headers: client.headers, next: next}
+		})
+		if len(client.metrics) > 0 {
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				return &metricsRoundTripper{next: next, recorder: client.metrics, transport: transport}
+			})
+		}
+		if client.tracerProvider != nil {
+			tracer := client.tracerProvider.Tracer(tracerName)
+			wrapper.wrapRoundTripper(func(transport TransportType, next http.RoundTripper) http.RoundTripper {
+				return &tracingRoundTripper{next: next, tracer: tracer, transport: transport}
+			})
+		}
+		// the timeout covers the whole request, including the retries on the primary
+		if client.defaultTimeout > 0 {
+			wrapper.wrapRoundTripper(func(_ TransportType, next http.RoundTripper) http.RoundTripper {
+				return &timeoutRoundTripper{next: next, timeout: client.defaultTimeout}
+			})
+		}
+	}
+
+	switch transport := client.transport.(type) {
+	case *TransportHTTP:
+		transport.checkpoints = client.checkpoints
+	case *TransportGraphQL:
+		transport.checkpoints = client.checkpoints
+		transport.fields = make(map[FieldModel]string, len(client.fields))
+		for model, fields := range client.fields {
+			set, err := selectionSet(fields)
+			if err != nil {
+				return nil, err
+			}
+			transport.fields[model] = set
+		}
+
+		// the subscriptions use a websocket connection instead of the http client
+		transport.bulkheads = operationBulkheads
+		transport.headers = client.headers
+		transport.tlsConfig = client.tlsConfig
+	}
 
 	if err := client.transport.Init(); err != nil {
 		return nil, err
@@ -90,20 +395,26 @@ func NewTransport(opts ...ClientOps) (TransportService, error) {
 	return client.transport, nil
 }
 
+// transportServerURL will return the url of the server of the transport
+func transportServerURL(transport TransportService) string {
+	switch t := transport.(type) {
+	case *TransportHTTP:
+		return t.server
+	case *TransportGraphQL:
+		return t.server
+	}
+	return ""
+}
+
 // NewTransportService create a new transport service interface
 func NewTransportService(transportService TransportService) TransportService {
 	return transportService
 }
 
-func processMetadata(metadata *bux.Metadata) *bux.Metadata {
-	if metadata == nil {
-		m := make(bux.Metadata)
-		metadata = &m
-	}
-
-	(*metadata)["user_agent"] = BuxUserAgent
-
-	return metadata
+// withUserAgent will return a copy of the metadata of the request with the user agent of the client,
+// the metadata of the caller is not modified
+func withUserAgent(values *bux.Metadata) *bux.Metadata {
+	return metadata.From(values).SetString(metadata.KeyUserAgent, BuxUserAgent).Bux()
 }
 
 // WithXPriv will set the xPriv
@@ -143,6 +454,8 @@ func WithGraphQL(serverURL string) ClientOps {
 				signRequest: c.signRequest,
 				adminXPriv:  c.adminXPriv,
 				httpClient:  &http.Client{},
+				logger:      c.logger,
+				scheduler:   c.scheduler,
 				xPriv:       c.xPriv,
 				xPub:        c.xPub,
 				accessKey:   c.accessKey,
@@ -161,6 +474,8 @@ func WithHTTP(serverURL string) ClientOps {
 				signRequest: c.signRequest,
 				adminXPriv:  c.adminXPriv,
 				httpClient:  &http.Client{},
+				logger:      c.logger,
+				scheduler:   c.scheduler,
 				xPriv:       c.xPriv,
 				xPub:        c.xPub,
 				accessKey:   c.accessKey,
@@ -179,6 +494,8 @@ func WithGraphQLClient(serverURL string, httpClient *http.Client) ClientOps {
 				signRequest: c.signRequest,
 				adminXPriv:  c.adminXPriv,
 				httpClient:  httpClient,
+				logger:      c.logger,
+				scheduler:   c.scheduler,
 				xPriv:       c.xPriv,
 				xPub:        c.xPub,
 				accessKey:   c.accessKey,
@@ -197,6 +514,8 @@ func WithHTTPClient(serverURL string, httpClient *http.Client) ClientOps {
 				signRequest: c.signRequest,
 				adminXPriv:  c.adminXPriv,
 				httpClient:  httpClient,
+				logger:      c.logger,
+				scheduler:   c.scheduler,
 				xPriv:       c.xPriv,
 				xPub:        c.xPub,
 				accessKey:   c.accessKey,
@@ -237,3 +556,37 @@ func WithDebugging(debug bool) ClientOps {
 		}
 	}
 }
+
+// WithLogger will set the logger of the debug output (stdout by default)
+func WithLogger(logger logging.Logger) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.logger = logger
+			if c.transport != nil {
+				c.transport.SetLogger(logger)
+			}
+		}
+	}
+}
+
+// WithIDObfuscator will obfuscate the identifiers (ex: txIDs, xPub IDs, addresses) in the logs, the
+// metrics and traces are keyed by operation (the method and path, or the graphql root field)
+func WithIDObfuscator(obfuscator logging.IDObfuscator) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.idObfuscator = obfuscator
+		}
+	}
+}
+
+// WithScheduler will set the scheduler of the reconnect and resubscribe waits (real time by default)
+func WithScheduler(scheduler scheduler.Scheduler) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.scheduler = scheduler
+			if c.transport != nil {
+				c.transport.SetScheduler(scheduler)
+			}
+		}
+	}
+}
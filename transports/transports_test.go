@@ -1,8 +1,12 @@
 package transports
 
 import (
+	"bytes"
+	"net"
 	"testing"
 
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +76,48 @@ func TestWithSignRequest(t *testing.T) {
 		assert.Equal(t, true, c.IsSignRequest())
 	})
 }
+
+// TestWithUserAgent will test the method withUserAgent()
+func TestWithUserAgent(t *testing.T) {
+	assert.Equal(t, &bux.Metadata{"user_agent": BuxUserAgent}, withUserAgent(nil))
+
+	metadata := &bux.Metadata{"key": "value"}
+	assert.Equal(t, &bux.Metadata{"key": "value", "user_agent": BuxUserAgent}, withUserAgent(metadata))
+	assert.Equal(t, &bux.Metadata{"key": "value"}, metadata)
+}
+
+// TestSRVURLs will test building the server URLs of the SRV records
+func TestSRVURLs(t *testing.T) {
+	urls := srvURLs([]*net.SRV{
+		{Target: "bux-0.bux.default.svc.cluster.local.", Port: 3003, Priority: 10},
+		{Target: "bux-1.bux.default.svc.cluster.local.", Port: 3004, Priority: 20},
+	}, "http", "/v1")
+	assert.Equal(t, []string{
+		"http://bux-0.bux.default.svc.cluster.local:3003/v1",
+		"http://bux-1.bux.default.svc.cluster.local:3004/v1",
+	}, urls)
+}
+
+// TestDebugResult will test the method debugResult()
+func TestDebugResult(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.NewTextLogger(&buffer)
+
+	t.Run("nil result", func(t *testing.T) {
+		buffer.Reset()
+		var webhook *Webhook
+		debugResult(logger, "webhook", webhook, func() []logging.Field {
+			return []logging.Field{logging.F("webhook_id", webhook.ID)}
+		})
+		assert.Contains(t, buffer.String(), "webhook result=empty")
+	})
+
+	t.Run("result", func(t *testing.T) {
+		buffer.Reset()
+		webhook := &Webhook{ID: "webhook-id"}
+		debugResult(logger, "webhook", webhook, func() []logging.Field {
+			return []logging.Field{logging.F("webhook_id", webhook.ID)}
+		})
+		assert.Contains(t, buffer.String(), "webhook webhook_id=webhook-id")
+	})
+}
@@ -0,0 +1,55 @@
+package transports
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Version is the version of the bux client library
+const Version = "v1.0.0"
+
+// APIVersion is the version of the bux server API used by the client
+const APIVersion = "v1"
+
+// APIVersionHeader is the header of the version of the bux server API used by the client
+const APIVersionHeader = "X-Api-Version"
+
+// DefaultUserAgent will return the User-Agent header sent to the bux server: the version of the client
+// library, of Go and the platform (ex: BuxClient v1.0.0 (go1.17; linux/amd64))
+func DefaultUserAgent() string {
+	return BuxUserAgent + " (" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+}
+
+// WithUserAgent will replace the User-Agent header sent with every request (see DefaultUserAgent)
+func WithUserAgent(userAgent string) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.userAgent = userAgent
+		}
+	}
+}
+
+// WithAPIVersion will replace the version of the bux server API sent with every request (see APIVersion),
+// ex: to keep using an older API of the server
+func WithAPIVersion(apiVersion string) ClientOps {
+	return func(c *Client) {
+		if c != nil {
+			c.apiVersion = apiVersion
+		}
+	}
+}
+
+// userAgentRoundTripper sets the User-Agent and API version headers of every request
+type userAgentRoundTripper struct {
+	apiVersion string
+	next       http.RoundTripper
+	userAgent  string
+}
+
+// RoundTrip will set the User-Agent and API version headers on a copy of the request and execute it
+func (u *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", u.userAgent)
+	req.Header.Set(APIVersionHeader, u.apiVersion)
+	return u.next.RoundTrip(req)
+}
@@ -0,0 +1,149 @@
+package buxclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// ErrUsageAnalyticsDisabled is when reporting the usage without a collector (see WithUsageAnalytics)
+var ErrUsageAnalyticsDisabled = errors.New("usage analytics is not enabled")
+
+// UsageCollector receives the usage reports of the client (opt-in, see WithUsageAnalytics), ex: to
+// aggregate the usage of the clients embedded in many services
+type UsageCollector interface {
+	CollectUsage(report *UsageReport)
+}
+
+// UsageReport is the anonymized usage of a client during a period: the number of requests and
+// errors per operation, without any key, identifier, amount or error message
+type UsageReport struct {
+	InstanceID string            `json:"instance_id"` // random, generated for every client
+	Operations []*OperationUsage `json:"operations"`
+	Since      time.Time         `json:"since"`
+	Until      time.Time         `json:"until"`
+	UserAgent  string            `json:"user_agent"`
+}
+
+// OperationUsage is the usage of one operation ("transactions" for graphql, "POST /transactions/search" for http)
+type OperationUsage struct {
+	Errors    uint64                   `json:"errors"`
+	Operation string                   `json:"operation"`
+	Requests  uint64                   `json:"requests"`
+	Transport transports.TransportType `json:"transport"`
+}
+
+// ErrorRate will return the share of the requests that failed (0 to 1)
+func (o *OperationUsage) ErrorRate() float64 {
+	if o.Requests == 0 {
+		return 0
+	}
+	return float64(o.Errors) / float64(o.Requests)
+}
+
+// usageKey is the key of the usage of an operation
+type usageKey struct {
+	operation string
+	transport transports.TransportType
+}
+
+// usageRecorder aggregates the usage between two reports
+type usageRecorder struct {
+	collector  UsageCollector
+	instanceID string
+	mu         sync.Mutex
+	operations map[usageKey]*OperationUsage
+	since      time.Time
+}
+
+// newUsageRecorder will return a new usage recorder with a random instance ID
+func newUsageRecorder(collector UsageCollector) *usageRecorder {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &usageRecorder{
+		collector:  collector,
+		instanceID: hex.EncodeToString(id),
+		operations: make(map[usageKey]*OperationUsage),
+	}
+}
+
+// RecordRequest will count the request (the duration is not reported)
+func (u *usageRecorder) RecordRequest(transport transports.TransportType, operation string, _ time.Duration, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := usageKey{operation: operation, transport: transport}
+	usage, ok := u.operations[key]
+	if !ok {
+		usage = &OperationUsage{Operation: operation, Transport: transport}
+		u.operations[key] = usage
+	}
+	usage.Requests++
+	if err != nil {
+		usage.Errors++
+	}
+}
+
+// report will send the usage since the last report to the collector, and start a new period
+func (u *usageRecorder) report(now time.Time) {
+	u.mu.Lock()
+	operations := u.operations
+	since := u.since
+	u.operations = make(map[usageKey]*OperationUsage)
+	u.since = now
+	u.mu.Unlock()
+
+	report := &UsageReport{
+		InstanceID: u.instanceID,
+		Operations: make([]*OperationUsage, 0, len(operations)),
+		Since:      since,
+		Until:      now,
+		UserAgent:  transports.BuxUserAgent,
+	}
+	for _, usage := range operations {
+		report.Operations = append(report.Operations, usage)
+	}
+	sort.Slice(report.Operations, func(i, j int) bool {
+		if report.Operations[i].Transport != report.Operations[j].Transport {
+			return report.Operations[i].Transport < report.Operations[j].Transport
+		}
+		return report.Operations[i].Operation < report.Operations[j].Operation
+	})
+	u.collector.CollectUsage(report)
+}
+
+// ReportUsage will send the usage since the last report to the collector of WithUsageAnalytics
+func (b *BuxClient) ReportUsage() error {
+	if b.usage == nil {
+		return ErrUsageAnalyticsDisabled
+	}
+	b.usage.report(b.scheduler.Now())
+	return nil
+}
+
+// RunUsageReports will send the usage to the collector every interval, until the context is done,
+// the usage of the last period is reported before returning
+func (b *BuxClient) RunUsageReports(ctx context.Context, interval time.Duration) error {
+	if b.usage == nil {
+		return ErrUsageAnalyticsDisabled
+	}
+
+	ticker := b.scheduler.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.usage.report(b.scheduler.Now())
+			return ctx.Err()
+		case <-ticker.C():
+			b.usage.report(b.scheduler.Now())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/wif"
+)
+
+// ErrInvalidAccessKey is when the access key is not a private key in the WIF or the hex format
+var ErrInvalidAccessKey = errors.New("invalid access key, expected a private key in the WIF or the hex format")
+
+// ParseAccessKey will return the private key of an access key in the WIF (ex: L1...) or the hex format
+// (64 characters, as issued by the bux server)
+func ParseAccessKey(accessKey string) (*bec.PrivateKey, error) {
+	accessKey = strings.TrimSpace(accessKey)
+	if accessKey == "" {
+		return nil, fmt.Errorf("%w: the access key is empty", ErrInvalidAccessKey)
+	}
+
+	if isHex(accessKey) {
+		if len(accessKey) != 64 {
+			return nil, fmt.Errorf("%w: a hex access key has 64 characters, got %d", ErrInvalidAccessKey, len(accessKey))
+		}
+		data, _ := hex.DecodeString(accessKey)
+		if err := validatePrivateKey(data); err != nil {
+			return nil, err
+		}
+		privateKey, _ := bec.PrivKeyFromBytes(bec.S256(), data)
+		return privateKey, nil
+	}
+
+	decoded, err := wif.DecodeWIF(accessKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a valid WIF (%s)", ErrInvalidAccessKey, err.Error())
+	}
+	if err = validatePrivateKey(decoded.PrivKey.Serialise()); err != nil {
+		return nil, err
+	}
+	return decoded.PrivKey, nil
+}
+
+// IsValidAccessKey will return whether the access key is a private key in the WIF or the hex format
+func IsValidAccessKey(accessKey string) bool {
+	_, err := ParseAccessKey(accessKey)
+	return err == nil
+}
+
+// AccessKeyPublicKey will return the public key of the access key (compressed, hex)
+func AccessKeyPublicKey(accessKey string) (string, error) {
+	privateKey, err := ParseAccessKey(accessKey)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(privateKey.PubKey().SerialiseCompressed()), nil
+}
+
+// AccessKeyID will return the ID of the access key on the bux server (the hash of its public key), ex:
+// to revoke it or to find it in the access keys of the xPub
+func AccessKeyID(accessKey string) (string, error) {
+	publicKey, err := AccessKeyPublicKey(accessKey)
+	if err != nil {
+		return "", err
+	}
+	return Hash(publicKey), nil
+}
+
+// PrivateKeyAccessKeyID will return the ID of the access key of the private key on the bux server
+func PrivateKeyAccessKeyID(privateKey *bec.PrivateKey) string {
+	return Hash(hex.EncodeToString(privateKey.PubKey().SerialiseCompressed()))
+}
+
+// validatePrivateKey will return ErrInvalidAccessKey if the private key is not in the range of the curve
+func validatePrivateKey(data []byte) error {
+	privateKey, _ := bec.PrivKeyFromBytes(bec.S256(), data)
+	if privateKey.D.Sign() == 0 || privateKey.D.Cmp(bec.S256().N) >= 0 {
+		return fmt.Errorf("%w: the private key is out of the range of the curve", ErrInvalidAccessKey)
+	}
+	return nil
+}
+
+// isHex will return whether the string only has hex characters
+func isHex(value string) bool {
+	for _, c := range value {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// ErrDestinationMismatch is when a destination was not derived from the key at its chain/num
+var ErrDestinationMismatch = errors.New("the destination does not belong to the xpub")
+
+// DeriveDestinationPrivateKey will derive the private key of the destination at chain/num from the
+// xPriv, the key that signs the inputs spending the destination
+func DeriveDestinationPrivateKey(xPriv *bip32.ExtendedKey, chain, num uint32) (*bec.PrivateKey, error) {
+	if xPriv == nil {
+		return nil, utils.ErrHDKeyNil
+	}
+
+	chainKey, err := xPriv.Child(chain)
+	if err != nil {
+		return nil, err
+	}
+	var numKey *bip32.ExtendedKey
+	if numKey, err = chainKey.Child(num); err != nil {
+		return nil, err
+	}
+	return bitcoin.GetPrivateKeyFromHDKey(numKey)
+}
+
+// DeriveDestinationLockingScript will derive the locking script (P2PKH, hex) of the destination at
+// chain/num from the xPub (or the xPriv), as the bux server derives it
+func DeriveDestinationLockingScript(hdKey *bip32.ExtendedKey, chain, num uint32) (string, error) {
+	publicKey, err := DerivePublicKey(hdKey, chain, num)
+	if err != nil {
+		return "", err
+	}
+	var script *bscript.Script
+	if script, err = bscript.NewP2PKHFromPubKeyEC(publicKey); err != nil {
+		return "", err
+	}
+	return script.String(), nil
+}
+
+// DeriveDestinationAddress will derive the address of the destination at chain/num from the xPub (or
+// the xPriv), as the bux server derives it
+func DeriveDestinationAddress(hdKey *bip32.ExtendedKey, chain, num uint32) (string, error) {
+	publicKey, err := DerivePublicKey(hdKey, chain, num)
+	if err != nil {
+		return "", err
+	}
+	var address *bscript.Address
+	if address, err = bscript.NewAddressFromPublicKey(publicKey, true); err != nil {
+		return "", err
+	}
+	return address.AddressString, nil
+}
+
+// VerifyDestination will return ErrDestinationMismatch if the destination (ex: provided by the server)
+// was not derived from the xPub (or the xPriv) at its chain/num: its locking script, address, ID and
+// xPub ID must all match
+func VerifyDestination(hdKey *bip32.ExtendedKey, destination *bux.Destination) error {
+	if hdKey == nil {
+		return utils.ErrHDKeyNil
+	}
+	if destination == nil {
+		return ErrDestinationMismatch
+	}
+
+	lockingScript, err := DeriveDestinationLockingScript(hdKey, destination.Chain, destination.Num)
+	if err != nil {
+		return err
+	}
+	var address string
+	if address, err = DeriveDestinationAddress(hdKey, destination.Chain, destination.Num); err != nil {
+		return err
+	}
+	xPub, err := hdKey.Neuter()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case destination.LockingScript != lockingScript,
+		destination.Address != "" && destination.Address != address,
+		destination.ID != "" && destination.ID != Hash(lockingScript),
+		destination.XpubID != "" && destination.XpubID != Hash(xPub.String()):
+		return ErrDestinationMismatch
+	}
+	return nil
+}
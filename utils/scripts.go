@@ -54,7 +54,7 @@ func GetUnlockingScript(tx *bt.Tx, inputIndex uint32, privateKey *bec.PrivateKey
 	}
 
 	var sig *bec.Signature
-	sig, err = privateKey.Sign(bt.ReverseBytes(sh))
+	sig, err = privateKey.Sign(sh)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUnlockingScript will test the signatures against known signature hashes (SIGHASH_ALL | FORKID)
+func TestGetUnlockingScript(t *testing.T) {
+	const (
+		oneInputTx  = "010000000193a35408b6068499e0d5abd799d3e827d9bfe70c9b75ebe209c91d25072326510000000000ffffffff02404b4c00000000001976a91404ff367be719efa79d76e4416ffb072cd53b208888acde94a905000000001976a91404d03f746652cfcb6cb55119ab473a045137d26588ac00000000"
+		twoInputsTx = "01000000027e2705da59f7112c7337d79840b56fff582b8f3a0e9df8eb19e282377bebb1bc0100000000ffffffffdebe6fe5ad8e9220a10fcf6340f7fca660d87aeedf0f74a142fba6de1f68d8490000000000ffffffff0300e1f505000000001976a9142987362cf0d21193ce7e7055824baac1ee245d0d88ac00e1f505000000001976a9143ca26faa390248b7a7ac45be53b0e4004ad7952688ac34657fe2000000001976a914eb0bd5edba389198e73f8efabddfc61666969ff788ac00000000"
+	)
+	vectors := []struct {
+		name             string
+		tx               string
+		index            uint32
+		previousSatoshis uint64
+		previousScript   string
+		sigHash          string
+	}{
+		{
+			"1 input 2 outputs", oneInputTx, 0, 100000000,
+			"76a914c0a3c167a28cabb9fbb495affa0761e6e74ac60d88ac",
+			"be9a42ef2e2dd7ef02cd631290667292cbbc5018f4e3f6843a8f4c302a2111b1",
+		},
+		{
+			"2 inputs 3 outputs, index 0", twoInputsTx, 0, 2000000000,
+			"76a914eb0bd5edba389198e73f8efabddfc61666969ff788ac",
+			"8b15eecfb6d5e727485e19797b5d1829e0630e8b43c806707685238e28a3194c",
+		},
+		{
+			"2 inputs 3 outputs, index 1", twoInputsTx, 1, 2000000000,
+			"76a914eb0bd5edba389198e73f8efabddfc61666969ff788ac",
+			"7b72c355a2714a5039d97fbd5eee792099b0eab4bf07d2e5bfcfc3309f81badb",
+		},
+	}
+
+	privateKey, err := bec.NewPrivateKey(bec.S256())
+	require.NoError(t, err)
+
+	for _, vector := range vectors {
+		t.Run(vector.name, func(t *testing.T) {
+			tx, err := bt.NewTxFromString(vector.tx)
+			require.NoError(t, err)
+			tx.Inputs[vector.index].PreviousTxSatoshis = vector.previousSatoshis
+			tx.Inputs[vector.index].PreviousTxScript, err = bscript.NewFromHexString(vector.previousScript)
+			require.NoError(t, err)
+
+			script, err := GetUnlockingScript(tx, vector.index, privateKey)
+			require.NoError(t, err)
+			parts, err := bscript.DecodeParts(*script)
+			require.NoError(t, err)
+			require.Len(t, parts, 2)
+			assert.Equal(t, byte(sighash.AllForkID), parts[0][len(parts[0])-1])
+			assert.Equal(t, privateKey.PubKey().SerialiseCompressed(), parts[1])
+
+			// the signature is over the signature hash as computed, not byte-reversed
+			signature, err := bec.ParseDERSignature(parts[0][:len(parts[0])-1], bec.S256())
+			require.NoError(t, err)
+			sigHash, err := hex.DecodeString(vector.sigHash)
+			require.NoError(t, err)
+			assert.True(t, signature.Verify(sigHash, privateKey.PubKey()))
+			assert.False(t, signature.Verify(bt.ReverseBytes(sigHash), privateKey.PubKey()))
+		})
+	}
+
+	t.Run("previous satoshis", func(t *testing.T) {
+		// the signature hash commits to the satoshis of the previous output
+		tx, err := bt.NewTxFromString(oneInputTx)
+		require.NoError(t, err)
+		tx.Inputs[0].PreviousTxScript, err = bscript.NewFromHexString(vectors[0].previousScript)
+		require.NoError(t, err)
+
+		script, err := GetUnlockingScript(tx, 0, privateKey)
+		require.NoError(t, err)
+		parts, err := bscript.DecodeParts(*script)
+		require.NoError(t, err)
+		signature, err := bec.ParseDERSignature(parts[0][:len(parts[0])-1], bec.S256())
+		require.NoError(t, err)
+		sigHash, err := hex.DecodeString(vectors[0].sigHash)
+		require.NoError(t, err)
+		assert.False(t, signature.Verify(sigHash, privateKey.PubKey()))
+	})
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bitcoinschema/go-bitcoin/v2"
+)
+
+var (
+	// paymailAliasRegExp matches the alias of a paymail address (the local part of an email address)
+	paymailAliasRegExp = regexp.MustCompile(`^[a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+(\.[a-zA-Z0-9!#$%&'*+/=?^_{|}~-]+)*$`)
+
+	// paymailDomainRegExp matches the domain of a paymail address (at least a dot, no port)
+	paymailDomainRegExp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+)
+
+// IsValidAddress will return whether the address is a valid Bitcoin address (P2PKH, mainnet), with a
+// valid checksum
+func IsValidAddress(address string) bool {
+	valid, err := bitcoin.ValidA58([]byte(address))
+	return err == nil && valid
+}
+
+// IsValidPaymail will return whether the paymail address is valid (alias@domain.tld), the domain is not
+// resolved
+func IsValidPaymail(paymailAddress string) bool {
+	parts := strings.Split(strings.TrimSpace(paymailAddress), "@")
+	return len(parts) == 2 && len(parts[0]) <= 64 && len(parts[1]) <= 253 &&
+		paymailAliasRegExp.MatchString(parts[0]) && paymailDomainRegExp.MatchString(parts[1])
+}
+
+// IsValidXpub will return whether the raw key is a valid extended public key, an xPriv is not valid
+func IsValidXpub(rawKey string) bool {
+	key, err := ValidateXPub(rawKey)
+	return err == nil && !key.IsPrivate()
+}
+
+// IsHandle will return whether the recipient is a handle ($handcash or 1relayx), the server converts
+// the handles to paymail addresses
+func IsHandle(recipient string) bool {
+	return len(recipient) > 1 && !strings.Contains(recipient, "@") &&
+		(recipient[0] == '$' || recipient[0] == '1' && len(recipient) < 25)
+}
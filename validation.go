@@ -0,0 +1,143 @@
+package buxclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/amounts"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/pkg/errors"
+)
+
+// maxSatoshis is the maximum amount of an output (21 million coins)
+const maxSatoshis = amounts.MaxSatoshis
+
+// ErrInvalidInput is when an input is rejected by the client, before it is sent to the server
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrTxIDMismatch is when the server recorded a transaction with another ID than the ID of the hex
+var ErrTxIDMismatch = errors.New("the recorded transaction does not match the transaction hex")
+
+// InvalidInputError is returned (without calling the server) when an input is invalid, it matches
+// ErrInvalidInput with errors.Is
+type InvalidInputError struct {
+	Field  string // ex: "recipients[2].satoshis"
+	Reason string
+}
+
+// Error will return the error message, with the field and the reason
+func (e *InvalidInputError) Error() string {
+	return ErrInvalidInput.Error() + ": " + e.Field + ": " + e.Reason
+}
+
+// Is will return whether the target is ErrInvalidInput
+func (e *InvalidInputError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// orderByField matches the fields the listings can be sorted by (ex: created_at)
+var orderByField = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateQueryParams will check the paging and sorting parameters of a listing (nil for the defaults)
+func validateQueryParams(queryParams *transports.QueryParams) error {
+	switch {
+	case queryParams == nil:
+		return nil
+	case queryParams.Page < 0:
+		return &InvalidInputError{Field: "params.page", Reason: "is negative"}
+	case queryParams.PageSize < 0:
+		return &InvalidInputError{Field: "params.page_size", Reason: "is negative"}
+	case queryParams.OrderByField != "" && !orderByField.MatchString(queryParams.OrderByField):
+		return &InvalidInputError{Field: "params.order_by_field", Reason: "is not a field name"}
+	case queryParams.SortDirection != "" && queryParams.SortDirection != transports.SortAscending &&
+		queryParams.SortDirection != transports.SortDescending:
+		return &InvalidInputError{Field: "params.sort_direction", Reason: "is not asc or desc"}
+	}
+	return nil
+}
+
+// validateDestinationCount will check that the count of new destinations is between 0 and MaxNewDestinations
+func validateDestinationCount(count int) error {
+	if count < 0 {
+		return &InvalidInputError{Field: "count", Reason: "is negative"}
+	} else if count > MaxNewDestinations {
+		return &InvalidInputError{Field: "count", Reason: fmt.Sprintf("is more than %d", MaxNewDestinations)}
+	}
+	return nil
+}
+
+// validateXPub will check that the raw xPub is a valid extended public key
+func validateXPub(rawXPub string) error {
+	key, err := bip32.NewKeyFromString(rawXPub)
+	if err != nil {
+		return &InvalidInputError{Field: "xpub", Reason: err.Error()}
+	}
+	if key.IsPrivate() {
+		return &InvalidInputError{Field: "xpub", Reason: "is a private key, use the public key (xpub)"}
+	}
+	return nil
+}
+
+// parseTransactionHex will decode the hex of a transaction
+func parseTransactionHex(field, hex string) (*bt.Tx, error) {
+	if hex == "" {
+		return nil, &InvalidInputError{Field: field, Reason: "is empty"}
+	}
+	tx, err := bt.NewTxFromString(hex)
+	if err != nil {
+		return nil, &InvalidInputError{Field: field, Reason: "is not a transaction: " + err.Error()}
+	}
+	return tx, nil
+}
+
+// validateTxID will check that the transaction ID is a 32 bytes hash in hex
+func validateTxID(field, txID string) error {
+	if decoded, err := hex.DecodeString(txID); err != nil || len(decoded) != 32 {
+		return &InvalidInputError{Field: field, Reason: "is not a transaction ID"}
+	}
+	return nil
+}
+
+// checkRecordedTxID will return ErrTxIDMismatch if the server recorded another transaction
+func checkRecordedTxID(tx *bt.Tx, transaction *bux.Transaction) error {
+	if transaction != nil && transaction.ID != "" && transaction.ID != tx.TxID() {
+		return errors.Wrapf(ErrTxIDMismatch, "recorded %s, expected %s", transaction.ID, tx.TxID())
+	}
+	return nil
+}
+
+// validateRecipients will check the destinations (address, paymail, handle or locking script) and the
+// amounts of the recipients, OP_RETURN recipients have no destination and can have no amount
+func validateRecipients(recipients []*transports.Recipients) error {
+	for index, recipient := range recipients {
+		field := fmt.Sprintf("recipients[%d]", index)
+		switch {
+		case recipient == nil:
+			return &InvalidInputError{Field: field, Reason: "is nil"}
+		case recipient.Script != "":
+			if err := validateScriptRecipient(field, recipient); err != nil {
+				return err
+			}
+		case recipient.OpReturn != nil:
+		case recipient.To == "":
+			return &InvalidInputError{Field: field + ".to", Reason: "is empty"}
+		case !utils.IsValidAddress(recipient.To) && !utils.IsValidPaymail(recipient.To) &&
+			!utils.IsHandle(recipient.To):
+			return &InvalidInputError{Field: field + ".to", Reason: "is not an address, a paymail or a handle"}
+		case recipient.Satoshis == 0:
+			return &InvalidInputError{Field: field + ".satoshis", Reason: "must be greater than 0"}
+		}
+		if recipient.Satoshis > maxSatoshis {
+			return &InvalidInputError{
+				Field:  field + ".satoshis",
+				Reason: fmt.Sprintf("%d exceeds the maximum of %d", recipient.Satoshis, uint64(maxSatoshis)),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package buxclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/BuxOrg/go-buxclient/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidation will test that the invalid inputs are rejected before sending any request
+func TestValidation(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions/record", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"id":"another-transaction"}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client, err := New(
+		WithXPriv(xPrivString),
+		WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("xpub", func(t *testing.T) {
+		requests = 0
+		var inputErr *InvalidInputError
+		require.ErrorAs(t, client.RegisterXpub(ctx, "xpub-invalid", nil), &inputErr)
+		assert.Equal(t, "xpub", inputErr.Field)
+		assert.ErrorIs(t, client.RegisterXpub(ctx, xPrivString, nil), ErrInvalidInput)
+		assert.Equal(t, 0, requests)
+	})
+
+	t.Run("hex", func(t *testing.T) {
+		requests = 0
+		_, err = client.RecordTransaction(ctx, "", "", nil)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		_, err = client.RecordTransaction(ctx, "not-a-transaction", "", nil)
+		var inputErr *InvalidInputError
+		require.ErrorAs(t, err, &inputErr)
+		assert.Equal(t, "hex", inputErr.Field)
+		assert.Equal(t, 0, requests)
+
+		// the server recorded another transaction
+		_, err = client.RecordTransaction(ctx, txHex, "", nil)
+		assert.ErrorIs(t, err, ErrTxIDMismatch)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("recipients", func(t *testing.T) {
+		requests = 0
+		for field, recipient := range map[string]*transports.Recipients{
+			"recipients[1]":          nil,
+			"recipients[1].to":       {Satoshis: 1000},
+			"recipients[1].satoshis": {To: testAddress},
+		} {
+			_, err = client.DraftToRecipients(ctx, []*transports.Recipients{
+				{To: testAddress, Satoshis: 1000}, recipient,
+			}, nil)
+			var inputErr *InvalidInputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, field, inputErr.Field)
+		}
+
+		// invalid checksum of the address
+		_, err = client.DraftToRecipients(ctx, []*transports.Recipients{
+			{To: "1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGu", Satoshis: 1000},
+		}, nil)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+
+		_, err = client.DraftToRecipients(ctx, []*transports.Recipients{
+			{To: testAddress, Satoshis: maxSatoshis + 1},
+		}, nil)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.Equal(t, 0, requests)
+	})
+
+	t.Run("query params", func(t *testing.T) {
+		requests = 0
+		for field, queryParams := range map[string]*transports.QueryParams{
+			"params.page":           {Page: -1},
+			"params.page_size":      {PageSize: -1},
+			"params.order_by_field": {OrderByField: "created_at; drop"},
+			"params.sort_direction": {OrderByField: "created_at", SortDirection: "newest"},
+		} {
+			_, err = client.SearchTransactions(ctx, nil, nil, queryParams)
+			var inputErr *InvalidInputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, field, inputErr.Field)
+		}
+		assert.Equal(t, 0, requests)
+
+		_, err = client.GetUtxos(ctx, nil, nil, transports.NewestFirst(1, 10))
+		assert.NotErrorIs(t, err, ErrInvalidInput)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("destination count", func(t *testing.T) {
+		requests = 0
+		for _, count := range []int{-1, MaxNewDestinations + 1} {
+			_, err = client.NewDestinations(ctx, count, nil)
+			var inputErr *InvalidInputError
+			require.ErrorAs(t, err, &inputErr)
+			assert.Equal(t, "count", inputErr.Field)
+		}
+		assert.Equal(t, 0, requests)
+	})
+}
+
+// TestValidators will test the validators of the addresses, the paymail addresses and the xPubs
+func TestValidators(t *testing.T) {
+	assert.True(t, utils.IsValidAddress(testAddress))
+	for _, address := range []string{"", "invalid-address", "1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGu", "bux@bux.org"} {
+		assert.False(t, utils.IsValidAddress(address), address)
+	}
+
+	for _, paymailAddress := range []string{"bux@bux.org", " Alice.Smith@Example.co.uk "} {
+		assert.True(t, utils.IsValidPaymail(paymailAddress), paymailAddress)
+	}
+	for _, paymailAddress := range []string{"", "alice", "@bux.org", "alice@bux", "a@b@bux.org", "al ice@bux.org",
+		"alice@bux_org.com", testAddress} {
+		assert.False(t, utils.IsValidPaymail(paymailAddress), paymailAddress)
+	}
+
+	assert.True(t, utils.IsValidXpub(xPubString))
+	for _, rawKey := range []string{"", "xpub-invalid", xPrivString} {
+		assert.False(t, utils.IsValidXpub(rawKey), rawKey)
+	}
+
+	// the handles are converted to paymail addresses by the server
+	assert.True(t, utils.IsHandle("$alice"))
+	assert.True(t, utils.IsHandle("1alice"))
+	assert.False(t, utils.IsHandle(testAddress))
+}
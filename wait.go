@@ -0,0 +1,137 @@
+package buxclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/pkg/errors"
+)
+
+// ErrTransactionRejected is when a waited transaction is rejected by the network (ex: double spend)
+var ErrTransactionRejected = errors.New("transaction was rejected")
+
+// TransactionRejectedError is returned when a waited transaction is rejected by the network
+type TransactionRejectedError struct {
+	Status *transports.TransactionStatus
+}
+
+// Error will return the error message
+func (e *TransactionRejectedError) Error() string {
+	if e.Status.DoubleSpend {
+		return "transaction " + e.Status.TxID + " was rejected: double spend"
+	}
+	return "transaction " + e.Status.TxID + " was rejected: " + e.Status.RejectedReason
+}
+
+// Is will match ErrTransactionRejected
+func (e *TransactionRejectedError) Is(target error) bool {
+	return target == ErrTransactionRejected
+}
+
+// WaitOptions are the options of WaitForTransaction
+type WaitOptions struct {
+	Confirmations   uint64        // Confirmations to wait for, 0 returns as soon as the transaction is broadcast
+	PollInterval    time.Duration // Wait between polls, doubled after each poll (the default when zero)
+	MaxPollInterval time.Duration // Maximum wait between polls
+}
+
+// DefaultWaitOptions will return the default options of WaitForTransaction (broadcast)
+func DefaultWaitOptions() *WaitOptions {
+	return &WaitOptions{
+		PollInterval:    2 * time.Second,
+		MaxPollInterval: time.Minute,
+	}
+}
+
+// WaitForTransaction will wait until the transaction is broadcast (seen on the network), or has the
+// confirmations of the options, polling its status with backoff. The transaction subscription wakes
+// up the wait early when the server supports it. Transient errors are retried until the context is
+// done, a rejected transaction returns a TransactionRejectedError.
+func (b *BuxClient) WaitForTransaction(ctx context.Context, txID string,
+	opts *WaitOptions) (*transports.TransactionStatus, error) {
+
+	if opts == nil {
+		opts = DefaultWaitOptions()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the subscription is only a hint, the status is always polled
+	var updates <-chan *bux.Transaction
+	if b.FeatureEnabled(FeatureSubscriptions) {
+		updates, _ = b.SubscribeTransactions(ctx, map[string]interface{}{"id": txID})
+	}
+
+	// a zero interval would poll the server without waiting
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultWaitOptions().PollInterval
+	}
+	if opts.MaxPollInterval > 0 && interval > opts.MaxPollInterval {
+		interval = opts.MaxPollInterval
+	}
+	var lastErr error
+	for {
+		status, err := b.waitedStatus(ctx, txID, opts.Confirmations)
+		switch {
+		case err == nil && status != nil:
+			return status, nil
+		case errors.Is(err, ErrTransactionRejected):
+			return nil, err
+		case err != nil:
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, errors.Wrapf(ctx.Err(), "last error: %s", lastErr.Error())
+			}
+			return nil, ctx.Err()
+		case _, ok := <-updates:
+			if !ok {
+				updates = nil
+			}
+		case <-b.scheduler.After(interval):
+			interval *= 2
+			if opts.MaxPollInterval > 0 && interval > opts.MaxPollInterval {
+				interval = opts.MaxPollInterval
+			}
+		}
+	}
+}
+
+// waitedStatus will return the status of the transaction if it reached the confirmations, nil otherwise
+func (b *BuxClient) waitedStatus(ctx context.Context, txID string,
+	confirmations uint64) (*transports.TransactionStatus, error) {
+
+	status, err := b.GetTransactionStatus(ctx, txID)
+	if err != nil || status == nil {
+		return nil, err
+	}
+	if status.Rejected() {
+		return nil, &TransactionRejectedError{Status: status}
+	}
+
+	switch {
+	case confirmations == 0:
+		if status.SeenOnNetwork || status.Mined {
+			return status, nil
+		}
+	case status.Mined:
+		var chainHeight uint64
+		if confirmations > 1 {
+			if chainHeight, err = b.getChainHeight(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if confirmations == 1 || chainHeight >= status.BlockHeight &&
+			chainHeight-status.BlockHeight+1 >= confirmations {
+			return status, nil
+		}
+	}
+	return nil, nil
+}
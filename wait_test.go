@@ -0,0 +1,142 @@
+package buxclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/go-buxclient/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWaitForTransaction will test the method WaitForTransaction()
+func TestWaitForTransaction(t *testing.T) {
+	const (
+		unseenJSON   = `{"tx_id":"` + txID + `","seen_on_network":false,"mined":false}`
+		seenJSON     = `{"tx_id":"` + txID + `","seen_on_network":true,"mined":false}`
+		minedJSON    = `{"tx_id":"` + txID + `","seen_on_network":true,"mined":true,"block_height":100}`
+		rejectedJSON = `{"tx_id":"` + txID + `","seen_on_network":false,"double_spend":true}`
+	)
+
+	// newClient will return a client polling the statuses in order (the last one is repeated)
+	newClient := func(t *testing.T, virtual *scheduler.Virtual, statuses ...string) (*BuxClient, func() int) {
+		var mu sync.Mutex
+		var polls int
+		mux := http.NewServeMux()
+		mux.HandleFunc("/transaction/status", func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			status := statuses[polls]
+			if polls < len(statuses)-1 {
+				polls++
+			}
+			if status == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, status)
+		})
+
+		client, err := New(
+			WithXPriv(xPrivString),
+			WithHTTPClient(strings.TrimSuffix(serverURL, "/"), &http.Client{Transport: localRoundTripper{handler: mux}}),
+			WithMinConfirmations(0, func(ctx context.Context) (uint64, error) { return 102, nil }),
+			WithScheduler(virtual),
+		)
+		require.NoError(t, err)
+		return client, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return polls
+		}
+	}
+
+	t.Run("broadcast", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now())
+		client, polls := newClient(t, virtual, unseenJSON, unseenJSON, seenJSON)
+
+		done := make(chan error)
+		go func() {
+			status, err := client.WaitForTransaction(context.Background(), txID, nil)
+			if err == nil {
+				assert.True(t, status.SeenOnNetwork)
+			}
+			done <- err
+		}()
+
+		// the poll interval is doubled after every poll
+		virtual.BlockUntil(1)
+		virtual.Advance(2 * time.Second)
+		require.Eventually(t, func() bool { return polls() == 2 }, time.Second, time.Millisecond)
+		virtual.BlockUntil(1)
+		virtual.Advance(2 * time.Second)
+		assert.Equal(t, 2, polls())
+		virtual.Advance(2 * time.Second)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("zero poll interval", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now())
+		client, polls := newClient(t, virtual, unseenJSON, seenJSON)
+
+		done := make(chan error)
+		go func() {
+			_, err := client.WaitForTransaction(context.Background(), txID, &WaitOptions{})
+			done <- err
+		}()
+
+		// the default interval is waited between the polls
+		virtual.BlockUntil(1)
+		assert.Equal(t, 1, polls())
+		virtual.Advance(DefaultWaitOptions().PollInterval)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("confirmations", func(t *testing.T) {
+		client, _ := newClient(t, scheduler.NewVirtual(time.Now()), minedJSON)
+
+		status, err := client.WaitForTransaction(context.Background(), txID, &WaitOptions{Confirmations: 3})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(100), status.BlockHeight)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = client.WaitForTransaction(ctx, txID, &WaitOptions{Confirmations: 4})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		client, _ := newClient(t, scheduler.NewVirtual(time.Now()), rejectedJSON)
+
+		_, err := client.WaitForTransaction(context.Background(), txID, nil)
+		assert.ErrorIs(t, err, ErrTransactionRejected)
+		assert.Contains(t, err.Error(), "double spend")
+	})
+
+	t.Run("transient errors", func(t *testing.T) {
+		virtual := scheduler.NewVirtual(time.Now())
+		client, _ := newClient(t, virtual, "", seenJSON)
+
+		done := make(chan error)
+		go func() {
+			_, err := client.WaitForTransaction(context.Background(), txID, nil)
+			done <- err
+		}()
+		virtual.BlockUntil(1)
+		virtual.Advance(2 * time.Second)
+		require.NoError(t, <-done)
+
+		// the context error keeps the last error
+		client, _ = newClient(t, virtual, "")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.WaitForTransaction(ctx, txID, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Contains(t, err.Error(), "last error")
+	})
+}
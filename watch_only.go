@@ -0,0 +1,71 @@
+package buxclient
+
+import (
+	"github.com/BuxOrg/bux"
+	"github.com/pkg/errors"
+)
+
+// Operations that need to sign (requests the server only accepts signed, or signing transactions)
+const (
+	OperationDeleteWebhook       = "DeleteWebhook"
+	OperationDraftToRecipients   = "DraftToRecipients"
+	OperationDraftTransaction    = "DraftTransaction"
+	OperationFinalizeTransaction = "FinalizeTransaction"
+	OperationGetDestination      = "GetDestination"
+	OperationNewDestinations     = "NewDestinations"
+	OperationRegisterWebhook     = "RegisterWebhook"
+	OperationRotateXPriv         = "RotateXPriv"
+)
+
+// ErrWatchOnly is when an operation that needs to sign is called on a client that can not sign
+var ErrWatchOnly = errors.New("the client is watch-only, an xPriv must be set to be able to sign")
+
+// WatchOnlyError is returned (upfront, without calling the server) when an operation that needs to
+// sign is called on a client that can not sign it, it matches ErrWatchOnly and bux.ErrMissingXPriv
+// with errors.Is
+type WatchOnlyError struct {
+	Operation string
+}
+
+// Error will return the error message, with the operation
+func (e *WatchOnlyError) Error() string {
+	return "the client is watch-only, an xPriv must be set to be able to call " + e.Operation
+}
+
+// Is will return whether the target is ErrWatchOnly or bux.ErrMissingXPriv
+func (e *WatchOnlyError) Is(target error) bool {
+	return target == ErrWatchOnly || target == bux.ErrMissingXPriv
+}
+
+// signingOperations are the operations that need to sign, true when only the xPriv can sign them
+// (the other requests can also be signed by an access key)
+var signingOperations = map[string]bool{
+	OperationDeleteWebhook:       false,
+	OperationDraftToRecipients:   false,
+	OperationDraftTransaction:    false,
+	OperationFinalizeTransaction: true,
+	OperationGetDestination:      false,
+	OperationNewDestinations:     false,
+	OperationRegisterWebhook:     false,
+	OperationRotateXPriv:         true,
+}
+
+// IsWatchOnly will return whether the client only has the xPub: it can read the data of the xPub, but
+// can not draft, sign or send transactions
+func (b *BuxClient) IsWatchOnly() bool {
+	xPriv, _, accessKey := b.keys()
+	return xPriv == nil && accessKey == nil
+}
+
+// checkSigning will return a WatchOnlyError for the operation if the client can not sign it
+func (b *BuxClient) checkSigning(operation string) error {
+	xPrivOnly, ok := signingOperations[operation]
+	xPriv, _, accessKey := b.keys()
+	switch {
+	case !ok, xPriv != nil:
+		return nil
+	case accessKey != nil && !xPrivOnly:
+		return nil
+	}
+	return &WatchOnlyError{Operation: operation}
+}
@@ -0,0 +1,78 @@
+package buxclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BuxOrg/bux"
+	"github.com/BuxOrg/go-buxclient/transports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchOnly will test the clients with only an xPub
+func TestWatchOnly(t *testing.T) {
+	var requests int
+	var authXPub string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		authXPub = req.Header.Get("auth_xpub")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, transactionsJSON)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		authXPub = req.Header.Get("auth_xpub")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data":{"transactions":`+transactionsJSON+`}}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	transportOptions := map[string]ClientOps{
+		"http":    WithHTTPClient(strings.TrimSuffix(serverURL, "/"), httpClient),
+		"graphql": WithGraphQLClient(serverURL+"graphql", httpClient),
+	}
+	for name, transportOption := range transportOptions {
+		t.Run(name, func(t *testing.T) {
+			// the requests can not be signed, even when signing is enabled
+			for _, signRequest := range []bool{false, true} {
+				requests, authXPub = 0, ""
+				client, err := New(WithXPub(xPubString), transportOption, WithSignRequest(signRequest))
+				require.NoError(t, err)
+				assert.True(t, client.IsWatchOnly())
+
+				transactions, err := client.GetTransactions(context.Background(), nil, nil, nil)
+				require.NoError(t, err)
+				assert.Len(t, transactions, 2)
+				assert.Equal(t, xPubString, authXPub)
+
+				_, err = client.SendToRecipients(context.Background(), []*transports.Recipients{{
+					To:       "bux@bux.org",
+					Satoshis: 1000,
+				}}, nil)
+				assert.ErrorIs(t, err, ErrWatchOnly)
+				_, err = client.GetDestination(context.Background(), nil)
+				assert.ErrorIs(t, err, ErrWatchOnly)
+				_, err = client.FinalizeTransaction(&bux.DraftTransaction{})
+				assert.ErrorIs(t, err, ErrWatchOnly)
+				assert.Equal(t, 1, requests)
+			}
+		})
+	}
+
+	t.Run("access key", func(t *testing.T) {
+		client, err := New(WithAccessKey(accessKeyString), WithHTTPClient(serverURL, httpClient))
+		require.NoError(t, err)
+		assert.False(t, client.IsWatchOnly())
+
+		// the requests are signed by the access key, the transactions can only be signed by the xPriv
+		_, err = client.FinalizeTransaction(&bux.DraftTransaction{})
+		var watchOnlyErr *WatchOnlyError
+		require.ErrorAs(t, err, &watchOnlyErr)
+		assert.Equal(t, OperationFinalizeTransaction, watchOnlyErr.Operation)
+		assert.ErrorIs(t, err, bux.ErrMissingXPriv)
+	})
+}